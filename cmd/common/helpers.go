@@ -4,8 +4,8 @@ import (
 	"os"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,7 +31,7 @@ func CreateResourceLock(cb *clients.Builder, componentNamespace, componentName s
 
 	id, err := os.Hostname()
 	if err != nil {
-		glog.Fatalf("error creating lock: %v", err)
+		logging.Fatalf("error creating lock: %v", err)
 	}
 
 	// add a uniquifier so that two processes on the same host don't accidentally both become active
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// RunLeaderElection runs run while holding lock, until SIGTERM or SIGINT.
+// The context passed to run is cancelled on the signal, and the lock is only
+// released once run returns, so that the next leader doesn't start before
+// this one is done with the work it had in flight.
+func RunLeaderElection(lock resourcelock.Interface, run func(ctx context.Context), onNewLeader func(identity string)) {
+	leCtx, leCancel := context.WithCancel(context.Background())
+	runCtx, runCancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		glog.Infof("Received %v, shutting down", sig)
+		runCancel()
+		select {
+		case <-started:
+			// released once run returns
+		default:
+			leCancel()
+		}
+	}()
+
+	leaderelection.RunOrDie(leCtx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   LeaseDuration,
+		RenewDeadline:   RenewDeadline,
+		RetryPeriod:     RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				close(started)
+				defer leCancel()
+
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+				go func() {
+					select {
+					case <-runCtx.Done():
+						cancel()
+					case <-ctx.Done():
+					}
+				}()
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				if leCtx.Err() != nil {
+					glog.Info("Released the leader lock")
+					return
+				}
+				logging.Fatalf("leaderelection lost")
+			},
+			OnNewLeader: onNewLeader,
+		},
+	})
+}
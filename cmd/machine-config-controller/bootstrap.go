@@ -4,6 +4,7 @@ import (
 	"flag"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/machine-config-operator/pkg/controller/bootstrap"
@@ -14,7 +15,7 @@ var (
 	bootstrapCmd = &cobra.Command{
 		Use:   "bootstrap",
 		Short: "Starts Machine Config Controller in bootstrap mode",
-		Long:  "",
+		Long:  "Renders the MachineConfigPools of --manifest-dir and writes them, with their rendered MachineConfigs, to --dest-dir. The configs are rendered by the same code as in the cluster, which creates the same rendered MachineConfigs from the same manifests.",
 		Run:   runbootstrapCmd,
 	}
 
@@ -40,10 +41,10 @@ func runbootstrapCmd(cmd *cobra.Command, args []string) {
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
 
 	if bootstrapOpts.manifestsDir == "" || bootstrapOpts.destinationDir == "" {
-		glog.Fatalf("--dest-dir or --manifest-dir not set")
+		logging.Fatalf("--dest-dir or --manifest-dir not set")
 	}
 
 	if err := bootstrap.New(rootOpts.templates, bootstrapOpts.manifestsDir, bootstrapOpts.pullSecretFile).Run(bootstrapOpts.destinationDir); err != nil {
-		glog.Fatalf("error running MCC[BOOTSTRAP]: %v", err)
+		logging.Fatalf("error running MCC[BOOTSTRAP]: %v", err)
 	}
 }
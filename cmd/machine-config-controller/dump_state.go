@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/internal/logging"
+	"github.com/openshift/machine-config-operator/pkg/controller/dump"
+)
+
+var (
+	dumpStateCmd = &cobra.Command{
+		Use:   "dump-state",
+		Short: "Writes a snapshot of the pools, rendered configs, nodes and recent errors to a directory",
+		Long:  "",
+		Run:   runDumpStateCmd,
+	}
+
+	dumpStateOpts struct {
+		kubeconfig string
+		destDir    string
+		since      time.Duration
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(dumpStateCmd)
+	dumpStateCmd.PersistentFlags().StringVar(&dumpStateOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster")
+	dumpStateCmd.PersistentFlags().StringVar(&dumpStateOpts.destDir, "dest-dir", "", "The directory the snapshot is written to.")
+	dumpStateCmd.PersistentFlags().DurationVar(&dumpStateOpts.since, "since", time.Hour, "How far back the warning events are collected.")
+}
+
+func runDumpStateCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if dumpStateOpts.destDir == "" {
+		logging.Fatalf("--dest-dir not set")
+	}
+
+	cb, err := clients.NewBuilder(dumpStateOpts.kubeconfig)
+	if err != nil {
+		logging.Fatalf("error creating clients: %v", err)
+	}
+	if err := dump.Dump(cb.KubeClientOrDie(componentName), cb.MachineConfigClientOrDie(componentName), dumpStateOpts.destDir, dumpStateOpts.since, time.Now()); err != nil {
+		logging.Fatalf("error dumping the MCO state: %v", err)
+	}
+	glog.Infof("Wrote the MCO state to %s", dumpStateOpts.destDir)
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"flag"
 
-	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +16,9 @@ var (
 		Use:   componentName,
 		Short: "Run Machine Config Controller",
 		Long:  "",
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return logging.Setup()
+		},
 	}
 
 	rootOpts struct {
@@ -25,11 +28,12 @@ var (
 
 func init() {
 	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	logging.AddFlags(rootCmd.PersistentFlags())
 	startCmd.PersistentFlags().StringVar(&rootOpts.templates, "templates", "/etc/mcc/templates", "Path to the template files used for creating MachineConfig objects")
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		glog.Exitf("Error executing MCC: %v", err)
+		logging.Exitf("Error executing MCC: %v", err)
 	}
 }
@@ -3,21 +3,34 @@ package main
 import (
 	"context"
 	"flag"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/cmd/common"
 	"github.com/openshift/machine-config-operator/internal/clients"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	acceleratorconfig "github.com/openshift/machine-config-operator/pkg/controller/accelerator-config"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	configsnippet "github.com/openshift/machine-config-operator/pkg/controller/config-snippet"
 	containerruntimeconfig "github.com/openshift/machine-config-operator/pkg/controller/container-runtime-config"
+	imagepolicy "github.com/openshift/machine-config-operator/pkg/controller/image-policy"
 	kubeletconfig "github.com/openshift/machine-config-operator/pkg/controller/kubelet-config"
 	"github.com/openshift/machine-config-operator/pkg/controller/node"
+	nodesshkeys "github.com/openshift/machine-config-operator/pkg/controller/node-ssh-keys"
+	nodetuningconfig "github.com/openshift/machine-config-operator/pkg/controller/node-tuning-config"
+	onpremnetworking "github.com/openshift/machine-config-operator/pkg/controller/on-prem-networking"
 	"github.com/openshift/machine-config-operator/pkg/controller/render"
 	"github.com/openshift/machine-config-operator/pkg/controller/template"
+	timesync "github.com/openshift/machine-config-operator/pkg/controller/time-sync"
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 )
 
 var (
@@ -33,18 +46,25 @@ var (
 		templates  string
 
 		resourceLockNamespace string
+		resourceLockName      string
+		promMetricsURL        string
+		shutdownDeadline      time.Duration
 	}
 )
 
 func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
-	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", metav1.NamespaceSystem, "Path to the template files used for creating MachineConfig objects")
+	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", metav1.NamespaceSystem, "Namespace of the leader election lock")
+	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockName, "resourcelock-name", componentName, "Name of the leader election lock")
+	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsURL, "metrics-url", ctrlcommon.DefaultMetricsBindAddress, "URL for prometheus metrics listener")
+	startCmd.PersistentFlags().DurationVar(&startOpts.shutdownDeadline, "shutdown-deadline", ctrlcommon.ShutdownDeadline, "How long the controllers are given to drain their queues on shutdown")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
 	flag.Set("logtostderr", "true")
 	flag.Parse()
+	ctrlcommon.ShutdownDeadline = startOpts.shutdownDeadline
 
 	// To help debugging, immediately log version
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
@@ -53,10 +73,15 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		ctrlcommon.WriteTerminationError(errors.Wrapf(err, "Creating clients"))
 	}
+	// every replica serves the metrics, so that the leader can be told from any of them
+	go ctrlcommon.StartMetricsListener(startOpts.promMetricsURL, make(chan struct{}))
+
 	run := func(ctx context.Context) {
-		ctrlctx := ctrlcommon.CreateControllerContext(cb, ctx.Done(), componentName)
+		tuning := getControllerTuning(cb)
+		ctrlctx := ctrlcommon.CreateControllerContextWithResyncPeriod(cb, ctx.Done(), componentName, ctrlcommon.GetResyncPeriod(tuning))
 
-		controllers := createControllers(ctrlctx)
+		controllers := createControllers(ctrlctx, tuning)
+		exitOnTuningChange(ctrlctx, tuning)
 
 		// Start the shared factory informers that you need to use in your controller
 		ctrlctx.InformerFactory.Start(ctrlctx.Stop)
@@ -64,46 +89,79 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 		ctrlctx.OpenShiftConfigKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.ConfigInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.OperatorInformerFactory.Start(ctrlctx.Stop)
+		ctrlctx.ConfigSnippetsKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
 
 		close(ctrlctx.InformersStarted)
 
-		for _, c := range controllers {
-			go c.Run(2, ctrlctx.Stop)
+		// wait for the controllers to drain their queues before giving up the lock
+		var wg sync.WaitGroup
+		for name, c := range controllers {
+			wg.Add(1)
+			go func(name string, c ctrlcommon.Controller) {
+				defer wg.Done()
+				c.Run(int(ctrlcommon.GetSubControllerTuning(tuning, name).Workers), ctrlctx.Stop)
+			}(name, c)
 		}
+		wg.Wait()
+	}
+
+	common.RunLeaderElection(common.CreateResourceLock(cb, startOpts.resourceLockNamespace, startOpts.resourceLockName), run, func(identity string) {
+		glog.Infof("Current leader: %s", identity)
+		ctrlcommon.SetMCCLeader(identity)
+	})
+}
 
-		select {}
+// getControllerTuning returns the tuning set on the ControllerConfig, nil when
+// there's none yet.
+func getControllerTuning(cb *clients.Builder) *mcfgv1.ControllerTuning {
+	cc, err := cb.MachineConfigClientOrDie(componentName).MachineconfigurationV1().ControllerConfigs().Get(context.TODO(), ctrlcommon.ControllerConfigName, metav1.GetOptions{})
+	if err != nil {
+		glog.Warningf("Failed to get the controller tuning, using the defaults: %v", err)
+		return nil
 	}
+	return cc.Spec.Tuning
+}
 
-	leaderelection.RunOrDie(context.TODO(), leaderelection.LeaderElectionConfig{
-		Lock:          common.CreateResourceLock(cb, startOpts.resourceLockNamespace, componentName),
-		LeaseDuration: common.LeaseDuration,
-		RenewDeadline: common.RenewDeadline,
-		RetryPeriod:   common.RetryPeriod,
-		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: run,
-			OnStoppedLeading: func() {
-				glog.Fatalf("leaderelection lost")
-			},
-		},
+// exitOnTuningChange exits once the tuning set on the ControllerConfig differs
+// from the one the controllers were started with, so that they're restarted with it.
+func exitOnTuningChange(ctx *ctrlcommon.ControllerContext, tuning *mcfgv1.ControllerTuning) {
+	check := func(obj interface{}) {
+		cc := obj.(*mcfgv1.ControllerConfig)
+		if cc.Name != ctrlcommon.ControllerConfigName || equality.Semantic.DeepEqual(cc.Spec.Tuning, tuning) {
+			return
+		}
+		glog.Infof("Controller tuning changed, exiting to restart with it")
+		os.Exit(0)
+	}
+	ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    check,
+		UpdateFunc: func(old, cur interface{}) { check(cur) },
 	})
-	panic("unreachable")
 }
 
-func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controller {
-	var controllers []ctrlcommon.Controller
+func createControllers(ctx *ctrlcommon.ControllerContext, tuning *mcfgv1.ControllerTuning) map[string]ctrlcommon.Controller {
+	rateLimiter := func(name string) workqueue.RateLimiter {
+		return ctrlcommon.NewRateLimiter(ctrlcommon.GetSubControllerTuning(tuning, name))
+	}
+
+	etcdInformer, err := ctrlcommon.GetEtcdInformer(ctx.ClientBuilder.OperatorClientOrDie("node-update-controller"), ctx.OperatorInformerFactory)
+	if err != nil {
+		glog.Warningf("Not checking the etcd quorum before updating masters: %v", err)
+	}
 
-	controllers = append(controllers,
+	return map[string]ctrlcommon.Controller{
 		// Our primary MCs come from here
-		template.New(
+		ctrlcommon.TemplateControllerName: template.New(
 			rootOpts.templates,
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.OpenShiftConfigKubeNamespacedInformerFactory.Core().V1().Secrets(),
 			ctx.ClientBuilder.KubeClientOrDie("template-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("template-controller"),
+			rateLimiter(ctrlcommon.TemplateControllerName),
 		),
 		// Add all "sub-renderers here"
-		kubeletconfig.New(
+		ctrlcommon.KubeletConfigControllerName: kubeletconfig.New(
 			rootOpts.templates,
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
@@ -111,8 +169,9 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.ConfigInformerFactory.Config().V1().FeatureGates(),
 			ctx.ClientBuilder.KubeClientOrDie("kubelet-config-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("kubelet-config-controller"),
+			rateLimiter(ctrlcommon.KubeletConfigControllerName),
 		),
-		containerruntimeconfig.New(
+		ctrlcommon.ContainerRuntimeConfigControllerName: containerruntimeconfig.New(
 			rootOpts.templates,
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
@@ -123,25 +182,85 @@ func createControllers(ctx *ctrlcommon.ControllerContext) []ctrlcommon.Controlle
 			ctx.ClientBuilder.KubeClientOrDie("container-runtime-config-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("container-runtime-config-controller"),
 			ctx.ClientBuilder.ConfigClientOrDie("container-runtime-config-controller"),
+			rateLimiter(ctrlcommon.ContainerRuntimeConfigControllerName),
+		),
+		ctrlcommon.NodeTuningConfigControllerName: nodetuningconfig.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().NodeTuningConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("node-tuning-config-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("node-tuning-config-controller"),
+			rateLimiter(ctrlcommon.NodeTuningConfigControllerName),
+		),
+		ctrlcommon.ImagePolicyControllerName: imagepolicy.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().ImagePolicies(),
+			ctx.ClientBuilder.KubeClientOrDie("image-policy-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("image-policy-controller"),
+			rateLimiter(ctrlcommon.ImagePolicyControllerName),
+		),
+		ctrlcommon.TimeSyncControllerName: timesync.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().TimeSyncs(),
+			ctx.ClientBuilder.KubeClientOrDie("time-sync-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("time-sync-controller"),
+			rateLimiter(ctrlcommon.TimeSyncControllerName),
+		),
+		ctrlcommon.OnPremNetworkingControllerName: onpremnetworking.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().OnPremNetworkings(),
+			ctx.ClientBuilder.KubeClientOrDie("on-prem-networking-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("on-prem-networking-controller"),
+			rateLimiter(ctrlcommon.OnPremNetworkingControllerName),
+		),
+		ctrlcommon.AcceleratorConfigControllerName: acceleratorconfig.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().AcceleratorConfigs(),
+			ctx.ClientBuilder.KubeClientOrDie("accelerator-config-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("accelerator-config-controller"),
+			rateLimiter(ctrlcommon.AcceleratorConfigControllerName),
+		),
+		ctrlcommon.NodeSSHKeysControllerName: nodesshkeys.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().NodeSSHKeySets(),
+			ctx.ClientBuilder.KubeClientOrDie("node-ssh-keys-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("node-ssh-keys-controller"),
+			rateLimiter(ctrlcommon.NodeSSHKeysControllerName),
+		),
+		// Snippets are read from a namespace which only exists when the admin opts in
+		ctrlcommon.ConfigSnippetControllerName: configsnippet.New(
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.ConfigSnippetsKubeNamespacedInformerFactory.Core().V1().ConfigMaps(),
+			ctx.ClientBuilder.KubeClientOrDie("config-snippet-controller"),
+			ctx.ClientBuilder.MachineConfigClientOrDie("config-snippet-controller"),
+			rateLimiter(ctrlcommon.ConfigSnippetControllerName),
 		),
 		// The renderer creates "rendered" MCs from the MC fragments generated by
 		// the above sub-controllers, which are then consumed by the node controller
-		render.New(
+		ctrlcommon.RenderControllerName: render.New(
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.ClientBuilder.KubeClientOrDie("render-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("render-controller"),
+			rateLimiter(ctrlcommon.RenderControllerName),
 		),
 		// The node controller consumes data written by the above
-		node.New(
+		ctrlcommon.NodeControllerName: node.New(
 			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigPools(),
+			ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
+			ctx.InformerFactory.Machineconfiguration().V1().ControllerConfigs(),
 			ctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctx.KubeInformerFactory.Core().V1().Pods(),
+			ctx.KubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets(),
+			etcdInformer,
 			ctx.ConfigInformerFactory.Config().V1().Schedulers(),
+			ctx.InformerFactory.Machineconfiguration().V1().NodeMachineConfigStates(),
 			ctx.ClientBuilder.KubeClientOrDie("node-update-controller"),
 			ctx.ClientBuilder.MachineConfigClientOrDie("node-update-controller"),
+			rateLimiter(ctrlcommon.NodeControllerName),
 		),
-	)
-
-	return controllers
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dumpStateCmd = &cobra.Command{
+		Use:   "dump-state",
+		Short: "Writes a snapshot of the MCO state of the node to a directory",
+		Args:  cobra.MaximumNArgs(0),
+		Run:   executeDumpState,
+	}
+
+	dumpStateOpts struct {
+		destDir   string
+		rootMount string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(dumpStateCmd)
+	dumpStateCmd.PersistentFlags().StringVar(&dumpStateOpts.destDir, "dest-dir", "", "The directory the snapshot is written to, relative to the root mount")
+	dumpStateCmd.PersistentFlags().StringVar(&dumpStateOpts.rootMount, "root-mount", "/", "Where the root filesystem of the node is mounted, e.g. /host from a debug pod")
+}
+
+func runDumpState(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if dumpStateOpts.destDir == "" {
+		return errors.New("--dest-dir not set")
+	}
+	if dumpStateOpts.rootMount != "/" {
+		if err := syscall.Chroot(dumpStateOpts.rootMount); err != nil {
+			return errors.Wrapf(err, "unable to chroot to %s", dumpStateOpts.rootMount)
+		}
+		if err := os.Chdir("/"); err != nil {
+			return err
+		}
+	}
+
+	exitCh := make(chan error)
+	defer close(exitCh)
+	dn, err := daemon.New(daemon.NewNodeUpdaterClient(), exitCh)
+	if err != nil {
+		return err
+	}
+	return dn.DumpState(dumpStateOpts.destDir)
+}
+
+func executeDumpState(cmd *cobra.Command, args []string) {
+	if err := runDumpState(cmd, args); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -5,22 +5,34 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/clients"
 	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
-var firstbootCompleteMachineconfig = &cobra.Command{
-	Use:                   "firstboot-complete-machineconfig",
-	DisableFlagsInUseLine: true,
-	Short:                 "Complete the host's initial boot into a MachineConfig",
-	Args:                  cobra.MaximumNArgs(0),
-	Run:                   executeFirstbootCompleteMachineConfig,
-}
+var (
+	firstbootCompleteMachineconfig = &cobra.Command{
+		Use:                   "firstboot-complete-machineconfig",
+		Aliases:               []string{"firstboot-complete"},
+		DisableFlagsInUseLine: true,
+		Short:                 "Complete the host's initial boot into a MachineConfig",
+		Args:                  cobra.MaximumNArgs(0),
+		Run:                   executeFirstbootCompleteMachineConfig,
+	}
+
+	firstbootOpts struct {
+		kubeconfig string
+		nodeName   string
+	}
+)
 
 // init executes upon import
 func init() {
 	rootCmd.AddCommand(firstbootCompleteMachineconfig)
+	firstbootCompleteMachineconfig.PersistentFlags().StringVar(&firstbootOpts.kubeconfig, "kubeconfig", "/var/lib/kubelet/kubeconfig", "Kubeconfig of the kubelet, used to report the progress on the node when it's already registered")
+	firstbootCompleteMachineconfig.PersistentFlags().StringVar(&firstbootOpts.nodeName, "node-name", "", "Name of the node, defaults to the hostname")
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 }
 
@@ -36,9 +48,38 @@ func runFirstBootCompleteMachineConfig(_ *cobra.Command, _ []string) error {
 		return err
 	}
 
+	// the node is usually registered only after the firstboot pivot, so the
+	// progress is only reported on it when the kubelet already joined
+	if _, err := os.Stat(firstbootOpts.kubeconfig); err == nil {
+		if err := setFirstbootNode(dn); err != nil {
+			glog.Warningf("Not reporting the firstboot progress on the node: %v", err)
+		}
+	}
+
 	return dn.RunFirstbootCompleteMachineconfig()
 }
 
+func setFirstbootNode(dn *daemon.Daemon) error {
+	name := firstbootOpts.nodeName
+	if name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		name = hostname
+	}
+	cb, err := clients.NewBuilder(firstbootOpts.kubeconfig)
+	if err != nil {
+		return err
+	}
+	kubeClient, err := cb.KubeClient(componentName)
+	if err != nil {
+		return err
+	}
+	dn.SetFirstbootNode(name, kubeClient.CoreV1().Nodes())
+	return nil
+}
+
 func executeFirstbootCompleteMachineConfig(cmd *cobra.Command, args []string) {
 	err := runFirstBootCompleteMachineConfig(cmd, args)
 	if err != nil {
@@ -3,7 +3,7 @@ package main
 import (
 	"flag"
 
-	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -16,15 +16,19 @@ var (
 		Use:   componentName,
 		Short: "Run Machine Config Daemon",
 		Long:  "Runs the Machine Config Daemon which handles communication between the host and the cluster as well as applying machineconfigs to the host",
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return logging.Setup()
+		},
 	}
 )
 
 func init() {
 	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	logging.AddFlags(rootCmd.PersistentFlags())
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		glog.Exitf("Error executing MCD: %v", err)
+		logging.Exitf("Error executing MCD: %v", err)
 	}
 }
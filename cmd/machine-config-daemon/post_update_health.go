@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	daemon "github.com/openshift/machine-config-operator/pkg/daemon"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	postUpdateHealthCmd = &cobra.Command{
+		Use:                   "post-update-health",
+		DisableFlagsInUseLine: true,
+		Short:                 "Check the health of the host after an update, rolling it back if it isn't healthy",
+		Args:                  cobra.MaximumNArgs(0),
+		Run:                   executePostUpdateHealth,
+	}
+
+	postUpdateHealthOpts struct {
+		restore                bool
+		kubeletHealthzEndpoint string
+	}
+)
+
+// init executes upon import
+func init() {
+	rootCmd.AddCommand(postUpdateHealthCmd)
+	postUpdateHealthCmd.PersistentFlags().BoolVar(&postUpdateHealthOpts.restore, "restore", false, "Restore the files of the config an update was rolled back to, instead of checking the health of the host")
+	postUpdateHealthCmd.PersistentFlags().StringVar(&postUpdateHealthOpts.kubeletHealthzEndpoint, "kubelet-healthz-endpoint", "http://localhost:10248/healthz", "healthz endpoint to check health")
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+}
+
+func runPostUpdateHealth(_ *cobra.Command, _ []string) error {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	exitCh := make(chan error)
+	defer close(exitCh)
+
+	dn, err := daemon.New(daemon.NewNodeUpdaterClient(), exitCh)
+	if err != nil {
+		return err
+	}
+	if postUpdateHealthOpts.restore {
+		return dn.RestorePostUpdateRollback()
+	}
+	return dn.RunPostUpdateHealthCheck(postUpdateHealthOpts.kubeletHealthzEndpoint)
+}
+
+func executePostUpdateHealth(cmd *cobra.Command, args []string) {
+	err := runPostUpdateHealth(cmd, args)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon"
 	"github.com/openshift/machine-config-operator/pkg/version"
@@ -34,12 +35,14 @@ var (
 		kubeletHealthzEnabled  bool
 		kubeletHealthzEndpoint string
 		promMetricsURL         string
+		apiSocket              string
+		hostService            bool
 	}
 )
 
 func init() {
 	rootCmd.AddCommand(startCmd)
-	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
+	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing and host service only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.nodeName, "node-name", "", "kubernetes node name daemon is managing.")
 	startCmd.PersistentFlags().StringVar(&startOpts.rootMount, "root-mount", "/rootfs", "where the nodes root filesystem is mounted for chroot and file manipulation.")
 	startCmd.PersistentFlags().StringVar(&startOpts.onceFrom, "once-from", "", "Runs the daemon once using a provided file path or URL endpoint as its machine config or ignition (.ign) file source")
@@ -47,6 +50,8 @@ func init() {
 	startCmd.PersistentFlags().BoolVar(&startOpts.kubeletHealthzEnabled, "kubelet-healthz-enabled", true, "kubelet healthz endpoint monitoring")
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeletHealthzEndpoint, "kubelet-healthz-endpoint", "http://localhost:10248/healthz", "healthz endpoint to check health")
 	startCmd.PersistentFlags().StringVar(&startOpts.promMetricsURL, "metrics-url", "127.0.0.1:8797", "URL for prometheus metrics listener")
+	startCmd.PersistentFlags().StringVar(&startOpts.apiSocket, "api-socket", daemon.DefaultAPISocketPath, "path of the unix socket serving the read-only daemon API")
+	startCmd.PersistentFlags().BoolVar(&startOpts.hostService, "host-service", false, "Runs as the host service installed by the supervise command, on the root filesystem of the node")
 }
 
 // bindPodMounts ensures that the daemon can still see e.g. /run/secrets/kubernetes.io
@@ -74,27 +79,37 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
 
 	onceFromMode := startOpts.onceFrom != ""
-	if !onceFromMode {
-		// in the daemon case
-		if err := bindPodMounts(startOpts.rootMount); err != nil {
-			glog.Fatalf("Binding pod mounts: %+v", err)
+	// The host service already runs on the root filesystem of the node
+	if !startOpts.hostService {
+		if !onceFromMode {
+			// in the daemon case
+			if err := bindPodMounts(startOpts.rootMount); err != nil {
+				logging.Fatalf("Binding pod mounts: %+v", err)
+			}
+		}
+
+		glog.Infof(`Calling chroot("%s")`, startOpts.rootMount)
+		if err := syscall.Chroot(startOpts.rootMount); err != nil {
+			logging.Fatalf("Unable to chroot to %s: %s", startOpts.rootMount, err)
 		}
-	}
 
-	glog.Infof(`Calling chroot("%s")`, startOpts.rootMount)
-	if err := syscall.Chroot(startOpts.rootMount); err != nil {
-		glog.Fatalf("Unable to chroot to %s: %s", startOpts.rootMount, err)
+		glog.V(2).Infof("Moving to / inside the chroot")
+		if err := os.Chdir("/"); err != nil {
+			logging.Fatalf("Unable to change directory to /: %s", err)
+		}
 	}
 
-	glog.V(2).Infof("Moving to / inside the chroot")
-	if err := os.Chdir("/"); err != nil {
-		glog.Fatalf("Unable to change directory to /: %s", err)
+	// The operator switched back to running the daemon in its pod
+	if !onceFromMode && !startOpts.hostService {
+		if err := daemon.RemoveHostService(); err != nil {
+			logging.Fatalf("Failed to remove %s: %v", daemon.HostServiceName, err)
+		}
 	}
 
 	if startOpts.nodeName == "" {
 		name, ok := os.LookupEnv("NODE_NAME")
 		if !ok || name == "" {
-			glog.Fatalf("node-name is required")
+			logging.Fatalf("node-name is required")
 		}
 		startOpts.nodeName = name
 	}
@@ -110,7 +125,7 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 		exitCh,
 	)
 	if err != nil {
-		glog.Fatalf("Failed to initialize single run daemon: %v", err)
+		logging.Fatalf("Failed to initialize single run daemon: %v", err)
 	}
 
 	// If we are asked to run once and it's a valid file system path use
@@ -118,19 +133,19 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	if startOpts.onceFrom != "" {
 		err = dn.RunOnceFrom(startOpts.onceFrom, startOpts.skipReboot)
 		if err != nil {
-			glog.Fatalf("%v", err)
+			logging.Fatalf("%v", err)
 		}
 		return
 	}
 
 	cb, err := clients.NewBuilder(startOpts.kubeconfig)
 	if err != nil {
-		glog.Fatalf("Failed to initialize ClientBuilder: %v", err)
+		logging.Fatalf("Failed to initialize ClientBuilder: %v", err)
 	}
 
 	kubeClient, err := cb.KubeClient(componentName)
 	if err != nil {
-		glog.Fatalf("Cannot initialize kubeClient: %v", err)
+		logging.Fatalf("Cannot initialize kubeClient: %v", err)
 	}
 
 	// This channel is used to ensure all spawned goroutines exit when we exit.
@@ -146,19 +161,23 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	dn.ClusterConnect(
 		startOpts.nodeName,
 		kubeClient,
+		cb.MachineConfigClientOrDie(componentName),
 		ctx.InformerFactory.Machineconfiguration().V1().MachineConfigs(),
 		ctx.KubeInformerFactory.Core().V1().Nodes(),
 		startOpts.kubeletHealthzEnabled,
 		startOpts.kubeletHealthzEndpoint,
 	)
 	if err != nil {
-		glog.Fatalf("Failed to initialize daemon: %v", err)
+		logging.Fatalf("Failed to initialize daemon: %v", err)
 	}
 
 	ctx.KubeInformerFactory.Start(stopCh)
 	ctx.InformerFactory.Start(stopCh)
 	close(ctx.InformersStarted)
 
+	// Start the local read-only API
+	go dn.StartAPIListener(startOpts.apiSocket, stopCh)
+
 	if err := dn.Run(stopCh, exitCh); err != nil {
 		ctrlcommon.WriteTerminationError(err)
 	}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+const (
+	serviceAccountDir = "/run/secrets/kubernetes.io/serviceaccount"
+	// superviseInterval is how often the pod refreshes the host service and
+	// makes sure it's running.
+	superviseInterval = 30 * time.Second
+)
+
+var (
+	superviseCmd = &cobra.Command{
+		Use:   "supervise",
+		Short: "Runs Machine Config Daemon as a host service",
+		Long: `Installs the daemon as the machine-config-daemon-engine systemd service of the
+host, and keeps it running. The daemon updates the node from the host, so updates
+proceed while the container runtime is reconfigured or down. The pod shows the
+logs of the service, and leaves it running when it exits.`,
+		Args: cobra.NoArgs,
+		Run:  runSuperviseCmd,
+	}
+
+	superviseOpts struct {
+		nodeName     string
+		rootMount    string
+		apiServerURL string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(superviseCmd)
+	superviseCmd.PersistentFlags().StringVar(&superviseOpts.nodeName, "node-name", "", "kubernetes node name daemon is managing.")
+	superviseCmd.PersistentFlags().StringVar(&superviseOpts.rootMount, "root-mount", "/rootfs", "where the nodes root filesystem is mounted for chroot and file manipulation.")
+	superviseCmd.PersistentFlags().StringVar(&superviseOpts.apiServerURL, "apiserver-url", "", "URL of the API server the host service connects to")
+}
+
+func runSuperviseCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
+
+	if superviseOpts.apiServerURL == "" {
+		logging.Fatalf("apiserver-url is required")
+	}
+	if superviseOpts.nodeName == "" {
+		name, ok := os.LookupEnv("NODE_NAME")
+		if !ok || name == "" {
+			logging.Fatalf("node-name is required")
+		}
+		superviseOpts.nodeName = name
+	}
+
+	// The binary of the pod is the one installed on the host, read it before
+	// leaving the pod filesystem
+	self, err := os.Executable()
+	if err != nil {
+		logging.Fatalf("Unable to find the daemon binary: %v", err)
+	}
+	binary, err := ioutil.ReadFile(self)
+	if err != nil {
+		logging.Fatalf("Unable to read the daemon binary: %v", err)
+	}
+
+	if err := bindPodMounts(superviseOpts.rootMount); err != nil {
+		logging.Fatalf("Binding pod mounts: %+v", err)
+	}
+	glog.Infof(`Calling chroot("%s")`, superviseOpts.rootMount)
+	if err := syscall.Chroot(superviseOpts.rootMount); err != nil {
+		logging.Fatalf("Unable to chroot to %s: %s", superviseOpts.rootMount, err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		logging.Fatalf("Unable to change directory to /: %s", err)
+	}
+
+	stopCh := make(chan struct{})
+	go daemon.FollowHostServiceLogs(stopCh)
+
+	termCh := make(chan os.Signal, 1)
+	signal.Notify(termCh, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(superviseInterval)
+	defer ticker.Stop()
+	for {
+		if err := installHostService(binary); err != nil {
+			glog.Errorf("Failed to install %s: %v", daemon.HostServiceName, err)
+		} else if !daemon.HostServiceActive() {
+			glog.Warningf("%s isn't running, systemd restarts it", daemon.HostServiceName)
+		}
+
+		select {
+		case <-ticker.C:
+		case sig := <-termCh:
+			// The host service keeps updating the node without the pod
+			glog.Infof("Got %s, leaving %s running", sig, daemon.HostServiceName)
+			close(stopCh)
+			return
+		}
+	}
+}
+
+// installHostService installs the host service with the current token of the
+// service account of the pod.
+func installHostService(binary []byte) error {
+	token, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return err
+	}
+	caData, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return err
+	}
+	kubeconfig, err := daemon.HostServiceKubeconfig(superviseOpts.apiServerURL, caData, token)
+	if err != nil {
+		return err
+	}
+	return daemon.InstallHostService(superviseOpts.nodeName, binary, kubeconfig)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+
+	"github.com/openshift/machine-config-operator/lib/resourceread"
+	"github.com/openshift/machine-config-operator/pkg/daemon"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check the node against a rendered MachineConfig",
+		Long: `Checks the booted OS image, and the files and units on disk, against a rendered
+MachineConfig and prints a JSON report of the matches and mismatches. Without
+--config, the node is checked against its current config. Exits with 2 when the
+node hasn't converged to the config.`,
+		Args: cobra.MaximumNArgs(0),
+		Run:  executeValidate,
+	}
+
+	validateOpts struct {
+		config    string
+		rootMount string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.PersistentFlags().StringVar(&validateOpts.config, "config", "", "Path of the rendered MachineConfig, as YAML or JSON, to check the node against")
+	validateCmd.PersistentFlags().StringVar(&validateOpts.rootMount, "root-mount", "/", "Where the root filesystem of the node is mounted, e.g. /host from a debug pod")
+}
+
+func runValidate(_ *cobra.Command, _ []string) (*daemon.ValidationReport, error) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	var config []byte
+	if validateOpts.config != "" {
+		var err error
+		// read before chrooting, the config usually isn't on the node
+		config, err = ioutil.ReadFile(validateOpts.config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if validateOpts.rootMount != "/" {
+		if err := syscall.Chroot(validateOpts.rootMount); err != nil {
+			return nil, errors.Wrapf(err, "unable to chroot to %s", validateOpts.rootMount)
+		}
+		if err := os.Chdir("/"); err != nil {
+			return nil, err
+		}
+	}
+
+	exitCh := make(chan error)
+	defer close(exitCh)
+	dn, err := daemon.New(daemon.NewNodeUpdaterClient(), exitCh)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		return dn.ValidateCurrentConfig()
+	}
+	mc, err := resourceread.ReadMachineConfigV1(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", validateOpts.config)
+	}
+	return dn.ValidateConfig(mc)
+}
+
+func executeValidate(cmd *cobra.Command, args []string) {
+	report, err := runValidate(cmd, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+	if !report.Converged {
+		os.Exit(2)
+	}
+}
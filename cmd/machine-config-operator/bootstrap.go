@@ -4,6 +4,7 @@ import (
 	"flag"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/machine-config-operator/pkg/operator"
@@ -125,6 +126,6 @@ func runBootstrapCmd(cmd *cobra.Command, args []string) {
 		&imgs,
 		bootstrapOpts.destinationDir,
 	); err != nil {
-		glog.Fatalf("error rendering bootstrap manifests: %v", err)
+		logging.Fatalf("error rendering bootstrap manifests: %v", err)
 	}
 }
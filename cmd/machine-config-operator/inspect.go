@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/openshift/machine-config-operator/internal/logging"
+	"github.com/spf13/cobra"
+
+	"github.com/openshift/machine-config-operator/pkg/controller/bootstrap"
+)
+
+var (
+	inspectCmd = &cobra.Command{
+		Use:   "inspect",
+		Short: "Render MachineConfigs offline",
+		Long:  "Renders the MachineConfigPools of a directory from its MachineConfigs the way the MachineConfigController does, and prints the rendered MachineConfigs or their diff against a previous rendered MachineConfig.",
+		Run:   runInspectCmd,
+	}
+
+	inspectOpts struct {
+		dir      string
+		pool     string
+		previous string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+	inspectCmd.PersistentFlags().StringVar(&inspectOpts.dir, "dir", "", "Directory of the MachineConfigPool and MachineConfig manifests, and optionally the ControllerConfig.")
+	inspectCmd.MarkFlagRequired("dir")
+	inspectCmd.PersistentFlags().StringVar(&inspectOpts.pool, "pool", "", "Only render this pool.")
+	inspectCmd.PersistentFlags().StringVar(&inspectOpts.previous, "previous", "", "Rendered MachineConfig manifest to diff the rendered config of --pool against.")
+}
+
+func runInspectCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	if err := bootstrap.Inspect(inspectOpts.dir, inspectOpts.pool, inspectOpts.previous, os.Stdout); err != nil {
+		logging.Fatalf("error inspecting %s: %v", inspectOpts.dir, err)
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"flag"
 
-	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -17,15 +17,19 @@ var (
 		Use:   componentName,
 		Short: "Run Machine Config Operator",
 		Long:  "",
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return logging.Setup()
+		},
 	}
 )
 
 func init() {
 	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	logging.AddFlags(rootCmd.PersistentFlags())
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		glog.Exitf("Error executing MCO: %v", err)
+		logging.Exitf("Error executing MCO: %v", err)
 	}
 }
@@ -4,18 +4,16 @@ import (
 	"context"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/golang/glog"
-	operatorclientset "github.com/openshift/client-go/operator/clientset/versioned"
-	operatorinformers "github.com/openshift/client-go/operator/informers/externalversions"
-	operatorv1 "github.com/openshift/client-go/operator/informers/externalversions/operator/v1"
 	"github.com/openshift/machine-config-operator/cmd/common"
 	"github.com/openshift/machine-config-operator/internal/clients"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/operator"
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/tools/leaderelection"
 )
 
 var (
@@ -29,6 +27,10 @@ var (
 	startOpts struct {
 		kubeconfig string
 		imagesFile string
+
+		resourceLockNamespace string
+		resourceLockName      string
+		shutdownDeadline      time.Duration
 	}
 )
 
@@ -36,28 +38,32 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.imagesFile, "images-json", "", "images.json file for MCO.")
+	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockNamespace, "resourcelock-namespace", componentNamespace, "Namespace of the leader election lock")
+	startCmd.PersistentFlags().StringVar(&startOpts.resourceLockName, "resourcelock-name", componentName, "Name of the leader election lock")
+	startCmd.PersistentFlags().DurationVar(&startOpts.shutdownDeadline, "shutdown-deadline", ctrlcommon.ShutdownDeadline, "How long the operator is given to drain its queue on shutdown")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
 	flag.Set("logtostderr", "true")
 	flag.Parse()
+	ctrlcommon.ShutdownDeadline = startOpts.shutdownDeadline
 
 	// To help debugging, immediately log version
 	glog.Infof("Version: %s (Raw: %s, Hash: %s)", os.Getenv("RELEASE_VERSION"), version.Raw, version.Hash)
 
 	if startOpts.imagesFile == "" {
-		glog.Fatal("--images-json cannot be empty")
+		logging.Fatal("--images-json cannot be empty")
 	}
 
 	cb, err := clients.NewBuilder(startOpts.kubeconfig)
 	if err != nil {
-		glog.Fatalf("error creating clients: %v", err)
+		logging.Fatalf("error creating clients: %v", err)
 	}
 	run := func(ctx context.Context) {
 		ctrlctx := ctrlcommon.CreateControllerContext(cb, ctx.Done(), componentNamespace)
 		operatorClient := cb.OperatorClientOrDie("operator-shared-informer")
 
-		etcdInformer, err := getEtcdInformer(operatorClient, ctrlctx.OperatorInformerFactory)
+		etcdInformer, err := ctrlcommon.GetEtcdInformer(operatorClient, ctrlctx.OperatorInformerFactory)
 		if err != nil {
 			// MCO pod needs to restart for transient apiserver errors
 			glog.Errorf("unable to query discovery API %#v", err)
@@ -81,6 +87,8 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 			ctrlctx.ConfigInformerFactory.Config().V1().Infrastructures(),
 			ctrlctx.ConfigInformerFactory.Config().V1().Networks(),
 			ctrlctx.ConfigInformerFactory.Config().V1().Proxies(),
+			ctrlctx.KubeInformerFactory.Core().V1().Nodes(),
+			ctrlctx.MachineAPIKubeNamespacedInformerFactory.Core().V1().Secrets(),
 			ctrlctx.ClientBuilder.MachineConfigClientOrDie(componentName),
 			ctrlctx.ClientBuilder.KubeClientOrDie(componentName),
 			ctrlctx.ClientBuilder.APIExtClientOrDie(componentName),
@@ -95,40 +103,14 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 		ctrlctx.APIExtInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.ConfigInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.OpenShiftKubeAPIServerKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
+		ctrlctx.MachineAPIKubeNamespacedInformerFactory.Start(ctrlctx.Stop)
 		ctrlctx.OperatorInformerFactory.Start(ctrlctx.Stop)
 		close(ctrlctx.InformersStarted)
 
-		go controller.Run(2, ctrlctx.Stop)
-
-		select {}
+		controller.Run(2, ctrlctx.Stop)
 	}
 
-	leaderelection.RunOrDie(context.TODO(), leaderelection.LeaderElectionConfig{
-		Lock:          common.CreateResourceLock(cb, componentNamespace, componentName),
-		LeaseDuration: common.LeaseDuration,
-		RenewDeadline: common.RenewDeadline,
-		RetryPeriod:   common.RetryPeriod,
-		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: run,
-			OnStoppedLeading: func() {
-				glog.Fatalf("leaderelection lost")
-			},
-		},
+	common.RunLeaderElection(common.CreateResourceLock(cb, startOpts.resourceLockNamespace, startOpts.resourceLockName), run, func(identity string) {
+		glog.Infof("Current leader: %s", identity)
 	})
-	panic("unreachable")
-}
-
-func getEtcdInformer(operatorClient operatorclientset.Interface, operatorSharedInformer operatorinformers.SharedInformerFactory) (operatorv1.EtcdInformer, error) {
-	operatorGroups, err := operatorClient.Discovery().ServerResourcesForGroupVersion("operator.openshift.io/v1")
-	if err != nil {
-		glog.Errorf("unable to get operatorGroups: %#v", err)
-		return nil, err
-	}
-
-	for _, o := range operatorGroups.APIResources {
-		if o.Kind == "Etcd" {
-			return operatorSharedInformer.Operator().V1().Etcds(), nil
-		}
-	}
-	return nil, nil
 }
@@ -4,6 +4,7 @@ import (
 	"flag"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/openshift/machine-config-operator/pkg/server"
 	"github.com/openshift/machine-config-operator/pkg/version"
 	"github.com/spf13/cobra"
@@ -20,6 +21,7 @@ var (
 	bootstrapOpts struct {
 		serverBaseDir    string
 		serverKubeConfig string
+		watch            bool
 	}
 )
 
@@ -27,6 +29,7 @@ func init() {
 	rootCmd.AddCommand(bootstrapCmd)
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapOpts.serverBaseDir, "server-basedir", "/etc/mcs/bootstrap", "base directory on the host, relative to which machine-configs and pools can be found.")
 	bootstrapCmd.PersistentFlags().StringVar(&bootstrapOpts.serverKubeConfig, "bootstrap-kubeconfig", "/etc/kubernetes/kubeconfig", "path to bootstrap kubeconfig served by the bootstrap server.")
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapOpts.watch, "watch", false, "watch the server base directory and re-render the served configs when files change.")
 }
 
 func runBootstrapCmd(cmd *cobra.Command, args []string) {
@@ -36,17 +39,22 @@ func runBootstrapCmd(cmd *cobra.Command, args []string) {
 	// To help debugging, immediately log version
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
 
-	bs, err := server.NewBootstrapServer(bootstrapOpts.serverBaseDir, bootstrapOpts.serverKubeConfig)
-
+	stopCh := make(chan struct{})
+	var bs server.Server
+	var err error
+	if bootstrapOpts.watch {
+		bs, err = server.NewWatchingBootstrapServer(bootstrapOpts.serverBaseDir, bootstrapOpts.serverKubeConfig, stopCh)
+	} else {
+		bs, err = server.NewBootstrapServer(bootstrapOpts.serverBaseDir, bootstrapOpts.serverKubeConfig)
+	}
 	if err != nil {
-		glog.Exitf("Machine Config Server exited with error: %v", err)
+		logging.Exitf("Machine Config Server exited with error: %v", err)
 	}
 
 	apiHandler := server.NewServerAPIHandler(bs)
-	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key)
-	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "")
+	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key, "")
+	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "", "")
 
-	stopCh := make(chan struct{})
 	go secureServer.Serve()
 	go insecureServer.Serve()
 	<-stopCh
@@ -3,7 +3,7 @@ package main
 import (
 	"flag"
 
-	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +16,9 @@ var (
 		Use:   componentName,
 		Short: "Run Machine Config Server",
 		Long:  "",
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return logging.Setup()
+		},
 	}
 
 	rootOpts struct {
@@ -28,6 +31,7 @@ var (
 
 func init() {
 	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	logging.AddFlags(rootCmd.PersistentFlags())
 	rootCmd.PersistentFlags().IntVar(&rootOpts.sport, "secure-port", 22623, "secure port to serve ignition configs")
 	rootCmd.PersistentFlags().StringVar(&rootOpts.cert, "cert", "/etc/ssl/mcs/tls.crt", "cert file for TLS")
 	rootCmd.PersistentFlags().StringVar(&rootOpts.key, "key", "/etc/ssl/mcs/tls.key", "key file for TLS")
@@ -36,6 +40,6 @@ func init() {
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		glog.Exitf("Error executing MCS: %v", err)
+		logging.Exitf("Error executing MCS: %v", err)
 	}
 }
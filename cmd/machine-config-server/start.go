@@ -2,8 +2,10 @@ package main
 
 import (
 	"flag"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/server"
 	"github.com/openshift/machine-config-operator/pkg/version"
@@ -21,6 +23,10 @@ var (
 	startOpts struct {
 		kubeconfig   string
 		apiserverURL string
+		clientCA     string
+		metricsURL   string
+
+		renderPendingRetryAfter time.Duration
 	}
 )
 
@@ -28,6 +34,9 @@ func init() {
 	rootCmd.AddCommand(startCmd)
 	startCmd.PersistentFlags().StringVar(&startOpts.kubeconfig, "kubeconfig", "", "Kubeconfig file to access a remote cluster (testing only)")
 	startCmd.PersistentFlags().StringVar(&startOpts.apiserverURL, "apiserver-url", "", "URL for apiserver; Used to generate kubeconfig")
+	startCmd.PersistentFlags().StringVar(&startOpts.metricsURL, "metrics-url", server.DefaultMetricsBindAddress, "URL for prometheus metrics listener")
+	startCmd.PersistentFlags().StringVar(&startOpts.clientCA, "client-ca", "", "CA bundle file; when set, configs are only served to clients presenting a certificate it signed")
+	startCmd.PersistentFlags().DurationVar(&startOpts.renderPendingRetryAfter, "render-pending-retry-after", 0, "When set, requests for pools with MachineConfig changes not rendered yet get a 503 telling to retry after this duration")
 }
 
 func runStartCmd(cmd *cobra.Command, args []string) {
@@ -38,19 +47,21 @@ func runStartCmd(cmd *cobra.Command, args []string) {
 	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
 
 	if startOpts.apiserverURL == "" {
-		glog.Exitf("--apiserver-url cannot be empty")
+		logging.Exitf("--apiserver-url cannot be empty")
 	}
 
-	cs, err := server.NewClusterServer(startOpts.kubeconfig, startOpts.apiserverURL)
+	cs, err := server.NewClusterServer(startOpts.kubeconfig, startOpts.apiserverURL, startOpts.renderPendingRetryAfter)
 	if err != nil {
 		ctrlcommon.WriteTerminationError(err)
 	}
 
 	apiHandler := server.NewServerAPIHandler(cs)
-	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key)
-	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "")
+	secureServer := server.NewAPIServer(apiHandler, rootOpts.sport, false, rootOpts.cert, rootOpts.key, startOpts.clientCA)
+	insecureServer := server.NewAPIServer(apiHandler, rootOpts.isport, true, "", "", startOpts.clientCA)
 
 	stopCh := make(chan struct{})
+	go server.StartMetricsListener(startOpts.metricsURL, stopCh)
+	go apiHandler.RunAudit(stopCh)
 	go secureServer.Serve()
 	go insecureServer.Serve()
 	<-stopCh
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/openshift/machine-config-operator/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+const (
+	componentName = "machine-config-webhook"
+)
+
+var (
+	rootCmd = &cobra.Command{
+		Use:   componentName,
+		Short: "Run Machine Config validating admission webhook",
+		Long:  "",
+	}
+
+	rootOpts struct {
+		sport int
+		cert  string
+		key   string
+	}
+)
+
+func init() {
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+	rootCmd.PersistentFlags().IntVar(&rootOpts.sport, "secure-port", 9443, "secure port to serve admission reviews")
+	rootCmd.PersistentFlags().StringVar(&rootOpts.cert, "cert", "/etc/ssl/mcw/tls.crt", "cert file for TLS")
+	rootCmd.PersistentFlags().StringVar(&rootOpts.key, "key", "/etc/ssl/mcw/tls.key", "key file for TLS")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		logging.Exitf("Error executing machine-config-webhook: %v", err)
+	}
+}
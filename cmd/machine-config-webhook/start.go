@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/version"
+	"github.com/openshift/machine-config-operator/pkg/webhook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	startCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Starts Machine Config Webhook",
+		Long:  "",
+		Run:   runStartCmd,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+}
+
+func runStartCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	// To help debugging, immediately log version
+	glog.Infof("Version: %+v (%s)", version.Raw, version.Hash)
+
+	webhook.NewServer(rootOpts.sport, rootOpts.cert, rootOpts.key).Serve()
+}
@@ -0,0 +1,192 @@
+// Package logging lets the MCO components write their glog output as JSON.
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// FormatText is the default glog format.
+	FormatText = "text"
+	// FormatJSON writes a JSON object per log line.
+	FormatJSON = "json"
+
+	// maxLineLength is the length longer lines are truncated to.
+	maxLineLength = 1024 * 1024
+	// flushTimeout is how long Fatal waits for the pending lines to be written.
+	flushTimeout = 5 * time.Second
+)
+
+var (
+	format = FormatText
+
+	fieldsLock sync.RWMutex
+	fields     = map[string]string{}
+
+	// pipe is the write end glog writes to in place of stderr, and converted
+	// is closed once all its lines were written.
+	pipe      *os.File
+	converted chan struct{}
+
+	// glogHeader matches the header glog prefixes lines with:
+	// Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+	glogHeader = regexp.MustCompile(`^([IWEF])\d{4} \d{2}:\d{2}:\d{2}\.\d{6}\s+\d+ ([^\]]+)\] ?(.*)$`)
+
+	glogLevels = map[string]string{
+		"I": "info",
+		"W": "warning",
+		"E": "error",
+		"F": "fatal",
+	}
+)
+
+// AddFlags adds the --log-format flag to fs.
+func AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&format, "log-format", FormatText, "Format of the logs: text or json")
+}
+
+// Setup applies the log format set with --log-format. It must be called
+// before anything is logged.
+func Setup() error {
+	switch format {
+	case FormatText:
+		return nil
+	case FormatJSON:
+	default:
+		return fmt.Errorf("invalid --log-format %q: must be %s or %s", format, FormatText, FormatJSON)
+	}
+
+	// glog writes straight to os.Stderr, so its lines are rewritten from a pipe
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	pipe = w
+	converted = make(chan struct{})
+	go func() {
+		defer close(converted)
+		convert(r, stderr)
+	}()
+	return nil
+}
+
+// Fatal logs like glog.Fatal, writing the pending lines before exiting.
+func Fatal(args ...interface{}) {
+	exit(255, fmt.Sprint(args...))
+}
+
+// Fatalf logs like glog.Fatalf, writing the pending lines before exiting.
+func Fatalf(format string, args ...interface{}) {
+	exit(255, fmt.Sprintf(format, args...))
+}
+
+// Exitf logs like glog.Exitf, writing the pending lines before exiting.
+func Exitf(format string, args ...interface{}) {
+	exit(1, fmt.Sprintf(format, args...))
+}
+
+// exit logs msg at the fatal level and exits with code. glog exits as soon
+// as the line is written to the pipe, so the JSON lines are written here
+// before exiting instead.
+func exit(code int, msg string) {
+	if pipe == nil {
+		if code == 1 {
+			glog.ExitDepth(2, msg)
+		}
+		glog.FatalDepth(2, msg)
+	}
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		file, line = "???", 1
+	}
+	fmt.Fprintf(pipe, "F%s %7d %s:%d] %s\n", time.Now().Format("0102 15:04:05.000000"), os.Getpid(), filepath.Base(file), line, msg)
+	pipe.Close()
+	select {
+	case <-converted:
+	case <-time.After(flushTimeout):
+	}
+	os.Exit(code)
+}
+
+// SetField sets key on all the log lines written from now on, or removes it
+// when value is empty. The stable keys are node, pool, config and phase.
+func SetField(key, value string) {
+	fieldsLock.Lock()
+	defer fieldsLock.Unlock()
+	if value == "" {
+		delete(fields, key)
+		return
+	}
+	fields[key] = value
+}
+
+// convert writes the JSON records of the lines of r to w until r is closed.
+// Lines longer than maxLineLength are truncated.
+func convert(r io.Reader, w io.Writer) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var line []byte
+	truncated := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !truncated {
+			line = append(line, chunk...)
+			if len(line) > maxLineLength {
+				line = line[:maxLineLength]
+				truncated = true
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if len(line) > 0 {
+			w.Write(formatJSON(string(bytes.TrimSuffix(line, []byte("\n"))), time.Now()))
+		}
+		if err != nil {
+			return
+		}
+		line = line[:0]
+		truncated = false
+	}
+}
+
+// formatJSON returns the JSON record of a glog line. Lines without a glog
+// header, e.g. the ones of multi-line messages, are logged at the info level.
+func formatJSON(line string, now time.Time) []byte {
+	fieldsLock.RLock()
+	record := make(map[string]string, len(fields)+4)
+	for k, v := range fields {
+		record[k] = v
+	}
+	fieldsLock.RUnlock()
+
+	record["ts"] = now.UTC().Format(time.RFC3339Nano)
+	record["level"] = "info"
+	record["msg"] = line
+	if m := glogHeader.FindStringSubmatch(line); m != nil {
+		record["level"] = glogLevels[m[1]]
+		record["caller"] = m[2]
+		record["msg"] = m[3]
+	}
+
+	// maps are marshalled with sorted keys, so records are stable
+	out, err := json.Marshal(record)
+	if err != nil {
+		out = []byte(fmt.Sprintf(`{"level":"error","msg":%q}`, err.Error()))
+	}
+	return append(out, '\n')
+}
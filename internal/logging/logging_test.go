@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatJSON(t *testing.T) {
+	now := time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+	SetField("node", "worker-0")
+	SetField("phase", "Draining")
+	SetField("phase", "")
+	defer SetField("node", "")
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{
+			line:     "I0401 10:00:00.123456    1234 update.go:42] Update prepared; beginning drain",
+			expected: `{"caller":"update.go:42","level":"info","msg":"Update prepared; beginning drain","node":"worker-0","ts":"2020-04-01T10:00:00Z"}` + "\n",
+		},
+		{
+			line:     "E0401 10:00:00.123456       1 daemon.go:7] failed: \"quoted\"",
+			expected: `{"caller":"daemon.go:7","level":"error","msg":"failed: \"quoted\"","node":"worker-0","ts":"2020-04-01T10:00:00Z"}` + "\n",
+		},
+		{
+			line:     "  continuation of a multi-line message",
+			expected: `{"level":"info","msg":"  continuation of a multi-line message","node":"worker-0","ts":"2020-04-01T10:00:00Z"}` + "\n",
+		},
+	}
+	for _, test := range tests {
+		if got := string(formatJSON(test.line, now)); got != test.expected {
+			t.Fatalf("expected %s, got %s", test.expected, got)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	long := strings.Repeat("x", maxLineLength+100*1024)
+	input := "I0401 10:00:00.123456    1234 update.go:42] first\n" + long + "\nlast"
+	var out bytes.Buffer
+	convert(strings.NewReader(input), &out)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(lines))
+	}
+	var record map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if len(record["msg"]) != maxLineLength {
+		t.Errorf("expected the long line truncated to %d bytes, got %d", maxLineLength, len(record["msg"]))
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "last" {
+		t.Errorf("expected the line after the long one, got %q", record["msg"])
+	}
+}
@@ -13,6 +13,22 @@ func EnsureConfigMap(modified *bool, existing *corev1.ConfigMap, required corev1
 	mergeMap(modified, &existing.Data, required.Data)
 }
 
+// EnsureService ensures that the existing matches the required.
+// modified is set to true when existing had to be updated with required.
+func EnsureService(modified *bool, existing *corev1.Service, required corev1.Service) {
+	EnsureObjectMeta(modified, &existing.ObjectMeta, required.ObjectMeta)
+
+	// the cluster IP is allocated by the apiserver, leave it alone
+	if !equality.Semantic.DeepEqual(existing.Spec.Selector, required.Spec.Selector) {
+		*modified = true
+		existing.Spec.Selector = required.Spec.Selector
+	}
+	if !equality.Semantic.DeepEqual(existing.Spec.Ports, required.Spec.Ports) {
+		*modified = true
+		existing.Spec.Ports = required.Spec.Ports
+	}
+}
+
 // ensurePodTemplateSpec ensures that the existing matches the required.
 // modified is set to true when existing had to be updated with required.
 func ensurePodTemplateSpec(modified *bool, existing *corev1.PodTemplateSpec, required corev1.PodTemplateSpec) {
@@ -133,6 +149,8 @@ func ensureContainer(modified *bool, existing *corev1.Container, required corev1
 		ensureVolumeMount(modified, existingCurr, required)
 	}
 
+	ensureResourceRequirements(modified, &existing.Resources, required.Resources)
+
 	if required.LivenessProbe != nil {
 		ensureProbePtr(modified, &existing.LivenessProbe, required.LivenessProbe)
 	}
@@ -144,6 +162,13 @@ func ensureContainer(modified *bool, existing *corev1.Container, required corev1
 	ensureSecurityContextPtr(modified, &existing.SecurityContext, required.SecurityContext)
 }
 
+func ensureResourceRequirements(modified *bool, existing *corev1.ResourceRequirements, required corev1.ResourceRequirements) {
+	if !equality.Semantic.DeepEqual(required, *existing) {
+		*modified = true
+		*existing = required
+	}
+}
+
 func ensureProbePtr(modified *bool, existing **corev1.Probe, required *corev1.Probe) {
 	// if we have no required, then we don't care what someone else has set
 	if required == nil {
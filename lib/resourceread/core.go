@@ -43,3 +43,12 @@ func ReadSecretV1OrDie(objBytes []byte) *corev1.Secret {
 	}
 	return requiredObj.(*corev1.Secret)
 }
+
+// ReadServiceV1OrDie reads service object from bytes. Panics on error.
+func ReadServiceV1OrDie(objBytes []byte) *corev1.Service {
+	requiredObj, err := runtime.Decode(coreCodecs.UniversalDecoder(corev1.SchemeGroupVersion), objBytes)
+	if err != nil {
+		panic(err)
+	}
+	return requiredObj.(*corev1.Service)
+}
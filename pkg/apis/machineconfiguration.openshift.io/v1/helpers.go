@@ -2,6 +2,7 @@ package v1
 
 import (
 	"fmt"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -81,6 +82,41 @@ func IsMachineConfigPoolConditionPresentAndEqual(conditions []MachineConfigPoolC
 	return false
 }
 
+// syncConditionSuccess and syncConditionFailure are the types of the conditions
+// of the last sync of a config.
+const (
+	syncConditionSuccess = "Success"
+	syncConditionFailure = "Failure"
+)
+
+// SetSyncCondition sets condition, the Success or Failure condition of the last
+// sync of a config such as a NodeTuningConfig, in the conditions of its status.
+// Success and Failure exclude each other, so condition replaces either, and the
+// lastTransitionTime is kept if the condition didn't change. The conditions of
+// the configs only differ by the type of their Type, so conditions is a pointer
+// to any of their slices; like sort.Slice, it panics if it isn't.
+func SetSyncCondition(conditions interface{}, condition interface{}) {
+	list := reflect.ValueOf(conditions).Elem()
+	set := reflect.New(reflect.TypeOf(condition)).Elem()
+	set.Set(reflect.ValueOf(condition))
+
+	kept := reflect.MakeSlice(list.Type(), 0, list.Len()+1)
+	for i := 0; i < list.Len(); i++ {
+		c := list.Index(i)
+		condType := c.FieldByName("Type").String()
+		if condType != syncConditionSuccess && condType != syncConditionFailure {
+			kept = reflect.Append(kept, c)
+			continue
+		}
+		if condType == set.FieldByName("Type").String() &&
+			c.FieldByName("Status").String() == set.FieldByName("Status").String() &&
+			c.FieldByName("Message").String() == set.FieldByName("Message").String() {
+			set.FieldByName("LastTransitionTime").Set(c.FieldByName("LastTransitionTime"))
+		}
+	}
+	list.Set(reflect.Append(kept, set))
+}
+
 // NewKubeletConfigCondition returns an instance of a KubeletConfigCondition
 func NewKubeletConfigCondition(condType KubeletConfigStatusConditionType, status corev1.ConditionStatus, message string) *KubeletConfigCondition {
 	return &KubeletConfigCondition{
@@ -101,6 +137,66 @@ func NewContainerRuntimeConfigCondition(condType ContainerRuntimeConfigStatusCon
 	}
 }
 
+// NewNodeTuningConfigCondition returns an instance of a NodeTuningConfigCondition
+func NewNodeTuningConfigCondition(condType NodeTuningConfigStatusConditionType, status corev1.ConditionStatus, message string) *NodeTuningConfigCondition {
+	return &NodeTuningConfigCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
+// NewImagePolicyCondition returns an instance of an ImagePolicyCondition
+func NewImagePolicyCondition(condType ImagePolicyStatusConditionType, status corev1.ConditionStatus, message string) *ImagePolicyCondition {
+	return &ImagePolicyCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
+// NewTimeSyncCondition returns an instance of a TimeSyncCondition
+func NewTimeSyncCondition(condType TimeSyncStatusConditionType, status corev1.ConditionStatus, message string) *TimeSyncCondition {
+	return &TimeSyncCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
+// NewOnPremNetworkingCondition returns an instance of a OnPremNetworkingCondition
+func NewOnPremNetworkingCondition(condType OnPremNetworkingStatusConditionType, status corev1.ConditionStatus, message string) *OnPremNetworkingCondition {
+	return &OnPremNetworkingCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
+// NewAcceleratorConfigCondition returns an instance of a AcceleratorConfigCondition
+func NewAcceleratorConfigCondition(condType AcceleratorConfigStatusConditionType, status corev1.ConditionStatus, message string) *AcceleratorConfigCondition {
+	return &AcceleratorConfigCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
+// NewNodeSSHKeySetCondition returns an instance of a NodeSSHKeySetCondition
+func NewNodeSSHKeySetCondition(condType NodeSSHKeySetStatusConditionType, status corev1.ConditionStatus, message string) *NodeSSHKeySetCondition {
+	return &NodeSSHKeySetCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Message:            message,
+	}
+}
+
 // NewControllerConfigStatusCondition creates a new ControllerConfigStatus condition.
 func NewControllerConfigStatusCondition(condType ControllerConfigStatusConditionType, status corev1.ConditionStatus, reason, message string) *ControllerConfigStatusCondition {
 	return &ControllerConfigStatusCondition{
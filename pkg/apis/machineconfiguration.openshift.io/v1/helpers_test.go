@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -179,3 +180,28 @@ func TestIsControllerConfigCompleted(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSyncCondition(t *testing.T) {
+	var conditions []NodeTuningConfigCondition
+	failure := *NewNodeTuningConfigCondition(NodeTuningConfigFailure, corev1.ConditionFalse, "Error: failed")
+	SetSyncCondition(&conditions, failure)
+
+	unchanged := failure
+	unchanged.LastTransitionTime = metav1.NewTime(failure.LastTransitionTime.Add(time.Minute))
+	SetSyncCondition(&conditions, unchanged)
+	if len(conditions) != 1 || conditions[0].LastTransitionTime != failure.LastTransitionTime {
+		t.Errorf("expected the unchanged failure to be kept, got %v", conditions)
+	}
+
+	SetSyncCondition(&conditions, *NewNodeTuningConfigCondition(NodeTuningConfigSuccess, corev1.ConditionTrue, "Success"))
+	if len(conditions) != 1 || conditions[0].Type != NodeTuningConfigSuccess {
+		t.Errorf("expected the success to replace the failure, got %v", conditions)
+	}
+
+	other := *NewNodeTuningConfigCondition("Other", corev1.ConditionTrue, "")
+	conditions = append([]NodeTuningConfigCondition{other}, conditions...)
+	SetSyncCondition(&conditions, failure)
+	if len(conditions) != 2 || conditions[0] != other {
+		t.Errorf("expected the other conditions to be kept, got %v", conditions)
+	}
+}
@@ -34,6 +34,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&MachineConfigList{},
 		&MachineConfigPool{},
 		&MachineConfigPoolList{},
+		&NodeTuningConfig{},
+		&NodeTuningConfigList{},
+		&ImagePolicy{},
+		&ImagePolicyList{},
+		&TimeSync{},
+		&TimeSyncList{},
+		&OnPremNetworking{},
+		&OnPremNetworkingList{},
+		&AcceleratorConfig{},
+		&AcceleratorConfigList{},
+		&NodeSSHKeySet{},
+		&NodeSSHKeySetList{},
+		&NodeMachineConfigState{},
+		&NodeMachineConfigStateList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
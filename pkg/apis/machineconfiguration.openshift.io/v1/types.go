@@ -77,10 +77,56 @@ type ControllerConfigSpec struct {
 	// images is map of images that are used by the controller to render templates under ./templates/
 	Images map[string]string `json:"images"`
 
+	// renderedConfigHistoryLimit is the number of rendered MachineConfigs kept per pool
+	// to roll back to. Its value is taken from the data.renderedConfigHistoryLimit field
+	// on the machine-config-operator-config ConfigMap, defaults to 5.
+	// +optional
+	RenderedConfigHistoryLimit int32 `json:"renderedConfigHistoryLimit,omitempty"`
+
+	// drainPolicy configures how the machine-config-daemon drains the nodes before
+	// rebooting them. Its value is taken from the data.drainPolicy field on the
+	// machine-config-operator-config ConfigMap and can be overridden per pool.
+	// +optional
+	DrainPolicy *DrainPolicy `json:"drainPolicy,omitempty"`
+
+	// nodeDisruptionPolicy maps files and units to the actions the machine-config-daemon
+	// takes to apply changes to them instead of rebooting the nodes. Its value is taken
+	// from the data.nodeDisruptionPolicy field on the machine-config-operator-config ConfigMap.
+	// +optional
+	NodeDisruptionPolicy *NodeDisruptionPolicy `json:"nodeDisruptionPolicy,omitempty"`
+
+	// fips enables FIPS mode on the machines of the cluster. Its value is taken from
+	// the fips field of the install-config in the kube-system/cluster-config-v1 ConfigMap,
+	// as FIPS mode can only be chosen at install time.
+	// +optional
+	FIPS bool `json:"fips,omitempty"`
+
+	// nodeDNS sets the DNS resolvers of the machines of the cluster, overriding the
+	// ones from DHCP. Its value is taken from the data.nodeDNS field on the
+	// machine-config-operator-config ConfigMap.
+	// +optional
+	NodeDNS *NodeDNS `json:"nodeDNS,omitempty"`
+
+	// templateOverrides replace the contents of templates rendered by the template
+	// controller, keyed by their path under templates/, e.g.
+	// master/00-master/_base/units/kubelet.yaml, to trial fixes without rebuilding
+	// the operator image. Only the files and units templates can be overridden.
+	// Its value is taken from the data.templateOverrides field on the
+	// machine-config-operator-config ConfigMap.
+	// +optional
+	TemplateOverrides map[string]string `json:"templateOverrides,omitempty"`
+
 	// osImageURL is the location of the container image that contains the OS update payload.
 	// Its value is taken from the data.osImageURL field on the machine-config-osimageurl ConfigMap.
 	OSImageURL string `json:"osImageURL"`
 
+	// osImageAllowList is the signed list of the OS images MachineConfigPools may run
+	// instead of osImageURL. Its value is taken from the os-image-allow-list ConfigMap
+	// in openshift-config, and its keyring from the os-image-allow-list-keyring ConfigMap
+	// in openshift-config-managed.
+	// +optional
+	OSImageAllowList *OSImageAllowList `json:"osImageAllowList,omitempty"`
+
 	// proxy holds the current proxy configuration for the nodes
 	// +nullable
 	Proxy *configv1.ProxyStatus `json:"proxy"`
@@ -92,6 +138,35 @@ type ControllerConfigSpec struct {
 
 	// kubeletIPv6 is true to force a single-stack IPv6 kubelet config
 	KubeletIPv6 bool `json:"kubeletIPv6,omitempty"`
+
+	// tuning tunes the informers and sub-controllers of the machine-config-controller
+	// for large clusters. Its value is taken from the data.controllerTuning field on
+	// the machine-config-operator-config ConfigMap.
+	// +optional
+	Tuning *ControllerTuning `json:"tuning,omitempty"`
+}
+
+// NodeDNS configures the DNS resolvers of the machines through NetworkManager.
+type NodeDNS struct {
+	// nameservers are the IP addresses of the DNS servers the machines send their
+	// queries to, at most 3.
+	Nameservers []string `json:"nameservers"`
+
+	// searches are the search domains of the machines, at most 6.
+	// +optional
+	Searches []string `json:"searches,omitempty"`
+}
+
+// OSImageAllowList is a signed list of OS images.
+type OSImageAllowList struct {
+	// images are the allowed OS image pullspecs, one per line.
+	Images string `json:"images"`
+
+	// signature is the ASCII-armored detached OpenPGP signature of images.
+	Signature string `json:"signature"`
+
+	// keyring is the ASCII-armored OpenPGP public keyring signature is verified with.
+	Keyring string `json:"keyring"`
 }
 
 // ControllerConfigStatus is the status for ControllerConfig
@@ -170,11 +245,22 @@ type MachineConfigSpec struct {
 	// Config is a Ignition Config object.
 	Config runtime.RawExtension `json:"config"`
 
+	// butane is a Butane (Fedora CoreOS Config) YAML document, translated to
+	// Ignition by the render controller and merged after config.
+	// +optional
+	Butane string `json:"butane,omitempty"`
+
 	// +nullable
 	KernelArguments []string `json:"kernelArguments"`
 
 	FIPS       bool   `json:"fips"`
 	KernelType string `json:"kernelType"`
+
+	// extensions lists the supported package sets (e.g. usbguard) to be
+	// layered on top of the OS with rpm-ostree.
+	// +nullable
+	// +optional
+	Extensions []string `json:"extensions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -211,16 +297,310 @@ type MachineConfigPoolSpec struct {
 	// nodeSelector specifies a label selector for Machines
 	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
 
+	// osImageURL, when set, overrides the OS image of the release payload for the
+	// machines of the pool, e.g. to run a hotfix OS image. It must be pinned by digest
+	// and listed in the osImageAllowList of the ControllerConfig.
+	// +optional
+	OSImageURL string `json:"osImageURL,omitempty"`
+
 	// paused specifies whether or not changes to this machine config pool should be stopped.
 	// This includes generating new desiredMachineConfig and update of machines.
 	Paused bool `json:"paused"`
 
+	// pausedUntil, when set along with paused, is when the pool resumes
+	// automatically. The controller clears both fields once it is reached.
+	// +optional
+	// +nullable
+	PausedUntil *metav1.Time `json:"pausedUntil,omitempty"`
+
 	// maxUnavailable specifies the percentage or constant number of machines that can be updating at any given time.
+	// Percentages are relative to the number of ready machines and rounded down.
 	// default is 1.
 	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 
 	// The targeted MachineConfig object for the machine config pool.
 	Configuration MachineConfigPoolStatusConfiguration `json:"configuration"`
+
+	// rollbackTo is the name of a rendered MachineConfig from status.history
+	// the nodes of the pool should be rolled back to. While it is set, newly
+	// rendered configurations are not rolled out.
+	// +optional
+	RollbackTo string `json:"rollbackTo,omitempty"`
+
+	// updateStrategy controls how new rendered MachineConfigs are rolled out
+	// to the machines of the pool.
+	// +optional
+	UpdateStrategy *MachineConfigPoolUpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// autoRemediateDrift, when true, has the machines of the pool write the files and
+	// units of their current MachineConfig back to disk when they drifted from it,
+	// instead of reporting Degraded.
+	// +optional
+	AutoRemediateDrift bool `json:"autoRemediateDrift,omitempty"`
+
+	// unmanagedPaths are the absolute paths of the files the machines of the pool
+	// stop writing, deleting and checking for drift, e.g. files owned by another
+	// node agent. Paths ending with / cover the files of that directory tree. Paths
+	// the MCO relies on, e.g. under /etc/kubernetes, are ignored.
+	// +optional
+	UnmanagedPaths []string `json:"unmanagedPaths,omitempty"`
+
+	// maintenanceWindows, when set, restricts when the machines of the pool start
+	// updating to a new rendered MachineConfig. Updates in progress when a window
+	// closes are completed. Rollbacks are not restricted.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// rebootStrategy sets how the machines of the pool reboot into a new
+	// MachineConfig, e.g. for hardware which needs a custom power cycle.
+	// default is a systemd reboot.
+	// +optional
+	RebootStrategy *RebootStrategy `json:"rebootStrategy,omitempty"`
+
+	// postUpdateHealthCheck, when set, has the machines of the pool check their
+	// health after rebooting into a new MachineConfig, and roll back to their
+	// previous one when the checks don't pass in time.
+	// +optional
+	PostUpdateHealthCheck *PostUpdateHealthCheck `json:"postUpdateHealthCheck,omitempty"`
+
+	// progressDeadlineMinutes is how long a rollout can go without a machine
+	// of the pool finishing its update before the pool reports RolloutStalled.
+	// When unset, the rollout is never reported as stalled.
+	// +optional
+	ProgressDeadlineMinutes *int32 `json:"progressDeadlineMinutes,omitempty"`
+}
+
+// PostUpdateHealthCheckType is a health check of the machines once they
+// rebooted into a new MachineConfig.
+type PostUpdateHealthCheckType string
+
+const (
+	// PostUpdateHealthCheckKubelet checks that the kubelet is healthy.
+	PostUpdateHealthCheckKubelet PostUpdateHealthCheckType = "Kubelet"
+	// PostUpdateHealthCheckCRIO checks that the crio service is active.
+	PostUpdateHealthCheckCRIO PostUpdateHealthCheckType = "CRIO"
+)
+
+// PostUpdateHealthCheck describes how the machines of a pool check their health
+// after rebooting into a new MachineConfig.
+type PostUpdateHealthCheck struct {
+	// checks are the health checks which must pass, Kubelet and CRIO. default
+	// is all of them.
+	// +optional
+	Checks []PostUpdateHealthCheckType `json:"checks,omitempty"`
+
+	// timeout is how long the checks have to pass after the reboot before the
+	// machine rolls back, between 1m and 1h. default is 10m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// RebootStrategyType is a way of rebooting the machines.
+type RebootStrategyType string
+
+const (
+	// RebootStrategyReboot reboots the machines with systemctl reboot.
+	RebootStrategyReboot RebootStrategyType = "Reboot"
+	// RebootStrategyKexec boots the new kernel of the machines with systemctl
+	// kexec, skipping the firmware.
+	RebootStrategyKexec RebootStrategyType = "Kexec"
+	// RebootStrategyUnit starts a systemd unit which reboots the machines.
+	RebootStrategyUnit RebootStrategyType = "Unit"
+)
+
+// RebootStrategy describes how the machines of a pool reboot.
+type RebootStrategy struct {
+	// type is Reboot, Kexec or Unit. Kexec and Unit fall back to a systemd
+	// reboot when they fail to start. default is Reboot.
+	// +optional
+	Type RebootStrategyType `json:"type,omitempty"`
+
+	// unit is the systemd unit started to reboot with the Unit type, e.g. one
+	// power cycling the machine through its BMC. It must be shipped by a
+	// MachineConfig of the pool.
+	// +optional
+	Unit string `json:"unit,omitempty"`
+
+	// gracePeriod is how long the machines wait after stopping the kubelet
+	// before rebooting, at most 1h. default is 0.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// MaintenanceWindow is a recurring time range during which machines can start updating.
+type MaintenanceWindow struct {
+	// schedule is when the window opens, as a cron expression of 5 fields:
+	// minute, hour, day of month, month and day of week, e.g. "0 22 * * 1-5".
+	Schedule string `json:"schedule"`
+
+	// duration is how long the window stays open, e.g. 4h.
+	Duration metav1.Duration `json:"duration"`
+
+	// timeZone is the IANA time zone of the schedule, e.g. Europe/Paris.
+	// default is UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// MachineConfigPoolUpdateStrategy describes how a pool rolls out a new rendered MachineConfig.
+type MachineConfigPoolUpdateStrategy struct {
+	// canary, when set, updates a subset of the machines first and holds
+	// the rest of the pool until the canary is promoted.
+	// +optional
+	Canary *MachineConfigPoolCanaryStrategy `json:"canary,omitempty"`
+
+	// maxUnavailablePerZone is the number of machines sharing the same
+	// topology.kubernetes.io/zone label that can be updating at any given time.
+	// Machines without a zone label are only bound by maxUnavailable.
+	// default is 1, 0 disables the limit.
+	// +optional
+	MaxUnavailablePerZone *int32 `json:"maxUnavailablePerZone,omitempty"`
+
+	// staged, when true, has the machines write new rendered MachineConfigs to disk
+	// and stage the OS update without draining nor rebooting. The update is applied
+	// on the next reboot of the machine, or once its node is annotated with
+	// machineconfiguration.openshift.io/applyStagedUpdate=true.
+	// +optional
+	Staged bool `json:"staged,omitempty"`
+
+	// drainPolicy overrides, field by field, the drainPolicy of the ControllerConfig
+	// for the machines of this pool.
+	// +optional
+	DrainPolicy *DrainPolicy `json:"drainPolicy,omitempty"`
+}
+
+// DrainPolicy configures how the machine-config-daemon drains a node before rebooting it.
+type DrainPolicy struct {
+	// timeout is how long a single drain attempt waits for the pods to be evicted.
+	// default is 20s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// attempts is the number of drain attempts before the update is failed.
+	// default is 5.
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// backoff is the wait before the second drain attempt, doubled after every
+	// failed attempt. default is 10s.
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+
+	// skipWaitForDeleteTimeout, when set, skips waiting for the pods whose deletion
+	// was requested longer than this ago, e.g. pods stuck on an unreachable volume.
+	// +optional
+	SkipWaitForDeleteTimeout *metav1.Duration `json:"skipWaitForDeleteTimeout,omitempty"`
+
+	// forceAfterAttempts, when set, deletes the pods instead of evicting them once
+	// that many drain attempts failed, bypassing their PodDisruptionBudgets.
+	// +optional
+	ForceAfterAttempts int32 `json:"forceAfterAttempts,omitempty"`
+}
+
+// ControllerTuning tunes the machine-config-controller. Changes are applied by
+// restarting the machine-config-controller.
+type ControllerTuning struct {
+	// resyncPeriod is the minimum period the informers resync after, jittered up to
+	// twice its value. default is 20m.
+	// +optional
+	ResyncPeriod *metav1.Duration `json:"resyncPeriod,omitempty"`
+
+	// controllers tunes the sub-controllers, by name.
+	// +optional
+	Controllers []SubControllerTuning `json:"controllers,omitempty"`
+}
+
+// SubControllerTuning tunes a sub-controller of the machine-config-controller.
+type SubControllerTuning struct {
+	// name is one of template, kubelet-config, container-runtime-config,
+	// node-tuning-config, image-policy, time-sync, on-prem-networking,
+	// accelerator-config, node-ssh-keys, config-snippet, render or node.
+	Name string `json:"name"`
+
+	// workers is the number of objects synced concurrently. default is 2.
+	// +optional
+	Workers int32 `json:"workers,omitempty"`
+
+	// qps is the overall rate of syncs per second of the work queue. default is 10.
+	// +optional
+	QPS int32 `json:"qps,omitempty"`
+
+	// burst is the number of syncs allowed above qps. default is 100.
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// NodeDisruptionPolicy maps files and units to the actions applying changes to them.
+// Changes to anything else still reboot the node.
+type NodeDisruptionPolicy struct {
+	// files are the actions applying changes to files, by path.
+	// +optional
+	Files []NodeDisruptionPolicyFile `json:"files,omitempty"`
+
+	// units are the actions applying changes to systemd units, by name.
+	// +optional
+	Units []NodeDisruptionPolicyUnit `json:"units,omitempty"`
+}
+
+// NodeDisruptionPolicyFile are the actions applying changes to a file.
+type NodeDisruptionPolicyFile struct {
+	// path is the absolute path of the file.
+	Path string `json:"path"`
+
+	// actions are run in order once the file is written.
+	Actions []NodeDisruptionAction `json:"actions"`
+}
+
+// NodeDisruptionPolicyUnit are the actions applying changes to a systemd unit.
+type NodeDisruptionPolicyUnit struct {
+	// name is the name of the unit, e.g. chronyd.service.
+	Name string `json:"name"`
+
+	// actions are run in order once the unit is written.
+	Actions []NodeDisruptionAction `json:"actions"`
+}
+
+// NodeDisruptionActionType is the type of a NodeDisruptionAction.
+type NodeDisruptionActionType string
+
+const (
+	// NodeDisruptionActionNone applies the change by writing it to disk only.
+	NodeDisruptionActionNone NodeDisruptionActionType = "None"
+	// NodeDisruptionActionReload reloads a unit.
+	NodeDisruptionActionReload NodeDisruptionActionType = "Reload"
+	// NodeDisruptionActionRestart restarts a unit.
+	NodeDisruptionActionRestart NodeDisruptionActionType = "Restart"
+	// NodeDisruptionActionDrain drains the node before applying the change.
+	NodeDisruptionActionDrain NodeDisruptionActionType = "Drain"
+	// NodeDisruptionActionReboot reboots the node, as when no policy is set.
+	NodeDisruptionActionReboot NodeDisruptionActionType = "Reboot"
+)
+
+// NodeDisruptionAction is an action taken by the machine-config-daemon to apply a change.
+type NodeDisruptionAction struct {
+	// type is one of None, Reload, Restart, Drain or Reboot.
+	Type NodeDisruptionActionType `json:"type"`
+
+	// unit is the systemd unit to reload or restart. It's required for the
+	// Reload and Restart actions of files, and defaults to the unit itself for
+	// the actions of units.
+	// +optional
+	Unit string `json:"unit,omitempty"`
+}
+
+// MachineConfigPoolCanaryStrategy configures the canary phase of a rollout.
+type MachineConfigPoolCanaryStrategy struct {
+	// nodes is the number or percentage of machines updated during the canary phase.
+	// default is 1.
+	// +optional
+	Nodes *intstr.IntOrString `json:"nodes,omitempty"`
+
+	// healthyDuration, when set, promotes the canary automatically once all the
+	// canary machines have been updated and ready for that long. Otherwise the
+	// pool is paused when the canary machines are updated and the rollout
+	// continues once it is unpaused.
+	// +optional
+	HealthyDuration *metav1.Duration `json:"healthyDuration,omitempty"`
 }
 
 // MachineConfigPoolStatus is the status for MachineConfigPool resource.
@@ -249,9 +629,117 @@ type MachineConfigPoolStatus struct {
 	// A node is marked degraded if applying a configuration failed..
 	DegradedMachineCount int32 `json:"degradedMachineCount"`
 
+	// unmanagedMachineCount represents the total number of machines excluded from
+	// the management of the MCO with the machineconfiguration.openshift.io/unmanaged
+	// annotation or label. They are frozen at their current config, and don't count
+	// against the update of the pool.
+	// +optional
+	UnmanagedMachineCount int32 `json:"unmanagedMachineCount,omitempty"`
+
 	// conditions represents the latest available observations of current state.
 	// +optional
 	Conditions []MachineConfigPoolCondition `json:"conditions"`
+
+	// history lists the rendered MachineConfigs all the machines of the pool
+	// were updated to, most recent first.
+	// +optional
+	History []MachineConfigPoolHistoryEntry `json:"history,omitempty"`
+
+	// canary reports the canary phase of the rendered MachineConfig being rolled out.
+	// +optional
+	Canary *MachineConfigPoolCanaryStatus `json:"canary,omitempty"`
+
+	// nodes reports where each machine of the pool is in the rollout, sorted by name.
+	// +optional
+	Nodes []MachineConfigPoolNodeStatus `json:"nodes,omitempty"`
+
+	// lastProgressTime is when the rollout in progress started or a machine
+	// last finished updating. It's only tracked for pools with a progress
+	// deadline, and unset while the pool isn't updating.
+	// +optional
+	LastProgressTime *metav1.Time `json:"lastProgressTime,omitempty"`
+}
+
+// MachineConfigPoolNodePhase is the step of the rollout a machine is in.
+type MachineConfigPoolNodePhase string
+
+const (
+	// NodePhasePending means the machine wasn't told to update to the pool configuration yet.
+	NodePhasePending MachineConfigPoolNodePhase = "Pending"
+
+	// NodePhaseUpdating means the daemon is applying the configuration.
+	NodePhaseUpdating MachineConfigPoolNodePhase = "Updating"
+
+	// NodePhaseCordoned means the machine is unschedulable, e.g. it is coming back from a reboot.
+	NodePhaseCordoned MachineConfigPoolNodePhase = "Cordoned"
+
+	// NodePhaseStaged means the daemon wrote the configuration and waits for the
+	// machine to be rebooted into it.
+	NodePhaseStaged MachineConfigPoolNodePhase = "Staged"
+
+	// NodePhaseDraining means the daemon is evicting the pods of the machine.
+	NodePhaseDraining MachineConfigPoolNodePhase = "Draining"
+
+	// NodePhaseRebooting means the daemon rebooted the machine into the configuration.
+	NodePhaseRebooting MachineConfigPoolNodePhase = "Rebooting"
+
+	// NodePhaseDone means the machine runs the pool configuration.
+	NodePhaseDone MachineConfigPoolNodePhase = "Done"
+
+	// NodePhaseDegraded means the daemon failed to apply the configuration.
+	NodePhaseDegraded MachineConfigPoolNodePhase = "Degraded"
+
+	// NodePhaseUnmanaged means the machine is intentionally excluded from the
+	// management of the MCO, e.g. for debugging, and stays at its current config.
+	NodePhaseUnmanaged MachineConfigPoolNodePhase = "Unmanaged"
+)
+
+// MachineConfigPoolNodeStatus reports the rollout progress of a machine.
+type MachineConfigPoolNodeStatus struct {
+	// name of the node.
+	Name string `json:"name"`
+
+	// phase is the step of the rollout the machine is in.
+	Phase MachineConfigPoolNodePhase `json:"phase"`
+
+	// currentConfig is the rendered MachineConfig the machine runs.
+	// +optional
+	CurrentConfig string `json:"currentConfig,omitempty"`
+
+	// desiredConfig is the rendered MachineConfig the machine was told to update to.
+	// +optional
+	DesiredConfig string `json:"desiredConfig,omitempty"`
+
+	// reason is reported by the daemon when the machine is degraded.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// MachineConfigPoolCanaryStatus reports the progress of a canary phase.
+type MachineConfigPoolCanaryStatus struct {
+	// configuration is the rendered MachineConfig being rolled out.
+	Configuration string `json:"configuration"`
+
+	// machineCount is the number of machines updated during the canary phase.
+	MachineCount int32 `json:"machineCount"`
+
+	// completedTime is when all the canary machines were updated and ready.
+	// +optional
+	// +nullable
+	CompletedTime *metav1.Time `json:"completedTime,omitempty"`
+
+	// promoted is true once the rest of the pool can be updated.
+	Promoted bool `json:"promoted"`
+}
+
+// MachineConfigPoolHistoryEntry records a rendered MachineConfig a pool was updated to.
+type MachineConfigPoolHistoryEntry struct {
+	// name of the rendered MachineConfig.
+	Name string `json:"name"`
+
+	// timestamp is when all the machines of the pool were updated to the rendered MachineConfig.
+	// +nullable
+	Timestamp metav1.Time `json:"timestamp"`
 }
 
 // MachineConfigPoolStatusConfiguration stores the current configuration for the pool, and
@@ -307,6 +795,39 @@ const (
 
 	// MachineConfigPoolDegraded is the overall status of the pool based, today, on whether we fail with NodeDegraded or RenderDegraded
 	MachineConfigPoolDegraded MachineConfigPoolConditionType = "Degraded"
+
+	// MachineConfigPoolPausedWithPendingUpdates means the pool is paused while a newer rendered
+	// MachineConfig is waiting to be rolled out. The reason becomes PausedTooLong once
+	// the updates have been held back long enough to put e.g. certificate rotations at risk.
+	MachineConfigPoolPausedWithPendingUpdates MachineConfigPoolConditionType = "PausedWithPendingUpdates"
+
+	// MachineConfigPoolDrainDegraded means some machines of the pool failed to drain
+	// with the configured drain policy.
+	MachineConfigPoolDrainDegraded MachineConfigPoolConditionType = "DrainDegraded"
+
+	// MachineConfigPoolFirstbootStalled means the firstboot pivot of some machines of the
+	// pool failed or didn't report progress for long.
+	MachineConfigPoolFirstbootStalled MachineConfigPoolConditionType = "FirstbootStalled"
+
+	// MachineConfigPoolPodDisruptionBlocked means no machine of the pool starts updating
+	// because PodDisruptionBudgets currently block the drain of all the candidates.
+	MachineConfigPoolPodDisruptionBlocked MachineConfigPoolConditionType = "PodDisruptionBlocked"
+
+	// MachineConfigPoolEtcdQuorumAtRisk means the masters of the master pool don't start
+	// updating because the etcd members are not all healthy.
+	MachineConfigPoolEtcdQuorumAtRisk MachineConfigPoolConditionType = "EtcdQuorumAtRisk"
+
+	// MachineConfigPoolInMaintenanceWindow means a maintenance window of the pool is open.
+	// Its message tells when it closes, or else when the next one opens.
+	MachineConfigPoolInMaintenanceWindow MachineConfigPoolConditionType = "InMaintenanceWindow"
+
+	// MachineConfigPoolServingCAPropagated means the pointer Ignition config used to
+	// provision the machines of the pool trusts the current machine-config-server CA.
+	MachineConfigPoolServingCAPropagated MachineConfigPoolConditionType = "ServingCAPropagated"
+
+	// MachineConfigPoolRolloutStalled means no machine of the pool finished updating within
+	// spec.progressDeadlineMinutes. Its message names the machine blocking the rollout.
+	MachineConfigPoolRolloutStalled MachineConfigPoolConditionType = "RolloutStalled"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -338,6 +859,72 @@ type KubeletConfig struct {
 type KubeletConfigSpec struct {
 	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
 	KubeletConfig             *runtime.RawExtension `json:"kubeletConfig,omitempty"`
+
+	// logLevel sets the kubelet verbosity (KUBELET_LOG_LEVEL) for the
+	// nodes in the selected pools. When unset the default of the kubelet
+	// unit is kept.
+	// +optional
+	LogLevel *int32 `json:"logLevel,omitempty"`
+
+	// hugepages lists the huge pages allocated at boot on the nodes of the
+	// selected pools, through kernel arguments. The size of the first one is
+	// the default huge page size, and each size is listed once.
+	// +optional
+	Hugepages []HugepagesAllocation `json:"hugepages,omitempty"`
+
+	// failSwapOn sets the kubelet failSwapOn. When true swap is also disabled
+	// with the systemd.swap=0 kernel argument, so the kubelet never finds it
+	// enabled. It can't disagree with the failSwapOn of kubeletConfig.
+	// +optional
+	FailSwapOn *bool `json:"failSwapOn,omitempty"`
+
+	// cpuManagerPolicy sets the kubelet cpuManagerPolicy, none or static.
+	// The static policy needs CPUs reserved for the system, with
+	// reservedSystemCPUs or the cpu of systemReserved or kubeReserved.
+	// It can't disagree with the cpuManagerPolicy of kubeletConfig.
+	// +optional
+	CPUManagerPolicy string `json:"cpuManagerPolicy,omitempty"`
+
+	// topologyManagerPolicy sets the kubelet topologyManagerPolicy: none,
+	// best-effort, restricted or single-numa-node. It can't disagree with the
+	// topologyManagerPolicy of kubeletConfig.
+	// +optional
+	TopologyManagerPolicy string `json:"topologyManagerPolicy,omitempty"`
+
+	// reservedSystemCPUs sets the kubelet reservedSystemCPUs, the CPUs reserved
+	// for the system and kubernetes daemons, e.g. 0-1,4. It can't disagree with
+	// the reservedSystemCPUs of kubeletConfig.
+	// +optional
+	ReservedSystemCPUs string `json:"reservedSystemCPUs,omitempty"`
+
+	// memoryManagerPolicy sets the kubelet memoryManagerPolicy, None or
+	// Static. Static needs the MemoryManager feature gate.
+	// +optional
+	MemoryManagerPolicy string `json:"memoryManagerPolicy,omitempty"`
+
+	// reservedMemory sets the kubelet reservedMemory, the memory reserved for
+	// the system on each NUMA node. It needs the Static memoryManagerPolicy,
+	// and must add up to the memory reserved by kubeReserved, systemReserved
+	// and the memory.available hard eviction threshold.
+	// +optional
+	ReservedMemory []MemoryReservation `json:"reservedMemory,omitempty"`
+}
+
+// MemoryReservation defines the memory reserved for the system on a NUMA node
+type MemoryReservation struct {
+	// numaNode is the ID of the NUMA node.
+	NumaNode int32 `json:"numaNode"`
+	// limits are the quantities reserved by type of memory, memory or
+	// hugepages-<size>.
+	Limits corev1.ResourceList `json:"limits"`
+}
+
+// HugepagesAllocation defines the huge pages of a size allocated at boot
+type HugepagesAllocation struct {
+	// size of the huge pages, 2M or 1G.
+	Size string `json:"size"`
+	// count is the number of huge pages to allocate.
+	Count int32 `json:"count"`
 }
 
 // KubeletConfigStatus defines the observed state of a KubeletConfig
@@ -380,6 +967,10 @@ const (
 
 	// KubeletConfigFailure designates a failure applying a KubeletConfig CR.
 	KubeletConfigFailure KubeletConfigStatusConditionType = "Failure"
+
+	// KubeletConfigCrashLooping designates a KubeletConfig CR which failed validation
+	// over and over.
+	KubeletConfigCrashLooping KubeletConfigStatusConditionType = "CrashLooping"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -413,8 +1004,22 @@ type ContainerRuntimeConfigSpec struct {
 	ContainerRuntimeConfig    *ContainerRuntimeConfiguration `json:"containerRuntimeConfig,omitempty"`
 }
 
+// ContainerRuntimeName is the name of a container runtime the machines can run.
+type ContainerRuntimeName string
+
+const (
+	// ContainerRuntimeCRIO is CRI-O, the default container runtime.
+	ContainerRuntimeCRIO ContainerRuntimeName = "crio"
+)
+
 // ContainerRuntimeConfiguration defines the tuneables of the container runtime
 type ContainerRuntimeConfiguration struct {
+	// runtime is the container runtime of the machines of the selected pools,
+	// which decides the runtime templates rendered and the endpoint the kubelet
+	// connects to. Only crio, the default, is supported.
+	// +optional
+	Runtime ContainerRuntimeName `json:"runtime,omitempty"`
+
 	// pidsLimit specifies the maximum number of processes allowed in a container
 	PidsLimit int64 `json:"pidsLimit,omitempty"`
 
@@ -430,6 +1035,65 @@ type ContainerRuntimeConfiguration struct {
 	// overlaySize specifies the maximum size of a container image.
 	// This flag can be used to set quota on the size of container images. (default: 10GB)
 	OverlaySize resource.Quantity `json:"overlaySize"`
+
+	// logToJournald specifies whether the container logs are also sent to journald,
+	// besides the container log files.
+	// +optional
+	LogToJournald *bool `json:"logToJournald,omitempty"`
+
+	// logDir specifies the default directory of the container logs, used when the
+	// kubelet doesn't pass one. It must be an absolute path under /var/log.
+	// +optional
+	LogDir string `json:"logDir,omitempty"`
+
+	// shortNameAliases maps image short names, e.g. busybox, to the fully
+	// qualified repositories they resolve to, e.g. mirror.example.com/library/busybox.
+	// +optional
+	ShortNameAliases map[string]string `json:"shortNameAliases,omitempty"`
+
+	// logFilter is a regular expression the messages of CRI-O must match to be
+	// logged, e.g. to only keep the ones relevant to an audit.
+	// +optional
+	LogFilter string `json:"logFilter,omitempty"`
+
+	// separateLogStreams specifies whether the stdout and stderr of the containers
+	// are logged as separate streams, so that they can be audited apart.
+	// +optional
+	SeparateLogStreams *bool `json:"separateLogStreams,omitempty"`
+
+	// journaldRateLimit limits the rate of the messages journald accepts from each
+	// service, including CRI-O and the containers logging to journald.
+	// +optional
+	JournaldRateLimit *JournaldRateLimit `json:"journaldRateLimit,omitempty"`
+
+	// cniConfigDir specifies the directory CRI-O loads the CNI network
+	// configurations from. It must be /etc/kubernetes/cni/net.d, where the
+	// cluster network operator writes the pod network configuration.
+	// +optional
+	CNIConfigDir string `json:"cniConfigDir,omitempty"`
+
+	// cniPluginDirs specifies the directories CRI-O looks the CNI plugins up
+	// in. They must be absolute paths and include /var/lib/cni/bin, where the
+	// cluster network operator installs its plugins.
+	// +optional
+	CNIPluginDirs []string `json:"cniPluginDirs,omitempty"`
+
+	// additionalSearchRegistries are registries searched, after the ones of the
+	// cluster Image config, when pulling images with unqualified names.
+	// Deprecated: use registrySources.containerRuntimeSearchRegistries of the
+	// cluster Image config.
+	// +optional
+	AdditionalSearchRegistries []string `json:"additionalSearchRegistries,omitempty"`
+}
+
+// JournaldRateLimit is the rate limit of journald: the messages of a service
+// beyond burst within interval are dropped. A zero interval or burst disables it.
+type JournaldRateLimit struct {
+	// interval is the time interval the messages are counted over.
+	Interval metav1.Duration `json:"interval"`
+
+	// burst is the number of messages accepted from a service within interval.
+	Burst int32 `json:"burst"`
 }
 
 // ContainerRuntimeConfigStatus defines the observed state of a ContainerRuntimeConfig
@@ -472,6 +1136,14 @@ const (
 
 	// ContainerRuntimeConfigFailure designates a failure applying a ContainerRuntimeConfig CR.
 	ContainerRuntimeConfigFailure ContainerRuntimeConfigStatusConditionType = "Failure"
+
+	// ContainerRuntimeConfigCrashLooping designates a ContainerRuntimeConfig CR which
+	// failed validation over and over, and is retried with a growing delay.
+	ContainerRuntimeConfigCrashLooping ContainerRuntimeConfigStatusConditionType = "CrashLooping"
+
+	// ContainerRuntimeConfigDeprecated designates a ContainerRuntimeConfig CR which
+	// is applied, but sets deprecated fields.
+	ContainerRuntimeConfigDeprecated ContainerRuntimeConfigStatusConditionType = "Deprecated"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -483,3 +1155,651 @@ type ContainerRuntimeConfigList struct {
 
 	Items []ContainerRuntimeConfig `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeTuningConfig describes sysctls and kernel arguments to apply to the
+// nodes of the selected pools.
+type NodeTuningConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec NodeTuningConfigSpec `json:"spec"`
+	// +optional
+	Status NodeTuningConfigStatus `json:"status"`
+}
+
+// NodeTuningConfigSpec defines the desired state of NodeTuningConfig
+type NodeTuningConfigSpec struct {
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
+
+	// sysctls is the list of kernel parameters to set on the nodes.
+	// Only a whitelisted set of parameters is accepted.
+	// +optional
+	Sysctls []Sysctl `json:"sysctls,omitempty"`
+
+	// kernelArguments contains a list of kernel arguments to be added.
+	// Only a whitelisted set of arguments is accepted.
+	// +nullable
+	// +optional
+	KernelArguments []string `json:"kernelArguments,omitempty"`
+}
+
+// Sysctl defines a kernel parameter to be set
+type Sysctl struct {
+	// name of the kernel parameter, e.g. vm.max_map_count.
+	Name string `json:"name"`
+	// value of the kernel parameter.
+	Value string `json:"value"`
+}
+
+// NodeTuningConfigStatus defines the observed state of a NodeTuningConfig
+type NodeTuningConfigStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []NodeTuningConfigCondition `json:"conditions"`
+}
+
+// NodeTuningConfigCondition defines the state of the NodeTuningConfig
+type NodeTuningConfigCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type NodeTuningConfigStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// NodeTuningConfigStatusConditionType is the state of the operator's reconciliation functionality.
+type NodeTuningConfigStatusConditionType string
+
+const (
+	// NodeTuningConfigSuccess designates a successful application of a NodeTuningConfig CR.
+	NodeTuningConfigSuccess NodeTuningConfigStatusConditionType = "Success"
+
+	// NodeTuningConfigFailure designates a failure applying a NodeTuningConfig CR.
+	NodeTuningConfigFailure NodeTuningConfigStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeTuningConfigList is a list of NodeTuningConfig resources
+type NodeTuningConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeTuningConfig `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImagePolicy describes the signatures the images pulled from registries must
+// carry on the nodes of the selected pools.
+type ImagePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec ImagePolicySpec `json:"spec"`
+	// +optional
+	Status ImagePolicyStatus `json:"status"`
+}
+
+// ImagePolicySpec defines the desired state of ImagePolicy
+type ImagePolicySpec struct {
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
+
+	// registries lists the registries whose images must be signed.
+	Registries []RegistrySignaturePolicy `json:"registries"`
+}
+
+// RegistrySignaturePolicy defines the signatures required for the images of
+// a registry.
+type RegistrySignaturePolicy struct {
+	// scope is the registry, optionally followed by a repository path, the
+	// policy applies to, e.g. quay.io or registry.example.com:5000/team.
+	Scope string `json:"scope"`
+
+	// gpgKeys are the ASCII armored GPG public keys trusted to sign the images
+	// of the scope. An image is accepted when one of them signed it.
+	GPGKeys []string `json:"gpgKeys"`
+
+	// sigstore is the URL of the lookaside storage the signatures of the
+	// images are read from, for registries which don't serve them.
+	// +optional
+	Sigstore string `json:"sigstore,omitempty"`
+}
+
+// ImagePolicyStatus defines the observed state of an ImagePolicy
+type ImagePolicyStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []ImagePolicyCondition `json:"conditions"`
+}
+
+// ImagePolicyCondition defines the state of the ImagePolicy
+type ImagePolicyCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type ImagePolicyStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// ImagePolicyStatusConditionType is the state of the operator's reconciliation functionality.
+type ImagePolicyStatusConditionType string
+
+const (
+	// ImagePolicySuccess designates a successful application of an ImagePolicy CR.
+	ImagePolicySuccess ImagePolicyStatusConditionType = "Success"
+
+	// ImagePolicyFailure designates a failure applying an ImagePolicy CR.
+	ImagePolicyFailure ImagePolicyStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImagePolicyList is a list of ImagePolicy resources
+type ImagePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ImagePolicy `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TimeSync describes the NTP sources chrony synchronizes the clock of the
+// machines of the selected pools with.
+type TimeSync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec TimeSyncSpec `json:"spec"`
+	// +optional
+	Status TimeSyncStatus `json:"status"`
+}
+
+// TimeSyncSpec defines the desired state of TimeSync
+type TimeSyncSpec struct {
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
+
+	// servers are the NTP servers to synchronize with.
+	// +optional
+	Servers []TimeSource `json:"servers,omitempty"`
+
+	// pools are the NTP pools to synchronize with, whose names resolve to
+	// several servers, e.g. 2.rhel.pool.ntp.org.
+	// +optional
+	Pools []TimeSource `json:"pools,omitempty"`
+}
+
+// TimeSource is an NTP server or pool.
+type TimeSource struct {
+	// address is the host name or IP address of the server or pool.
+	Address string `json:"address"`
+
+	// options are chrony options of the source, e.g. iburst, prefer or
+	// maxpoll 6. Only the options which don't refer to files on the machines
+	// are allowed.
+	// +optional
+	Options []string `json:"options,omitempty"`
+}
+
+// TimeSyncStatus defines the observed state of a TimeSync
+type TimeSyncStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []TimeSyncCondition `json:"conditions"`
+}
+
+// TimeSyncCondition defines the state of the TimeSync
+type TimeSyncCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type TimeSyncStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// TimeSyncStatusConditionType is the state of the operator's reconciliation functionality.
+type TimeSyncStatusConditionType string
+
+const (
+	// TimeSyncSuccess designates a successful application of a TimeSync CR.
+	TimeSyncSuccess TimeSyncStatusConditionType = "Success"
+
+	// TimeSyncFailure designates a failure applying a TimeSync CR.
+	TimeSyncFailure TimeSyncStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TimeSyncList is a list of TimeSync resources
+type TimeSyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []TimeSync `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OnPremNetworking describes the keepalived and coredns static pods serving the
+// API and ingress virtual IPs and the internal DNS of the machines of the
+// selected pools, on the baremetal, oVirt and vSphere IPI platforms.
+type OnPremNetworking struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec OnPremNetworkingSpec `json:"spec"`
+	// +optional
+	Status OnPremNetworkingStatus `json:"status"`
+}
+
+// OnPremNetworkingSpec defines the desired state of OnPremNetworking
+type OnPremNetworkingSpec struct {
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
+
+	// keepalived configures the keepalived static pod, which holds the virtual IPs.
+	// +optional
+	Keepalived OnPremStaticPod `json:"keepalived,omitempty"`
+
+	// coreDNS configures the coredns static pod, which resolves the names of
+	// the cluster for the machines.
+	// +optional
+	CoreDNS OnPremStaticPod `json:"coreDNS,omitempty"`
+}
+
+// OnPremStaticPod configures a static pod of the on-premise networking.
+type OnPremStaticPod struct {
+	// resources are the cpu and memory requests of each container of the pod.
+	// They default to 100m of cpu and 200Mi of memory.
+	// +optional
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+}
+
+// OnPremNetworkingStatus defines the observed state of a OnPremNetworking
+type OnPremNetworkingStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// platform is the platform the static pods were last rendered for.
+	// +optional
+	Platform string `json:"platform,omitempty"`
+
+	// apiVIP is the virtual IP of the API server the static pods serve.
+	// +optional
+	APIVIP string `json:"apiVIP,omitempty"`
+
+	// ingressVIP is the virtual IP of the ingress the static pods serve.
+	// +optional
+	IngressVIP string `json:"ingressVIP,omitempty"`
+
+	// staticPods are the paths of the static pod manifests rendered.
+	// +optional
+	StaticPods []string `json:"staticPods,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []OnPremNetworkingCondition `json:"conditions"`
+}
+
+// OnPremNetworkingCondition defines the state of the OnPremNetworking
+type OnPremNetworkingCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type OnPremNetworkingStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// OnPremNetworkingStatusConditionType is the state of the operator's reconciliation functionality.
+type OnPremNetworkingStatusConditionType string
+
+const (
+	// OnPremNetworkingSuccess designates a successful application of a OnPremNetworking CR.
+	OnPremNetworkingSuccess OnPremNetworkingStatusConditionType = "Success"
+
+	// OnPremNetworkingFailure designates a failure applying a OnPremNetworking CR.
+	OnPremNetworkingFailure OnPremNetworkingStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OnPremNetworkingList is a list of OnPremNetworking resources
+type OnPremNetworkingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []OnPremNetworking `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AcceleratorConfig describes what the machines of the selected pools need for
+// a device, e.g. a GPU, to work: kernel arguments, extensions and kernel
+// modules not to load. It lets device operators prepare the machines without
+// writing MachineConfigs which collide with each other's.
+type AcceleratorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec AcceleratorConfigSpec `json:"spec"`
+	// +optional
+	Status AcceleratorConfigStatus `json:"status"`
+}
+
+// AcceleratorConfigSpec defines the desired state of AcceleratorConfig
+type AcceleratorConfigSpec struct {
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
+
+	// kernelArguments are the kernel arguments to add, e.g. intel_iommu=on.
+	// +optional
+	KernelArguments []string `json:"kernelArguments,omitempty"`
+
+	// extensions are the RHCOS extensions to install, e.g. kernel-devel.
+	// +optional
+	Extensions []string `json:"extensions,omitempty"`
+
+	// blacklistedModules are the kernel modules not to load, e.g. nouveau,
+	// neither from the initramfs nor from the root filesystem.
+	// +optional
+	BlacklistedModules []string `json:"blacklistedModules,omitempty"`
+}
+
+// AcceleratorConfigStatus defines the observed state of a AcceleratorConfig
+type AcceleratorConfigStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// pools are the names of the pools whose MachineConfig holds the
+	// AcceleratorConfig.
+	// +optional
+	Pools []string `json:"pools,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []AcceleratorConfigCondition `json:"conditions"`
+}
+
+// AcceleratorConfigCondition defines the state of the AcceleratorConfig
+type AcceleratorConfigCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type AcceleratorConfigStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// AcceleratorConfigStatusConditionType is the state of the operator's reconciliation functionality.
+type AcceleratorConfigStatusConditionType string
+
+const (
+	// AcceleratorConfigSuccess designates a successful application of a AcceleratorConfig CR.
+	AcceleratorConfigSuccess AcceleratorConfigStatusConditionType = "Success"
+
+	// AcceleratorConfigFailure designates a failure applying a AcceleratorConfig CR.
+	AcceleratorConfigFailure AcceleratorConfigStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AcceleratorConfigList is a list of AcceleratorConfig resources
+type AcceleratorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AcceleratorConfig `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeSSHKeySet lists SSH public keys allowed to log in as a user on the
+// machines of the selected pools. Rotating keys is a change of the list, which
+// the nodes pick up without rebooting.
+type NodeSSHKeySet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec NodeSSHKeySetSpec `json:"spec"`
+	// +optional
+	Status NodeSSHKeySetStatus `json:"status"`
+}
+
+// NodeSSHKeySetSpec defines the desired state of NodeSSHKeySet
+type NodeSSHKeySetSpec struct {
+	MachineConfigPoolSelector *metav1.LabelSelector `json:"machineConfigPoolSelector,omitempty"`
+
+	// user is the user the keys log in as, core when empty. Users other than
+	// core must already exist on the machines.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// authorizedKeys are the SSH public keys, one per entry, in the
+	// authorized_keys format without options, e.g.
+	// ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI... admin@example.com.
+	AuthorizedKeys []string `json:"authorizedKeys"`
+}
+
+// NodeSSHKeySetStatus defines the observed state of a NodeSSHKeySet
+type NodeSSHKeySetStatus struct {
+	// observedGeneration represents the generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// pools are the names of the pools whose MachineConfig holds the keys.
+	// +optional
+	Pools []string `json:"pools,omitempty"`
+
+	// fingerprints are the SHA256 fingerprints of the authorizedKeys, in the
+	// same order, as printed by ssh-keygen -l.
+	// +optional
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	// conditions represents the latest available observations of current state.
+	// +optional
+	Conditions []NodeSSHKeySetCondition `json:"conditions"`
+}
+
+// NodeSSHKeySetCondition defines the state of the NodeSSHKeySet
+type NodeSSHKeySetCondition struct {
+	// type specifies the state of the operator's reconciliation functionality.
+	Type NodeSSHKeySetStatusConditionType `json:"type"`
+
+	// status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// lastTransitionTime is the time of the last update to the current status object.
+	// +nullable
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// reason is the reason for the condition's last transition.  Reasons are PascalCase
+	Reason string `json:"reason,omitempty"`
+
+	// message provides additional information about the current condition.
+	// This is only to be consumed by humans.
+	Message string `json:"message,omitempty"`
+}
+
+// NodeSSHKeySetStatusConditionType is the state of the operator's reconciliation functionality.
+type NodeSSHKeySetStatusConditionType string
+
+const (
+	// NodeSSHKeySetSuccess designates a successful application of a NodeSSHKeySet CR.
+	NodeSSHKeySetSuccess NodeSSHKeySetStatusConditionType = "Success"
+
+	// NodeSSHKeySetFailure designates a failure applying a NodeSSHKeySet CR.
+	NodeSSHKeySetFailure NodeSSHKeySetStatusConditionType = "Failure"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeSSHKeySetList is a list of NodeSSHKeySet resources
+type NodeSSHKeySetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeSSHKeySet `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeMachineConfigState describes the config of a node and the progress of
+// its daemon. There's one per node, of the same name, in the namespace of the
+// MCO: the node controller sets the desired config, the daemon reports the rest.
+type NodeMachineConfigState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec NodeMachineConfigStateSpec `json:"spec"`
+	// +optional
+	Status NodeMachineConfigStateStatus `json:"status"`
+}
+
+// NodeMachineConfigStateSpec defines the desired state of NodeMachineConfigState
+type NodeMachineConfigStateSpec struct {
+	// node is the name of the node.
+	Node string `json:"node"`
+
+	// desiredConfig is the rendered MachineConfig the node controller wants the
+	// node to run.
+	// +optional
+	DesiredConfig string `json:"desiredConfig,omitempty"`
+}
+
+// NodeMachineConfigStateStatus defines the observed state of a NodeMachineConfigState
+type NodeMachineConfigStateStatus struct {
+	// currentConfig is the rendered MachineConfig the node runs.
+	// +optional
+	CurrentConfig string `json:"currentConfig,omitempty"`
+
+	// desiredConfig is the rendered MachineConfig the daemon last saw desired.
+	// +optional
+	DesiredConfig string `json:"desiredConfig,omitempty"`
+
+	// state of the daemon: Done, Working, Degraded or Unreconcilable.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// phase is the step of the update the daemon is in while Working, e.g.
+	// Draining or Rebooting.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// lastError is why the daemon is Degraded or Unreconcilable.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// lastTransitionTime is when the state last changed.
+	// +nullable
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// updateStartTime is when the daemon started the last update.
+	// +nullable
+	// +optional
+	UpdateStartTime *metav1.Time `json:"updateStartTime,omitempty"`
+
+	// updateCompletionTime is when the daemon completed the last update.
+	// +nullable
+	// +optional
+	UpdateCompletionTime *metav1.Time `json:"updateCompletionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeMachineConfigStateList is a list of NodeMachineConfigState resources
+type NodeMachineConfigStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeMachineConfigState `json:"items"`
+}
@@ -12,6 +12,148 @@ import (
 	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcceleratorConfig) DeepCopyInto(out *AcceleratorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcceleratorConfig.
+func (in *AcceleratorConfig) DeepCopy() *AcceleratorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AcceleratorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AcceleratorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcceleratorConfigCondition) DeepCopyInto(out *AcceleratorConfigCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcceleratorConfigCondition.
+func (in *AcceleratorConfigCondition) DeepCopy() *AcceleratorConfigCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(AcceleratorConfigCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcceleratorConfigList) DeepCopyInto(out *AcceleratorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AcceleratorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcceleratorConfigList.
+func (in *AcceleratorConfigList) DeepCopy() *AcceleratorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(AcceleratorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AcceleratorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcceleratorConfigSpec) DeepCopyInto(out *AcceleratorConfigSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KernelArguments != nil {
+		in, out := &in.KernelArguments, &out.KernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlacklistedModules != nil {
+		in, out := &in.BlacklistedModules, &out.BlacklistedModules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcceleratorConfigSpec.
+func (in *AcceleratorConfigSpec) DeepCopy() *AcceleratorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AcceleratorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcceleratorConfigStatus) DeepCopyInto(out *AcceleratorConfigStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]AcceleratorConfigCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcceleratorConfigStatus.
+func (in *AcceleratorConfigStatus) DeepCopy() *AcceleratorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AcceleratorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerRuntimeConfig) DeepCopyInto(out *ContainerRuntimeConfig) {
 	*out = *in
@@ -144,6 +286,38 @@ func (in *ContainerRuntimeConfiguration) DeepCopyInto(out *ContainerRuntimeConfi
 	*out = *in
 	out.LogSizeMax = in.LogSizeMax.DeepCopy()
 	out.OverlaySize = in.OverlaySize.DeepCopy()
+	if in.LogToJournald != nil {
+		in, out := &in.LogToJournald, &out.LogToJournald
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ShortNameAliases != nil {
+		in, out := &in.ShortNameAliases, &out.ShortNameAliases
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SeparateLogStreams != nil {
+		in, out := &in.SeparateLogStreams, &out.SeparateLogStreams
+		*out = new(bool)
+		**out = **in
+	}
+	if in.JournaldRateLimit != nil {
+		in, out := &in.JournaldRateLimit, &out.JournaldRateLimit
+		*out = new(JournaldRateLimit)
+		**out = **in
+	}
+	if in.CNIPluginDirs != nil {
+		in, out := &in.CNIPluginDirs, &out.CNIPluginDirs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalSearchRegistries != nil {
+		in, out := &in.AdditionalSearchRegistries, &out.AdditionalSearchRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -263,6 +437,33 @@ func (in *ControllerConfigSpec) DeepCopyInto(out *ControllerConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.DrainPolicy != nil {
+		in, out := &in.DrainPolicy, &out.DrainPolicy
+		*out = new(DrainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeDisruptionPolicy != nil {
+		in, out := &in.NodeDisruptionPolicy, &out.NodeDisruptionPolicy
+		*out = new(NodeDisruptionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeDNS != nil {
+		in, out := &in.NodeDNS, &out.NodeDNS
+		*out = new(NodeDNS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateOverrides != nil {
+		in, out := &in.TemplateOverrides, &out.TemplateOverrides
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.OSImageAllowList != nil {
+		in, out := &in.OSImageAllowList, &out.OSImageAllowList
+		*out = new(OSImageAllowList)
+		**out = **in
+	}
 	if in.Proxy != nil {
 		in, out := &in.Proxy, &out.Proxy
 		*out = new(configv1.ProxyStatus)
@@ -273,6 +474,11 @@ func (in *ControllerConfigSpec) DeepCopyInto(out *ControllerConfigSpec) {
 		*out = new(configv1.Infrastructure)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Tuning != nil {
+		in, out := &in.Tuning, &out.Tuning
+		*out = new(ControllerTuning)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -327,7 +533,80 @@ func (in *ControllerConfigStatusCondition) DeepCopy() *ControllerConfigStatusCon
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeletConfig) DeepCopyInto(out *KubeletConfig) {
+func (in *ControllerTuning) DeepCopyInto(out *ControllerTuning) {
+	*out = *in
+	if in.ResyncPeriod != nil {
+		in, out := &in.ResyncPeriod, &out.ResyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Controllers != nil {
+		in, out := &in.Controllers, &out.Controllers
+		*out = make([]SubControllerTuning, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerTuning.
+func (in *ControllerTuning) DeepCopy() *ControllerTuning {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerTuning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainPolicy) DeepCopyInto(out *DrainPolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SkipWaitForDeleteTimeout != nil {
+		in, out := &in.SkipWaitForDeleteTimeout, &out.SkipWaitForDeleteTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainPolicy.
+func (in *DrainPolicy) DeepCopy() *DrainPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugepagesAllocation) DeepCopyInto(out *HugepagesAllocation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugepagesAllocation.
+func (in *HugepagesAllocation) DeepCopy() *HugepagesAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(HugepagesAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePolicy) DeepCopyInto(out *ImagePolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -336,18 +615,18 @@ func (in *KubeletConfig) DeepCopyInto(out *KubeletConfig) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfig.
-func (in *KubeletConfig) DeepCopy() *KubeletConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicy.
+func (in *ImagePolicy) DeepCopy() *ImagePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeletConfig)
+	out := new(ImagePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KubeletConfig) DeepCopyObject() runtime.Object {
+func (in *ImagePolicy) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -355,30 +634,30 @@ func (in *KubeletConfig) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeletConfigCondition) DeepCopyInto(out *KubeletConfigCondition) {
+func (in *ImagePolicyCondition) DeepCopyInto(out *ImagePolicyCondition) {
 	*out = *in
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigCondition.
-func (in *KubeletConfigCondition) DeepCopy() *KubeletConfigCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicyCondition.
+func (in *ImagePolicyCondition) DeepCopy() *ImagePolicyCondition {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeletConfigCondition)
+	out := new(ImagePolicyCondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeletConfigList) DeepCopyInto(out *KubeletConfigList) {
+func (in *ImagePolicyList) DeepCopyInto(out *ImagePolicyList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]KubeletConfig, len(*in))
+		*out = make([]ImagePolicy, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -386,18 +665,18 @@ func (in *KubeletConfigList) DeepCopyInto(out *KubeletConfigList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigList.
-func (in *KubeletConfigList) DeepCopy() *KubeletConfigList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicyList.
+func (in *ImagePolicyList) DeepCopy() *ImagePolicyList {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeletConfigList)
+	out := new(ImagePolicyList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KubeletConfigList) DeepCopyObject() runtime.Object {
+func (in *ImagePolicyList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -405,37 +684,39 @@ func (in *KubeletConfigList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeletConfigSpec) DeepCopyInto(out *KubeletConfigSpec) {
+func (in *ImagePolicySpec) DeepCopyInto(out *ImagePolicySpec) {
 	*out = *in
 	if in.MachineConfigPoolSelector != nil {
 		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
 		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.KubeletConfig != nil {
-		in, out := &in.KubeletConfig, &out.KubeletConfig
-		*out = new(runtime.RawExtension)
-		(*in).DeepCopyInto(*out)
+	if in.Registries != nil {
+		in, out := &in.Registries, &out.Registries
+		*out = make([]RegistrySignaturePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigSpec.
-func (in *KubeletConfigSpec) DeepCopy() *KubeletConfigSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicySpec.
+func (in *ImagePolicySpec) DeepCopy() *ImagePolicySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeletConfigSpec)
+	out := new(ImagePolicySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeletConfigStatus) DeepCopyInto(out *KubeletConfigStatus) {
+func (in *ImagePolicyStatus) DeepCopyInto(out *ImagePolicyStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]KubeletConfigCondition, len(*in))
+		*out = make([]ImagePolicyCondition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -443,37 +724,55 @@ func (in *KubeletConfigStatus) DeepCopyInto(out *KubeletConfigStatus) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigStatus.
-func (in *KubeletConfigStatus) DeepCopy() *KubeletConfigStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicyStatus.
+func (in *ImagePolicyStatus) DeepCopy() *ImagePolicyStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeletConfigStatus)
+	out := new(ImagePolicyStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
+func (in *JournaldRateLimit) DeepCopyInto(out *JournaldRateLimit) {
+	*out = *in
+	out.Interval = in.Interval
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JournaldRateLimit.
+func (in *JournaldRateLimit) DeepCopy() *JournaldRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(JournaldRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfig) DeepCopyInto(out *KubeletConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfig.
-func (in *MachineConfig) DeepCopy() *MachineConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfig.
+func (in *KubeletConfig) DeepCopy() *KubeletConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfig)
+	out := new(KubeletConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MachineConfig) DeepCopyObject() runtime.Object {
+func (in *KubeletConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -481,17 +780,165 @@ func (in *MachineConfig) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfigList) DeepCopyInto(out *MachineConfigList) {
+func (in *KubeletConfigCondition) DeepCopyInto(out *KubeletConfigCondition) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]MachineConfig, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigCondition.
+func (in *KubeletConfigCondition) DeepCopy() *KubeletConfigCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfigCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfigList) DeepCopyInto(out *KubeletConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KubeletConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigList.
+func (in *KubeletConfigList) DeepCopy() *KubeletConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeletConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfigSpec) DeepCopyInto(out *KubeletConfigSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KubeletConfig != nil {
+		in, out := &in.KubeletConfig, &out.KubeletConfig
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Hugepages != nil {
+		in, out := &in.Hugepages, &out.Hugepages
+		*out = make([]HugepagesAllocation, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailSwapOn != nil {
+		in, out := &in.FailSwapOn, &out.FailSwapOn
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReservedMemory != nil {
+		in, out := &in.ReservedMemory, &out.ReservedMemory
+		*out = make([]MemoryReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigSpec.
+func (in *KubeletConfigSpec) DeepCopy() *KubeletConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfigStatus) DeepCopyInto(out *KubeletConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]KubeletConfigCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfigStatus.
+func (in *KubeletConfigStatus) DeepCopy() *KubeletConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfig.
+func (in *MachineConfig) DeepCopy() *MachineConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigList) DeepCopyInto(out *MachineConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -541,6 +988,52 @@ func (in *MachineConfigPool) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolCanaryStatus) DeepCopyInto(out *MachineConfigPoolCanaryStatus) {
+	*out = *in
+	if in.CompletedTime != nil {
+		in, out := &in.CompletedTime, &out.CompletedTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolCanaryStatus.
+func (in *MachineConfigPoolCanaryStatus) DeepCopy() *MachineConfigPoolCanaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolCanaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolCanaryStrategy) DeepCopyInto(out *MachineConfigPoolCanaryStrategy) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.HealthyDuration != nil {
+		in, out := &in.HealthyDuration, &out.HealthyDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolCanaryStrategy.
+func (in *MachineConfigPoolCanaryStrategy) DeepCopy() *MachineConfigPoolCanaryStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolCanaryStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfigPoolCondition) DeepCopyInto(out *MachineConfigPoolCondition) {
 	*out = *in
@@ -558,6 +1051,23 @@ func (in *MachineConfigPoolCondition) DeepCopy() *MachineConfigPoolCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolHistoryEntry) DeepCopyInto(out *MachineConfigPoolHistoryEntry) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolHistoryEntry.
+func (in *MachineConfigPoolHistoryEntry) DeepCopy() *MachineConfigPoolHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfigPoolList) DeepCopyInto(out *MachineConfigPoolList) {
 	*out = *in
@@ -591,6 +1101,22 @@ func (in *MachineConfigPoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolNodeStatus) DeepCopyInto(out *MachineConfigPoolNodeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolNodeStatus.
+func (in *MachineConfigPoolNodeStatus) DeepCopy() *MachineConfigPoolNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
 	*out = *in
@@ -604,12 +1130,46 @@ func (in *MachineConfigPoolSpec) DeepCopyInto(out *MachineConfigPoolSpec) {
 		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PausedUntil != nil {
+		in, out := &in.PausedUntil, &out.PausedUntil
+		*out = (*in).DeepCopy()
+	}
 	if in.MaxUnavailable != nil {
 		in, out := &in.MaxUnavailable, &out.MaxUnavailable
 		*out = new(intstr.IntOrString)
 		**out = **in
 	}
 	in.Configuration.DeepCopyInto(&out.Configuration)
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(MachineConfigPoolUpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnmanagedPaths != nil {
+		in, out := &in.UnmanagedPaths, &out.UnmanagedPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.RebootStrategy != nil {
+		in, out := &in.RebootStrategy, &out.RebootStrategy
+		*out = new(RebootStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostUpdateHealthCheck != nil {
+		in, out := &in.PostUpdateHealthCheck, &out.PostUpdateHealthCheck
+		*out = new(PostUpdateHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProgressDeadlineMinutes != nil {
+		in, out := &in.ProgressDeadlineMinutes, &out.ProgressDeadlineMinutes
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -634,6 +1194,27 @@ func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]MachineConfigPoolHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(MachineConfigPoolCanaryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]MachineConfigPoolNodeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastProgressTime != nil {
+		in, out := &in.LastProgressTime, &out.LastProgressTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -669,6 +1250,37 @@ func (in *MachineConfigPoolStatusConfiguration) DeepCopy() *MachineConfigPoolSta
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolUpdateStrategy) DeepCopyInto(out *MachineConfigPoolUpdateStrategy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(MachineConfigPoolCanaryStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxUnavailablePerZone != nil {
+		in, out := &in.MaxUnavailablePerZone, &out.MaxUnavailablePerZone
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DrainPolicy != nil {
+		in, out := &in.DrainPolicy, &out.DrainPolicy
+		*out = new(DrainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolUpdateStrategy.
+func (in *MachineConfigPoolUpdateStrategy) DeepCopy() *MachineConfigPoolUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MachineConfigSpec) DeepCopyInto(out *MachineConfigSpec) {
 	*out = *in
@@ -678,6 +1290,11 @@ func (in *MachineConfigSpec) DeepCopyInto(out *MachineConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -690,3 +1307,953 @@ func (in *MachineConfigSpec) DeepCopy() *MachineConfigSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryReservation) DeepCopyInto(out *MemoryReservation) {
+	*out = *in
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryReservation.
+func (in *MemoryReservation) DeepCopy() *MemoryReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDNS) DeepCopyInto(out *NodeDNS) {
+	*out = *in
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Searches != nil {
+		in, out := &in.Searches, &out.Searches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDNS.
+func (in *NodeDNS) DeepCopy() *NodeDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDisruptionAction) DeepCopyInto(out *NodeDisruptionAction) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDisruptionAction.
+func (in *NodeDisruptionAction) DeepCopy() *NodeDisruptionAction {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDisruptionAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDisruptionPolicy) DeepCopyInto(out *NodeDisruptionPolicy) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]NodeDisruptionPolicyFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Units != nil {
+		in, out := &in.Units, &out.Units
+		*out = make([]NodeDisruptionPolicyUnit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDisruptionPolicy.
+func (in *NodeDisruptionPolicy) DeepCopy() *NodeDisruptionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDisruptionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDisruptionPolicyFile) DeepCopyInto(out *NodeDisruptionPolicyFile) {
+	*out = *in
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]NodeDisruptionAction, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDisruptionPolicyFile.
+func (in *NodeDisruptionPolicyFile) DeepCopy() *NodeDisruptionPolicyFile {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDisruptionPolicyFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeDisruptionPolicyUnit) DeepCopyInto(out *NodeDisruptionPolicyUnit) {
+	*out = *in
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]NodeDisruptionAction, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeDisruptionPolicyUnit.
+func (in *NodeDisruptionPolicyUnit) DeepCopy() *NodeDisruptionPolicyUnit {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeDisruptionPolicyUnit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMachineConfigState) DeepCopyInto(out *NodeMachineConfigState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMachineConfigState.
+func (in *NodeMachineConfigState) DeepCopy() *NodeMachineConfigState {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMachineConfigState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeMachineConfigState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMachineConfigStateList) DeepCopyInto(out *NodeMachineConfigStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeMachineConfigState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMachineConfigStateList.
+func (in *NodeMachineConfigStateList) DeepCopy() *NodeMachineConfigStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMachineConfigStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeMachineConfigStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMachineConfigStateSpec) DeepCopyInto(out *NodeMachineConfigStateSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMachineConfigStateSpec.
+func (in *NodeMachineConfigStateSpec) DeepCopy() *NodeMachineConfigStateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMachineConfigStateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMachineConfigStateStatus) DeepCopyInto(out *NodeMachineConfigStateStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.UpdateStartTime != nil {
+		in, out := &in.UpdateStartTime, &out.UpdateStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.UpdateCompletionTime != nil {
+		in, out := &in.UpdateCompletionTime, &out.UpdateCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMachineConfigStateStatus.
+func (in *NodeMachineConfigStateStatus) DeepCopy() *NodeMachineConfigStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMachineConfigStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSSHKeySet) DeepCopyInto(out *NodeSSHKeySet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSSHKeySet.
+func (in *NodeSSHKeySet) DeepCopy() *NodeSSHKeySet {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSSHKeySet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeSSHKeySet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSSHKeySetCondition) DeepCopyInto(out *NodeSSHKeySetCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSSHKeySetCondition.
+func (in *NodeSSHKeySetCondition) DeepCopy() *NodeSSHKeySetCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSSHKeySetCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSSHKeySetList) DeepCopyInto(out *NodeSSHKeySetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeSSHKeySet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSSHKeySetList.
+func (in *NodeSSHKeySetList) DeepCopy() *NodeSSHKeySetList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSSHKeySetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeSSHKeySetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSSHKeySetSpec) DeepCopyInto(out *NodeSSHKeySetSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuthorizedKeys != nil {
+		in, out := &in.AuthorizedKeys, &out.AuthorizedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSSHKeySetSpec.
+func (in *NodeSSHKeySetSpec) DeepCopy() *NodeSSHKeySetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSSHKeySetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeSSHKeySetStatus) DeepCopyInto(out *NodeSSHKeySetStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Fingerprints != nil {
+		in, out := &in.Fingerprints, &out.Fingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NodeSSHKeySetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeSSHKeySetStatus.
+func (in *NodeSSHKeySetStatus) DeepCopy() *NodeSSHKeySetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeSSHKeySetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTuningConfig) DeepCopyInto(out *NodeTuningConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTuningConfig.
+func (in *NodeTuningConfig) DeepCopy() *NodeTuningConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTuningConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeTuningConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTuningConfigCondition) DeepCopyInto(out *NodeTuningConfigCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTuningConfigCondition.
+func (in *NodeTuningConfigCondition) DeepCopy() *NodeTuningConfigCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTuningConfigCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTuningConfigList) DeepCopyInto(out *NodeTuningConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeTuningConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTuningConfigList.
+func (in *NodeTuningConfigList) DeepCopy() *NodeTuningConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTuningConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeTuningConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTuningConfigSpec) DeepCopyInto(out *NodeTuningConfigSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sysctls != nil {
+		in, out := &in.Sysctls, &out.Sysctls
+		*out = make([]Sysctl, len(*in))
+		copy(*out, *in)
+	}
+	if in.KernelArguments != nil {
+		in, out := &in.KernelArguments, &out.KernelArguments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTuningConfigSpec.
+func (in *NodeTuningConfigSpec) DeepCopy() *NodeTuningConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTuningConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTuningConfigStatus) DeepCopyInto(out *NodeTuningConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NodeTuningConfigCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTuningConfigStatus.
+func (in *NodeTuningConfigStatus) DeepCopy() *NodeTuningConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTuningConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSImageAllowList) DeepCopyInto(out *OSImageAllowList) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSImageAllowList.
+func (in *OSImageAllowList) DeepCopy() *OSImageAllowList {
+	if in == nil {
+		return nil
+	}
+	out := new(OSImageAllowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnPremNetworking) DeepCopyInto(out *OnPremNetworking) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnPremNetworking.
+func (in *OnPremNetworking) DeepCopy() *OnPremNetworking {
+	if in == nil {
+		return nil
+	}
+	out := new(OnPremNetworking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OnPremNetworking) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnPremNetworkingCondition) DeepCopyInto(out *OnPremNetworkingCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnPremNetworkingCondition.
+func (in *OnPremNetworkingCondition) DeepCopy() *OnPremNetworkingCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(OnPremNetworkingCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnPremNetworkingList) DeepCopyInto(out *OnPremNetworkingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OnPremNetworking, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnPremNetworkingList.
+func (in *OnPremNetworkingList) DeepCopy() *OnPremNetworkingList {
+	if in == nil {
+		return nil
+	}
+	out := new(OnPremNetworkingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OnPremNetworkingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnPremNetworkingSpec) DeepCopyInto(out *OnPremNetworkingSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Keepalived.DeepCopyInto(&out.Keepalived)
+	in.CoreDNS.DeepCopyInto(&out.CoreDNS)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnPremNetworkingSpec.
+func (in *OnPremNetworkingSpec) DeepCopy() *OnPremNetworkingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OnPremNetworkingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnPremNetworkingStatus) DeepCopyInto(out *OnPremNetworkingStatus) {
+	*out = *in
+	if in.StaticPods != nil {
+		in, out := &in.StaticPods, &out.StaticPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]OnPremNetworkingCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnPremNetworkingStatus.
+func (in *OnPremNetworkingStatus) DeepCopy() *OnPremNetworkingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OnPremNetworkingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnPremStaticPod) DeepCopyInto(out *OnPremStaticPod) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnPremStaticPod.
+func (in *OnPremStaticPod) DeepCopy() *OnPremStaticPod {
+	if in == nil {
+		return nil
+	}
+	out := new(OnPremStaticPod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostUpdateHealthCheck) DeepCopyInto(out *PostUpdateHealthCheck) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]PostUpdateHealthCheckType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostUpdateHealthCheck.
+func (in *PostUpdateHealthCheck) DeepCopy() *PostUpdateHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(PostUpdateHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebootStrategy) DeepCopyInto(out *RebootStrategy) {
+	*out = *in
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebootStrategy.
+func (in *RebootStrategy) DeepCopy() *RebootStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RebootStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistrySignaturePolicy) DeepCopyInto(out *RegistrySignaturePolicy) {
+	*out = *in
+	if in.GPGKeys != nil {
+		in, out := &in.GPGKeys, &out.GPGKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrySignaturePolicy.
+func (in *RegistrySignaturePolicy) DeepCopy() *RegistrySignaturePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistrySignaturePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubControllerTuning) DeepCopyInto(out *SubControllerTuning) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubControllerTuning.
+func (in *SubControllerTuning) DeepCopy() *SubControllerTuning {
+	if in == nil {
+		return nil
+	}
+	out := new(SubControllerTuning)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Sysctl) DeepCopyInto(out *Sysctl) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Sysctl.
+func (in *Sysctl) DeepCopy() *Sysctl {
+	if in == nil {
+		return nil
+	}
+	out := new(Sysctl)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSource) DeepCopyInto(out *TimeSource) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSource.
+func (in *TimeSource) DeepCopy() *TimeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSync) DeepCopyInto(out *TimeSync) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSync.
+func (in *TimeSync) DeepCopy() *TimeSync {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TimeSync) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSyncCondition) DeepCopyInto(out *TimeSyncCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSyncCondition.
+func (in *TimeSyncCondition) DeepCopy() *TimeSyncCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSyncCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSyncList) DeepCopyInto(out *TimeSyncList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TimeSync, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSyncList.
+func (in *TimeSyncList) DeepCopy() *TimeSyncList {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSyncList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TimeSyncList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSyncSpec) DeepCopyInto(out *TimeSyncSpec) {
+	*out = *in
+	if in.MachineConfigPoolSelector != nil {
+		in, out := &in.MachineConfigPoolSelector, &out.MachineConfigPoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]TimeSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]TimeSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSyncSpec.
+func (in *TimeSyncSpec) DeepCopy() *TimeSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSyncStatus) DeepCopyInto(out *TimeSyncStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]TimeSyncCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSyncStatus.
+func (in *TimeSyncStatus) DeepCopy() *TimeSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,368 @@
+package acceleratorconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times the AcceleratorConfigs will be retried before they are dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// the AcceleratorConfigs are going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+
+	// acceleratorConfigsKey is the only key of the queue: the AcceleratorConfigs
+	// of all the pools are synced together since each pool merges several.
+	acceleratorConfigsKey = "accelerator-configs"
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the accelerator config controller. It merges the
+// AcceleratorConfigs selecting a pool into a single MachineConfig of the pool,
+// so that device operators don't write MachineConfigs colliding with each other.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+
+	acLister       mcfglistersv1.AcceleratorConfigLister
+	acListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new accelerator config controller
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	acInformer mcfginformersv1.AcceleratorConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-acceleratorconfigcontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-acceleratorconfigcontroller"),
+	}
+
+	acInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addAcceleratorConfig,
+		UpdateFunc: ctrl.updateAcceleratorConfig,
+		DeleteFunc: ctrl.deleteAcceleratorConfig,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigPool,
+		UpdateFunc: ctrl.updateMachineConfigPool,
+		DeleteFunc: ctrl.deleteMachineConfigPool,
+	})
+
+	ctrl.syncHandler = ctrl.syncAcceleratorConfigs
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.acLister = acInformer.Lister()
+	ctrl.acListerSynced = acInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the accelerator config controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.acListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-AcceleratorConfigController")
+	defer glog.Info("Shutting down MachineConfigController-AcceleratorConfigController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-AcceleratorConfigController", ctrl.queue)
+}
+
+func (ctrl *Controller) addAcceleratorConfig(obj interface{}) {
+	cfg := obj.(*mcfgv1.AcceleratorConfig)
+	glog.V(4).Infof("Adding AcceleratorConfig %s", cfg.Name)
+	ctrl.enqueue()
+}
+
+func (ctrl *Controller) updateAcceleratorConfig(old, cur interface{}) {
+	oldConfig := old.(*mcfgv1.AcceleratorConfig)
+	newConfig := cur.(*mcfgv1.AcceleratorConfig)
+
+	if !reflect.DeepEqual(oldConfig.Spec, newConfig.Spec) {
+		glog.V(4).Infof("Update AcceleratorConfig %s", oldConfig.Name)
+		ctrl.enqueue()
+	}
+}
+
+func (ctrl *Controller) deleteAcceleratorConfig(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.AcceleratorConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.AcceleratorConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a AcceleratorConfig %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleting AcceleratorConfig %s", cfg.Name)
+	ctrl.enqueue()
+}
+
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	ctrl.enqueue()
+}
+
+// updateMachineConfigPool only requeues when the labels of the pool, which the
+// AcceleratorConfigs select it by, change.
+func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	if !reflect.DeepEqual(oldPool.Labels, curPool.Labels) {
+		ctrl.enqueue()
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
+	ctrl.enqueue()
+}
+
+func (ctrl *Controller) enqueue() {
+	ctrl.queue.Add(acceleratorConfigsKey)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.AcceleratorConfigControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing AcceleratorConfigs: %v", err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping AcceleratorConfigs out of the queue: %v", err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncAcceleratorConfigs merges the AcceleratorConfigs of each pool, in the
+// order of their names, into its MachineConfig, and deletes the MachineConfigs
+// of the pools without any. An AcceleratorConfig which is invalid, or conflicts
+// with the ones merged before it, is left out as a whole.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncAcceleratorConfigs(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing AcceleratorConfigs (%v)", startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing AcceleratorConfigs (%v)", time.Since(startTime))
+	}()
+
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	cfgs, err := ctrl.acLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	sort.Slice(cfgs, func(i, j int) bool { return cfgs[i].Name < cfgs[j].Name })
+
+	results := make(map[string]error)
+	selectors := make(map[string]labels.Selector)
+	var valid []*mcfgv1.AcceleratorConfig
+	for _, cfg := range cfgs {
+		if cfg.DeletionTimestamp != nil {
+			continue
+		}
+		if err := validateAcceleratorConfig(cfg); err != nil {
+			results[cfg.Name] = err
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cfg.Spec.MachineConfigPoolSelector)
+		if err != nil {
+			results[cfg.Name] = fmt.Errorf("invalid label selector: %v", err)
+			continue
+		}
+		selectors[cfg.Name] = selector
+		valid = append(valid, cfg)
+	}
+
+	applied := make(map[string][]string)
+	managed := make(map[string]bool)
+	var errs []error
+	for _, pool := range pools {
+		var acc poolAccelerators
+		for _, cfg := range valid {
+			// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
+			selector := selectors[cfg.Name]
+			if selector.Empty() || !selector.Matches(labels.Set(pool.Labels)) {
+				continue
+			}
+			if err := acc.tryAdd(cfg); err != nil {
+				if results[cfg.Name] == nil {
+					results[cfg.Name] = fmt.Errorf("MachineConfigPool %s: %v", pool.Name, err)
+				}
+			}
+		}
+		if len(acc.sources) == 0 {
+			continue
+		}
+		managedKey := getManagedAcceleratorsKey(pool.Name)
+		managed[managedKey] = true
+		if err := ctrl.applyManagedConfig(pool, &acc); err != nil {
+			for _, name := range acc.sources {
+				if results[name] == nil {
+					results[name] = err
+				}
+			}
+			if _, ok := err.(*ctrlcommon.ForgetError); !ok {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, name := range acc.sources {
+			applied[name] = append(applied[name], pool.Name)
+		}
+	}
+
+	if err := ctrlcommon.DeletePoolConfigs(ctrl.client, ctrlcommon.AcceleratorConfigsAnnotationKey, managed); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, cfg := range cfgs {
+		if cfg.DeletionTimestamp != nil {
+			continue
+		}
+		if results[cfg.Name] == nil && len(applied[cfg.Name]) == 0 {
+			results[cfg.Name] = fmt.Errorf("AcceleratorConfig %s does not match any MachineConfigPools", cfg.Name)
+		}
+		ctrl.syncStatusOnly(cfg, applied[cfg.Name], results[cfg.Name])
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// applyManagedConfig creates or updates the MachineConfig of pool holding the
+// AcceleratorConfigs merged into acc.
+func (ctrl *Controller) applyManagedConfig(pool *mcfgv1.MachineConfigPool, acc *poolAccelerators) error {
+	mc, err := mtmpl.MachineConfigFromIgnConfig(pool.Name, getManagedAcceleratorsKey(pool.Name), createNewAcceleratorIgnition(acc.modules))
+	if err != nil {
+		return err
+	}
+	spec := acc.machineConfigSpec()
+	mc.Spec.KernelArguments = spec.KernelArguments
+	mc.Spec.Extensions = spec.Extensions
+	if err := ctrlcommon.ApplyPoolConfig(ctrl.client, mc, ctrlcommon.AcceleratorConfigsAnnotationKey, acc.sources); err != nil {
+		return err
+	}
+	glog.Infof("Applied AcceleratorConfigs %s on MachineConfigPool %s", strings.Join(acc.sources, ", "), pool.Name)
+	return nil
+}
+
+// syncStatusOnly records the pools cfg is applied to and the outcome of the
+// sync, unless they didn't change since the last one.
+func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.AcceleratorConfig, pools []string, err error) {
+	condition := wrapErrorWithCondition(err)
+	if cfg.Status.ObservedGeneration == cfg.Generation && reflect.DeepEqual(cfg.Status.Pools, pools) {
+		if n := len(cfg.Status.Conditions); n > 0 && cfg.Status.Conditions[n-1].Type == condition.Type && cfg.Status.Conditions[n-1].Message == condition.Message {
+			return
+		}
+	}
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.acLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = cfg.Generation
+		newcfg.Status.Pools = pools
+		mcfgv1.SetSyncCondition(&newcfg.Status.Conditions, condition)
+		_, lerr := ctrl.client.MachineconfigurationV1().AcceleratorConfigs().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating AcceleratorConfig %s status: %v", cfg.Name, statusUpdateError)
+	}
+}
@@ -0,0 +1,123 @@
+package acceleratorconfig
+
+import (
+	"context"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newAcceleratorConfig(name string, spec mcfgv1.AcceleratorConfigSpec, selector *metav1.LabelSelector) *mcfgv1.AcceleratorConfig {
+	spec.MachineConfigPoolSelector = selector
+	return &mcfgv1.AcceleratorConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
+		Spec:       spec,
+	}
+}
+
+func newController(t *testing.T, pools []*mcfgv1.MachineConfigPool, cfgs []*mcfgv1.AcceleratorConfig, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	for _, p := range pools {
+		objects = append(objects, p)
+	}
+	for _, cfg := range cfgs {
+		objects = append(objects, cfg)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		i.Machineconfiguration().V1().AcceleratorConfigs(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.eventRecorder = &record.FakeRecorder{}
+	helpers.StartInformers(t, i)
+	return c, client
+}
+
+func TestAcceleratorConfigsMerged(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["accelerators"] = "gpu"
+	mcp2 := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "accelerators", "gpu")
+	gpu := newAcceleratorConfig("gpu-operator", mcfgv1.AcceleratorConfigSpec{
+		KernelArguments:    []string{"intel_iommu=on"},
+		Extensions:         []string{"kernel-devel"},
+		BlacklistedModules: []string{"nouveau"},
+	}, selector)
+	nic := newAcceleratorConfig("sriov-operator", mcfgv1.AcceleratorConfigSpec{
+		KernelArguments: []string{"intel_iommu=on", "iommu=pt"},
+	}, selector)
+	conflicting := newAcceleratorConfig("zz-operator", mcfgv1.AcceleratorConfigSpec{
+		KernelArguments: []string{"default_hugepagesz=1G", "default_hugepagesz=2M"},
+	}, selector)
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp, mcp2}, []*mcfgv1.AcceleratorConfig{nic, gpu, conflicting})
+	require.Nil(t, c.syncHandler(acceleratorConfigsKey))
+
+	rendered, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedAcceleratorsKey(mcp.Name), metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "worker", rendered.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "gpu-operator,sriov-operator", rendered.Annotations[ctrlcommon.AcceleratorConfigsAnnotationKey])
+	assert.Equal(t, []string{"intel_iommu=on", "iommu=pt", "rd.driver.blacklist=nouveau"}, rendered.Spec.KernelArguments)
+	assert.Equal(t, []string{"kernel-devel"}, rendered.Spec.Extensions)
+
+	ignCfg, _, err := ign.Parse(rendered.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 1)
+	assert.Equal(t, blacklistConfigPath, ignCfg.Storage.Files[0].Path)
+	contents, err := dataurl.DecodeString(ignCfg.Storage.Files[0].Contents.Source)
+	require.Nil(t, err)
+	assert.Contains(t, string(contents.Data), "blacklist nouveau\n")
+
+	// The master pool isn't selected
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedAcceleratorsKey(mcp2.Name), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	gpu, err = client.MachineconfigurationV1().AcceleratorConfigs().Get(context.TODO(), gpu.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"worker"}, gpu.Status.Pools)
+	require.Len(t, gpu.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.AcceleratorConfigSuccess, gpu.Status.Conditions[0].Type)
+
+	conflicting, err = client.MachineconfigurationV1().AcceleratorConfigs().Get(context.TODO(), conflicting.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, conflicting.Status.Pools)
+	require.Len(t, conflicting.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.AcceleratorConfigFailure, conflicting.Status.Conditions[0].Type)
+}
+
+func TestAcceleratorConfigsStaleConfigDeleted(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	stale := helpers.NewMachineConfig(getManagedAcceleratorsKey("gpu"), map[string]string{mcfgv1.MachineConfigRoleLabelKey: "gpu"}, "", nil)
+	stale.Annotations = map[string]string{ctrlcommon.AcceleratorConfigsAnnotationKey: "gpu-operator"}
+	other := helpers.NewMachineConfig(getManagedAcceleratorsKey("infra"), map[string]string{mcfgv1.MachineConfigRoleLabelKey: "infra"}, "", nil)
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, nil, stale, other)
+	require.Nil(t, c.syncHandler(acceleratorConfigsKey))
+
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), stale.Name, metav1.GetOptions{})
+	assert.NotNil(t, err)
+	// a MachineConfig of the same name not written by the controller is left alone
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), other.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+}
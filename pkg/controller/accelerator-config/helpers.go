@@ -0,0 +1,147 @@
+package acceleratorconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const blacklistConfigPath = "/etc/modprobe.d/accelerator-blacklist.conf"
+
+// moduleNameRegex matches a kernel module name.
+var moduleNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// validateAcceleratorConfig returns an error if the AcceleratorConfig requests
+// nothing, an invalid module name, or kernel arguments or extensions which a
+// MachineConfig couldn't set.
+func validateAcceleratorConfig(cfg *mcfgv1.AcceleratorConfig) error {
+	if len(cfg.Spec.KernelArguments) == 0 && len(cfg.Spec.Extensions) == 0 && len(cfg.Spec.BlacklistedModules) == 0 {
+		return fmt.Errorf("AcceleratorConfig: at least one kernel argument, extension or blacklisted module must be set")
+	}
+	for _, module := range cfg.Spec.BlacklistedModules {
+		if !moduleNameRegex.MatchString(module) {
+			return fmt.Errorf("AcceleratorConfig: invalid module name %q", module)
+		}
+	}
+	var acc poolAccelerators
+	acc.add(cfg)
+	if err := ctrlcommon.ValidateMachineConfig(acc.machineConfigSpec()); err != nil {
+		return fmt.Errorf("AcceleratorConfig: %v", err)
+	}
+	return nil
+}
+
+// poolAccelerators merges the AcceleratorConfigs of a pool, in the order they're
+// added, without duplicates.
+type poolAccelerators struct {
+	kernelArguments []string
+	extensions      []string
+	modules         []string
+	sources         []string
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func (a *poolAccelerators) add(cfg *mcfgv1.AcceleratorConfig) {
+	a.kernelArguments = appendUnique(a.kernelArguments, cfg.Spec.KernelArguments...)
+	a.extensions = appendUnique(a.extensions, cfg.Spec.Extensions...)
+	a.modules = appendUnique(a.modules, cfg.Spec.BlacklistedModules...)
+	a.sources = append(a.sources, cfg.Name)
+}
+
+// tryAdd adds cfg unless the result isn't a valid MachineConfig, e.g. when cfg
+// and a previous AcceleratorConfig set huge pages of different sizes.
+func (a *poolAccelerators) tryAdd(cfg *mcfgv1.AcceleratorConfig) error {
+	merged := poolAccelerators{
+		kernelArguments: append([]string{}, a.kernelArguments...),
+		extensions:      append([]string{}, a.extensions...),
+		modules:         append([]string{}, a.modules...),
+		sources:         append([]string{}, a.sources...),
+	}
+	merged.add(cfg)
+	if err := ctrlcommon.ValidateMachineConfig(merged.machineConfigSpec()); err != nil {
+		return fmt.Errorf("conflicts with %s: %v", strings.Join(a.sources, ", "), err)
+	}
+	*a = merged
+	return nil
+}
+
+// machineConfigSpec returns the kernel arguments and extensions of the
+// MachineConfig. The blacklisted modules are also kept out of the initramfs
+// with rd.driver.blacklist.
+func (a *poolAccelerators) machineConfigSpec() mcfgv1.MachineConfigSpec {
+	kargs := append([]string{}, a.kernelArguments...)
+	for _, module := range a.modules {
+		kargs = appendUnique(kargs, "rd.driver.blacklist="+module)
+	}
+	return mcfgv1.MachineConfigSpec{
+		KernelArguments: kargs,
+		Extensions:      append([]string{}, a.extensions...),
+	}
+}
+
+// renderBlacklist returns the modprobe configuration blacklisting modules.
+func renderBlacklist(modules []string) []byte {
+	var b strings.Builder
+	b.WriteString("# Kernel modules blacklisted by AcceleratorConfigs\n")
+	for _, module := range modules {
+		fmt.Fprintf(&b, "blacklist %s\n", module)
+	}
+	return []byte(b.String())
+}
+
+// createNewAcceleratorIgnition returns an Ignition config writing the modprobe
+// configuration blacklisting modules, if any.
+func createNewAcceleratorIgnition(modules []string) igntypes.Config {
+	tempIgnConfig := ctrlcommon.NewIgnConfig()
+	if len(modules) == 0 {
+		return tempIgnConfig
+	}
+	mode := 0644
+	du := dataurl.New(renderBlacklist(modules), "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, igntypes.File{
+		Node: igntypes.Node{
+			Filesystem: "root",
+			Path:       blacklistConfigPath,
+		},
+		FileEmbedded1: igntypes.FileEmbedded1{
+			Mode: &mode,
+			Contents: igntypes.FileContents{
+				Source: du.String(),
+			},
+		},
+	})
+	return tempIgnConfig
+}
+
+// getManagedAcceleratorsKey returns the name of the MachineConfig holding the
+// AcceleratorConfigs of the pool. It sorts after the MachineConfigs of the MCO.
+func getManagedAcceleratorsKey(pool string) string {
+	return fmt.Sprintf("99-%s-accelerators", pool)
+}
+
+func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.AcceleratorConfigCondition {
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewAcceleratorConfigCondition(mcfgv1.AcceleratorConfigStatusConditionType(condition.Type), condition.Status, condition.Message)
+}
@@ -0,0 +1,81 @@
+package acceleratorconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestValidateAcceleratorConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    mcfgv1.AcceleratorConfigSpec
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name: "kernel arguments, extensions and modules",
+			spec: mcfgv1.AcceleratorConfigSpec{
+				KernelArguments:    []string{"intel_iommu=on", "iommu=pt"},
+				Extensions:         []string{"kernel-devel"},
+				BlacklistedModules: []string{"nouveau"},
+			},
+		},
+		{
+			name:    "unsupported extension",
+			spec:    mcfgv1.AcceleratorConfigSpec{Extensions: []string{"nvidia-driver"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid module name",
+			spec:    mcfgv1.AcceleratorConfigSpec{BlacklistedModules: []string{"nouveau\ninstall nvidia /bin/sh"}},
+			wantErr: true,
+		},
+		{
+			name:    "conflicting kernel arguments",
+			spec:    mcfgv1.AcceleratorConfigSpec{KernelArguments: []string{"default_hugepagesz=1G", "default_hugepagesz=2M"}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateAcceleratorConfig(&mcfgv1.AcceleratorConfig{Spec: test.spec})
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPoolAccelerators(t *testing.T) {
+	var acc poolAccelerators
+	require.NoError(t, acc.tryAdd(newAcceleratorConfig("gpu", mcfgv1.AcceleratorConfigSpec{
+		KernelArguments:    []string{"intel_iommu=on", "default_hugepagesz=1G"},
+		BlacklistedModules: []string{"nouveau"},
+	}, nil)))
+	require.NoError(t, acc.tryAdd(newAcceleratorConfig("nic", mcfgv1.AcceleratorConfigSpec{
+		KernelArguments: []string{"intel_iommu=on", "iommu=pt"},
+		Extensions:      []string{"kernel-devel"},
+	}, nil)))
+	// there is only one default huge page size
+	err := acc.tryAdd(newAcceleratorConfig("fpga", mcfgv1.AcceleratorConfigSpec{
+		KernelArguments: []string{"default_hugepagesz=2M"},
+		Extensions:      []string{"usbguard"},
+	}, nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "conflicts with gpu, nic")
+
+	assert.Equal(t, []string{"gpu", "nic"}, acc.sources)
+	spec := acc.machineConfigSpec()
+	assert.Equal(t, []string{"intel_iommu=on", "default_hugepagesz=1G", "iommu=pt", "rd.driver.blacklist=nouveau"}, spec.KernelArguments)
+	assert.Equal(t, []string{"kernel-devel"}, spec.Extensions)
+	assert.Equal(t, "# Kernel modules blacklisted by AcceleratorConfigs\nblacklist nouveau\n", string(renderBlacklist(acc.modules)))
+}
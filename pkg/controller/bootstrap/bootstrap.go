@@ -46,11 +46,6 @@ func New(templatesDir, manifestDir, pullSecretFile string) *Bootstrap {
 // Run runs boostrap for Machine Config Controller
 // It writes all the assets to destDir
 func (b *Bootstrap) Run(destDir string) error {
-	infos, err := ioutil.ReadDir(b.manifestDir)
-	if err != nil {
-		return err
-	}
-
 	psfraw, err := ioutil.ReadFile(b.pullSecretFile)
 	if err != nil {
 		return err
@@ -61,57 +56,11 @@ func (b *Bootstrap) Run(destDir string) error {
 		return err
 	}
 
-	scheme := runtime.NewScheme()
-	mcfgv1.Install(scheme)
-	apioperatorsv1alpha1.Install(scheme)
-	codecFactory := serializer.NewCodecFactory(scheme)
-	decoder := codecFactory.UniversalDecoder(mcfgv1.GroupVersion, apioperatorsv1alpha1.GroupVersion)
-
-	var cconfig *mcfgv1.ControllerConfig
-	var pools []*mcfgv1.MachineConfigPool
-	var configs []*mcfgv1.MachineConfig
-	var icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy
-	for _, info := range infos {
-		if info.IsDir() {
-			continue
-		}
-
-		file, err := os.Open(filepath.Join(b.manifestDir, info.Name()))
-		if err != nil {
-			return fmt.Errorf("error opening %s: %v", file.Name(), err)
-		}
-		defer file.Close()
-
-		manifests, err := parseManifests(file.Name(), file)
-		if err != nil {
-			return fmt.Errorf("error parsing manifests from %s: %v", file.Name(), err)
-		}
-
-		for idx, m := range manifests {
-			obji, err := runtime.Decode(decoder, m.Raw)
-			if err != nil {
-				if runtime.IsNotRegisteredError(err) {
-					// don't care
-					glog.V(4).Infof("skipping path %q [%d] manifest because it is not part of expected api group: %v", file.Name(), idx+1, err)
-					continue
-				}
-				return fmt.Errorf("error parsing %q [%d] manifest: %v", file.Name(), idx+1, err)
-			}
-
-			switch obj := obji.(type) {
-			case *mcfgv1.MachineConfigPool:
-				pools = append(pools, obj)
-			case *mcfgv1.MachineConfig:
-				configs = append(configs, obj)
-			case *mcfgv1.ControllerConfig:
-				cconfig = obj
-			case *apioperatorsv1alpha1.ImageContentSourcePolicy:
-				icspRules = append(icspRules, obj)
-			default:
-				glog.Infof("skipping %q [%d] manifest because of unhandled %T", file.Name(), idx+1, obji)
-			}
-		}
+	m, err := readManifests(b.manifestDir)
+	if err != nil {
+		return err
 	}
+	cconfig, pools, configs, icspRules := m.cconfig, m.pools, m.configs, m.icspRules
 
 	if cconfig == nil {
 		return fmt.Errorf("error: no controllerconfig found in dir: %q", destDir)
@@ -133,8 +82,7 @@ func (b *Bootstrap) Run(destDir string) error {
 		return err
 	}
 
-	serializer := json.NewYAMLSerializer(json.DefaultMetaFactory, scheme, scheme)
-	encoder := codecFactory.EncoderForVersion(serializer, mcfgv1.GroupVersion)
+	encoder := newYAMLEncoder()
 
 	poolsdir := filepath.Join(destDir, "machine-pools")
 	if err := os.MkdirAll(poolsdir, 0764); err != nil {
@@ -170,6 +118,96 @@ func (b *Bootstrap) Run(destDir string) error {
 	return nil
 }
 
+// manifests are the objects of a manifest directory the MCC renders.
+type manifests struct {
+	cconfig   *mcfgv1.ControllerConfig
+	pools     []*mcfgv1.MachineConfigPool
+	configs   []*mcfgv1.MachineConfig
+	icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy
+}
+
+var (
+	manifestScheme = runtime.NewScheme()
+	manifestCodecs = serializer.NewCodecFactory(manifestScheme)
+)
+
+func init() {
+	mcfgv1.Install(manifestScheme)
+	apioperatorsv1alpha1.Install(manifestScheme)
+}
+
+// newYAMLEncoder returns an encoder writing objects as YAML.
+func newYAMLEncoder() runtime.Encoder {
+	yamlSerializer := json.NewYAMLSerializer(json.DefaultMetaFactory, manifestScheme, manifestScheme)
+	return manifestCodecs.EncoderForVersion(yamlSerializer, mcfgv1.GroupVersion)
+}
+
+// readManifests reads the objects of the files of dir. Objects of other kinds
+// are skipped.
+func readManifests(dir string) (*manifests, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifests{}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		objs, err := readManifestFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, obji := range objs {
+			switch obj := obji.(type) {
+			case *mcfgv1.MachineConfigPool:
+				m.pools = append(m.pools, obj)
+			case *mcfgv1.MachineConfig:
+				m.configs = append(m.configs, obj)
+			case *mcfgv1.ControllerConfig:
+				m.cconfig = obj
+			case *apioperatorsv1alpha1.ImageContentSourcePolicy:
+				m.icspRules = append(m.icspRules, obj)
+			default:
+				glog.Infof("skipping %q manifest because of unhandled %T", info.Name(), obji)
+			}
+		}
+	}
+	return m, nil
+}
+
+// readManifestFile decodes the objects of the file at path which are part of
+// the machineconfiguration or operator API groups.
+func readManifestFile(path string) ([]runtime.Object, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", path, err)
+	}
+	defer file.Close()
+
+	manifests, err := parseManifests(file.Name(), file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifests from %s: %v", file.Name(), err)
+	}
+
+	decoder := manifestCodecs.UniversalDecoder(mcfgv1.GroupVersion, apioperatorsv1alpha1.GroupVersion)
+	var objs []runtime.Object
+	for idx, m := range manifests {
+		obji, err := runtime.Decode(decoder, m.Raw)
+		if err != nil {
+			if runtime.IsNotRegisteredError(err) {
+				// don't care
+				glog.V(4).Infof("skipping path %q [%d] manifest because it is not part of expected api group: %v", file.Name(), idx+1, err)
+				continue
+			}
+			return nil, fmt.Errorf("error parsing %q [%d] manifest: %v", file.Name(), idx+1, err)
+		}
+		objs = append(objs, obji)
+	}
+	return objs, nil
+}
+
 func getPullSecretFromSecret(sData []byte) ([]byte, error) {
 	obji, err := runtime.Decode(kscheme.Codecs.UniversalDecoder(corev1.SchemeGroupVersion), sData)
 	if err != nil {
@@ -0,0 +1,183 @@
+package bootstrap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/controller/render"
+)
+
+// Inspect renders offline the pools of manifestDir from its MachineConfigs, the
+// way the render controller does, and writes the rendered MachineConfig of pool,
+// or of all the pools when empty, to w. When previousFile holds a rendered
+// MachineConfig, the diff of the rendered config against it is written instead.
+func Inspect(manifestDir, pool, previousFile string, w io.Writer) error {
+	m, err := readManifests(manifestDir)
+	if err != nil {
+		return err
+	}
+	cconfig := m.cconfig
+	if cconfig == nil {
+		// only needed for the OS image, which MachineConfigs can set as well
+		cconfig = &mcfgv1.ControllerConfig{}
+	}
+
+	pools := m.pools
+	if pool != "" {
+		pools = nil
+		for _, p := range m.pools {
+			if p.Name == pool {
+				pools = append(pools, p)
+			}
+		}
+	}
+	if len(pools) == 0 {
+		return fmt.Errorf("no pool %q found in %s", pool, manifestDir)
+	}
+	if previousFile != "" && len(pools) > 1 {
+		return fmt.Errorf("a pool must be picked to diff against %s", previousFile)
+	}
+
+	_, rendered, err := render.RunBootstrap(pools, m.configs, cconfig)
+	if err != nil {
+		return err
+	}
+
+	if previousFile == "" {
+		encoder := newYAMLEncoder()
+		for i, config := range rendered {
+			if i > 0 {
+				fmt.Fprintln(w, "---")
+			}
+			if err := encoder.Encode(config, w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	previous, err := readMachineConfig(previousFile)
+	if err != nil {
+		return err
+	}
+	diff, err := diffMachineConfigs(previous, rendered[0])
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, diff)
+	return err
+}
+
+// readMachineConfig reads the MachineConfig of the file at path.
+func readMachineConfig(path string) (*mcfgv1.MachineConfig, error) {
+	objs, err := readManifestFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if config, ok := obj.(*mcfgv1.MachineConfig); ok {
+			return config, nil
+		}
+	}
+	return nil, fmt.Errorf("no MachineConfig found in %s", path)
+}
+
+// diffMachineConfigs returns the unified diff of the descriptions of oldConfig
+// and newConfig, empty when they apply the same changes to the machines.
+func diffMachineConfigs(oldConfig, newConfig *mcfgv1.MachineConfig) (string, error) {
+	oldDesc, err := describeMachineConfig(oldConfig)
+	if err != nil {
+		return "", fmt.Errorf("describing %s: %v", oldConfig.Name, err)
+	}
+	newDesc, err := describeMachineConfig(newConfig)
+	if err != nil {
+		return "", fmt.Errorf("describing %s: %v", newConfig.Name, err)
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(oldDesc),
+		B:        difflib.SplitLines(newDesc),
+		FromFile: oldConfig.Name,
+		ToFile:   newConfig.Name,
+		Context:  3,
+	})
+}
+
+// describeMachineConfig returns a text description of what config applies to
+// the machines, with the file contents decoded so that they diff line by line.
+func describeMachineConfig(config *mcfgv1.MachineConfig) (string, error) {
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(config.Spec.Config.Raw)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "osImageURL: %s\n", config.Spec.OSImageURL)
+	fmt.Fprintf(&b, "kernelType: %s\n", config.Spec.KernelType)
+	fmt.Fprintf(&b, "kernelArguments: %s\n", strings.Join(config.Spec.KernelArguments, " "))
+	fmt.Fprintf(&b, "extensions: %s\n", strings.Join(config.Spec.Extensions, " "))
+	fmt.Fprintf(&b, "fips: %t\n", config.Spec.FIPS)
+
+	users := ignConfig.Passwd.Users
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	for _, u := range users {
+		fmt.Fprintf(&b, "user %s:\n", u.Name)
+		for _, key := range u.SSHAuthorizedKeys {
+			fmt.Fprintf(&b, "  ssh key %s\n", key)
+		}
+	}
+
+	files := ignConfig.Storage.Files
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	for _, f := range files {
+		mode := "default"
+		if f.Mode != nil {
+			mode = fmt.Sprintf("%04o", *f.Mode)
+		}
+		fmt.Fprintf(&b, "file %s (mode %s):\n", f.Path, mode)
+		contents, err := dataurl.DecodeString(f.Contents.Source)
+		if err != nil {
+			return "", fmt.Errorf("decoding the contents of %s: %v", f.Path, err)
+		}
+		writeIndented(&b, string(contents.Data))
+	}
+
+	units := ignConfig.Systemd.Units
+	sort.Slice(units, func(i, j int) bool { return units[i].Name < units[j].Name })
+	for _, u := range units {
+		state := ""
+		if u.Enabled != nil && *u.Enabled {
+			state = " (enabled)"
+		} else if u.Enabled != nil {
+			state = " (disabled)"
+		}
+		if u.Mask {
+			state += " (masked)"
+		}
+		fmt.Fprintf(&b, "unit %s%s:\n", u.Name, state)
+		writeIndented(&b, u.Contents)
+		dropins := u.Dropins
+		sort.Slice(dropins, func(i, j int) bool { return dropins[i].Name < dropins[j].Name })
+		for _, d := range dropins {
+			fmt.Fprintf(&b, "unit %s dropin %s:\n", u.Name, d.Name)
+			writeIndented(&b, d.Contents)
+		}
+	}
+	return b.String(), nil
+}
+
+func writeIndented(b *bytes.Buffer, contents string) {
+	if contents == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(contents, "\n"), "\n") {
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+}
@@ -0,0 +1,46 @@
+package bootstrap
+
+import (
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newFile(path, contents string) igntypes.File {
+	mode := 0644
+	return igntypes.File{
+		Node: igntypes.Node{Filesystem: "root", Path: path},
+		FileEmbedded1: igntypes.FileEmbedded1{
+			Mode:     &mode,
+			Contents: igntypes.FileContents{Source: dataurl.EncodeBytes([]byte(contents))},
+		},
+	}
+}
+
+func TestDiffMachineConfigs(t *testing.T) {
+	oldConfig := helpers.NewMachineConfig("rendered-worker-old", nil, "example.com/os@sha256:aaaa", []igntypes.File{
+		newFile("/etc/chrony.conf", "pool 2.rhel.pool.ntp.org iburst\ndriftfile /var/lib/chrony/drift\n"),
+		newFile("/etc/motd", "hello\n"),
+	})
+	newConfig := helpers.NewMachineConfig("rendered-worker-new", nil, "example.com/os@sha256:aaaa", []igntypes.File{
+		newFile("/etc/motd", "hello\n"),
+		newFile("/etc/chrony.conf", "pool ntp.example.com iburst\ndriftfile /var/lib/chrony/drift\n"),
+	})
+
+	diff, err := diffMachineConfigs(oldConfig, oldConfig)
+	require.Nil(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = diffMachineConfigs(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.Contains(t, diff, "--- rendered-worker-old\n+++ rendered-worker-new\n")
+	assert.Contains(t, diff, "\n-  pool 2.rhel.pool.ntp.org iburst\n+  pool ntp.example.com iburst\n")
+	// the order of the files doesn't matter
+	assert.NotContains(t, diff, "-file /etc/motd")
+	assert.NotContains(t, diff, "+file /etc/motd")
+}
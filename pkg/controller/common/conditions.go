@@ -0,0 +1,48 @@
+package common
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SyncConditionSuccess is the type of the condition of a config synced successfully.
+	SyncConditionSuccess = "Success"
+	// SyncConditionFailure is the type of the condition of a config failing to sync.
+	SyncConditionFailure = "Failure"
+)
+
+// SyncCondition is the outcome of a sync of a config, e.g. a KubeletConfig.
+// The conditions of the configs only differ by the type of their Type, each
+// controller builds its own from it.
+type SyncCondition struct {
+	Type    string
+	Status  corev1.ConditionStatus
+	Message string
+}
+
+// NewSyncCondition returns a Failure condition with the message of err, or a
+// Success one when err is nil. args, when set, are the format and arguments
+// of the message instead.
+func NewSyncCondition(err error, args ...interface{}) SyncCondition {
+	condition := SyncCondition{
+		Type:    SyncConditionSuccess,
+		Status:  corev1.ConditionTrue,
+		Message: "Success",
+	}
+	if err != nil {
+		condition = SyncCondition{
+			Type:    SyncConditionFailure,
+			Status:  corev1.ConditionFalse,
+			Message: fmt.Sprintf("Error: %v", err),
+		}
+	}
+	if len(args) > 0 {
+		format, ok := args[0].(string)
+		if ok {
+			condition.Message = fmt.Sprintf(format, args[1:]...)
+		}
+	}
+	return condition
+}
@@ -0,0 +1,15 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewSyncCondition(t *testing.T) {
+	assert.Equal(t, SyncCondition{Type: SyncConditionSuccess, Status: corev1.ConditionTrue, Message: "Success"}, NewSyncCondition(nil))
+	assert.Equal(t, SyncCondition{Type: SyncConditionFailure, Status: corev1.ConditionFalse, Message: "Error: broken"}, NewSyncCondition(fmt.Errorf("broken")))
+	assert.Equal(t, "Error: pool worker: broken", NewSyncCondition(fmt.Errorf("broken"), "Error: pool %s: %v", "worker", "broken").Message)
+}
@@ -4,6 +4,44 @@ const (
 	// GeneratedByControllerVersionAnnotationKey is used to tag the machineconfigs generated by the controller with the version of the controller.
 	GeneratedByControllerVersionAnnotationKey = "machineconfiguration.openshift.io/generated-by-controller-version"
 
+	// AllowFileOverrideAnnotationKey is set to "true" on a MachineConfig to let its files
+	// replace the ones of the same path defined by other MachineConfigs of the pool.
+	AllowFileOverrideAnnotationKey = "machineconfiguration.openshift.io/allow-file-override"
+
+	// CanaryPausedAnnotationKey is set on a MachineConfigPool to the rendered MachineConfig
+	// whose canary phase paused the pool. Unpausing the pool promotes the canary.
+	CanaryPausedAnnotationKey = "machineconfiguration.openshift.io/canary-paused"
+
+	// SourceMachineConfigsAnnotationKey is set on the rendered MachineConfigs to the JSON list
+	// of the names and generations of the MachineConfigs merged into them.
+	SourceMachineConfigsAnnotationKey = "machineconfiguration.openshift.io/source-machine-configs"
+
+	// ConfigSnippetsNamespace is the namespace the ConfigMaps holding config snippets
+	// are read from. It isn't created by the MCO, creating it opts in to the snippets.
+	ConfigSnippetsNamespace = "openshift-machine-config-snippets"
+
+	// ConfigSnippetPoolLabelKey is set on a ConfigMap of ConfigSnippetsNamespace to the
+	// name of the MachineConfigPool its config snippet is applied to.
+	ConfigSnippetPoolLabelKey = "machineconfiguration.openshift.io/config-snippet-pool"
+
+	// ConfigSnippetsAnnotationKey is set on the MachineConfigs generated from config
+	// snippets to the comma separated names of their ConfigMaps, in merge order.
+	ConfigSnippetsAnnotationKey = "machineconfiguration.openshift.io/config-snippets"
+
+	// AcceleratorConfigsAnnotationKey is set on the MachineConfigs generated from
+	// AcceleratorConfigs to the comma separated names of the AcceleratorConfigs,
+	// in merge order.
+	AcceleratorConfigsAnnotationKey = "machineconfiguration.openshift.io/accelerator-configs"
+
+	// NodeSSHKeySetsAnnotationKey is set on the MachineConfigs generated from
+	// NodeSSHKeySets to the comma separated names of the NodeSSHKeySets,
+	// in the order their keys are listed.
+	NodeSSHKeySetsAnnotationKey = "machineconfiguration.openshift.io/node-ssh-key-sets"
+
+	// MCONamespace is the namespace of the MCO, holding the NodeMachineConfigStates
+	// of the nodes.
+	MCONamespace = "openshift-machine-config-operator"
+
 	// ControllerConfigName is the name of the ControllerConfig object that controllers use
 	ControllerConfigName = "machine-config-controller"
 
@@ -13,3 +51,11 @@ const (
 	// KernelTypeRealtime denominates the realtime kernel type
 	KernelTypeRealtime = "realtime"
 )
+
+// SupportedExtensions maps the extensions which can be requested through
+// MachineConfigSpec.Extensions to the packages they install.
+var SupportedExtensions = map[string][]string{
+	"kerberos":     {"krb5-workstation", "libkadm5"},
+	"kernel-devel": {"kernel-devel", "kernel-headers"},
+	"usbguard":     {"usbguard"},
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/golang/glog"
 	configinformers "github.com/openshift/client-go/config/informers/externalversions"
+	operatorclientset "github.com/openshift/client-go/operator/clientset/versioned"
 	operatorinformers "github.com/openshift/client-go/operator/informers/externalversions"
+	operatorinformersv1 "github.com/openshift/client-go/operator/informers/externalversions/operator/v1"
 	"github.com/openshift/machine-config-operator/internal/clients"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	mcfginformers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
@@ -22,10 +24,10 @@ const (
 	minResyncPeriod = 20 * time.Minute
 )
 
-func resyncPeriod() func() time.Duration {
+func resyncPeriod(min time.Duration) func() time.Duration {
 	return func() time.Duration {
 		factor := rand.Float64() + 1
-		return time.Duration(float64(minResyncPeriod.Nanoseconds()) * factor)
+		return time.Duration(float64(min.Nanoseconds()) * factor)
 	}
 }
 
@@ -39,6 +41,8 @@ type ControllerContext struct {
 	KubeNamespacedInformerFactory                       informers.SharedInformerFactory
 	OpenShiftConfigKubeNamespacedInformerFactory        informers.SharedInformerFactory
 	OpenShiftKubeAPIServerKubeNamespacedInformerFactory informers.SharedInformerFactory
+	MachineAPIKubeNamespacedInformerFactory             informers.SharedInformerFactory
+	ConfigSnippetsKubeNamespacedInformerFactory         informers.SharedInformerFactory
 	APIExtInformerFactory                               apiextinformers.SharedInformerFactory
 	ConfigInformerFactory                               configinformers.SharedInformerFactory
 	OperatorInformerFactory                             operatorinformers.SharedInformerFactory
@@ -54,24 +58,39 @@ type ControllerContext struct {
 
 // CreateControllerContext creates the ControllerContext with the ClientBuilder.
 func CreateControllerContext(cb *clients.Builder, stop <-chan struct{}, targetNamespace string) *ControllerContext {
+	return CreateControllerContextWithResyncPeriod(cb, stop, targetNamespace, minResyncPeriod)
+}
+
+// CreateControllerContextWithResyncPeriod creates the ControllerContext with the
+// ClientBuilder, with informers resyncing after minResync at least.
+func CreateControllerContextWithResyncPeriod(cb *clients.Builder, stop <-chan struct{}, targetNamespace string, minResync time.Duration) *ControllerContext {
+	resync := resyncPeriod(minResync)
 	client := cb.MachineConfigClientOrDie("machine-config-shared-informer")
 	kubeClient := cb.KubeClientOrDie("kube-shared-informer")
 	apiExtClient := cb.APIExtClientOrDie("apiext-shared-informer")
 	configClient := cb.ConfigClientOrDie("config-shared-informer")
 	operatorClient := cb.OperatorClientOrDie("operator-shared-informer")
-	sharedInformers := mcfginformers.NewSharedInformerFactory(client, resyncPeriod()())
-	sharedNamespacedInformers := mcfginformers.NewFilteredSharedInformerFactory(client, resyncPeriod()(), targetNamespace, nil)
-	kubeSharedInformer := informers.NewSharedInformerFactory(kubeClient, resyncPeriod()())
-	kubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resyncPeriod()(), targetNamespace, nil)
-	openShiftConfigKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resyncPeriod()(), "openshift-config", nil)
+	sharedInformers := mcfginformers.NewSharedInformerFactory(client, resync())
+	sharedNamespacedInformers := mcfginformers.NewFilteredSharedInformerFactory(client, resync(), targetNamespace, nil)
+	kubeSharedInformer := informers.NewSharedInformerFactory(kubeClient, resync())
+	kubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resync(), targetNamespace, nil)
+	openShiftConfigKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resync(), "openshift-config", nil)
 	openShiftKubeAPIServerKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient,
-		resyncPeriod()(),
+		resync(),
 		"openshift-kube-apiserver-operator",
 		func(opt *metav1.ListOptions) {
 			opt.FieldSelector = fields.OneTermEqualSelector("metadata.name", "kube-apiserver-to-kubelet-client-ca").String()
 		},
 	)
 
+	machineAPIKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resync(), "openshift-machine-api", nil)
+	// only the ConfigMaps labeled for a pool hold config snippets
+	configSnippetsKubeNamespacedSharedInformer := informers.NewFilteredSharedInformerFactory(kubeClient, resync(), ConfigSnippetsNamespace,
+		func(opt *metav1.ListOptions) {
+			opt.LabelSelector = ConfigSnippetPoolLabelKey
+		},
+	)
+
 	// filter out CRDs that do not have the MCO label
 	assignFilterLabels := func(opts *metav1.ListOptions) {
 		labelsMap, err := labels.ConvertSelectorToLabelsMap(opts.LabelSelector)
@@ -81,10 +100,10 @@ func CreateControllerContext(cb *clients.Builder, stop <-chan struct{}, targetNa
 		}
 		opts.LabelSelector = labels.Merge(labelsMap, map[string]string{daemonconsts.OpenShiftOperatorManagedLabel: ""}).String()
 	}
-	apiExtSharedInformer := apiextinformers.NewSharedInformerFactoryWithOptions(apiExtClient, resyncPeriod()(),
+	apiExtSharedInformer := apiextinformers.NewSharedInformerFactoryWithOptions(apiExtClient, resync(),
 		apiextinformers.WithNamespace(targetNamespace), apiextinformers.WithTweakListOptions(assignFilterLabels))
-	configSharedInformer := configinformers.NewSharedInformerFactory(configClient, resyncPeriod()())
-	operatorSharedInformer := operatorinformers.NewSharedInformerFactory(operatorClient, resyncPeriod()())
+	configSharedInformer := configinformers.NewSharedInformerFactory(configClient, resync())
+	operatorSharedInformer := operatorinformers.NewSharedInformerFactory(operatorClient, resync())
 
 	return &ControllerContext{
 		ClientBuilder:                                       cb,
@@ -94,11 +113,30 @@ func CreateControllerContext(cb *clients.Builder, stop <-chan struct{}, targetNa
 		KubeNamespacedInformerFactory:                       kubeNamespacedSharedInformer,
 		OpenShiftConfigKubeNamespacedInformerFactory:        openShiftConfigKubeNamespacedSharedInformer,
 		OpenShiftKubeAPIServerKubeNamespacedInformerFactory: openShiftKubeAPIServerKubeNamespacedSharedInformer,
+		MachineAPIKubeNamespacedInformerFactory:             machineAPIKubeNamespacedSharedInformer,
+		ConfigSnippetsKubeNamespacedInformerFactory:         configSnippetsKubeNamespacedSharedInformer,
 		APIExtInformerFactory:                               apiExtSharedInformer,
 		ConfigInformerFactory:                               configSharedInformer,
 		OperatorInformerFactory:                             operatorSharedInformer,
 		Stop:                                                stop,
 		InformersStarted:                                    make(chan struct{}),
-		ResyncPeriod:                                        resyncPeriod(),
+		ResyncPeriod:                                        resync,
+	}
+}
+
+// GetEtcdInformer returns the informer of the etcd operator config, nil when the
+// cluster doesn't run the etcd operator.
+func GetEtcdInformer(operatorClient operatorclientset.Interface, operatorSharedInformer operatorinformers.SharedInformerFactory) (operatorinformersv1.EtcdInformer, error) {
+	operatorGroups, err := operatorClient.Discovery().ServerResourcesForGroupVersion("operator.openshift.io/v1")
+	if err != nil {
+		glog.Errorf("unable to get operatorGroups: %#v", err)
+		return nil, err
+	}
+
+	for _, o := range operatorGroups.APIResources {
+		if o.Kind == "Etcd" {
+			return operatorSharedInformer.Operator().V1().Etcds(), nil
+		}
 	}
+	return nil, nil
 }
@@ -0,0 +1,16 @@
+package common
+
+// ForgetError wraps the errors of a sync that retrying doesn't fix, e.g. a
+// config failing validation: its key is forgotten instead of requeued.
+type ForgetError struct {
+	Err error
+}
+
+// NewForgetError returns err wrapped in a ForgetError.
+func NewForgetError(err error) *ForgetError {
+	return &ForgetError{Err: err}
+}
+
+func (e *ForgetError) Error() string {
+	return e.Err.Error()
+}
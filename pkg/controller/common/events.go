@@ -0,0 +1,139 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// EventAggregationWindow is the period over which the events of an object
+	// with the same reason are rate limited.
+	EventAggregationWindow = 5 * time.Minute
+	// EventAggregationBurst is the number of events of an object with the same
+	// reason recorded per EventAggregationWindow.
+	EventAggregationBurst = 3
+
+	// maxAggregatedKeys bounds the number of object and reason pairs tracked.
+	maxAggregatedKeys = 4096
+)
+
+// aggregatingRecorder is an EventRecorder which rate limits the events
+// correlated by their involved object and reason. Suppressed events are
+// counted and reported with the next event recorded for the same pair.
+type aggregatingRecorder struct {
+	record.EventRecorder
+
+	clock  clock.Clock
+	window time.Duration
+	burst  int
+
+	lock  sync.Mutex
+	state map[string]*aggregatedEvents
+}
+
+type aggregatedEvents struct {
+	windowStart time.Time
+	recorded    int
+	suppressed  int
+}
+
+// NewAggregatingRecorder wraps recorder so that during a rollout, the events of
+// an object with the same reason don't flood the event stream: at most burst of
+// them are recorded per window.
+func NewAggregatingRecorder(recorder record.EventRecorder, window time.Duration, burst int) record.EventRecorder {
+	return newAggregatingRecorder(recorder, clock.RealClock{}, window, burst)
+}
+
+func newAggregatingRecorder(recorder record.EventRecorder, clk clock.Clock, window time.Duration, burst int) *aggregatingRecorder {
+	return &aggregatingRecorder{
+		EventRecorder: recorder,
+		clock:         clk,
+		window:        window,
+		burst:         burst,
+		state:         map[string]*aggregatedEvents{},
+	}
+}
+
+// Event implements record.EventRecorder.
+func (r *aggregatingRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if message, ok := r.aggregate(object, reason, message); ok {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf implements record.EventRecorder.
+func (r *aggregatingRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf implements record.EventRecorder.
+func (r *aggregatingRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if message, ok := r.aggregate(object, reason, fmt.Sprintf(messageFmt, args...)); ok {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// aggregate returns the message to record for an event, and whether it should
+// be recorded at all.
+func (r *aggregatingRecorder) aggregate(object runtime.Object, reason, message string) (string, bool) {
+	key, ok := eventKey(object, reason)
+	if !ok {
+		return message, true
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock.Now()
+	events, ok := r.state[key]
+	if !ok || now.Sub(events.windowStart) >= r.window {
+		if len(r.state) >= maxAggregatedKeys {
+			r.pruneLocked(now)
+		}
+		suppressed := 0
+		if ok {
+			suppressed = events.suppressed
+		}
+		events = &aggregatedEvents{windowStart: now, suppressed: suppressed}
+		r.state[key] = events
+	}
+
+	if events.recorded >= r.burst {
+		events.suppressed++
+		return "", false
+	}
+	events.recorded++
+	if events.suppressed > 0 {
+		message = fmt.Sprintf("%s (%d similar events suppressed)", message, events.suppressed)
+		events.suppressed = 0
+	}
+	return message, true
+}
+
+// pruneLocked forgets the pairs whose window ended without suppressed events.
+func (r *aggregatingRecorder) pruneLocked(now time.Time) {
+	for key, events := range r.state {
+		if now.Sub(events.windowStart) >= r.window && events.suppressed == 0 {
+			delete(r.state, key)
+		}
+	}
+}
+
+// eventKey correlates the events of object with reason.
+func eventKey(object runtime.Object, reason string) (string, bool) {
+	if ref, ok := object.(*corev1.ObjectReference); ok {
+		return fmt.Sprintf("%s/%s/%s/%s", ref.Kind, ref.Namespace, ref.Name, reason), true
+	}
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%T/%s/%s/%s", object, accessor.GetNamespace(), accessor.GetName(), reason), true
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestAggregatingRecorder(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	fake := record.NewFakeRecorder(100)
+	recorder := newAggregatingRecorder(fake, fakeClock, time.Minute, 2)
+
+	node0 := &corev1.ObjectReference{Kind: "Node", Name: "node-0"}
+	node1 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	for i := 0; i < 5; i++ {
+		recorder.Eventf(node0, corev1.EventTypeNormal, "Drain", "Draining node %d", i)
+	}
+	recorder.Eventf(node0, corev1.EventTypeNormal, "Reboot", "Rebooting")
+	recorder.Eventf(node1, corev1.EventTypeNormal, "Drain", "Draining node")
+
+	fakeClock.Step(time.Minute)
+	recorder.Eventf(node0, corev1.EventTypeNormal, "Drain", "Draining node %d", 5)
+	recorder.Eventf(node0, corev1.EventTypeNormal, "Drain", "Draining node %d", 6)
+
+	close(fake.Events)
+	var events []string
+	for event := range fake.Events {
+		events = append(events, event)
+	}
+	assert.Equal(t, []string{
+		"Normal Drain Draining node 0",
+		"Normal Drain Draining node 1",
+		"Normal Reboot Rebooting",
+		"Normal Drain Draining node",
+		"Normal Drain Draining node 5 (3 similar events suppressed)",
+		"Normal Drain Draining node 6",
+	}, events)
+}
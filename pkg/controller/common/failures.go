@@ -0,0 +1,73 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// CrashLoopingValidationFailures is the number of times the same config
+	// fails validation before it's reported as crash looping.
+	CrashLoopingValidationFailures = 5
+
+	// droppedKeyBaseDelay is how long a key dropped out of a queue for the
+	// first time waits before it's synced again.
+	droppedKeyBaseDelay = time.Minute
+	// droppedKeyMaxDelay caps how long a key dropped out of a queue waits.
+	droppedKeyMaxDelay = 30 * time.Minute
+)
+
+// SyncFailures tracks the keys of a queue failing to sync over and over, which
+// the rate limiter of the queue forgets once they're dropped out of it.
+type SyncFailures struct {
+	lock    sync.Mutex
+	dropped map[interface{}]int
+	invalid map[interface{}]int
+}
+
+// NewSyncFailures returns an empty SyncFailures.
+func NewSyncFailures() *SyncFailures {
+	return &SyncFailures{
+		dropped: map[interface{}]int{},
+		invalid: map[interface{}]int{},
+	}
+}
+
+// Dropped records key was dropped out of the queue after failing to sync, and
+// returns how long to wait before syncing it again: doubling on every drop
+// from droppedKeyBaseDelay up to droppedKeyMaxDelay.
+func (f *SyncFailures) Dropped(key interface{}) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delay := droppedKeyBaseDelay << uint(f.dropped[key])
+	if delay <= 0 || delay >= droppedKeyMaxDelay {
+		return droppedKeyMaxDelay
+	}
+	f.dropped[key]++
+	return delay
+}
+
+// Invalid records the config of key failed validation, and returns whether it
+// failed more than CrashLoopingValidationFailures times.
+func (f *SyncFailures) Invalid(key interface{}) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.invalid[key]++
+	return f.invalid[key] > CrashLoopingValidationFailures
+}
+
+// CrashLooping returns whether the config of key failed validation more than
+// CrashLoopingValidationFailures times.
+func (f *SyncFailures) CrashLooping(key interface{}) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.invalid[key] > CrashLoopingValidationFailures
+}
+
+// Forget forgets the failures of key, once it synced.
+func (f *SyncFailures) Forget(key interface{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.dropped, key)
+	delete(f.invalid, key)
+}
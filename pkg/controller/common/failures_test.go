@@ -0,0 +1,37 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncFailuresDropped(t *testing.T) {
+	f := NewSyncFailures()
+	var delays []time.Duration
+	for i := 0; i < 7; i++ {
+		delays = append(delays, f.Dropped("a"))
+	}
+	assert.Equal(t, []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 30 * time.Minute, 30 * time.Minute,
+	}, delays)
+	assert.Equal(t, time.Minute, f.Dropped("b"))
+
+	f.Forget("a")
+	assert.Equal(t, time.Minute, f.Dropped("a"))
+}
+
+func TestSyncFailuresInvalid(t *testing.T) {
+	f := NewSyncFailures()
+	for i := 0; i < CrashLoopingValidationFailures; i++ {
+		assert.False(t, f.Invalid("a"))
+	}
+	assert.False(t, f.CrashLooping("a"))
+	assert.True(t, f.Invalid("a"))
+	assert.True(t, f.CrashLooping("a"))
+	assert.False(t, f.CrashLooping("b"))
+
+	f.Forget("a")
+	assert.False(t, f.CrashLooping("a"))
+}
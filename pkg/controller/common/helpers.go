@@ -5,91 +5,95 @@ import (
 	"io/ioutil"
 	"reflect"
 	"sort"
+	"strings"
 
+	ctconfig "github.com/coreos/container-linux-config-transpiler/config"
 	ignconverter "github.com/coreos/ign-converter"
 	ign2error "github.com/coreos/ignition/config/shared/errors"
 	ign "github.com/coreos/ignition/config/v2_2"
 	ign2types "github.com/coreos/ignition/config/v2_2/types"
+	ign23types "github.com/coreos/ignition/config/v2_3/types"
 	validate2 "github.com/coreos/ignition/config/validate"
 	ign3 "github.com/coreos/ignition/v2/config/v3_0"
 	ign3types "github.com/coreos/ignition/v2/config/v3_0/types"
 	validate3 "github.com/coreos/ignition/v2/config/validate"
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	errors "github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // MergeMachineConfigs combines multiple machineconfig objects into one object.
-// It sorts all the configs in increasing order of their name.
-// It uses the Ignition config from first object as base and appends all the rest.
-// Kernel arguments are concatenated.
-// It uses only the OSImageURL provided by the CVO and ignores any MC provided OSImageURL.
+// It is the merge the render controller uses for the rendered config of a pool,
+// and its semantics are kept stable so that other tools can predict the rendered
+// output. The configs are merged in increasing order of their name, which is
+// why MachineConfigs are usually prefixed with a number, and precedence is:
+//
+//   - Ignition: the config of the first MachineConfig is the base and the ones
+//     of the others are appended to it. Lists like files and units are
+//     concatenated, so when several MachineConfigs set the same path or unit the
+//     last one in name order is the one applied to the machines. Users of the
+//     same name are merged into the first one, with the SSH keys of all.
+//     Configs are merged as spec 2.2, if any of them uses spec 3 the result is
+//     translated to spec 3, which only keeps that last one. The Butane config of a
+//     MachineConfig is translated and appended to its Ignition config.
+//   - KernelArguments are concatenated in name order, duplicates are kept.
+//   - Extensions are merged into a sorted list without duplicates.
+//   - FIPS is enabled when any MachineConfig enables it.
+//   - KernelType is realtime when any MachineConfig sets it, default otherwise.
+//   - OSImageURL is osImageURL, the one of the release payload or of the pool
+//     override; the ones of the MachineConfigs are ignored.
+//
+// configs isn't modified. The merged config has no name nor metadata.
 func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*mcfgv1.MachineConfig, error) {
 	if len(configs) == 0 {
 		return nil, nil
 	}
+	configs = append([]*mcfgv1.MachineConfig{}, configs...)
 	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
 
-	var fips, ok bool
+	var fips, outputV3 bool
 	var kernelType string
-	var outIgn ign2types.Config
 
-	if configs[0].Spec.Config.Raw == nil {
-		outIgn = ign2types.Config{}
-	} else {
-		parsedIgn, err := IgnParseWrapper(configs[0].Spec.Config.Raw)
-		if err != nil {
-			return nil, err
-		}
-		switch parsedIgnValue := parsedIgn.(type) {
-		case ign3types.Config:
-			convertedIgn, err := convertIgnition3to2(parsedIgnValue)
-			if err != nil {
-				return nil, err
-			}
-			outIgn = convertedIgn
-		default:
-			outIgn, ok = parsedIgn.(ign2types.Config)
-			if !ok {
-				return nil, errors.Errorf("something unexpected happened when parsing: %v", ok)
-			}
-		}
+	outIgn, v3, err := getMachineConfigIgnition(configs[0])
+	if err != nil {
+		return nil, err
 	}
+	outputV3 = v3
 
-	for idx := 1; idx < len(configs); idx++ {
-		// if any of the config has FIPS enabled, it'll be set
-		if configs[idx].Spec.FIPS {
+	// if any of the config has FIPS enabled, it'll be set
+	for _, cfg := range configs {
+		if cfg.Spec.FIPS {
 			fips = true
 		}
+	}
 
-		var appendIgn ign2types.Config
-		if configs[idx].Spec.Config.Raw == nil {
-			appendIgn = ign2types.Config{}
-		} else {
-			parsedIgn, err := IgnParseWrapper(configs[idx].Spec.Config.Raw)
-			if err != nil {
-				return nil, err
-			}
-			switch parsedIgnValue := parsedIgn.(type) {
-			case ign3types.Config:
-				convertedIgn, err := convertIgnition3to2(parsedIgnValue)
-				if err != nil {
-					return nil, err
-				}
-				appendIgn = convertedIgn
-			default:
-				appendIgn, ok = parsedIgn.(ign2types.Config)
-				if !ok {
-					return nil, errors.Errorf("something unexpected happened when parsing: %v", ok)
-				}
-			}
+	for idx := 1; idx < len(configs); idx++ {
+		appendIgn, v3, err := getMachineConfigIgnition(configs[idx])
+		if err != nil {
+			return nil, err
 		}
+		outputV3 = outputV3 || v3
 		outIgn = ign.Append(outIgn, appendIgn)
 	}
-	rawOutIgn, err := json.Marshal(outIgn)
-	if err != nil {
-		return nil, err
+	outIgn.Passwd.Users = mergePasswdUsers(outIgn.Passwd.Users)
+	var rawOutIgn []byte
+	if outputV3 {
+		outIgnV3, err := ConvertIgnition2to3(outIgn)
+		if err != nil {
+			return nil, err
+		}
+		rawOutIgn, err = json.Marshal(outIgnV3)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rawOutIgn, err = json.Marshal(outIgn)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// sets the KernelType if specified in any of the MachineConfig
@@ -98,8 +102,6 @@ func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*m
 		if cfg.Spec.KernelType == KernelTypeRealtime {
 			kernelType = cfg.Spec.KernelType
 			break
-		} else if kernelType == KernelTypeDefault {
-			kernelType = cfg.Spec.KernelType
 		}
 	}
 
@@ -109,9 +111,18 @@ func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*m
 	}
 
 	kargs := []string{}
+	var extensions []string
+	seenExtensions := make(map[string]bool)
 	for _, cfg := range configs {
 		kargs = append(kargs, cfg.Spec.KernelArguments...)
+		for _, ext := range cfg.Spec.Extensions {
+			if !seenExtensions[ext] {
+				seenExtensions[ext] = true
+				extensions = append(extensions, ext)
+			}
+		}
 	}
+	sort.Strings(extensions)
 
 	return &mcfgv1.MachineConfig{
 		Spec: mcfgv1.MachineConfigSpec{
@@ -122,10 +133,141 @@ func MergeMachineConfigs(configs []*mcfgv1.MachineConfig, osImageURL string) (*m
 			},
 			FIPS:       fips,
 			KernelType: kernelType,
+			Extensions: extensions,
 		},
 	}, nil
 }
 
+// mergePasswdUsers merges the users of the same name, e.g. core in the
+// 99-<pool>-ssh MachineConfig of the installer and in the one of the
+// NodeSSHKeySets: the first one is kept, with the SSH keys of all of them,
+// without duplicates. Ignition appends users, which would list them twice.
+func mergePasswdUsers(users []ign2types.PasswdUser) []ign2types.PasswdUser {
+	var merged []ign2types.PasswdUser
+	byName := make(map[string]int, len(users))
+	for _, u := range users {
+		idx, ok := byName[u.Name]
+		if !ok {
+			byName[u.Name] = len(merged)
+			u.SSHAuthorizedKeys = appendSSHKeys(nil, u.SSHAuthorizedKeys)
+			merged = append(merged, u)
+			continue
+		}
+		merged[idx].SSHAuthorizedKeys = appendSSHKeys(merged[idx].SSHAuthorizedKeys, u.SSHAuthorizedKeys)
+	}
+	return merged
+}
+
+// appendSSHKeys appends the keys of add missing from keys.
+func appendSSHKeys(keys, add []ign2types.SSHAuthorizedKey) []ign2types.SSHAuthorizedKey {
+	for _, key := range add {
+		found := false
+		for _, k := range keys {
+			if k == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// getMachineConfigIgnition returns the Ignition config of cfg as spec 2.2, with
+// its Butane config appended, and whether its Ignition config is of spec 3.
+func getMachineConfigIgnition(cfg *mcfgv1.MachineConfig) (ign2types.Config, bool, error) {
+	var out ign2types.Config
+	var v3 bool
+	if cfg.Spec.Config.Raw != nil {
+		parsedIgn, err := IgnParseWrapper(cfg.Spec.Config.Raw)
+		if err != nil {
+			return ign2types.Config{}, false, err
+		}
+		switch parsedIgnValue := parsedIgn.(type) {
+		case ign3types.Config:
+			v3 = true
+			out, err = ConvertIgnition3to2(parsedIgnValue)
+			if err != nil {
+				return ign2types.Config{}, false, err
+			}
+		case ign2types.Config:
+			out = parsedIgnValue
+		default:
+			return ign2types.Config{}, false, errors.Errorf("something unexpected happened when parsing: %T", parsedIgn)
+		}
+	}
+	if cfg.Spec.Butane != "" {
+		butaneIgn, err := TranslateButane([]byte(cfg.Spec.Butane))
+		if err != nil {
+			return ign2types.Config{}, false, errors.Wrapf(err, "MachineConfig %s has an invalid butane config", cfg.Name)
+		}
+		if out.Ignition.Version == "" {
+			out = butaneIgn
+		} else {
+			out = ign.Append(out, butaneIgn)
+		}
+	}
+	return out, v3, nil
+}
+
+// TranslateButane translates a Butane config to an Ignition config with the
+// Container Linux Config transpiler, like the templates are. Only its storage,
+// systemd and passwd sections are translated, the other keys, e.g. variant and
+// version, are ignored. The nodes only have a root filesystem, so it's the
+// default of the files, directories and links.
+func TranslateButane(data []byte) (ign2types.Config, error) {
+	ctCfg, ast, rpt := ctconfig.Parse(data)
+	if rpt.IsFatal() {
+		return ign2types.Config{}, errors.Errorf("failed to parse Butane config: %s", rpt)
+	}
+	for i := range ctCfg.Storage.Files {
+		if ctCfg.Storage.Files[i].Filesystem == "" {
+			ctCfg.Storage.Files[i].Filesystem = "root"
+		}
+	}
+	for i := range ctCfg.Storage.Directories {
+		if ctCfg.Storage.Directories[i].Filesystem == "" {
+			ctCfg.Storage.Directories[i].Filesystem = "root"
+		}
+	}
+	for i := range ctCfg.Storage.Links {
+		if ctCfg.Storage.Links[i].Filesystem == "" {
+			ctCfg.Storage.Links[i].Filesystem = "root"
+		}
+	}
+	cfg, rpt := ctconfig.Convert(ctCfg, "", ast)
+	if rpt.IsFatal() {
+		return ign2types.Config{}, errors.Errorf("failed to translate Butane config: %s", rpt)
+	}
+	return cfg, nil
+}
+
+// NewCustomMachineConfigPool returns a MachineConfigPool for the custom role name.
+// It selects the nodes labeled node-role.kubernetes.io/<name> and inherits all the
+// MachineConfigs of the worker pool on top of the ones labeled with the custom role.
+func NewCustomMachineConfigPool(name string) *mcfgv1.MachineConfigPool {
+	return &mcfgv1.MachineConfigPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{name: ""},
+		},
+		Spec: mcfgv1.MachineConfigPoolSpec{
+			MachineConfigSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      mcfgv1.MachineConfigRoleLabelKey,
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{"worker", name},
+				}},
+			},
+			NodeSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"node-role.kubernetes.io/" + name: ""},
+			},
+		},
+	}
+}
+
 // NewIgnConfig returns an empty ignition config with version set as latest version
 func NewIgnConfig() ign2types.Config {
 	return ign2types.Config{
@@ -139,11 +281,11 @@ func NewIgnConfig() ign2types.Config {
 func WriteTerminationError(err error) {
 	msg := err.Error()
 	ioutil.WriteFile("/dev/termination-log", []byte(msg), 0644)
-	glog.Fatal(msg)
+	logging.Fatal(msg)
 }
 
-// convertIgnition3to2 takes an igntion v3 config and returns a v2 config
-func convertIgnition3to2(ignconfig ign3types.Config) (ign2types.Config, error) {
+// ConvertIgnition3to2 takes an igntion v3 config and returns a v2 config
+func ConvertIgnition3to2(ignconfig ign3types.Config) (ign2types.Config, error) {
 	converted2, err := ignconverter.Translate3to2(ignconfig)
 	if err != nil {
 		return converted2, errors.Errorf("unable to convert Ignition V3 config to V2: %v", err)
@@ -152,6 +294,140 @@ func convertIgnition3to2(ignconfig ign3types.Config) (ign2types.Config, error) {
 	return converted2, nil
 }
 
+// ConvertIgnition2to3 takes an ignition v2 config and returns a v3 config.
+// Spec 3 doesn't allow an entry to be defined twice, so files, directories,
+// links and units which were redefined (as the merge of MachineConfigs does)
+// are collapsed first, keeping the last definition like Ignition v2 would.
+func ConvertIgnition2to3(ignconfig ign2types.Config) (ign3types.Config, error) {
+	// The converter works on spec 2.3, which is a superset of 2.2
+	raw, err := json.Marshal(dedupeIgnition2(ignconfig))
+	if err != nil {
+		return ign3types.Config{}, errors.Errorf("unable to convert Ignition V2 config to V3: %v", err)
+	}
+	var ignconfig23 ign23types.Config
+	if err := json.Unmarshal(raw, &ignconfig23); err != nil {
+		return ign3types.Config{}, errors.Errorf("unable to convert Ignition V2 config to V3: %v", err)
+	}
+	ignconfig23.Ignition.Version = ign23types.MaxVersion.String()
+	converted3, err := ignconverter.Translate(ignconfig23, map[string]string{"root": "/"})
+	if err != nil {
+		return converted3, errors.Errorf("unable to convert Ignition V2 config to V3: %v", err)
+	}
+	glog.V(4).Infof("Successfully translated ignition V2 config to ignition V3 config: %v", converted3)
+	return converted3, nil
+}
+
+// dedupeIgnition2 returns a copy of ignconfig where every path, unit and user
+// is defined only once. Later definitions replace earlier ones, unit dropins
+// and SSH keys are merged, and the fields a later unit leaves unset are kept.
+func dedupeIgnition2(ignconfig ign2types.Config) ign2types.Config {
+	out := ignconfig
+	out.Storage.Files = nil
+	out.Storage.Directories = nil
+	out.Storage.Links = nil
+	out.Systemd.Units = nil
+	out.Passwd.Users = nil
+
+	files := make(map[string]int)
+	for _, f := range ignconfig.Storage.Files {
+		if idx, ok := files[f.Path]; ok {
+			out.Storage.Files[idx] = f
+			continue
+		}
+		files[f.Path] = len(out.Storage.Files)
+		out.Storage.Files = append(out.Storage.Files, f)
+	}
+	dirs := make(map[string]int)
+	for _, d := range ignconfig.Storage.Directories {
+		if idx, ok := dirs[d.Path]; ok {
+			out.Storage.Directories[idx] = d
+			continue
+		}
+		dirs[d.Path] = len(out.Storage.Directories)
+		out.Storage.Directories = append(out.Storage.Directories, d)
+	}
+	links := make(map[string]int)
+	for _, l := range ignconfig.Storage.Links {
+		if idx, ok := links[l.Path]; ok {
+			out.Storage.Links[idx] = l
+			continue
+		}
+		links[l.Path] = len(out.Storage.Links)
+		out.Storage.Links = append(out.Storage.Links, l)
+	}
+
+	units := make(map[string]int)
+	for _, u := range ignconfig.Systemd.Units {
+		idx, ok := units[u.Name]
+		if !ok {
+			units[u.Name] = len(out.Systemd.Units)
+			u.Dropins = append([]ign2types.SystemdDropin(nil), u.Dropins...)
+			out.Systemd.Units = append(out.Systemd.Units, u)
+			continue
+		}
+		prev := out.Systemd.Units[idx]
+		// a later definition only carrying dropins, e.g. the kubelet log
+		// level one, doesn't reset the contents and state of the unit
+		if u.Contents == "" {
+			u.Contents = prev.Contents
+		}
+		if u.Enabled == nil {
+			u.Enabled = prev.Enabled
+		}
+		if !u.Enable {
+			u.Enable = prev.Enable
+		}
+		if !u.Mask {
+			u.Mask = prev.Mask
+		}
+		dropins := prev.Dropins
+		for _, d := range u.Dropins {
+			replaced := false
+			for i := range dropins {
+				if dropins[i].Name == d.Name {
+					dropins[i] = d
+					replaced = true
+				}
+			}
+			if !replaced {
+				dropins = append(dropins, d)
+			}
+		}
+		u.Dropins = dropins
+		out.Systemd.Units[idx] = u
+	}
+
+	users := make(map[string]int)
+	for _, u := range ignconfig.Passwd.Users {
+		idx, ok := users[u.Name]
+		if !ok {
+			users[u.Name] = len(out.Passwd.Users)
+			u.SSHAuthorizedKeys = append([]ign2types.SSHAuthorizedKey(nil), u.SSHAuthorizedKeys...)
+			out.Passwd.Users = append(out.Passwd.Users, u)
+			continue
+		}
+		out.Passwd.Users[idx].SSHAuthorizedKeys = append(out.Passwd.Users[idx].SSHAuthorizedKeys, u.SSHAuthorizedKeys...)
+	}
+	return out
+}
+
+// ParseAndConvertConfig parses rawIgn, either a V2 or a V3 config, and returns
+// it as a V2 config, which is what the daemon and the server operate on.
+func ParseAndConvertConfig(rawIgn []byte) (ign2types.Config, error) {
+	ignconfig, err := IgnParseWrapper(rawIgn)
+	if err != nil {
+		return ign2types.Config{}, err
+	}
+	switch cfg := ignconfig.(type) {
+	case ign2types.Config:
+		return cfg, nil
+	case ign3types.Config:
+		return ConvertIgnition3to2(cfg)
+	default:
+		return ign2types.Config{}, errors.Errorf("unrecognized ignition type")
+	}
+}
+
 // ValidateIgnition wraps the underlying Ignition V2/V3 validation, but explicitly supports
 // a completely empty Ignition config as valid.  This is because we
 // want to allow MachineConfig objects which just have e.g. KernelArguments
@@ -180,12 +456,38 @@ func ValidateIgnition(ignconfig interface{}) error {
 	}
 }
 
+// validateExtensions checks that only supported extensions are requested and
+// that they don't conflict with the rest of the MachineConfigSpec.
+func validateExtensions(cfg mcfgv1.MachineConfigSpec) error {
+	for _, ext := range cfg.Extensions {
+		if ext == "kernel-rt" {
+			return errors.Errorf("extension %s is not supported, set kernelType=%s instead", ext, KernelTypeRealtime)
+		}
+		if _, ok := SupportedExtensions[ext]; !ok {
+			return errors.Errorf("extension %s is not supported", ext)
+		}
+		// kernel-devel matches the default kernel, not kernel-rt
+		if ext == "kernel-devel" && cfg.KernelType == KernelTypeRealtime {
+			return errors.Errorf("extension %s conflicts with kernelType=%s", ext, KernelTypeRealtime)
+		}
+	}
+	return nil
+}
+
 // ValidateMachineConfig validates that given MachineConfig Spec is valid.
 func ValidateMachineConfig(cfg mcfgv1.MachineConfigSpec) error {
 	if !(cfg.KernelType == "" || cfg.KernelType == KernelTypeDefault || cfg.KernelType == KernelTypeRealtime) {
 		return errors.Errorf("kernelType=%s is invalid", cfg.KernelType)
 	}
 
+	if err := validateExtensions(cfg); err != nil {
+		return err
+	}
+
+	if err := validateKernelArguments(cfg.KernelArguments); err != nil {
+		return err
+	}
+
 	if cfg.Config.Raw != nil {
 		ignCfg, err := IgnParseWrapper(cfg.Config.Raw)
 		if err != nil {
@@ -195,6 +497,59 @@ func ValidateMachineConfig(cfg mcfgv1.MachineConfigSpec) error {
 			return err
 		}
 	}
+
+	if cfg.Butane != "" {
+		ignCfg, err := TranslateButane([]byte(cfg.Butane))
+		if err != nil {
+			return errors.Wrapf(err, "invalid butane config")
+		}
+		if err := ValidateIgnition(ignCfg); err != nil {
+			return errors.Wrapf(err, "invalid butane config")
+		}
+	}
+	return nil
+}
+
+// singleValueKernelArguments are the kernel arguments which must have a single
+// value for the kubelet to start: it refuses to start with swap enabled when
+// failSwapOn is set. Other kernel arguments, e.g. console or hugepagesz, may be
+// repeated and their order matters, so they're passed as they are.
+var singleValueKernelArguments = map[string]bool{"default_hugepagesz": true, "systemd.swap": true}
+
+// validateKernelArguments returns an error if kargs set one of
+// singleValueKernelArguments to different values, or allocate a different
+// number of huge pages of the same size, e.g. when a KubeletConfig and another
+// MachineConfig of the pool disagree. hugepages applies to the size of the
+// hugepagesz preceding it, or to the default size.
+func validateKernelArguments(kargs []string) error {
+	values := make(map[string]string)
+	hugepages := make(map[string]string)
+	hugepagesSize := ""
+	for _, karg := range kargs {
+		parts := strings.SplitN(karg, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := parts[0], parts[1]
+		switch {
+		case singleValueKernelArguments[name]:
+			if previous, ok := values[name]; ok && previous != value {
+				return errors.Errorf("kernelArguments set %s to both %s and %s", name, previous, value)
+			}
+			values[name] = value
+		case name == "hugepagesz":
+			hugepagesSize = value
+		case name == "hugepages":
+			if previous, ok := hugepages[hugepagesSize]; ok && previous != value {
+				size := hugepagesSize
+				if size == "" {
+					size = "the default size"
+				}
+				return errors.Errorf("kernelArguments allocate both %s and %s huge pages of %s", previous, value, size)
+			}
+			hugepages[hugepagesSize] = value
+		}
+	}
 	return nil
 }
 
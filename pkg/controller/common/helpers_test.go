@@ -1,10 +1,12 @@
 package common
 
 import (
+	"fmt"
 	"testing"
 
 	ign2types "github.com/coreos/ignition/config/v2_2/types"
 	ign3types "github.com/coreos/ignition/v2/config/v3_0/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -64,7 +66,7 @@ func TestConvertIgnition3to2(t *testing.T) {
 	isValid := ValidateIgnition(testIgn3Config)
 	require.Nil(t, isValid)
 
-	convertedIgn, err := convertIgnition3to2(testIgn3Config)
+	convertedIgn, err := ConvertIgnition3to2(testIgn3Config)
 	require.Nil(t, err)
 	assert.IsType(t, ign2types.Config{}, convertedIgn)
 	isValid2 := ValidateIgnition(convertedIgn)
@@ -98,3 +100,266 @@ func TestIgnParseWrapper(t *testing.T) {
 	require.NotNil(t, err)
 	assert.Equal(t, ign2types.Config{}, convertedIgn)
 }
+
+func TestMergeMachineConfigsKernelType(t *testing.T) {
+	tests := []struct {
+		name        string
+		kernelTypes []string
+		expected    string
+	}{
+		{
+			name:        "unset",
+			kernelTypes: []string{"", ""},
+			expected:    KernelTypeDefault,
+		},
+		{
+			name:        "default",
+			kernelTypes: []string{KernelTypeDefault, ""},
+			expected:    KernelTypeDefault,
+		},
+		{
+			name:        "realtime wins over default",
+			kernelTypes: []string{KernelTypeDefault, KernelTypeRealtime, ""},
+			expected:    KernelTypeRealtime,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var configs []*mcfgv1.MachineConfig
+			for i, kt := range test.kernelTypes {
+				mc := helpers.CreateMachineConfigFromIgnition(NewIgnConfig())
+				mc.Name = fmt.Sprintf("%02d-kerneltype", i)
+				mc.Spec.KernelType = kt
+				configs = append(configs, mc)
+			}
+			merged, err := MergeMachineConfigs(configs, "")
+			require.Nil(t, err)
+			assert.Equal(t, test.expected, merged.Spec.KernelType)
+		})
+	}
+}
+
+func TestConvertIgnition2to3(t *testing.T) {
+	mode := 420
+	newFile := func(source string) ign2types.File {
+		return ign2types.File{
+			Node:          ign2types.Node{Filesystem: "root", Path: "/etc/foo"},
+			FileEmbedded1: ign2types.FileEmbedded1{Mode: &mode, Contents: ign2types.FileContents{Source: source}},
+		}
+	}
+	testIgn2Config := NewIgnConfig()
+	testIgn2Config.Storage.Files = []ign2types.File{newFile("data:,one"), newFile("data:,two")}
+	testIgn2Config.Systemd.Units = []ign2types.Unit{
+		{Name: "foo.service", Dropins: []ign2types.SystemdDropin{{Name: "a.conf", Contents: "[Unit]"}}},
+		{Name: "foo.service", Dropins: []ign2types.SystemdDropin{{Name: "b.conf", Contents: "[Unit]"}}},
+	}
+	testIgn2Config.Passwd.Users = []ign2types.PasswdUser{
+		{Name: "core", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"1234"}},
+		{Name: "core", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"5678"}},
+	}
+
+	convertedIgn, err := ConvertIgnition2to3(testIgn2Config)
+	require.Nil(t, err)
+	require.Nil(t, ValidateIgnition(convertedIgn))
+	assert.Equal(t, "3.0.0", convertedIgn.Ignition.Version)
+	// redefinitions are collapsed, the last one wins
+	require.Len(t, convertedIgn.Storage.Files, 1)
+	assert.Equal(t, "data:,two", *convertedIgn.Storage.Files[0].Contents.Source)
+	require.Len(t, convertedIgn.Systemd.Units, 1)
+	assert.Len(t, convertedIgn.Systemd.Units[0].Dropins, 2)
+	require.Len(t, convertedIgn.Passwd.Users, 1)
+	assert.Equal(t, []ign3types.SSHAuthorizedKey{"1234", "5678"}, convertedIgn.Passwd.Users[0].SSHAuthorizedKeys)
+	// the input isn't modified
+	assert.Len(t, testIgn2Config.Systemd.Units[0].Dropins, 1)
+
+	// and it survives the round trip
+	roundTrip, err := ConvertIgnition3to2(convertedIgn)
+	require.Nil(t, err)
+	require.Len(t, roundTrip.Storage.Files, 1)
+	assert.Equal(t, "/etc/foo", roundTrip.Storage.Files[0].Path)
+}
+
+func TestMergeMachineConfigsIgnitionV3(t *testing.T) {
+	mcV2 := helpers.CreateMachineConfigFromIgnition(NewIgnConfig())
+	mcV2.Name = "00-v2"
+	mcV2Only := helpers.CreateMachineConfigFromIgnition(NewIgnConfig())
+	mcV2Only.Name = "01-v2"
+
+	// configs using spec 2.2 only are rendered as spec 2.2
+	merged, err := MergeMachineConfigs([]*mcfgv1.MachineConfig{mcV2, mcV2Only}, "")
+	require.Nil(t, err)
+	parsed, err := IgnParseWrapper(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	assert.IsType(t, ign2types.Config{}, parsed)
+
+	// a single spec 3 config switches the output to spec 3
+	ign3Config := ign3types.Config{Ignition: ign3types.Ignition{Version: "3.0.0"}}
+	ign3Config.Passwd.Users = []ign3types.PasswdUser{{Name: "core", SSHAuthorizedKeys: []ign3types.SSHAuthorizedKey{"1234"}}}
+	mcV3 := helpers.CreateMachineConfigFromIgnition(ign3Config)
+	mcV3.Name = "99-v3"
+
+	merged, err = MergeMachineConfigs([]*mcfgv1.MachineConfig{mcV2, mcV3}, "")
+	require.Nil(t, err)
+	parsed, err = IgnParseWrapper(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.IsType(t, ign3types.Config{}, parsed)
+	assert.Equal(t, "core", parsed.(ign3types.Config).Passwd.Users[0].Name)
+	require.Nil(t, ValidateMachineConfig(merged.Spec))
+
+	// which is translated back to spec 2.2 for the daemon and the server
+	converted, err := ParseAndConvertConfig(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	assert.Equal(t, []ign2types.SSHAuthorizedKey{"1234"}, converted.Passwd.Users[0].SSHAuthorizedKeys)
+}
+
+func TestDedupeIgnition2Units(t *testing.T) {
+	enabled := true
+	ignConfig := NewIgnConfig()
+	ignConfig.Systemd.Units = []ign2types.Unit{
+		{Name: "kubelet.service", Enabled: &enabled, Contents: "[Service]\nExecStart=/usr/bin/kubelet\n", Dropins: []ign2types.SystemdDropin{{Name: "10-mco-default-env.conf", Contents: "[Service]\n"}}},
+		{Name: "kubelet.service", Dropins: []ign2types.SystemdDropin{{Name: "30-logging.conf", Contents: "[Service]\nEnvironment=\"KUBELET_LOG_LEVEL=4\"\n"}}},
+	}
+
+	deduped := dedupeIgnition2(ignConfig)
+	require.Len(t, deduped.Systemd.Units, 1)
+	unit := deduped.Systemd.Units[0]
+	assert.Equal(t, "[Service]\nExecStart=/usr/bin/kubelet\n", unit.Contents)
+	assert.Equal(t, &enabled, unit.Enabled)
+	assert.Equal(t, []string{"10-mco-default-env.conf", "30-logging.conf"}, []string{unit.Dropins[0].Name, unit.Dropins[1].Name})
+
+	// and so does the spec 3 config it's translated to
+	converted, err := ConvertIgnition2to3(ignConfig)
+	require.Nil(t, err)
+	require.Len(t, converted.Systemd.Units, 1)
+	require.NotNil(t, converted.Systemd.Units[0].Contents)
+	assert.Equal(t, "[Service]\nExecStart=/usr/bin/kubelet\n", *converted.Systemd.Units[0].Contents)
+	assert.Equal(t, &enabled, converted.Systemd.Units[0].Enabled)
+	assert.Len(t, converted.Systemd.Units[0].Dropins, 2)
+}
+
+func TestMergeMachineConfigsSSHKeys(t *testing.T) {
+	// the MachineConfig of the installer holding the SSH key of the install config
+	installerIgn := NewIgnConfig()
+	installerIgn.Passwd.Users = []ign2types.PasswdUser{{Name: "core", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"ssh-ed25519 AAAA installer"}}}
+	installerMC := helpers.CreateMachineConfigFromIgnition(installerIgn)
+	installerMC.Name = "99-worker-ssh"
+
+	// the MachineConfig of the NodeSSHKeySets of the pool
+	keysIgn := NewIgnConfig()
+	keysIgn.Passwd.Users = []ign2types.PasswdUser{
+		{Name: "core", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"ssh-ed25519 AAAA installer", "ssh-ed25519 BBBB admin"}},
+		{Name: "support", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"ssh-ed25519 CCCC support"}},
+	}
+	keysMC := helpers.CreateMachineConfigFromIgnition(keysIgn)
+	keysMC.Name = "99-worker-node-ssh-keys"
+
+	merged, err := MergeMachineConfigs([]*mcfgv1.MachineConfig{keysMC, installerMC}, "")
+	require.Nil(t, err)
+	require.Nil(t, ValidateMachineConfig(merged.Spec))
+	converted, err := ParseAndConvertConfig(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	assert.Equal(t, []ign2types.PasswdUser{
+		{Name: "core", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"ssh-ed25519 AAAA installer", "ssh-ed25519 BBBB admin"}},
+		{Name: "support", SSHAuthorizedKeys: []ign2types.SSHAuthorizedKey{"ssh-ed25519 CCCC support"}},
+	}, converted.Passwd.Users)
+}
+
+func TestMergeMachineConfigsButane(t *testing.T) {
+	ignCfg := NewIgnConfig()
+	ignCfg.Storage.Files = []ign2types.File{{Node: ign2types.Node{Filesystem: "root", Path: "/etc/motd"}}}
+	mcIgn := helpers.CreateMachineConfigFromIgnition(ignCfg)
+	mcIgn.Name = "00-ign"
+	mcIgn.Spec.Butane = `variant: fcos
+version: 1.0.0
+storage:
+  files:
+  - path: /etc/issue
+    contents:
+      inline: hello
+`
+	mcButane := &mcfgv1.MachineConfig{}
+	mcButane.Name = "01-butane"
+	mcButane.Spec.Butane = `systemd:
+  units:
+  - name: foo.service
+    enabled: true
+`
+	require.Nil(t, ValidateMachineConfig(mcIgn.Spec))
+	require.Nil(t, ValidateMachineConfig(mcButane.Spec))
+
+	// the Butane config of a MachineConfig is appended to its Ignition config
+	merged, err := MergeMachineConfigs([]*mcfgv1.MachineConfig{mcButane, mcIgn}, "")
+	require.Nil(t, err)
+	assert.Empty(t, merged.Spec.Butane)
+	parsed, err := IgnParseWrapper(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.IsType(t, ign2types.Config{}, parsed)
+	cfg := parsed.(ign2types.Config)
+	require.Len(t, cfg.Storage.Files, 2)
+	assert.Equal(t, "/etc/motd", cfg.Storage.Files[0].Path)
+	assert.Equal(t, "/etc/issue", cfg.Storage.Files[1].Path)
+	assert.Equal(t, "root", cfg.Storage.Files[1].Filesystem)
+	require.Len(t, cfg.Systemd.Units, 1)
+	assert.Equal(t, "foo.service", cfg.Systemd.Units[0].Name)
+
+	mcButane.Spec.Butane = "storage: ["
+	assert.NotNil(t, ValidateMachineConfig(mcButane.Spec))
+	_, err = MergeMachineConfigs([]*mcfgv1.MachineConfig{mcButane, mcIgn}, "")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "MachineConfig 01-butane has an invalid butane config")
+}
+
+func TestValidateKernelArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		kargs   []string
+		wantErr bool
+	}{
+		{
+			name:  "none",
+			kargs: nil,
+		},
+		{
+			name:  "repeated with the same value",
+			kargs: []string{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=4", "nosmt", "default_hugepagesz=1G", "hugepagesz=1G", "hugepages=4"},
+		},
+		{
+			name:  "hugepages of several sizes",
+			kargs: []string{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=4", "hugepagesz=2M", "hugepages=512"},
+		},
+		{
+			name:  "repeated consoles",
+			kargs: []string{"console=tty0", "console=ttyS0,115200n8"},
+		},
+		{
+			name:    "different default hugepages size",
+			kargs:   []string{"default_hugepagesz=1G", "default_hugepagesz=2M"},
+			wantErr: true,
+		},
+		{
+			name:    "different number of hugepages of the same size",
+			kargs:   []string{"hugepagesz=1G", "hugepages=4", "hugepagesz=2M", "hugepages=512", "hugepagesz=1G", "hugepages=8"},
+			wantErr: true,
+		},
+		{
+			name:    "different number of hugepages of the default size",
+			kargs:   []string{"hugepages=4", "hugepages=8"},
+			wantErr: true,
+		},
+		{
+			name:    "swap enabled and disabled",
+			kargs:   []string{"systemd.swap=0", "systemd.swap=1"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateKernelArguments(test.kargs)
+			if test.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the tests")
+
+// TestMergeMachineConfigsGolden checks the merge of the MachineConfigs of
+// testdata/merge against testdata/merge/rendered.golden.json. The merge
+// semantics are relied upon outside of the MCO, the golden file should only
+// change along with the documented precedence rules. Run the test with
+// -update to regenerate it.
+func TestMergeMachineConfigsGolden(t *testing.T) {
+	paths, err := filepath.Glob("testdata/merge/*.yaml")
+	require.Nil(t, err)
+
+	var configs []*mcfgv1.MachineConfig
+	// read them in reverse to check that the merge orders them by name
+	for i := len(paths) - 1; i >= 0; i-- {
+		data, err := ioutil.ReadFile(paths[i])
+		require.Nil(t, err)
+		config := &mcfgv1.MachineConfig{}
+		require.Nil(t, yaml.Unmarshal(data, config))
+		configs = append(configs, config)
+	}
+	unsorted := append([]*mcfgv1.MachineConfig{}, configs...)
+
+	merged, err := MergeMachineConfigs(configs, "example.com/os@sha256:1111")
+	require.Nil(t, err)
+	assert.Equal(t, unsorted, configs, "the configs passed shouldn't be modified")
+
+	rendered, err := json.MarshalIndent(merged.Spec, "", "  ")
+	require.Nil(t, err)
+	rendered = append(rendered, '\n')
+
+	golden := "testdata/merge/rendered.golden.json"
+	if *updateGolden {
+		require.Nil(t, ioutil.WriteFile(golden, rendered, 0644))
+	}
+	expected, err := ioutil.ReadFile(golden)
+	require.Nil(t, err)
+	if !bytes.Equal(expected, rendered) {
+		t.Fatalf("merged config doesn't match %s, got:\n%s", golden, rendered)
+	}
+}
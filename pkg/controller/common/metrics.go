@@ -0,0 +1,109 @@
+package common
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// DefaultMetricsBindAddress is the address of the metrics listener
+	DefaultMetricsBindAddress = "127.0.0.1:8796"
+
+	// MCCPoolMachines is the number of machines of each pool per state
+	MCCPoolMachines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_pool_machines",
+			Help: "machines of each pool per state: total, ready, updated, unavailable, degraded or unmanaged",
+		}, []string{"pool", "state"})
+
+	// MCCRenderDuration is how long rendering the configuration of a pool takes
+	MCCRenderDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcc_render_duration_seconds",
+			Help:    "time taken to render the configuration of a pool",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"pool"})
+
+	// MCCLastSuccessfulRender is when the configuration of a pool was last rendered
+	MCCLastSuccessfulRender = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_last_successful_render_timestamp_seconds",
+			Help: "unix time the configuration of a pool was last rendered successfully",
+		}, []string{"pool"})
+
+	// MCCSyncErrors counts the failed syncs per sub-controller
+	MCCSyncErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcc_sync_errors_total",
+			Help: "failed syncs per sub-controller",
+		}, []string{"controller"})
+
+	// MCCPoolRolloutStalled is whether the rollout of a pool missed its progress deadline
+	MCCPoolRolloutStalled = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_pool_rollout_stalled",
+			Help: "1 when no machine of the pool finished updating within its progress deadline",
+		}, []string{"pool"})
+
+	// MCCLeader is the identity of the replica leading the machine-config-controller
+	MCCLeader = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcc_leader",
+			Help: "1 for the identity of the replica holding the leader election lock of the machine-config-controller",
+		}, []string{"identity"})
+
+	mccMetricsList = []prometheus.Collector{
+		MCCPoolMachines,
+		MCCRenderDuration,
+		MCCLastSuccessfulRender,
+		MCCSyncErrors,
+		MCCPoolRolloutStalled,
+		MCCLeader,
+	}
+)
+
+// SetMCCLeader reports identity as the current leader of the machine-config-controller.
+func SetMCCLeader(identity string) {
+	MCCLeader.Reset()
+	MCCLeader.WithLabelValues(identity).Set(1)
+}
+
+func registerMCCMetrics() error {
+	for _, metric := range mccMetricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartMetricsListener is metrics listener via http on localhost
+func StartMetricsListener(addr string, stopCh <-chan struct{}) {
+	if addr == "" {
+		addr = DefaultMetricsBindAddress
+	}
+
+	glog.Info("Registering Prometheus metrics")
+	if err := registerMCCMetrics(); err != nil {
+		glog.Errorf("unable to register metrics: %v", err)
+	}
+
+	glog.Infof("Starting metrics listener on %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	s := http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("metrics listener exited with error: %v", err)
+		}
+	}()
+	<-stopCh
+	if err := s.Shutdown(context.Background()); err != nil {
+		glog.Errorf("error stopping metrics listener: %v", err)
+	}
+}
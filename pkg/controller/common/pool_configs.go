@@ -0,0 +1,95 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	macherrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+// The pool configs are the MachineConfigs a controller merges the configs
+// selecting a pool into, e.g. the AcceleratorConfigs of the pool. They're
+// annotated with the names of the configs they hold, which tells them apart
+// from the MachineConfigs of the same name the controller didn't write.
+
+var poolConfigBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// ApplyPoolConfig creates or updates the pool config mc, annotated with
+// annotationKey set to the names of the configs of sources it holds. Updating a
+// MachineConfig of the same name without annotationKey fails with a
+// ForgetError, it isn't overwritten.
+func ApplyPoolConfig(client mcfgclientset.Interface, mc *mcfgv1.MachineConfig, annotationKey string, sources []string) error {
+	annotations := map[string]string{
+		GeneratedByControllerVersionAnnotationKey: version.Hash,
+		annotationKey: strings.Join(sources, ","),
+	}
+	// Create or Update, on conflict retry
+	err := retry.RetryOnConflict(poolConfigBackoff, func() error {
+		cur, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), mc.Name, metav1.GetOptions{})
+		if macherrors.IsNotFound(err) {
+			newMC := mc.DeepCopy()
+			newMC.SetAnnotations(annotations)
+			_, err = client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), newMC, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if !IsPoolConfig(cur, annotationKey) {
+			return NewForgetError(fmt.Errorf("MachineConfig %s already exists and wasn't generated from the configs of its pool", mc.Name))
+		}
+		newMC := cur.DeepCopy()
+		newMC.Spec = mc.Spec
+		newMC.SetAnnotations(annotations)
+		_, err = client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), newMC, metav1.UpdateOptions{})
+		return err
+	})
+	if _, ok := err.(*ForgetError); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("could not Create/Update MachineConfig %s: %v", mc.Name, err)
+	}
+	return nil
+}
+
+// DeletePoolConfigs deletes the pool configs annotated with annotationKey which
+// aren't in keep, those of pools without configs anymore or deleted.
+func DeletePoolConfigs(client mcfgclientset.Interface, annotationKey string, keep map[string]bool) error {
+	mcs, err := client.MachineconfigurationV1().MachineConfigs().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range mcs.Items {
+		mc := &mcs.Items[i]
+		if !IsPoolConfig(mc, annotationKey) || keep[mc.Name] {
+			continue
+		}
+		if err := client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !macherrors.IsNotFound(err) {
+			return err
+		}
+		glog.Infof("Deleted the pool config %s", mc.Name)
+	}
+	return nil
+}
+
+// IsPoolConfig returns whether mc is a pool config annotated with
+// annotationKey. A pool config merges several configs, none controls it.
+func IsPoolConfig(mc *mcfgv1.MachineConfig, annotationKey string) bool {
+	_, ok := mc.Annotations[annotationKey]
+	return ok
+}
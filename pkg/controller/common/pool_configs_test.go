@@ -0,0 +1,38 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+)
+
+func TestPoolConfigs(t *testing.T) {
+	other := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "99-master-accelerators"}}
+	client := fake.NewSimpleClientset(other)
+
+	mc := &mcfgv1.MachineConfig{ObjectMeta: metav1.ObjectMeta{Name: "99-worker-accelerators"}}
+	mc.Spec.KernelArguments = []string{"intel_iommu=on"}
+	require.Nil(t, ApplyPoolConfig(client, mc, AcceleratorConfigsAnnotationKey, []string{"a", "b"}))
+	mc.Spec.KernelArguments = []string{"intel_iommu=on", "iommu=pt"}
+	require.Nil(t, ApplyPoolConfig(client, mc, AcceleratorConfigsAnnotationKey, []string{"a"}))
+	applied, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), mc.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"intel_iommu=on", "iommu=pt"}, applied.Spec.KernelArguments)
+	assert.Equal(t, "a", applied.Annotations[AcceleratorConfigsAnnotationKey])
+
+	// a MachineConfig of the same name not generated from the configs of the pool is left alone
+	err = ApplyPoolConfig(client, other, AcceleratorConfigsAnnotationKey, []string{"a"})
+	assert.IsType(t, &ForgetError{}, err)
+
+	require.Nil(t, DeletePoolConfigs(client, AcceleratorConfigsAnnotationKey, map[string]bool{}))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), mc.Name, metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), other.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// Names of the sub-controllers of the machine-config-controller, as used
+// to tune them through ControllerConfigSpec.Tuning.
+const (
+	TemplateControllerName               = "template"
+	KubeletConfigControllerName          = "kubelet-config"
+	ContainerRuntimeConfigControllerName = "container-runtime-config"
+	NodeTuningConfigControllerName       = "node-tuning-config"
+	ImagePolicyControllerName            = "image-policy"
+	TimeSyncControllerName               = "time-sync"
+	OnPremNetworkingControllerName       = "on-prem-networking"
+	AcceleratorConfigControllerName      = "accelerator-config"
+	NodeSSHKeysControllerName            = "node-ssh-keys"
+	ConfigSnippetControllerName          = "config-snippet"
+	RenderControllerName                 = "render"
+	NodeControllerName                   = "node"
+)
+
+// SubControllerNames are the names of all the tunable sub-controllers.
+var SubControllerNames = []string{
+	TemplateControllerName,
+	KubeletConfigControllerName,
+	ContainerRuntimeConfigControllerName,
+	NodeTuningConfigControllerName,
+	ImagePolicyControllerName,
+	TimeSyncControllerName,
+	OnPremNetworkingControllerName,
+	AcceleratorConfigControllerName,
+	NodeSSHKeysControllerName,
+	ConfigSnippetControllerName,
+	RenderControllerName,
+	NodeControllerName,
+}
+
+const (
+	defaultWorkers = 2
+	// defaultQPS and defaultBurst match workqueue.DefaultControllerRateLimiter.
+	defaultQPS   = 10
+	defaultBurst = 100
+)
+
+// GetResyncPeriod returns the minimum informer resync period set by tuning,
+// which may be nil, or the default one.
+func GetResyncPeriod(tuning *mcfgv1.ControllerTuning) time.Duration {
+	if tuning == nil || tuning.ResyncPeriod == nil || tuning.ResyncPeriod.Duration <= 0 {
+		return minResyncPeriod
+	}
+	return tuning.ResyncPeriod.Duration
+}
+
+// GetSubControllerTuning returns the tuning of the named sub-controller set by
+// tuning, which may be nil, with the defaults filled in.
+func GetSubControllerTuning(tuning *mcfgv1.ControllerTuning, name string) mcfgv1.SubControllerTuning {
+	t := mcfgv1.SubControllerTuning{Name: name}
+	if tuning != nil {
+		for _, c := range tuning.Controllers {
+			if c.Name == name {
+				t = c
+				break
+			}
+		}
+	}
+	if t.Workers <= 0 {
+		t.Workers = defaultWorkers
+	}
+	if t.QPS <= 0 {
+		t.QPS = defaultQPS
+	}
+	if t.Burst <= 0 {
+		t.Burst = defaultBurst
+	}
+	return t
+}
+
+// NewRateLimiter returns the work queue rate limiter of a sub-controller. It
+// behaves like workqueue.DefaultControllerRateLimiter with the overall rate
+// set by t.
+func NewRateLimiter(t mcfgv1.SubControllerTuning) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(t.QPS), int(t.Burst))},
+	)
+}
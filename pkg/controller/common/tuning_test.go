@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestGetSubControllerTuning(t *testing.T) {
+	defaults := mcfgv1.SubControllerTuning{Name: NodeControllerName, Workers: 2, QPS: 10, Burst: 100}
+	assert.Equal(t, defaults, GetSubControllerTuning(nil, NodeControllerName))
+	assert.Equal(t, minResyncPeriod, GetResyncPeriod(nil))
+
+	tuning := &mcfgv1.ControllerTuning{
+		ResyncPeriod: &metav1.Duration{Duration: time.Hour},
+		Controllers: []mcfgv1.SubControllerTuning{
+			{Name: RenderControllerName, Workers: 5},
+			{Name: NodeControllerName, QPS: 5, Burst: 20},
+		},
+	}
+	assert.Equal(t, time.Hour, GetResyncPeriod(tuning))
+	assert.Equal(t, mcfgv1.SubControllerTuning{Name: RenderControllerName, Workers: 5, QPS: 10, Burst: 100}, GetSubControllerTuning(tuning, RenderControllerName))
+	assert.Equal(t, mcfgv1.SubControllerTuning{Name: NodeControllerName, Workers: 2, QPS: 5, Burst: 20}, GetSubControllerTuning(tuning, NodeControllerName))
+	assert.Equal(t, mcfgv1.SubControllerTuning{Name: TemplateControllerName, Workers: 2, QPS: 10, Burst: 100}, GetSubControllerTuning(tuning, TemplateControllerName))
+}
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// ShutdownDeadline is how long the workers of a controller are given to drain
+// its queues once it's stopped.
+var ShutdownDeadline = 30 * time.Second
+
+// Workers runs the workers of a controller and drains its queues once stopped,
+// so that a shutdown doesn't abandon the items being synced or already queued.
+type Workers struct {
+	stopCh <-chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewWorkers returns the Workers of a controller stopped by stopCh.
+func NewWorkers(stopCh <-chan struct{}) *Workers {
+	return &Workers{stopCh: stopCh}
+}
+
+// Run starts count workers running worker until stopCh is closed and the
+// queue worker reads from is shut down and drained.
+func (w *Workers) Run(count int, worker func()) {
+	for i := 0; i < count; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			wait.Until(worker, time.Second, w.stopCh)
+			// wait.Until doesn't call worker once stopped, drain what's left
+			worker()
+		}()
+	}
+}
+
+// Wait blocks until stopCh is closed, then shuts the queues down and waits for
+// the workers to finish the items left in them, for at most ShutdownDeadline.
+func (w *Workers) Wait(name string, queues ...workqueue.Interface) {
+	<-w.stopCh
+
+	queued := 0
+	for _, queue := range queues {
+		queued += queue.Len()
+		queue.ShutDown()
+	}
+	glog.Infof("Draining %s, %d items queued", name, queued)
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(ShutdownDeadline):
+		glog.Warningf("%s didn't drain its queues within %v", name, ShutdownDeadline)
+	}
+}
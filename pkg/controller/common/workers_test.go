@@ -0,0 +1,68 @@
+package common
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestWorkersDrainQueue(t *testing.T) {
+	queue := workqueue.New()
+	stopCh := make(chan struct{})
+
+	var mu sync.Mutex
+	synced := []string{}
+	worker := func() {
+		for {
+			key, quit := queue.Get()
+			if quit {
+				return
+			}
+			// a sync still in progress when stopped
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			synced = append(synced, key.(string))
+			mu.Unlock()
+			queue.Done(key)
+		}
+	}
+
+	workers := NewWorkers(stopCh)
+	for _, key := range []string{"a", "b", "c"} {
+		queue.Add(key)
+	}
+	workers.Run(1, worker)
+	close(stopCh)
+	workers.Wait("test", queue)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, synced)
+	assert.True(t, queue.ShuttingDown())
+}
+
+func TestWorkersShutdownDeadline(t *testing.T) {
+	defer func(deadline time.Duration) { ShutdownDeadline = deadline }(ShutdownDeadline)
+	ShutdownDeadline = 10 * time.Millisecond
+
+	queue := workqueue.New()
+	stopCh := make(chan struct{})
+	block := make(chan struct{})
+	defer close(block)
+
+	workers := NewWorkers(stopCh)
+	queue.Add("stuck")
+	workers.Run(1, func() {
+		key, _ := queue.Get()
+		defer queue.Done(key)
+		<-block
+	})
+	close(stopCh)
+
+	start := time.Now()
+	workers.Wait("test", queue)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
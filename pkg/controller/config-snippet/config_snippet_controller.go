@@ -0,0 +1,356 @@
+package configsnippet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	macherrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelistersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// maxRetries is the number of times a pool will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a pool is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the config snippet controller. It merges the Ignition and
+// Butane snippets of the ConfigMaps labeled for a pool into a MachineConfig of
+// the pool, so that tools which can only write namespaced resources can still
+// configure the nodes.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(pool string) error
+	enqueuePool func(pool string)
+
+	cmLister       corelistersv1.ConfigMapLister
+	cmListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new config snippet controller. cmInformer lists the ConfigMaps
+// of ctrlcommon.ConfigSnippetsNamespace.
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	cmInformer coreinformersv1.ConfigMapInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-configsnippetcontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-configsnippetcontroller"),
+	}
+
+	cmInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addConfigMap,
+		UpdateFunc: ctrl.updateConfigMap,
+		DeleteFunc: ctrl.deleteConfigMap,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigPool,
+		DeleteFunc: ctrl.deleteMachineConfigPool,
+	})
+
+	ctrl.syncHandler = ctrl.syncConfigSnippets
+	ctrl.enqueuePool = ctrl.enqueue
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.cmLister = cmInformer.Lister()
+	ctrl.cmListerSynced = cmInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the config snippet controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.cmListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-ConfigSnippetController")
+	defer glog.Info("Shutting down MachineConfigController-ConfigSnippetController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-ConfigSnippetController", ctrl.queue)
+}
+
+func (ctrl *Controller) addConfigMap(obj interface{}) {
+	cm := obj.(*corev1.ConfigMap)
+	glog.V(4).Infof("Adding config snippet %s", cm.Name)
+	ctrl.enqueueConfigMapPool(cm)
+}
+
+func (ctrl *Controller) updateConfigMap(old, cur interface{}) {
+	oldCM := old.(*corev1.ConfigMap)
+	curCM := cur.(*corev1.ConfigMap)
+	if oldCM.ResourceVersion == curCM.ResourceVersion {
+		return
+	}
+	glog.V(4).Infof("Updating config snippet %s", curCM.Name)
+	// the snippet may have moved to another pool
+	if oldCM.Labels[ctrlcommon.ConfigSnippetPoolLabelKey] != curCM.Labels[ctrlcommon.ConfigSnippetPoolLabelKey] {
+		ctrl.enqueueConfigMapPool(oldCM)
+	}
+	ctrl.enqueueConfigMapPool(curCM)
+}
+
+func (ctrl *Controller) deleteConfigMap(obj interface{}) {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cm, ok = tombstone.Obj.(*corev1.ConfigMap)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a ConfigMap %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleting config snippet %s", cm.Name)
+	ctrl.enqueueConfigMapPool(cm)
+}
+
+func (ctrl *Controller) enqueueConfigMapPool(cm *corev1.ConfigMap) {
+	if pool := cm.Labels[ctrlcommon.ConfigSnippetPoolLabelKey]; pool != "" {
+		ctrl.enqueuePool(pool)
+	}
+}
+
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	ctrl.enqueuePool(pool.Name)
+}
+
+func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
+	pool, ok := obj.(*mcfgv1.MachineConfigPool)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		pool, ok = tombstone.Obj.(*mcfgv1.MachineConfigPool)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfigPool %#v", obj))
+			return
+		}
+	}
+	ctrl.enqueuePool(pool.Name)
+}
+
+func (ctrl *Controller) enqueue(pool string) {
+	ctrl.queue.Add(pool)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.ConfigSnippetControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		glog.V(2).Infof("Not applying the config snippets of pool %v: %v", key, err)
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing the config snippets of pool %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping pool %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncConfigSnippets merges the snippets of the pool with the given name into
+// its MachineConfig, or deletes it when the pool has no snippets anymore.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncConfigSnippets(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing the config snippets of pool %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing the config snippets of pool %q (%v)", key, time.Since(startTime))
+	}()
+
+	pool, err := ctrl.mcpLister.Get(key)
+	if macherrors.IsNotFound(err) {
+		return ctrl.deleteManagedConfig(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{ctrlcommon.ConfigSnippetPoolLabelKey: pool.Name})
+	cms, err := ctrl.cmLister.ConfigMaps(ctrlcommon.ConfigSnippetsNamespace).List(selector)
+	if err != nil {
+		return err
+	}
+	if len(cms) == 0 {
+		return ctrl.deleteManagedConfig(pool.Name)
+	}
+	// the snippets are merged in the order of their names, like MachineConfigs
+	sort.Slice(cms, func(i, j int) bool { return cms[i].Name < cms[j].Name })
+
+	ignConfig := ctrlcommon.NewIgnConfig()
+	var sources []string
+	for _, cm := range cms {
+		snippet, err := convertSnippet(cm)
+		if err != nil {
+			ctrl.eventRecorder.Eventf(cm, corev1.EventTypeWarning, "InvalidConfigSnippet", "Not applying the config snippets of pool %s: %v", pool.Name, err)
+			return ctrlcommon.NewForgetError(fmt.Errorf("config snippet %s: %v", cm.Name, err))
+		}
+		ignConfig = ign.Append(ignConfig, snippet)
+		sources = append(sources, cm.Name)
+	}
+	if err := ctrlcommon.ValidateIgnition(ignConfig); err != nil {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidConfigSnippets", "Not applying the config snippets %s: %v", strings.Join(sources, ", "), err)
+		return ctrlcommon.NewForgetError(fmt.Errorf("config snippets %s: %v", strings.Join(sources, ", "), err))
+	}
+
+	managedKey := getManagedSnippetsKey(pool.Name)
+	mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+	if err != nil && !macherrors.IsNotFound(err) {
+		return err
+	}
+	isNotFound := macherrors.IsNotFound(err)
+	if !isNotFound && !isManagedConfig(mc) {
+		return ctrlcommon.NewForgetError(fmt.Errorf("MachineConfig %s already exists and doesn't hold config snippets", managedKey))
+	}
+	newMC, err := mtmpl.MachineConfigFromIgnConfig(pool.Name, managedKey, ignConfig)
+	if err != nil {
+		return err
+	}
+	if !isNotFound {
+		mc.Spec.Config = newMC.Spec.Config
+		newMC = mc
+	}
+	newMC.SetAnnotations(map[string]string{
+		ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+		ctrlcommon.ConfigSnippetsAnnotationKey:               strings.Join(sources, ","),
+	})
+
+	// Create or Update, on conflict retry
+	if err := retry.RetryOnConflict(updateBackoff, func() error {
+		var err error
+		if isNotFound {
+			_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), newMC, metav1.CreateOptions{})
+		} else {
+			_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), newMC, metav1.UpdateOptions{})
+		}
+		return err
+	}); err != nil {
+		return fmt.Errorf("could not Create/Update MachineConfig %s: %v", managedKey, err)
+	}
+	glog.Infof("Applied config snippets %s on MachineConfigPool %s", strings.Join(sources, ", "), pool.Name)
+	return nil
+}
+
+// deleteManagedConfig deletes the MachineConfig holding the snippets of the
+// pool with the given name, if any.
+func (ctrl *Controller) deleteManagedConfig(pool string) error {
+	managedKey := getManagedSnippetsKey(pool)
+	mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+	if macherrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !isManagedConfig(mc) {
+		return nil
+	}
+	if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), managedKey, metav1.DeleteOptions{}); err != nil && !macherrors.IsNotFound(err) {
+		return err
+	}
+	glog.Infof("Deleted the config snippets of MachineConfigPool %s", pool)
+	return nil
+}
+
+// isManagedConfig returns whether mc holds config snippets. The MachineConfigs
+// can't be owned by the ConfigMaps, which are namespaced.
+func isManagedConfig(mc *mcfgv1.MachineConfig) bool {
+	_, ok := mc.Annotations[ctrlcommon.ConfigSnippetsAnnotationKey]
+	return ok
+}
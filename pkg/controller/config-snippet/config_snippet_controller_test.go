@@ -0,0 +1,118 @@
+package configsnippet
+
+import (
+	"context"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+var alwaysReady = func() bool { return true }
+
+func newController(t *testing.T, pools []*mcfgv1.MachineConfigPool, cms []*corev1.ConfigMap, objects ...runtime.Object) (*Controller, *fake.Clientset, *record.FakeRecorder) {
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+	ki := kubeinformers.NewSharedInformerFactory(k8sfake.NewSimpleClientset(), 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		ki.Core().V1().ConfigMaps(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.mcpListerSynced = alwaysReady
+	c.cmListerSynced = alwaysReady
+	recorder := record.NewFakeRecorder(10)
+	c.eventRecorder = recorder
+
+	for _, p := range pools {
+		require.Nil(t, i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(p))
+	}
+	for _, cm := range cms {
+		require.Nil(t, ki.Core().V1().ConfigMaps().Informer().GetIndexer().Add(cm))
+	}
+	return c, client, recorder
+}
+
+func TestConfigSnippetsCreate(t *testing.T) {
+	worker := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	master := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	cms := []*corev1.ConfigMap{
+		newSnippet("b-issue", "worker", map[string]string{snippetButaneKey: butaneSnippet}),
+		newSnippet("a-motd", "worker", map[string]string{snippetIgnitionKey: ignitionSnippet}),
+	}
+
+	c, client, _ := newController(t, []*mcfgv1.MachineConfigPool{worker, master}, cms)
+	require.Nil(t, c.syncHandler("worker"))
+
+	mc, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-config-snippets", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "worker", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "a-motd,b-issue", mc.Annotations[ctrlcommon.ConfigSnippetsAnnotationKey])
+	ignCfg, _, err := ign.Parse(mc.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 2)
+	assert.Equal(t, "/etc/motd", ignCfg.Storage.Files[0].Path)
+	assert.Equal(t, "/etc/issue.d/gitops.issue", ignCfg.Storage.Files[1].Path)
+	require.Len(t, ignCfg.Systemd.Units, 1)
+
+	// the master pool has no snippets
+	require.Nil(t, c.syncHandler("master"))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-master-config-snippets", metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestConfigSnippetsInvalid(t *testing.T) {
+	worker := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	cms := []*corev1.ConfigMap{
+		newSnippet("motd", "worker", map[string]string{snippetIgnitionKey: ignitionSnippet}),
+		newSnippet("broken", "worker", map[string]string{snippetIgnitionKey: "{"}),
+	}
+
+	c, client, recorder := newController(t, []*mcfgv1.MachineConfigPool{worker}, cms)
+	err := c.syncHandler("worker")
+	require.NotNil(t, err)
+	_, ok := err.(*ctrlcommon.ForgetError)
+	assert.True(t, ok)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "InvalidConfigSnippet")
+
+	// none of the snippets is applied
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), "99-worker-config-snippets", metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestConfigSnippetsDelete(t *testing.T) {
+	worker := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	managed := helpers.NewMachineConfig("99-worker-config-snippets", map[string]string{"node-role/worker": ""}, "", nil)
+	managed.Annotations = map[string]string{ctrlcommon.ConfigSnippetsAnnotationKey: "motd"}
+	user := helpers.NewMachineConfig("99-master-config-snippets", map[string]string{"node-role/master": ""}, "", nil)
+
+	c, client, _ := newController(t, []*mcfgv1.MachineConfigPool{worker}, nil, managed, user)
+
+	// the snippets of the worker pool were all deleted
+	require.Nil(t, c.syncHandler("worker"))
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managed.Name, metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// the master pool is gone, but its MachineConfig doesn't hold snippets
+	require.Nil(t, c.syncHandler("master"))
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), user.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,62 @@
+package configsnippet
+
+import (
+	"fmt"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	corev1 "k8s.io/api/core/v1"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// snippetIgnitionKey is the key of the data of a snippet ConfigMap holding
+	// an Ignition config, of spec 2.x.
+	snippetIgnitionKey = "config.ign"
+
+	// snippetButaneKey is the key of the data of a snippet ConfigMap holding a
+	// Butane config. Its storage, systemd and passwd sections are translated.
+	snippetButaneKey = "config.bu"
+)
+
+// convertSnippet returns the Ignition config of the snippet held by cm, its
+// Ignition config followed by its Butane config when it has both.
+func convertSnippet(cm *corev1.ConfigMap) (igntypes.Config, error) {
+	ignData, hasIgn := cm.Data[snippetIgnitionKey]
+	buData, hasBu := cm.Data[snippetButaneKey]
+	if !hasIgn && !hasBu {
+		return igntypes.Config{}, fmt.Errorf("neither %s nor %s is set", snippetIgnitionKey, snippetButaneKey)
+	}
+
+	var out igntypes.Config
+	if hasIgn {
+		cfg, err := parseIgnitionSnippet([]byte(ignData))
+		if err != nil {
+			return igntypes.Config{}, fmt.Errorf("invalid %s: %v", snippetIgnitionKey, err)
+		}
+		out = ign.Append(out, cfg)
+	}
+	if hasBu {
+		cfg, err := ctrlcommon.TranslateButane([]byte(buData))
+		if err != nil {
+			return igntypes.Config{}, fmt.Errorf("invalid %s: %v", snippetButaneKey, err)
+		}
+		out = ign.Append(out, cfg)
+	}
+	return out, nil
+}
+
+func parseIgnitionSnippet(data []byte) (igntypes.Config, error) {
+	cfg, rpt, err := ign.Parse(data)
+	if err != nil {
+		return igntypes.Config{}, fmt.Errorf("only Ignition spec 2.x is supported: %v: %s", err, rpt)
+	}
+	return cfg, nil
+}
+
+// getManagedSnippetsKey returns the name of the MachineConfig holding the
+// snippets of the pool. It sorts after the MachineConfigs of the MCO.
+func getManagedSnippetsKey(pool string) string {
+	return fmt.Sprintf("99-%s-config-snippets", pool)
+}
@@ -0,0 +1,80 @@
+package configsnippet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSnippet(name, pool string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "openshift-machine-config-snippets",
+			Labels:    map[string]string{"machineconfiguration.openshift.io/config-snippet-pool": pool},
+		},
+		Data: data,
+	}
+}
+
+const (
+	ignitionSnippet = `{"ignition":{"version":"2.2.0"},"storage":{"files":[{"filesystem":"root","path":"/etc/motd","contents":{"source":"data:,hello"},"mode":420}]}}`
+	butaneSnippet   = `variant: fcos
+version: 1.0.0
+storage:
+  files:
+  - path: /etc/issue.d/gitops.issue
+    mode: 0644
+    contents:
+      inline: managed by gitops
+systemd:
+  units:
+  - name: gitops.service
+    enabled: true
+    contents: |
+      [Service]
+      ExecStart=/usr/bin/true
+`
+)
+
+func TestConvertSnippet(t *testing.T) {
+	cfg, err := convertSnippet(newSnippet("motd", "worker", map[string]string{snippetIgnitionKey: ignitionSnippet}))
+	require.Nil(t, err)
+	require.Len(t, cfg.Storage.Files, 1)
+	assert.Equal(t, "/etc/motd", cfg.Storage.Files[0].Path)
+
+	cfg, err = convertSnippet(newSnippet("issue", "worker", map[string]string{snippetButaneKey: butaneSnippet}))
+	require.Nil(t, err)
+	require.Len(t, cfg.Storage.Files, 1)
+	assert.Equal(t, "/etc/issue.d/gitops.issue", cfg.Storage.Files[0].Path)
+	assert.Equal(t, "root", cfg.Storage.Files[0].Filesystem)
+	require.NotNil(t, cfg.Storage.Files[0].Mode)
+	assert.Equal(t, 0644, *cfg.Storage.Files[0].Mode)
+	contents, err := dataurl.DecodeString(cfg.Storage.Files[0].Contents.Source)
+	require.Nil(t, err)
+	assert.Equal(t, "managed by gitops", string(contents.Data))
+	require.Len(t, cfg.Systemd.Units, 1)
+	assert.Equal(t, "gitops.service", cfg.Systemd.Units[0].Name)
+
+	// the Ignition config comes first
+	cfg, err = convertSnippet(newSnippet("both", "worker", map[string]string{snippetIgnitionKey: ignitionSnippet, snippetButaneKey: butaneSnippet}))
+	require.Nil(t, err)
+	require.Len(t, cfg.Storage.Files, 2)
+	assert.Equal(t, "/etc/motd", cfg.Storage.Files[0].Path)
+	assert.Equal(t, "/etc/issue.d/gitops.issue", cfg.Storage.Files[1].Path)
+
+	for _, data := range []map[string]string{
+		nil,
+		{"config.yaml": butaneSnippet},
+		{snippetIgnitionKey: "{"},
+		{snippetIgnitionKey: `{"ignition":{"version":"3.1.0"}}`},
+		{snippetButaneKey: "storage: ["},
+	} {
+		_, err := convertSnippet(newSnippet("invalid", "worker", data))
+		assert.NotNil(t, err, "%v", data)
+	}
+}
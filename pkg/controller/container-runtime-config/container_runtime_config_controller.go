@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
 	ign "github.com/coreos/ignition/config/v2_2"
@@ -99,6 +100,8 @@ type Controller struct {
 
 	queue    workqueue.RateLimitingInterface
 	imgQueue workqueue.RateLimitingInterface
+	// failures tracks the containerruntimeconfigs failing to sync over and over
+	failures *ctrlcommon.SyncFailures
 
 	// we need this method to mock out patch calls in unit until https://github.com/openshift/machine-config-operator/pull/611#issuecomment-481397185
 	// which is probably going to be in kube 1.14
@@ -117,6 +120,7 @@ func New(
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
 	configClient configclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -127,8 +131,9 @@ func New(
 		client:        mcfgClient,
 		configClient:  configClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-containerruntimeconfigcontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-containerruntimeconfigcontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-containerruntimeconfigcontroller"),
 		imgQueue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		failures:      ctrlcommon.NewSyncFailures(),
 	}
 
 	mcrInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -190,14 +195,11 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	glog.Info("Starting MachineConfigController-ContainerRuntimeConfigController")
 	defer glog.Info("Shutting down MachineConfigController-ContainerRuntimeConfigController")
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.worker, time.Second, stopCh)
-	}
-
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
 	// Just need one worker for the image config
-	go wait.Until(ctrl.imgWorker, time.Second, stopCh)
-
-	<-stopCh
+	w.Run(1, ctrl.imgWorker)
+	w.Wait("MachineConfigController-ContainerRuntimeConfigController", ctrl.queue, ctrl.imgQueue)
 }
 
 func ctrConfigTriggerObjectChange(old, new *mcfgv1.ContainerRuntimeConfig) bool {
@@ -241,6 +243,9 @@ func (ctrl *Controller) updateContainerRuntimeConfig(oldObj, newObj interface{})
 	if ctrConfigTriggerObjectChange(oldCtrCfg, newCtrCfg) {
 		glog.V(4).Infof("Update ContainerRuntimeConfig %s", oldCtrCfg.Name)
 		ctrl.enqueueContainerRuntimeConfig(newCtrCfg)
+		if hasAdditionalSearchRegistries(oldCtrCfg) || hasAdditionalSearchRegistries(newCtrCfg) {
+			ctrl.imgQueue.Add("openshift-config")
+		}
 	}
 }
 
@@ -248,6 +253,9 @@ func (ctrl *Controller) addContainerRuntimeConfig(obj interface{}) {
 	cfg := obj.(*mcfgv1.ContainerRuntimeConfig)
 	glog.V(4).Infof("Adding ContainerRuntimeConfig %s", cfg.Name)
 	ctrl.enqueueContainerRuntimeConfig(cfg)
+	if hasAdditionalSearchRegistries(cfg) {
+		ctrl.imgQueue.Add("openshift-config")
+	}
 }
 
 func (ctrl *Controller) deleteContainerRuntimeConfig(obj interface{}) {
@@ -269,6 +277,9 @@ func (ctrl *Controller) deleteContainerRuntimeConfig(obj interface{}) {
 	} else {
 		glog.V(4).Infof("Deleted ContainerRuntimeConfig %s and restored default config", cfg.Name)
 	}
+	if hasAdditionalSearchRegistries(cfg) {
+		ctrl.imgQueue.Add("openshift-config")
+	}
 }
 
 func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.ContainerRuntimeConfig) error {
@@ -345,19 +356,23 @@ func (ctrl *Controller) processNextImgWorkItem() bool {
 func (ctrl *Controller) handleErr(err error, key interface{}) {
 	if err == nil {
 		ctrl.queue.Forget(key)
+		ctrl.failures.Forget(key)
 		return
 	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.ContainerRuntimeConfigControllerName).Inc()
 
-	if ctrl.queue.NumRequeues(key) < maxRetries {
+	if ctrl.queue.NumRequeues(key) < maxRetries && !ctrl.failures.CrashLooping(key) {
 		glog.V(2).Infof("Error syncing containerruntimeconfig %v: %v", key, err)
 		ctrl.queue.AddRateLimited(key)
 		return
 	}
 
+	// wait longer every time the containerruntimeconfig is dropped, instead of hot looping
+	delay := ctrl.failures.Dropped(key)
 	utilruntime.HandleError(err)
-	glog.V(2).Infof("Dropping containerruntimeconfig %q out of the queue: %v", key, err)
+	glog.V(2).Infof("Dropping containerruntimeconfig %q out of the queue for %v: %v", key, delay, err)
 	ctrl.queue.Forget(key)
-	ctrl.queue.AddAfter(key, 1*time.Minute)
+	ctrl.queue.AddAfter(key, delay)
 }
 
 func (ctrl *Controller) handleImgErr(err error, key interface{}) {
@@ -365,6 +380,7 @@ func (ctrl *Controller) handleImgErr(err error, key interface{}) {
 		ctrl.imgQueue.Forget(key)
 		return
 	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.ContainerRuntimeConfigControllerName).Inc()
 
 	if ctrl.imgQueue.NumRequeues(key) < maxRetries {
 		glog.V(2).Infof("Error syncing image config %v: %v", key, err)
@@ -379,9 +395,10 @@ func (ctrl *Controller) handleImgErr(err error, key interface{}) {
 }
 
 // generateOriginalContainerRuntimeConfigs returns rendered default storage, registries and policy config files
-func generateOriginalContainerRuntimeConfigs(templateDir string, cc *mcfgv1.ControllerConfig, role string) (*igntypes.File, *igntypes.File, *igntypes.File, error) {
+// of the container runtime, the default one when empty
+func generateOriginalContainerRuntimeConfigs(templateDir string, cc *mcfgv1.ControllerConfig, role string, runtime mcfgv1.ContainerRuntimeName) (*igntypes.File, *igntypes.File, *igntypes.File, error) {
 	// Render the default templates
-	rc := &mtmpl.RenderConfig{ControllerConfigSpec: &cc.Spec}
+	rc := &mtmpl.RenderConfig{ControllerConfigSpec: &cc.Spec, ContainerRuntime: runtime}
 	generatedConfigs, err := mtmpl.GenerateMachineConfigsForRole(rc, role, templateDir)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("generateMachineConfigsforRole failed with error %s", err)
@@ -423,16 +440,23 @@ func generateOriginalContainerRuntimeConfigs(templateDir string, cc *mcfgv1.Cont
 }
 
 func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.ContainerRuntimeConfig, err error, args ...interface{}) error {
+	// The Deprecated condition goes before the Success one, which must stay the
+	// last condition for the synced generation to be skipped
+	var conditions []mcfgv1.ContainerRuntimeConfigCondition
+	if condition := deprecatedCondition(cfg); condition != nil && err == nil {
+		conditions = append(conditions, *condition)
+	}
+	conditions = append(conditions, wrapErrorWithCondition(err, args...))
 	statusUpdateErr := retry.RetryOnConflict(updateBackoff, func() error {
 		if cfg.GetGeneration() != cfg.Status.ObservedGeneration {
 			cfg.Status.ObservedGeneration = cfg.GetGeneration()
-			cfg.Status.Conditions = append(cfg.Status.Conditions, wrapErrorWithCondition(err, args...))
+			cfg.Status.Conditions = append(cfg.Status.Conditions, conditions...)
 		} else if cfg.GetGeneration() == cfg.Status.ObservedGeneration && err == nil {
 			// If the CR was created before a matching label was added, the CR would be in failure state
 			// However the observed generation would be the same, so check if err is nil as well
 			// Which means that, the ctrcfg was finally successfully able to sync. In that case update the status
 			// to success and clear the previous failure status
-			cfg.Status.Conditions = []mcfgv1.ContainerRuntimeConfigCondition{wrapErrorWithCondition(err, args...)}
+			cfg.Status.Conditions = conditions
 		}
 		_, updateErr := ctrl.client.MachineconfigurationV1().ContainerRuntimeConfigs().UpdateStatus(context.TODO(), cfg, metav1.UpdateOptions{})
 		return updateErr
@@ -445,6 +469,33 @@ func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.ContainerRuntimeConfig, err e
 	return err
 }
 
+// syncCrashLooping reports cfg failed validation with err over and over. The
+// condition and the event are only added once per error, so that retrying cfg
+// doesn't spam its status and the events.
+func (ctrl *Controller) syncCrashLooping(cfg *mcfgv1.ContainerRuntimeConfig, err error) error {
+	condition := mcfgv1.NewContainerRuntimeConfigCondition(mcfgv1.ContainerRuntimeConfigCrashLooping, corev1.ConditionTrue,
+		fmt.Sprintf("Error: failed validation more than %d times, retrying with backoff: %v", ctrlcommon.CrashLoopingValidationFailures, err))
+	if n := len(cfg.Status.Conditions); n > 0 && cfg.Status.Conditions[n-1].Type == condition.Type && cfg.Status.Conditions[n-1].Message == condition.Message {
+		return err
+	}
+	ctrl.eventRecorder.Eventf(cfg, corev1.EventTypeWarning, "CrashLooping", condition.Message)
+	statusUpdateErr := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.mccrLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = newcfg.GetGeneration()
+		newcfg.Status.Conditions = append(newcfg.Status.Conditions, *condition)
+		_, updateErr := ctrl.client.MachineconfigurationV1().ContainerRuntimeConfigs().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return updateErr
+	})
+	if statusUpdateErr != nil {
+		glog.Warningf("error updating container runtime config status: %v", statusUpdateErr)
+	}
+	return err
+}
+
 // syncContainerRuntimeConfig will sync the ContainerRuntimeconfig with the given key.
 // This function is not meant to be invoked concurrently with the same key.
 func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
@@ -495,7 +546,10 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 	}
 
 	// Validate the ContainerRuntimeConfig CR
-	if err := validateUserContainerRuntimeConfig(cfg); err != nil {
+	if err := ValidateUserContainerRuntimeConfig(cfg); err != nil {
+		if ctrl.failures.Invalid(key) {
+			return ctrl.syncCrashLooping(cfg, err)
+		}
 		return ctrl.syncStatusOnly(cfg, err)
 	}
 
@@ -528,7 +582,7 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 			}
 			isNotFound := errors.IsNotFound(err)
 			// Generate the original ContainerRuntimeConfig
-			originalStorageIgn, _, _, err := generateOriginalContainerRuntimeConfigs(ctrl.templatesDir, controllerConfig, role)
+			originalStorageIgn, _, _, err := generateOriginalContainerRuntimeConfigs(ctrl.templatesDir, controllerConfig, role, cfg.Spec.ContainerRuntimeConfig.Runtime)
 			if err != nil {
 				return ctrl.syncStatusOnly(cfg, err, "could not generate origin ContainerRuntime Configs: %v", err)
 			}
@@ -544,11 +598,8 @@ func (ctrl *Controller) syncContainerRuntimeConfig(key string) error {
 				}
 			}
 
-			// Create the cri-o drop-in files
-			if ctrcfg.LogLevel != "" || ctrcfg.PidsLimit != 0 || ctrcfg.LogSizeMax != (resource.Quantity{}) {
-				crioFileConfigs := createCRIODropinFiles(cfg)
-				configFileList = append(configFileList, crioFileConfigs...)
-			}
+			// Create the cri-o drop-in files of the fields which are set
+			configFileList = append(configFileList, createCRIODropinFiles(cfg)...)
 
 			if isNotFound {
 				tempIgnCfg := ctrlcommon.NewIgnConfig()
@@ -636,14 +687,6 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 		return err
 	}
 
-	// Go through the registries in the image spec to get and validate the blocked registries
-	blockedRegs, err := getValidBlockedRegistries(&clusterVersionCfg.Status, &imgcfg.Spec)
-	if err != nil && err != errParsingReference {
-		glog.V(2).Infof("%v, skipping....", err)
-	} else if err == errParsingReference {
-		return err
-	}
-
 	// Get ControllerConfig
 	controllerConfig, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName)
 	if err != nil {
@@ -658,6 +701,15 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 		return err
 	}
 
+	// Go through the registries in the image spec to get and validate the blocked registries,
+	// the ones the payload is pulled from, possibly through mirrors, are dropped
+	blockedRegs, blockedErr := getValidBlockedRegistries(&clusterVersionCfg.Status, &imgcfg.Spec, icspRules)
+	if blockedErr == errParsingReference {
+		return blockedErr
+	} else if blockedErr != nil {
+		glog.Warningf("Ignoring blockedRegistries entries: %v", blockedErr)
+	}
+
 	sel, err := metav1.LabelSelectorAsSelector(metav1.AddLabelToSelector(&metav1.LabelSelector{}, builtInLabelKey, ""))
 	if err != nil {
 		return err
@@ -668,6 +720,14 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 		return err
 	}
 	for _, pool := range mcpPools {
+		additionalSearchRegs, err := ctrl.getAdditionalSearchRegistries(pool)
+		if err != nil {
+			return err
+		}
+		searchRegs := getSearchRegistries(&imgcfg.Spec, additionalSearchRegs)
+		if blockedErr != nil {
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "BlockedPayloadRegistry", "Ignoring blockedRegistries entries of the cluster image config: %v", blockedErr)
+		}
 		// To keep track of whether we "actually" got an updated image config
 		applied := true
 		role := pool.Name
@@ -675,7 +735,7 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 		managedKey := getManagedKeyReg(pool)
 		if err := retry.RetryOnConflict(updateBackoff, func() error {
 			registriesIgn, err := registriesConfigIgnition(ctrl.templatesDir, controllerConfig, role,
-				imgcfg.Spec.RegistrySources.InsecureRegistries, blockedRegs, imgcfg.Spec.RegistrySources.AllowedRegistries, icspRules)
+				imgcfg.Spec.RegistrySources.InsecureRegistries, blockedRegs, imgcfg.Spec.RegistrySources.AllowedRegistries, searchRegs, icspRules)
 			if err != nil {
 				return err
 			}
@@ -736,7 +796,7 @@ func (ctrl *Controller) syncImageConfig(key string) error {
 }
 
 func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.ControllerConfig, role string,
-	insecureRegs, blockedRegs, allowedRegs []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) (*igntypes.Config, error) {
+	insecureRegs, blockedRegs, allowedRegs, searchRegs []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) (*igntypes.Config, error) {
 
 	var (
 		registriesTOML []byte
@@ -744,17 +804,17 @@ func registriesConfigIgnition(templateDir string, controllerConfig *mcfgv1.Contr
 	)
 
 	// Generate the original registries config
-	_, originalRegistriesIgn, originalPolicyIgn, err := generateOriginalContainerRuntimeConfigs(templateDir, controllerConfig, role)
+	_, originalRegistriesIgn, originalPolicyIgn, err := generateOriginalContainerRuntimeConfigs(templateDir, controllerConfig, role, "")
 	if err != nil {
 		return nil, fmt.Errorf("could not generate origin ContainerRuntime Configs: %v", err)
 	}
 
-	if insecureRegs != nil || blockedRegs != nil || len(icspRules) != 0 {
+	if insecureRegs != nil || blockedRegs != nil || len(searchRegs) != 0 || len(icspRules) != 0 {
 		dataURL, err := dataurl.DecodeString(originalRegistriesIgn.Contents.Source)
 		if err != nil {
 			return nil, fmt.Errorf("could not decode original registries config: %v", err)
 		}
-		registriesTOML, err = updateRegistriesConfig(dataURL.Data, insecureRegs, blockedRegs, icspRules)
+		registriesTOML, err = updateRegistriesConfig(dataURL.Data, insecureRegs, blockedRegs, searchRegs, icspRules)
 		if err != nil {
 			return nil, fmt.Errorf("could not update registries config with new changes: %v", err)
 		}
@@ -783,6 +843,7 @@ func RunImageBootstrap(templateDir string, controllerConfig *mcfgv1.ControllerCo
 	insecureRegs := []string(nil)
 	blockedRegs := []string(nil)
 	allowedRegs := []string(nil)
+	searchRegs := []string(nil)
 
 	var res []*mcfgv1.MachineConfig
 	for _, pool := range mcpPools {
@@ -790,7 +851,7 @@ func RunImageBootstrap(templateDir string, controllerConfig *mcfgv1.ControllerCo
 		managedKey := getManagedKeyReg(pool)
 
 		registriesIgn, err := registriesConfigIgnition(templateDir, controllerConfig, role,
-			insecureRegs, blockedRegs, allowedRegs, icspRules)
+			insecureRegs, blockedRegs, allowedRegs, searchRegs, icspRules)
 		if err != nil {
 			return nil, err
 		}
@@ -906,6 +967,38 @@ func (ctrl *Controller) getPoolsForContainerRuntimeConfig(config *mcfgv1.Contain
 	return pools, nil
 }
 
+// getAdditionalSearchRegistries returns the deprecated additionalSearchRegistries
+// of the valid ContainerRuntimeConfigs selecting pool, ordered by their names.
+func (ctrl *Controller) getAdditionalSearchRegistries(pool *mcfgv1.MachineConfigPool) ([]string, error) {
+	cfgs, err := ctrl.mccrLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cfgs, func(i, j int) bool { return cfgs[i].Name < cfgs[j].Name })
+
+	var regs []string
+	for _, cfg := range cfgs {
+		if cfg.DeletionTimestamp != nil || cfg.Spec.ContainerRuntimeConfig == nil || len(cfg.Spec.ContainerRuntimeConfig.AdditionalSearchRegistries) == 0 {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cfg.Spec.MachineConfigPoolSelector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pool.Labels)) {
+			continue
+		}
+		if err := ValidateUserContainerRuntimeConfig(cfg); err != nil {
+			continue
+		}
+		regs = append(regs, cfg.Spec.ContainerRuntimeConfig.AdditionalSearchRegistries...)
+	}
+	return regs, nil
+}
+
+// hasAdditionalSearchRegistries returns whether cfg sets the deprecated
+// additionalSearchRegistries, which are rendered along with the image config.
+func hasAdditionalSearchRegistries(cfg *mcfgv1.ContainerRuntimeConfig) bool {
+	return cfg.Spec.ContainerRuntimeConfig != nil && len(cfg.Spec.ContainerRuntimeConfig.AdditionalSearchRegistries) > 0
+}
+
 // isUpdatingFromOldCRIOConf returns true if the mc associated with cfg has /etc/crio/crio.conf as
 // its file path.
 func (ctrl *Controller) isUpdatingFromOldCRIOConf(cfg *mcfgv1.ContainerRuntimeConfig) (bool, error) {
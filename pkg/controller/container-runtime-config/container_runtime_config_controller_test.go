@@ -25,7 +25,10 @@ import (
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
+	"github.com/BurntSushi/toml"
+	"github.com/containers/image/pkg/sysregistriesv2"
 	ign "github.com/coreos/ignition/config/v2_2"
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	apicfgv1 "github.com/openshift/api/config/v1"
@@ -36,6 +39,7 @@ import (
 	operatorinformer "github.com/openshift/client-go/operator/informers/externalversions"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
 	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
 	"github.com/openshift/machine-config-operator/test/helpers"
@@ -108,6 +112,9 @@ func newControllerConfig(name, platform string) *mcfgv1.ControllerConfig {
 		Spec: mcfgv1.ControllerConfigSpec{
 			EtcdDiscoveryDomain: fmt.Sprintf("%s.tt.testing", name),
 			Platform:            platform,
+			Images: map[string]string{
+				mtmpl.InfraImageKey: "image/infraImage:1",
+			},
 		},
 	}
 	return cc
@@ -150,7 +157,7 @@ func newClusterVersionConfig(name, desiredImage string) *apicfgv1.ClusterVersion
 		TypeMeta:   metav1.TypeMeta{APIVersion: apicfgv1.SchemeGroupVersion.String()},
 		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
 		Status: apicfgv1.ClusterVersionStatus{
-			Desired: apicfgv1.Update{
+			Desired: apicfgv1.Release{
 				Image: desiredImage,
 			},
 		},
@@ -172,7 +179,7 @@ func (f *fixture) newController() *Controller {
 		ci.Config().V1().Images(),
 		oi.Operator().V1alpha1().ImageContentSourcePolicies(),
 		ci.Config().V1().ClusterVersions(),
-		k8sfake.NewSimpleClientset(), f.client, f.imgClient)
+		k8sfake.NewSimpleClientset(), f.client, f.imgClient, workqueue.DefaultControllerRateLimiter())
 
 	c.patchContainerRuntimeConfigsFunc = func(name string, patch []byte) error {
 		f.client.Invokes(core.NewRootPatchAction(schema.GroupVersionResource{Version: "v1", Group: "machineconfiguration.openshift.io", Resource: "containerruntimeconfigs"}, name, types.MergePatchType, patch), nil)
@@ -349,7 +356,8 @@ func verifyRegistriesConfigAndPolicyJSONContents(t *testing.T, mc *mcfgv1.Machin
 	// configuration file.
 	expectedRegistriesConf, err := updateRegistriesConfig(templateRegistriesConfig,
 		imgcfg.Spec.RegistrySources.InsecureRegistries,
-		imgcfg.Spec.RegistrySources.BlockedRegistries, icsps)
+		imgcfg.Spec.RegistrySources.BlockedRegistries,
+		imgcfg.Spec.RegistrySources.ContainerRuntimeSearchRegistries, icsps)
 	require.NoError(t, err)
 	assert.Equal(t, mcName, mc.ObjectMeta.Name)
 
@@ -737,6 +745,41 @@ func TestRunImageBootstrap(t *testing.T) {
 	}
 }
 
+// TestPayloadRegistriesNotBlocked tests that the entries blocking the registries
+// the payload is pulled from, even through mirrors or during an update, are dropped
+func TestPayloadRegistriesNotBlocked(t *testing.T) {
+	cvcfg := newClusterVersionConfig("version", "quay.io/openshift-release-dev/ocp-release:4.6.1")
+	cvcfg.Status.History = []apicfgv1.UpdateHistory{
+		{State: apicfgv1.PartialUpdate, Image: "quay.io/openshift-release-dev/ocp-release:4.6.1"},
+		{State: apicfgv1.CompletedUpdate, Image: "registry.old.io/ocp/release:4.6.0"},
+	}
+	icsps := []*apioperatorsv1alpha1.ImageContentSourcePolicy{
+		newICSP("release", []apioperatorsv1alpha1.RepositoryDigestMirrors{
+			{Source: "quay.io/openshift-release-dev", Mirrors: []string{"mirror.local:5000/ocp"}},
+		}),
+	}
+	imgcfg := newImageConfig("cluster", &apicfgv1.RegistrySources{
+		BlockedRegistries: []string{
+			"quay.io/openshift-release-dev",
+			"quay.io/other",
+			"registry.old.io",
+			"*.local:5000",
+			"mirror.local:5000/ocp/ocp-release",
+			"docker.io",
+		},
+	})
+
+	blocked, err := getValidBlockedRegistries(&cvcfg.Status, &imgcfg.Spec, icsps)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"registry.old.io" blocks registry.old.io/ocp/release`)
+	assert.Contains(t, err.Error(), `"mirror.local:5000/ocp/ocp-release" blocks mirror.local:5000/ocp/ocp-release`)
+	assert.Equal(t, []string{"quay.io/other", "docker.io"}, blocked)
+
+	cvcfg.Status.Desired.Image = "not a reference"
+	_, err = getValidBlockedRegistries(&cvcfg.Status, &imgcfg.Spec, icsps)
+	assert.Equal(t, errParsingReference, err)
+}
+
 // TestRegistriesValidation tests the validity of registries allowed to be listed
 // under blocked registries
 func TestRegistriesValidation(t *testing.T) {
@@ -770,7 +813,7 @@ func TestRegistriesValidation(t *testing.T) {
 	for _, test := range failureTests {
 		imgcfg := newImageConfig(test.name, test.config)
 		cvcfg := newClusterVersionConfig("version", "blah.io/myuser/myimage:test")
-		blocked, err := getValidBlockedRegistries(&cvcfg.Status, &imgcfg.Spec)
+		blocked, err := getValidBlockedRegistries(&cvcfg.Status, &imgcfg.Spec, nil)
 		if err == nil {
 			t.Errorf("%s: failed", test.name)
 		}
@@ -785,7 +828,7 @@ func TestRegistriesValidation(t *testing.T) {
 	for _, test := range successTests {
 		imgcfg := newImageConfig(test.name, test.config)
 		cvcfg := newClusterVersionConfig("version", "blah.io/myuser/myimage:test")
-		blocked, err := getValidBlockedRegistries(&cvcfg.Status, &imgcfg.Spec)
+		blocked, err := getValidBlockedRegistries(&cvcfg.Status, &imgcfg.Spec, nil)
 		if err != nil {
 			t.Errorf("%s: failed", test.name)
 		}
@@ -822,6 +865,102 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 				LogLevel: "invalid",
 			},
 		},
+		{
+			name: "relative log dir",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				LogDir: "var/log/crio/pods",
+			},
+		},
+		{
+			name: "log dir outside of /var/log",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				LogDir: "/var/log/../lib/pods",
+			},
+		},
+		{
+			name: "log dir /var/log",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				LogDir: "/var/log/",
+			},
+		},
+		{
+			name: "relative cni config dir",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				CNIConfigDir: "etc/cni/net.d",
+			},
+		},
+		{
+			name: "cni config dir the cluster network operator doesn't write to",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				CNIConfigDir: "/etc/cni/net.d",
+			},
+		},
+		{
+			name: "relative cni plugin dir",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				CNIPluginDirs: []string{"/var/lib/cni/bin", "opt/cni/bin"},
+			},
+		},
+		{
+			name: "cni plugin dirs without the cluster network plugins",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				CNIPluginDirs: []string{"/opt/cni/bin"},
+			},
+		},
+		{
+			name: "log filter not a regular expression",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				LogFilter: "audit(",
+			},
+		},
+		{
+			name: "negative journald rate limit",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				JournaldRateLimit: &mcfgv1.JournaldRateLimit{Burst: -1},
+			},
+		},
+		{
+			name: "short name alias with a registry",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: map[string]string{"docker.io/busybox": "mirror.example.com/library/busybox"},
+			},
+		},
+		{
+			name: "short name alias with a tag",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: map[string]string{"busybox:latest": "mirror.example.com/library/busybox"},
+			},
+		},
+		{
+			name: "short name alias to a short name",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: map[string]string{"busybox": "library/busybox"},
+			},
+		},
+		{
+			name: "short name alias to a digest",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: map[string]string{"busybox": "mirror.example.com/library/busybox@sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+			},
+		},
+		{
+			name: "unsupported runtime",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				Runtime: "docker",
+			},
+		},
+		{
+			name: "search registry with a repository",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				AdditionalSearchRegistries: []string{"quay.io/openshift"},
+			},
+		},
+		{
+			name: "invalid search registry",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				AdditionalSearchRegistries: []string{"Quay IO"},
+			},
+		},
 	}
 
 	successTests := []struct {
@@ -846,12 +985,53 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 				LogLevel: "debug",
 			},
 		},
+		{
+			name: "valid log dir",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				LogDir: "/var/log/crio/pods",
+			},
+		},
+		{
+			name: "valid cni dirs",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				CNIConfigDir:  "/etc/kubernetes/cni/net.d/",
+				CNIPluginDirs: []string{"/var/lib/cni/bin/", "/opt/cni/bin"},
+			},
+		},
+		{
+			name: "valid audit logging",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				LogFilter:         "^(audit|exec):",
+				JournaldRateLimit: &mcfgv1.JournaldRateLimit{Interval: metav1.Duration{Duration: 30 * time.Second}, Burst: 10000},
+			},
+		},
+		{
+			name: "valid runtime",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				Runtime: mcfgv1.ContainerRuntimeCRIO,
+			},
+		},
+		{
+			name: "valid short name aliases",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				ShortNameAliases: map[string]string{
+					"busybox":      "mirror.example.com/library/busybox",
+					"library/ubi8": "mirror.example.com:5000/ubi8",
+				},
+			},
+		},
+		{
+			name: "valid search registries",
+			config: &mcfgv1.ContainerRuntimeConfiguration{
+				AdditionalSearchRegistries: []string{"quay.io", "registry.example.com:5000", "localhost"},
+			},
+		},
 	}
 
 	// Failure Tests
 	for _, test := range failureTests {
 		ctrcfg := newContainerRuntimeConfig(test.name, test.config, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
-		err := validateUserContainerRuntimeConfig(ctrcfg)
+		err := ValidateUserContainerRuntimeConfig(ctrcfg)
 		if err == nil {
 			t.Errorf("%s: failed", test.name)
 		}
@@ -860,13 +1040,131 @@ func TestContainerRuntimeConfigOptions(t *testing.T) {
 	// Successful Tests
 	for _, test := range successTests {
 		ctrcfg := newContainerRuntimeConfig(test.name, test.config, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
-		err := validateUserContainerRuntimeConfig(ctrcfg)
+		err := ValidateUserContainerRuntimeConfig(ctrcfg)
 		if err != nil {
 			t.Errorf("%s: failed with %v. should have succeeded", test.name, err)
 		}
 	}
 }
 
+// TestContainerRuntimeConfigCrashLooping ensures that a containerruntimeconfig failing validation over and over
+// is reported as crash looping, and retried with backoff instead of by the rate limiter of the queue.
+func TestContainerRuntimeConfigCrashLooping(t *testing.T) {
+	f := newFixture(t)
+
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, "aws")
+	mcp := helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0")
+	mcp.ObjectMeta.Labels["custom-crio"] = "my-config"
+	ctrcfg1 := newContainerRuntimeConfig("invalid-pids-limit", &mcfgv1.ContainerRuntimeConfiguration{PidsLimit: 10}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "custom-crio", "my-config"))
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.mccrLister = append(f.mccrLister, ctrcfg1)
+	f.objects = append(f.objects, ctrcfg1)
+
+	c := f.newController()
+	key := getKey(ctrcfg1, t)
+	for i := 0; i < ctrlcommon.CrashLoopingValidationFailures; i++ {
+		err := c.syncHandler(key)
+		require.NotNil(t, err)
+		assert.False(t, c.failures.CrashLooping(key))
+	}
+
+	err := c.syncHandler(key)
+	require.NotNil(t, err)
+	assert.True(t, c.failures.CrashLooping(key))
+	actions := filterInformerActions(f.client.Actions())
+	update, ok := actions[len(actions)-1].(core.UpdateAction)
+	require.True(t, ok)
+	conditions := update.GetObject().(*mcfgv1.ContainerRuntimeConfig).Status.Conditions
+	assert.Equal(t, mcfgv1.ContainerRuntimeConfigCrashLooping, conditions[len(conditions)-1].Type)
+
+	c.handleErr(err, key)
+	assert.Equal(t, 0, c.queue.NumRequeues(key))
+}
+
+// TestContainerRuntimeConfigUnknownCNIConfigDir ensures that a CNI config dir the cluster network
+// operator doesn't write to is reported on the containerruntimeconfig, instead of leaving the nodes NotReady.
+func TestContainerRuntimeConfigUnknownCNIConfigDir(t *testing.T) {
+	f := newFixture(t)
+
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, "aws")
+	mcp := helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0")
+	mcp.ObjectMeta.Labels["custom-crio"] = "my-config"
+	ctrcfg1 := newContainerRuntimeConfig("cni", &mcfgv1.ContainerRuntimeConfiguration{CNIConfigDir: "/etc/cni/net.d"}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "custom-crio", "my-config"))
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.mccrLister = append(f.mccrLister, ctrcfg1)
+	f.objects = append(f.objects, ctrcfg1)
+
+	c := f.newController()
+	require.NotNil(t, c.syncHandler(getKey(ctrcfg1, t)))
+	actions := filterInformerActions(f.client.Actions())
+	update, ok := actions[len(actions)-1].(core.UpdateAction)
+	require.True(t, ok)
+	conditions := update.GetObject().(*mcfgv1.ContainerRuntimeConfig).Status.Conditions
+	require.NotEmpty(t, conditions)
+	assert.Equal(t, mcfgv1.ContainerRuntimeConfigFailure, conditions[len(conditions)-1].Type)
+	assert.Contains(t, conditions[len(conditions)-1].Message, clusterNetworkConfigDir)
+	// nothing is rendered for the pool
+	for _, action := range actions {
+		assert.NotEqual(t, "machineconfigs", action.GetResource().Resource)
+	}
+}
+
+// TestImageConfigSearchRegistries ensures that the search registries of the image config and the deprecated
+// ones of the containerruntimeconfigs are rendered without duplicates, and that the deprecated field is
+// reported on the containerruntimeconfig.
+func TestImageConfigSearchRegistries(t *testing.T) {
+	f := newFixture(t)
+
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName, "aws")
+	mcp := helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0")
+	mcp.ObjectMeta.Labels["custom-crio"] = "my-config"
+	mcp2 := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	imgcfg1 := newImageConfig("cluster", &apicfgv1.RegistrySources{ContainerRuntimeSearchRegistries: []string{"registry.example.com", "docker.io"}})
+	cvcfg1 := newClusterVersionConfig("version", "test.io/myuser/myimage:test")
+	ctrcfg1 := newContainerRuntimeConfig("search", &mcfgv1.ContainerRuntimeConfiguration{AdditionalSearchRegistries: []string{"quay.io", "registry.example.com"}},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "custom-crio", "my-config"))
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp, mcp2)
+	f.mccrLister = append(f.mccrLister, ctrcfg1)
+	f.objects = append(f.objects, ctrcfg1)
+	f.imgLister = append(f.imgLister, imgcfg1)
+	f.cvLister = append(f.cvLister, cvcfg1)
+	f.imgObjects = append(f.imgObjects, imgcfg1)
+
+	c := f.newController()
+	require.NoError(t, c.syncImgHandler("cluster"))
+	for pool, want := range map[*mcfgv1.MachineConfigPool][]string{
+		mcp:  {"registry.example.com", "docker.io", "quay.io"},
+		mcp2: {"registry.example.com", "docker.io"},
+	} {
+		mc, err := f.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedKeyReg(pool), metav1.GetOptions{})
+		require.NoError(t, err)
+		registriesConf, err := findRegistriesConfig(mc)
+		require.NoError(t, err)
+		data, err := dataurl.DecodeString(registriesConf.Contents.Source)
+		require.NoError(t, err)
+		tomlConf := sysregistriesv2.V2RegistriesConf{}
+		_, err = toml.Decode(string(data.Data), &tomlConf)
+		require.NoError(t, err)
+		assert.Equal(t, want, tomlConf.UnqualifiedSearchRegistries, pool.Name)
+	}
+
+	require.NoError(t, c.syncHandler(getKey(ctrcfg1, t)))
+	actions := filterInformerActions(f.client.Actions())
+	update, ok := actions[len(actions)-1].(core.UpdateAction)
+	require.True(t, ok)
+	conditions := update.GetObject().(*mcfgv1.ContainerRuntimeConfig).Status.Conditions
+	require.Len(t, conditions, 2)
+	assert.Equal(t, mcfgv1.ContainerRuntimeConfigDeprecated, conditions[0].Type)
+	assert.Contains(t, conditions[0].Message, "containerRuntimeSearchRegistries")
+	assert.Equal(t, mcfgv1.ContainerRuntimeConfigSuccess, conditions[1].Type)
+}
+
 func getKey(config *mcfgv1.ContainerRuntimeConfig, t *testing.T) string {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(config)
 	if err != nil {
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/containers/image/docker/reference"
@@ -19,6 +22,7 @@ import (
 	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
 	"github.com/openshift/runtime-utils/pkg/registries"
 	"github.com/vincent-petithory/dataurl"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +39,24 @@ const (
 	CRIODropInFilePathLogLevel   = "/etc/crio/crio.conf.d/01-ctrcfg-logLevel"
 	crioDropInFilePathPidsLimit  = "/etc/crio/crio.conf.d/01-ctrcfg-pidsLimit"
 	crioDropInFilePathLogSizeMax = "/etc/crio/crio.conf.d/01-ctrcfg-logSizeMax"
+	// the drop-ins are named after the fields of the ContainerRuntimeConfiguration
+	crioDropInFilePathLogToJournald      = "/etc/crio/crio.conf.d/01-ctrcfg-logToJournald"
+	crioDropInFilePathLogDir             = "/etc/crio/crio.conf.d/01-ctrcfg-logDir"
+	crioDropInFilePathLogFilter          = "/etc/crio/crio.conf.d/01-ctrcfg-logFilter"
+	crioDropInFilePathSeparateLogStreams = "/etc/crio/crio.conf.d/01-ctrcfg-separateLogStreams"
+	crioDropInFilePathCNIConfigDir       = "/etc/crio/crio.conf.d/01-ctrcfg-cniConfigDir"
+	crioDropInFilePathCNIPluginDirs      = "/etc/crio/crio.conf.d/01-ctrcfg-cniPluginDirs"
+	// journaldDropInFilePathRateLimit is read by journald, along with journald.conf
+	journaldDropInFilePathRateLimit = "/etc/systemd/journald.conf.d/01-ctrcfg-journaldRateLimit.conf"
+	// registriesDropInFilePathShortNameAliases is read by containers/image, along with registries.conf
+	registriesDropInFilePathShortNameAliases = "/etc/containers/registries.conf.d/01-ctrcfg-shortNameAliases.conf"
+	containerLogDirPrefix                    = "/var/log/"
+	// clusterNetworkPluginDir is where the cluster network operator installs
+	// the CNI plugins of the pod network, e.g. multus.
+	clusterNetworkPluginDir = "/var/lib/cni/bin"
+	// clusterNetworkConfigDir is where the cluster network operator writes the
+	// CNI configuration of the pod network.
+	clusterNetworkConfigDir = "/etc/kubernetes/cni/net.d"
 )
 
 var errParsingReference = errors.New("error parsing reference of desired image from cluster version config")
@@ -86,6 +108,78 @@ type tomlConfigCRIOLogSizeMax struct {
 	} `toml:"crio"`
 }
 
+// tomlConfigCRIOLogToJournald is used for conversions when log-to-journald is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions. false is a valid setting, so the field isn't omitted when empty.
+type tomlConfigCRIOLogToJournald struct {
+	Crio struct {
+		Runtime struct {
+			LogToJournald bool `toml:"log_to_journald"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIOLogDir is used for conversions when log-dir is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIOLogDir struct {
+	Crio struct {
+		Runtime struct {
+			LogDir string `toml:"log_dir,omitempty"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIOLogFilter is used for conversions when log-filter is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIOLogFilter struct {
+	Crio struct {
+		Runtime struct {
+			LogFilter string `toml:"log_filter,omitempty"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIOSeparateLogStreams is used for conversions when separate-log-streams
+// is changed TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions. false is a valid setting, so the field isn't omitted when empty.
+type tomlConfigCRIOSeparateLogStreams struct {
+	Crio struct {
+		Runtime struct {
+			SeparateLogStreams bool `toml:"separate_log_streams"`
+		} `toml:"runtime"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIOCNIConfigDir is used for conversions when network-dir is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIOCNIConfigDir struct {
+	Crio struct {
+		Network struct {
+			NetworkDir string `toml:"network_dir,omitempty"`
+		} `toml:"network"`
+	} `toml:"crio"`
+}
+
+// tomlConfigCRIOCNIPluginDirs is used for conversions when plugin-dirs is changed
+// TOML-friendly (it has all of the explicit tables). It's just used for
+// conversions.
+type tomlConfigCRIOCNIPluginDirs struct {
+	Crio struct {
+		Network struct {
+			PluginDirs []string `toml:"plugin_dirs,omitempty"`
+		} `toml:"network"`
+	} `toml:"crio"`
+}
+
+// tomlConfigShortNameAliases is the registries.conf.d drop-in of the short
+// name aliases.
+type tomlConfigShortNameAliases struct {
+	Aliases map[string]string `toml:"aliases"`
+}
+
 // generatedConfigFile is a struct that holds the filepath and data of the various configs
 // Using a struct array ensures that the order of the ignition files always stay the same
 // ensuring that double MCs are not created due to a change in the order
@@ -177,27 +271,8 @@ func getManagedKeyReg(pool *mcfgv1.MachineConfigPool) string {
 }
 
 func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.ContainerRuntimeConfigCondition {
-	var condition *mcfgv1.ContainerRuntimeConfigCondition
-	if err != nil {
-		condition = mcfgv1.NewContainerRuntimeConfigCondition(
-			mcfgv1.ContainerRuntimeConfigFailure,
-			corev1.ConditionFalse,
-			fmt.Sprintf("Error: %v", err),
-		)
-	} else {
-		condition = mcfgv1.NewContainerRuntimeConfigCondition(
-			mcfgv1.ContainerRuntimeConfigSuccess,
-			corev1.ConditionTrue,
-			"Success",
-		)
-	}
-	if len(args) > 0 {
-		format, ok := args[0].(string)
-		if ok {
-			condition.Message = fmt.Sprintf(format, args[:1]...)
-		}
-	}
-	return *condition
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewContainerRuntimeConfigCondition(mcfgv1.ContainerRuntimeConfigStatusConditionType(condition.Type), condition.Status, condition.Message)
 }
 
 // updateStorageConfig decodes the data rendered from the template, merges the changes in and encodes it
@@ -231,7 +306,8 @@ func addTOMLgeneratedConfigFile(configFileList []generatedConfigFile, path strin
 	return configFileList, nil
 }
 
-// createCRIODropinFiles gets the data from the CRD and creates the respective drio in file in /etc/crio/crio.conf.d
+// createCRIODropinFiles gets the data from the CRD and creates the respective drio in file in /etc/crio/crio.conf.d,
+// or /etc/containers/registries.conf.d for the short name aliases
 // We create different drop-in files for each CRI-O field that can be changed by the ctrcfg CR
 // this ensures that we don't have to rely on hard coded defaults that might cause problems
 // in future if something in cri-o or the templates used by the MCO changes
@@ -265,10 +341,71 @@ func createCRIODropinFiles(cfg *mcfgv1.ContainerRuntimeConfig) []generatedConfig
 			glog.V(2).Infoln(cfg, err, "error updating user changes for log-size-max to crio.conf.d: %v", err)
 		}
 	}
+	if ctrcfg.LogToJournald != nil {
+		tomlConf := tomlConfigCRIOLogToJournald{}
+		tomlConf.Crio.Runtime.LogToJournald = *ctrcfg.LogToJournald
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathLogToJournald, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for log-to-journald to crio.conf.d: %v", err)
+		}
+	}
+	if ctrcfg.LogDir != "" {
+		tomlConf := tomlConfigCRIOLogDir{}
+		tomlConf.Crio.Runtime.LogDir = ctrcfg.LogDir
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathLogDir, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for log-dir to crio.conf.d: %v", err)
+		}
+	}
+	if ctrcfg.LogFilter != "" {
+		tomlConf := tomlConfigCRIOLogFilter{}
+		tomlConf.Crio.Runtime.LogFilter = ctrcfg.LogFilter
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathLogFilter, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for log-filter to crio.conf.d: %v", err)
+		}
+	}
+	if ctrcfg.SeparateLogStreams != nil {
+		tomlConf := tomlConfigCRIOSeparateLogStreams{}
+		tomlConf.Crio.Runtime.SeparateLogStreams = *ctrcfg.SeparateLogStreams
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathSeparateLogStreams, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for separate-log-streams to crio.conf.d: %v", err)
+		}
+	}
+	if ctrcfg.CNIConfigDir != "" {
+		tomlConf := tomlConfigCRIOCNIConfigDir{}
+		tomlConf.Crio.Network.NetworkDir = ctrcfg.CNIConfigDir
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathCNIConfigDir, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for network-dir to crio.conf.d: %v", err)
+		}
+	}
+	if len(ctrcfg.CNIPluginDirs) != 0 {
+		tomlConf := tomlConfigCRIOCNIPluginDirs{}
+		tomlConf.Crio.Network.PluginDirs = ctrcfg.CNIPluginDirs
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, crioDropInFilePathCNIPluginDirs, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for plugin-dirs to crio.conf.d: %v", err)
+		}
+	}
+	if ctrcfg.JournaldRateLimit != nil {
+		generatedConfigFileList = append(generatedConfigFileList, generatedConfigFile{
+			filePath: journaldDropInFilePathRateLimit,
+			data:     journaldRateLimitConfig(ctrcfg.JournaldRateLimit),
+		})
+	}
+	if len(ctrcfg.ShortNameAliases) != 0 {
+		tomlConf := tomlConfigShortNameAliases{Aliases: ctrcfg.ShortNameAliases}
+		generatedConfigFileList, err = addTOMLgeneratedConfigFile(generatedConfigFileList, registriesDropInFilePathShortNameAliases, tomlConf)
+		if err != nil {
+			glog.V(2).Infoln(cfg, err, "error updating user changes for short-name-aliases to registries.conf.d: %v", err)
+		}
+	}
 	return generatedConfigFileList
 }
 
-func updateRegistriesConfig(data []byte, internalInsecure, internalBlocked []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) ([]byte, error) {
+func updateRegistriesConfig(data []byte, internalInsecure, internalBlocked, internalSearch []string, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) ([]byte, error) {
 	tomlConf := sysregistriesv2.V2RegistriesConf{}
 	if _, err := toml.Decode(string(data), &tomlConf); err != nil {
 		return nil, fmt.Errorf("error unmarshalling registries config: %v", err)
@@ -277,6 +414,10 @@ func updateRegistriesConfig(data []byte, internalInsecure, internalBlocked []str
 	if err := registries.EditRegistriesConfig(&tomlConf, internalInsecure, internalBlocked, icspRules); err != nil {
 		return nil, err
 	}
+	// the search registries replace the ones of the templates
+	if len(internalSearch) > 0 {
+		tomlConf.UnqualifiedSearchRegistries = internalSearch
+	}
 
 	var newData bytes.Buffer
 	encoder := toml.NewEncoder(&newData)
@@ -340,8 +481,15 @@ func updatePolicyJSON(data []byte, internalBlocked, internalAllowed []string) ([
 	return policyJSON, nil
 }
 
-// validateUserContainerRuntimeConfig ensures that the values set by the user are valid
-func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) error {
+// journaldRateLimitConfig returns the journald.conf.d drop-in of the rate limit.
+// The interval is written in microseconds, the precision of journald.
+func journaldRateLimitConfig(limit *mcfgv1.JournaldRateLimit) []byte {
+	return []byte(fmt.Sprintf("[Journal]\nRateLimitIntervalSec=%dus\nRateLimitBurst=%d\n",
+		limit.Interval.Duration.Microseconds(), limit.Burst))
+}
+
+// ValidateUserContainerRuntimeConfig ensures that the values set by the user are valid
+func ValidateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) error {
 	if cfg.Spec.ContainerRuntimeConfig == nil {
 		return nil
 	}
@@ -351,6 +499,10 @@ func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) erro
 	}
 
 	ctrcfg := cfg.Spec.ContainerRuntimeConfig
+	if err := mtmpl.ValidateContainerRuntime(ctrcfg.Runtime); err != nil {
+		return err
+	}
+
 	if ctrcfg.PidsLimit > 0 && ctrcfg.PidsLimit < minPidsLimit {
 		return fmt.Errorf("invalid PidsLimit %q, cannot be less than 20", ctrcfg.PidsLimit)
 	}
@@ -359,6 +511,45 @@ func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) erro
 		return fmt.Errorf("invalid LogSizeMax %q, cannot be less than 8kB", ctrcfg.LogSizeMax.String())
 	}
 
+	// the logs must not end up on a filesystem the kubelet doesn't garbage collect, e.g. /
+	if ctrcfg.LogDir != "" && (!filepath.IsAbs(ctrcfg.LogDir) || !strings.HasPrefix(filepath.Clean(ctrcfg.LogDir), containerLogDirPrefix)) {
+		return fmt.Errorf("invalid LogDir %q, must be an absolute path under %s", ctrcfg.LogDir, containerLogDirPrefix)
+	}
+
+	if ctrcfg.CNIConfigDir != "" {
+		if err := validateCNIConfigDir(ctrcfg.CNIConfigDir); err != nil {
+			return err
+		}
+	}
+
+	if len(ctrcfg.CNIPluginDirs) != 0 {
+		if err := validateCNIPluginDirs(ctrcfg.CNIPluginDirs); err != nil {
+			return err
+		}
+	}
+
+	if ctrcfg.LogFilter != "" {
+		if _, err := regexp.Compile(ctrcfg.LogFilter); err != nil {
+			return fmt.Errorf("invalid LogFilter %q, must be a regular expression: %v", ctrcfg.LogFilter, err)
+		}
+	}
+
+	if limit := ctrcfg.JournaldRateLimit; limit != nil && (limit.Interval.Duration < 0 || limit.Burst < 0) {
+		return fmt.Errorf("invalid JournaldRateLimit, interval and burst cannot be negative")
+	}
+
+	for shortName, target := range ctrcfg.ShortNameAliases {
+		if err := validateShortNameAlias(shortName, target); err != nil {
+			return err
+		}
+	}
+
+	for _, reg := range ctrcfg.AdditionalSearchRegistries {
+		if err := validateSearchRegistry(reg); err != nil {
+			return err
+		}
+	}
+
 	if ctrcfg.LogLevel != "" {
 		validLogLevels := map[string]bool{
 			"error": true,
@@ -376,30 +567,165 @@ func validateUserContainerRuntimeConfig(cfg *mcfgv1.ContainerRuntimeConfig) erro
 	return nil
 }
 
+// validateCNIConfigDir checks that CRI-O keeps loading the CNI configuration
+// the cluster network operator writes: the nodes would go NotReady without a
+// pod network otherwise.
+func validateCNIConfigDir(dir string) error {
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("invalid CNIConfigDir %q, must be an absolute path", dir)
+	}
+	if filepath.Clean(dir) != clusterNetworkConfigDir {
+		return fmt.Errorf("invalid CNIConfigDir %q, the cluster network operator writes the pod network configuration to %s", dir, clusterNetworkConfigDir)
+	}
+	return nil
+}
+
+// validateCNIPluginDirs checks that the CNI plugin directories are absolute
+// paths and that the plugins of the pod network stay available to CRI-O.
+func validateCNIPluginDirs(dirs []string) error {
+	found := false
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("invalid CNIPluginDirs entry %q, must be an absolute path", dir)
+		}
+		if filepath.Clean(dir) == clusterNetworkPluginDir {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("invalid CNIPluginDirs %v, must include %s where the cluster network plugins are installed", dirs, clusterNetworkPluginDir)
+	}
+	return nil
+}
+
+// validateShortNameAlias checks that shortName is a repository without a
+// registry, and that target is a fully qualified repository, without a tag or
+// digest, as containers/image requires.
+func validateShortNameAlias(shortName, target string) error {
+	named, err := reference.ParseNormalizedNamed(shortName)
+	if err != nil || !reference.IsNameOnly(named) || strings.HasPrefix(shortName, reference.Domain(named)+"/") {
+		return fmt.Errorf("invalid ShortNameAliases entry %q, must be a short name without a registry, tag or digest", shortName)
+	}
+	named, err = reference.ParseNamed(target)
+	if err != nil || !reference.IsNameOnly(named) {
+		return fmt.Errorf("invalid ShortNameAliases target %q of %q, must be a fully qualified repository without a tag or digest", target, shortName)
+	}
+	return nil
+}
+
+// validateSearchRegistry checks that reg is a registry host, with an optional
+// port, as the unqualified-search-registries of registries.conf must be.
+func validateSearchRegistry(reg string) error {
+	named, err := reference.ParseNamed(reg + "/image")
+	if err != nil || reference.Domain(named) != reg {
+		return fmt.Errorf("invalid AdditionalSearchRegistries entry %q, must be a registry host without a repository", reg)
+	}
+	return nil
+}
+
+// getSearchRegistries returns the containerRuntimeSearchRegistries of the image
+// spec followed by the deprecated additionalSearchRegistries, without the
+// entries listed more than once.
+func getSearchRegistries(imgSpec *apicfgv1.ImageSpec, additionalRegs []string) []string {
+	var searchRegs []string
+	seen := map[string]bool{}
+	for _, regs := range [][]string{imgSpec.RegistrySources.ContainerRuntimeSearchRegistries, additionalRegs} {
+		for _, reg := range regs {
+			if !seen[reg] {
+				seen[reg] = true
+				searchRegs = append(searchRegs, reg)
+			}
+		}
+	}
+	return searchRegs
+}
+
+// deprecatedCondition returns the Deprecated condition of cfg, or nil when
+// cfg doesn't set deprecated fields.
+func deprecatedCondition(cfg *mcfgv1.ContainerRuntimeConfig) *mcfgv1.ContainerRuntimeConfigCondition {
+	if cfg.Spec.ContainerRuntimeConfig == nil || len(cfg.Spec.ContainerRuntimeConfig.AdditionalSearchRegistries) == 0 {
+		return nil
+	}
+	return mcfgv1.NewContainerRuntimeConfigCondition(mcfgv1.ContainerRuntimeConfigDeprecated, corev1.ConditionTrue,
+		"additionalSearchRegistries is deprecated, use registrySources.containerRuntimeSearchRegistries of the cluster Image config")
+}
+
+// getPayloadRepositories returns the repositories the release payload is pulled
+// from: the ones of the desired release and of the last completed one, which the
+// cluster runs until the update completes, and their ImageContentSourcePolicy
+// mirrors.
+func getPayloadRepositories(clusterVersionStatus *apicfgv1.ClusterVersionStatus, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) ([]string, error) {
+	images := []string{clusterVersionStatus.Desired.Image}
+	for _, entry := range clusterVersionStatus.History {
+		if entry.State == apicfgv1.CompletedUpdate {
+			if entry.Image != clusterVersionStatus.Desired.Image {
+				images = append(images, entry.Image)
+			}
+			break
+		}
+	}
+	var repos []string
+	for _, image := range images {
+		ref, err := reference.ParseNamed(image)
+		if err != nil {
+			return nil, errParsingReference
+		}
+		repo := ref.Name()
+		repos = append(repos, repo)
+		for _, icsp := range icspRules {
+			for _, rdm := range icsp.Spec.RepositoryDigestMirrors {
+				if repo == rdm.Source || strings.HasPrefix(repo, rdm.Source+"/") {
+					for _, mirror := range rdm.Mirrors {
+						repos = append(repos, mirror+strings.TrimPrefix(repo, rdm.Source))
+					}
+				}
+			}
+		}
+	}
+	return repos, nil
+}
+
+// blocksRepository returns whether the blockedRegistries entry reg blocks the
+// pulls from repo: reg is its registry, one of its parent namespaces or the
+// repository itself, or a *.domain wildcard matching its registry.
+func blocksRepository(reg, repo string) bool {
+	if reg == repo || strings.HasPrefix(repo, reg+"/") {
+		return true
+	}
+	domain := strings.SplitN(repo, "/", 2)[0]
+	return strings.HasPrefix(reg, "*.") && strings.HasSuffix(domain, reg[1:])
+}
+
 // getValidBlockedRegistries gets the blocked registries in the image spec and validates that the user is not adding
-// the registry being used by the payload to the list of blocked registries.
-// If the user is, we drop that registry and continue with syncing the registries.conf with the other registry options
-func getValidBlockedRegistries(clusterVersionStatus *apicfgv1.ClusterVersionStatus, imgSpec *apicfgv1.ImageSpec) ([]string, error) {
+// the registries the release payload is pulled from to the list of blocked registries, which would break upgrades.
+// If the user is, we drop those entries, return why along with the others and continue with syncing the registries.conf
+// with the other registry options
+func getValidBlockedRegistries(clusterVersionStatus *apicfgv1.ClusterVersionStatus, imgSpec *apicfgv1.ImageSpec, icspRules []*apioperatorsv1alpha1.ImageContentSourcePolicy) ([]string, error) {
 	if clusterVersionStatus == nil || imgSpec == nil {
 		return nil, nil
 	}
 
-	var blockedRegs []string
-
-	// Get the registry being used by the payload from the clusterversion config
-	ref, err := reference.ParseNamed(clusterVersionStatus.Desired.Image)
+	payloadRepos, err := getPayloadRepositories(clusterVersionStatus, icspRules)
 	if err != nil {
-		return nil, errParsingReference
+		return nil, err
 	}
-	payloadReg := reference.Domain(ref)
-	for i, reg := range imgSpec.RegistrySources.BlockedRegistries {
-		// if there is a match, return all the blocked registries except the one that matched and return an error as well
-		if reg == payloadReg {
-			blockedRegs = append(blockedRegs, imgSpec.RegistrySources.BlockedRegistries[i+1:]...)
-			return blockedRegs, fmt.Errorf("error adding %q to blocked registries, cannot block the registry being used by the payload", payloadReg)
+	var blockedRegs, invalid []string
+	for _, reg := range imgSpec.RegistrySources.BlockedRegistries {
+		var blocked string
+		for _, repo := range payloadRepos {
+			if blocksRepository(reg, repo) {
+				blocked = repo
+				break
+			}
+		}
+		if blocked != "" {
+			invalid = append(invalid, fmt.Sprintf("%q blocks %s", reg, blocked))
+			continue
 		}
-		// Was not a match to the registry being used by the payload, so add to valid blocked registries
 		blockedRegs = append(blockedRegs, reg)
 	}
+	if len(invalid) > 0 {
+		return blockedRegs, fmt.Errorf("cannot block the registries the release payload is pulled from: %s", strings.Join(invalid, ", "))
+	}
 	return blockedRegs, nil
 }
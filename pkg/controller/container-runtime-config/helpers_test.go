@@ -7,14 +7,17 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/containers/image/pkg/sysregistriesv2"
 	signature "github.com/containers/image/signature"
 	"github.com/containers/image/types"
 	apioperatorsv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
 )
 
@@ -28,15 +31,22 @@ func TestUpdateRegistriesConfig(t *testing.T) {
 	templateBytes := buf.Bytes()
 
 	tests := []struct {
-		name              string
-		insecure, blocked []string
-		icspRules         []*apioperatorsv1alpha1.ImageContentSourcePolicy
-		want              sysregistriesv2.V2RegistriesConf
+		name                      string
+		insecure, blocked, search []string
+		icspRules                 []*apioperatorsv1alpha1.ImageContentSourcePolicy
+		want                      sysregistriesv2.V2RegistriesConf
 	}{
 		{
 			name: "unchanged",
 			want: templateConfig,
 		},
+		{
+			name:   "search",
+			search: []string{"quay.io", "registry.example.com:5000"},
+			want: sysregistriesv2.V2RegistriesConf{
+				UnqualifiedSearchRegistries: []string{"quay.io", "registry.example.com:5000"},
+			},
+		},
 		{
 			name:     "insecure+blocked",
 			insecure: []string{"registry.access.redhat.com", "insecure.com", "common.com"},
@@ -149,7 +159,7 @@ func TestUpdateRegistriesConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := updateRegistriesConfig(templateBytes, tt.insecure, tt.blocked, tt.icspRules)
+			got, err := updateRegistriesConfig(templateBytes, tt.insecure, tt.blocked, tt.search, tt.icspRules)
 			if err != nil {
 				t.Errorf("updateRegistriesConfig() error = %v", err)
 				return
@@ -259,3 +269,67 @@ func TestUpdatePolicyJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateCRIODropinFilesLogs(t *testing.T) {
+	logToJournald := false
+	ctrcfg := newContainerRuntimeConfig("logs", &mcfgv1.ContainerRuntimeConfiguration{
+		LogToJournald: &logToJournald,
+		LogDir:        "/var/log/crio/pods",
+	}, nil)
+
+	files := createCRIODropinFiles(ctrcfg)
+	require.Len(t, files, 2)
+	assert.Equal(t, crioDropInFilePathLogToJournald, files[0].filePath)
+	// false must be written, it isn't CRI-O's default
+	assert.Equal(t, "[crio]\n  [crio.runtime]\n    log_to_journald = false\n", string(files[0].data))
+	assert.Equal(t, crioDropInFilePathLogDir, files[1].filePath)
+	assert.Equal(t, "[crio]\n  [crio.runtime]\n    log_dir = \"/var/log/crio/pods\"\n", string(files[1].data))
+
+	assert.Empty(t, createCRIODropinFiles(newContainerRuntimeConfig("empty", &mcfgv1.ContainerRuntimeConfiguration{}, nil)))
+}
+
+func TestCreateCRIODropinFilesAudit(t *testing.T) {
+	separateLogStreams := true
+	ctrcfg := newContainerRuntimeConfig("audit", &mcfgv1.ContainerRuntimeConfiguration{
+		LogFilter:          "^(audit|exec):",
+		SeparateLogStreams: &separateLogStreams,
+		JournaldRateLimit:  &mcfgv1.JournaldRateLimit{Interval: metav1.Duration{Duration: 30 * time.Second}, Burst: 10000},
+	}, nil)
+
+	files := createCRIODropinFiles(ctrcfg)
+	require.Len(t, files, 3)
+	assert.Equal(t, crioDropInFilePathLogFilter, files[0].filePath)
+	assert.Equal(t, "[crio]\n  [crio.runtime]\n    log_filter = \"^(audit|exec):\"\n", string(files[0].data))
+	assert.Equal(t, crioDropInFilePathSeparateLogStreams, files[1].filePath)
+	assert.Equal(t, "[crio]\n  [crio.runtime]\n    separate_log_streams = true\n", string(files[1].data))
+	assert.Equal(t, journaldDropInFilePathRateLimit, files[2].filePath)
+	assert.Equal(t, "[Journal]\nRateLimitIntervalSec=30000000us\nRateLimitBurst=10000\n", string(files[2].data))
+}
+
+func TestCreateCRIODropinFilesShortNameAliases(t *testing.T) {
+	ctrcfg := newContainerRuntimeConfig("aliases", &mcfgv1.ContainerRuntimeConfiguration{
+		ShortNameAliases: map[string]string{
+			"ubi8":    "mirror.example.com/ubi8",
+			"busybox": "mirror.example.com/library/busybox",
+		},
+	}, nil)
+
+	files := createCRIODropinFiles(ctrcfg)
+	require.Len(t, files, 1)
+	assert.Equal(t, registriesDropInFilePathShortNameAliases, files[0].filePath)
+	assert.Equal(t, "[aliases]\n  busybox = \"mirror.example.com/library/busybox\"\n  ubi8 = \"mirror.example.com/ubi8\"\n", string(files[0].data))
+}
+
+func TestCreateCRIODropinFilesCNI(t *testing.T) {
+	ctrcfg := newContainerRuntimeConfig("cni", &mcfgv1.ContainerRuntimeConfiguration{
+		CNIConfigDir:  "/etc/kubernetes/cni/net.d",
+		CNIPluginDirs: []string{"/var/lib/cni/bin", "/opt/cni/bin"},
+	}, nil)
+
+	files := createCRIODropinFiles(ctrcfg)
+	require.Len(t, files, 2)
+	assert.Equal(t, crioDropInFilePathCNIConfigDir, files[0].filePath)
+	assert.Equal(t, "[crio]\n  [crio.network]\n    network_dir = \"/etc/kubernetes/cni/net.d\"\n", string(files[0].data))
+	assert.Equal(t, crioDropInFilePathCNIPluginDirs, files[1].filePath)
+	assert.Equal(t, "[crio]\n  [crio.network]\n    plugin_dirs = [\"/var/lib/cni/bin\", \"/opt/cni/bin\"]\n", string(files[1].data))
+}
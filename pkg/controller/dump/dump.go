@@ -0,0 +1,198 @@
+// Package dump writes a snapshot of the state of the MCO in a cluster to a
+// directory, for must-gather and support cases.
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+)
+
+const (
+	// PoolsFile holds the MachineConfigPools.
+	PoolsFile = "pools.json"
+	// NodesFile holds the MCO state of the nodes.
+	NodesFile = "nodes.json"
+	// EventsFile holds the recent warning events of the MCO components.
+	EventsFile = "events.json"
+	// RenderedConfigsDir holds the rendered MachineConfigs in use, one file per config.
+	RenderedConfigsDir = "rendered-configs"
+
+	annotationPrefix = "machineconfiguration.openshift.io/"
+	// eventComponentPrefix prefixes the event sources of the operator,
+	// controllers and daemons.
+	eventComponentPrefix = "machineconfig"
+)
+
+// NodeState is the MCO state of a node.
+type NodeState struct {
+	Name string `json:"name"`
+	// Pools are the pools selecting the node.
+	Pools         []string `json:"pools"`
+	Unschedulable bool     `json:"unschedulable"`
+	Ready         bool     `json:"ready"`
+	// Annotations are the machineconfiguration.openshift.io annotations of the node.
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Dump writes to dir the MachineConfigPools, the rendered MachineConfigs they and
+// their nodes use, the MCO state of the nodes, and the warning events recorded by
+// the MCO components since since before now, e.g. the failed syncs.
+func Dump(kubeClient kubernetes.Interface, mcfgClient mcfgclientset.Interface, dir string, since time.Duration, now time.Time) error {
+	pools, err := mcfgClient.MachineconfigurationV1().MachineConfigPools().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	nodes, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	events, err := kubeClient.CoreV1().Events(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, RenderedConfigsDir), 0755); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, PoolsFile), pools.Items); err != nil {
+		return err
+	}
+	nodeStates, err := getNodeStates(pools.Items, nodes.Items)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, NodesFile), nodeStates); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, EventsFile), getRecentEvents(events.Items, now.Add(-since))); err != nil {
+		return err
+	}
+
+	for _, name := range getRenderedConfigNames(pools.Items, nodeStates) {
+		config, err := mcfgClient.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// reported as such by the pools and nodes already
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(filepath.Join(dir, RenderedConfigsDir, name+".json"), config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getNodeStates(pools []mcfgv1.MachineConfigPool, nodes []corev1.Node) ([]NodeState, error) {
+	selectors := make(map[string]labels.Selector, len(pools))
+	for _, pool := range pools {
+		selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+		if err != nil {
+			return nil, err
+		}
+		selectors[pool.Name] = selector
+	}
+
+	states := make([]NodeState, 0, len(nodes))
+	for _, node := range nodes {
+		state := NodeState{
+			Name:          node.Name,
+			Pools:         []string{},
+			Unschedulable: node.Spec.Unschedulable,
+			Annotations:   map[string]string{},
+		}
+		for name, selector := range selectors {
+			// an empty selector would match all the nodes
+			if !selector.Empty() && selector.Matches(labels.Set(node.Labels)) {
+				state.Pools = append(state.Pools, name)
+			}
+		}
+		sort.Strings(state.Pools)
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady {
+				state.Ready = cond.Status == corev1.ConditionTrue
+			}
+		}
+		for k, v := range node.Annotations {
+			if strings.HasPrefix(k, annotationPrefix) {
+				state.Annotations[k] = v
+			}
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states, nil
+}
+
+// getRenderedConfigNames returns the rendered configs the pools target and run,
+// and the ones the nodes run and are updating to.
+func getRenderedConfigNames(pools []mcfgv1.MachineConfigPool, nodes []NodeState) []string {
+	names := map[string]bool{}
+	for _, pool := range pools {
+		names[pool.Spec.Configuration.Name] = true
+		names[pool.Status.Configuration.Name] = true
+	}
+	for _, node := range nodes {
+		names[node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]] = true
+		names[node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]] = true
+	}
+	delete(names, "")
+	var out []string
+	for name := range names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// getRecentEvents returns the warning events of the MCO components last seen
+// after after, oldest first.
+func getRecentEvents(events []corev1.Event, after time.Time) []corev1.Event {
+	out := []corev1.Event{}
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning || !strings.HasPrefix(event.Source.Component, eventComponentPrefix) {
+			continue
+		}
+		if eventTime(event).Before(after) {
+			continue
+		}
+		out = append(out, event)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return eventTime(out[i]).Before(eventTime(out[j])) })
+	return out
+}
+
+func eventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
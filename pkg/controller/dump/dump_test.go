@@ -0,0 +1,91 @@
+package dump
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestDump(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-1")
+	pool.Spec.Configuration.Name = "rendered-worker-2"
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-0",
+			Labels: map[string]string{"node-role/worker": ""},
+			Annotations: map[string]string{
+				daemonconsts.CurrentMachineConfigAnnotationKey: "rendered-worker-1",
+				daemonconsts.DesiredMachineConfigAnnotationKey: "rendered-worker-2",
+				"unrelated": "annotation",
+			},
+		},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+	}
+	newEvent := func(name, component, eventType string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:    metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Source:        corev1.EventSource{Component: component},
+			Type:          eventType,
+			LastTimestamp: metav1.NewTime(now.Add(-age)),
+		}
+	}
+	kubeClient := k8sfake.NewSimpleClientset(node,
+		newEvent("recent", "machineconfigcontroller-nodecontroller", corev1.EventTypeWarning, time.Minute),
+		newEvent("old", "machineconfigdaemon", corev1.EventTypeWarning, 2*time.Hour),
+		newEvent("normal", "machineconfigdaemon", corev1.EventTypeNormal, time.Minute),
+		newEvent("other", "kubelet", corev1.EventTypeWarning, time.Minute),
+	)
+	mcfgClient := fake.NewSimpleClientset(pool, helpers.NewMachineConfig("rendered-worker-1", nil, "", nil))
+
+	dir, err := ioutil.TempDir("", "dump")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	require.Nil(t, Dump(kubeClient, mcfgClient, dir, time.Hour, now))
+
+	var nodes []NodeState
+	readJSON(t, filepath.Join(dir, NodesFile), &nodes)
+	assert.Equal(t, []NodeState{{
+		Name:  "node-0",
+		Pools: []string{"worker"},
+		Ready: true,
+		Annotations: map[string]string{
+			daemonconsts.CurrentMachineConfigAnnotationKey: "rendered-worker-1",
+			daemonconsts.DesiredMachineConfigAnnotationKey: "rendered-worker-2",
+		},
+	}}, nodes)
+
+	var events []corev1.Event
+	readJSON(t, filepath.Join(dir, EventsFile), &events)
+	require.Len(t, events, 1)
+	assert.Equal(t, "recent", events[0].Name)
+
+	// the rendered configs which don't exist anymore are skipped
+	files, err := ioutil.ReadDir(filepath.Join(dir, RenderedConfigsDir))
+	require.Nil(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "rendered-worker-1.json", files[0].Name())
+
+	_, err = os.Stat(filepath.Join(dir, PoolsFile))
+	assert.Nil(t, err)
+}
+
+func readJSON(t *testing.T, path string, v interface{}) {
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	require.Nil(t, json.Unmarshal(data, v))
+}
@@ -0,0 +1,214 @@
+package imagepolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	signature "github.com/containers/image/signature"
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/ghodss/yaml"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	policyConfigPath = "/etc/containers/policy.json"
+	// gpgKeysDir is where the keys of an ImagePolicy are written on the node
+	gpgKeysDir = "/etc/pki/containers"
+	// registriesDDir is where the sigstore settings of an ImagePolicy are written on the node
+	registriesDDir = "/etc/containers/registries.d"
+)
+
+// scopeRegex matches a registry host, with an optional port and repository
+// path. Tags and digests aren't accepted, policies apply to whole repositories.
+var scopeRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?(:[0-9]+)?(/[a-z0-9]+([._/-][a-z0-9]+)*)?$`)
+
+// validateImagePolicy returns an error if a scope of the ImagePolicy is invalid
+// or set more than once, or lacks keys.
+func validateImagePolicy(cfg *mcfgv1.ImagePolicy) error {
+	if len(cfg.Spec.Registries) == 0 {
+		return fmt.Errorf("ImagePolicy: at least one registry must be set")
+	}
+	seen := make(map[string]bool)
+	for _, reg := range cfg.Spec.Registries {
+		if !scopeRegex.MatchString(reg.Scope) {
+			return fmt.Errorf("ImagePolicy: invalid scope %q", reg.Scope)
+		}
+		if seen[reg.Scope] {
+			return fmt.Errorf("ImagePolicy: scope %q is set more than once", reg.Scope)
+		}
+		seen[reg.Scope] = true
+		if len(reg.GPGKeys) == 0 {
+			return fmt.Errorf("ImagePolicy: no GPG key set for scope %q", reg.Scope)
+		}
+		for _, key := range reg.GPGKeys {
+			if !strings.Contains(key, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+				return fmt.Errorf("ImagePolicy: the keys of scope %q must be ASCII armored GPG public keys", reg.Scope)
+			}
+		}
+		if reg.Sigstore != "" {
+			u, err := url.Parse(reg.Sigstore)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+				return fmt.Errorf("ImagePolicy: invalid sigstore %q for scope %q: must be an http, https or file URL", reg.Sigstore, reg.Scope)
+			}
+		}
+	}
+	return nil
+}
+
+// scopeFileName returns the name of the files written for scope.
+func scopeFileName(scope string) string {
+	return strings.NewReplacer("/", "_", ":", "_").Replace(scope)
+}
+
+func gpgKeysPath(scope string) string {
+	return path.Join(gpgKeysDir, scopeFileName(scope)+".gpg")
+}
+
+func registriesDPath(scope string) string {
+	return path.Join(registriesDDir, scopeFileName(scope)+".yaml")
+}
+
+// updatePolicyJSON decodes the policy.json the pool would get otherwise and
+// requires the images of the scopes of registries to be signed by their keys.
+// Scopes rejected by the base policy, i.e. blocked registries, stay rejected.
+func updatePolicyJSON(data []byte, registries []mcfgv1.RegistrySignaturePolicy) ([]byte, error) {
+	policyObj := &signature.Policy{}
+	if err := json.NewDecoder(bytes.NewBuffer(data)).Decode(policyObj); err != nil {
+		return nil, fmt.Errorf("error decoding policy json: %v", err)
+	}
+	if policyObj.Transports == nil {
+		policyObj.Transports = map[string]signature.PolicyTransportScopes{}
+	}
+	for _, transport := range []string{"atomic", "docker"} {
+		scopes := policyObj.Transports[transport]
+		if scopes == nil {
+			scopes = make(signature.PolicyTransportScopes)
+		}
+		for _, reg := range registries {
+			if isRejected(scopes[reg.Scope]) {
+				continue
+			}
+			req, err := signature.NewPRSignedByKeyPath(signature.SBKeyTypeGPGKeys, gpgKeysPath(reg.Scope), signature.NewPRMMatchRepoDigestOrExact())
+			if err != nil {
+				return nil, err
+			}
+			scopes[reg.Scope] = signature.PolicyRequirements{req}
+		}
+		policyObj.Transports[transport] = scopes
+	}
+	return json.Marshal(policyObj)
+}
+
+func isRejected(reqs signature.PolicyRequirements) bool {
+	rejected, err := json.Marshal(signature.NewPRReject())
+	if err != nil {
+		return false
+	}
+	for _, req := range reqs {
+		if b, err := json.Marshal(req); err == nil && bytes.Equal(b, rejected) {
+			return true
+		}
+	}
+	return false
+}
+
+// registriesDConfig returns the registries.d configuration reading the
+// signatures of the images of reg from its sigstore.
+func registriesDConfig(reg mcfgv1.RegistrySignaturePolicy) ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}{
+		"docker": map[string]interface{}{
+			reg.Scope: map[string]string{"sigstore": reg.Sigstore},
+		},
+	})
+}
+
+// basePolicyJSON returns the policy.json set by the last of configs, sorted by
+// name, which is applied before the MachineConfig named managedKey.
+func basePolicyJSON(configs []*mcfgv1.MachineConfig, managedKey string) ([]byte, error) {
+	configs = append([]*mcfgv1.MachineConfig{}, configs...)
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name > configs[j].Name })
+	for _, mc := range configs {
+		if mc.Name >= managedKey {
+			continue
+		}
+		ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing MachineConfig %s: %v", mc.Name, err)
+		}
+		for i := len(ignCfg.Storage.Files) - 1; i >= 0; i-- {
+			f := ignCfg.Storage.Files[i]
+			if f.Path != policyConfigPath {
+				continue
+			}
+			contents, err := dataurl.DecodeString(f.Contents.Source)
+			if err != nil {
+				return nil, fmt.Errorf("decoding the policy json of MachineConfig %s: %v", mc.Name, err)
+			}
+			return contents.Data, nil
+		}
+	}
+	return nil, fmt.Errorf("no MachineConfig sets %s", policyConfigPath)
+}
+
+// createNewImagePolicyIgnition returns an Ignition config writing policyJSON,
+// and the keys and sigstore settings of registries.
+func createNewImagePolicyIgnition(policyJSON []byte, registries []mcfgv1.RegistrySignaturePolicy) (igntypes.Config, error) {
+	tempIgnConfig := ctrlcommon.NewIgnConfig()
+	addFile := func(filePath string, data []byte) {
+		mode := 0644
+		du := dataurl.New(data, "text/plain")
+		du.Encoding = dataurl.EncodingASCII
+		tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, igntypes.File{
+			Node: igntypes.Node{
+				Filesystem: "root",
+				Path:       filePath,
+			},
+			FileEmbedded1: igntypes.FileEmbedded1{
+				Mode: &mode,
+				Contents: igntypes.FileContents{
+					Source: du.String(),
+				},
+			},
+		})
+	}
+
+	addFile(policyConfigPath, policyJSON)
+	for _, reg := range registries {
+		var keys strings.Builder
+		for _, key := range reg.GPGKeys {
+			keys.WriteString(strings.TrimSpace(key))
+			keys.WriteString("\n")
+		}
+		addFile(gpgKeysPath(reg.Scope), []byte(keys.String()))
+		if reg.Sigstore == "" {
+			continue
+		}
+		data, err := registriesDConfig(reg)
+		if err != nil {
+			return tempIgnConfig, err
+		}
+		addFile(registriesDPath(reg.Scope), data)
+	}
+	return tempIgnConfig, nil
+}
+
+// getManagedSignaturePolicyKey returns the name of the MachineConfig of pool.
+// It sorts after the one of the container runtime config controller setting
+// the registries, so that the policy.json rendered here is the one applied.
+func getManagedSignaturePolicyKey(pool *mcfgv1.MachineConfigPool) string {
+	return fmt.Sprintf("99-%s-%s-signature-policy", pool.Name, pool.ObjectMeta.UID)
+}
+
+func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.ImagePolicyCondition {
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewImagePolicyCondition(mcfgv1.ImagePolicyStatusConditionType(condition.Type), condition.Status, condition.Message)
+}
@@ -0,0 +1,124 @@
+package imagepolicy
+
+import (
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+const testKey = "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmQINBFzb\n-----END PGP PUBLIC KEY BLOCK-----\n"
+
+func TestValidateImagePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		registries []mcfgv1.RegistrySignaturePolicy
+		wantErr    bool
+	}{
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name: "registry and repository scopes",
+			registries: []mcfgv1.RegistrySignaturePolicy{
+				{Scope: "quay.io", GPGKeys: []string{testKey}},
+				{Scope: "registry.example.com:5000/team/app", GPGKeys: []string{testKey}, Sigstore: "https://sigstore.example.com/signatures"},
+			},
+		},
+		{
+			name:       "scope with a tag",
+			registries: []mcfgv1.RegistrySignaturePolicy{{Scope: "quay.io/app:latest", GPGKeys: []string{testKey}}},
+			wantErr:    true,
+		},
+		{
+			name:       "scope with a scheme",
+			registries: []mcfgv1.RegistrySignaturePolicy{{Scope: "https://quay.io", GPGKeys: []string{testKey}}},
+			wantErr:    true,
+		},
+		{
+			name: "duplicate scope",
+			registries: []mcfgv1.RegistrySignaturePolicy{
+				{Scope: "quay.io", GPGKeys: []string{testKey}},
+				{Scope: "quay.io", GPGKeys: []string{testKey}},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "no key",
+			registries: []mcfgv1.RegistrySignaturePolicy{{Scope: "quay.io"}},
+			wantErr:    true,
+		},
+		{
+			name:       "key not armored",
+			registries: []mcfgv1.RegistrySignaturePolicy{{Scope: "quay.io", GPGKeys: []string{"mQINBFzb"}}},
+			wantErr:    true,
+		},
+		{
+			name:       "sigstore not a URL",
+			registries: []mcfgv1.RegistrySignaturePolicy{{Scope: "quay.io", GPGKeys: []string{testKey}, Sigstore: "sigstore.example.com"}},
+			wantErr:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &mcfgv1.ImagePolicy{Spec: mcfgv1.ImagePolicySpec{Registries: test.registries}}
+			err := validateImagePolicy(cfg)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdatePolicyJSON(t *testing.T) {
+	// The policy of a cluster blocking docker.io
+	base := `{"default":[{"type":"insecureAcceptAnything"}],"transports":{"atomic":{"docker.io":[{"type":"reject"}]},"docker":{"docker.io":[{"type":"reject"}]},"docker-daemon":{"":[{"type":"insecureAcceptAnything"}]}}}`
+	registries := []mcfgv1.RegistrySignaturePolicy{
+		{Scope: "quay.io/team", GPGKeys: []string{testKey}},
+		{Scope: "docker.io", GPGKeys: []string{testKey}},
+	}
+
+	policyJSON, err := updatePolicyJSON([]byte(base), registries)
+	require.NoError(t, err)
+
+	signedBy := `[{"type":"signedBy","keyType":"GPGKeys","keyPath":"/etc/pki/containers/quay.io_team.gpg","signedIdentity":{"type":"matchRepoDigestOrExact"}}]`
+	expected := `{"default":[{"type":"insecureAcceptAnything"}],"transports":{` +
+		`"atomic":{"docker.io":[{"type":"reject"}],"quay.io/team":` + signedBy + `},` +
+		`"docker":{"docker.io":[{"type":"reject"}],"quay.io/team":` + signedBy + `},` +
+		`"docker-daemon":{"":[{"type":"insecureAcceptAnything"}]}}}`
+	assert.JSONEq(t, expected, string(policyJSON))
+}
+
+// policyFile returns the policy.json file of an Ignition config.
+func policyFile(data string) igntypes.File {
+	ignConfig, err := createNewImagePolicyIgnition([]byte(data), nil)
+	if err != nil {
+		panic(err)
+	}
+	return ignConfig.Storage.Files[0]
+}
+
+func TestBasePolicyJSON(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	managedKey := getManagedSignaturePolicyKey(pool)
+	configs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("01-worker-container-runtime", nil, "", []igntypes.File{policyFile("template")}),
+		helpers.NewMachineConfig("99-worker-"+string(pool.UID)+"-registries", nil, "", []igntypes.File{policyFile("registries")}),
+		helpers.NewMachineConfig("99-worker-ssh", nil, "", nil),
+		helpers.NewMachineConfig(managedKey, nil, "", []igntypes.File{policyFile("signature policy")}),
+	}
+
+	data, err := basePolicyJSON(configs, managedKey)
+	require.NoError(t, err)
+	assert.Equal(t, "registries", string(data))
+
+	_, err = basePolicyJSON(configs[2:], managedKey)
+	assert.Error(t, err)
+}
@@ -0,0 +1,464 @@
+package imagepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	macherrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// maxRetries is the number of times an ImagePolicy will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// an ImagePolicy is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+var (
+	// controllerKind contains the schema.GroupVersionKind for this controller type.
+	controllerKind = mcfgv1.SchemeGroupVersion.WithKind("ImagePolicy")
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the image policy controller. It renders the signature
+// requirements of ImagePolicies into the policy.json of the selected pools,
+// on top of the one the other MachineConfigs of the pools set.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler        func(key string) error
+	enqueueImagePolicy func(*mcfgv1.ImagePolicy)
+
+	ipLister       mcfglistersv1.ImagePolicyLister
+	ipListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	mcLister       mcfglistersv1.MachineConfigLister
+	mcListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new image policy controller
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	ipInformer mcfginformersv1.ImagePolicyInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-imagepolicycontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-imagepolicycontroller"),
+	}
+
+	ipInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addImagePolicy,
+		UpdateFunc: ctrl.updateImagePolicy,
+		DeleteFunc: ctrl.deleteImagePolicy,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctrl.addMachineConfigPool,
+	})
+
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfig,
+		UpdateFunc: ctrl.updateMachineConfig,
+		DeleteFunc: ctrl.deleteMachineConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncImagePolicy
+	ctrl.enqueueImagePolicy = ctrl.enqueue
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+
+	ctrl.ipLister = ipInformer.Lister()
+	ctrl.ipListerSynced = ipInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the image policy controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ipListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-ImagePolicyController")
+	defer glog.Info("Shutting down MachineConfigController-ImagePolicyController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-ImagePolicyController", ctrl.queue)
+}
+
+func (ctrl *Controller) updateImagePolicy(old, cur interface{}) {
+	oldConfig := old.(*mcfgv1.ImagePolicy)
+	newConfig := cur.(*mcfgv1.ImagePolicy)
+
+	if !reflect.DeepEqual(oldConfig.Spec, newConfig.Spec) {
+		glog.V(4).Infof("Update ImagePolicy %s", oldConfig.Name)
+		ctrl.enqueueImagePolicy(newConfig)
+	}
+}
+
+func (ctrl *Controller) addImagePolicy(obj interface{}) {
+	cfg := obj.(*mcfgv1.ImagePolicy)
+	glog.V(4).Infof("Adding ImagePolicy %s", cfg.Name)
+	ctrl.enqueueImagePolicy(cfg)
+}
+
+func (ctrl *Controller) deleteImagePolicy(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.ImagePolicy)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.ImagePolicy)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not an ImagePolicy %#v", obj))
+			return
+		}
+	}
+	if err := ctrl.cascadeDelete(cfg); err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't delete MachineConfigs for %#v: %v", cfg, err))
+	} else {
+		glog.V(4).Infof("Deleted ImagePolicy %s and its MachineConfigs", cfg.Name)
+	}
+}
+
+// addMachineConfigPool requeues all the ImagePolicies so that newly created
+// pools get the policy they are selected for.
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	ctrl.enqueueAll()
+}
+
+func (ctrl *Controller) addMachineConfig(obj interface{}) {
+	ctrl.machineConfigChanged(obj.(*mcfgv1.MachineConfig))
+}
+
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	ctrl.machineConfigChanged(cur.(*mcfgv1.MachineConfig))
+}
+
+func (ctrl *Controller) deleteMachineConfig(obj interface{}) {
+	mc, ok := obj.(*mcfgv1.MachineConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		mc, ok = tombstone.Obj.(*mcfgv1.MachineConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a MachineConfig %#v", obj))
+			return
+		}
+	}
+	ctrl.machineConfigChanged(mc)
+}
+
+// machineConfigChanged requeues all the ImagePolicies when a MachineConfig
+// which may set the base policy.json changes, e.g. the one of the registries.
+// The rendered MachineConfigs and the ones of this controller are skipped.
+func (ctrl *Controller) machineConfigChanged(mc *mcfgv1.MachineConfig) {
+	if ref := metav1.GetControllerOf(mc); ref != nil && (ref.Kind == controllerKind.Kind || ref.Kind == "MachineConfigPool") {
+		return
+	}
+	ctrl.enqueueAll()
+}
+
+func (ctrl *Controller) enqueueAll() {
+	cfgs, err := ctrl.ipLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list ImagePolicies: %v", err))
+		return
+	}
+	for _, cfg := range cfgs {
+		ctrl.enqueueImagePolicy(cfg)
+	}
+}
+
+// cascadeDelete removes the MachineConfigs rendered for the given ImagePolicy
+func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.ImagePolicy) error {
+	mcs, err := ctrl.client.MachineconfigurationV1().MachineConfigs().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs.Items {
+		ref := metav1.GetControllerOf(&mc)
+		if ref == nil || ref.Kind != controllerKind.Kind || ref.UID != cfg.UID {
+			continue
+		}
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !macherrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctrl *Controller) enqueue(cfg *mcfgv1.ImagePolicy) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(cfg)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %#v: %v", cfg, err))
+		return
+	}
+	ctrl.queue.Add(key)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.ImagePolicyControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing imagepolicy %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping imagepolicy %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.ImagePolicy, err error, args ...interface{}) error {
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.ipLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = cfg.Generation
+		mcfgv1.SetSyncCondition(&newcfg.Status.Conditions, wrapErrorWithCondition(err, args...))
+		_, lerr := ctrl.client.MachineconfigurationV1().ImagePolicies().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating imagepolicy status: %v", statusUpdateError)
+	}
+	return err
+}
+
+// syncImagePolicy will sync the ImagePolicy with the given key.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncImagePolicy(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing imagepolicy %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing imagepolicy %q (%v)", key, time.Since(startTime))
+	}()
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ctrl.ipLister.Get(name)
+	if macherrors.IsNotFound(err) {
+		glog.V(2).Infof("ImagePolicy %v has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deep-copy otherwise we are mutating our cache.
+	cfg = cfg.DeepCopy()
+
+	if cfg.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if err := validateImagePolicy(cfg); err != nil {
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	pools, err := ctrl.getPoolsForImagePolicy(cfg)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, err)
+	}
+	if len(pools) == 0 {
+		err := fmt.Errorf("ImagePolicy %v does not match any MachineConfigPools", key)
+		glog.V(2).Infof("%v", err)
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	for _, pool := range pools {
+		role := pool.Name
+		managedKey := getManagedSignaturePolicyKey(pool)
+		mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+		if err != nil && !macherrors.IsNotFound(err) {
+			return ctrl.syncStatusOnly(cfg, err, "could not find MachineConfig: %v", managedKey)
+		}
+		isNotFound := macherrors.IsNotFound(err)
+		if !isNotFound {
+			if ref := metav1.GetControllerOf(mc); ref != nil && ref.UID != cfg.UID {
+				err := fmt.Errorf("MachineConfigPool %s already has the image policy of %s %s", pool.Name, ref.Kind, ref.Name)
+				return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+			}
+		}
+
+		basePolicy, err := ctrl.getBasePolicyJSON(pool, managedKey)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not get the policy json of MachineConfigPool %s: %v", pool.Name, err)
+		}
+		policyJSON, err := updatePolicyJSON(basePolicy, cfg.Spec.Registries)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not update policy json: %v", err)
+		}
+		ignConfig, err := createNewImagePolicyIgnition(policyJSON, cfg.Spec.Registries)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not create image policy Ignition: %v", err)
+		}
+		if isNotFound {
+			mc, err = mtmpl.MachineConfigFromIgnConfig(role, managedKey, ignConfig)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not create MachineConfig from new Ignition config: %v", err)
+			}
+		} else {
+			rawIgn, err := json.Marshal(ignConfig)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not marshal image policy Ignition: %v", err)
+			}
+			mc.Spec.Config.Raw = rawIgn
+		}
+
+		mc.SetAnnotations(map[string]string{
+			ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+		})
+		oref := metav1.NewControllerRef(cfg, controllerKind)
+		mc.SetOwnerReferences([]metav1.OwnerReference{*oref})
+
+		// Create or Update, on conflict retry
+		if err := retry.RetryOnConflict(updateBackoff, func() error {
+			var err error
+			if isNotFound {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), mc, metav1.CreateOptions{})
+			} else {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), mc, metav1.UpdateOptions{})
+			}
+			return err
+		}); err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not Create/Update MachineConfig: %v", err)
+		}
+		glog.Infof("Applied ImagePolicy %v on MachineConfigPool %v", key, pool.Name)
+	}
+
+	return ctrl.syncStatusOnly(cfg, nil)
+}
+
+// getBasePolicyJSON returns the policy.json pool would get from its
+// MachineConfigs without the one named managedKey.
+func (ctrl *Controller) getBasePolicyJSON(pool *mcfgv1.MachineConfigPool, managedKey string) ([]byte, error) {
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %v", err)
+	}
+	configs, err := ctrl.mcLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	return basePolicyJSON(configs, managedKey)
+}
+
+func (ctrl *Controller) getPoolsForImagePolicy(config *mcfgv1.ImagePolicy) ([]*mcfgv1.MachineConfigPool, error) {
+	pList, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(config.Spec.MachineConfigPoolSelector)
+	if err != nil {
+		return nil, ctrlcommon.NewForgetError(fmt.Errorf("invalid label selector: %v", err))
+	}
+
+	var pools []*mcfgv1.MachineConfigPool
+	for _, p := range pList {
+		// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
+		if selector.Empty() || !selector.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
@@ -0,0 +1,162 @@
+package imagepolicy
+
+import (
+	"context"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+var (
+	alwaysReady = func() bool { return true }
+
+	basePolicy = `{"default":[{"type":"insecureAcceptAnything"}],"transports":{"docker-daemon":{"":[{"type":"insecureAcceptAnything"}]}}}`
+)
+
+func newImagePolicy(name string, registries []mcfgv1.RegistrySignaturePolicy, selector *metav1.LabelSelector) *mcfgv1.ImagePolicy {
+	return &mcfgv1.ImagePolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
+		Spec: mcfgv1.ImagePolicySpec{
+			MachineConfigPoolSelector: selector,
+			Registries:                registries,
+		},
+	}
+}
+
+func newController(t *testing.T, pools []*mcfgv1.MachineConfigPool, mcs []*mcfgv1.MachineConfig, cfgs []*mcfgv1.ImagePolicy, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	for _, cfg := range cfgs {
+		objects = append(objects, cfg)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		i.Machineconfiguration().V1().MachineConfigs(),
+		i.Machineconfiguration().V1().ImagePolicies(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.mcpListerSynced = alwaysReady
+	c.mcListerSynced = alwaysReady
+	c.ipListerSynced = alwaysReady
+	c.eventRecorder = &record.FakeRecorder{}
+
+	for _, p := range pools {
+		require.Nil(t, i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(p))
+	}
+	for _, mc := range mcs {
+		require.Nil(t, i.Machineconfiguration().V1().MachineConfigs().Informer().GetIndexer().Add(mc))
+	}
+	for _, cfg := range cfgs {
+		require.Nil(t, i.Machineconfiguration().V1().ImagePolicies().Informer().GetIndexer().Add(cfg))
+	}
+	return c, client
+}
+
+func findFile(t *testing.T, ignCfg igntypes.Config, path string) string {
+	for _, f := range ignCfg.Storage.Files {
+		if f.Path == path {
+			contents, err := dataurl.DecodeString(f.Contents.Source)
+			require.Nil(t, err)
+			return string(contents.Data)
+		}
+	}
+	t.Fatalf("no file %s in the Ignition config", path)
+	return ""
+}
+
+func TestImagePolicyCreate(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["signatures"] = "required"
+	mcp2 := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	mc := helpers.NewMachineConfig("01-worker-container-runtime", map[string]string{"node-role/worker": ""}, "", []igntypes.File{policyFile(basePolicy)})
+	ip := newImagePolicy("signed",
+		[]mcfgv1.RegistrySignaturePolicy{{Scope: "registry.example.com/team", GPGKeys: []string{testKey}, Sigstore: "https://sigstore.example.com"}},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "signatures", "required"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp, mcp2}, []*mcfgv1.MachineConfig{mc}, []*mcfgv1.ImagePolicy{ip})
+	require.Nil(t, c.syncHandler(ip.Name))
+
+	rendered, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedSignaturePolicyKey(mcp), metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "worker", rendered.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	require.NotNil(t, metav1.GetControllerOf(rendered))
+	assert.Equal(t, ip.UID, metav1.GetControllerOf(rendered).UID)
+
+	ignCfg, _, err := ign.Parse(rendered.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 3)
+	assert.Contains(t, findFile(t, ignCfg, policyConfigPath), `"registry.example.com/team":[{"type":"signedBy","keyType":"GPGKeys","keyPath":"/etc/pki/containers/registry.example.com_team.gpg"`)
+	assert.Equal(t, testKey, findFile(t, ignCfg, "/etc/pki/containers/registry.example.com_team.gpg"))
+	assert.Equal(t, "docker:\n  registry.example.com/team:\n    sigstore: https://sigstore.example.com\n",
+		findFile(t, ignCfg, "/etc/containers/registries.d/registry.example.com_team.yaml"))
+
+	// The master pool isn't selected
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedSignaturePolicyKey(mcp2), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	ip, err = client.MachineconfigurationV1().ImagePolicies().Get(context.TODO(), ip.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, ip.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.ImagePolicySuccess, ip.Status.Conditions[0].Type)
+}
+
+func TestImagePolicyRejected(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["signatures"] = "required"
+	ip := newImagePolicy("bad",
+		[]mcfgv1.RegistrySignaturePolicy{{Scope: "registry.example.com/team:latest", GPGKeys: []string{testKey}}},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "signatures", "required"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, nil, []*mcfgv1.ImagePolicy{ip})
+	err := c.syncHandler(ip.Name)
+	require.NotNil(t, err)
+	_, ok := err.(*ctrlcommon.ForgetError)
+	assert.True(t, ok)
+
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedSignaturePolicyKey(mcp), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	ip, err = client.MachineconfigurationV1().ImagePolicies().Get(context.TODO(), ip.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, ip.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.ImagePolicyFailure, ip.Status.Conditions[0].Type)
+}
+
+func TestImagePolicyCascadeDelete(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["signatures"] = "required"
+	mc := helpers.NewMachineConfig("01-worker-container-runtime", map[string]string{"node-role/worker": ""}, "", []igntypes.File{policyFile(basePolicy)})
+	ip := newImagePolicy("signed",
+		[]mcfgv1.RegistrySignaturePolicy{{Scope: "quay.io", GPGKeys: []string{testKey}}},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "signatures", "required"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, []*mcfgv1.MachineConfig{mc}, []*mcfgv1.ImagePolicy{ip}, mc)
+	require.Nil(t, c.syncHandler(ip.Name))
+	require.Nil(t, c.cascadeDelete(ip))
+
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedSignaturePolicyKey(mcp), metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), mc.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+}
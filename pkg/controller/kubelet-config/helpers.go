@@ -10,7 +10,6 @@ import (
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/vincent-petithory/dataurl"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -18,6 +17,21 @@ import (
 	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
 )
 
+const (
+	// kubeletLogLevelDropinName is the kubelet.service drop-in that carries
+	// the KUBELET_LOG_LEVEL set through KubeletConfig.Spec.LogLevel
+	kubeletLogLevelDropinName = "20-logging.conf"
+	// maxKubeletLogLevel is the highest verbosity accepted for the kubelet
+	maxKubeletLogLevel = 10
+	// disableSwapKernelArgument keeps systemd from enabling the swap devices
+	// of /etc/fstab, the kubelet doesn't start with swap on unless failSwapOn
+	// is false
+	disableSwapKernelArgument = "systemd.swap=0"
+)
+
+// hugepagesSizes are the huge page sizes accepted in KubeletConfig.Spec.Hugepages
+var hugepagesSizes = map[string]bool{"2M": true, "1G": true}
+
 func createNewKubeletIgnition(jsonConfig []byte) igntypes.Config {
 	mode := 0644
 	du := dataurl.New(jsonConfig, "text/plain")
@@ -39,6 +53,57 @@ func createNewKubeletIgnition(jsonConfig []byte) igntypes.Config {
 	return tempIgnConfig
 }
 
+// addKubeletLogLevelDropin adds a kubelet.service drop-in overriding
+// KUBELET_LOG_LEVEL to the given Ignition config.
+func addKubeletLogLevelDropin(ignCfg *igntypes.Config, logLevel int32) {
+	ignCfg.Systemd.Units = append(ignCfg.Systemd.Units, igntypes.Unit{
+		Name: "kubelet.service",
+		Dropins: []igntypes.SystemdDropin{
+			{
+				Name:     kubeletLogLevelDropinName,
+				Contents: fmt.Sprintf("[Service]\nEnvironment=\"KUBELET_LOG_LEVEL=%d\"\n", logLevel),
+			},
+		},
+	})
+}
+
+// getFailSwapOn returns the failSwapOn the KubeletConfig sets, nil if it
+// doesn't set it. The KubeletConfig must be valid.
+func getFailSwapOn(cfg *mcfgv1.KubeletConfig) *bool {
+	if cfg.Spec.FailSwapOn != nil {
+		return cfg.Spec.FailSwapOn
+	}
+	if cfg.Spec.KubeletConfig == nil || cfg.Spec.KubeletConfig.Raw == nil {
+		return nil
+	}
+	kcDecoded, err := decodeKubeletConfig(cfg.Spec.KubeletConfig.Raw)
+	if err != nil {
+		return nil
+	}
+	return kcDecoded.FailSwapOn
+}
+
+// getKubeletKernelArguments returns the kernel arguments the nodes need for the
+// kubelet to start with the KubeletConfig: the huge pages to allocate, the first
+// size being the default, and swap disabled when the KubeletConfig sets failSwapOn.
+// hugepages must follow the hugepagesz it applies to.
+func getKubeletKernelArguments(cfg *mcfgv1.KubeletConfig) []string {
+	var kargs []string
+	for i, hp := range cfg.Spec.Hugepages {
+		if i == 0 {
+			kargs = append(kargs, fmt.Sprintf("default_hugepagesz=%s", hp.Size))
+		}
+		kargs = append(kargs,
+			fmt.Sprintf("hugepagesz=%s", hp.Size),
+			fmt.Sprintf("hugepages=%d", hp.Count),
+		)
+	}
+	if failSwapOn := getFailSwapOn(cfg); failSwapOn != nil && *failSwapOn {
+		kargs = append(kargs, disableSwapKernelArgument)
+	}
+	return kargs
+}
+
 func createNewDefaultFeatureGate() *osev1.FeatureGate {
 	return &osev1.FeatureGate{
 		Spec: osev1.FeatureGateSpec{
@@ -70,15 +135,52 @@ func getManagedKubeletConfigKey(pool *mcfgv1.MachineConfigPool) string {
 	return fmt.Sprintf("99-%s-%s-kubelet", pool.Name, pool.ObjectMeta.UID)
 }
 
-// validates a KubeletConfig and returns an error if invalid
-func validateUserKubeletConfig(cfg *mcfgv1.KubeletConfig) error {
-	if cfg.Spec.KubeletConfig.Raw == nil {
-		return nil
+// ValidateUserKubeletConfig validates a KubeletConfig and returns an error if invalid
+func ValidateUserKubeletConfig(cfg *mcfgv1.KubeletConfig) error {
+	if cfg.Spec.LogLevel != nil && (*cfg.Spec.LogLevel < 0 || *cfg.Spec.LogLevel > maxKubeletLogLevel) {
+		return fmt.Errorf("KubeletConfig: logLevel must be between 0 and %d, but contains: %d", maxKubeletLogLevel, *cfg.Spec.LogLevel)
+	}
+	sizes := make(map[string]bool)
+	for _, hp := range cfg.Spec.Hugepages {
+		if !hugepagesSizes[hp.Size] {
+			return fmt.Errorf("KubeletConfig: hugepages size must be 2M or 1G, but contains: %s", hp.Size)
+		}
+		if sizes[hp.Size] {
+			return fmt.Errorf("KubeletConfig: hugepages size must be listed once, but contains %s several times", hp.Size)
+		}
+		sizes[hp.Size] = true
+		if hp.Count <= 0 {
+			return fmt.Errorf("KubeletConfig: hugepages count must be positive, but contains: %d", hp.Count)
+		}
+	}
+	if cfg.Spec.KubeletConfig == nil || cfg.Spec.KubeletConfig.Raw == nil {
+		return validateResourceManagers(cfg, &kubeletconfigv1beta1.KubeletConfiguration{})
 	}
 	kcDecoded, err := decodeKubeletConfig(cfg.Spec.KubeletConfig.Raw)
 	if err != nil {
 		return fmt.Errorf("KubeletConfig could not be unmarshalled, err: %v", err)
 	}
+	if err := validateKubeletConfigSchema(cfg.Spec.KubeletConfig.Raw, kcDecoded); err != nil {
+		return err
+	}
+	if cfg.Spec.FailSwapOn != nil && kcDecoded.FailSwapOn != nil && *cfg.Spec.FailSwapOn != *kcDecoded.FailSwapOn {
+		return fmt.Errorf("KubeletConfig: failSwapOn is %t, but the failSwapOn of kubeletConfig is %t", *cfg.Spec.FailSwapOn, *kcDecoded.FailSwapOn)
+	}
+	if err := validateResourceManagers(cfg, kcDecoded); err != nil {
+		return err
+	}
+
+	// Check the fields a user cannot set within the KubeletConfig CR.
+	// If a user were to set these values, the system may become unrecoverable
+	// (ie: not recover after a reboot).
+	// Therefore, if the KubeletConfig CR instance contains a non-zero or non-empty value
+	// for one of them, the MCC will not apply the CR and error out instead.
+	if err := validateMCOOwnedKubeletFields(kcDecoded); err != nil {
+		return err
+	}
+	if err := validateKubeletConfigSchema(cfg.Spec.KubeletConfig.Raw, kcDecoded); err != nil {
+		return err
+	}
 
 	// Check all the fields a user cannot set within the KubeletConfig CR.
 	// If a user were to set these values, the system may become unrecoverable
@@ -108,27 +210,8 @@ func validateUserKubeletConfig(cfg *mcfgv1.KubeletConfig) error {
 }
 
 func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.KubeletConfigCondition {
-	var condition *mcfgv1.KubeletConfigCondition
-	if err != nil {
-		condition = mcfgv1.NewKubeletConfigCondition(
-			mcfgv1.KubeletConfigFailure,
-			corev1.ConditionFalse,
-			fmt.Sprintf("Error: %v", err),
-		)
-	} else {
-		condition = mcfgv1.NewKubeletConfigCondition(
-			mcfgv1.KubeletConfigSuccess,
-			corev1.ConditionTrue,
-			"Success",
-		)
-	}
-	if len(args) > 0 {
-		format, ok := args[0].(string)
-		if ok {
-			condition.Message = fmt.Sprintf(format, args[:1]...)
-		}
-	}
-	return *condition
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewKubeletConfigCondition(mcfgv1.KubeletConfigStatusConditionType(condition.Type), condition.Status, condition.Message)
 }
 
 func decodeKubeletConfig(data []byte) (*kubeletconfigv1beta1.KubeletConfiguration, error) {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
@@ -62,7 +63,7 @@ var updateBackoff = wait.Backoff{
 	Jitter:   1.0,
 }
 
-var errCouldNotFindMCPSet = newForgetError(errors.New("could not find any MachineConfigPool set for KubeletConfig"))
+var errCouldNotFindMCPSet = ctrlcommon.NewForgetError(errors.New("could not find any MachineConfigPool set for KubeletConfig"))
 
 // Controller defines the kubelet config controller.
 type Controller struct {
@@ -88,6 +89,8 @@ type Controller struct {
 
 	queue        workqueue.RateLimitingInterface
 	featureQueue workqueue.RateLimitingInterface
+	// failures tracks the kubeletconfigs failing to sync over and over
+	failures *ctrlcommon.SyncFailures
 
 	// we need this method to mock out patch calls in unit until https://github.com/openshift/machine-config-operator/pull/611#issuecomment-481397185
 	// which is probably going to be in kube 1.14
@@ -103,6 +106,7 @@ func New(
 	featInformer oseinformersv1.FeatureGateInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -112,8 +116,9 @@ func New(
 		templatesDir:  templatesDir,
 		client:        mcfgClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-kubeletconfigcontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-kubeletconfigcontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-kubeletconfigcontroller"),
 		featureQueue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-featurecontroller"),
+		failures:      ctrlcommon.NewSyncFailures(),
 	}
 
 	mkuInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -161,15 +166,10 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	glog.Info("Starting MachineConfigController-KubeletConfigController")
 	defer glog.Info("Shutting down MachineConfigController-KubeletConfigController")
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.worker, time.Second, stopCh)
-	}
-
-	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.featureWorker, time.Second, stopCh)
-	}
-
-	<-stopCh
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Run(workers, ctrl.featureWorker)
+	w.Wait("MachineConfigController-KubeletConfigController", ctrl.queue, ctrl.featureQueue)
 }
 
 func kubeletConfigTriggerObjectChange(old, new *mcfgv1.KubeletConfig) bool {
@@ -284,24 +284,28 @@ func (ctrl *Controller) processNextWorkItem() bool {
 func (ctrl *Controller) handleErr(err error, key interface{}) {
 	if err == nil {
 		ctrl.queue.Forget(key)
+		ctrl.failures.Forget(key)
 		return
 	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.KubeletConfigControllerName).Inc()
 
-	if _, ok := err.(*forgetError); ok {
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
 		ctrl.queue.Forget(key)
 		return
 	}
 
-	if ctrl.queue.NumRequeues(key) < maxRetries {
+	if ctrl.queue.NumRequeues(key) < maxRetries && !ctrl.failures.CrashLooping(key) {
 		glog.V(2).Infof("Error syncing kubeletconfig %v: %v", key, err)
 		ctrl.queue.AddRateLimited(key)
 		return
 	}
 
+	// wait longer every time the kubeletconfig is dropped, instead of hot looping
+	delay := ctrl.failures.Dropped(key)
 	utilruntime.HandleError(err)
-	glog.V(2).Infof("Dropping kubeletconfig %q out of the queue: %v", key, err)
+	glog.V(2).Infof("Dropping kubeletconfig %q out of the queue for %v: %v", key, delay, err)
 	ctrl.queue.Forget(key)
-	ctrl.queue.AddAfter(key, 1*time.Minute)
+	ctrl.queue.AddAfter(key, delay)
 }
 
 func (ctrl *Controller) handleFeatureErr(err error, key interface{}) {
@@ -360,6 +364,32 @@ func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.KubeletConfig, err error, arg
 	return err
 }
 
+// syncCrashLooping reports cfg failed validation with err over and over. The
+// condition and the event are only added once per error, so that retrying cfg
+// doesn't spam its status and the events.
+func (ctrl *Controller) syncCrashLooping(cfg *mcfgv1.KubeletConfig, err error) error {
+	condition := mcfgv1.NewKubeletConfigCondition(mcfgv1.KubeletConfigCrashLooping, corev1.ConditionTrue,
+		fmt.Sprintf("Error: failed validation more than %d times: %v", ctrlcommon.CrashLoopingValidationFailures, err))
+	if n := len(cfg.Status.Conditions); n > 0 && cfg.Status.Conditions[n-1].Type == condition.Type && cfg.Status.Conditions[n-1].Message == condition.Message {
+		return err
+	}
+	ctrl.eventRecorder.Eventf(cfg, corev1.EventTypeWarning, "CrashLooping", condition.Message)
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.mckLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.Conditions = append(newcfg.Status.Conditions, *condition)
+		_, lerr := ctrl.client.MachineconfigurationV1().KubeletConfigs().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating kubeletconfig status: %v", statusUpdateError)
+	}
+	return err
+}
+
 // syncKubeletConfig will sync the kubeletconfig with the given key.
 // This function is not meant to be invoked concurrently with the same key.
 func (ctrl *Controller) syncKubeletConfig(key string) error {
@@ -401,8 +431,11 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 	}
 
 	// Validate the KubeletConfig CR
-	if err := validateUserKubeletConfig(cfg); err != nil {
-		return ctrl.syncStatusOnly(cfg, newForgetError(err))
+	if err := ValidateUserKubeletConfig(cfg); err != nil {
+		if ctrl.failures.Invalid(key) {
+			return ctrl.syncCrashLooping(cfg, ctrlcommon.NewForgetError(err))
+		}
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
 	}
 
 	// Find all MachineConfigPools
@@ -432,6 +465,7 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		return ctrl.syncStatusOnly(cfg, err)
 	}
 
+	var stripped []string
 	for _, pool := range mcpPools {
 		role := pool.Name
 		// Get MachineConfig
@@ -454,25 +488,40 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		if err != nil {
 			return ctrl.syncStatusOnly(cfg, err, "could not deserialize the Kubelet source: %v", err)
 		}
-		specKubeletConfig, err := decodeKubeletConfig(cfg.Spec.KubeletConfig.Raw)
-		if err != nil {
-			return ctrl.syncStatusOnly(cfg, err, "could not deserialize the new Kubelet config: %v", err)
+		if cfg.Spec.KubeletConfig != nil && cfg.Spec.KubeletConfig.Raw != nil {
+			specKubeletConfig, err := decodeKubeletConfig(cfg.Spec.KubeletConfig.Raw)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not deserialize the new Kubelet config: %v", err)
+			}
+			// Keep the values of the MCO for the fields it owns
+			stripped = stripMCOOwnedKubeletFields(specKubeletConfig)
+			// Merge the Old and New
+			err = mergo.Merge(originalKubeConfig, specKubeletConfig, mergo.WithOverride)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not merge original config and new config: %v", err)
+			}
 		}
-		// Merge the Old and New
-		err = mergo.Merge(originalKubeConfig, specKubeletConfig, mergo.WithOverride)
-		if err != nil {
-			return ctrl.syncStatusOnly(cfg, err, "could not merge original config and new config: %v", err)
+		if cfg.Spec.FailSwapOn != nil {
+			originalKubeConfig.FailSwapOn = cfg.Spec.FailSwapOn
 		}
+		originalKubeConfig = getResourceManagers(cfg, originalKubeConfig)
 		// Merge in Feature Gates
 		err = mergo.Merge(&originalKubeConfig.FeatureGates, featureGates, mergo.WithOverride)
 		if err != nil {
 			return ctrl.syncStatusOnly(cfg, err, "could not merge FeatureGates: %v", err)
 		}
+		if err := validateMemoryManager(cfg, originalKubeConfig); err != nil {
+			return ctrl.syncStatusOnly(cfg, err)
+		}
 		// Encode the new config into raw JSON
 		cfgJSON, err := encodeKubeletConfig(originalKubeConfig, kubeletconfigv1beta1.SchemeGroupVersion)
 		if err != nil {
 			return ctrl.syncStatusOnly(cfg, err, "could not encode JSON: %v", err)
 		}
+		cfgJSON, err = addMemoryManager(cfg, cfgJSON)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not add the memory manager to the JSON: %v", err)
+		}
 		if isNotFound {
 			ignConfig := ctrlcommon.NewIgnConfig()
 			mc, err = mtmpl.MachineConfigFromIgnConfig(role, managedKey, ignConfig)
@@ -482,11 +531,16 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 			mc.ObjectMeta.UID = uuid.NewUUID()
 		}
 		cfgIgn := createNewKubeletIgnition(cfgJSON)
+		if cfg.Spec.LogLevel != nil {
+			addKubeletLogLevelDropin(&cfgIgn, *cfg.Spec.LogLevel)
+		}
 		rawIgn, err := json.Marshal(cfgIgn)
 		if err != nil {
 			return ctrl.syncStatusOnly(cfg, err, "could not marshal kubelet config Ignition: %v", err)
 		}
 		mc.Spec.Config.Raw = rawIgn
+		// Set along the kubelet config, so both roll out in the same rendered config
+		mc.Spec.KernelArguments = getKubeletKernelArguments(cfg)
 
 		mc.SetAnnotations(map[string]string{
 			ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
@@ -513,6 +567,10 @@ func (ctrl *Controller) syncKubeletConfig(key string) error {
 		glog.Infof("Applied KubeletConfig %v on MachineConfigPool %v", key, pool.Name)
 	}
 
+	if len(stripped) > 0 {
+		glog.Infof("KubeletConfig %v sets fields owned by the MCO, which were ignored: %s", key, strings.Join(stripped, ", "))
+		return ctrl.syncStatusOnly(cfg, nil, "Success, ignored the fields owned by the MCO: %s", strings.Join(stripped, ", "))
+	}
 	return ctrl.syncStatusOnly(cfg, nil)
 }
 
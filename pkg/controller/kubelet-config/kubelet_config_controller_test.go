@@ -1,14 +1,18 @@
 package kubeletconfig
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/glog"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -19,6 +23,7 @@ import (
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
 
 	osev1 "github.com/openshift/api/config/v1"
@@ -28,6 +33,7 @@ import (
 	oseconfigfake "github.com/openshift/client-go/config/clientset/versioned/fake"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
 	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
 	"github.com/openshift/machine-config-operator/test/helpers"
@@ -103,6 +109,9 @@ func newControllerConfig(name, platform string) *mcfgv1.ControllerConfig {
 		Spec: mcfgv1.ControllerConfigSpec{
 			EtcdDiscoveryDomain: fmt.Sprintf("%s.tt.testing", name),
 			Platform:            platform,
+			Images: map[string]string{
+				mtmpl.InfraImageKey: "image/infraImage:1",
+			},
 		},
 	}
 	return cc
@@ -141,6 +150,7 @@ func (f *fixture) newController() *Controller {
 		featinformer.Config().V1().FeatureGates(),
 		k8sfake.NewSimpleClientset(),
 		f.client,
+		workqueue.DefaultControllerRateLimiter(),
 	)
 	c.mcpListerSynced = alwaysReady
 	c.mckListerSynced = alwaysReady
@@ -478,7 +488,7 @@ func TestKubeletConfigBlacklistedOptions(t *testing.T) {
 	// Failure Tests
 	for _, test := range failureTests {
 		kc := newKubeletConfig(test.name, test.config, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
-		err := validateUserKubeletConfig(kc)
+		err := ValidateUserKubeletConfig(kc)
 		if err == nil {
 			t.Errorf("%s: failed", test.name)
 		}
@@ -487,13 +497,134 @@ func TestKubeletConfigBlacklistedOptions(t *testing.T) {
 	// Successful Tests
 	for _, test := range successTests {
 		kc := newKubeletConfig(test.name, test.config, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
-		err := validateUserKubeletConfig(kc)
+		err := ValidateUserKubeletConfig(kc)
 		if err != nil {
 			t.Errorf("%s: failed with %v. should have succeeded", test.name, err)
 		}
 	}
 }
 
+func TestKubeletConfigMCOOwnedFields(t *testing.T) {
+	// all the rejected fields are reported at once
+	kc := newKubeletConfig("rejected", &kubeletconfigv1beta1.KubeletConfiguration{
+		CgroupDriver:  "cgroupfs",
+		StaticPodPath: "/etc/pods",
+	}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+	err := ValidateUserKubeletConfig(kc)
+	if err == nil || !strings.Contains(err.Error(), "cgroupDriver, staticPodPath") {
+		t.Errorf("expected cgroupDriver and staticPodPath to be rejected, got %v", err)
+	}
+
+	// the stripped fields are accepted and reset
+	anonymous := true
+	kubeconf := &kubeletconfigv1beta1.KubeletConfiguration{
+		MaxPods:       100,
+		SystemCgroups: "/user.slice",
+		Authentication: kubeletconfigv1beta1.KubeletAuthentication{
+			Anonymous: kubeletconfigv1beta1.KubeletAnonymousAuthentication{Enabled: &anonymous},
+			X509:      kubeletconfigv1beta1.KubeletX509Authentication{ClientCAFile: "/etc/ca.crt"},
+		},
+	}
+	kc = newKubeletConfig("stripped", kubeconf, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+	if err := ValidateUserKubeletConfig(kc); err != nil {
+		t.Errorf("stripped fields should be accepted, got %v", err)
+	}
+	stripped := stripMCOOwnedKubeletFields(kubeconf)
+	expected := []string{"authentication.anonymous.enabled", "authentication.x509.clientCAFile", "systemCgroups"}
+	if !reflect.DeepEqual(stripped, expected) {
+		t.Errorf("expected %v to be stripped, got %v", expected, stripped)
+	}
+	if kubeconf.SystemCgroups != "" || kubeconf.Authentication.Anonymous.Enabled != nil || kubeconf.Authentication.X509.ClientCAFile != "" {
+		t.Errorf("fields not stripped: %+v", kubeconf)
+	}
+	if kubeconf.MaxPods != 100 {
+		t.Errorf("maxPods should be kept, got %d", kubeconf.MaxPods)
+	}
+}
+
+func TestKubeletConfigSchemaValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr []string
+	}{
+		{
+			name: "known fields",
+			raw:  `{"maxPods": 100, "cpuManagerPolicy": "static", "systemReserved": {"cpu": "500m"}, "authentication": {"webhook": {"cacheTTL": "2m"}}}`,
+		},
+		{
+			name: "yaml",
+			raw:  "apiVersion: kubelet.config.k8s.io/v1beta1\nkind: KubeletConfiguration\nmaxPods: 100\n",
+		},
+		{
+			name:    "unknown fields",
+			raw:     `{"maxPod": 100, "authentication": {"webhook": {"cacheTtl": "2m"}}}`,
+			wantErr: []string{"unknown fields: authentication.webhook.cacheTtl, maxPod"},
+		},
+		{
+			name: "invalid enums",
+			raw:  `{"cpuManagerPolicy": "dynamic", "enforceNodeAllocatable": ["pods", "system"], "authorization": {"mode": "Always"}}`,
+			wantErr: []string{
+				"authorization.mode must be one of AlwaysAllow, Webhook, but contains: Always",
+				"cpuManagerPolicy must be one of none, static, but contains: dynamic",
+				"enforceNodeAllocatable must be one of pods, system-reserved, kube-reserved, none, but contains: system",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		kc := newKubeletConfig(test.name, &kubeletconfigv1beta1.KubeletConfiguration{}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+		kc.Spec.KubeletConfig.Raw = []byte(test.raw)
+		err := ValidateUserKubeletConfig(kc)
+		if len(test.wantErr) == 0 {
+			if err != nil {
+				t.Errorf("%s: failed with %v. should have succeeded", test.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: should have failed", test.name)
+			continue
+		}
+		for _, want := range test.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: error %q doesn't contain %q", test.name, err, want)
+			}
+		}
+	}
+}
+
+func TestKubeletConfigLogLevel(t *testing.T) {
+	for _, level := range []int32{-1, 11} {
+		kc := newKubeletConfig("log-level", &kubeletconfigv1beta1.KubeletConfiguration{}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+		kc.Spec.LogLevel = &level
+		if err := ValidateUserKubeletConfig(kc); err == nil {
+			t.Errorf("logLevel %d: expected validation error", level)
+		}
+	}
+
+	level := int32(6)
+	kc := newKubeletConfig("log-level", &kubeletconfigv1beta1.KubeletConfiguration{}, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", ""))
+	kc.Spec.KubeletConfig = nil
+	kc.Spec.LogLevel = &level
+	if err := ValidateUserKubeletConfig(kc); err != nil {
+		t.Errorf("logLevel %d: unexpected validation error: %v", level, err)
+	}
+
+	ignCfg := createNewKubeletIgnition([]byte("{}"))
+	addKubeletLogLevelDropin(&ignCfg, level)
+	if len(ignCfg.Systemd.Units) != 1 || ignCfg.Systemd.Units[0].Name != "kubelet.service" {
+		t.Fatalf("expected a kubelet.service unit, got %+v", ignCfg.Systemd.Units)
+	}
+	dropins := ignCfg.Systemd.Units[0].Dropins
+	if len(dropins) != 1 || dropins[0].Name != kubeletLogLevelDropinName {
+		t.Fatalf("expected a %s drop-in, got %+v", kubeletLogLevelDropinName, dropins)
+	}
+	if expected := "[Service]\nEnvironment=\"KUBELET_LOG_LEVEL=6\"\n"; dropins[0].Contents != expected {
+		t.Errorf("expected drop-in contents %q, got %q", expected, dropins[0].Contents)
+	}
+}
+
 func TestKubeletFeatureExists(t *testing.T) {
 	for _, platform := range []string{"aws", "none", "unrecognized"} {
 		t.Run(platform, func(t *testing.T) {
@@ -542,3 +673,235 @@ func getKeyFromFeatureGate(gate *osev1.FeatureGate, t *testing.T) string {
 	}
 	return key
 }
+
+func TestKubeletConfigHugepagesAndSwap(t *testing.T) {
+	enabled, disabled := true, false
+	selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", "")
+
+	invalid := map[string]*mcfgv1.KubeletConfig{
+		"repeated size": newKubeletConfig("hugepages", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"unknown size":  newKubeletConfig("hugepages", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"no pages":      newKubeletConfig("hugepages", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"failSwapOn":    newKubeletConfig("swap", &kubeletconfigv1beta1.KubeletConfiguration{FailSwapOn: &enabled}, selector),
+	}
+	invalid["repeated size"].Spec.Hugepages = []mcfgv1.HugepagesAllocation{{Size: "1G", Count: 4}, {Size: "1G", Count: 8}}
+	invalid["unknown size"].Spec.Hugepages = []mcfgv1.HugepagesAllocation{{Size: "16G", Count: 1}}
+	invalid["no pages"].Spec.Hugepages = []mcfgv1.HugepagesAllocation{{Size: "1G", Count: 0}}
+	invalid["failSwapOn"].Spec.FailSwapOn = &disabled
+	for name, kc := range invalid {
+		if err := ValidateUserKubeletConfig(kc); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	kc := newKubeletConfig("hugepages", &kubeletconfigv1beta1.KubeletConfiguration{}, selector)
+	kc.Spec.Hugepages = []mcfgv1.HugepagesAllocation{{Size: "1G", Count: 4}}
+	kc.Spec.FailSwapOn = &enabled
+	if err := ValidateUserKubeletConfig(kc); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	expected := []string{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=4", disableSwapKernelArgument}
+	if kargs := getKubeletKernelArguments(kc); !reflect.DeepEqual(expected, kargs) {
+		t.Errorf("expected kernel arguments %v, got %v", expected, kargs)
+	}
+
+	// the first size is the default one
+	kc = newKubeletConfig("hugepages", &kubeletconfigv1beta1.KubeletConfiguration{}, selector)
+	kc.Spec.Hugepages = []mcfgv1.HugepagesAllocation{{Size: "1G", Count: 4}, {Size: "2M", Count: 512}}
+	if err := ValidateUserKubeletConfig(kc); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	expected = []string{"default_hugepagesz=1G", "hugepagesz=1G", "hugepages=4", "hugepagesz=2M", "hugepages=512"}
+	if kargs := getKubeletKernelArguments(kc); !reflect.DeepEqual(expected, kargs) {
+		t.Errorf("expected kernel arguments %v, got %v", expected, kargs)
+	}
+
+	// swap is left alone when the kubelet accepts it
+	kc = newKubeletConfig("swap", &kubeletconfigv1beta1.KubeletConfiguration{FailSwapOn: &disabled}, selector)
+	if kargs := getKubeletKernelArguments(kc); len(kargs) != 0 {
+		t.Errorf("expected no kernel arguments, got %v", kargs)
+	}
+	kc = newKubeletConfig("swap", &kubeletconfigv1beta1.KubeletConfiguration{FailSwapOn: &enabled}, selector)
+	if kargs := getKubeletKernelArguments(kc); !reflect.DeepEqual([]string{disableSwapKernelArgument}, kargs) {
+		t.Errorf("expected kernel arguments %v, got %v", []string{disableSwapKernelArgument}, kargs)
+	}
+}
+
+func TestKubeletConfigResourceManagers(t *testing.T) {
+	selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", "")
+
+	invalid := map[string]*mcfgv1.KubeletConfig{
+		"unknown cpu manager policy":      newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"unknown topology manager policy": newKubeletConfig("topology", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"invalid reserved cpus":           newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"reversed reserved cpus range":    newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"disagrees with kubeletConfig":    newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{CPUManagerPolicy: "none"}, selector),
+		"static without reserved cpus": newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{
+			SystemReserved: map[string]string{"cpu": "0", "memory": "1Gi"},
+		}, selector),
+		"static in kubeletConfig without reserved cpus": newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{
+			CPUManagerPolicy: "static",
+			SystemReserved:   map[string]string{"cpu": "0"},
+		}, selector),
+	}
+	invalid["unknown cpu manager policy"].Spec.CPUManagerPolicy = "dynamic"
+	invalid["unknown topology manager policy"].Spec.TopologyManagerPolicy = "single-socket"
+	invalid["invalid reserved cpus"].Spec.ReservedSystemCPUs = "0,a"
+	invalid["reversed reserved cpus range"].Spec.ReservedSystemCPUs = "3-1"
+	invalid["disagrees with kubeletConfig"].Spec.CPUManagerPolicy = "static"
+	invalid["static without reserved cpus"].Spec.CPUManagerPolicy = "static"
+	for name, kc := range invalid {
+		if err := ValidateUserKubeletConfig(kc); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	valid := map[string]*mcfgv1.KubeletConfig{
+		"reserved system cpus": newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{
+			SystemReserved: map[string]string{"cpu": "0"},
+		}, selector),
+		"cpu reserved by kubeReserved": newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{
+			SystemReserved: map[string]string{"cpu": "0"},
+			KubeReserved:   map[string]string{"cpu": "1"},
+		}, selector),
+		// the templates reserve cpu in systemReserved
+		"default reservation":   newKubeletConfig("cpu", &kubeletconfigv1beta1.KubeletConfiguration{}, selector),
+		"without kubeletConfig": {Spec: mcfgv1.KubeletConfigSpec{MachineConfigPoolSelector: selector}},
+	}
+	valid["reserved system cpus"].Spec.ReservedSystemCPUs = "0-1,4"
+	for name, kc := range valid {
+		kc.Spec.CPUManagerPolicy = "static"
+		kc.Spec.TopologyManagerPolicy = "single-numa-node"
+		if err := ValidateUserKubeletConfig(kc); err != nil {
+			t.Errorf("%s: unexpected validation error: %v", name, err)
+		}
+	}
+
+	merged := getResourceManagers(valid["reserved system cpus"], &kubeletconfigv1beta1.KubeletConfiguration{CPUManagerPolicy: "none"})
+	if merged.CPUManagerPolicy != "static" || merged.TopologyManagerPolicy != "single-numa-node" || merged.ReservedSystemCPUs != "0-1,4" {
+		t.Errorf("expected the policies of the spec to be rendered, got %q, %q, %q", merged.CPUManagerPolicy, merged.TopologyManagerPolicy, merged.ReservedSystemCPUs)
+	}
+}
+
+func TestKubeletConfigMemoryManager(t *testing.T) {
+	selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "", "")
+	reservation := func(numaNode int32, limits ...string) mcfgv1.MemoryReservation {
+		r := mcfgv1.MemoryReservation{NumaNode: numaNode, Limits: corev1.ResourceList{}}
+		for i := 0; i < len(limits); i += 2 {
+			r.Limits[corev1.ResourceName(limits[i])] = resource.MustParse(limits[i+1])
+		}
+		return r
+	}
+
+	invalid := map[string]mcfgv1.KubeletConfigSpec{
+		"unknown memory manager policy": {MemoryManagerPolicy: "dynamic"},
+		"reserved memory without policy": {
+			ReservedMemory: []mcfgv1.MemoryReservation{reservation(0, "memory", "1Gi")},
+		},
+		"reserved memory with none policy": {
+			MemoryManagerPolicy: "None",
+			ReservedMemory:      []mcfgv1.MemoryReservation{reservation(0, "memory", "1Gi")},
+		},
+		"negative numa node": {
+			MemoryManagerPolicy: "Static",
+			ReservedMemory:      []mcfgv1.MemoryReservation{reservation(-1, "memory", "1Gi")},
+		},
+		"numa node reserved twice": {
+			MemoryManagerPolicy: "Static",
+			ReservedMemory:      []mcfgv1.MemoryReservation{reservation(0, "memory", "512Mi"), reservation(0, "memory", "512Mi")},
+		},
+		"no limits": {
+			MemoryManagerPolicy: "Static",
+			ReservedMemory:      []mcfgv1.MemoryReservation{reservation(0)},
+		},
+		"cpu limit": {
+			MemoryManagerPolicy: "Static",
+			ReservedMemory:      []mcfgv1.MemoryReservation{reservation(0, "memory", "1Gi", "cpu", "1")},
+		},
+		"zero limit": {
+			MemoryManagerPolicy: "Static",
+			ReservedMemory:      []mcfgv1.MemoryReservation{reservation(0, "memory", "0")},
+		},
+	}
+	for name, spec := range invalid {
+		spec.MachineConfigPoolSelector = selector
+		if err := ValidateUserKubeletConfig(&mcfgv1.KubeletConfig{Spec: spec}); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	inKubeletConfig := newKubeletConfig("memory", &kubeletconfigv1beta1.KubeletConfiguration{}, selector)
+	inKubeletConfig.Spec.KubeletConfig.Raw = []byte(`{"memoryManagerPolicy":"Static"}`)
+	if err := ValidateUserKubeletConfig(inKubeletConfig); err == nil {
+		t.Errorf("expected memoryManagerPolicy in kubeletConfig to fail validation")
+	}
+
+	kc := &mcfgv1.KubeletConfig{Spec: mcfgv1.KubeletConfigSpec{
+		MachineConfigPoolSelector: selector,
+		MemoryManagerPolicy:       "Static",
+		ReservedMemory: []mcfgv1.MemoryReservation{
+			reservation(0, "memory", "612Mi", "hugepages-2Mi", "64Mi"),
+			reservation(1, "memory", "512Mi"),
+		},
+	}}
+	if err := ValidateUserKubeletConfig(kc); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	// The templates reserve 1Gi of memory, the kubelet evicts at 100Mi by default
+	rendered := func(evictionHard map[string]string, features ...string) *kubeletconfigv1beta1.KubeletConfiguration {
+		rkc := &kubeletconfigv1beta1.KubeletConfiguration{
+			SystemReserved: map[string]string{"cpu": "500m", "memory": "1Gi"},
+			KubeReserved:   map[string]string{"hugepages-2Mi": "64Mi"},
+			EvictionHard:   evictionHard,
+			FeatureGates:   map[string]bool{},
+		}
+		for _, f := range features {
+			rkc.FeatureGates[f] = true
+		}
+		return rkc
+	}
+	if err := validateMemoryManager(kc, rendered(nil, "MemoryManager")); err != nil {
+		t.Errorf("unexpected memory manager error: %v", err)
+	}
+	for name, rkc := range map[string]*kubeletconfigv1beta1.KubeletConfiguration{
+		"without feature gate":         rendered(nil),
+		"more eviction":                rendered(map[string]string{"memory.available": "200Mi"}, "MemoryManager"),
+		"no memory eviction":           rendered(map[string]string{"nodefs.available": "10%"}, "MemoryManager"),
+		"percentage eviction":          rendered(map[string]string{"memory.available": "5%"}, "MemoryManager"),
+		"huge pages not kube reserved": {FeatureGates: map[string]bool{"MemoryManager": true}, SystemReserved: map[string]string{"memory": "1Gi"}},
+	} {
+		if err := validateMemoryManager(kc, rkc); err == nil {
+			t.Errorf("%s: expected memory manager error", name)
+		}
+	}
+	none := kc.DeepCopy()
+	none.Spec.MemoryManagerPolicy = "None"
+	none.Spec.ReservedMemory = nil
+	if err := validateMemoryManager(none, rendered(nil)); err != nil {
+		t.Errorf("unexpected memory manager error for the None policy: %v", err)
+	}
+
+	cfgJSON, err := addMemoryManager(kc, []byte(`{"kind":"KubeletConfiguration","maxPods":250}`))
+	if err != nil {
+		t.Fatalf("could not add the memory manager: %v", err)
+	}
+	var out struct {
+		MaxPods             int32                      `json:"maxPods"`
+		MemoryManagerPolicy string                     `json:"memoryManagerPolicy"`
+		ReservedMemory      []mcfgv1.MemoryReservation `json:"reservedMemory"`
+	}
+	if err := json.Unmarshal(cfgJSON, &out); err != nil {
+		t.Fatalf("could not unmarshal %s: %v", cfgJSON, err)
+	}
+	if out.MaxPods != 250 || out.MemoryManagerPolicy != "Static" || len(out.ReservedMemory) != 2 {
+		t.Fatalf("expected the memory manager of the spec to be rendered, got %s", cfgJSON)
+	}
+	if q := out.ReservedMemory[0].Limits[corev1.ResourceName("hugepages-2Mi")]; q.String() != "64Mi" {
+		t.Errorf("expected 64Mi of 2Mi huge pages reserved on NUMA node 0, got %s", q.String())
+	}
+	unchanged, err := addMemoryManager(&mcfgv1.KubeletConfig{}, []byte(`{"maxPods":250}`))
+	if err != nil || string(unchanged) != `{"maxPods":250}` {
+		t.Errorf("expected a KubeletConfig without memory manager to keep the JSON, got %s, %v", unchanged, err)
+	}
+}
@@ -195,6 +195,13 @@ func (ctrl *Controller) deleteFeature(obj interface{}) {
 	glog.V(4).Infof("Deleted Feature %s and restored default config", features.Name)
 }
 
+// apiServerFeatureGates are the feature gates of the FeatureSets that only the
+// API server reads. They're left out of the kubelet config, as adding them
+// would change the kubelet config of every pool.
+var apiServerFeatureGates = map[string]bool{
+	"APIPriorityAndFairness": true,
+}
+
 //nolint:gocritic
 func (ctrl *Controller) generateFeatureMap(features *osev1.FeatureGate) (*map[string]bool, error) {
 	rv := make(map[string]bool)
@@ -203,10 +210,14 @@ func (ctrl *Controller) generateFeatureMap(features *osev1.FeatureGate) (*map[st
 		return &rv, fmt.Errorf("enabled FeatureSet %v does not have a corresponding config", features.Spec.FeatureSet)
 	}
 	for _, featEnabled := range set.Enabled {
-		rv[featEnabled] = true
+		if !apiServerFeatureGates[featEnabled] {
+			rv[featEnabled] = true
+		}
 	}
 	for _, featDisabled := range set.Disabled {
-		rv[featDisabled] = false
+		if !apiServerFeatureGates[featDisabled] {
+			rv[featDisabled] = false
+		}
 	}
 	// The CustomNoUpgrade options will override our defaults. This is
 	// expected behavior and can potentially break a cluster.
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	osev1 "github.com/openshift/api/config/v1"
 	"github.com/vincent-petithory/dataurl"
 
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -61,3 +62,27 @@ func TestFeaturesDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestFeatureMapAPIServerGates(t *testing.T) {
+	f := newFixture(t)
+	ctrl := f.newController()
+
+	defaultFeatureGates, err := ctrl.generateFeatureMap(createNewDefaultFeatureGate())
+	if err != nil {
+		t.Fatalf("could not generate defaultFeatureGates: %v", err)
+	}
+	if _, ok := (*defaultFeatureGates)["APIPriorityAndFairness"]; ok {
+		t.Errorf("expected APIPriorityAndFairness to be left out of the kubelet feature gates, got %v", *defaultFeatureGates)
+	}
+
+	custom := createNewDefaultFeatureGate()
+	custom.Spec.FeatureSet = osev1.CustomNoUpgrade
+	custom.Spec.CustomNoUpgrade = &osev1.CustomFeatureGates{Enabled: []string{"APIPriorityAndFairness"}}
+	customFeatureGates, err := ctrl.generateFeatureMap(custom)
+	if err != nil {
+		t.Fatalf("could not generate customFeatureGates: %v", err)
+	}
+	if !(*customFeatureGates)["APIPriorityAndFairness"] {
+		t.Errorf("expected APIPriorityAndFairness enabled by CustomNoUpgrade to be kept, got %v", *customFeatureGates)
+	}
+}
@@ -0,0 +1,111 @@
+package kubeletconfig
+
+import (
+	"fmt"
+	"strings"
+
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+// mcoOwnedKubeletField is a field of the KubeletConfiguration the MCO sets so
+// that the nodes bootstrap and join the cluster, which users can't override.
+type mcoOwnedKubeletField struct {
+	path  string
+	isSet func(*kubeletconfigv1beta1.KubeletConfiguration) bool
+	// strip resets the field in a user KubeletConfiguration, so that the one of
+	// the MCO is kept. KubeletConfigs setting the fields without it are rejected,
+	// as the nodes would misbehave without the setting.
+	strip func(*kubeletconfigv1beta1.KubeletConfiguration)
+}
+
+var mcoOwnedKubeletFields = []mcoOwnedKubeletField{
+	{
+		path:  "cgroupDriver",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.CgroupDriver != "" },
+	},
+	{
+		path:  "clusterDNS",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return len(kc.ClusterDNS) > 0 },
+	},
+	{
+		path:  "clusterDomain",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.ClusterDomain != "" },
+	},
+	{
+		path:  "featureGates",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return len(kc.FeatureGates) > 0 },
+	},
+	{
+		path: "runtimeRequestTimeout",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool {
+			return kc.RuntimeRequestTimeout.Duration != 0
+		},
+	},
+	{
+		path:  "staticPodPath",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.StaticPodPath != "" },
+	},
+	{
+		path: "authentication.anonymous.enabled",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool {
+			return kc.Authentication.Anonymous.Enabled != nil
+		},
+		strip: func(kc *kubeletconfigv1beta1.KubeletConfiguration) { kc.Authentication.Anonymous.Enabled = nil },
+	},
+	{
+		path: "authentication.x509.clientCAFile",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool {
+			return kc.Authentication.X509.ClientCAFile != ""
+		},
+		strip: func(kc *kubeletconfigv1beta1.KubeletConfiguration) { kc.Authentication.X509.ClientCAFile = "" },
+	},
+	{
+		path:  "cgroupRoot",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.CgroupRoot != "" },
+		strip: func(kc *kubeletconfigv1beta1.KubeletConfiguration) { kc.CgroupRoot = "" },
+	},
+	{
+		path:  "systemCgroups",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.SystemCgroups != "" },
+		strip: func(kc *kubeletconfigv1beta1.KubeletConfiguration) { kc.SystemCgroups = "" },
+	},
+	// the serving certificates are bootstrapped, see serverTLSBootstrap
+	{
+		path:  "tlsCertFile",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.TLSCertFile != "" },
+		strip: func(kc *kubeletconfigv1beta1.KubeletConfiguration) { kc.TLSCertFile = "" },
+	},
+	{
+		path:  "tlsPrivateKeyFile",
+		isSet: func(kc *kubeletconfigv1beta1.KubeletConfiguration) bool { return kc.TLSPrivateKeyFile != "" },
+		strip: func(kc *kubeletconfigv1beta1.KubeletConfiguration) { kc.TLSPrivateKeyFile = "" },
+	},
+}
+
+// validateMCOOwnedKubeletFields returns an error listing the fields owned by
+// the MCO which kc sets and which can't be stripped.
+func validateMCOOwnedKubeletFields(kc *kubeletconfigv1beta1.KubeletConfiguration) error {
+	var rejected []string
+	for _, f := range mcoOwnedKubeletFields {
+		if f.strip == nil && f.isSet(kc) {
+			rejected = append(rejected, f.path)
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("KubeletConfiguration: %s not allowed to be set, the MCO owns them", strings.Join(rejected, ", "))
+	}
+	return nil
+}
+
+// stripMCOOwnedKubeletFields resets the fields owned by the MCO which kc sets
+// and returns their paths.
+func stripMCOOwnedKubeletFields(kc *kubeletconfigv1beta1.KubeletConfiguration) []string {
+	var stripped []string
+	for _, f := range mcoOwnedKubeletFields {
+		if f.strip != nil && f.isSet(kc) {
+			f.strip(kc)
+			stripped = append(stripped, f.path)
+		}
+	}
+	return stripped
+}
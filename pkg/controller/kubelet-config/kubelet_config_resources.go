@@ -0,0 +1,275 @@
+package kubeletconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+const (
+	cpuManagerPolicyNone   = "none"
+	cpuManagerPolicyStatic = "static"
+
+	// The memory manager came with the kubelet 1.21, the KubeletConfiguration
+	// we vendor doesn't know its fields.
+	memoryManagerPolicyNone   = "None"
+	memoryManagerPolicyStatic = "Static"
+	memoryManagerFeatureGate  = "MemoryManager"
+
+	// defaultEvictionHardMemory is the memory.available hard eviction
+	// threshold of the kubelet when evictionHard isn't set.
+	defaultEvictionHardMemory = "100Mi"
+)
+
+// getResourceManagers returns the kubelet config kc with the resource manager
+// policies of the KubeletConfig spec, which take precedence.
+func getResourceManagers(cfg *mcfgv1.KubeletConfig, kc *kubeletconfigv1beta1.KubeletConfiguration) *kubeletconfigv1beta1.KubeletConfiguration {
+	merged := kc.DeepCopy()
+	if cfg.Spec.CPUManagerPolicy != "" {
+		merged.CPUManagerPolicy = cfg.Spec.CPUManagerPolicy
+	}
+	if cfg.Spec.TopologyManagerPolicy != "" {
+		merged.TopologyManagerPolicy = cfg.Spec.TopologyManagerPolicy
+	}
+	if cfg.Spec.ReservedSystemCPUs != "" {
+		merged.ReservedSystemCPUs = cfg.Spec.ReservedSystemCPUs
+	}
+	return merged
+}
+
+// addMemoryManager returns the encoded kubelet config cfgJSON with the memory
+// manager of the KubeletConfig spec.
+func addMemoryManager(cfg *mcfgv1.KubeletConfig, cfgJSON []byte) ([]byte, error) {
+	if cfg.Spec.MemoryManagerPolicy == "" && len(cfg.Spec.ReservedMemory) == 0 {
+		return cfgJSON, nil
+	}
+	kc := map[string]interface{}{}
+	if err := json.Unmarshal(cfgJSON, &kc); err != nil {
+		return nil, err
+	}
+	if cfg.Spec.MemoryManagerPolicy != "" {
+		kc["memoryManagerPolicy"] = cfg.Spec.MemoryManagerPolicy
+	}
+	if len(cfg.Spec.ReservedMemory) > 0 {
+		kc["reservedMemory"] = cfg.Spec.ReservedMemory
+	}
+	return json.Marshal(kc)
+}
+
+// validateResourceManagers checks that the resource manager policies of the
+// KubeletConfig are valid, agree with the ones of its kubeletConfig, and that
+// the CPUs the static CPU manager policy needs are reserved.
+func validateResourceManagers(cfg *mcfgv1.KubeletConfig, kc *kubeletconfigv1beta1.KubeletConfiguration) error {
+	if p := cfg.Spec.CPUManagerPolicy; p != "" && p != cpuManagerPolicyNone && p != cpuManagerPolicyStatic {
+		return fmt.Errorf("KubeletConfig: cpuManagerPolicy must be one of %s, %s, but contains: %s", cpuManagerPolicyNone, cpuManagerPolicyStatic, p)
+	}
+	if p := cfg.Spec.TopologyManagerPolicy; p != "" && !isTopologyManagerPolicy(p) {
+		return fmt.Errorf("KubeletConfig: topologyManagerPolicy must be one of %s, but contains: %s", strings.Join(topologyManagerPolicies, ", "), p)
+	}
+	if p := cfg.Spec.MemoryManagerPolicy; p != "" && p != memoryManagerPolicyNone && p != memoryManagerPolicyStatic {
+		return fmt.Errorf("KubeletConfig: memoryManagerPolicy must be one of %s, %s, but contains: %s", memoryManagerPolicyNone, memoryManagerPolicyStatic, p)
+	}
+	for _, field := range []struct{ name, spec, kubeletConfig string }{
+		{"cpuManagerPolicy", cfg.Spec.CPUManagerPolicy, kc.CPUManagerPolicy},
+		{"topologyManagerPolicy", cfg.Spec.TopologyManagerPolicy, kc.TopologyManagerPolicy},
+		{"reservedSystemCPUs", cfg.Spec.ReservedSystemCPUs, kc.ReservedSystemCPUs},
+	} {
+		if field.spec != "" && field.kubeletConfig != "" && field.spec != field.kubeletConfig {
+			return fmt.Errorf("KubeletConfig: %s is %s, but the %s of kubeletConfig is %s", field.name, field.spec, field.name, field.kubeletConfig)
+		}
+	}
+
+	merged := getResourceManagers(cfg, kc)
+	if merged.ReservedSystemCPUs != "" {
+		if err := validateCPUSet(merged.ReservedSystemCPUs); err != nil {
+			return fmt.Errorf("KubeletConfig: reservedSystemCPUs must be a list of CPUs, e.g. 0-1,4, but contains: %s: %v", merged.ReservedSystemCPUs, err)
+		}
+	}
+	if merged.CPUManagerPolicy == cpuManagerPolicyStatic && !reservesCPUs(merged) {
+		return fmt.Errorf("KubeletConfig: the static cpuManagerPolicy needs reserved CPUs, set reservedSystemCPUs or the cpu of systemReserved or kubeReserved")
+	}
+	if len(cfg.Spec.ReservedMemory) > 0 {
+		if cfg.Spec.MemoryManagerPolicy != memoryManagerPolicyStatic {
+			return fmt.Errorf("KubeletConfig: reservedMemory needs the %s memoryManagerPolicy", memoryManagerPolicyStatic)
+		}
+		if err := validateReservedMemory(cfg.Spec.ReservedMemory); err != nil {
+			return fmt.Errorf("KubeletConfig: invalid reservedMemory: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateMemoryManager checks the Static memory manager policy of the
+// KubeletConfig against the kubelet config kc it renders to: the kubelet
+// refuses to start unless the MemoryManager feature gate is enabled and the
+// reservedMemory of all the NUMA nodes adds up to the memory reserved by
+// kubeReserved, systemReserved and the memory.available hard eviction
+// threshold, and the huge pages reserved by kubeReserved and systemReserved.
+func validateMemoryManager(cfg *mcfgv1.KubeletConfig, kc *kubeletconfigv1beta1.KubeletConfiguration) error {
+	if cfg.Spec.MemoryManagerPolicy != memoryManagerPolicyStatic {
+		return nil
+	}
+	if !kc.FeatureGates[memoryManagerFeatureGate] {
+		return fmt.Errorf("KubeletConfig: the %s memoryManagerPolicy needs the %s feature gate", memoryManagerPolicyStatic, memoryManagerFeatureGate)
+	}
+	expected, err := getMemoryReservation(kc)
+	if err != nil {
+		return fmt.Errorf("KubeletConfig: %v", err)
+	}
+	reserved := corev1.ResourceList{}
+	for _, r := range cfg.Spec.ReservedMemory {
+		for name, quantity := range r.Limits {
+			sum := reserved[name]
+			sum.Add(quantity)
+			reserved[name] = sum
+		}
+	}
+	names := []string{}
+	for name := range expected {
+		names = append(names, string(name))
+	}
+	for name := range reserved {
+		if _, ok := expected[name]; !ok {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		want, got := expected[corev1.ResourceName(name)], reserved[corev1.ResourceName(name)]
+		if want.Cmp(got) != 0 {
+			return fmt.Errorf("KubeletConfig: reservedMemory reserves %s of %s, but kubeReserved, systemReserved and evictionHard reserve %s", got.String(), name, want.String())
+		}
+	}
+	return nil
+}
+
+// getMemoryReservation returns the memory and huge pages the kubelet config kc
+// reserves for the node, per resource.
+func getMemoryReservation(kc *kubeletconfigv1beta1.KubeletConfiguration) (corev1.ResourceList, error) {
+	reservation := corev1.ResourceList{}
+	add := func(name, value, field string) error {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s: %s", field, name, value)
+		}
+		sum := reservation[corev1.ResourceName(name)]
+		sum.Add(q)
+		reservation[corev1.ResourceName(name)] = sum
+		return nil
+	}
+	for field, reserved := range map[string]map[string]string{"kubeReserved": kc.KubeReserved, "systemReserved": kc.SystemReserved} {
+		for name, value := range reserved {
+			if name != string(corev1.ResourceMemory) && !strings.HasPrefix(name, corev1.ResourceHugePagesPrefix) {
+				continue
+			}
+			if err := add(name, value, field); err != nil {
+				return nil, err
+			}
+		}
+	}
+	threshold := defaultEvictionHardMemory
+	if kc.EvictionHard != nil {
+		threshold = kc.EvictionHard["memory.available"]
+	}
+	if strings.HasSuffix(threshold, "%") {
+		return nil, fmt.Errorf("the %s memoryManagerPolicy needs a quantity for the memory.available hard eviction threshold, but contains: %s", memoryManagerPolicyStatic, threshold)
+	}
+	if threshold != "" {
+		if err := add(string(corev1.ResourceMemory), threshold, "evictionHard"); err != nil {
+			return nil, err
+		}
+	}
+	return reservation, nil
+}
+
+// validateReservedMemory checks that reserved holds at most one reservation
+// per NUMA node, of memory and huge pages only, as the kubelet requires.
+func validateReservedMemory(reserved []mcfgv1.MemoryReservation) error {
+	numaNodes := map[int32]bool{}
+	for _, r := range reserved {
+		if r.NumaNode < 0 {
+			return fmt.Errorf("invalid NUMA node %d", r.NumaNode)
+		}
+		if numaNodes[r.NumaNode] {
+			return fmt.Errorf("NUMA node %d is reserved more than once", r.NumaNode)
+		}
+		numaNodes[r.NumaNode] = true
+		if len(r.Limits) == 0 {
+			return fmt.Errorf("NUMA node %d has no limits", r.NumaNode)
+		}
+		for name, quantity := range r.Limits {
+			if name != corev1.ResourceMemory && !strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+				return fmt.Errorf("NUMA node %d: %s isn't memory nor huge pages", r.NumaNode, name)
+			}
+			if quantity.Sign() <= 0 {
+				return fmt.Errorf("NUMA node %d: %s must be positive", r.NumaNode, name)
+			}
+		}
+	}
+	return nil
+}
+
+// topologyManagerPolicies are the policies of the kubelet topology manager.
+var topologyManagerPolicies = []string{
+	kubeletconfigv1beta1.NoneTopologyManagerPolicy,
+	kubeletconfigv1beta1.BestEffortTopologyManagerPolicy,
+	kubeletconfigv1beta1.RestrictedTopologyManagerPolicy,
+	kubeletconfigv1beta1.SingleNumaNodeTopologyManager,
+}
+
+func isTopologyManagerPolicy(policy string) bool {
+	for _, p := range topologyManagerPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// reservesCPUs returns whether the kubelet config kc reserves CPUs for the
+// system and kubernetes daemons, as the static CPU manager policy requires. The
+// templates reserve CPU in systemReserved, unless kc replaces it.
+func reservesCPUs(kc *kubeletconfigv1beta1.KubeletConfiguration) bool {
+	if kc.ReservedSystemCPUs != "" {
+		return true
+	}
+	for _, reserved := range []map[string]string{kc.SystemReserved, kc.KubeReserved} {
+		if cpu, ok := reserved["cpu"]; ok {
+			if q, err := resource.ParseQuantity(cpu); err == nil && !q.IsZero() {
+				return true
+			}
+		}
+	}
+	_, replaced := kc.SystemReserved["cpu"]
+	return !replaced
+}
+
+// validateCPUSet checks that cpus is a list of CPUs in the Linux cpuset
+// format, e.g. 0-1,4.
+func validateCPUSet(cpus string) error {
+	for _, r := range strings.Split(cpus, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+		first, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid CPU %q", bounds[0])
+		}
+		if len(bounds) == 1 {
+			continue
+		}
+		last, err := strconv.ParseUint(bounds[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid CPU %q", bounds[1])
+		}
+		if last < first {
+			return fmt.Errorf("invalid range %q", r)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package kubeletconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// kubeletConfigEnum is a field of the KubeletConfiguration taking one of a
+// fixed set of values.
+type kubeletConfigEnum struct {
+	path    string
+	values  func(*kubeletconfigv1beta1.KubeletConfiguration) []string
+	allowed []string
+}
+
+var kubeletConfigEnums = []kubeletConfigEnum{
+	{
+		path: "authorization.mode",
+		values: func(kc *kubeletconfigv1beta1.KubeletConfiguration) []string {
+			return []string{string(kc.Authorization.Mode)}
+		},
+		allowed: []string{string(kubeletconfigv1beta1.KubeletAuthorizationModeAlwaysAllow), string(kubeletconfigv1beta1.KubeletAuthorizationModeWebhook)},
+	},
+	{
+		path: "configMapAndSecretChangeDetectionStrategy",
+		values: func(kc *kubeletconfigv1beta1.KubeletConfiguration) []string {
+			return []string{string(kc.ConfigMapAndSecretChangeDetectionStrategy)}
+		},
+		allowed: []string{
+			string(kubeletconfigv1beta1.GetChangeDetectionStrategy),
+			string(kubeletconfigv1beta1.TTLCacheChangeDetectionStrategy),
+			string(kubeletconfigv1beta1.WatchChangeDetectionStrategy),
+		},
+	},
+	{
+		path:    "cpuManagerPolicy",
+		values:  func(kc *kubeletconfigv1beta1.KubeletConfiguration) []string { return []string{kc.CPUManagerPolicy} },
+		allowed: []string{"none", "static"},
+	},
+	{
+		path:    "enforceNodeAllocatable",
+		values:  func(kc *kubeletconfigv1beta1.KubeletConfiguration) []string { return kc.EnforceNodeAllocatable },
+		allowed: []string{"pods", "system-reserved", "kube-reserved", "none"},
+	},
+	{
+		path:    "hairpinMode",
+		values:  func(kc *kubeletconfigv1beta1.KubeletConfiguration) []string { return []string{kc.HairpinMode} },
+		allowed: []string{kubeletconfigv1beta1.HairpinVeth, kubeletconfigv1beta1.PromiscuousBridge, kubeletconfigv1beta1.HairpinNone},
+	},
+	{
+		path: "topologyManagerPolicy",
+		values: func(kc *kubeletconfigv1beta1.KubeletConfiguration) []string {
+			return []string{kc.TopologyManagerPolicy}
+		},
+		allowed: []string{
+			kubeletconfigv1beta1.NoneTopologyManagerPolicy,
+			kubeletconfigv1beta1.BestEffortTopologyManagerPolicy,
+			kubeletconfigv1beta1.RestrictedTopologyManagerPolicy,
+			kubeletconfigv1beta1.SingleNumaNodeTopologyManager,
+		},
+	},
+}
+
+// validateKubeletConfigSchema checks the raw KubeletConfiguration of a
+// KubeletConfig against the upstream schema, so that a config the kubelet
+// can't load is rejected here rather than on the nodes. The error lists all
+// the unknown fields and invalid enum values.
+func validateKubeletConfigSchema(raw []byte, kc *kubeletconfigv1beta1.KubeletConfiguration) error {
+	data, err := yaml.ToJSON(raw)
+	if err != nil {
+		return fmt.Errorf("KubeletConfig could not be unmarshalled, err: %v", err)
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("KubeletConfig could not be unmarshalled, err: %v", err)
+	}
+
+	var problems []string
+	if unknown := unknownFields(obj, reflect.TypeOf(*kc), ""); len(unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown fields: %s", strings.Join(unknown, ", ")))
+	}
+	for _, enum := range kubeletConfigEnums {
+		for _, v := range enum.values(kc) {
+			if v != "" && !containsString(enum.allowed, v) {
+				problems = append(problems, fmt.Sprintf("%s must be one of %s, but contains: %s", enum.path, strings.Join(enum.allowed, ", "), v))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("KubeletConfiguration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// unknownFields returns the paths of the keys of obj, decoded from JSON, which
+// aren't fields of t. Values of types with their own JSON decoding, like
+// durations and quantities, aren't inspected.
+func unknownFields(obj interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ft, ok := fields[k]
+			if !ok {
+				unknown = append(unknown, path+k)
+				continue
+			}
+			unknown = append(unknown, unknownFields(m[k], ft, path+k+".")...)
+		}
+	case reflect.Map:
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for k, v := range m {
+			unknown = append(unknown, unknownFields(v, t.Elem(), path+k+".")...)
+		}
+		sort.Strings(unknown)
+	case reflect.Slice, reflect.Array:
+		l, ok := obj.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, v := range l {
+			unknown = append(unknown, unknownFields(v, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i))...)
+		}
+	}
+	return unknown
+}
+
+// jsonFields returns the types of the fields of the struct type t by JSON
+// name, including the ones of its inlined structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" && f.Anonymous {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
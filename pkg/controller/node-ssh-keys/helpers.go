@@ -0,0 +1,147 @@
+package nodesshkeys
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// defaultUser is the user the keys log in as when the NodeSSHKeySet doesn't set one.
+const defaultUser = "core"
+
+// userNameRegex matches the user names useradd accepts.
+var userNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// keyTypes are the public key algorithms sshd accepts in authorized_keys.
+var keyTypes = map[string]bool{
+	"ssh-rsa":                            true,
+	"ssh-dss":                            true,
+	"ssh-ed25519":                        true,
+	"ecdsa-sha2-nistp256":                true,
+	"ecdsa-sha2-nistp384":                true,
+	"ecdsa-sha2-nistp521":                true,
+	"sk-ssh-ed25519@openssh.com":         true,
+	"sk-ecdsa-sha2-nistp256@openssh.com": true,
+}
+
+// getUser returns the user the keys of cfg log in as.
+func getUser(cfg *mcfgv1.NodeSSHKeySet) string {
+	if cfg.Spec.User == "" {
+		return defaultUser
+	}
+	return cfg.Spec.User
+}
+
+// parseAuthorizedKey checks that key is a single public key of the
+// authorized_keys format, without options, and returns its SHA256 fingerprint
+// as printed by ssh-keygen -l.
+func parseAuthorizedKey(key string) (string, error) {
+	if strings.ContainsAny(key, "\r\n") {
+		return "", fmt.Errorf("the key spans several lines")
+	}
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("the key must be made of a type and a base64 encoded key")
+	}
+	if !keyTypes[fields[0]] {
+		return "", fmt.Errorf("unsupported key type %q", fields[0])
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid %s key: %v", fields[0], err)
+	}
+	// the key starts with its type, as a length prefixed string
+	keyType := fields[0]
+	if len(blob) < 4+len(keyType) || binary.BigEndian.Uint32(blob) != uint32(len(keyType)) || string(blob[4:4+len(keyType)]) != keyType {
+		return "", fmt.Errorf("invalid %s key: it doesn't hold a %s key", keyType, keyType)
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// validateNodeSSHKeySet returns the fingerprints of the keys of the
+// NodeSSHKeySet, or an error if it has no keys, an invalid one or an invalid
+// user name.
+func validateNodeSSHKeySet(cfg *mcfgv1.NodeSSHKeySet) ([]string, error) {
+	if !userNameRegex.MatchString(getUser(cfg)) {
+		return nil, fmt.Errorf("NodeSSHKeySet: invalid user name %q", cfg.Spec.User)
+	}
+	if len(cfg.Spec.AuthorizedKeys) == 0 {
+		return nil, fmt.Errorf("NodeSSHKeySet: at least one authorized key must be set")
+	}
+	fingerprints := make([]string, 0, len(cfg.Spec.AuthorizedKeys))
+	for i, key := range cfg.Spec.AuthorizedKeys {
+		fingerprint, err := parseAuthorizedKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("NodeSSHKeySet: authorized key %d: %v", i, err)
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	return fingerprints, nil
+}
+
+// poolSSHKeys merges the keys of the NodeSSHKeySets of a pool, by user in the
+// order they're added, without duplicates.
+type poolSSHKeys struct {
+	users   []string
+	keys    map[string][]string
+	sources []string
+}
+
+func (p *poolSSHKeys) add(cfg *mcfgv1.NodeSSHKeySet) {
+	if p.keys == nil {
+		p.keys = map[string][]string{}
+	}
+	user := getUser(cfg)
+	if _, ok := p.keys[user]; !ok {
+		p.users = append(p.users, user)
+	}
+	for _, key := range cfg.Spec.AuthorizedKeys {
+		key = strings.TrimSpace(key)
+		found := false
+		for _, existing := range p.keys[user] {
+			if existing == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.keys[user] = append(p.keys[user], key)
+		}
+	}
+	p.sources = append(p.sources, cfg.Name)
+}
+
+// createNewSSHKeysIgnition returns an Ignition config setting the SSH keys of
+// the users of keys. The rendered MachineConfig of the pool merges them with the
+// keys the other MachineConfigs, e.g. 99-worker-ssh, set for the same users.
+func createNewSSHKeysIgnition(keys *poolSSHKeys) igntypes.Config {
+	tempIgnConfig := ctrlcommon.NewIgnConfig()
+	for _, user := range keys.users {
+		u := igntypes.PasswdUser{Name: user}
+		for _, key := range keys.keys[user] {
+			u.SSHAuthorizedKeys = append(u.SSHAuthorizedKeys, igntypes.SSHAuthorizedKey(key))
+		}
+		tempIgnConfig.Passwd.Users = append(tempIgnConfig.Passwd.Users, u)
+	}
+	return tempIgnConfig
+}
+
+// getManagedSSHKeysKey returns the name of the MachineConfig holding the
+// NodeSSHKeySets of the pool. It sorts after the MachineConfigs of the MCO.
+func getManagedSSHKeysKey(pool string) string {
+	return fmt.Sprintf("99-%s-node-ssh-keys", pool)
+}
+
+func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.NodeSSHKeySetCondition {
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewNodeSSHKeySetCondition(mcfgv1.NodeSSHKeySetStatusConditionType(condition.Type), condition.Status, condition.Message)
+}
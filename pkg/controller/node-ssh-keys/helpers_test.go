@@ -0,0 +1,94 @@
+package nodesshkeys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	adminKey         = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHSa8aitty0+0+XQ2gydwmdRIjA1KcPzHAIZItq1Vgk/ admin@example.com"
+	adminFingerprint = "SHA256:Ei4/WsaQgE1CpGiVjHHjw9bKwFCDD3Orn/ihWGXkgj0"
+	opsKey           = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBHYNIK2kVLei9UOcq1BMVxp38x8jZvZ4FWjomh0wWEmokJaXy6TIxYds7Sns/30FiFdwmcAOX95fAapxzv9ef3Y= ops@example.com"
+	opsFingerprint   = "SHA256:HwKDlqeL7symEt6HYEDHLp30XLI8Xj5yb8Z046GrI7w"
+)
+
+func TestValidateNodeSSHKeySet(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         mcfgv1.NodeSSHKeySetSpec
+		fingerprints []string
+		wantErr      bool
+	}{
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:         "keys of core",
+			spec:         mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{adminKey, opsKey}},
+			fingerprints: []string{adminFingerprint, opsFingerprint},
+		},
+		{
+			name:         "keys of another user",
+			spec:         mcfgv1.NodeSSHKeySetSpec{User: "admin", AuthorizedKeys: []string{adminKey}},
+			fingerprints: []string{adminFingerprint},
+		},
+		{
+			name:    "invalid user name",
+			spec:    mcfgv1.NodeSSHKeySetSpec{User: "../root", AuthorizedKeys: []string{adminKey}},
+			wantErr: true,
+		},
+		{
+			name:    "key with options",
+			spec:    mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{`command="/bin/sh" ` + adminKey}},
+			wantErr: true,
+		},
+		{
+			name:    "several keys in one",
+			spec:    mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{adminKey + "\n" + opsKey}},
+			wantErr: true,
+		},
+		{
+			name:    "type not matching the key",
+			spec:    mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{"ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIHSa8aitty0+0+XQ2gydwmdRIjA1KcPzHAIZItq1Vgk/"}},
+			wantErr: true,
+		},
+		{
+			name:    "truncated key",
+			spec:    mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{"ssh-ed25519 AAAAC3NzaC1lZDI1"}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fingerprints, err := validateNodeSSHKeySet(&mcfgv1.NodeSSHKeySet{Spec: test.spec})
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.fingerprints, fingerprints)
+		})
+	}
+}
+
+func TestPoolSSHKeys(t *testing.T) {
+	var keys poolSSHKeys
+	keys.add(newNodeSSHKeySet("admins", mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{adminKey}}, nil))
+	keys.add(newNodeSSHKeySet("ops", mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{opsKey, adminKey + " "}}, nil))
+	keys.add(newNodeSSHKeySet("support", mcfgv1.NodeSSHKeySetSpec{User: "support", AuthorizedKeys: []string{opsKey}}, nil))
+
+	assert.Equal(t, []string{"admins", "ops", "support"}, keys.sources)
+	ignCfg := createNewSSHKeysIgnition(&keys)
+	require.Len(t, ignCfg.Passwd.Users, 2)
+	assert.Equal(t, "core", ignCfg.Passwd.Users[0].Name)
+	assert.Len(t, ignCfg.Passwd.Users[0].SSHAuthorizedKeys, 2)
+	assert.EqualValues(t, adminKey, ignCfg.Passwd.Users[0].SSHAuthorizedKeys[0])
+	assert.EqualValues(t, opsKey, ignCfg.Passwd.Users[0].SSHAuthorizedKeys[1])
+	assert.Equal(t, "support", ignCfg.Passwd.Users[1].Name)
+	assert.Len(t, ignCfg.Passwd.Users[1].SSHAuthorizedKeys, 1)
+}
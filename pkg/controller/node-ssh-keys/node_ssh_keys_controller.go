@@ -0,0 +1,365 @@
+package nodesshkeys
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// maxRetries is the number of times the NodeSSHKeySets will be retried before they are dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// the NodeSSHKeySets are going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+
+	// nodeSSHKeySetsKey is the only key of the queue: the NodeSSHKeySets
+	// of all the pools are synced together since each pool merges several.
+	nodeSSHKeySetsKey = "node-ssh-key-sets"
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the node ssh keys controller. It merges the
+// NodeSSHKeySets selecting a pool into a single MachineConfig of the pool, so
+// that keys are rotated by editing NodeSSHKeySets rather than MachineConfigs.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler func(key string) error
+
+	skLister       mcfglistersv1.NodeSSHKeySetLister
+	skListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new node ssh keys controller
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	skInformer mcfginformersv1.NodeSSHKeySetInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-nodesshkeyscontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-nodesshkeyscontroller"),
+	}
+
+	skInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addNodeSSHKeySet,
+		UpdateFunc: ctrl.updateNodeSSHKeySet,
+		DeleteFunc: ctrl.deleteNodeSSHKeySet,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfigPool,
+		UpdateFunc: ctrl.updateMachineConfigPool,
+		DeleteFunc: ctrl.deleteMachineConfigPool,
+	})
+
+	ctrl.syncHandler = ctrl.syncNodeSSHKeySets
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.skLister = skInformer.Lister()
+	ctrl.skListerSynced = skInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the node ssh keys controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.skListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-NodeSSHKeysController")
+	defer glog.Info("Shutting down MachineConfigController-NodeSSHKeysController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-NodeSSHKeysController", ctrl.queue)
+}
+
+func (ctrl *Controller) addNodeSSHKeySet(obj interface{}) {
+	cfg := obj.(*mcfgv1.NodeSSHKeySet)
+	glog.V(4).Infof("Adding NodeSSHKeySet %s", cfg.Name)
+	ctrl.enqueue()
+}
+
+func (ctrl *Controller) updateNodeSSHKeySet(old, cur interface{}) {
+	oldConfig := old.(*mcfgv1.NodeSSHKeySet)
+	newConfig := cur.(*mcfgv1.NodeSSHKeySet)
+
+	if !reflect.DeepEqual(oldConfig.Spec, newConfig.Spec) {
+		glog.V(4).Infof("Update NodeSSHKeySet %s", oldConfig.Name)
+		ctrl.enqueue()
+	}
+}
+
+func (ctrl *Controller) deleteNodeSSHKeySet(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.NodeSSHKeySet)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.NodeSSHKeySet)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a NodeSSHKeySet %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleting NodeSSHKeySet %s", cfg.Name)
+	ctrl.enqueue()
+}
+
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	ctrl.enqueue()
+}
+
+// updateMachineConfigPool only requeues when the labels of the pool, which the
+// NodeSSHKeySets select it by, change.
+func (ctrl *Controller) updateMachineConfigPool(old, cur interface{}) {
+	oldPool := old.(*mcfgv1.MachineConfigPool)
+	curPool := cur.(*mcfgv1.MachineConfigPool)
+	if !reflect.DeepEqual(oldPool.Labels, curPool.Labels) {
+		ctrl.enqueue()
+	}
+}
+
+func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
+	ctrl.enqueue()
+}
+
+func (ctrl *Controller) enqueue() {
+	ctrl.queue.Add(nodeSSHKeySetsKey)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.NodeSSHKeysControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing NodeSSHKeySets: %v", err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping NodeSSHKeySets out of the queue: %v", err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+// syncNodeSSHKeySets merges the keys of the NodeSSHKeySets of each pool, in
+// the order of their names, into its MachineConfig, and deletes the
+// MachineConfigs of the pools without any. An invalid NodeSSHKeySet is left
+// out as a whole.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncNodeSSHKeySets(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing NodeSSHKeySets (%v)", startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing NodeSSHKeySets (%v)", time.Since(startTime))
+	}()
+
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+	cfgs, err := ctrl.skLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	sort.Slice(cfgs, func(i, j int) bool { return cfgs[i].Name < cfgs[j].Name })
+
+	results := make(map[string]error)
+	fingerprints := make(map[string][]string)
+	selectors := make(map[string]labels.Selector)
+	var valid []*mcfgv1.NodeSSHKeySet
+	for _, cfg := range cfgs {
+		if cfg.DeletionTimestamp != nil {
+			continue
+		}
+		keyFingerprints, err := validateNodeSSHKeySet(cfg)
+		if err != nil {
+			results[cfg.Name] = err
+			continue
+		}
+		fingerprints[cfg.Name] = keyFingerprints
+		selector, err := metav1.LabelSelectorAsSelector(cfg.Spec.MachineConfigPoolSelector)
+		if err != nil {
+			results[cfg.Name] = fmt.Errorf("invalid label selector: %v", err)
+			continue
+		}
+		selectors[cfg.Name] = selector
+		valid = append(valid, cfg)
+	}
+
+	applied := make(map[string][]string)
+	managed := make(map[string]bool)
+	var errs []error
+	for _, pool := range pools {
+		var keys poolSSHKeys
+		for _, cfg := range valid {
+			// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
+			selector := selectors[cfg.Name]
+			if selector.Empty() || !selector.Matches(labels.Set(pool.Labels)) {
+				continue
+			}
+			keys.add(cfg)
+		}
+		if len(keys.sources) == 0 {
+			continue
+		}
+		managedKey := getManagedSSHKeysKey(pool.Name)
+		managed[managedKey] = true
+		if err := ctrl.applyManagedConfig(pool, &keys); err != nil {
+			for _, name := range keys.sources {
+				if results[name] == nil {
+					results[name] = err
+				}
+			}
+			if _, ok := err.(*ctrlcommon.ForgetError); !ok {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, name := range keys.sources {
+			applied[name] = append(applied[name], pool.Name)
+		}
+	}
+
+	if err := ctrlcommon.DeletePoolConfigs(ctrl.client, ctrlcommon.NodeSSHKeySetsAnnotationKey, managed); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, cfg := range cfgs {
+		if cfg.DeletionTimestamp != nil {
+			continue
+		}
+		if results[cfg.Name] == nil && len(applied[cfg.Name]) == 0 {
+			results[cfg.Name] = fmt.Errorf("NodeSSHKeySet %s does not match any MachineConfigPools", cfg.Name)
+		}
+		ctrl.syncStatusOnly(cfg, applied[cfg.Name], fingerprints[cfg.Name], results[cfg.Name])
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// applyManagedConfig creates or updates the MachineConfig of pool holding the
+// NodeSSHKeySets merged into keys.
+func (ctrl *Controller) applyManagedConfig(pool *mcfgv1.MachineConfigPool, keys *poolSSHKeys) error {
+	mc, err := mtmpl.MachineConfigFromIgnConfig(pool.Name, getManagedSSHKeysKey(pool.Name), createNewSSHKeysIgnition(keys))
+	if err != nil {
+		return err
+	}
+	if err := ctrlcommon.ApplyPoolConfig(ctrl.client, mc, ctrlcommon.NodeSSHKeySetsAnnotationKey, keys.sources); err != nil {
+		return err
+	}
+	glog.Infof("Applied NodeSSHKeySets %s on MachineConfigPool %s", strings.Join(keys.sources, ", "), pool.Name)
+	return nil
+}
+
+// syncStatusOnly records the pools cfg is applied to, the fingerprints of its
+// keys and the outcome of the sync, unless they didn't change since the last one.
+func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.NodeSSHKeySet, pools, fingerprints []string, err error) {
+	condition := wrapErrorWithCondition(err)
+	if cfg.Status.ObservedGeneration == cfg.Generation && reflect.DeepEqual(cfg.Status.Pools, pools) && reflect.DeepEqual(cfg.Status.Fingerprints, fingerprints) {
+		if n := len(cfg.Status.Conditions); n > 0 && cfg.Status.Conditions[n-1].Type == condition.Type && cfg.Status.Conditions[n-1].Message == condition.Message {
+			return
+		}
+	}
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.skLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = cfg.Generation
+		newcfg.Status.Pools = pools
+		newcfg.Status.Fingerprints = fingerprints
+		mcfgv1.SetSyncCondition(&newcfg.Status.Conditions, condition)
+		_, lerr := ctrl.client.MachineconfigurationV1().NodeSSHKeySets().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating NodeSSHKeySet %s status: %v", cfg.Name, statusUpdateError)
+	}
+}
@@ -0,0 +1,113 @@
+package nodesshkeys
+
+import (
+	"context"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newNodeSSHKeySet(name string, spec mcfgv1.NodeSSHKeySetSpec, selector *metav1.LabelSelector) *mcfgv1.NodeSSHKeySet {
+	spec.MachineConfigPoolSelector = selector
+	return &mcfgv1.NodeSSHKeySet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
+		Spec:       spec,
+	}
+}
+
+func newController(t *testing.T, pools []*mcfgv1.MachineConfigPool, cfgs []*mcfgv1.NodeSSHKeySet, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	for _, p := range pools {
+		objects = append(objects, p)
+	}
+	for _, cfg := range cfgs {
+		objects = append(objects, cfg)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		i.Machineconfiguration().V1().NodeSSHKeySets(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.eventRecorder = &record.FakeRecorder{}
+	helpers.StartInformers(t, i)
+	return c, client
+}
+
+func TestNodeSSHKeySetsMerged(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["ssh-access"] = "ops"
+	mcp2 := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "ssh-access", "ops")
+	admins := newNodeSSHKeySet("admins", mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{adminKey}}, selector)
+	ops := newNodeSSHKeySet("ops", mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{opsKey}}, selector)
+	invalid := newNodeSSHKeySet("zz-invalid", mcfgv1.NodeSSHKeySetSpec{AuthorizedKeys: []string{"ssh-ed25519 not-base64"}}, selector)
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp, mcp2}, []*mcfgv1.NodeSSHKeySet{ops, admins, invalid})
+	require.Nil(t, c.syncHandler(nodeSSHKeySetsKey))
+
+	rendered, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedSSHKeysKey(mcp.Name), metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "worker", rendered.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, "admins,ops", rendered.Annotations[ctrlcommon.NodeSSHKeySetsAnnotationKey])
+
+	ignCfg, _, err := ign.Parse(rendered.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Passwd.Users, 1)
+	assert.Equal(t, "core", ignCfg.Passwd.Users[0].Name)
+	require.Len(t, ignCfg.Passwd.Users[0].SSHAuthorizedKeys, 2)
+	assert.EqualValues(t, adminKey, ignCfg.Passwd.Users[0].SSHAuthorizedKeys[0])
+	assert.EqualValues(t, opsKey, ignCfg.Passwd.Users[0].SSHAuthorizedKeys[1])
+
+	// The master pool isn't selected
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedSSHKeysKey(mcp2.Name), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	admins, err = client.MachineconfigurationV1().NodeSSHKeySets().Get(context.TODO(), admins.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"worker"}, admins.Status.Pools)
+	assert.Equal(t, []string{adminFingerprint}, admins.Status.Fingerprints)
+	require.Len(t, admins.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.NodeSSHKeySetSuccess, admins.Status.Conditions[0].Type)
+
+	invalid, err = client.MachineconfigurationV1().NodeSSHKeySets().Get(context.TODO(), invalid.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Empty(t, invalid.Status.Pools)
+	require.Len(t, invalid.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.NodeSSHKeySetFailure, invalid.Status.Conditions[0].Type)
+}
+
+func TestNodeSSHKeySetsStaleConfigDeleted(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	stale := helpers.NewMachineConfig(getManagedSSHKeysKey("infra"), map[string]string{mcfgv1.MachineConfigRoleLabelKey: "infra"}, "", nil)
+	stale.Annotations = map[string]string{ctrlcommon.NodeSSHKeySetsAnnotationKey: "admins"}
+	other := helpers.NewMachineConfig(getManagedSSHKeysKey("master"), map[string]string{mcfgv1.MachineConfigRoleLabelKey: "master"}, "", nil)
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, nil, stale, other)
+	require.Nil(t, c.syncHandler(nodeSSHKeySetsKey))
+
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), stale.Name, metav1.GetOptions{})
+	assert.NotNil(t, err)
+	// a MachineConfig of the same name not written by the controller is left alone
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), other.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+}
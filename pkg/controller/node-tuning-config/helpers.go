@@ -0,0 +1,157 @@
+package nodetuningconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// sysctlConfPath is where the sysctls of a NodeTuningConfig are written on the node
+	sysctlConfPath = "/etc/sysctl.d/99-nodetuningconfig.conf"
+)
+
+// allowedSysctls is the whitelist of sysctls a NodeTuningConfig may set.
+// Entries ending with a "." allow any sysctl under that prefix.
+var allowedSysctls = []string{
+	"fs.aio-max-nr",
+	"fs.file-max",
+	"fs.inotify.",
+	"fs.mqueue.",
+	"kernel.msgmax",
+	"kernel.msgmnb",
+	"kernel.msgmni",
+	"kernel.numa_balancing",
+	"kernel.pid_max",
+	"kernel.sched_",
+	"kernel.sem",
+	"kernel.shmall",
+	"kernel.shmmax",
+	"kernel.shmmni",
+	"net.",
+	"vm.",
+}
+
+// allowedKernelArguments is the whitelist of kernel arguments (the part before
+// the "=", if any) a NodeTuningConfig may add.
+var allowedKernelArguments = map[string]bool{
+	"amd_iommu":             true,
+	"audit":                 true,
+	"default_hugepagesz":    true,
+	"hugepages":             true,
+	"hugepagesz":            true,
+	"intel_idle.max_cstate": true,
+	"intel_iommu":           true,
+	"intel_pstate":          true,
+	"iommu":                 true,
+	"irqaffinity":           true,
+	"isolcpus":              true,
+	"mitigations":           true,
+	"nmi_watchdog":          true,
+	"nohz":                  true,
+	"nohz_full":             true,
+	"nosmt":                 true,
+	"processor.max_cstate":  true,
+	"rcu_nocb_poll":         true,
+	"rcu_nocbs":             true,
+	"skew_tick":             true,
+	"systemd.cpu_affinity":  true,
+	"transparent_hugepage":  true,
+	"tsc":                   true,
+}
+
+var sysctlNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func isSysctlAllowed(name string) bool {
+	for _, allowed := range allowedSysctls {
+		if strings.HasSuffix(allowed, ".") || strings.HasSuffix(allowed, "_") {
+			if strings.HasPrefix(name, allowed) {
+				return true
+			}
+			continue
+		}
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNodeTuningConfig returns an error if the NodeTuningConfig sets a
+// sysctl or kernel argument which isn't whitelisted.
+func validateNodeTuningConfig(cfg *mcfgv1.NodeTuningConfig) error {
+	if len(cfg.Spec.Sysctls) == 0 && len(cfg.Spec.KernelArguments) == 0 {
+		return fmt.Errorf("NodeTuningConfig: at least one of sysctls or kernelArguments must be set")
+	}
+	seen := make(map[string]bool)
+	for _, s := range cfg.Spec.Sysctls {
+		if !sysctlNameRegex.MatchString(s.Name) {
+			return fmt.Errorf("NodeTuningConfig: invalid sysctl name %q", s.Name)
+		}
+		if !isSysctlAllowed(s.Name) {
+			return fmt.Errorf("NodeTuningConfig: sysctl %q is not allowed", s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("NodeTuningConfig: sysctl %q is set more than once", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Value == "" || strings.ContainsAny(s.Value, "\n\r") {
+			return fmt.Errorf("NodeTuningConfig: invalid value %q for sysctl %q", s.Value, s.Name)
+		}
+	}
+	for _, karg := range cfg.Spec.KernelArguments {
+		if karg == "" || strings.ContainsAny(karg, " \t\n\r") {
+			return fmt.Errorf("NodeTuningConfig: invalid kernel argument %q", karg)
+		}
+		key := strings.SplitN(karg, "=", 2)[0]
+		if !allowedKernelArguments[key] {
+			return fmt.Errorf("NodeTuningConfig: kernel argument %q is not allowed", karg)
+		}
+	}
+	return nil
+}
+
+// createNewTuningIgnition returns an Ignition config writing the sysctls of the
+// NodeTuningConfig to sysctlConfPath.
+func createNewTuningIgnition(sysctls []mcfgv1.Sysctl) igntypes.Config {
+	tempIgnConfig := ctrlcommon.NewIgnConfig()
+	if len(sysctls) == 0 {
+		return tempIgnConfig
+	}
+	var b strings.Builder
+	for _, s := range sysctls {
+		fmt.Fprintf(&b, "%s = %s\n", s.Name, s.Value)
+	}
+	mode := 0644
+	du := dataurl.New([]byte(b.String()), "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	tempFile := igntypes.File{
+		Node: igntypes.Node{
+			Filesystem: "root",
+			Path:       sysctlConfPath,
+		},
+		FileEmbedded1: igntypes.FileEmbedded1{
+			Mode: &mode,
+			Contents: igntypes.FileContents{
+				Source: du.String(),
+			},
+		},
+	}
+	tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, tempFile)
+	return tempIgnConfig
+}
+
+func getManagedTuningKey(pool *mcfgv1.MachineConfigPool) string {
+	return fmt.Sprintf("99-%s-%s-tuning", pool.Name, pool.ObjectMeta.UID)
+}
+
+func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.NodeTuningConfigCondition {
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewNodeTuningConfigCondition(mcfgv1.NodeTuningConfigStatusConditionType(condition.Type), condition.Status, condition.Message)
+}
@@ -0,0 +1,77 @@
+package nodetuningconfig
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestValidateNodeTuningConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		sysctls []mcfgv1.Sysctl
+		kargs   []string
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:    "whitelisted sysctls",
+			sysctls: []mcfgv1.Sysctl{{Name: "vm.max_map_count", Value: "262144"}, {Name: "kernel.shmmax", Value: "68719476736"}, {Name: "kernel.sched_rt_runtime_us", Value: "-1"}},
+		},
+		{
+			name:    "sysctl not whitelisted",
+			sysctls: []mcfgv1.Sysctl{{Name: "kernel.modules_disabled", Value: "1"}},
+			wantErr: true,
+		},
+		{
+			name:    "sysctl prefix must match a full component",
+			sysctls: []mcfgv1.Sysctl{{Name: "kernel.shmmax_bogus", Value: "1"}},
+			wantErr: true,
+		},
+		{
+			name:    "sysctl set twice",
+			sysctls: []mcfgv1.Sysctl{{Name: "vm.swappiness", Value: "10"}, {Name: "vm.swappiness", Value: "20"}},
+			wantErr: true,
+		},
+		{
+			name:    "sysctl value with newline",
+			sysctls: []mcfgv1.Sysctl{{Name: "vm.swappiness", Value: "10\nkernel.modules_disabled = 1"}},
+			wantErr: true,
+		},
+		{
+			name:    "sysctl with invalid name",
+			sysctls: []mcfgv1.Sysctl{{Name: "vm swappiness", Value: "10"}},
+			wantErr: true,
+		},
+		{
+			name:  "whitelisted kargs",
+			kargs: []string{"nosmt", "hugepagesz=1G", "hugepages=16", "isolcpus=2-3"},
+		},
+		{
+			name:    "karg not whitelisted",
+			kargs:   []string{"init=/bin/sh"},
+			wantErr: true,
+		},
+		{
+			name:    "karg with spaces",
+			kargs:   []string{"nosmt isolcpus=1"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newNodeTuningConfig(test.name, test.sysctls, test.kargs, nil)
+			err := validateNodeTuningConfig(cfg)
+			if test.wantErr && err == nil {
+				t.Errorf("expected an error")
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package nodetuningconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	macherrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// maxRetries is the number of times a NodeTuningConfig will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a NodeTuningConfig is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+var (
+	// controllerKind contains the schema.GroupVersionKind for this controller type.
+	controllerKind = mcfgv1.SchemeGroupVersion.WithKind("NodeTuningConfig")
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the node tuning config controller.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler             func(key string) error
+	enqueueNodeTuningConfig func(*mcfgv1.NodeTuningConfig)
+
+	ntcLister       mcfglistersv1.NodeTuningConfigLister
+	ntcListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new node tuning config controller
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	ntcInformer mcfginformersv1.NodeTuningConfigInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-nodetuningconfigcontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-nodetuningconfigcontroller"),
+	}
+
+	ntcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addNodeTuningConfig,
+		UpdateFunc: ctrl.updateNodeTuningConfig,
+		DeleteFunc: ctrl.deleteNodeTuningConfig,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctrl.addMachineConfigPool,
+	})
+
+	ctrl.syncHandler = ctrl.syncNodeTuningConfig
+	ctrl.enqueueNodeTuningConfig = ctrl.enqueue
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.ntcLister = ntcInformer.Lister()
+	ctrl.ntcListerSynced = ntcInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the node tuning config controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.ntcListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-NodeTuningConfigController")
+	defer glog.Info("Shutting down MachineConfigController-NodeTuningConfigController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-NodeTuningConfigController", ctrl.queue)
+}
+
+func (ctrl *Controller) updateNodeTuningConfig(old, cur interface{}) {
+	oldConfig := old.(*mcfgv1.NodeTuningConfig)
+	newConfig := cur.(*mcfgv1.NodeTuningConfig)
+
+	if !reflect.DeepEqual(oldConfig.Spec, newConfig.Spec) {
+		glog.V(4).Infof("Update NodeTuningConfig %s", oldConfig.Name)
+		ctrl.enqueueNodeTuningConfig(newConfig)
+	}
+}
+
+func (ctrl *Controller) addNodeTuningConfig(obj interface{}) {
+	cfg := obj.(*mcfgv1.NodeTuningConfig)
+	glog.V(4).Infof("Adding NodeTuningConfig %s", cfg.Name)
+	ctrl.enqueueNodeTuningConfig(cfg)
+}
+
+func (ctrl *Controller) deleteNodeTuningConfig(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.NodeTuningConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.NodeTuningConfig)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a NodeTuningConfig %#v", obj))
+			return
+		}
+	}
+	if err := ctrl.cascadeDelete(cfg); err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't delete MachineConfigs for %#v: %v", cfg, err))
+	} else {
+		glog.V(4).Infof("Deleted NodeTuningConfig %s and its MachineConfigs", cfg.Name)
+	}
+}
+
+// addMachineConfigPool requeues all the NodeTuningConfigs so that newly created
+// pools get the tuning they are selected for.
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	pool := obj.(*mcfgv1.MachineConfigPool)
+	cfgs, err := ctrl.ntcLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list NodeTuningConfigs for pool %s: %v", pool.Name, err))
+		return
+	}
+	for _, cfg := range cfgs {
+		ctrl.enqueueNodeTuningConfig(cfg)
+	}
+}
+
+// cascadeDelete removes the MachineConfigs rendered for the given NodeTuningConfig
+func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.NodeTuningConfig) error {
+	mcs, err := ctrl.client.MachineconfigurationV1().MachineConfigs().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs.Items {
+		ref := metav1.GetControllerOf(&mc)
+		if ref == nil || ref.Kind != controllerKind.Kind || ref.UID != cfg.UID {
+			continue
+		}
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !macherrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctrl *Controller) enqueue(cfg *mcfgv1.NodeTuningConfig) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(cfg)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %#v: %v", cfg, err))
+		return
+	}
+	ctrl.queue.Add(key)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.NodeTuningConfigControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing nodetuningconfig %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping nodetuningconfig %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.NodeTuningConfig, err error, args ...interface{}) error {
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.ntcLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = cfg.Generation
+		mcfgv1.SetSyncCondition(&newcfg.Status.Conditions, wrapErrorWithCondition(err, args...))
+		_, lerr := ctrl.client.MachineconfigurationV1().NodeTuningConfigs().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating nodetuningconfig status: %v", statusUpdateError)
+	}
+	return err
+}
+
+// syncNodeTuningConfig will sync the NodeTuningConfig with the given key.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncNodeTuningConfig(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing nodetuningconfig %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing nodetuningconfig %q (%v)", key, time.Since(startTime))
+	}()
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ctrl.ntcLister.Get(name)
+	if macherrors.IsNotFound(err) {
+		glog.V(2).Infof("NodeTuningConfig %v has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deep-copy otherwise we are mutating our cache.
+	cfg = cfg.DeepCopy()
+
+	if cfg.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if err := validateNodeTuningConfig(cfg); err != nil {
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	pools, err := ctrl.getPoolsForNodeTuningConfig(cfg)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, err)
+	}
+	if len(pools) == 0 {
+		err := fmt.Errorf("NodeTuningConfig %v does not match any MachineConfigPools", key)
+		glog.V(2).Infof("%v", err)
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	for _, pool := range pools {
+		role := pool.Name
+		managedKey := getManagedTuningKey(pool)
+		mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+		if err != nil && !macherrors.IsNotFound(err) {
+			return ctrl.syncStatusOnly(cfg, err, "could not find MachineConfig: %v", managedKey)
+		}
+		isNotFound := macherrors.IsNotFound(err)
+		if !isNotFound {
+			if ref := metav1.GetControllerOf(mc); ref != nil && ref.UID != cfg.UID {
+				err := fmt.Errorf("MachineConfigPool %s is already tuned by %s %s", pool.Name, ref.Kind, ref.Name)
+				return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+			}
+		}
+
+		ignConfig := createNewTuningIgnition(cfg.Spec.Sysctls)
+		if isNotFound {
+			mc, err = mtmpl.MachineConfigFromIgnConfig(role, managedKey, ignConfig)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not create MachineConfig from new Ignition config: %v", err)
+			}
+		} else {
+			rawIgn, err := json.Marshal(ignConfig)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not marshal tuning Ignition: %v", err)
+			}
+			mc.Spec.Config.Raw = rawIgn
+		}
+		mc.Spec.KernelArguments = cfg.Spec.KernelArguments
+
+		mc.SetAnnotations(map[string]string{
+			ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+		})
+		oref := metav1.NewControllerRef(cfg, controllerKind)
+		mc.SetOwnerReferences([]metav1.OwnerReference{*oref})
+
+		// Create or Update, on conflict retry
+		if err := retry.RetryOnConflict(updateBackoff, func() error {
+			var err error
+			if isNotFound {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), mc, metav1.CreateOptions{})
+			} else {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), mc, metav1.UpdateOptions{})
+			}
+			return err
+		}); err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not Create/Update MachineConfig: %v", err)
+		}
+		glog.Infof("Applied NodeTuningConfig %v on MachineConfigPool %v", key, pool.Name)
+	}
+
+	return ctrl.syncStatusOnly(cfg, nil)
+}
+
+func (ctrl *Controller) getPoolsForNodeTuningConfig(config *mcfgv1.NodeTuningConfig) ([]*mcfgv1.MachineConfigPool, error) {
+	pList, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(config.Spec.MachineConfigPoolSelector)
+	if err != nil {
+		return nil, ctrlcommon.NewForgetError(fmt.Errorf("invalid label selector: %v", err))
+	}
+
+	var pools []*mcfgv1.MachineConfigPool
+	for _, p := range pList {
+		// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
+		if selector.Empty() || !selector.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
@@ -0,0 +1,146 @@
+package nodetuningconfig
+
+import (
+	"context"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+var (
+	alwaysReady = func() bool { return true }
+)
+
+func newNodeTuningConfig(name string, sysctls []mcfgv1.Sysctl, kargs []string, selector *metav1.LabelSelector) *mcfgv1.NodeTuningConfig {
+	return &mcfgv1.NodeTuningConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
+		Spec: mcfgv1.NodeTuningConfigSpec{
+			MachineConfigPoolSelector: selector,
+			Sysctls:                   sysctls,
+			KernelArguments:           kargs,
+		},
+	}
+}
+
+func newController(t *testing.T, pools []*mcfgv1.MachineConfigPool, cfgs []*mcfgv1.NodeTuningConfig, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	for _, cfg := range cfgs {
+		objects = append(objects, cfg)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		i.Machineconfiguration().V1().NodeTuningConfigs(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.mcpListerSynced = alwaysReady
+	c.ntcListerSynced = alwaysReady
+	c.eventRecorder = &record.FakeRecorder{}
+
+	for _, p := range pools {
+		require.Nil(t, i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(p))
+	}
+	for _, cfg := range cfgs {
+		require.Nil(t, i.Machineconfiguration().V1().NodeTuningConfigs().Informer().GetIndexer().Add(cfg))
+	}
+	return c, client
+}
+
+func TestNodeTuningConfigCreate(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["tuning"] = "low-latency"
+	mcp2 := helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v0")
+	ntc := newNodeTuningConfig("low-latency",
+		[]mcfgv1.Sysctl{{Name: "vm.max_map_count", Value: "262144"}, {Name: "net.core.somaxconn", Value: "1024"}},
+		[]string{"nosmt", "hugepages=4"},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "tuning", "low-latency"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp, mcp2}, []*mcfgv1.NodeTuningConfig{ntc})
+	require.Nil(t, c.syncHandler(ntc.Name))
+
+	mc, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTuningKey(mcp), metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "worker", mc.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	assert.Equal(t, []string{"nosmt", "hugepages=4"}, mc.Spec.KernelArguments)
+	require.NotNil(t, metav1.GetControllerOf(mc))
+	assert.Equal(t, ntc.UID, metav1.GetControllerOf(mc).UID)
+
+	ignCfg, _, err := ign.Parse(mc.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 1)
+	assert.Equal(t, sysctlConfPath, ignCfg.Storage.Files[0].Path)
+	contents, err := dataurl.DecodeString(ignCfg.Storage.Files[0].Contents.Source)
+	require.Nil(t, err)
+	assert.Equal(t, "vm.max_map_count = 262144\nnet.core.somaxconn = 1024\n", string(contents.Data))
+
+	// The master pool isn't selected
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTuningKey(mcp2), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	ntc, err = client.MachineconfigurationV1().NodeTuningConfigs().Get(context.TODO(), ntc.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, ntc.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.NodeTuningConfigSuccess, ntc.Status.Conditions[0].Type)
+}
+
+func TestNodeTuningConfigRejected(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["tuning"] = "bad"
+	ntc := newNodeTuningConfig("bad",
+		[]mcfgv1.Sysctl{{Name: "kernel.modules_disabled", Value: "1"}},
+		nil,
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "tuning", "bad"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, []*mcfgv1.NodeTuningConfig{ntc})
+	err := c.syncHandler(ntc.Name)
+	require.NotNil(t, err)
+	_, ok := err.(*ctrlcommon.ForgetError)
+	assert.True(t, ok)
+
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTuningKey(mcp), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	ntc, err = client.MachineconfigurationV1().NodeTuningConfigs().Get(context.TODO(), ntc.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, ntc.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.NodeTuningConfigFailure, ntc.Status.Conditions[0].Type)
+}
+
+func TestNodeTuningConfigCascadeDelete(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["tuning"] = "low-latency"
+	ntc := newNodeTuningConfig("low-latency",
+		[]mcfgv1.Sysctl{{Name: "vm.swappiness", Value: "10"}},
+		nil,
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "tuning", "low-latency"))
+	other := helpers.NewMachineConfig("00-worker", map[string]string{mcfgv1.MachineConfigRoleLabelKey: "worker"}, "", nil)
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, []*mcfgv1.NodeTuningConfig{ntc}, other)
+	require.Nil(t, c.syncHandler(ntc.Name))
+	require.Nil(t, c.cascadeDelete(ntc))
+
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTuningKey(mcp), metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), other.Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// getCanaryStrategy returns the canary strategy of the pool, if any.
+// Rollbacks skip the canary phase as the target config already ran on the pool.
+func getCanaryStrategy(pool *mcfgv1.MachineConfigPool) *mcfgv1.MachineConfigPoolCanaryStrategy {
+	if pool.Spec.UpdateStrategy == nil || getTargetConfig(pool) != pool.Spec.Configuration.Name {
+		return nil
+	}
+	return pool.Spec.UpdateStrategy.Canary
+}
+
+func canaryMachineCount(canary *mcfgv1.MachineConfigPoolCanaryStrategy, nodes []*corev1.Node) (int, error) {
+	intOrPercent := intstrutil.FromInt(1)
+	if canary.Nodes != nil {
+		intOrPercent = *canary.Nodes
+	}
+	count, err := intstrutil.GetValueFromIntOrPercent(&intOrPercent, len(nodes), true)
+	if err != nil {
+		return 0, err
+	}
+	if count < 1 {
+		count = 1
+	}
+	return count, nil
+}
+
+// calculateCanaryStatus returns the canary status of the rollout of the target
+// config, or nil if the pool isn't in a canary phase.
+func calculateCanaryStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node, now metav1.Time) *mcfgv1.MachineConfigPoolCanaryStatus {
+	canary := getCanaryStrategy(pool)
+	if canary == nil {
+		return nil
+	}
+	targetConfig := getTargetConfig(pool)
+	if pool.Status.Configuration.Name == targetConfig {
+		// nothing left to roll out
+		return nil
+	}
+	count, err := canaryMachineCount(canary, nodes)
+	if err != nil {
+		glog.Warningf("Pool %s: invalid canary nodes: %v", pool.Name, err)
+		return nil
+	}
+
+	status := &mcfgv1.MachineConfigPoolCanaryStatus{Configuration: targetConfig}
+	if pool.Status.Canary != nil && pool.Status.Canary.Configuration == targetConfig {
+		status = pool.Status.Canary.DeepCopy()
+	}
+	status.MachineCount = int32(count)
+	if status.Promoted {
+		return status
+	}
+
+	if len(getReadyMachines(targetConfig, nodes)) < count {
+		// a canary machine went away or isn't healthy anymore, start over
+		status.CompletedTime = nil
+		return status
+	}
+	if status.CompletedTime == nil {
+		status.CompletedTime = &now
+	}
+
+	if canary.HealthyDuration != nil {
+		status.Promoted = now.Sub(status.CompletedTime.Time) >= canary.HealthyDuration.Duration
+	} else {
+		status.Promoted = pool.Annotations[ctrlcommon.CanaryPausedAnnotationKey] == targetConfig && !pool.Spec.Paused
+	}
+	return status
+}
+
+// limitCanaryCandidates trims the candidates so that no more than the canary
+// machines target the new config until the canary is promoted.
+func limitCanaryCandidates(canary *mcfgv1.MachineConfigPoolCanaryStatus, nodes, candidates []*corev1.Node) []*corev1.Node {
+	if canary == nil || canary.Promoted {
+		return candidates
+	}
+	capacity := int(canary.MachineCount)
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == canary.Configuration {
+			capacity--
+		}
+	}
+	if capacity <= 0 {
+		return nil
+	}
+	if len(candidates) < capacity {
+		return candidates
+	}
+	return candidates[:capacity]
+}
+
+// syncCanary holds the rollout once the canary machines are updated: the pool
+// is either requeued until the healthy duration has elapsed, or paused until
+// someone unpauses it.
+func (ctrl *Controller) syncCanary(pool *mcfgv1.MachineConfigPool, canary *mcfgv1.MachineConfigPoolCanaryStatus) (*mcfgv1.MachineConfigPool, error) {
+	if canary == nil || canary.Promoted || canary.CompletedTime == nil {
+		return pool, nil
+	}
+
+	strategy := getCanaryStrategy(pool)
+	if strategy.HealthyDuration != nil {
+		ctrl.enqueueAfter(pool, strategy.HealthyDuration.Duration-time.Since(canary.CompletedTime.Time))
+		return pool, nil
+	}
+	if pool.Annotations[ctrlcommon.CanaryPausedAnnotationKey] == canary.Configuration {
+		return pool, nil
+	}
+
+	glog.Infof("Pool %s: canary machines updated to %s, pausing", pool.Name, canary.Configuration)
+	newPool := pool.DeepCopy()
+	if newPool.Annotations == nil {
+		newPool.Annotations = map[string]string{}
+	}
+	newPool.Annotations[ctrlcommon.CanaryPausedAnnotationKey] = canary.Configuration
+	newPool.Spec.Paused = true
+	newPool, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
+	if err != nil {
+		return pool, err
+	}
+	ctrl.eventRecorder.Eventf(newPool, corev1.EventTypeNormal, "CanaryPaused", "Paused after updating %d canary machines to %s, unpause the pool to continue", canary.MachineCount, canary.Configuration)
+	return newPool, nil
+}
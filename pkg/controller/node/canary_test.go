@@ -0,0 +1,107 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCanaryPool(nodes intstr.IntOrString, healthyDuration *metav1.Duration) *mcfgv1.MachineConfigPool {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Status.Configuration.Name = "v0"
+	pool.Spec.UpdateStrategy = &mcfgv1.MachineConfigPoolUpdateStrategy{
+		Canary: &mcfgv1.MachineConfigPoolCanaryStrategy{Nodes: &nodes, HealthyDuration: healthyDuration},
+	}
+	return pool
+}
+
+func TestCanaryCandidates(t *testing.T) {
+	pool := newCanaryPool(intstr.FromString("50%"), nil)
+	pool.Spec.MaxUnavailable = &intstr.IntOrString{Type: intstr.Int, IntVal: 4}
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	canary := calculateCanaryStatus(pool, nodes, metav1.Now())
+	require.NotNil(t, canary)
+	assert.Equal(t, int32(2), canary.MachineCount)
+	assert.Nil(t, canary.CompletedTime)
+	candidates := limitCanaryCandidates(canary, nodes, getCandidateMachines(pool, nodes, 4))
+	assert.Len(t, candidates, 2)
+
+	// the canary machines are already targeted
+	nodes[0] = newNodeWithReady("node-0", "v0", "v1", corev1.ConditionTrue)
+	nodes[1] = newNodeWithReady("node-1", "v0", "v1", corev1.ConditionTrue)
+	canary = calculateCanaryStatus(pool, nodes, metav1.Now())
+	assert.Empty(t, limitCanaryCandidates(canary, nodes, getCandidateMachines(pool, nodes, 4)))
+
+	// once promoted the rest of the pool is updated
+	canary.Promoted = true
+	assert.Len(t, limitCanaryCandidates(canary, nodes, getCandidateMachines(pool, nodes, 4)), 2)
+
+	// rollbacks skip the canary phase
+	pool.Spec.RollbackTo = "v0"
+	pool.Status.History = []mcfgv1.MachineConfigPoolHistoryEntry{{Name: "v0"}}
+	assert.Nil(t, calculateCanaryStatus(pool, nodes, metav1.Now()))
+}
+
+func TestCanaryHealthyDuration(t *testing.T) {
+	pool := newCanaryPool(intstr.FromInt(1), &metav1.Duration{Duration: time.Hour})
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	now := metav1.Now()
+	canary := calculateCanaryStatus(pool, nodes, now)
+	require.NotNil(t, canary.CompletedTime)
+	assert.Equal(t, now, *canary.CompletedTime)
+	assert.False(t, canary.Promoted)
+
+	pool.Status.Canary = canary
+	canary = calculateCanaryStatus(pool, nodes, metav1.NewTime(now.Add(30*time.Minute)))
+	assert.False(t, canary.Promoted)
+
+	// the canary machine must stay ready for the whole duration
+	nodes[0] = newNodeWithReady("node-0", "v1", "v1", corev1.ConditionFalse)
+	assert.Nil(t, calculateCanaryStatus(pool, nodes, metav1.NewTime(now.Add(2*time.Hour))).CompletedTime)
+
+	nodes[0] = newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue)
+	canary = calculateCanaryStatus(pool, nodes, metav1.NewTime(now.Add(2*time.Hour)))
+	assert.True(t, canary.Promoted)
+}
+
+func TestCanaryPause(t *testing.T) {
+	f := newFixture(t)
+	pool := newCanaryPool(intstr.FromInt(1), nil)
+	f.mcpLister = append(f.mcpLister, pool)
+	f.objects = append(f.objects, pool)
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v1", "v1", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	c := f.newController()
+	canary := calculateCanaryStatus(pool, nodes, metav1.Now())
+	newPool, err := c.syncCanary(pool, canary)
+	require.Nil(t, err)
+	assert.True(t, newPool.Spec.Paused)
+	assert.Equal(t, "v1", newPool.Annotations[ctrlcommon.CanaryPausedAnnotationKey])
+	assert.False(t, calculateCanaryStatus(newPool, nodes, metav1.Now()).Promoted)
+
+	// unpausing promotes the canary
+	newPool.Spec.Paused = false
+	assert.True(t, calculateCanaryStatus(newPool, nodes, metav1.Now()).Promoted)
+}
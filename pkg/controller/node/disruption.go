@@ -0,0 +1,21 @@
+package node
+
+import (
+	"encoding/json"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// getNodeDisruptionPolicy returns the JSON encoded node disruption policy the
+// daemons should use, or an empty string when none is set.
+func (ctrl *Controller) getNodeDisruptionPolicy() (string, error) {
+	cc, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName)
+	if err != nil || cc.Spec.NodeDisruptionPolicy == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(cc.Spec.NodeDisruptionPolicy)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// mergeDrainPolicy returns the cluster drain policy with the fields set in the
+// pool override replaced. It returns nil when neither is set.
+func mergeDrainPolicy(cluster, pool *mcfgv1.DrainPolicy) *mcfgv1.DrainPolicy {
+	if cluster == nil && pool == nil {
+		return nil
+	}
+	merged := &mcfgv1.DrainPolicy{}
+	if cluster != nil {
+		merged = cluster.DeepCopy()
+	}
+	if pool == nil {
+		return merged
+	}
+	if pool.Timeout != nil {
+		merged.Timeout = pool.Timeout.DeepCopy()
+	}
+	if pool.Attempts != 0 {
+		merged.Attempts = pool.Attempts
+	}
+	if pool.Backoff != nil {
+		merged.Backoff = pool.Backoff.DeepCopy()
+	}
+	if pool.SkipWaitForDeleteTimeout != nil {
+		merged.SkipWaitForDeleteTimeout = pool.SkipWaitForDeleteTimeout.DeepCopy()
+	}
+	if pool.ForceAfterAttempts != 0 {
+		merged.ForceAfterAttempts = pool.ForceAfterAttempts
+	}
+	return merged
+}
+
+// getDrainPolicy returns the JSON encoded drain policy the daemons of the pool
+// should use, or an empty string for the default one.
+func (ctrl *Controller) getDrainPolicy(pool *mcfgv1.MachineConfigPool) (string, error) {
+	var cluster, override *mcfgv1.DrainPolicy
+	if cc, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName); err == nil {
+		cluster = cc.Spec.DrainPolicy
+	}
+	if pool.Spec.UpdateStrategy != nil {
+		override = pool.Spec.UpdateStrategy.DrainPolicy
+	}
+	policy := mergeDrainPolicy(cluster, override)
+	if policy == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// setDrainDegraded reports the nodes of the pool whose daemon gave up draining them.
+func setDrainDegraded(status *mcfgv1.MachineConfigPoolStatus, nodes []*corev1.Node) {
+	var failed []string
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] == daemonconsts.MachineConfigDaemonStateDegraded &&
+			node.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] == daemonconsts.MachineConfigDaemonPhaseDrainFailed {
+			failed = append(failed, node.Name)
+		}
+	}
+	if len(failed) == 0 {
+		sdrain := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDrainDegraded, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(status, *sdrain)
+		return
+	}
+	sdrain := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDrainDegraded, corev1.ConditionTrue, fmt.Sprintf("%d nodes failed to drain", len(failed)), strings.Join(failed, ", "))
+	mcfgv1.SetMachineConfigPoolCondition(status, *sdrain)
+}
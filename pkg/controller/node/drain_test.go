@@ -0,0 +1,69 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeDrainPolicy(t *testing.T) {
+	assert.Nil(t, mergeDrainPolicy(nil, nil))
+
+	cluster := &mcfgv1.DrainPolicy{
+		Timeout:  &metav1.Duration{Duration: time.Minute},
+		Attempts: 3,
+	}
+	pool := &mcfgv1.DrainPolicy{
+		Timeout:            &metav1.Duration{Duration: 5 * time.Minute},
+		ForceAfterAttempts: 2,
+	}
+	assert.Equal(t, cluster, mergeDrainPolicy(cluster, nil))
+	assert.Equal(t, pool, mergeDrainPolicy(nil, pool))
+	assert.Equal(t, &mcfgv1.DrainPolicy{
+		Timeout:            &metav1.Duration{Duration: 5 * time.Minute},
+		Attempts:           3,
+		ForceAfterAttempts: 2,
+	}, mergeDrainPolicy(cluster, pool))
+	// the cluster policy is left untouched
+	assert.Equal(t, time.Minute, cluster.Timeout.Duration)
+}
+
+func TestSetDrainDegraded(t *testing.T) {
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{}),
+		newNodeWithLabel("node-1", "v0", "v1", map[string]string{}),
+	}
+	status := mcfgv1.MachineConfigPoolStatus{}
+	setDrainDegraded(&status, nodes)
+	assert.False(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolDrainDegraded))
+
+	nodes[1].Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = daemonconsts.MachineConfigDaemonStateDegraded
+	nodes[1].Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] = daemonconsts.MachineConfigDaemonPhaseDrainFailed
+	setDrainDegraded(&status, nodes)
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolDrainDegraded)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "node-1", cond.Message)
+}
+
+func TestGetDrainPolicyAnnotation(t *testing.T) {
+	f := newFixture(t)
+	c := f.newController()
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	policy, err := c.getDrainPolicy(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, "", policy)
+
+	pool.Spec.UpdateStrategy = &mcfgv1.MachineConfigPoolUpdateStrategy{
+		DrainPolicy: &mcfgv1.DrainPolicy{Timeout: &metav1.Duration{Duration: time.Minute}},
+	}
+	policy, err = c.getDrainPolicy(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"timeout":"1m0s"}`, policy)
+}
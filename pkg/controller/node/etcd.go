@@ -0,0 +1,69 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// etcdMembersAvailable and etcdMembersDegraded are the conditions the etcd
+	// operator reports the health of the etcd members with.
+	etcdMembersAvailable = "EtcdMembersAvailable"
+	etcdMembersDegraded  = "EtcdMembersDegraded"
+
+	// etcdRecheckInterval is how often the master pool is synced again while its
+	// updates are held for the etcd quorum.
+	etcdRecheckInterval = 30 * time.Second
+)
+
+// getEtcdQuorumRisk returns why taking down another etcd member, by updating a
+// master, would risk losing the etcd quorum given the status of the etcd operator,
+// empty if it wouldn't.
+func getEtcdQuorumRisk(etcd *operatorv1.Etcd) string {
+	for _, cond := range etcd.Status.Conditions {
+		switch {
+		case cond.Type == etcdMembersAvailable && cond.Status != operatorv1.ConditionTrue:
+			return fmt.Sprintf("etcd members are not available: %s", cond.Message)
+		case cond.Type == etcdMembersDegraded && cond.Status == operatorv1.ConditionTrue:
+			return fmt.Sprintf("etcd members are degraded: %s", cond.Message)
+		}
+	}
+	return ""
+}
+
+// checkEtcdQuorum returns why the masters of the pool can't start updating
+// without risking the etcd quorum, empty if they can or the pool isn't the
+// master pool. Clusters without an etcd operator, or whose etcd operator is
+// unmanaged, aren't checked.
+func (ctrl *Controller) checkEtcdQuorum(pool *mcfgv1.MachineConfigPool) (string, error) {
+	if pool.Name != "master" || ctrl.etcdLister == nil {
+		return "", nil
+	}
+	etcd, err := ctrl.etcdLister.Get("cluster")
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if etcd.Spec.ManagementState == operatorv1.Unmanaged {
+		return "", nil
+	}
+	return getEtcdQuorumRisk(etcd), nil
+}
+
+// setEtcdQuorumAtRisk reports why the updates of the masters are held.
+func setEtcdQuorumAtRisk(status *mcfgv1.MachineConfigPoolStatus, risk string) {
+	if risk == "" {
+		mcfgv1.RemoveMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolEtcdQuorumAtRisk)
+		return
+	}
+	cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolEtcdQuorumAtRisk, corev1.ConditionTrue, "UpdatesHeld",
+		fmt.Sprintf("Not updating more masters, it would risk losing the etcd quorum: %s", risk))
+	mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+}
@@ -0,0 +1,83 @@
+package node
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorlistersv1 "github.com/openshift/client-go/operator/listers/operator/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newEtcd(state operatorv1.ManagementState, conditions ...operatorv1.OperatorCondition) *operatorv1.Etcd {
+	etcd := &operatorv1.Etcd{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	etcd.Spec.ManagementState = state
+	etcd.Status.Conditions = conditions
+	return etcd
+}
+
+func TestGetEtcdQuorumRisk(t *testing.T) {
+	healthy := []operatorv1.OperatorCondition{
+		{Type: etcdMembersAvailable, Status: operatorv1.ConditionTrue, Message: "3 members are available"},
+		{Type: etcdMembersDegraded, Status: operatorv1.ConditionFalse},
+	}
+	assert.Equal(t, "", getEtcdQuorumRisk(newEtcd(operatorv1.Managed, healthy...)))
+	assert.Equal(t, "", getEtcdQuorumRisk(newEtcd(operatorv1.Managed)))
+
+	assert.Equal(t, "etcd members are not available: 2 of 3 members are available, master-1 is unhealthy",
+		getEtcdQuorumRisk(newEtcd(operatorv1.Managed,
+			operatorv1.OperatorCondition{Type: etcdMembersAvailable, Status: operatorv1.ConditionFalse, Message: "2 of 3 members are available, master-1 is unhealthy"})))
+	assert.Equal(t, "etcd members are degraded: master-2 is not started",
+		getEtcdQuorumRisk(newEtcd(operatorv1.Managed, healthy[0],
+			operatorv1.OperatorCondition{Type: etcdMembersDegraded, Status: operatorv1.ConditionTrue, Message: "master-2 is not started"})))
+}
+
+func TestCheckEtcdQuorum(t *testing.T) {
+	unhealthy := operatorv1.OperatorCondition{Type: etcdMembersAvailable, Status: operatorv1.ConditionFalse, Message: "2 of 3 members are available"}
+	master := helpers.NewMachineConfigPool("master", nil, helpers.MasterSelector, "v1")
+	worker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+
+	newController := func(etcd *operatorv1.Etcd) *Controller {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		if etcd != nil {
+			require.Nil(t, indexer.Add(etcd))
+		}
+		return &Controller{etcdLister: operatorlistersv1.NewEtcdLister(indexer)}
+	}
+
+	risk, err := newController(newEtcd(operatorv1.Managed, unhealthy)).checkEtcdQuorum(master)
+	require.Nil(t, err)
+	assert.Equal(t, "etcd members are not available: 2 of 3 members are available", risk)
+
+	for _, test := range []struct {
+		ctrl *Controller
+		pool *mcfgv1.MachineConfigPool
+	}{
+		{ctrl: newController(newEtcd(operatorv1.Managed, unhealthy)), pool: worker},
+		{ctrl: newController(newEtcd(operatorv1.Unmanaged, unhealthy)), pool: master},
+		{ctrl: newController(nil), pool: master},
+		{ctrl: &Controller{}, pool: master},
+	} {
+		risk, err := test.ctrl.checkEtcdQuorum(test.pool)
+		require.Nil(t, err)
+		assert.Equal(t, "", risk)
+	}
+}
+
+func TestSetEtcdQuorumAtRisk(t *testing.T) {
+	status := mcfgv1.MachineConfigPoolStatus{}
+	setEtcdQuorumAtRisk(&status, "etcd members are degraded: master-2 is not started")
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolEtcdQuorumAtRisk)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Not updating more masters, it would risk losing the etcd quorum: etcd members are degraded: master-2 is not started", cond.Message)
+
+	setEtcdQuorumAtRisk(&status, "")
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolEtcdQuorumAtRisk))
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// firstbootStallTimeout is how long the firstboot pivot of a node can go without
+// reporting progress before it's considered hung rather than slow.
+const firstbootStallTimeout = 30 * time.Minute
+
+// getFirstbootStall returns why the firstboot pivot of node is stalled, or an
+// empty string when it's done or still progressing.
+func getFirstbootStall(node *corev1.Node, now time.Time) string {
+	phase := node.Annotations[daemonconsts.FirstbootPhaseAnnotationKey]
+	switch phase {
+	case "", daemonconsts.FirstbootPhaseDone:
+		return ""
+	case daemonconsts.FirstbootPhaseFailed:
+		return fmt.Sprintf("%s: firstboot failed", node.Name)
+	}
+	progress, err := time.Parse(time.RFC3339, node.Annotations[daemonconsts.FirstbootProgressAnnotationKey])
+	if err != nil {
+		return fmt.Sprintf("%s: firstboot %s without valid progress time", node.Name, phase)
+	}
+	if since := now.Sub(progress); since > firstbootStallTimeout {
+		return fmt.Sprintf("%s: firstboot %s without progress for %v", node.Name, phase, since.Round(time.Minute))
+	}
+	return ""
+}
+
+// setFirstbootStalled reports the nodes of the pool whose firstboot pivot failed
+// or stopped reporting progress.
+func setFirstbootStalled(status *mcfgv1.MachineConfigPoolStatus, nodes []*corev1.Node, now time.Time) {
+	var stalled []string
+	for _, node := range nodes {
+		if reason := getFirstbootStall(node, now); reason != "" {
+			stalled = append(stalled, reason)
+		}
+	}
+	if len(stalled) == 0 {
+		sfirstboot := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolFirstbootStalled, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(status, *sfirstboot)
+		return
+	}
+	sort.Strings(stalled)
+	sfirstboot := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolFirstbootStalled, corev1.ConditionTrue, fmt.Sprintf("%d nodes stalled in firstboot", len(stalled)), strings.Join(stalled, "; "))
+	mcfgv1.SetMachineConfigPoolCondition(status, *sfirstboot)
+}
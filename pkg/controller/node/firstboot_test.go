@@ -0,0 +1,40 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetFirstbootStalled(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	setFirstboot := func(node *corev1.Node, phase string, progress time.Time) {
+		node.Annotations[daemonconsts.FirstbootPhaseAnnotationKey] = phase
+		node.Annotations[daemonconsts.FirstbootProgressAnnotationKey] = progress.Format(time.RFC3339)
+	}
+	nodes := []*corev1.Node{
+		newNodeWithLabel("node-0", "v1", "v1", map[string]string{}),
+		newNodeWithLabel("node-1", "v1", "v1", map[string]string{}),
+		newNodeWithLabel("node-2", "v1", "v1", map[string]string{}),
+	}
+	// a slow pivot keeps reporting progress
+	setFirstboot(nodes[0], daemonconsts.FirstbootPhaseDone, now.Add(-time.Hour))
+	setFirstboot(nodes[1], daemonconsts.FirstbootPhasePivoting, now.Add(-10*time.Minute))
+	status := mcfgv1.MachineConfigPoolStatus{}
+	setFirstbootStalled(&status, nodes, now)
+	assert.False(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolFirstbootStalled))
+
+	// a hung one doesn't
+	setFirstboot(nodes[1], daemonconsts.FirstbootPhasePivoting, now.Add(-45*time.Minute))
+	setFirstboot(nodes[2], daemonconsts.FirstbootPhaseFailed, now)
+	setFirstbootStalled(&status, nodes, now)
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolFirstbootStalled)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "2 nodes stalled in firstboot", cond.Reason)
+	assert.Equal(t, "node-1: firstboot Pivoting without progress for 45m0s; node-2: firstboot failed", cond.Message)
+}
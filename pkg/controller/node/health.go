@@ -0,0 +1,85 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// minHealthCheckTimeout leaves the kubelet time to come up after the reboot.
+	minHealthCheckTimeout = time.Minute
+	// maxHealthCheckTimeout bounds how long a machine stays in a tentative
+	// config before it's either Done or rolled back.
+	maxHealthCheckTimeout = time.Hour
+)
+
+// validatePostUpdateHealthCheck returns an error if the post-update health
+// check can't be run by the daemon.
+func validatePostUpdateHealthCheck(check *mcfgv1.PostUpdateHealthCheck) error {
+	for _, c := range check.Checks {
+		switch c {
+		case mcfgv1.PostUpdateHealthCheckKubelet, mcfgv1.PostUpdateHealthCheckCRIO:
+		default:
+			return fmt.Errorf("unknown check %q", c)
+		}
+	}
+	if check.Timeout != nil && (check.Timeout.Duration < minHealthCheckTimeout || check.Timeout.Duration > maxHealthCheckTimeout) {
+		return fmt.Errorf("timeout %v is not between %v and %v", check.Timeout.Duration, minHealthCheckTimeout, maxHealthCheckTimeout)
+	}
+	return nil
+}
+
+// getPostUpdateHealthCheck returns the JSON encoded post-update health check of
+// the pool, empty when it has none or an invalid one, and why it's invalid.
+func getPostUpdateHealthCheck(pool *mcfgv1.MachineConfigPool) (string, string, error) {
+	check := pool.Spec.PostUpdateHealthCheck
+	if check == nil {
+		return "", "", nil
+	}
+	if err := validatePostUpdateHealthCheck(check); err != nil {
+		return "", err.Error(), nil
+	}
+	data, err := json.Marshal(check)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), "", nil
+}
+
+// syncPostUpdateHealthCheck hands the post-update health check of the pool to
+// the daemons of its nodes. Nodes of pools with an invalid one complete their
+// updates without checking their health.
+func (ctrl *Controller) syncPostUpdateHealthCheck(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	check, invalid, err := getPostUpdateHealthCheck(pool)
+	if err != nil {
+		return err
+	}
+	if invalid != "" {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidPostUpdateHealthCheck", "Ignoring postUpdateHealthCheck: %s", invalid)
+	}
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.PostUpdateHealthCheckAnnotationKey] == check {
+			continue
+		}
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			if check != "" {
+				node.Annotations[daemonconsts.PostUpdateHealthCheckAnnotationKey] = check
+			} else {
+				delete(node.Annotations, daemonconsts.PostUpdateHealthCheckAnnotationKey)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestValidatePostUpdateHealthCheck(t *testing.T) {
+	for _, check := range []mcfgv1.PostUpdateHealthCheck{
+		{},
+		{Checks: []mcfgv1.PostUpdateHealthCheckType{mcfgv1.PostUpdateHealthCheckKubelet}},
+		{Checks: []mcfgv1.PostUpdateHealthCheckType{mcfgv1.PostUpdateHealthCheckKubelet, mcfgv1.PostUpdateHealthCheckCRIO}, Timeout: &metav1.Duration{Duration: 5 * time.Minute}},
+	} {
+		assert.Nil(t, validatePostUpdateHealthCheck(&check), "%+v", check)
+	}
+	for _, check := range []mcfgv1.PostUpdateHealthCheck{
+		{Checks: []mcfgv1.PostUpdateHealthCheckType{"Etcd"}},
+		{Timeout: &metav1.Duration{Duration: 30 * time.Second}},
+		{Timeout: &metav1.Duration{Duration: 2 * time.Hour}},
+	} {
+		assert.NotNil(t, validatePostUpdateHealthCheck(&check), "%+v", check)
+	}
+}
+
+func TestGetPostUpdateHealthCheck(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	check, invalid, err := getPostUpdateHealthCheck(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, "", check)
+	assert.Equal(t, "", invalid)
+
+	pool.Spec.PostUpdateHealthCheck = &mcfgv1.PostUpdateHealthCheck{Checks: []mcfgv1.PostUpdateHealthCheckType{mcfgv1.PostUpdateHealthCheckCRIO}, Timeout: &metav1.Duration{Duration: 5 * time.Minute}}
+	check, invalid, err = getPostUpdateHealthCheck(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"checks":["CRIO"],"timeout":"5m0s"}`, check)
+	assert.Equal(t, "", invalid)
+
+	pool.Spec.PostUpdateHealthCheck = &mcfgv1.PostUpdateHealthCheck{Checks: []mcfgv1.PostUpdateHealthCheckType{"Etcd"}}
+	check, invalid, err = getPostUpdateHealthCheck(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, "", check)
+	assert.Equal(t, `unknown check "Etcd"`, invalid)
+}
@@ -0,0 +1,104 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// defaultRenderedConfigHistoryLimit is the number of rendered MachineConfigs
+	// kept per pool when the ControllerConfig doesn't say otherwise.
+	defaultRenderedConfigHistoryLimit = 5
+
+	// renderedConfigGCGracePeriod protects freshly rendered MachineConfigs
+	// the pool might not be targeting yet from the garbage collection.
+	renderedConfigGCGracePeriod = 10 * time.Minute
+)
+
+// getTargetConfig returns the rendered MachineConfig the nodes of the pool
+// should be running. A valid spec.rollbackTo takes precedence over the
+// latest rendered config.
+func getTargetConfig(pool *mcfgv1.MachineConfigPool) string {
+	if pool.Spec.RollbackTo != "" && isInHistory(pool, pool.Spec.RollbackTo) {
+		return pool.Spec.RollbackTo
+	}
+	return pool.Spec.Configuration.Name
+}
+
+func isInHistory(pool *mcfgv1.MachineConfigPool, name string) bool {
+	for _, entry := range pool.Status.History {
+		if entry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateHistory records that the pool was fully updated to name, moving it
+// to the front of the history if it was already there.
+func updateHistory(history []mcfgv1.MachineConfigPoolHistoryEntry, name string, now metav1.Time) []mcfgv1.MachineConfigPoolHistoryEntry {
+	if len(history) > 0 && history[0].Name == name {
+		return history
+	}
+	newHistory := []mcfgv1.MachineConfigPoolHistoryEntry{{Name: name, Timestamp: now}}
+	for _, entry := range history {
+		if entry.Name != name {
+			newHistory = append(newHistory, entry)
+		}
+	}
+	return newHistory
+}
+
+// getHistoryLimit returns how many rendered MachineConfigs are kept per pool.
+func (ctrl *Controller) getHistoryLimit() int {
+	cc, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName)
+	if err != nil || cc.Spec.RenderedConfigHistoryLimit <= 0 {
+		return defaultRenderedConfigHistoryLimit
+	}
+	return int(cc.Spec.RenderedConfigHistoryLimit)
+}
+
+// garbageCollectRenderedConfigs deletes the rendered MachineConfigs of the pool
+// which are neither in its history nor targeted by the pool or any of its nodes.
+func (ctrl *Controller) garbageCollectRenderedConfigs(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	inUse := map[string]bool{
+		pool.Spec.Configuration.Name:   true,
+		pool.Status.Configuration.Name: true,
+		pool.Spec.RollbackTo:           true,
+	}
+	for _, entry := range pool.Status.History {
+		inUse[entry.Name] = true
+	}
+	for _, node := range nodes {
+		inUse[node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey]] = true
+		inUse[node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey]] = true
+	}
+
+	mcs, err := ctrl.mcLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs {
+		controllerRef := metav1.GetControllerOf(mc)
+		if controllerRef == nil || controllerRef.Kind != "MachineConfigPool" || controllerRef.UID != pool.UID {
+			continue
+		}
+		if inUse[mc.Name] || time.Since(mc.CreationTimestamp.Time) < renderedConfigGCGracePeriod {
+			continue
+		}
+		glog.Infof("Pool %s: deleting rendered MachineConfig %s no longer in history", pool.Name, mc.Name)
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
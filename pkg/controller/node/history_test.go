@@ -0,0 +1,102 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateHistory(t *testing.T) {
+	now := metav1.Now()
+	history := updateHistory(nil, "v0", now)
+	assert.Equal(t, []mcfgv1.MachineConfigPoolHistoryEntry{{Name: "v0", Timestamp: now}}, history)
+
+	// the same config isn't recorded twice in a row
+	history = updateHistory(history, "v0", metav1.NewTime(now.Add(time.Minute)))
+	assert.Equal(t, []mcfgv1.MachineConfigPoolHistoryEntry{{Name: "v0", Timestamp: now}}, history)
+
+	history = updateHistory(history, "v1", now)
+	history = updateHistory(history, "v2", now)
+	assert.Equal(t, "v2", history[0].Name)
+	assert.Len(t, history, 3)
+
+	// rolling back moves the config to the front
+	history = updateHistory(history, "v0", now)
+	var names []string
+	for _, entry := range history {
+		names = append(names, entry.Name)
+	}
+	assert.Equal(t, []string{"v0", "v2", "v1"}, names)
+}
+
+func TestRollbackTo(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v2")
+	pool.Status.History = []mcfgv1.MachineConfigPoolHistoryEntry{{Name: "v1"}, {Name: "v0"}}
+	assert.Equal(t, "v2", getTargetConfig(pool))
+
+	// only configs in the history can be rolled back to
+	pool.Spec.RollbackTo = "v-unknown"
+	assert.Equal(t, "v2", getTargetConfig(pool))
+
+	pool.Spec.RollbackTo = "v0"
+	assert.Equal(t, "v0", getTargetConfig(pool))
+
+	nodes := []*corev1.Node{
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-1", "v2", "v2", corev1.ConditionTrue),
+	}
+	candidates := getCandidateMachines(pool, nodes, 1)
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, "node-1", candidates[0].Name)
+
+	nodes[1] = newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue)
+	status := calculateStatus(pool, nodes)
+	assert.Equal(t, int32(2), status.UpdatedMachineCount)
+	assert.Equal(t, "v0", status.Configuration.Name)
+	assert.Equal(t, "v0", status.History[0].Name)
+	assert.Len(t, status.History, 2)
+}
+
+func TestGarbageCollectRenderedConfigs(t *testing.T) {
+	f := newFixture(t)
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "rendered-worker-3")
+	pool.Status.Configuration.Name = "rendered-worker-2"
+	pool.Status.History = []mcfgv1.MachineConfigPoolHistoryEntry{{Name: "rendered-worker-2"}, {Name: "rendered-worker-1"}}
+	oref := metav1.NewControllerRef(pool, mcfgv1.SchemeGroupVersion.WithKind("MachineConfigPool"))
+
+	var configs []*mcfgv1.MachineConfig
+	for _, name := range []string{"rendered-worker-0", "rendered-worker-1", "rendered-worker-2", "rendered-worker-3", "rendered-worker-4", "rendered-worker-new", "00-worker"} {
+		mc := helpers.NewMachineConfig(name, nil, "", nil)
+		if name != "00-worker" {
+			mc.OwnerReferences = []metav1.OwnerReference{*oref}
+		}
+		configs = append(configs, mc)
+	}
+	// just rendered, the pool might not be targeting it yet
+	configs[5].CreationTimestamp = metav1.Now()
+	for _, mc := range configs {
+		f.objects = append(f.objects, mc)
+	}
+	f.mcpLister = append(f.mcpLister, pool)
+	f.objects = append(f.objects, pool)
+
+	// rendered-worker-4 is still used by a node which never finished updating
+	nodes := []*corev1.Node{newNode("node-0", "rendered-worker-4", "rendered-worker-3")}
+
+	c := f.newController()
+	assert.Nil(t, c.garbageCollectRenderedConfigs(pool, nodes))
+
+	var deleted []string
+	for _, action := range filterInformerActions(f.client.Actions()) {
+		if action.GetVerb() == "delete" && action.GetResource().Resource == "machineconfigs" {
+			deleted = append(deleted, action.(interface{ GetName() string }).GetName())
+		}
+	}
+	assert.Equal(t, []string{"rendered-worker-0"}, deleted)
+}
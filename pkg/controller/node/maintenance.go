@@ -0,0 +1,227 @@
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// cronScheduleHorizon bounds the search for the next activation of a schedule,
+// e.g. "0 0 30 2 *" never activates.
+const cronScheduleHorizon = 5
+
+// cronField is the bitset of the values of a field of a cron schedule.
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronSchedule is a parsed cron expression of 5 fields: minute, hour, day of
+// month, month and day of week.
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+	// as in cron, when both days are restricted, either of them matching is enough
+	domStar, dowStar bool
+}
+
+// parseCronSchedule parses spec. Fields are *, values, ranges and lists of those,
+// optionally with a /step. Days of week go from 0 (Sunday) to 7 (Sunday again).
+func parseCronSchedule(spec string) (*cronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+	bounds := []struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	parsed := make([]cronField, len(fields))
+	for i, field := range fields {
+		f, err := parseCronField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %v", field, err)
+		}
+		parsed[i] = f
+	}
+	if parsed[4].has(7) {
+		parsed[4] |= 1
+	}
+	return &cronSchedule{
+		minute:  parsed[0],
+		hour:    parsed[1],
+		dom:     parsed[2],
+		month:   parsed[3],
+		dow:     parsed[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (cronField, error) {
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+		}
+		low, high := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rng)
+			}
+			low, high = v, v
+			if step > 1 {
+				// as in cron, 5/15 means from 5 to the maximum
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is out of the range %d-%d", rng, min, max)
+		}
+		for v := low; v <= high; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := s.dom.has(t.Day()), s.dow.has(int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first activation of the schedule strictly after t, in the
+// location of t, or the zero time if there is none in the next years.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.Year() + cronScheduleHorizon
+	for t.Year() <= limit {
+		switch {
+		case !s.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// maintenanceWindow is a validated MaintenanceWindow.
+type maintenanceWindow struct {
+	schedule *cronSchedule
+	duration time.Duration
+	location *time.Location
+}
+
+// parseMaintenanceWindows returns the valid maintenance windows of the pool, and
+// why the others are invalid.
+func parseMaintenanceWindows(pool *mcfgv1.MachineConfigPool) ([]maintenanceWindow, []string) {
+	var windows []maintenanceWindow
+	var invalid []string
+	for _, w := range pool.Spec.MaintenanceWindows {
+		schedule, err := parseCronSchedule(w.Schedule)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("schedule %q: %v", w.Schedule, err))
+			continue
+		}
+		if w.Duration.Duration <= 0 {
+			invalid = append(invalid, fmt.Sprintf("schedule %q: duration must be positive", w.Schedule))
+			continue
+		}
+		location, err := time.LoadLocation(w.TimeZone)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("schedule %q: invalid time zone %q", w.Schedule, w.TimeZone))
+			continue
+		}
+		windows = append(windows, maintenanceWindow{schedule: schedule, duration: w.Duration.Duration, location: location})
+	}
+	return windows, invalid
+}
+
+// getMaintenanceWindowState returns whether one of windows is open at now and
+// when the open windows close, or else when the next window opens. The time is
+// zero when no window will open.
+func getMaintenanceWindowState(windows []maintenanceWindow, now time.Time) (bool, time.Time) {
+	var closes, opens time.Time
+	for _, w := range windows {
+		local := now.In(w.location)
+		// the windows which opened within duration are still open
+		for start := w.schedule.next(local.Add(-w.duration)); !start.IsZero() && !start.After(local); start = w.schedule.next(start) {
+			if end := start.Add(w.duration); end.After(closes) {
+				closes = end
+			}
+		}
+		if start := w.schedule.next(local); !start.IsZero() && (opens.IsZero() || start.Before(opens)) {
+			opens = start
+		}
+	}
+	if !closes.IsZero() {
+		return true, closes
+	}
+	return false, opens
+}
+
+// inMaintenanceWindow returns whether the machines of the pool can start
+// updating at now, and when that changes, zero if it doesn't.
+func inMaintenanceWindow(pool *mcfgv1.MachineConfigPool, now time.Time) (bool, time.Time) {
+	if len(pool.Spec.MaintenanceWindows) == 0 || (pool.Spec.RollbackTo != "" && isInHistory(pool, pool.Spec.RollbackTo)) {
+		return true, time.Time{}
+	}
+	windows, _ := parseMaintenanceWindows(pool)
+	return getMaintenanceWindowState(windows, now)
+}
+
+// setMaintenanceWindowCondition reports whether a maintenance window of the
+// pool is open, and when the next one opens.
+func setMaintenanceWindowCondition(status *mcfgv1.MachineConfigPoolStatus, pool *mcfgv1.MachineConfigPool, now time.Time) {
+	if len(pool.Spec.MaintenanceWindows) == 0 {
+		mcfgv1.RemoveMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolInMaintenanceWindow)
+		return
+	}
+
+	windows, invalid := parseMaintenanceWindows(pool)
+	open, at := getMaintenanceWindowState(windows, now)
+	var cond *mcfgv1.MachineConfigPoolCondition
+	switch {
+	case open:
+		cond = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolInMaintenanceWindow, corev1.ConditionTrue, "WindowOpen",
+			fmt.Sprintf("Maintenance window open until %s", at.UTC().Format(time.RFC3339)))
+	case !at.IsZero():
+		cond = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolInMaintenanceWindow, corev1.ConditionFalse, "WindowClosed",
+			fmt.Sprintf("Next maintenance window opens at %s", at.UTC().Format(time.RFC3339)))
+	default:
+		cond = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolInMaintenanceWindow, corev1.ConditionFalse, "NoWindowScheduled",
+			"No maintenance window is scheduled")
+	}
+	if len(invalid) > 0 {
+		cond.Message += fmt.Sprintf(", ignoring invalid windows: %s", strings.Join(invalid, "; "))
+	}
+	mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+}
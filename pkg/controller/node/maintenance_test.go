@@ -0,0 +1,132 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	for _, spec := range []string{"* * * * *", "0 22 * * 1-5", "*/15 1,3 1-10/2 * 7", "30 2 * 6-8 0"} {
+		_, err := parseCronSchedule(spec)
+		assert.Nil(t, err, spec)
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@daily"} {
+		_, err := parseCronSchedule(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2020, time.January, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{{
+		spec:     "* * * * *",
+		expected: time.Date(2020, time.January, 1, 10, 31, 0, 0, time.UTC),
+	}, {
+		spec:     "30 10 * * *",
+		expected: time.Date(2020, time.January, 2, 10, 30, 0, 0, time.UTC),
+	}, {
+		spec:     "0 22 * * 1-5",
+		expected: time.Date(2020, time.January, 1, 22, 0, 0, 0, time.UTC),
+	}, {
+		// Sunday
+		spec:     "0 2 * * 7",
+		expected: time.Date(2020, time.January, 5, 2, 0, 0, 0, time.UTC),
+	}, {
+		// the 15th or a Friday
+		spec:     "0 0 15 * 5",
+		expected: time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC),
+	}, {
+		spec:     "0 0 29 2 *",
+		expected: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+	}, {
+		spec:     "0 0 30 2 *",
+		expected: time.Time{},
+	}}
+	for _, test := range tests {
+		schedule, err := parseCronSchedule(test.spec)
+		require.Nil(t, err)
+		assert.Equal(t, test.expected, schedule.next(now), test.spec)
+	}
+}
+
+func newPoolWithWindows(windows ...mcfgv1.MaintenanceWindow) *mcfgv1.MachineConfigPool {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.MaintenanceWindows = windows
+	return pool
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 23, 0, 0, 0, time.UTC)
+	nightly := mcfgv1.MaintenanceWindow{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+
+	open, at := inMaintenanceWindow(newPoolWithWindows(), now)
+	assert.True(t, open)
+	assert.True(t, at.IsZero())
+
+	open, at = inMaintenanceWindow(newPoolWithWindows(nightly), now)
+	assert.True(t, open)
+	assert.Equal(t, time.Date(2020, time.January, 2, 2, 0, 0, 0, time.UTC), at.UTC())
+
+	open, at = inMaintenanceWindow(newPoolWithWindows(nightly), now.Add(3*time.Hour))
+	assert.False(t, open)
+	assert.Equal(t, time.Date(2020, time.January, 2, 22, 0, 0, 0, time.UTC), at.UTC())
+
+	// 22:00 in Paris is 21:00 UTC in winter
+	paris := nightly
+	paris.TimeZone = "Europe/Paris"
+	open, at = inMaintenanceWindow(newPoolWithWindows(paris), now.Add(-90*time.Minute))
+	assert.True(t, open)
+	assert.Equal(t, time.Date(2020, time.January, 2, 1, 0, 0, 0, time.UTC), at.UTC())
+
+	// the earliest window opens first
+	weekly := mcfgv1.MaintenanceWindow{Schedule: "0 8 * * 4", Duration: metav1.Duration{Duration: time.Hour}}
+	open, at = inMaintenanceWindow(newPoolWithWindows(nightly, weekly), now.Add(4*time.Hour))
+	assert.False(t, open)
+	assert.Equal(t, time.Date(2020, time.January, 2, 8, 0, 0, 0, time.UTC), at.UTC())
+
+	// invalid windows never open
+	invalid := mcfgv1.MaintenanceWindow{Schedule: "0 22 * *", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+	open, at = inMaintenanceWindow(newPoolWithWindows(invalid), now)
+	assert.False(t, open)
+	assert.True(t, at.IsZero())
+
+	// rollbacks don't wait for a window
+	pool := newPoolWithWindows(nightly)
+	pool.Spec.RollbackTo = "v0"
+	pool.Status.History = []mcfgv1.MachineConfigPoolHistoryEntry{{Name: "v0"}}
+	open, _ = inMaintenanceWindow(pool, now.Add(3*time.Hour))
+	assert.True(t, open)
+}
+
+func TestSetMaintenanceWindowCondition(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	status := mcfgv1.MachineConfigPoolStatus{}
+	nightly := mcfgv1.MaintenanceWindow{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+
+	setMaintenanceWindowCondition(&status, newPoolWithWindows(nightly), now)
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolInMaintenanceWindow)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "Next maintenance window opens at 2020-01-01T22:00:00Z", cond.Message)
+
+	setMaintenanceWindowCondition(&status, newPoolWithWindows(nightly, mcfgv1.MaintenanceWindow{Schedule: "0 0 * * *", TimeZone: "Mars/Olympus"}), now.Add(11*time.Hour))
+	cond = mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolInMaintenanceWindow)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "Maintenance window open until 2020-01-02T02:00:00Z, ignoring invalid windows: ")
+
+	setMaintenanceWindowCondition(&status, newPoolWithWindows(), now)
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolInMaintenanceWindow))
+}
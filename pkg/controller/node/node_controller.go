@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
 	configv1 "github.com/openshift/api/config/v1"
 	cligoinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
 	cligolistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+	operatorinformersv1 "github.com/openshift/client-go/operator/informers/externalversions/operator/v1"
+	operatorlistersv1 "github.com/openshift/client-go/operator/listers/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	"github.com/openshift/machine-config-operator/internal"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
@@ -30,9 +36,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformersv1 "k8s.io/client-go/informers/core/v1"
+	policyinformersv1beta1 "k8s.io/client-go/informers/policy/v1beta1"
 	clientset "k8s.io/client-go/kubernetes"
 	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	policylisterv1beta1 "k8s.io/client-go/listers/policy/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	clientretry "k8s.io/client-go/util/retry"
@@ -77,10 +85,24 @@ type Controller struct {
 	enqueueMachineConfigPool func(*mcfgv1.MachineConfigPool)
 
 	mcpLister  mcfglistersv1.MachineConfigPoolLister
+	mcLister   mcfglistersv1.MachineConfigLister
+	ccLister   mcfglistersv1.ControllerConfigLister
 	nodeLister corelisterv1.NodeLister
+	podIndexer cache.Indexer
+	pdbLister  policylisterv1beta1.PodDisruptionBudgetLister
+	etcdLister operatorlistersv1.EtcdLister
+
+	nodeStateLister mcfglistersv1.NodeMachineConfigStateLister
 
 	mcpListerSynced  cache.InformerSynced
+	mcListerSynced   cache.InformerSynced
+	ccListerSynced   cache.InformerSynced
 	nodeListerSynced cache.InformerSynced
+	podListerSynced  cache.InformerSynced
+	pdbListerSynced  cache.InformerSynced
+	etcdListerSynced cache.InformerSynced
+
+	nodeStateListerSynced cache.InformerSynced
 
 	schedulerList         cligolistersv1.SchedulerLister
 	schedulerListerSynced cache.InformerSynced
@@ -91,10 +113,17 @@ type Controller struct {
 // New returns a new node controller.
 func New(
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	ccInformer mcfginformersv1.ControllerConfigInformer,
 	nodeInformer coreinformersv1.NodeInformer,
+	podInformer coreinformersv1.PodInformer,
+	pdbInformer policyinformersv1beta1.PodDisruptionBudgetInformer,
+	etcdInformer operatorinformersv1.EtcdInformer,
 	schedulerInformer cligoinformersv1.SchedulerInformer,
+	nodeStateInformer mcfginformersv1.NodeMachineConfigStateInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -103,8 +132,8 @@ func New(
 	ctrl := &Controller{
 		client:        mcfgClient,
 		kubeClient:    kubeClient,
-		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-nodecontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-nodecontroller"),
+		eventRecorder: ctrlcommon.NewAggregatingRecorder(eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-nodecontroller"}), ctrlcommon.EventAggregationWindow, ctrlcommon.EventAggregationBurst),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-nodecontroller"),
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -122,17 +151,41 @@ func New(
 		UpdateFunc: ctrl.checkMasterNodesOnUpdate,
 		DeleteFunc: ctrl.checkMasterNodesOnDelete,
 	})
+	nodeStateInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateNodeState,
+	})
 	ctrl.syncHandler = ctrl.syncMachineConfigPool
 	ctrl.enqueueMachineConfigPool = ctrl.enqueueDefault
 
 	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.ccLister = ccInformer.Lister()
 	ctrl.nodeLister = nodeInformer.Lister()
+	if err := podInformer.Informer().AddIndexers(cache.Indexers{podNodeNameIndex: indexPodByNodeName}); err != nil {
+		glog.Warningf("Failed to index the pods by node: %v", err)
+	}
+	ctrl.podIndexer = podInformer.Informer().GetIndexer()
+	ctrl.pdbLister = pdbInformer.Lister()
 	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
 	ctrl.nodeListerSynced = nodeInformer.Informer().HasSynced
+	ctrl.podListerSynced = podInformer.Informer().HasSynced
+	ctrl.pdbListerSynced = pdbInformer.Informer().HasSynced
+	if etcdInformer != nil {
+		ctrl.etcdLister = etcdInformer.Lister()
+		ctrl.etcdListerSynced = etcdInformer.Informer().HasSynced
+	} else {
+		// the cluster doesn't run the etcd operator
+		ctrl.etcdListerSynced = func() bool { return true }
+	}
 
 	ctrl.schedulerList = schedulerInformer.Lister()
 	ctrl.schedulerListerSynced = schedulerInformer.Informer().HasSynced
 
+	ctrl.nodeStateLister = nodeStateInformer.Lister()
+	ctrl.nodeStateListerSynced = nodeStateInformer.Informer().HasSynced
+
 	return ctrl
 }
 
@@ -141,18 +194,16 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer ctrl.queue.ShutDown()
 
-	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.nodeListerSynced, ctrl.schedulerListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.mcListerSynced, ctrl.ccListerSynced, ctrl.nodeListerSynced, ctrl.podListerSynced, ctrl.pdbListerSynced, ctrl.etcdListerSynced, ctrl.schedulerListerSynced, ctrl.nodeStateListerSynced) {
 		return
 	}
 
 	glog.Info("Starting MachineConfigController-NodeController")
 	defer glog.Info("Shutting down MachineConfigController-NodeController")
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.worker, time.Second, stopCh)
-	}
-
-	<-stopCh
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-NodeController", ctrl.queue)
 }
 
 func (ctrl *Controller) getCurrentMasters() ([]*corev1.Node, error) {
@@ -317,6 +368,9 @@ func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
 	}
 	glog.V(4).Infof("Deleting MachineConfigPool %s", pool.Name)
 	// TODO(abhinavdahiya): handle deletes.
+	for _, state := range poolMachineStates {
+		ctrlcommon.MCCPoolMachines.DeleteLabelValues(pool.Name, state)
+	}
 }
 
 // Determine if masters are currently configured as schedulable
@@ -645,6 +699,7 @@ func (ctrl *Controller) handleErr(err error, key interface{}) {
 		ctrl.queue.Forget(key)
 		return
 	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.NodeControllerName).Inc()
 
 	if ctrl.queue.NumRequeues(key) < maxRetries {
 		glog.V(2).Infof("Error syncing machineconfigpool %v: %v", key, err)
@@ -703,27 +758,114 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return ctrl.syncStatusOnly(pool)
 	}
 
+	pool, err = ctrl.syncPause(pool)
+	if err != nil {
+		return err
+	}
 	if pool.Spec.Paused {
 		return ctrl.syncStatusOnly(pool)
 	}
 
+	if pool.Spec.RollbackTo != "" && !isInHistory(pool, pool.Spec.RollbackTo) {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidRollback", "Ignoring rollbackTo %s: not found in the history of the pool", pool.Spec.RollbackTo)
+	}
+	if _, invalid := parseMaintenanceWindows(pool); len(invalid) > 0 {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidMaintenanceWindow", "Ignoring maintenanceWindows: %s", strings.Join(invalid, "; "))
+	}
+
 	nodes, err := ctrl.getNodesForPool(pool)
 	if err != nil {
 		return err
 	}
 
+	if err := ctrl.syncNodeStates(nodes); err != nil {
+		return err
+	}
+	if err := ctrl.syncAutoRemediateDrift(pool, nodes); err != nil {
+		return err
+	}
+	if err := ctrl.syncUnmanagedPaths(pool, nodes); err != nil {
+		return err
+	}
+	if err := ctrl.syncRebootStrategy(pool, nodes); err != nil {
+		return err
+	}
+	if err := ctrl.syncPostUpdateHealthCheck(pool, nodes); err != nil {
+		return err
+	}
+
 	maxunavail, err := maxUnavailable(pool, nodes)
 	if err != nil {
 		return err
 	}
 
-	candidates := getCandidateMachines(pool, nodes, maxunavail)
+	canary := calculateCanaryStatus(pool, nodes, metav1.Now())
+	pool, err = ctrl.syncCanary(pool, canary)
+	if err != nil {
+		return err
+	}
+	if pool.Spec.Paused {
+		return ctrl.syncStatusOnly(pool)
+	}
+
+	drainPolicy, err := ctrl.getDrainPolicy(pool)
+	if err != nil {
+		return err
+	}
+	disruptionPolicy, err := ctrl.getNodeDisruptionPolicy()
+	if err != nil {
+		return err
+	}
+	policies := map[string]string{
+		daemonconsts.DrainPolicyAnnotationKey:          drainPolicy,
+		daemonconsts.NodeDisruptionPolicyAnnotationKey: disruptionPolicy,
+	}
+
+	targetConfig := getTargetConfig(pool)
+	candidates, capacity := getAllCandidateMachines(pool, nodes, maxunavail)
+	open, changesAt := inMaintenanceWindow(pool, time.Now())
+	if !changesAt.IsZero() {
+		// sync again when the window opens or closes
+		ctrl.enqueueAfter(pool, time.Until(changesAt))
+	}
+	if !open && len(candidates) > 0 {
+		glog.V(2).Infof("Pool %s: outside of its maintenance windows, not updating %d nodes", pool.Name, len(candidates))
+		candidates = nil
+	}
+	var blockers rolloutBlockers
+	if len(candidates) > 0 {
+		blockers.etcdQuorumRisk, err = ctrl.checkEtcdQuorum(pool)
+		if err != nil {
+			return err
+		}
+		if blockers.etcdQuorumRisk != "" {
+			glog.Warningf("Pool %s: not updating %d nodes: %s", pool.Name, len(candidates), blockers.etcdQuorumRisk)
+			ctrl.enqueueAfter(pool, etcdRecheckInterval)
+			candidates = nil
+		}
+	}
+	if !isStagedPool(pool) {
+		candidates, blockers.pdbs, err = ctrl.filterDrainableCandidates(candidates)
+		if err != nil {
+			return err
+		}
+		if len(blockers.pdbs) > 0 {
+			ctrl.enqueueAfter(pool, pdbRecheckInterval)
+		}
+	}
+	if len(candidates) > capacity {
+		candidates = candidates[:capacity]
+	}
+	candidates = limitCanaryCandidates(canary, nodes, candidates)
 	for _, node := range candidates {
-		if err := ctrl.setDesiredMachineConfigAnnotation(node.Name, pool.Spec.Configuration.Name); err != nil {
+		if err := ctrl.setDesiredMachineConfigAnnotation(node.Name, targetConfig, isStagedPool(pool), policies); err != nil {
 			return err
 		}
 	}
-	return ctrl.syncStatusOnly(pool)
+	if err := ctrl.garbageCollectRenderedConfigs(pool, nodes); err != nil {
+		glog.Warningf("Pool %s: failed to garbage collect rendered MachineConfigs: %v", pool.Name, err)
+	}
+	return ctrl.syncStatus(pool, blockers)
 }
 
 func (ctrl *Controller) getNodesForPool(pool *mcfgv1.MachineConfigPool) ([]*corev1.Node, error) {
@@ -752,11 +894,70 @@ func (ctrl *Controller) getNodesForPool(pool *mcfgv1.MachineConfigPool) ([]*core
 		}
 		nodes = append(nodes, n)
 	}
-	return nodes, nil
+	return ctrl.applyNodeStates(nodes), nil
+}
+
+// getConflictingNodes returns the reasons why nodes selected by the pool can't be
+// assigned to a single pool, e.g. they are labeled with more than one custom role.
+func (ctrl *Controller) getConflictingNodes(pool *mcfgv1.MachineConfigPool) ([]string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %v", err)
+	}
+
+	nodes, err := ctrl.nodeLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, n := range nodes {
+		if _, err := ctrl.getPoolsForNode(n); err != nil {
+			conflicts = append(conflicts, err.Error())
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts, nil
+}
+
+// syncAutoRemediateDrift hands the autoRemediateDrift setting of the pool to
+// the daemons of its nodes.
+func (ctrl *Controller) syncAutoRemediateDrift(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	for _, node := range nodes {
+		_, remediate := node.Annotations[daemonconsts.AutoRemediateDriftAnnotationKey]
+		if remediate == pool.Spec.AutoRemediateDrift {
+			continue
+		}
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			if pool.Spec.AutoRemediateDrift {
+				node.Annotations[daemonconsts.AutoRemediateDriftAnnotationKey] = "true"
+			} else {
+				delete(node.Annotations, daemonconsts.AutoRemediateDriftAnnotationKey)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfig string) error {
+// isStagedPool returns whether the machines of the pool only stage updates.
+// Rollbacks are always applied right away.
+func isStagedPool(pool *mcfgv1.MachineConfigPool) bool {
+	return pool.Spec.UpdateStrategy != nil && pool.Spec.UpdateStrategy.Staged && getTargetConfig(pool) == pool.Spec.Configuration.Name
+}
+
+// setDesiredMachineConfigAnnotation sets the desired config of the node along with
+// the policies the daemon applies it with, keyed by annotation. Empty policies are removed.
+func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfig string, staged bool, policies map[string]string) error {
 	glog.Infof("Setting node %s to desired config %s", nodeName, currentConfig)
+	if err := ctrl.setNodeStateDesiredConfig(nodeName, currentConfig); err != nil {
+		return fmt.Errorf("failed to set the desired config of the NodeMachineConfigState of node %s: %v", nodeName, err)
+	}
 	return clientretry.RetryOnConflict(nodeUpdateBackoff, func() error {
 		oldNode, err := ctrl.kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
 		if err != nil {
@@ -771,10 +972,27 @@ func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfi
 		if newNode.Annotations == nil {
 			newNode.Annotations = map[string]string{}
 		}
-		if newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == currentConfig {
+		_, isStaged := newNode.Annotations[daemonconsts.StagedUpdateAnnotationKey]
+		upToDate := newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] == currentConfig && isStaged == staged
+		for key, policy := range policies {
+			upToDate = upToDate && newNode.Annotations[key] == policy
+		}
+		if upToDate {
 			return nil
 		}
 		newNode.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = currentConfig
+		if staged {
+			newNode.Annotations[daemonconsts.StagedUpdateAnnotationKey] = "true"
+		} else {
+			delete(newNode.Annotations, daemonconsts.StagedUpdateAnnotationKey)
+		}
+		for key, policy := range policies {
+			if policy != "" {
+				newNode.Annotations[key] = policy
+			} else {
+				delete(newNode.Annotations, key)
+			}
+		}
 		newData, err := json.Marshal(newNode)
 		if err != nil {
 			return err
@@ -790,12 +1008,24 @@ func (ctrl *Controller) setDesiredMachineConfigAnnotation(nodeName, currentConfi
 }
 
 func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int) []*corev1.Node {
-	targetConfig := pool.Spec.Configuration.Name
+	nodes, capacity := getAllCandidateMachines(pool, nodesInPool, maxUnavailable)
+	if len(nodes) < capacity {
+		return nodes
+	}
+	return nodes[:capacity]
+}
+
+// getAllCandidateMachines returns the nodes which can start updating, in the
+// order they should, and how many of them can update at once.
+func getAllCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.Node, maxUnavailable int) ([]*corev1.Node, int) {
+	targetConfig := getTargetConfig(pool)
+	// unmanaged nodes are neither updated nor count against availability
+	nodesInPool, _ = splitUnmanagedNodes(nodesInPool)
 
 	unavail := getUnavailableMachines(nodesInPool)
 	// If we're at capacity, there's nothing to do.
 	if len(unavail) >= maxUnavailable {
-		return nil
+		return nil, 0
 	}
 	capacity := maxUnavailable - len(unavail)
 	failingThisConfig := 0
@@ -816,25 +1046,100 @@ func getCandidateMachines(pool *mcfgv1.MachineConfigPool, nodesInPool []*corev1.
 	// availability - it might be a transient issue, and if the issue
 	// clears we don't want multiple to update at once.
 	if failingThisConfig >= capacity {
-		return nil
+		return nil, 0
 	}
 	capacity -= failingThisConfig
 
-	if len(nodes) < capacity {
-		return nodes
+	sortByUpdatePriority(nodes)
+	return filterZoneCandidates(pool, unavail, nodes), capacity
+}
+
+// getUpdatePriority returns the update priority of the node, its annotation
+// taking precedence over its label. Invalid priorities count as the default 0.
+func getUpdatePriority(node *corev1.Node) int {
+	value, ok := node.Annotations[daemonconsts.UpdatePriorityAnnotationKey]
+	if !ok {
+		value, ok = node.Labels[daemonconsts.UpdatePriorityAnnotationKey]
 	}
-	return nodes[:capacity]
+	if !ok {
+		return 0
+	}
+	priority, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		glog.Warningf("Ignoring invalid update priority %q of node %s: %v", value, node.Name, err)
+		return 0
+	}
+	return priority
+}
+
+// sortByUpdatePriority sorts the candidates by decreasing update priority,
+// keeping the order of the nodes with the same priority.
+func sortByUpdatePriority(nodes []*corev1.Node) {
+	priorities := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		priorities[node.Name] = getUpdatePriority(node)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return priorities[nodes[i].Name] > priorities[nodes[j].Name] })
+}
+
+// getNodeZone returns the zone of the node, if labeled.
+func getNodeZone(node *corev1.Node) string {
+	if zone, ok := node.Labels[corev1.LabelZoneFailureDomainStable]; ok {
+		return zone
+	}
+	return node.Labels[corev1.LabelZoneFailureDomain]
 }
 
+// filterZoneCandidates drops the candidates that would make more than
+// maxUnavailablePerZone machines of the same zone unavailable at once.
+func filterZoneCandidates(pool *mcfgv1.MachineConfigPool, unavail, candidates []*corev1.Node) []*corev1.Node {
+	perZone := 1
+	if pool.Spec.UpdateStrategy != nil && pool.Spec.UpdateStrategy.MaxUnavailablePerZone != nil {
+		perZone = int(*pool.Spec.UpdateStrategy.MaxUnavailablePerZone)
+	}
+	if perZone == 0 {
+		return candidates
+	}
+
+	busy := make(map[string]int)
+	for _, node := range unavail {
+		busy[getNodeZone(node)]++
+	}
+	var nodes []*corev1.Node
+	for _, node := range candidates {
+		zone := getNodeZone(node)
+		if zone != "" {
+			if busy[zone] >= perZone {
+				continue
+			}
+			busy[zone]++
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// maxUnavailable returns how many machines of the pool can be updating at once.
+// Percentages are relative to the ready machines so that unready machines,
+// which already count as unavailable, don't inflate the budget.
 func maxUnavailable(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) (int, error) {
 	intOrPercent := intstrutil.FromInt(1)
 	if pool.Spec.MaxUnavailable != nil {
 		intOrPercent = *pool.Spec.MaxUnavailable
 	}
-	maxunavail, err := intstrutil.GetValueFromIntOrPercent(&intOrPercent, len(nodes), false)
+	ready := 0
+	for _, node := range nodes {
+		if isNodeReady(node) {
+			ready++
+		}
+	}
+	maxunavail, err := intstrutil.GetValueFromIntOrPercent(&intOrPercent, ready, false)
 	if err != nil {
 		return 0, err
 	}
+	if maxunavail < 0 {
+		return 0, fmt.Errorf("invalid maxUnavailable %s: must not be negative", intOrPercent.String())
+	}
 	if maxunavail == 0 {
 		maxunavail = 1
 	}
@@ -20,6 +20,7 @@ import (
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
 	apicfgv1 "github.com/openshift/api/config/v1"
 	fakeconfigv1client "github.com/openshift/client-go/config/clientset/versioned/fake"
@@ -72,12 +73,20 @@ func (f *fixture) newController() *Controller {
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
 	k8sI := kubeinformers.NewSharedInformerFactory(f.kubeclient, noResyncPeriodFunc())
 	ci := configv1informer.NewSharedInformerFactory(f.schedulerClient, noResyncPeriodFunc())
-	c := New(i.Machineconfiguration().V1().MachineConfigPools(), k8sI.Core().V1().Nodes(),
-		ci.Config().V1().Schedulers(), f.kubeclient, f.client)
+	c := New(i.Machineconfiguration().V1().MachineConfigPools(), i.Machineconfiguration().V1().MachineConfigs(),
+		i.Machineconfiguration().V1().ControllerConfigs(), k8sI.Core().V1().Nodes(),
+		k8sI.Core().V1().Pods(), k8sI.Policy().V1beta1().PodDisruptionBudgets(), nil, ci.Config().V1().Schedulers(),
+		i.Machineconfiguration().V1().NodeMachineConfigStates(), f.kubeclient, f.client, workqueue.DefaultControllerRateLimiter())
 
 	c.mcpListerSynced = alwaysReady
+	c.mcListerSynced = alwaysReady
+	c.ccListerSynced = alwaysReady
 	c.nodeListerSynced = alwaysReady
+	c.podListerSynced = alwaysReady
+	c.pdbListerSynced = alwaysReady
+	c.etcdListerSynced = alwaysReady
 	c.schedulerListerSynced = alwaysReady
+	c.nodeStateListerSynced = alwaysReady
 	c.eventRecorder = &record.FakeRecorder{}
 
 	stopCh := make(chan struct{})
@@ -186,11 +195,23 @@ func checkAction(expected, actual core.Action, t *testing.T) {
 func filterInformerActions(actions []core.Action) []core.Action {
 	ret := []core.Action{}
 	for _, action := range actions {
+		// the NodeMachineConfigStates are covered by their own tests
+		if action.GetResource().Resource == "nodemachineconfigstates" {
+			continue
+		}
 		if len(action.GetNamespace()) == 0 &&
 			(action.Matches("list", "machineconfigpools") ||
 				action.Matches("watch", "machineconfigpools") ||
+				action.Matches("list", "machineconfigs") ||
+				action.Matches("watch", "machineconfigs") ||
+				action.Matches("list", "controllerconfigs") ||
+				action.Matches("watch", "controllerconfigs") ||
 				action.Matches("list", "nodes") ||
-				action.Matches("watch", "nodes")) {
+				action.Matches("watch", "nodes") ||
+				action.Matches("list", "pods") ||
+				action.Matches("watch", "pods") ||
+				action.Matches("list", "poddisruptionbudgets") ||
+				action.Matches("watch", "poddisruptionbudgets")) {
 			continue
 		}
 		ret = append(ret, action)
@@ -462,6 +483,29 @@ func TestMaxUnavailable(t *testing.T) {
 			nodes:      newNodeSet(7),
 			expected:   3,
 			err:        false,
+		}, {
+			// percentages are relative to the ready machines
+			maxUnavail: intStrPtr(intstr.FromString("50%")),
+			nodes: []*corev1.Node{
+				newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+				newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue),
+				newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+				newNodeWithReady("node-3", "v0", "v0", corev1.ConditionTrue),
+				newNodeWithReady("node-4", "v0", "v0", corev1.ConditionFalse),
+				newNodeWithReady("node-5", "v0", "v0", corev1.ConditionFalse),
+			},
+			expected: 2,
+			err:      false,
+		}, {
+			maxUnavail: intStrPtr(intstr.FromString("10%")),
+			nodes:      newNodeSet(4),
+			expected:   1,
+			err:        false,
+		}, {
+			maxUnavail: intStrPtr(intstr.FromInt(-1)),
+			nodes:      newNodeSet(4),
+			expected:   0,
+			err:        true,
 		},
 	}
 
@@ -597,6 +641,140 @@ func TestGetCandidateMachines(t *testing.T) {
 	}
 }
 
+func newNodeInZone(name, currentConfig, desiredConfig, zone string) *corev1.Node {
+	node := newNodeWithReady(name, currentConfig, desiredConfig, corev1.ConditionTrue)
+	node.Labels = map[string]string{corev1.LabelZoneFailureDomainStable: zone}
+	return node
+}
+
+func TestGetCandidateMachinesZones(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	tests := []struct {
+		perZone  *int32
+		nodes    []*corev1.Node
+		expected []string
+	}{{
+		// one machine per zone by default
+		nodes: []*corev1.Node{
+			newNodeInZone("node-0", "v0", "v0", "a"),
+			newNodeInZone("node-1", "v0", "v0", "a"),
+			newNodeInZone("node-2", "v0", "v0", "b"),
+			newNodeInZone("node-3", "v0", "v0", "c"),
+		},
+		expected: []string{"node-0", "node-2", "node-3"},
+	}, {
+		// a machine is already updating in zone a
+		nodes: []*corev1.Node{
+			newNodeInZone("node-0", "v0", "v1", "a"),
+			newNodeInZone("node-1", "v0", "v0", "a"),
+			newNodeInZone("node-2", "v0", "v0", "b"),
+			newNode("node-3", "v0", "v0"),
+		},
+		expected: []string{"node-2", "node-3"},
+	}, {
+		perZone: int32Ptr(2),
+		nodes: []*corev1.Node{
+			newNodeInZone("node-0", "v0", "v0", "a"),
+			newNodeInZone("node-1", "v0", "v0", "a"),
+			newNodeInZone("node-2", "v0", "v0", "a"),
+		},
+		expected: []string{"node-0", "node-1"},
+	}, {
+		perZone: int32Ptr(0),
+		nodes: []*corev1.Node{
+			newNodeInZone("node-0", "v0", "v0", "a"),
+			newNodeInZone("node-1", "v0", "v0", "a"),
+			newNodeInZone("node-2", "v0", "v0", "a"),
+		},
+		expected: []string{"node-0", "node-1", "node-2"},
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := &mcfgv1.MachineConfigPool{
+				Spec: mcfgv1.MachineConfigPoolSpec{
+					Configuration:  mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+					UpdateStrategy: &mcfgv1.MachineConfigPoolUpdateStrategy{MaxUnavailablePerZone: test.perZone},
+				},
+			}
+
+			got := getCandidateMachines(pool, test.nodes, 4)
+			var nodeNames []string
+			for _, node := range got {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			assert.Equal(t, test.expected, nodeNames)
+		})
+	}
+}
+
+func newNodeWithPriority(name, currentConfig, desiredConfig string, annotation, label string) *corev1.Node {
+	node := newNodeWithReady(name, currentConfig, desiredConfig, corev1.ConditionTrue)
+	if annotation != "" {
+		node.Annotations[daemonconsts.UpdatePriorityAnnotationKey] = annotation
+	}
+	if label != "" {
+		node.Labels = map[string]string{daemonconsts.UpdatePriorityAnnotationKey: label}
+	}
+	return node
+}
+
+func TestGetCandidateMachinesPriority(t *testing.T) {
+	tests := []struct {
+		nodes    []*corev1.Node
+		expected []string
+	}{{
+		// same priority, pool order
+		nodes: []*corev1.Node{
+			newNodeWithPriority("node-0", "v0", "v0", "", ""),
+			newNodeWithPriority("node-1", "v0", "v0", "", ""),
+			newNodeWithPriority("node-2", "v0", "v0", "", ""),
+		},
+		expected: []string{"node-0", "node-1"},
+	}, {
+		nodes: []*corev1.Node{
+			newNodeWithPriority("node-0", "v0", "v0", "", ""),
+			newNodeWithPriority("node-1", "v0", "v0", "-1", ""),
+			newNodeWithPriority("node-2", "v0", "v0", "10", ""),
+			newNodeWithPriority("node-3", "v0", "v0", "", "5"),
+		},
+		expected: []string{"node-2", "node-3"},
+	}, {
+		// the annotation takes precedence over the label, invalid priorities count as 0
+		nodes: []*corev1.Node{
+			newNodeWithPriority("node-0", "v0", "v0", "-5", "5"),
+			newNodeWithPriority("node-1", "v0", "v0", "high", ""),
+			newNodeWithPriority("node-2", "v0", "v0", "", "1"),
+		},
+		expected: []string{"node-2", "node-1"},
+	}, {
+		// nodes already at the target config don't count
+		nodes: []*corev1.Node{
+			newNodeWithPriority("node-0", "v1", "v1", "10", ""),
+			newNodeWithPriority("node-1", "v0", "v0", "", ""),
+			newNodeWithPriority("node-2", "v0", "v0", "1", ""),
+		},
+		expected: []string{"node-2", "node-1"},
+	}}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			pool := &mcfgv1.MachineConfigPool{
+				Spec: mcfgv1.MachineConfigPoolSpec{
+					Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v1"}},
+				},
+			}
+
+			got := getCandidateMachines(pool, test.nodes, 2)
+			var nodeNames []string
+			for _, node := range got {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			assert.Equal(t, test.expected, nodeNames)
+		})
+	}
+}
+
 func assertPatchesNode0ToV1(t *testing.T, actions []core.Action) {
 	if !assert.Equal(t, 2, len(actions)) {
 		t.Fatal("actions")
@@ -618,6 +796,7 @@ func TestSetDesiredMachineConfigAnnotation(t *testing.T) {
 	tests := []struct {
 		node       *corev1.Node
 		extraannos map[string]string
+		staged     bool
 
 		verify func([]core.Action, *testing.T)
 	}{{
@@ -676,6 +855,19 @@ func TestSetDesiredMachineConfigAnnotation(t *testing.T) {
 				t.Fatal(actions)
 			}
 		},
+	}, {
+		// the node is already targeting v1 but now has to stage it
+		node:   newNode("node-0", "v0", "v1"),
+		staged: true,
+		verify: func(actions []core.Action, t *testing.T) {
+			if !assert.Equal(t, 2, len(actions)) {
+				return
+			}
+			if !actions[1].Matches("patch", "nodes") {
+				t.Fatal(actions)
+			}
+			assert.Contains(t, string(actions[1].(core.PatchAction).GetPatch()), daemonconsts.StagedUpdateAnnotationKey)
+		},
 	}}
 
 	for idx, test := range tests {
@@ -694,7 +886,7 @@ func TestSetDesiredMachineConfigAnnotation(t *testing.T) {
 
 			c := f.newController()
 
-			err := c.setDesiredMachineConfigAnnotation(test.node.Name, "v1")
+			err := c.setDesiredMachineConfigAnnotation(test.node.Name, "v1", test.staged, nil)
 			if !assert.Nil(t, err) {
 				return
 			}
@@ -871,5 +1063,39 @@ func filterLastTransitionTime(obj runtime.Object) runtime.Object {
 	for idx := range o.Status.Conditions {
 		o.Status.Conditions[idx].LastTransitionTime = metav1.Time{}
 	}
+	for idx := range o.Status.History {
+		o.Status.History[idx].Timestamp = metav1.Time{}
+	}
 	return o
 }
+
+func TestConflictingNodesDegradePool(t *testing.T) {
+	f := newFixture(t)
+	worker := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v0")
+	infra := helpers.NewMachineConfigPool("infra", nil, helpers.InfraSelector, "v0")
+	other := helpers.NewMachineConfigPool("other", nil, metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/other", ""), "v0")
+	node := newNode("node-0", "v0", "v0")
+	node.Labels = map[string]string{"node-role/worker": "", "node-role/infra": "", "node-role/other": ""}
+
+	f.nodeLister = append(f.nodeLister, node)
+	f.kubeobjects = append(f.kubeobjects, node)
+	for _, pool := range []*mcfgv1.MachineConfigPool{worker, infra, other} {
+		f.mcpLister = append(f.mcpLister, pool)
+		f.objects = append(f.objects, pool)
+	}
+	c := f.newController()
+
+	conflicts, err := c.getConflictingNodes(infra)
+	assert.Nil(t, err)
+	assert.Len(t, conflicts, 1)
+
+	status := calculateStatus(infra, nil)
+	setConflictingNodesDegraded(&status, conflicts)
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolNodeDegraded))
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolDegraded))
+
+	node.Labels = map[string]string{"node-role/worker": "", "node-role/infra": ""}
+	conflicts, err = c.getConflictingNodes(infra)
+	assert.Nil(t, err)
+	assert.Empty(t, conflicts)
+}
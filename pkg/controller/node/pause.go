@@ -0,0 +1,73 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// pausedTooLongThreshold is how long a paused pool can hold back a newer
+	// rendered MachineConfig before it is reported as PausedTooLong. Rotated
+	// certificates, e.g. the kubelet CA, are delivered through rendered configs.
+	pausedTooLongThreshold = 24 * time.Hour
+
+	reasonPausedTooLong = "PausedTooLong"
+)
+
+// syncPause resumes the pool once spec.pausedUntil is reached. For pools which
+// are still paused, it makes sure the pool is synced again when the pause
+// expires or when it starts blocking updates for too long.
+func (ctrl *Controller) syncPause(pool *mcfgv1.MachineConfigPool) (*mcfgv1.MachineConfigPool, error) {
+	if !pool.Spec.Paused {
+		return pool, nil
+	}
+
+	if pool.Spec.PausedUntil != nil {
+		if remaining := time.Until(pool.Spec.PausedUntil.Time); remaining > 0 {
+			ctrl.enqueueAfter(pool, remaining)
+		} else {
+			glog.Infof("Pool %s: pause expired at %s, resuming", pool.Name, pool.Spec.PausedUntil)
+			newPool := pool.DeepCopy()
+			newPool.Spec.Paused = false
+			newPool.Spec.PausedUntil = nil
+			newPool, err := ctrl.client.MachineconfigurationV1().MachineConfigPools().Update(context.TODO(), newPool, metav1.UpdateOptions{})
+			if err != nil {
+				return pool, err
+			}
+			ctrl.eventRecorder.Eventf(newPool, corev1.EventTypeNormal, "PauseExpired", "Resuming the pool, paused until %s", pool.Spec.PausedUntil)
+			return newPool, nil
+		}
+	}
+
+	cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolPausedWithPendingUpdates)
+	if cond != nil && cond.Status == corev1.ConditionTrue && cond.Reason != reasonPausedTooLong {
+		ctrl.enqueueAfter(pool, pausedTooLongThreshold-time.Since(cond.LastTransitionTime.Time))
+	}
+	return pool, nil
+}
+
+// setPausedCondition reports whether the pool is paused while a newer rendered
+// MachineConfig is pending, and whether that has lasted for too long.
+func setPausedCondition(status *mcfgv1.MachineConfigPoolStatus, pool *mcfgv1.MachineConfigPool, now time.Time) {
+	if !pool.Spec.Paused || status.Configuration.Name == pool.Spec.Configuration.Name {
+		cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolPausedWithPendingUpdates, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+		return
+	}
+
+	cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolPausedWithPendingUpdates, corev1.ConditionTrue, "Paused",
+		fmt.Sprintf("Pool is paused, %s is not rolled out", pool.Spec.Configuration.Name))
+	if current := mcfgv1.GetMachineConfigPoolCondition(*status, mcfgv1.MachineConfigPoolPausedWithPendingUpdates); current != nil && current.Status == corev1.ConditionTrue {
+		if since := now.Sub(current.LastTransitionTime.Time); since >= pausedTooLongThreshold {
+			cond.Reason = reasonPausedTooLong
+			cond.Message = fmt.Sprintf("Pool has been paused for %s with pending updates, %s is not rolled out. Pending updates may include certificate rotations required to keep nodes working", since.Round(time.Minute), pool.Spec.Configuration.Name)
+		}
+	}
+	mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+}
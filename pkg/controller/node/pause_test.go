@@ -0,0 +1,60 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPausedCondition(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.Paused = true
+	status := mcfgv1.MachineConfigPoolStatus{Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "v0"}}}
+
+	now := time.Now()
+	setPausedCondition(&status, pool, now)
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolPausedWithPendingUpdates)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Paused", cond.Reason)
+
+	setPausedCondition(&status, pool, now.Add(pausedTooLongThreshold+time.Minute))
+	cond = mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolPausedWithPendingUpdates)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonPausedTooLong, cond.Reason)
+
+	// nothing pending
+	status.Configuration.Name = "v1"
+	setPausedCondition(&status, pool, now)
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionFalse(status.Conditions, mcfgv1.MachineConfigPoolPausedWithPendingUpdates))
+}
+
+func TestSyncPauseExpired(t *testing.T) {
+	f := newFixture(t)
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	pool.Spec.Paused = true
+	until := metav1.NewTime(time.Now().Add(-time.Minute))
+	pool.Spec.PausedUntil = &until
+	f.mcpLister = append(f.mcpLister, pool)
+	f.objects = append(f.objects, pool)
+
+	c := f.newController()
+	newPool, err := c.syncPause(pool)
+	require.Nil(t, err)
+	assert.False(t, newPool.Spec.Paused)
+	assert.Nil(t, newPool.Spec.PausedUntil)
+
+	// not expired yet
+	until = metav1.NewTime(time.Now().Add(time.Hour))
+	pool.Spec.PausedUntil = &until
+	newPool, err = c.syncPause(pool)
+	require.Nil(t, err)
+	assert.True(t, newPool.Spec.Paused)
+}
@@ -0,0 +1,130 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// podNodeNameIndex indexes the pods by the name of their node.
+	podNodeNameIndex = "nodeName"
+
+	// pdbRecheckInterval is how often a pool whose candidates are all blocked by
+	// PodDisruptionBudgets is synced again.
+	pdbRecheckInterval = time.Minute
+
+	// mirrorPodAnnotationKey is set on the mirror pods of static pods, which
+	// aren't evicted by the drain.
+	mirrorPodAnnotationKey = "kubernetes.io/config.mirror"
+)
+
+func indexPodByNodeName(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+// isEvictedOnDrain returns whether draining its node evicts the pod. Like the
+// drain of the daemon, it skips the pods of DaemonSets, static pods, and the
+// pods which are already gone.
+func isEvictedOnDrain(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[mirrorPodAnnotationKey]; ok {
+		return false
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
+
+// getDrainBlockers returns the PodDisruptionBudgets, as namespace/name, which
+// currently don't allow evicting a pod of the node.
+func (ctrl *Controller) getDrainBlockers(node *corev1.Node) ([]string, error) {
+	objs, err := ctrl.podIndexer.ByIndex(podNodeNameIndex, node.Name)
+	if err != nil {
+		return nil, err
+	}
+	blockers := map[string]bool{}
+	for _, obj := range objs {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || !isEvictedOnDrain(pod) {
+			continue
+		}
+		pdbs, err := ctrl.pdbLister.PodDisruptionBudgets(pod.Namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, pdb := range pdbs {
+			if pdb.Status.DisruptionsAllowed > 0 {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			// as for evictions, an empty selector matches no pod
+			if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			blockers[pdb.Namespace+"/"+pdb.Name] = true
+		}
+	}
+	var out []string
+	for blocker := range blockers {
+		out = append(out, blocker)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// filterDrainableCandidates returns the candidates whose drain isn't blocked by
+// a PodDisruptionBudget, in the same order. The others are held back until their
+// PodDisruptionBudgets allow it, rather than failing to drain. When all the
+// candidates are blocked, it returns the PodDisruptionBudgets blocking them.
+func (ctrl *Controller) filterDrainableCandidates(candidates []*corev1.Node) ([]*corev1.Node, []string, error) {
+	var drainable []*corev1.Node
+	blockers := map[string]bool{}
+	for _, node := range candidates {
+		nodeBlockers, err := ctrl.getDrainBlockers(node)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(nodeBlockers) == 0 {
+			drainable = append(drainable, node)
+			continue
+		}
+		for _, blocker := range nodeBlockers {
+			blockers[blocker] = true
+		}
+	}
+	if len(drainable) > 0 || len(blockers) == 0 {
+		return drainable, nil, nil
+	}
+	var out []string
+	for blocker := range blockers {
+		out = append(out, blocker)
+	}
+	sort.Strings(out)
+	return nil, out, nil
+}
+
+// setPodDisruptionBlocked reports the PodDisruptionBudgets blocking the drain of
+// all the candidates of the pool.
+func setPodDisruptionBlocked(status *mcfgv1.MachineConfigPoolStatus, blockers []string) {
+	if len(blockers) == 0 {
+		mcfgv1.RemoveMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolPodDisruptionBlocked)
+		return
+	}
+	cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolPodDisruptionBlocked, corev1.ConditionTrue, "AllCandidatesBlocked",
+		fmt.Sprintf("All candidates blocked by PodDisruptionBudget %s", strings.Join(blockers, ", ")))
+	mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	policylisterv1beta1 "k8s.io/client-go/listers/policy/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func newPodOnNode(name, node string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app", Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func newPDB(name string, selector map[string]string, allowed int32) *policyv1beta1.PodDisruptionBudget {
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app"},
+		Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+	}
+}
+
+func newPDBController(t *testing.T, pods []*corev1.Pod, pdbs []*policyv1beta1.PodDisruptionBudget) *Controller {
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{podNodeNameIndex: indexPodByNodeName})
+	for _, pod := range pods {
+		require.Nil(t, podIndexer.Add(pod))
+	}
+	pdbIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pdb := range pdbs {
+		require.Nil(t, pdbIndexer.Add(pdb))
+	}
+	return &Controller{podIndexer: podIndexer, pdbLister: policylisterv1beta1.NewPodDisruptionBudgetLister(pdbIndexer)}
+}
+
+func TestFilterDrainableCandidates(t *testing.T) {
+	daemonSetPod := newPodOnNode("ds", "node-1", map[string]string{"app": "db"})
+	daemonSetPod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: func(b bool) *bool { return &b }(true)}}
+	donePod := newPodOnNode("done", "node-1", map[string]string{"app": "db"})
+	donePod.Status.Phase = corev1.PodSucceeded
+
+	pods := []*corev1.Pod{
+		newPodOnNode("db-0", "node-0", map[string]string{"app": "db"}),
+		newPodOnNode("web-0", "node-0", map[string]string{"app": "web"}),
+		newPodOnNode("web-1", "node-1", map[string]string{"app": "web"}),
+		daemonSetPod,
+		donePod,
+		newPodOnNode("cache-0", "node-2", map[string]string{"app": "cache"}),
+	}
+	nodes := []*corev1.Node{newNode("node-0", "v0", "v0"), newNode("node-1", "v0", "v0"), newNode("node-2", "v0", "v0")}
+
+	// node-0 is blocked by db, node-2 by cache
+	ctrl := newPDBController(t, pods, []*policyv1beta1.PodDisruptionBudget{
+		newPDB("db", map[string]string{"app": "db"}, 0),
+		newPDB("web", map[string]string{"app": "web"}, 1),
+		newPDB("cache", map[string]string{"app": "cache"}, 0),
+		newPDB("all", map[string]string{}, 0),
+	})
+	drainable, blockers, err := ctrl.filterDrainableCandidates(nodes)
+	require.Nil(t, err)
+	require.Len(t, drainable, 1)
+	assert.Equal(t, "node-1", drainable[0].Name)
+	assert.Empty(t, blockers)
+
+	drainable, blockers, err = ctrl.filterDrainableCandidates([]*corev1.Node{nodes[2], nodes[0]})
+	require.Nil(t, err)
+	assert.Empty(t, drainable)
+	assert.Equal(t, []string{"app/cache", "app/db"}, blockers)
+
+	drainable, blockers, err = ctrl.filterDrainableCandidates(nil)
+	require.Nil(t, err)
+	assert.Empty(t, drainable)
+	assert.Empty(t, blockers)
+}
+
+func TestSetPodDisruptionBlocked(t *testing.T) {
+	status := mcfgv1.MachineConfigPoolStatus{}
+	setPodDisruptionBlocked(&status, []string{"app/cache", "app/db"})
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolPodDisruptionBlocked)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "All candidates blocked by PodDisruptionBudget app/cache, app/db", cond.Message)
+
+	setPodDisruptionBlocked(&status, nil)
+	assert.Nil(t, mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolPodDisruptionBlocked))
+}
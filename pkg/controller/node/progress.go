@@ -0,0 +1,97 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// blockingPhases are the phases of the machines which can block a rollout, the
+// ones most likely to be the cause first.
+var blockingPhases = []mcfgv1.MachineConfigPoolNodePhase{
+	mcfgv1.NodePhaseDegraded,
+	mcfgv1.NodePhaseDraining,
+	mcfgv1.NodePhaseRebooting,
+	mcfgv1.NodePhaseCordoned,
+	mcfgv1.NodePhaseUpdating,
+	mcfgv1.NodePhasePending,
+}
+
+// isRolloutHeld returns whether the machines of the pool are held back from
+// updating on purpose, which doesn't count against the progress deadline.
+func isRolloutHeld(pool *mcfgv1.MachineConfigPool, now time.Time) bool {
+	open, _ := inMaintenanceWindow(pool, now)
+	return pool.Spec.Paused || !open || isStagedPool(pool)
+}
+
+// getBlockingNode returns the machine most likely blocking the rollout.
+func getBlockingNode(nodes []mcfgv1.MachineConfigPoolNodeStatus) *mcfgv1.MachineConfigPoolNodeStatus {
+	for _, phase := range blockingPhases {
+		for i := range nodes {
+			if nodes[i].Phase == phase {
+				return &nodes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// setRolloutStalled records when the rollout of the pool last progressed, and
+// reports it as stalled once that's longer ago than its progress deadline.
+// updating is whether some machines of the pool are still to be updated.
+func setRolloutStalled(status *mcfgv1.MachineConfigPoolStatus, pool *mcfgv1.MachineConfigPool, updating bool, now time.Time) {
+	deadline := getProgressDeadline(pool)
+	if deadline == 0 || !updating || isRolloutHeld(pool, now) {
+		status.LastProgressTime = nil
+	} else if status.LastProgressTime == nil || status.UpdatedMachineCount != pool.Status.UpdatedMachineCount {
+		status.LastProgressTime = &metav1.Time{Time: now}
+	}
+
+	if status.LastProgressTime == nil || now.Sub(status.LastProgressTime.Time) < deadline {
+		cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutStalled, corev1.ConditionFalse, "", "")
+		mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+		return
+	}
+
+	message := fmt.Sprintf("No node finished updating to %s for %v", pool.Spec.Configuration.Name, now.Sub(status.LastProgressTime.Time).Round(time.Minute))
+	reason := "NoProgress"
+	if node := getBlockingNode(status.Nodes); node != nil {
+		reason = "Node" + string(node.Phase)
+		message += fmt.Sprintf(", node %s is %s", node.Name, node.Phase)
+		if node.Reason != "" {
+			message += ": " + node.Reason
+		}
+	}
+	cond := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolRolloutStalled, corev1.ConditionTrue, reason, message)
+	mcfgv1.SetMachineConfigPoolCondition(status, *cond)
+}
+
+// getProgressDeadline returns the progress deadline of the pool, 0 if it has none.
+func getProgressDeadline(pool *mcfgv1.MachineConfigPool) time.Duration {
+	if pool.Spec.ProgressDeadlineMinutes == nil || *pool.Spec.ProgressDeadlineMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(*pool.Spec.ProgressDeadlineMinutes) * time.Minute
+}
+
+// syncRolloutStalled makes sure the pool is synced again when the progress
+// deadline of its rollout passes, and reports whether it's stalled.
+func (ctrl *Controller) syncRolloutStalled(pool *mcfgv1.MachineConfigPool, status mcfgv1.MachineConfigPoolStatus) {
+	stalled := mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled)
+	if stalled {
+		ctrlcommon.MCCPoolRolloutStalled.WithLabelValues(pool.Name).Set(1)
+		if !mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled) {
+			cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolRolloutStalled)
+			ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "RolloutStalled", cond.Message)
+		}
+		return
+	}
+	ctrlcommon.MCCPoolRolloutStalled.WithLabelValues(pool.Name).Set(0)
+	if status.LastProgressTime != nil {
+		ctrl.enqueueAfter(pool, getProgressDeadline(pool)-time.Since(status.LastProgressTime.Time))
+	}
+}
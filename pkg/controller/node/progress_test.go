@@ -0,0 +1,65 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRolloutStalled(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	deadline := int32(30)
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v2")
+	newStatus := func(updated int32) mcfgv1.MachineConfigPoolStatus {
+		status := pool.Status
+		status.UpdatedMachineCount = updated
+		status.Nodes = []mcfgv1.MachineConfigPoolNodeStatus{
+			{Name: "node-0", Phase: mcfgv1.NodePhaseDone},
+			{Name: "node-1", Phase: mcfgv1.NodePhasePending},
+			{Name: "node-2", Phase: mcfgv1.NodePhaseDraining},
+		}
+		return status
+	}
+
+	// nothing is tracked without a deadline
+	status := newStatus(1)
+	setRolloutStalled(&status, pool, true, now)
+	assert.Nil(t, status.LastProgressTime)
+	assert.False(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled))
+
+	// the rollout starting is progress
+	pool.Spec.ProgressDeadlineMinutes = &deadline
+	setRolloutStalled(&status, pool, true, now)
+	require.NotNil(t, status.LastProgressTime)
+	assert.Equal(t, now, status.LastProgressTime.Time)
+	pool.Status = status
+
+	// stalled once the deadline passes without a node finishing
+	status = newStatus(1)
+	setRolloutStalled(&status, pool, true, now.Add(45*time.Minute))
+	cond := mcfgv1.GetMachineConfigPoolCondition(status, mcfgv1.MachineConfigPoolRolloutStalled)
+	require.NotNil(t, cond)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, "NodeDraining", cond.Reason)
+	assert.Equal(t, "No node finished updating to v2 for 45m0s, node node-2 is Draining", cond.Message)
+	pool.Status = status
+
+	// a node finishing restarts the clock
+	status = newStatus(2)
+	setRolloutStalled(&status, pool, true, now.Add(50*time.Minute))
+	assert.Equal(t, now.Add(50*time.Minute), status.LastProgressTime.Time)
+	assert.False(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled))
+
+	// a paused pool isn't stalled
+	pool.Spec.Paused = true
+	status = newStatus(2)
+	setRolloutStalled(&status, pool, true, now.Add(2*time.Hour))
+	assert.Nil(t, status.LastProgressTime)
+	assert.False(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled))
+}
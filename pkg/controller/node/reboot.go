@@ -0,0 +1,89 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxRebootGracePeriod bounds how long the machines wait before rebooting,
+// the node is unavailable and drained in the meantime.
+const maxRebootGracePeriod = time.Hour
+
+// rebootUnitRegex matches the name of a systemd service or target.
+var rebootUnitRegex = regexp.MustCompile(`^[a-zA-Z0-9:_.\\@-]+\.(service|target)$`)
+
+// validateRebootStrategy returns an error if the reboot strategy can't be
+// applied by the daemon.
+func validateRebootStrategy(strategy *mcfgv1.RebootStrategy) error {
+	switch strategy.Type {
+	case "", mcfgv1.RebootStrategyReboot, mcfgv1.RebootStrategyKexec:
+		if strategy.Unit != "" {
+			return fmt.Errorf("unit is only valid with the %s type", mcfgv1.RebootStrategyUnit)
+		}
+	case mcfgv1.RebootStrategyUnit:
+		if !rebootUnitRegex.MatchString(strategy.Unit) {
+			return fmt.Errorf("unit %q is not the name of a systemd service or target", strategy.Unit)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", strategy.Type)
+	}
+	if strategy.GracePeriod != nil && (strategy.GracePeriod.Duration < 0 || strategy.GracePeriod.Duration > maxRebootGracePeriod) {
+		return fmt.Errorf("gracePeriod %v is not between 0 and %v", strategy.GracePeriod.Duration, maxRebootGracePeriod)
+	}
+	return nil
+}
+
+// getRebootStrategy returns the JSON encoded reboot strategy of the pool, empty
+// when it has none or an invalid one, and why it's invalid.
+func getRebootStrategy(pool *mcfgv1.MachineConfigPool) (string, string, error) {
+	strategy := pool.Spec.RebootStrategy
+	if strategy == nil {
+		return "", "", nil
+	}
+	if err := validateRebootStrategy(strategy); err != nil {
+		return "", err.Error(), nil
+	}
+	data, err := json.Marshal(strategy)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), "", nil
+}
+
+// syncRebootStrategy hands the reboot strategy of the pool to the daemons of
+// its nodes. Nodes of pools with an invalid one reboot the default way.
+func (ctrl *Controller) syncRebootStrategy(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	strategy, invalid, err := getRebootStrategy(pool)
+	if err != nil {
+		return err
+	}
+	if invalid != "" {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidRebootStrategy", "Ignoring rebootStrategy: %s", invalid)
+	}
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.RebootStrategyAnnotationKey] == strategy {
+			continue
+		}
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			if strategy != "" {
+				node.Annotations[daemonconsts.RebootStrategyAnnotationKey] = strategy
+			} else {
+				delete(node.Annotations, daemonconsts.RebootStrategyAnnotationKey)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
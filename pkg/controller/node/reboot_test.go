@@ -0,0 +1,55 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestValidateRebootStrategy(t *testing.T) {
+	for _, strategy := range []mcfgv1.RebootStrategy{
+		{},
+		{Type: mcfgv1.RebootStrategyReboot, GracePeriod: &metav1.Duration{Duration: time.Minute}},
+		{Type: mcfgv1.RebootStrategyKexec},
+		{Type: mcfgv1.RebootStrategyUnit, Unit: "bmc-power-cycle.service"},
+		{Type: mcfgv1.RebootStrategyUnit, Unit: "power-cycle@bmc.target"},
+	} {
+		assert.Nil(t, validateRebootStrategy(&strategy), "%+v", strategy)
+	}
+	for _, strategy := range []mcfgv1.RebootStrategy{
+		{Type: "PowerCycle"},
+		{Type: mcfgv1.RebootStrategyKexec, Unit: "bmc-power-cycle.service"},
+		{Type: mcfgv1.RebootStrategyUnit},
+		{Type: mcfgv1.RebootStrategyUnit, Unit: "bmc-power-cycle"},
+		{Type: mcfgv1.RebootStrategyUnit, Unit: "x.service; reboot -f"},
+		{GracePeriod: &metav1.Duration{Duration: -time.Second}},
+		{GracePeriod: &metav1.Duration{Duration: 2 * time.Hour}},
+	} {
+		assert.NotNil(t, validateRebootStrategy(&strategy), "%+v", strategy)
+	}
+}
+
+func TestGetRebootStrategy(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	strategy, invalid, err := getRebootStrategy(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, "", strategy)
+	assert.Equal(t, "", invalid)
+
+	pool.Spec.RebootStrategy = &mcfgv1.RebootStrategy{Type: mcfgv1.RebootStrategyKexec, GracePeriod: &metav1.Duration{Duration: 30 * time.Second}}
+	strategy, invalid, err = getRebootStrategy(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"type":"Kexec","gracePeriod":"30s"}`, strategy)
+	assert.Equal(t, "", invalid)
+
+	pool.Spec.RebootStrategy = &mcfgv1.RebootStrategy{Type: mcfgv1.RebootStrategyUnit}
+	strategy, invalid, err = getRebootStrategy(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, "", strategy)
+	assert.Equal(t, `unit "" is not the name of a systemd service or target`, invalid)
+}
@@ -0,0 +1,139 @@
+package node
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientretry "k8s.io/client-go/util/retry"
+)
+
+// nodeKind is the schema.GroupVersionKind of the owner of the NodeMachineConfigStates.
+var nodeKind = corev1.SchemeGroupVersion.WithKind("Node")
+
+// newNodeState returns the NodeMachineConfigState of node, owned by it so that
+// it's garbage collected along with it.
+func newNodeState(node *corev1.Node, desiredConfig string) *mcfgv1.NodeMachineConfigState {
+	return &mcfgv1.NodeMachineConfigState{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      node.Name,
+			Namespace: ctrlcommon.MCONamespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: nodeKind.GroupVersion().String(),
+				Kind:       nodeKind.Kind,
+				Name:       node.Name,
+				UID:        node.UID,
+			}},
+		},
+		Spec: mcfgv1.NodeMachineConfigStateSpec{
+			Node:          node.Name,
+			DesiredConfig: desiredConfig,
+		},
+	}
+}
+
+// syncNodeStates creates the NodeMachineConfigStates the nodes don't have yet,
+// with the desired config of their annotation.
+func (ctrl *Controller) syncNodeStates(nodes []*corev1.Node) error {
+	for _, node := range nodes {
+		_, err := ctrl.nodeStateLister.NodeMachineConfigStates(ctrlcommon.MCONamespace).Get(node.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		state := newNodeState(node, node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
+		_, err = ctrl.client.MachineconfigurationV1().NodeMachineConfigStates(ctrlcommon.MCONamespace).Create(context.TODO(), state, metav1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// setNodeStateDesiredConfig sets the desired config of the NodeMachineConfigState
+// of the node, before the one of its annotation so the daemon never reports
+// progress on a config the state doesn't know about.
+func (ctrl *Controller) setNodeStateDesiredConfig(nodeName, desiredConfig string) error {
+	return clientretry.RetryOnConflict(nodeUpdateBackoff, func() error {
+		states := ctrl.client.MachineconfigurationV1().NodeMachineConfigStates(ctrlcommon.MCONamespace)
+		state, err := states.Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			node, err := ctrl.nodeLister.Get(nodeName)
+			if err != nil {
+				return err
+			}
+			_, err = states.Create(context.TODO(), newNodeState(node, desiredConfig), metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if state.Spec.DesiredConfig == desiredConfig {
+			return nil
+		}
+		state.Spec.DesiredConfig = desiredConfig
+		_, err = states.Update(context.TODO(), state, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// applyNodeState returns the node with the annotations of the daemon replaced by
+// the NodeMachineConfigState of the node, which is the source of truth. Nodes
+// whose daemon didn't report a state yet, e.g. older daemons during an upgrade,
+// are returned as is.
+func applyNodeState(node *corev1.Node, state *mcfgv1.NodeMachineConfigState) *corev1.Node {
+	if state == nil || state.Status.State == "" {
+		return node
+	}
+	node = node.DeepCopy()
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	if state.Spec.DesiredConfig != "" {
+		node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] = state.Spec.DesiredConfig
+	}
+	node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] = state.Status.CurrentConfig
+	node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey] = state.Status.State
+	node.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] = state.Status.Phase
+	node.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey] = state.Status.LastError
+	return node
+}
+
+// applyNodeStates applies the NodeMachineConfigStates of the nodes to them.
+func (ctrl *Controller) applyNodeStates(nodes []*corev1.Node) []*corev1.Node {
+	out := make([]*corev1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		state, err := ctrl.nodeStateLister.NodeMachineConfigStates(ctrlcommon.MCONamespace).Get(node.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			glog.Warningf("Failed to get the NodeMachineConfigState of node %s: %v", node.Name, err)
+		}
+		out = append(out, applyNodeState(node, state))
+	}
+	return out
+}
+
+func (ctrl *Controller) updateNodeState(old, cur interface{}) {
+	oldState := old.(*mcfgv1.NodeMachineConfigState)
+	curState := cur.(*mcfgv1.NodeMachineConfigState)
+	if reflect.DeepEqual(oldState.Status, curState.Status) {
+		return
+	}
+	node, err := ctrl.nodeLister.Get(curState.Spec.Node)
+	if err != nil {
+		return
+	}
+	pool, err := ctrl.getPrimaryPoolForNode(node)
+	if err != nil || pool == nil {
+		return
+	}
+	glog.V(4).Infof("NodeMachineConfigState %s updated", curState.Name)
+	ctrl.enqueueMachineConfigPool(pool)
+}
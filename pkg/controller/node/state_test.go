@@ -0,0 +1,37 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestApplyNodeState(t *testing.T) {
+	node := newNode("node-0", "v0", "v1")
+
+	// no state yet, or not reported by the daemon
+	assert.Equal(t, node, applyNodeState(node, nil))
+	state := &mcfgv1.NodeMachineConfigState{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+		Spec:       mcfgv1.NodeMachineConfigStateSpec{Node: "node-0", DesiredConfig: "v1"},
+	}
+	assert.Equal(t, node, applyNodeState(node, state))
+
+	state.Status = mcfgv1.NodeMachineConfigStateStatus{
+		CurrentConfig: "v0",
+		DesiredConfig: "v1",
+		State:         daemonconsts.MachineConfigDaemonStateDegraded,
+		LastError:     "boom",
+	}
+	got := applyNodeState(node, state)
+	assert.Equal(t, "v0", got.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey])
+	assert.Equal(t, "v1", got.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey])
+	assert.Equal(t, daemonconsts.MachineConfigDaemonStateDegraded, got.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey])
+	assert.Equal(t, "boom", got.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey])
+	// the node of the cache is left as is
+	assert.Equal(t, daemonconsts.MachineConfigDaemonStateWorking, node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey])
+}
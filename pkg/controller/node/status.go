@@ -3,40 +3,91 @@ package node
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// rolloutBlockers are why no node of a pool could start updating in the last sync.
+type rolloutBlockers struct {
+	// pdbs are the PodDisruptionBudgets blocking the drain of all the candidates.
+	pdbs []string
+	// etcdQuorumRisk is why updating a master would risk the etcd quorum.
+	etcdQuorumRisk string
+}
+
 func (ctrl *Controller) syncStatusOnly(pool *mcfgv1.MachineConfigPool) error {
+	return ctrl.syncStatus(pool, rolloutBlockers{})
+}
+
+// syncStatus updates the status of the pool, reporting the blockers of its rollout.
+func (ctrl *Controller) syncStatus(pool *mcfgv1.MachineConfigPool, blockers rolloutBlockers) error {
 	nodes, err := ctrl.getNodesForPool(pool)
 	if err != nil {
 		return err
 	}
 
 	newStatus := calculateStatus(pool, nodes)
+	setPoolMachinesMetrics(pool.Name, newStatus)
+	conflicts, err := ctrl.getConflictingNodes(pool)
+	if err != nil {
+		return err
+	}
+	setConflictingNodesDegraded(&newStatus, conflicts)
+	setPodDisruptionBlocked(&newStatus, blockers.pdbs)
+	setEtcdQuorumAtRisk(&newStatus, blockers.etcdQuorumRisk)
+	ctrl.syncRolloutStalled(pool, newStatus)
+	if limit := ctrl.getHistoryLimit(); len(newStatus.History) > limit {
+		newStatus.History = newStatus.History[:limit]
+	}
 	if equality.Semantic.DeepEqual(pool.Status, newStatus) {
 		return nil
 	}
 
+	newCond := mcfgv1.GetMachineConfigPoolCondition(newStatus, mcfgv1.MachineConfigPoolPausedWithPendingUpdates)
+	oldCond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolPausedWithPendingUpdates)
+	if newCond != nil && newCond.Reason == reasonPausedTooLong && (oldCond == nil || oldCond.Reason != reasonPausedTooLong) {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, reasonPausedTooLong, newCond.Message)
+	}
+
 	newPool := pool
 	newPool.Status = newStatus
 	_, err = ctrl.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
 	return err
 }
 
-func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv1.MachineConfigPoolStatus {
-	machineCount := int32(len(nodes))
+// poolMachineStates are the values of the state label of MCCPoolMachines.
+var poolMachineStates = []string{"total", "ready", "updated", "unavailable", "degraded", "unmanaged"}
 
-	updatedMachines := getUpdatedMachines(pool.Spec.Configuration.Name, nodes)
+// setPoolMachinesMetrics reports the machine counts of status.
+func setPoolMachinesMetrics(pool string, status mcfgv1.MachineConfigPoolStatus) {
+	counts := []int32{status.MachineCount, status.ReadyMachineCount, status.UpdatedMachineCount, status.UnavailableMachineCount, status.DegradedMachineCount, status.UnmanagedMachineCount}
+	for i, state := range poolMachineStates {
+		ctrlcommon.MCCPoolMachines.WithLabelValues(pool, state).Set(float64(counts[i]))
+	}
+}
+
+func calculateStatus(pool *mcfgv1.MachineConfigPool, allNodes []*corev1.Node) mcfgv1.MachineConfigPoolStatus {
+	machineCount := int32(len(allNodes))
+	targetConfig := getTargetConfig(pool)
+
+	// the unmanaged nodes are only counted, they are left as is on purpose
+	nodes, unmanagedMachines := splitUnmanagedNodes(allNodes)
+	managedMachineCount := int32(len(nodes))
+	unmanagedMachineCount := int32(len(unmanagedMachines))
+
+	updatedMachines := getUpdatedMachines(targetConfig, nodes)
 	updatedMachineCount := int32(len(updatedMachines))
 
-	readyMachines := getReadyMachines(pool.Spec.Configuration.Name, nodes)
+	readyMachines := getReadyMachines(targetConfig, nodes)
 	readyMachineCount := int32(len(readyMachines))
 
 	unavailableMachines := getUnavailableMachines(nodes)
@@ -59,38 +110,65 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 		ReadyMachineCount:       readyMachineCount,
 		UnavailableMachineCount: unavailableMachineCount,
 		DegradedMachineCount:    degradedMachineCount,
+		UnmanagedMachineCount:   unmanagedMachineCount,
 	}
 
 	status.Configuration = pool.Status.Configuration
+	status.History = pool.Status.History
+	status.LastProgressTime = pool.Status.LastProgressTime
+	status.Canary = calculateCanaryStatus(pool, allNodes, metav1.Now())
+	status.Nodes = getNodePhases(targetConfig, allNodes)
 
 	conditions := pool.Status.Conditions
 	for i := range conditions {
 		status.Conditions = append(status.Conditions, conditions[i])
 	}
 
-	allUpdated := updatedMachineCount == machineCount &&
-		readyMachineCount == machineCount &&
+	allUpdated := updatedMachineCount == managedMachineCount &&
+		readyMachineCount == managedMachineCount &&
 		unavailableMachineCount == 0
 
 	if allUpdated {
 		//TODO: update api to only have one condition regarding status of update.
-		updatedMsg := fmt.Sprintf("All nodes are updated with %s", pool.Spec.Configuration.Name)
+		updatedMsg := fmt.Sprintf("All nodes are updated with %s", targetConfig)
+		if unmanagedMachineCount > 0 {
+			var names []string
+			for _, n := range unmanagedMachines {
+				names = append(names, n.Name)
+			}
+			updatedMsg = fmt.Sprintf("All managed nodes are updated with %s, %d unmanaged nodes are excluded: %s", targetConfig, unmanagedMachineCount, strings.Join(names, ", "))
+		}
 		supdated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, corev1.ConditionTrue, "", updatedMsg)
 		mcfgv1.SetMachineConfigPoolCondition(&status, *supdated)
 
 		supdating := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionFalse, "", "")
 		mcfgv1.SetMachineConfigPoolCondition(&status, *supdating)
-		if status.Configuration.Name != pool.Spec.Configuration.Name {
+		if status.Configuration.Name != targetConfig {
 			glog.Infof("Pool %s: %s", pool.Name, updatedMsg)
-			status.Configuration = pool.Spec.Configuration
+			if targetConfig == pool.Spec.Configuration.Name {
+				status.Configuration = pool.Spec.Configuration
+			} else {
+				// rolled back, the sources of that config aren't known anymore
+				status.Configuration = mcfgv1.MachineConfigPoolStatusConfiguration{}
+				status.Configuration.Name = targetConfig
+			}
+		}
+		if status.Configuration.Name != "" {
+			status.History = updateHistory(status.History, status.Configuration.Name, metav1.Now())
 		}
 	} else {
 		supdated := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdated, corev1.ConditionFalse, "", "")
 		mcfgv1.SetMachineConfigPoolCondition(&status, *supdated)
-		supdating := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionTrue, "", fmt.Sprintf("All nodes are updating to %s", pool.Spec.Configuration.Name))
+		supdating := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolUpdating, corev1.ConditionTrue, "", fmt.Sprintf("All nodes are updating to %s", targetConfig))
 		mcfgv1.SetMachineConfigPoolCondition(&status, *supdating)
 	}
 
+	setPausedCondition(&status, pool, time.Now())
+	setMaintenanceWindowCondition(&status, pool, time.Now())
+	setDrainDegraded(&status, nodes)
+	setFirstbootStalled(&status, nodes, time.Now())
+	setRolloutStalled(&status, pool, !allUpdated, time.Now())
+
 	var nodeDegraded bool
 	if degradedMachineCount > 0 {
 		nodeDegraded = true
@@ -116,6 +194,66 @@ func calculateStatus(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) mcfgv
 	return status
 }
 
+// setConflictingNodesDegraded marks the pool degraded when some of the nodes it
+// selects also belong to other pools and can't be managed.
+func setConflictingNodesDegraded(status *mcfgv1.MachineConfigPoolStatus, conflicts []string) {
+	if len(conflicts) == 0 {
+		return
+	}
+	sdegraded := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolNodeDegraded, corev1.ConditionTrue, fmt.Sprintf("%d nodes match multiple pools", len(conflicts)), strings.Join(conflicts, ", "))
+	mcfgv1.SetMachineConfigPoolCondition(status, *sdegraded)
+	sdegraded = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolDegraded, corev1.ConditionTrue, "", "")
+	mcfgv1.SetMachineConfigPoolCondition(status, *sdegraded)
+}
+
+// getNodePhase returns the step of the rollout to targetConfig the node is in,
+// based on what its daemon reports.
+func getNodePhase(targetConfig string, node *corev1.Node) mcfgv1.MachineConfigPoolNodePhase {
+	if isNodeUnmanaged(node) {
+		return mcfgv1.NodePhaseUnmanaged
+	}
+	state := node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey]
+	if state == daemonconsts.MachineConfigDaemonStateDegraded || state == daemonconsts.MachineConfigDaemonStateUnreconcilable {
+		return mcfgv1.NodePhaseDegraded
+	}
+	if node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey] != targetConfig {
+		return mcfgv1.NodePhasePending
+	}
+	if isNodeDone(node) {
+		return mcfgv1.NodePhaseDone
+	}
+	switch node.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] {
+	case daemonconsts.MachineConfigDaemonPhaseStaged:
+		return mcfgv1.NodePhaseStaged
+	case daemonconsts.MachineConfigDaemonPhaseDraining:
+		return mcfgv1.NodePhaseDraining
+	case daemonconsts.MachineConfigDaemonPhaseRebooting:
+		return mcfgv1.NodePhaseRebooting
+	}
+	if node.Spec.Unschedulable {
+		return mcfgv1.NodePhaseCordoned
+	}
+	return mcfgv1.NodePhaseUpdating
+}
+
+func getNodePhases(targetConfig string, nodes []*corev1.Node) []mcfgv1.MachineConfigPoolNodeStatus {
+	var phases []mcfgv1.MachineConfigPoolNodeStatus
+	for _, node := range nodes {
+		phase := mcfgv1.MachineConfigPoolNodeStatus{
+			Name:          node.Name,
+			Phase:         getNodePhase(targetConfig, node),
+			CurrentConfig: node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey],
+			DesiredConfig: node.Annotations[daemonconsts.DesiredMachineConfigAnnotationKey],
+		}
+		if phase.Phase == mcfgv1.NodePhaseDegraded {
+			phase.Reason = node.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey]
+		}
+		phases = append(phases, phase)
+	}
+	sort.Slice(phases, func(i, j int) bool { return phases[i].Name < phases[j].Name })
+	return phases
+}
+
 // isNodeManaged checks whether the MCD has ever run on a node
 func isNodeManaged(node *corev1.Node) bool {
 	if node.Annotations == nil {
@@ -234,6 +372,10 @@ func isNodeUnavailable(node *corev1.Node) bool {
 	if isNodeDone(node) {
 		return false
 	}
+	// Staged nodes keep running their workloads until they are rebooted
+	if isNodeMCDState(node, daemonconsts.MachineConfigDaemonStateWorking) && node.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] == daemonconsts.MachineConfigDaemonPhaseStaged {
+		return false
+	}
 	// Now we know the node isn't ready - the current config must not
 	// equal target.  We want to further filter down on the MCD state.
 	// If a MCD is in a terminal (failing) state then we can safely retarget it.
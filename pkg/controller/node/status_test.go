@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -306,6 +308,49 @@ func TestGetUnavailableMachines(t *testing.T) {
 	}
 }
 
+func TestGetNodePhases(t *testing.T) {
+	draining := newNodeWithReadyAndDaemonState("node-2", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+	draining.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] = daemonconsts.MachineConfigDaemonPhaseDraining
+	rebooting := newNodeWithReadyAndDaemonState("node-3", "v0", "v1", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateWorking)
+	rebooting.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] = daemonconsts.MachineConfigDaemonPhaseRebooting
+	cordoned := newNodeWithReadyAndDaemonState("node-4", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+	cordoned.Spec.Unschedulable = true
+	staged := newNodeWithReadyAndDaemonState("node-7", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking)
+	staged.Annotations[daemonconsts.MachineConfigDaemonPhaseAnnotationKey] = daemonconsts.MachineConfigDaemonPhaseStaged
+	degraded := newNodeWithReadyAndDaemonState("node-5", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateDegraded)
+	degraded.Annotations[daemonconsts.MachineConfigDaemonReasonAnnotationKey] = "failed to drain"
+
+	nodes := []*corev1.Node{
+		degraded,
+		cordoned,
+		rebooting,
+		draining,
+		newNodeWithReadyAndDaemonState("node-1", "v0", "v1", corev1.ConditionTrue, daemonconsts.MachineConfigDaemonStateWorking),
+		newNodeWithReady("node-0", "v0", "v0", corev1.ConditionTrue),
+		newNodeWithReady("node-6", "v1", "v1", corev1.ConditionTrue),
+		staged,
+	}
+	expected := []mcfgv1.MachineConfigPoolNodeStatus{
+		{Name: "node-0", Phase: mcfgv1.NodePhasePending, CurrentConfig: "v0", DesiredConfig: "v0"},
+		{Name: "node-1", Phase: mcfgv1.NodePhaseUpdating, CurrentConfig: "v0", DesiredConfig: "v1"},
+		{Name: "node-2", Phase: mcfgv1.NodePhaseDraining, CurrentConfig: "v0", DesiredConfig: "v1"},
+		{Name: "node-3", Phase: mcfgv1.NodePhaseRebooting, CurrentConfig: "v0", DesiredConfig: "v1"},
+		{Name: "node-4", Phase: mcfgv1.NodePhaseCordoned, CurrentConfig: "v0", DesiredConfig: "v1"},
+		{Name: "node-5", Phase: mcfgv1.NodePhaseDegraded, CurrentConfig: "v0", DesiredConfig: "v1", Reason: "failed to drain"},
+		{Name: "node-6", Phase: mcfgv1.NodePhaseDone, CurrentConfig: "v1", DesiredConfig: "v1"},
+		{Name: "node-7", Phase: mcfgv1.NodePhaseStaged, CurrentConfig: "v0", DesiredConfig: "v1"},
+	}
+	got := getNodePhases("v1", nodes)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("mismatch: got %v, expected %v", got, expected)
+	}
+
+	// staged machines keep running their workloads
+	if isNodeUnavailable(staged) {
+		t.Fatalf("staged node %s should be available", staged.Name)
+	}
+}
+
 func TestCalculateStatus(t *testing.T) {
 	tests := []struct {
 		nodes         []*corev1.Node
@@ -619,3 +664,25 @@ func TestCalculateStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestSetPoolMachinesMetrics(t *testing.T) {
+	setPoolMachinesMetrics("worker", mcfgv1.MachineConfigPoolStatus{
+		MachineCount:            5,
+		ReadyMachineCount:       3,
+		UpdatedMachineCount:     4,
+		UnavailableMachineCount: 2,
+		DegradedMachineCount:    1,
+		UnmanagedMachineCount:   1,
+	})
+
+	expected := map[string]float64{"total": 5, "ready": 3, "updated": 4, "unavailable": 2, "degraded": 1, "unmanaged": 1}
+	for state, count := range expected {
+		var m dto.Metric
+		if err := ctrlcommon.MCCPoolMachines.WithLabelValues("worker", state).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetGauge().GetValue(); got != count {
+			t.Fatalf("%s machines: expected %v, got %v", state, count, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// mcoOwnedPaths are the files and directory trees, ending with /, the MCO relies
+// on to manage the machines, which can't be unmanaged.
+var mcoOwnedPaths = []string{
+	"/etc/kubernetes/",
+	"/etc/machine-config-daemon/",
+	"/etc/systemd/system/",
+	"/etc/crio/",
+	"/etc/containers/registries.conf",
+	"/etc/containers/registries.d/",
+	"/etc/containers/policy.json",
+	"/etc/pki/ca-trust/source/anchors/",
+	"/home/core/.ssh/",
+	daemonconsts.MachineConfigEncapsulatedPath,
+	daemonconsts.EtcPivotFile,
+}
+
+// validateUnmanagedPath returns an error if p isn't a clean absolute path, or if
+// it covers, or is covered by, a path the MCO relies on.
+func validateUnmanagedPath(p string) error {
+	if !path.IsAbs(p) {
+		return fmt.Errorf("%s is not an absolute path", p)
+	}
+	dir := strings.HasSuffix(p, "/")
+	if clean := path.Clean(p); clean != strings.TrimSuffix(p, "/") && !(dir && clean == "/") {
+		return fmt.Errorf("%s is not a clean path", p)
+	}
+	for _, owned := range mcoOwnedPaths {
+		if pathCovers(p, owned) || pathCovers(owned, p) {
+			return fmt.Errorf("%s is managed by the MCO", owned)
+		}
+	}
+	return nil
+}
+
+// pathCovers returns whether the file or directory tree p covers other.
+func pathCovers(p, other string) bool {
+	if strings.HasSuffix(p, "/") {
+		return strings.HasPrefix(other, p) || other == strings.TrimSuffix(p, "/")
+	}
+	return p == other || p == strings.TrimSuffix(other, "/")
+}
+
+// getUnmanagedPaths returns the JSON encoded valid unmanaged paths of the pool,
+// empty when there are none, and why the others are invalid.
+func getUnmanagedPaths(pool *mcfgv1.MachineConfigPool) (string, []string, error) {
+	var valid, invalid []string
+	for _, p := range pool.Spec.UnmanagedPaths {
+		if err := validateUnmanagedPath(p); err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		valid = append(valid, p)
+	}
+	if len(valid) == 0 {
+		return "", invalid, nil
+	}
+	data, err := json.Marshal(valid)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(data), invalid, nil
+}
+
+// syncUnmanagedPaths hands the valid unmanagedPaths of the pool to the daemons
+// of its nodes.
+func (ctrl *Controller) syncUnmanagedPaths(pool *mcfgv1.MachineConfigPool, nodes []*corev1.Node) error {
+	paths, invalid, err := getUnmanagedPaths(pool)
+	if err != nil {
+		return err
+	}
+	if len(invalid) > 0 {
+		ctrl.eventRecorder.Eventf(pool, corev1.EventTypeWarning, "InvalidUnmanagedPaths", "Ignoring unmanagedPaths: %s", strings.Join(invalid, "; "))
+	}
+	for _, node := range nodes {
+		if node.Annotations[daemonconsts.UnmanagedPathsAnnotationKey] == paths {
+			continue
+		}
+		_, err := internal.UpdateNodeRetry(ctrl.kubeClient.CoreV1().Nodes(), ctrl.nodeLister, node.Name, func(node *corev1.Node) {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			if paths != "" {
+				node.Annotations[daemonconsts.UnmanagedPathsAnnotationKey] = paths
+			} else {
+				delete(node.Annotations, daemonconsts.UnmanagedPathsAnnotationKey)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNodeUnmanaged returns whether the administrator excluded the node from the
+// management of the MCO with the unmanaged annotation or label. Unlike the nodes
+// not managed yet, its daemon runs but leaves it at its current config.
+func isNodeUnmanaged(node *corev1.Node) bool {
+	return node.Annotations[daemonconsts.UnmanagedNodeAnnotationKey] == "true" ||
+		node.Labels[daemonconsts.UnmanagedNodeAnnotationKey] == "true"
+}
+
+// splitUnmanagedNodes returns the nodes the MCO manages, and the ones excluded
+// from its management.
+func splitUnmanagedNodes(nodes []*corev1.Node) ([]*corev1.Node, []*corev1.Node) {
+	var managed, unmanaged []*corev1.Node
+	for _, node := range nodes {
+		if isNodeUnmanaged(node) {
+			unmanaged = append(unmanaged, node)
+		} else {
+			managed = append(managed, node)
+		}
+	}
+	return managed, unmanaged
+}
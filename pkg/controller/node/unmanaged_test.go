@@ -0,0 +1,78 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestValidateUnmanagedPath(t *testing.T) {
+	for _, p := range []string{"/etc/resolv.conf", "/etc/agent/", "/etc/containers/storage.conf", "/var/lib/agent/state"} {
+		assert.Nil(t, validateUnmanagedPath(p), p)
+	}
+	for _, p := range []string{
+		"etc/resolv.conf",
+		"/etc/../etc/resolv.conf",
+		"/etc//resolv.conf",
+		"/",
+		"/etc/",
+		"/etc/kubernetes/kubelet.conf",
+		"/etc/kubernetes",
+		"/etc/containers/",
+		"/etc/containers/registries.conf",
+		"/home/core/",
+		"/etc/ignition-machine-config-encapsulated.json",
+	} {
+		assert.NotNil(t, validateUnmanagedPath(p), p)
+	}
+}
+
+func TestGetUnmanagedPaths(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	paths, invalid, err := getUnmanagedPaths(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, "", paths)
+	assert.Empty(t, invalid)
+
+	pool.Spec.UnmanagedPaths = []string{"/etc/resolv.conf", "/etc/kubernetes/", "/etc/agent/"}
+	paths, invalid, err = getUnmanagedPaths(pool)
+	assert.Nil(t, err)
+	assert.Equal(t, `["/etc/resolv.conf","/etc/agent/"]`, paths)
+	assert.Equal(t, []string{"/etc/kubernetes/ is managed by the MCO"}, invalid)
+}
+
+func TestUnmanagedNodes(t *testing.T) {
+	pool := helpers.NewMachineConfigPool("worker", nil, helpers.WorkerSelector, "v1")
+	// degraded and behind, but excluded on purpose
+	annotated := newNodeWithReadyAndDaemonState("node-0", "v0", "v0", corev1.ConditionFalse, daemonconsts.MachineConfigDaemonStateDegraded)
+	annotated.Annotations[daemonconsts.UnmanagedNodeAnnotationKey] = "true"
+	labeled := newNodeWithReady("node-1", "v0", "v0", corev1.ConditionTrue)
+	labeled.Labels = map[string]string{daemonconsts.UnmanagedNodeAnnotationKey: "true"}
+	nodes := []*corev1.Node{
+		annotated,
+		labeled,
+		newNodeWithReady("node-2", "v0", "v0", corev1.ConditionTrue),
+	}
+
+	candidates := getCandidateMachines(pool, nodes, 2)
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, "node-2", candidates[0].Name)
+
+	nodes[2] = newNodeWithReady("node-2", "v1", "v1", corev1.ConditionTrue)
+	status := calculateStatus(pool, nodes)
+	assert.Equal(t, int32(3), status.MachineCount)
+	assert.Equal(t, int32(1), status.UpdatedMachineCount)
+	assert.Equal(t, int32(2), status.UnmanagedMachineCount)
+	assert.Equal(t, int32(0), status.DegradedMachineCount)
+	assert.Equal(t, int32(0), status.UnavailableMachineCount)
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionTrue(status.Conditions, mcfgv1.MachineConfigPoolUpdated))
+	assert.True(t, mcfgv1.IsMachineConfigPoolConditionFalse(status.Conditions, mcfgv1.MachineConfigPoolDegraded))
+	assert.Equal(t, mcfgv1.NodePhaseUnmanaged, status.Nodes[0].Phase)
+	assert.Equal(t, mcfgv1.NodePhaseUnmanaged, status.Nodes[1].Phase)
+	assert.Equal(t, mcfgv1.NodePhaseDone, status.Nodes[2].Phase)
+}
@@ -0,0 +1,199 @@
+package onpremnetworking
+
+import (
+	"fmt"
+	"sort"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/ghodss/yaml"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	keepalivedManifestPath = "/etc/kubernetes/manifests/keepalived.yaml"
+	corednsManifestPath    = "/etc/kubernetes/manifests/coredns.yaml"
+)
+
+// staticPodPaths are the static pods of the OnPremNetworking.
+var staticPodPaths = []string{corednsManifestPath, keepalivedManifestPath}
+
+// onPremPlatform is the on-premise platform of the cluster, with its virtual IPs.
+type onPremPlatform struct {
+	platformType configv1.PlatformType
+	apiVIP       string
+	ingressVIP   string
+}
+
+// getOnPremPlatform returns the on-premise platform of infra. It returns an
+// error on other platforms, or when the platform has no virtual IPs, as on
+// vSphere UPI.
+func getOnPremPlatform(infra *configv1.Infrastructure) (*onPremPlatform, error) {
+	if infra == nil || infra.Status.PlatformStatus == nil {
+		return nil, fmt.Errorf("the platform status of the cluster is not known yet")
+	}
+	status := infra.Status.PlatformStatus
+	p := &onPremPlatform{platformType: status.Type}
+	switch status.Type {
+	case configv1.BareMetalPlatformType:
+		if s := status.BareMetal; s != nil {
+			p.apiVIP, p.ingressVIP = s.APIServerInternalIP, s.IngressIP
+		}
+	case configv1.OvirtPlatformType:
+		if s := status.Ovirt; s != nil {
+			p.apiVIP, p.ingressVIP = s.APIServerInternalIP, s.IngressIP
+		}
+	case configv1.VSpherePlatformType:
+		if s := status.VSphere; s != nil {
+			p.apiVIP, p.ingressVIP = s.APIServerInternalIP, s.IngressIP
+		}
+	default:
+		return nil, fmt.Errorf("platform %s has no on-premise networking", status.Type)
+	}
+	if p.apiVIP == "" || p.ingressVIP == "" {
+		return nil, fmt.Errorf("platform %s has no API and ingress virtual IPs", status.Type)
+	}
+	return p, nil
+}
+
+// getTemplateMachineConfigName returns the name of the MachineConfig the
+// template controller renders the static pods of the platform into for pool.
+// Custom pools inherit the configs of the workers.
+func getTemplateMachineConfigName(pool *mcfgv1.MachineConfigPool) string {
+	if pool.Name == "master" {
+		return "00-master"
+	}
+	return "00-worker"
+}
+
+// validateOnPremNetworking returns an error if a static pod requests another
+// resource than cpu and memory, or a quantity which isn't positive.
+func validateOnPremNetworking(cfg *mcfgv1.OnPremNetworking) error {
+	for name, pod := range map[string]mcfgv1.OnPremStaticPod{"keepalived": cfg.Spec.Keepalived, "coreDNS": cfg.Spec.CoreDNS} {
+		for res, q := range pod.Resources {
+			if res != corev1.ResourceCPU && res != corev1.ResourceMemory {
+				return fmt.Errorf("OnPremNetworking: %s: unsupported resource %q, only cpu and memory can be requested", name, res)
+			}
+			if q.Sign() <= 0 {
+				return fmt.Errorf("OnPremNetworking: %s: %s must be positive, got %s", name, res, q.String())
+			}
+		}
+	}
+	return nil
+}
+
+// defaultResources are the requests of the containers of the templated static
+// pods, those which aren't set by the OnPremNetworking.
+var defaultResources = corev1.ResourceList{
+	corev1.ResourceCPU:    resource.MustParse("100m"),
+	corev1.ResourceMemory: resource.MustParse("200Mi"),
+}
+
+// getRequests returns the default requests overridden by the ones of pod.
+func getRequests(pod mcfgv1.OnPremStaticPod) corev1.ResourceList {
+	requests := defaultResources.DeepCopy()
+	for res, q := range pod.Resources {
+		requests[res] = q.DeepCopy()
+	}
+	return requests
+}
+
+// isTemplateMachineConfig returns whether mc is a MachineConfig the template
+// controller renders the static pods of the platform into.
+func isTemplateMachineConfig(mc *mcfgv1.MachineConfig) bool {
+	return mc.Name == "00-master" || mc.Name == "00-worker"
+}
+
+// renderStaticPods returns the static pod manifests of the OnPremNetworking
+// by path. The pods are the ones of the templates of the platform, in
+// templateMC, with the requests of their containers set from spec.
+func renderStaticPods(templateMC *mcfgv1.MachineConfig, spec mcfgv1.OnPremNetworkingSpec) (map[string][]byte, error) {
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(templateMC.Spec.Config.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the Ignition config of %s: %v", templateMC.Name, err)
+	}
+	specs := map[string]mcfgv1.OnPremStaticPod{
+		keepalivedManifestPath: spec.Keepalived,
+		corednsManifestPath:    spec.CoreDNS,
+	}
+	manifests := make(map[string][]byte, len(specs))
+	for _, f := range ignCfg.Storage.Files {
+		podSpec, ok := specs[f.Path]
+		if !ok {
+			continue
+		}
+		contents, err := dataurl.DecodeString(f.Contents.Source)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode %s of %s: %v", f.Path, templateMC.Name, err)
+		}
+		pod := &corev1.Pod{}
+		if err := yaml.Unmarshal(contents.Data, pod); err != nil {
+			return nil, fmt.Errorf("could not unmarshal %s of %s: %v", f.Path, templateMC.Name, err)
+		}
+		requests := getRequests(podSpec)
+		for i := range pod.Spec.Containers {
+			pod.Spec.Containers[i].Resources.Requests = requests.DeepCopy()
+		}
+		data, err := yaml.Marshal(pod)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal %s: %v", f.Path, err)
+		}
+		manifests[f.Path] = data
+	}
+	for _, path := range staticPodPaths {
+		if _, ok := manifests[path]; !ok {
+			return nil, fmt.Errorf("%s has no static pod %s", templateMC.Name, path)
+		}
+	}
+	return manifests, nil
+}
+
+// createNewOnPremNetworkingIgnition returns an Ignition config writing the
+// static pod manifests, in the order of their paths.
+func createNewOnPremNetworkingIgnition(manifests map[string][]byte) igntypes.Config {
+	tempIgnConfig := ctrlcommon.NewIgnConfig()
+	mode := 0644
+	for _, path := range getManifestPaths(manifests) {
+		du := dataurl.New(manifests[path], "text/plain")
+		du.Encoding = dataurl.EncodingASCII
+		tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, igntypes.File{
+			Node: igntypes.Node{
+				Filesystem: "root",
+				Path:       path,
+			},
+			FileEmbedded1: igntypes.FileEmbedded1{
+				Mode: &mode,
+				Contents: igntypes.FileContents{
+					Source: du.String(),
+				},
+			},
+		})
+	}
+	return tempIgnConfig
+}
+
+func getManifestPaths(manifests map[string][]byte) []string {
+	paths := make([]string, 0, len(manifests))
+	for path := range manifests {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// getManagedOnPremNetworkingKey returns the name of the MachineConfig of pool.
+// It sorts after the MachineConfig shipping the templated static pods, so that
+// the rendered ones replace them.
+func getManagedOnPremNetworkingKey(pool *mcfgv1.MachineConfigPool) string {
+	return fmt.Sprintf("99-%s-%s-on-prem-networking", pool.Name, pool.ObjectMeta.UID)
+}
+
+func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.OnPremNetworkingCondition {
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewOnPremNetworkingCondition(mcfgv1.OnPremNetworkingStatusConditionType(condition.Type), condition.Status, condition.Message)
+}
@@ -0,0 +1,148 @@
+package onpremnetworking
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func newInfra(status *configv1.PlatformStatus) *configv1.Infrastructure {
+	return &configv1.Infrastructure{Status: configv1.InfrastructureStatus{PlatformStatus: status}}
+}
+
+func TestGetOnPremPlatform(t *testing.T) {
+	tests := []struct {
+		name           string
+		infra          *configv1.Infrastructure
+		wantAPIVIP     string
+		wantIngressVIP string
+		wantErr        bool
+	}{
+		{
+			name:    "no platform status",
+			infra:   newInfra(nil),
+			wantErr: true,
+		},
+		{
+			name:    "cloud platform",
+			infra:   newInfra(&configv1.PlatformStatus{Type: configv1.AWSPlatformType}),
+			wantErr: true,
+		},
+		{
+			name: "baremetal",
+			infra: newInfra(&configv1.PlatformStatus{Type: configv1.BareMetalPlatformType, BareMetal: &configv1.BareMetalPlatformStatus{
+				APIServerInternalIP: "192.168.111.5", IngressIP: "192.168.111.4", NodeDNSIP: "192.168.111.2",
+			}}),
+			wantAPIVIP:     "192.168.111.5",
+			wantIngressVIP: "192.168.111.4",
+		},
+		{
+			name: "ovirt",
+			infra: newInfra(&configv1.PlatformStatus{Type: configv1.OvirtPlatformType, Ovirt: &configv1.OvirtPlatformStatus{
+				APIServerInternalIP: "10.0.0.5", IngressIP: "10.0.0.4", NodeDNSIP: "10.0.0.2",
+			}}),
+			wantAPIVIP:     "10.0.0.5",
+			wantIngressVIP: "10.0.0.4",
+		},
+		{
+			name:    "vsphere UPI has no VIPs",
+			infra:   newInfra(&configv1.PlatformStatus{Type: configv1.VSpherePlatformType, VSphere: &configv1.VSpherePlatformStatus{}}),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := getOnPremPlatform(test.infra)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantAPIVIP, p.apiVIP)
+			assert.Equal(t, test.wantIngressVIP, p.ingressVIP)
+		})
+	}
+}
+
+func TestValidateOnPremNetworking(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources corev1.ResourceList
+		wantErr   bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "cpu and memory",
+			resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("100Mi")},
+		},
+		{
+			name:      "unsupported resource",
+			resources: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+			wantErr:   true,
+		},
+		{
+			name:      "zero cpu",
+			resources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("0")},
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &mcfgv1.OnPremNetworking{Spec: mcfgv1.OnPremNetworkingSpec{CoreDNS: mcfgv1.OnPremStaticPod{Resources: test.resources}}}
+			err := validateOnPremNetworking(cfg)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRenderStaticPods(t *testing.T) {
+	status := &configv1.PlatformStatus{Type: configv1.OvirtPlatformType, Ovirt: &configv1.OvirtPlatformStatus{
+		APIServerInternalIP: "10.0.0.5", IngressIP: "10.0.0.4", NodeDNSIP: "10.0.0.2",
+	}}
+	templateMC := newTemplateMachineConfig(t, newControllerConfig(status), "master")
+	spec := mcfgv1.OnPremNetworkingSpec{
+		Keepalived: mcfgv1.OnPremStaticPod{Resources: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}},
+	}
+
+	manifests, err := renderStaticPods(templateMC, spec)
+	require.NoError(t, err)
+	assert.Equal(t, staticPodPaths, getManifestPaths(manifests))
+
+	// The pods are the templated ones, only their requests change
+	keepalived := &corev1.Pod{}
+	require.NoError(t, yaml.Unmarshal(manifests[keepalivedManifestPath], keepalived))
+	assert.Equal(t, "openshift-ovirt-infra", keepalived.Namespace)
+	require.Len(t, keepalived.Spec.Containers, 2)
+	assert.Equal(t, "keepalived:latest", keepalived.Spec.Containers[0].Image)
+	for _, c := range keepalived.Spec.Containers {
+		assert.Equal(t, "100m", c.Resources.Requests.Cpu().String())
+		assert.Equal(t, "64Mi", c.Resources.Requests.Memory().String())
+	}
+	assert.Contains(t, keepalived.Spec.Containers[1].Command, "10.0.0.5")
+
+	coredns := &corev1.Pod{}
+	require.NoError(t, yaml.Unmarshal(manifests[corednsManifestPath], coredns))
+	for _, c := range coredns.Spec.Containers {
+		assert.Equal(t, "200Mi", c.Resources.Requests.Memory().String())
+	}
+	require.Len(t, coredns.Spec.InitContainers, 1)
+	assert.Empty(t, coredns.Spec.InitContainers[0].Resources.Requests)
+
+	// Without the templated static pods, e.g. on another platform, there's nothing to render
+	templateMC = newTemplateMachineConfig(t, newControllerConfig(&configv1.PlatformStatus{Type: configv1.AWSPlatformType}), "master")
+	_, err = renderStaticPods(templateMC, spec)
+	assert.Error(t, err)
+}
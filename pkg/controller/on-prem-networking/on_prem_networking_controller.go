@@ -0,0 +1,476 @@
+package onpremnetworking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	macherrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// maxRetries is the number of times a OnPremNetworking will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a OnPremNetworking is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+var (
+	// controllerKind contains the schema.GroupVersionKind for this controller type.
+	controllerKind = mcfgv1.SchemeGroupVersion.WithKind("OnPremNetworking")
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the on-prem networking controller. It renders the
+// keepalived and coredns static pods of OnPremNetworkings into the
+// /etc/kubernetes/manifests of the selected pools.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler             func(key string) error
+	enqueueOnPremNetworking func(*mcfgv1.OnPremNetworking)
+
+	onpLister       mcfglistersv1.OnPremNetworkingLister
+	onpListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	ccLister       mcfglistersv1.ControllerConfigLister
+	ccListerSynced cache.InformerSynced
+
+	mcLister       mcfglistersv1.MachineConfigLister
+	mcListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new on-prem networking controller
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	ccInformer mcfginformersv1.ControllerConfigInformer,
+	mcInformer mcfginformersv1.MachineConfigInformer,
+	onpInformer mcfginformersv1.OnPremNetworkingInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-onpremnetworkingcontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-onpremnetworkingcontroller"),
+	}
+
+	onpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addOnPremNetworking,
+		UpdateFunc: ctrl.updateOnPremNetworking,
+		DeleteFunc: ctrl.deleteOnPremNetworking,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctrl.addMachineConfigPool,
+	})
+
+	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateControllerConfig,
+	})
+
+	mcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addMachineConfig,
+		UpdateFunc: ctrl.updateMachineConfig,
+	})
+
+	ctrl.syncHandler = ctrl.syncOnPremNetworking
+	ctrl.enqueueOnPremNetworking = ctrl.enqueue
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.ccLister = ccInformer.Lister()
+	ctrl.ccListerSynced = ccInformer.Informer().HasSynced
+
+	ctrl.mcLister = mcInformer.Lister()
+	ctrl.mcListerSynced = mcInformer.Informer().HasSynced
+
+	ctrl.onpLister = onpInformer.Lister()
+	ctrl.onpListerSynced = onpInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the on-prem networking controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.ccListerSynced, ctrl.mcListerSynced, ctrl.onpListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-OnPremNetworkingController")
+	defer glog.Info("Shutting down MachineConfigController-OnPremNetworkingController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-OnPremNetworkingController", ctrl.queue)
+}
+
+func (ctrl *Controller) updateOnPremNetworking(old, cur interface{}) {
+	oldConfig := old.(*mcfgv1.OnPremNetworking)
+	newConfig := cur.(*mcfgv1.OnPremNetworking)
+
+	if !reflect.DeepEqual(oldConfig.Spec, newConfig.Spec) {
+		glog.V(4).Infof("Update OnPremNetworking %s", oldConfig.Name)
+		ctrl.enqueueOnPremNetworking(newConfig)
+	}
+}
+
+func (ctrl *Controller) addOnPremNetworking(obj interface{}) {
+	cfg := obj.(*mcfgv1.OnPremNetworking)
+	glog.V(4).Infof("Adding OnPremNetworking %s", cfg.Name)
+	ctrl.enqueueOnPremNetworking(cfg)
+}
+
+func (ctrl *Controller) deleteOnPremNetworking(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.OnPremNetworking)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.OnPremNetworking)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a OnPremNetworking %#v", obj))
+			return
+		}
+	}
+	if err := ctrl.cascadeDelete(cfg); err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't delete MachineConfigs for %#v: %v", cfg, err))
+	} else {
+		glog.V(4).Infof("Deleted OnPremNetworking %s and its MachineConfigs", cfg.Name)
+	}
+}
+
+// addMachineConfigPool requeues all the OnPremNetworkings so that newly created pools
+// get the static pods they are selected for.
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	ctrl.enqueueAll()
+}
+
+// updateControllerConfig requeues all the OnPremNetworkings when the platform
+// or its virtual IPs change.
+func (ctrl *Controller) updateControllerConfig(old, cur interface{}) {
+	oldCC := old.(*mcfgv1.ControllerConfig)
+	newCC := cur.(*mcfgv1.ControllerConfig)
+
+	if !reflect.DeepEqual(oldCC.Spec.Infra, newCC.Spec.Infra) {
+		glog.V(4).Infof("Update ControllerConfig %s", newCC.Name)
+		ctrl.enqueueAll()
+	}
+}
+
+// addMachineConfig requeues all the OnPremNetworkings when a MachineConfig
+// holding the templated static pods is created.
+func (ctrl *Controller) addMachineConfig(obj interface{}) {
+	mc := obj.(*mcfgv1.MachineConfig)
+	if isTemplateMachineConfig(mc) {
+		glog.V(4).Infof("Adding MachineConfig %s", mc.Name)
+		ctrl.enqueueAll()
+	}
+}
+
+// updateMachineConfig requeues all the OnPremNetworkings when the templated
+// static pods change, e.g. on an image update.
+func (ctrl *Controller) updateMachineConfig(old, cur interface{}) {
+	oldMC := old.(*mcfgv1.MachineConfig)
+	newMC := cur.(*mcfgv1.MachineConfig)
+
+	if isTemplateMachineConfig(newMC) && !reflect.DeepEqual(oldMC.Spec, newMC.Spec) {
+		glog.V(4).Infof("Update MachineConfig %s", newMC.Name)
+		ctrl.enqueueAll()
+	}
+}
+
+func (ctrl *Controller) enqueueAll() {
+	cfgs, err := ctrl.onpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list OnPremNetworkings: %v", err))
+		return
+	}
+	for _, cfg := range cfgs {
+		ctrl.enqueueOnPremNetworking(cfg)
+	}
+}
+
+// cascadeDelete removes the MachineConfigs rendered for the given OnPremNetworking
+func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.OnPremNetworking) error {
+	mcs, err := ctrl.client.MachineconfigurationV1().MachineConfigs().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs.Items {
+		ref := metav1.GetControllerOf(&mc)
+		if ref == nil || ref.Kind != controllerKind.Kind || ref.UID != cfg.UID {
+			continue
+		}
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !macherrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctrl *Controller) enqueue(cfg *mcfgv1.OnPremNetworking) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(cfg)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %#v: %v", cfg, err))
+		return
+	}
+	ctrl.queue.Add(key)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.OnPremNetworkingControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing onpremnetworking %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping onpremnetworking %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.OnPremNetworking, err error, args ...interface{}) error {
+	return ctrl.syncStatus(cfg, nil, nil, err, args...)
+}
+
+// syncStatus appends the condition of err to the status of cfg, and records
+// the platform and the static pods rendered, if any.
+func (ctrl *Controller) syncStatus(cfg *mcfgv1.OnPremNetworking, platform *onPremPlatform, staticPods []string, err error, args ...interface{}) error {
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.onpLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = cfg.Generation
+		if platform != nil {
+			newcfg.Status.Platform = string(platform.platformType)
+			newcfg.Status.APIVIP = platform.apiVIP
+			newcfg.Status.IngressVIP = platform.ingressVIP
+			newcfg.Status.StaticPods = staticPods
+		}
+		mcfgv1.SetSyncCondition(&newcfg.Status.Conditions, wrapErrorWithCondition(err, args...))
+		_, lerr := ctrl.client.MachineconfigurationV1().OnPremNetworkings().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating onpremnetworking status: %v", statusUpdateError)
+	}
+	return err
+}
+
+// syncOnPremNetworking will sync the OnPremNetworking with the given key.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncOnPremNetworking(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing onpremnetworking %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing onpremnetworking %q (%v)", key, time.Since(startTime))
+	}()
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ctrl.onpLister.Get(name)
+	if macherrors.IsNotFound(err) {
+		glog.V(2).Infof("OnPremNetworking %v has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deep-copy otherwise we are mutating our cache.
+	cfg = cfg.DeepCopy()
+
+	if cfg.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if err := validateOnPremNetworking(cfg); err != nil {
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	pools, err := ctrl.getPoolsForOnPremNetworking(cfg)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, err)
+	}
+	if len(pools) == 0 {
+		err := fmt.Errorf("OnPremNetworking %v does not match any MachineConfigPools", key)
+		glog.V(2).Infof("%v", err)
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	cc, err := ctrl.ccLister.Get(ctrlcommon.ControllerConfigName)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, err, "could not get ControllerConfig %v", err)
+	}
+	platform, err := getOnPremPlatform(cc.Spec.Infra)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	for _, pool := range pools {
+		role := pool.Name
+		managedKey := getManagedOnPremNetworkingKey(pool)
+		templateKey := getTemplateMachineConfigName(pool)
+		templateMC, err := ctrl.mcLister.Get(templateKey)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not find MachineConfig: %v", templateKey)
+		}
+		manifests, err := renderStaticPods(templateMC, cfg.Spec)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+		}
+		ignConfig := createNewOnPremNetworkingIgnition(manifests)
+		rawIgn, err := json.Marshal(ignConfig)
+		if err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not marshal static pods Ignition: %v", err)
+		}
+
+		mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+		if err != nil && !macherrors.IsNotFound(err) {
+			return ctrl.syncStatusOnly(cfg, err, "could not find MachineConfig: %v", managedKey)
+		}
+		isNotFound := macherrors.IsNotFound(err)
+		if isNotFound {
+			mc, err = mtmpl.MachineConfigFromIgnConfig(role, managedKey, ignConfig)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not create MachineConfig from new Ignition config: %v", err)
+			}
+		} else {
+			if ref := metav1.GetControllerOf(mc); ref != nil && ref.UID != cfg.UID {
+				err := fmt.Errorf("MachineConfigPool %s already has the static pods of %s %s", pool.Name, ref.Kind, ref.Name)
+				return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+			}
+			mc.Spec.Config.Raw = rawIgn
+		}
+
+		mc.SetAnnotations(map[string]string{
+			ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+		})
+		oref := metav1.NewControllerRef(cfg, controllerKind)
+		mc.SetOwnerReferences([]metav1.OwnerReference{*oref})
+
+		// Create or Update, on conflict retry
+		if err := retry.RetryOnConflict(updateBackoff, func() error {
+			var err error
+			if isNotFound {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), mc, metav1.CreateOptions{})
+			} else {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), mc, metav1.UpdateOptions{})
+			}
+			return err
+		}); err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not Create/Update MachineConfig: %v", err)
+		}
+		glog.Infof("Applied OnPremNetworking %v on MachineConfigPool %v", key, pool.Name)
+	}
+
+	return ctrl.syncStatus(cfg, platform, staticPodPaths, nil)
+}
+
+func (ctrl *Controller) getPoolsForOnPremNetworking(config *mcfgv1.OnPremNetworking) ([]*mcfgv1.MachineConfigPool, error) {
+	pList, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(config.Spec.MachineConfigPoolSelector)
+	if err != nil {
+		return nil, ctrlcommon.NewForgetError(fmt.Errorf("invalid label selector: %v", err))
+	}
+
+	var pools []*mcfgv1.MachineConfigPool
+	for _, p := range pList {
+		// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
+		if selector.Empty() || !selector.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
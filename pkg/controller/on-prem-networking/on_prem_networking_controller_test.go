@@ -0,0 +1,169 @@
+package onpremnetworking
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+var alwaysReady = func() bool { return true }
+
+func newOnPremNetworking(name string, selector *metav1.LabelSelector) *mcfgv1.OnPremNetworking {
+	return &mcfgv1.OnPremNetworking{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
+		Spec: mcfgv1.OnPremNetworkingSpec{
+			MachineConfigPoolSelector: selector,
+		},
+	}
+}
+
+// newControllerConfig returns a ControllerConfig with the images the templates
+// need, on baremetal unless status is set.
+func newControllerConfig(status *configv1.PlatformStatus) *mcfgv1.ControllerConfig {
+	if status == nil {
+		status = &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType, BareMetal: &configv1.BareMetalPlatformStatus{
+			APIServerInternalIP: "192.168.111.5", IngressIP: "192.168.111.4",
+		}}
+	}
+	return &mcfgv1.ControllerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: ctrlcommon.ControllerConfigName},
+		Spec: mcfgv1.ControllerConfigSpec{
+			Images: map[string]string{
+				"keepalivedImage":          "keepalived:latest",
+				"corednsImage":             "coredns:latest",
+				"baremetalRuntimeCfgImage": "runtimecfg:latest",
+				"mdnsPublisherImage":       "mdns-publisher:latest",
+				"haproxyImage":             "haproxy:latest",
+				"infraImageKey":            "pod:latest",
+			},
+			Infra:    newInfra(status),
+			Platform: strings.ToLower(string(status.Type)),
+		},
+	}
+}
+
+// newTemplateMachineConfig returns the MachineConfig the templates of role
+// render into for cc.
+func newTemplateMachineConfig(t *testing.T, cc *mcfgv1.ControllerConfig, role string) *mcfgv1.MachineConfig {
+	mcs, err := mtmpl.GenerateMachineConfigsForRole(&mtmpl.RenderConfig{ControllerConfigSpec: &cc.Spec, PullSecret: `{"dummy":"dummy"}`}, role, "../../../templates")
+	require.NoError(t, err)
+	for _, mc := range mcs {
+		if mc.Name == "00-"+role {
+			return mc
+		}
+	}
+	t.Fatalf("no MachineConfig 00-%s rendered", role)
+	return nil
+}
+
+func newController(t *testing.T, cc *mcfgv1.ControllerConfig, pools []*mcfgv1.MachineConfigPool, cfgs []*mcfgv1.OnPremNetworking, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	templateMC := newTemplateMachineConfig(t, cc, "master")
+	objects = append(objects, templateMC)
+	for _, cfg := range cfgs {
+		objects = append(objects, cfg)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		i.Machineconfiguration().V1().ControllerConfigs(),
+		i.Machineconfiguration().V1().MachineConfigs(),
+		i.Machineconfiguration().V1().OnPremNetworkings(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.mcpListerSynced = alwaysReady
+	c.ccListerSynced = alwaysReady
+	c.mcListerSynced = alwaysReady
+	c.onpListerSynced = alwaysReady
+	c.eventRecorder = &record.FakeRecorder{}
+
+	require.Nil(t, i.Machineconfiguration().V1().ControllerConfigs().Informer().GetIndexer().Add(cc))
+	require.Nil(t, i.Machineconfiguration().V1().MachineConfigs().Informer().GetIndexer().Add(templateMC))
+	for _, p := range pools {
+		require.Nil(t, i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(p))
+	}
+	for _, cfg := range cfgs {
+		require.Nil(t, i.Machineconfiguration().V1().OnPremNetworkings().Informer().GetIndexer().Add(cfg))
+	}
+	return c, client
+}
+
+func TestOnPremNetworkingCreate(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	mcp.ObjectMeta.Labels["on-prem-networking"] = "default"
+	mcp2 := helpers.NewMachineConfigPool("infra", helpers.InfraSelector, helpers.InfraSelector, "v0")
+	onp := newOnPremNetworking("default", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "on-prem-networking", "default"))
+
+	c, client := newController(t, newControllerConfig(nil), []*mcfgv1.MachineConfigPool{mcp, mcp2}, []*mcfgv1.OnPremNetworking{onp})
+	require.Nil(t, c.syncHandler(onp.Name))
+
+	rendered, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedOnPremNetworkingKey(mcp), metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "master", rendered.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	require.NotNil(t, metav1.GetControllerOf(rendered))
+	assert.Equal(t, onp.UID, metav1.GetControllerOf(rendered).UID)
+
+	ignCfg, _, err := ign.Parse(rendered.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 2)
+	assert.Equal(t, corednsManifestPath, ignCfg.Storage.Files[0].Path)
+	assert.Equal(t, keepalivedManifestPath, ignCfg.Storage.Files[1].Path)
+
+	// The infra pool isn't selected
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedOnPremNetworkingKey(mcp2), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	onp, err = client.MachineconfigurationV1().OnPremNetworkings().Get(context.TODO(), onp.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, onp.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.OnPremNetworkingSuccess, onp.Status.Conditions[0].Type)
+	assert.Equal(t, "BareMetal", onp.Status.Platform)
+	assert.Equal(t, "192.168.111.5", onp.Status.APIVIP)
+	assert.Equal(t, "192.168.111.4", onp.Status.IngressVIP)
+	assert.Equal(t, []string{corednsManifestPath, keepalivedManifestPath}, onp.Status.StaticPods)
+}
+
+func TestOnPremNetworkingUnsupportedPlatform(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	mcp.ObjectMeta.Labels["on-prem-networking"] = "default"
+	onp := newOnPremNetworking("default", metav1.AddLabelToSelector(&metav1.LabelSelector{}, "on-prem-networking", "default"))
+	cc := newControllerConfig(&configv1.PlatformStatus{Type: configv1.AWSPlatformType})
+
+	c, client := newController(t, cc, []*mcfgv1.MachineConfigPool{mcp}, []*mcfgv1.OnPremNetworking{onp})
+	err := c.syncHandler(onp.Name)
+	require.NotNil(t, err)
+	_, ok := err.(*ctrlcommon.ForgetError)
+	assert.True(t, ok)
+
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedOnPremNetworkingKey(mcp), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	onp, err = client.MachineconfigurationV1().OnPremNetworkings().Get(context.TODO(), onp.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, onp.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.OnPremNetworkingFailure, onp.Status.Conditions[0].Type)
+	assert.Empty(t, onp.Status.StaticPods)
+}
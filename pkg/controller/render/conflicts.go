@@ -0,0 +1,101 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+
+	ign2types "github.com/coreos/ignition/config/v2_2/types"
+	ign3types "github.com/coreos/ignition/v2/config/v3_0/types"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+// fileSource records which MachineConfig last defined a path and with what contents.
+type fileSource struct {
+	config    string
+	contents  string
+	generated bool
+}
+
+// isGenerated returns true for configs generated by the controllers (templates,
+// kubelet and container runtime configs, ...). Those are built to override the
+// defaults and user configs are expected to override them, so they never conflict.
+// The configs holding config snippets are generated too, but their contents come
+// from the user, so they are checked like user configs.
+func isGenerated(config *mcfgv1.MachineConfig) bool {
+	if _, ok := config.Annotations[ctrlcommon.ConfigSnippetsAnnotationKey]; ok {
+		return false
+	}
+	_, ok := config.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey]
+	return ok
+}
+
+// getOverwrittenFiles returns path -> contents for every file config writes
+// as a whole. Appended files can't conflict and are skipped.
+func getOverwrittenFiles(config *mcfgv1.MachineConfig) (map[string]string, error) {
+	files := make(map[string]string)
+	if config.Spec.Config.Raw == nil {
+		return files, nil
+	}
+	ignCfg, err := ctrlcommon.IgnParseWrapper(config.Spec.Config.Raw)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg := ignCfg.(type) {
+	case ign2types.Config:
+		for _, f := range cfg.Storage.Files {
+			if f.Append {
+				continue
+			}
+			files[f.Path] = f.Contents.Compression + ":" + f.Contents.Source
+		}
+	case ign3types.Config:
+		for _, f := range cfg.Storage.Files {
+			var compression, source string
+			if f.Contents.Compression != nil {
+				compression = *f.Contents.Compression
+			}
+			if f.Contents.Source != nil {
+				source = *f.Contents.Source
+			}
+			files[f.Path] = compression + ":" + source
+		}
+	}
+	return files, nil
+}
+
+// detectFileConflicts returns an error naming both MachineConfigs and the path
+// when two user provided MachineConfigs of a pool write the same file with
+// different contents. Configs are checked in name order, the order they are
+// merged in, so the result is deterministic and the last config wins if it
+// carries the override annotation.
+func detectFileConflicts(configs []*mcfgv1.MachineConfig) error {
+	sorted := make([]*mcfgv1.MachineConfig, len(configs))
+	copy(sorted, configs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	seen := make(map[string]fileSource)
+	for _, config := range sorted {
+		generated := isGenerated(config)
+		allowOverride := config.Annotations[ctrlcommon.AllowFileOverrideAnnotationKey] == "true"
+		files, err := getOverwrittenFiles(config)
+		if err != nil {
+			return err
+		}
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			contents := files[path]
+			prev, ok := seen[path]
+			if ok && prev.contents != contents && !prev.generated && !generated && !allowOverride {
+				return fmt.Errorf("file %s is defined with different contents by MachineConfigs %s and %s; set the %s=true annotation on %s to let it take precedence",
+					path, prev.config, config.Name, ctrlcommon.AllowFileOverrideAnnotationKey, config.Name)
+			}
+			seen[path] = fileSource{config: config.Name, contents: contents, generated: generated}
+		}
+	}
+	return nil
+}
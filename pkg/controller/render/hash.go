@@ -3,10 +3,13 @@ package render
 import (
 	//nolint:gosec
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/ghodss/yaml"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
 )
 
 var (
@@ -51,3 +54,45 @@ func hashData(data []byte) ([]byte, error) {
 	}
 	return hasher.Sum(nil), nil
 }
+
+// renderInput is what a rendered config is generated from, besides the
+// MachineConfigs of the pool.
+type renderInput struct {
+	ControllerVersion string                    `json:"controllerVersion"`
+	OSImageURL        string                    `json:"osImageURL"`
+	PoolOSImageURL    string                    `json:"poolOSImageURL"`
+	OSImageAllowList  *mcfgv1.OSImageAllowList  `json:"osImageAllowList"`
+	Sources           []renderInputSourceConfig `json:"sources"`
+}
+
+type renderInputSourceConfig struct {
+	Name       string                   `json:"name"`
+	Generation int64                    `json:"generation"`
+	Spec       mcfgv1.MachineConfigSpec `json:"spec"`
+}
+
+// getRenderInputHash returns the hash of the inputs of the rendered config of
+// pool: its configs, sorted by name, the OS image settings and the version of
+// the controller. The same inputs always render the same config.
+func getRenderInputHash(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) (string, error) {
+	input := renderInput{
+		ControllerVersion: version.Hash,
+		OSImageURL:        cconfig.Spec.OSImageURL,
+		PoolOSImageURL:    pool.Spec.OSImageURL,
+		OSImageAllowList:  cconfig.Spec.OSImageAllowList,
+	}
+	for _, cfg := range configs {
+		input.Sources = append(input.Sources, renderInputSourceConfig{Name: cfg.Name, Generation: cfg.Generation, Spec: cfg.Spec})
+	}
+	sort.Slice(input.Sources, func(i, j int) bool { return input.Sources[i].Name < input.Sources[j].Name })
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	h, err := hashData(data)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h), nil
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"golang.org/x/crypto/openpgp"
+)
+
+// getPoolOSImageURL returns the OS image the machines of pool run: its override
+// once validated against the signed allow list, or the one of the release payload.
+func getPoolOSImageURL(pool *mcfgv1.MachineConfigPool, cconfig *mcfgv1.ControllerConfig) (string, error) {
+	override := pool.Spec.OSImageURL
+	if override == "" || override == cconfig.Spec.OSImageURL {
+		return cconfig.Spec.OSImageURL, nil
+	}
+	// a tag may point at another image by the time the machines pull it
+	if !strings.Contains(override, "@sha256:") {
+		return "", fmt.Errorf("OS image override %s must be pinned by digest", override)
+	}
+	allowed, err := getAllowedOSImages(cconfig.Spec.OSImageAllowList)
+	if err != nil {
+		return "", fmt.Errorf("OS image override %s: %v", override, err)
+	}
+	if !allowed[override] {
+		return "", fmt.Errorf("OS image override %s isn't in the OS image allow list", override)
+	}
+	return override, nil
+}
+
+// getAllowedOSImages returns the images of allowList, once its signature is verified.
+func getAllowedOSImages(allowList *mcfgv1.OSImageAllowList) (map[string]bool, error) {
+	if allowList == nil {
+		return nil, fmt.Errorf("no OS image allow list is set")
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(allowList.Keyring))
+	if err != nil {
+		return nil, fmt.Errorf("reading the OS image allow list keyring: %v", err)
+	}
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, strings.NewReader(allowList.Images), strings.NewReader(allowList.Signature)); err != nil {
+		return nil, fmt.Errorf("verifying the OS image allow list signature: %v", err)
+	}
+
+	allowed := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(allowList.Images))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			allowed[line] = true
+		}
+	}
+	return allowed, scanner.Err()
+}
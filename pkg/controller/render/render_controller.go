@@ -2,8 +2,11 @@ package render
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -21,7 +24,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
@@ -66,9 +68,20 @@ type Controller struct {
 	ccLister       mcfglistersv1.ControllerConfigLister
 	ccListerSynced cache.InformerSynced
 
+	// renderedMu guards rendered, which caches the last config rendered for each
+	// pool, so that the pools whose inputs didn't change aren't rendered again.
+	renderedMu sync.Mutex
+	rendered   map[string]renderedConfig
+
 	queue workqueue.RateLimitingInterface
 }
 
+// renderedConfig is the config rendered for a pool, and the hash of its inputs.
+type renderedConfig struct {
+	inputHash string
+	name      string
+}
+
 // New returns a new render controller.
 func New(
 	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
@@ -76,6 +89,7 @@ func New(
 	ccInformer mcfginformersv1.ControllerConfigInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -84,7 +98,8 @@ func New(
 	ctrl := &Controller{
 		client:        mcfgClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-rendercontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-rendercontroller"),
+		rendered:      map[string]renderedConfig{},
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-rendercontroller"),
 	}
 
 	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -97,6 +112,9 @@ func New(
 		UpdateFunc: ctrl.updateMachineConfig,
 		DeleteFunc: ctrl.deleteMachineConfig,
 	})
+	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: ctrl.updateControllerConfig,
+	})
 
 	ctrl.syncHandler = ctrl.syncMachineConfigPool
 	ctrl.enqueueMachineConfigPool = ctrl.enqueueDefault
@@ -123,11 +141,9 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	glog.Info("Starting MachineConfigController-RenderController")
 	defer glog.Info("Shutting down MachineConfigController-RenderController")
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.worker, time.Second, stopCh)
-	}
-
-	<-stopCh
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-RenderController", ctrl.queue)
 }
 
 func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
@@ -160,9 +176,33 @@ func (ctrl *Controller) deleteMachineConfigPool(obj interface{}) {
 		}
 	}
 	glog.V(4).Infof("Deleting MachineConfigPool %s", pool.Name)
+	ctrl.renderedMu.Lock()
+	delete(ctrl.rendered, pool.Name)
+	ctrl.renderedMu.Unlock()
 	// TODO(abhinavdahiya): handle deletes.
 }
 
+// updateControllerConfig re-renders the pools overriding their OS image when
+// the allow list their override is validated against changes.
+func (ctrl *Controller) updateControllerConfig(old, cur interface{}) {
+	oldCC := old.(*mcfgv1.ControllerConfig)
+	curCC := cur.(*mcfgv1.ControllerConfig)
+	if reflect.DeepEqual(oldCC.Spec.OSImageAllowList, curCC.Spec.OSImageAllowList) {
+		return
+	}
+	pools, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list MachineConfigPools: %v", err))
+		return
+	}
+	for _, pool := range pools {
+		if pool.Spec.OSImageURL != "" {
+			glog.V(4).Infof("OS image allow list changed, re-rendering MachineConfigPool %s", pool.Name)
+			ctrl.enqueueMachineConfigPool(pool)
+		}
+	}
+}
+
 func (ctrl *Controller) addMachineConfig(obj interface{}) {
 	mc := obj.(*mcfgv1.MachineConfig)
 	if mc.DeletionTimestamp != nil {
@@ -371,6 +411,7 @@ func (ctrl *Controller) handleErr(err error, key interface{}) {
 		ctrl.queue.Forget(key)
 		return
 	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.RenderControllerName).Inc()
 
 	if ctrl.queue.NumRequeues(key) < maxRetries {
 		glog.V(2).Infof("Error syncing machineconfigpool %v: %v", key, err)
@@ -433,9 +474,12 @@ func (ctrl *Controller) syncMachineConfigPool(key string) error {
 		return ctrl.syncFailingStatus(pool, fmt.Errorf("no MachineConfigs found matching selector %v", selector))
 	}
 
+	renderStart := time.Now()
 	if err := ctrl.syncGeneratedMachineConfig(pool, mcs); err != nil {
 		return ctrl.syncFailingStatus(pool, err)
 	}
+	ctrlcommon.MCCRenderDuration.WithLabelValues(pool.Name).Observe(time.Since(renderStart).Seconds())
+	ctrlcommon.MCCLastSuccessfulRender.WithLabelValues(pool.Name).SetToCurrentTime()
 
 	return ctrl.syncAvailableStatus(pool)
 }
@@ -461,16 +505,6 @@ func (ctrl *Controller) syncFailingStatus(pool *mcfgv1.MachineConfigPool, err er
 	return err
 }
 
-// This function will eventually contain a sane garbage collection policy for rendered MachineConfigs;
-// see https://github.com/openshift/machine-config-operator/issues/301
-// It will probably involve making sure we're only GCing a config after all nodes don't have it
-// in either desired or current config.
-func (ctrl *Controller) garbageCollectRenderedConfigs(pool *mcfgv1.MachineConfigPool) error {
-	// Temporarily until https://github.com/openshift/machine-config-operator/pull/318
-	// which depends on the strategy for https://github.com/openshift/machine-config-operator/issues/301
-	return nil
-}
-
 func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig) error {
 	if len(configs) == 0 {
 		return nil
@@ -481,28 +515,37 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		return err
 	}
 
-	generated, err := generateRenderedMachineConfig(pool, configs, cc)
+	inputHash, err := getRenderInputHash(pool, configs, cc)
 	if err != nil {
 		return err
 	}
+	if ctrl.isRenderUpToDate(pool, inputHash) {
+		glog.V(4).Infof("Pool %s: the inputs of %s didn't change, skipping render", pool.Name, pool.Spec.Configuration.Name)
+		return nil
+	}
 
-	source := []corev1.ObjectReference{}
-	for _, cfg := range configs {
-		source = append(source, corev1.ObjectReference{Kind: machineconfigKind.Kind, Name: cfg.GetName(), APIVersion: machineconfigKind.GroupVersion().String()})
+	generated, err := generateRenderedMachineConfig(pool, configs, cc)
+	if err != nil {
+		return err
 	}
 
+	source := getRenderedSource(configs)
+
 	_, err = ctrl.mcLister.Get(generated.Name)
 	if apierrors.IsNotFound(err) {
 		_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), generated, metav1.CreateOptions{})
 		glog.V(2).Infof("Generated machineconfig %s from %d configs: %s", generated.Name, len(source), source)
+	} else if err == nil {
+		// the same config may be rendered again from sources of other generations
+		_, _, err = resourceapply.ApplyMachineConfig(ctrl.client.MachineconfigurationV1(), generated)
 	}
 	if err != nil {
 		return err
 	}
 
 	if pool.Spec.Configuration.Name == generated.Name {
-		_, _, err = resourceapply.ApplyMachineConfig(ctrl.client.MachineconfigurationV1(), generated)
-		return err
+		ctrl.setRendered(pool, inputHash, generated.Name)
+		return nil
 	}
 
 	newPool := pool.DeepCopy()
@@ -514,12 +557,31 @@ func (ctrl *Controller) syncGeneratedMachineConfig(pool *mcfgv1.MachineConfigPoo
 		return err
 	}
 	glog.V(2).Infof("Pool %s: now targeting: %s", pool.Name, pool.Spec.Configuration.Name)
+	ctrl.setRendered(pool, inputHash, generated.Name)
 
-	if err := ctrl.garbageCollectRenderedConfigs(pool); err != nil {
-		return err
+	// Rendered configs which aren't needed anymore are garbage collected by the node controller,
+	// which knows what the nodes are running.
+	return nil
+}
+
+// isRenderUpToDate returns whether the config pool targets was rendered from
+// inputs of the same hash, and still exists, in which case rendering it again
+// would only churn the API.
+func (ctrl *Controller) isRenderUpToDate(pool *mcfgv1.MachineConfigPool, inputHash string) bool {
+	ctrl.renderedMu.Lock()
+	rendered, ok := ctrl.rendered[pool.Name]
+	ctrl.renderedMu.Unlock()
+	if !ok || rendered.inputHash != inputHash || rendered.name != pool.Spec.Configuration.Name {
+		return false
 	}
+	_, err := ctrl.mcLister.Get(rendered.name)
+	return err == nil
+}
 
-	return nil
+func (ctrl *Controller) setRendered(pool *mcfgv1.MachineConfigPool, inputHash, name string) {
+	ctrl.renderedMu.Lock()
+	defer ctrl.renderedMu.Unlock()
+	ctrl.rendered[pool.Name] = renderedConfig{inputHash: inputHash, name: name}
 }
 
 // generateRenderedMachineConfig takes all MCs for a given pool and returns a single rendered MC. For ex master-XXXX or worker-XXXX
@@ -527,13 +589,26 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 	// Before merging all MCs for a specific pool, let's make sure MachineConfigs are valid
 	for _, config := range configs {
 		if err := ctrlcommon.ValidateMachineConfig(config.Spec); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("MachineConfig %s is invalid: %v", config.Name, err)
 		}
 	}
-	merged, err := ctrlcommon.MergeMachineConfigs(configs, cconfig.Spec.OSImageURL)
+	// The merge lets the last config win, make sure that's what the user asked for
+	if err := detectFileConflicts(configs); err != nil {
+		return nil, err
+	}
+	osImageURL, err := getPoolOSImageURL(pool, cconfig)
 	if err != nil {
 		return nil, err
 	}
+	merged, err := ctrlcommon.MergeMachineConfigs(configs, osImageURL)
+	if err != nil {
+		return nil, err
+	}
+	// Settings that are valid on their own may still conflict once merged,
+	// e.g. an extension in one MC and kernelType in another
+	if err := ctrlcommon.ValidateMachineConfig(merged.Spec); err != nil {
+		return nil, err
+	}
 	hashedName, err := getMachineConfigHashedName(pool, merged)
 	if err != nil {
 		return nil, err
@@ -546,18 +621,72 @@ func generateRenderedMachineConfig(pool *mcfgv1.MachineConfigPool, configs []*mc
 		merged.Annotations = map[string]string{}
 	}
 	merged.Annotations[ctrlcommon.GeneratedByControllerVersionAnnotationKey] = version.Hash
+	sources, err := sourceMachineConfigsAnnotation(configs)
+	if err != nil {
+		return nil, err
+	}
+	merged.Annotations[ctrlcommon.SourceMachineConfigsAnnotationKey] = sources
 
 	return merged, nil
 }
 
+// sourceMachineConfig identifies a MachineConfig merged into a rendered one.
+type sourceMachineConfig struct {
+	Name       string `json:"name"`
+	Generation int64  `json:"generation"`
+}
+
+// sourceMachineConfigsAnnotation returns the value of the annotation listing
+// configs, in merge order, so that the rendered config tells which changes of
+// its sources made it.
+func sourceMachineConfigsAnnotation(configs []*mcfgv1.MachineConfig) (string, error) {
+	sources := make([]sourceMachineConfig, 0, len(configs))
+	for _, cfg := range configs {
+		sources = append(sources, sourceMachineConfig{Name: cfg.Name, Generation: sourceGeneration(cfg)})
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sourceGeneration returns the generation of a source config. The configs read
+// from disk at bootstrap have none yet, the API server sets it to 1 when they're
+// created, which is what the rendered config must tell for the installer and the
+// cluster to render it the same.
+func sourceGeneration(cfg *mcfgv1.MachineConfig) int64 {
+	if cfg.Generation == 0 {
+		return 1
+	}
+	return cfg.Generation
+}
+
+// getRenderedSource returns the references to the configs a pool's rendered
+// config is merged from, in merge order.
+func getRenderedSource(configs []*mcfgv1.MachineConfig) []corev1.ObjectReference {
+	configs = append([]*mcfgv1.MachineConfig{}, configs...)
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	source := []corev1.ObjectReference{}
+	for _, cfg := range configs {
+		source = append(source, corev1.ObjectReference{Kind: machineconfigKind.Kind, Name: cfg.GetName(), APIVersion: machineconfigKind.GroupVersion().String()})
+	}
+	return source
+}
+
 // RunBootstrap runs the render controller in bootstrap mode.
 // For each pool, it matches the machineconfigs based on label selector and
 // returns the generated machineconfigs and pool with CurrentMachineConfig status field set.
+// Pools and configs are returned sorted by name, rendered by the same code as in
+// the cluster, so that the installer writes what the cluster would create.
 func RunBootstrap(pools []*mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineConfig, cconfig *mcfgv1.ControllerConfig) ([]*mcfgv1.MachineConfigPool, []*mcfgv1.MachineConfig, error) {
 	var (
 		opools   []*mcfgv1.MachineConfigPool
 		oconfigs []*mcfgv1.MachineConfig
 	)
+	pools = append([]*mcfgv1.MachineConfigPool{}, pools...)
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
 	for _, pool := range pools {
 		pcs, err := getMachineConfigsForPool(pool, configs)
 		if err != nil {
@@ -569,8 +698,12 @@ func RunBootstrap(pools []*mcfgv1.MachineConfigPool, configs []*mcfgv1.MachineCo
 			return nil, nil, err
 		}
 
+		// the pool targets its rendered config as it would in the cluster
+		source := getRenderedSource(pcs)
 		pool.Spec.Configuration.Name = generated.Name
+		pool.Spec.Configuration.Source = source
 		pool.Status.Configuration.Name = generated.Name
+		pool.Status.Configuration.Source = source
 		opools = append(opools, pool)
 		oconfigs = append(oconfigs, generated)
 	}
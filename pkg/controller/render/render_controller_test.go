@@ -1,9 +1,11 @@
 package render
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,6 +27,7 @@ import (
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -63,7 +68,7 @@ func (f *fixture) newController() *Controller {
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
 
 	c := New(i.Machineconfiguration().V1().MachineConfigPools(), i.Machineconfiguration().V1().MachineConfigs(),
-		i.Machineconfiguration().V1().ControllerConfigs(), k8sfake.NewSimpleClientset(), f.client)
+		i.Machineconfiguration().V1().ControllerConfigs(), k8sfake.NewSimpleClientset(), f.client, workqueue.DefaultControllerRateLimiter())
 
 	c.mcpListerSynced = alwaysReady
 	c.mcListerSynced = alwaysReady
@@ -308,6 +313,56 @@ func TestIgnValidationGenerateRenderedMachineConfig(t *testing.T) {
 
 }
 
+func TestButaneGenerateRenderedMachineConfig(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-worker", helpers.WorkerSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-worker", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{}),
+		helpers.NewMachineConfig("05-butane", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{}),
+	}
+	mcs[1].Spec.Butane = "storage:\n  files:\n  - path: /etc/issue\n    contents:\n      inline: hello\n"
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	merged, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	ignCfg, _, err := ign.Parse(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 1)
+	assert.Equal(t, "/etc/issue", ignCfg.Storage.Files[0].Path)
+
+	// the pool is degraded with the name of the invalid MachineConfig
+	mcs[1].Spec.Butane = "storage: ["
+	_, err = generateRenderedMachineConfig(mcp, mcs, cc)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "MachineConfig 05-butane is invalid: invalid butane config")
+}
+
+func TestExtensionsGenerateRenderedMachineConfig(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-worker", helpers.WorkerSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-worker", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{}),
+		helpers.NewMachineConfig("05-extensions", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{}),
+		helpers.NewMachineConfig("06-more-extensions", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{}),
+	}
+	mcs[1].Spec.Extensions = []string{"usbguard", "kernel-devel"}
+	mcs[2].Spec.Extensions = []string{"usbguard"}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	merged, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"kernel-devel", "usbguard"}, merged.Spec.Extensions)
+
+	// unknown extensions are rejected
+	mcs[2].Spec.Extensions = []string{"not-an-extension"}
+	_, err = generateRenderedMachineConfig(mcp, mcs, cc)
+	require.NotNil(t, err)
+
+	// kernel-devel conflicts with the realtime kernel set by another MC
+	mcs[2].Spec.Extensions = nil
+	mcs[2].Spec.KernelType = ctrlcommon.KernelTypeRealtime
+	_, err = generateRenderedMachineConfig(mcp, mcs, cc)
+	require.NotNil(t, err)
+}
+
 func TestUpdatesGeneratedMachineConfig(t *testing.T) {
 	f := newFixture(t)
 	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
@@ -374,6 +429,106 @@ func TestGenerateMachineConfigNoOverrideOSImageURL(t *testing.T) {
 	assert.Equal(t, "dummy", gmc.Spec.OSImageURL)
 }
 
+func TestGenerateMachineConfigSourceAnnotation(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("99-master-ssh", map[string]string{"node-role/master": ""}, "", []igntypes.File{}),
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "", []igntypes.File{}),
+	}
+	mcs[0].Generation = 3
+	mcs[1].Generation = 1
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `[{"name":"00-test-cluster-master","generation":1},{"name":"99-master-ssh","generation":3}]`,
+		gmc.Annotations[ctrlcommon.SourceMachineConfigsAnnotationKey])
+
+	// new generations of the sources don't change the rendered config, only its annotation
+	mcs[0].Generation = 4
+	gmc2, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, gmc.Name, gmc2.Name)
+	assert.Equal(t, `[{"name":"00-test-cluster-master","generation":1},{"name":"99-master-ssh","generation":4}]`,
+		gmc2.Annotations[ctrlcommon.SourceMachineConfigsAnnotationKey])
+}
+
+func TestGenerateMachineConfigPoolOSImageURL(t *testing.T) {
+	const hotfix = "quay.io/openshift/os@sha256:hotfix"
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy-test-1", []igntypes.File{}),
+	}
+
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	require.Nil(t, err)
+	var keyring bytes.Buffer
+	w, err := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	require.Nil(t, err)
+	require.Nil(t, entity.Serialize(w))
+	require.Nil(t, w.Close())
+	sign := func(images string) string {
+		var sig bytes.Buffer
+		require.Nil(t, openpgp.ArmoredDetachSignText(&sig, entity, strings.NewReader(images), nil))
+		return sig.String()
+	}
+	images := "# hotfixes\n" + hotfix + "\n"
+
+	tests := []struct {
+		name      string
+		override  string
+		allowList *mcfgv1.OSImageAllowList
+		want      string
+		wantErr   bool
+	}{{
+		name: "no override",
+		want: "dummy",
+	}, {
+		name:      "allowed override",
+		override:  hotfix,
+		allowList: &mcfgv1.OSImageAllowList{Images: images, Signature: sign(images), Keyring: keyring.String()},
+		want:      hotfix,
+	}, {
+		name:     "no allow list",
+		override: hotfix,
+		wantErr:  true,
+	}, {
+		name:      "not pinned by digest",
+		override:  "quay.io/openshift/os:hotfix",
+		allowList: &mcfgv1.OSImageAllowList{Images: images, Signature: sign(images), Keyring: keyring.String()},
+		wantErr:   true,
+	}, {
+		name:      "not allowed",
+		override:  "quay.io/openshift/os@sha256:other",
+		allowList: &mcfgv1.OSImageAllowList{Images: images, Signature: sign(images), Keyring: keyring.String()},
+		wantErr:   true,
+	}, {
+		name:      "tampered allow list",
+		override:  "quay.io/openshift/os@sha256:other",
+		allowList: &mcfgv1.OSImageAllowList{Images: images + "quay.io/openshift/os@sha256:other\n", Signature: sign(images), Keyring: keyring.String()},
+		wantErr:   true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+			mcp.Spec.OSImageURL = tc.override
+			cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+			cc.Spec.OSImageAllowList = tc.allowList
+
+			gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tc.want, gmc.Spec.OSImageURL)
+		})
+	}
+}
+
 func TestDoNothing(t *testing.T) {
 	f := newFixture(t)
 	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
@@ -417,6 +572,101 @@ func TestDoNothing(t *testing.T) {
 	f.run(getKey(mcp, t))
 }
 
+func TestSkipsUnchangedRender(t *testing.T) {
+	f := newFixture(t)
+	mcp := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-master", map[string]string{"node-role/master": ""}, "dummy://", []igntypes.File{{Node: igntypes.Node{Filesystem: "root", Path: "/dummy/0"}}}),
+	}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	gmc, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	mcp.Spec.Configuration.Name = gmc.Name
+	mcp.Status.Configuration.Name = gmc.Name
+
+	f.ccLister = append(f.ccLister, cc)
+	f.mcpLister = append(f.mcpLister, mcp)
+	f.objects = append(f.objects, mcp, mcs[0], gmc)
+	f.mcLister = append(f.mcLister, mcs[0], gmc)
+
+	c := f.newController()
+	require.Nil(t, c.syncHandler(getKey(mcp, t)))
+	assert.Len(t, filterInformerActions(f.client.Actions()), 1)
+
+	// nothing changed, nothing is rendered
+	f.client.ClearActions()
+	require.Nil(t, c.syncHandler(getKey(mcp, t)))
+	assert.Empty(t, filterInformerActions(f.client.Actions()))
+
+	// a new generation of a source is rendered again
+	inputHash, err := getRenderInputHash(mcp, mcs, cc)
+	require.Nil(t, err)
+	mcs[0].Generation++
+	newHash, err := getRenderInputHash(mcp, mcs, cc)
+	require.Nil(t, err)
+	assert.NotEqual(t, inputHash, newHash)
+	assert.False(t, c.isRenderUpToDate(mcp, newHash))
+	assert.True(t, c.isRenderUpToDate(mcp, inputHash))
+}
+
+func TestRunBootstrapMatchesCluster(t *testing.T) {
+	worker := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, nil, "")
+	master := helpers.NewMachineConfigPool("master", helpers.MasterSelector, nil, "")
+	newInputs := func() ([]*mcfgv1.MachineConfigPool, []*mcfgv1.MachineConfig) {
+		pools := []*mcfgv1.MachineConfigPool{worker.DeepCopy(), master.DeepCopy()}
+		mcs := []*mcfgv1.MachineConfig{
+			helpers.NewMachineConfig("99-master-ssh", map[string]string{"node-role/master": ""}, "", []igntypes.File{{Node: igntypes.Node{Filesystem: "root", Path: "/dummy/1"}}}),
+			helpers.NewMachineConfig("00-worker", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{{Node: igntypes.Node{Filesystem: "root", Path: "/dummy/2"}}}),
+			helpers.NewMachineConfig("00-master", map[string]string{"node-role/master": ""}, "dummy://", []igntypes.File{{Node: igntypes.Node{Filesystem: "root", Path: "/dummy/0"}}}),
+		}
+		return pools, mcs
+	}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	pools, mcs := newInputs()
+	bpools, bconfigs, err := RunBootstrap(pools, mcs, cc)
+	require.Nil(t, err)
+	require.Len(t, bpools, 2)
+	require.Len(t, bconfigs, 2)
+	assert.Equal(t, "master", bpools[0].Name)
+	assert.Equal(t, "worker", bpools[1].Name)
+
+	// the output doesn't depend on the order of the inputs
+	pools, mcs = newInputs()
+	pools[0], pools[1] = pools[1], pools[0]
+	mcs[0], mcs[2] = mcs[2], mcs[0]
+	bpools2, bconfigs2, err := RunBootstrap(pools, mcs, cc)
+	require.Nil(t, err)
+	assert.Equal(t, bpools, bpools2)
+	assert.Equal(t, bconfigs, bconfigs2)
+
+	// the cluster renders the same once the manifests are created
+	for i, pool := range bpools {
+		f := newFixture(t)
+		pool, mcs := pool.DeepCopy(), []*mcfgv1.MachineConfig{}
+		pool.Spec.Configuration = mcfgv1.MachineConfigPoolStatusConfiguration{}
+		pool.Status.Configuration = mcfgv1.MachineConfigPoolStatusConfiguration{}
+		_, inputs := newInputs()
+		for _, mc := range inputs {
+			mc.Generation = 1
+			mcs = append(mcs, mc)
+			f.objects = append(f.objects, mc)
+		}
+		f.ccLister = append(f.ccLister, cc)
+		f.mcpLister = append(f.mcpLister, pool)
+		f.mcLister = append(f.mcLister, mcs...)
+		f.objects = append(f.objects, pool)
+
+		c := f.newController()
+		require.Nil(t, c.syncHandler(getKey(pool, t)))
+		actions := filterInformerActions(f.client.Actions())
+		require.Len(t, actions, 2)
+		assert.Equal(t, bconfigs[i], actions[0].(core.CreateAction).GetObject())
+		assert.Equal(t, bpools[i].Spec.Configuration, actions[1].(core.UpdateAction).GetObject().(*mcfgv1.MachineConfigPool).Spec.Configuration)
+	}
+}
+
 func TestGetMachineConfigsForPool(t *testing.T) {
 	masterPool := helpers.NewMachineConfigPool("test-cluster-master", helpers.MasterSelector, nil, "")
 	files := []igntypes.File{{
@@ -480,3 +730,68 @@ func TestMachineConfigsNoBailWithoutPool(t *testing.T) {
 	c.deleteMachineConfig(mc)
 	require.Len(t, queue, 3)
 }
+
+func TestFileConflictsGenerateRenderedMachineConfig(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-worker", helpers.WorkerSelector, nil, "")
+	newFile := func(contents string) igntypes.File {
+		return igntypes.File{
+			Node:          igntypes.Node{Filesystem: "root", Path: "/etc/foo.conf"},
+			FileEmbedded1: igntypes.FileEmbedded1{Contents: igntypes.FileContents{Source: "data:," + contents}},
+		}
+	}
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("00-test-cluster-worker", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{newFile("default")}),
+		helpers.NewMachineConfig("60-foo", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{newFile("one")}),
+		helpers.NewMachineConfig("50-foo", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{newFile("two")}),
+	}
+	// the default comes from the templates
+	mcs[0].Annotations = map[string]string{ctrlcommon.GeneratedByControllerVersionAnnotationKey: "v0"}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	_, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "/etc/foo.conf")
+	assert.Contains(t, err.Error(), "by MachineConfigs 50-foo and 60-foo")
+
+	// the annotation has to be on the config that wins
+	mcs[2].Annotations = map[string]string{ctrlcommon.AllowFileOverrideAnnotationKey: "true"}
+	_, err = generateRenderedMachineConfig(mcp, mcs, cc)
+	require.NotNil(t, err)
+
+	mcs[1].Annotations = map[string]string{ctrlcommon.AllowFileOverrideAnnotationKey: "true"}
+	merged, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+	ignCfg, _, err := ign.Parse(merged.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 3)
+	assert.Equal(t, "data:,one", ignCfg.Storage.Files[2].Contents.Source)
+
+	// identical contents don't conflict
+	mcs[1] = helpers.NewMachineConfig("60-foo", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{newFile("two")})
+	_, err = generateRenderedMachineConfig(mcp, mcs, cc)
+	require.Nil(t, err)
+}
+
+func TestFileConflictsConfigSnippets(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("test-cluster-worker", helpers.WorkerSelector, nil, "")
+	newFile := func(contents string) igntypes.File {
+		return igntypes.File{
+			Node:          igntypes.Node{Filesystem: "root", Path: "/etc/foo.conf"},
+			FileEmbedded1: igntypes.FileEmbedded1{Contents: igntypes.FileContents{Source: "data:," + contents}},
+		}
+	}
+	mcs := []*mcfgv1.MachineConfig{
+		helpers.NewMachineConfig("50-foo", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{newFile("one")}),
+		helpers.NewMachineConfig("99-test-cluster-worker-config-snippets", map[string]string{"node-role/worker": ""}, "dummy://", []igntypes.File{newFile("two")}),
+	}
+	// the snippets config is generated by a controller, but holds user contents
+	mcs[1].Annotations = map[string]string{
+		ctrlcommon.GeneratedByControllerVersionAnnotationKey: "v0",
+		ctrlcommon.ConfigSnippetsAnnotationKey:               "foo-snippet",
+	}
+	cc := newControllerConfig(ctrlcommon.ControllerConfigName)
+
+	_, err := generateRenderedMachineConfig(mcp, mcs, cc)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "by MachineConfigs 50-foo and 99-test-cluster-worker-config-snippets")
+}
@@ -0,0 +1,149 @@
+package template
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+)
+
+// sprigFuncs are the sprig functions the templates can call. The others are
+// left out so that rendering only depends on the RenderConfig: no environment,
+// dates or random values end up in the MachineConfigs.
+var sprigFuncs = []string{
+	"b64dec", "b64enc", "contains", "default", "empty", "hasPrefix", "hasSuffix",
+	"indent", "join", "lower", "nindent", "quote", "replace", "splitList",
+	"squote", "toString", "trim", "trimPrefix", "trimSuffix", "upper",
+}
+
+// templateFuncs returns the functions the templates can call: the curated sprig
+// ones, the CIDR math ones, and the ones rendering parts of the RenderConfig.
+func templateFuncs() template.FuncMap {
+	all := sprig.TxtFuncMap()
+	funcs := template.FuncMap{}
+	for _, name := range sprigFuncs {
+		funcs[name] = all[name]
+	}
+	funcs["cidrHost"] = cidrHost
+	funcs["cidrNetmask"] = cidrNetmask
+	funcs["cidrPrefixLen"] = cidrPrefixLen
+	funcs["cidrSubnet"] = cidrSubnet
+	funcs["cidrContains"] = cidrContains
+	funcs["skip"] = skipMissing
+	funcs["etcdServerCertDNSNames"] = etcdServerCertDNSNames
+	funcs["etcdPeerCertDNSNames"] = etcdPeerCertDNSNames
+	funcs["etcdServerCertCommand"] = etcdServerCertCommand
+	funcs["etcdPeerCertCommand"] = etcdPeerCertCommand
+	funcs["etcdMetricCertCommand"] = etcdMetricCertCommand
+	funcs["cloudProvider"] = cloudProvider
+	funcs["cloudConfigFlag"] = cloudConfigFlag
+	funcs["containerRuntimeUnit"] = containerRuntimeUnit
+	funcs["containerRuntimeEndpoint"] = containerRuntimeEndpoint
+	return funcs
+}
+
+func parseCIDR(cidr string) (*net.IPNet, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if ip4 := network.IP.To4(); ip4 != nil {
+		network.IP = ip4
+	}
+	return network, nil
+}
+
+// addToIP returns the address n after ip, which must fit in the family of ip.
+func addToIP(ip net.IP, n *big.Int) (net.IP, error) {
+	sum := new(big.Int).Add(new(big.Int).SetBytes(ip), n)
+	if sum.Sign() < 0 || sum.BitLen() > len(ip)*8 {
+		return nil, fmt.Errorf("address out of range")
+	}
+	out := make(net.IP, len(ip))
+	b := sum.Bytes()
+	copy(out[len(out)-len(b):], b)
+	return out, nil
+}
+
+// cidrHost returns the address of the hostnum-th host of cidr, e.g.
+// {{cidrHost "10.0.0.0/24" 5}} is 10.0.0.5. A negative hostnum counts from
+// the end of the network, -1 being its last address.
+func cidrHost(cidr string, hostnum int) (string, error) {
+	network, err := parseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	ones, bits := network.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	n := big.NewInt(int64(hostnum))
+	if n.Sign() < 0 {
+		n.Add(n, size)
+	}
+	if n.Sign() < 0 || n.Cmp(size) >= 0 {
+		return "", fmt.Errorf("cidrHost: host %d is out of %s", hostnum, cidr)
+	}
+	ip, err := addToIP(network.IP, n)
+	if err != nil {
+		return "", fmt.Errorf("cidrHost: %v", err)
+	}
+	return ip.String(), nil
+}
+
+// cidrNetmask returns the dotted netmask of an IPv4 cidr, e.g. 255.255.255.0.
+func cidrNetmask(cidr string) (string, error) {
+	network, err := parseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	if len(network.IP) != net.IPv4len {
+		return "", fmt.Errorf("cidrNetmask: %s isn't an IPv4 network", cidr)
+	}
+	return net.IP(network.Mask).String(), nil
+}
+
+// cidrPrefixLen returns the prefix length of cidr, e.g. 24.
+func cidrPrefixLen(cidr string) (int, error) {
+	network, err := parseCIDR(cidr)
+	if err != nil {
+		return 0, err
+	}
+	ones, _ := network.Mask.Size()
+	return ones, nil
+}
+
+// cidrSubnet returns the netnum-th subnet of cidr whose prefix is newbits
+// longer, e.g. {{cidrSubnet "10.0.0.0/16" 8 2}} is 10.0.2.0/24.
+func cidrSubnet(cidr string, newbits, netnum int) (string, error) {
+	network, err := parseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	ones, bits := network.Mask.Size()
+	if newbits < 0 || ones+newbits > bits {
+		return "", fmt.Errorf("cidrSubnet: can't extend the prefix of %s by %d bits", cidr, newbits)
+	}
+	if netnum < 0 || big.NewInt(int64(netnum)).Cmp(new(big.Int).Lsh(big.NewInt(1), uint(newbits))) >= 0 {
+		return "", fmt.Errorf("cidrSubnet: %s has no subnet %d of %d more bits", cidr, netnum, newbits)
+	}
+	offset := new(big.Int).Lsh(big.NewInt(int64(netnum)), uint(bits-ones-newbits))
+	ip, err := addToIP(network.IP, offset)
+	if err != nil {
+		return "", fmt.Errorf("cidrSubnet: %v", err)
+	}
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(ones+newbits, bits)}).String(), nil
+}
+
+// cidrContains returns whether the address ip is in cidr.
+func cidrContains(cidr, ip string) (bool, error) {
+	network, err := parseCIDR(cidr)
+	if err != nil {
+		return false, err
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false, fmt.Errorf("cidrContains: invalid IP address %q", ip)
+	}
+	return network.Contains(addr), nil
+}
@@ -0,0 +1,91 @@
+package template
+
+import (
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIDRFuncs(t *testing.T) {
+	cases := []struct {
+		tmpl string
+		res  string
+		err  bool
+	}{{
+		tmpl: `{{cidrHost "10.0.0.0/24" 5}}`,
+		res:  "10.0.0.5",
+	}, {
+		tmpl: `{{cidrHost "10.0.0.0/24" -2}}`,
+		res:  "10.0.0.254",
+	}, {
+		tmpl: `{{cidrHost "fd00::/64" 10}}`,
+		res:  "fd00::a",
+	}, {
+		tmpl: `{{cidrHost "10.0.0.0/24" 256}}`,
+		err:  true,
+	}, {
+		tmpl: `{{cidrNetmask "10.0.0.0/20"}}`,
+		res:  "255.255.240.0",
+	}, {
+		tmpl: `{{cidrNetmask "fd00::/64"}}`,
+		err:  true,
+	}, {
+		tmpl: `{{cidrPrefixLen "192.168.1.0/26"}}`,
+		res:  "26",
+	}, {
+		tmpl: `{{cidrSubnet "10.0.0.0/16" 8 2}}`,
+		res:  "10.0.2.0/24",
+	}, {
+		tmpl: `{{cidrSubnet "fd00::/48" 16 3}}`,
+		res:  "fd00:0:0:3::/64",
+	}, {
+		tmpl: `{{cidrSubnet "10.0.0.0/16" 8 256}}`,
+		err:  true,
+	}, {
+		tmpl: `{{cidrContains "10.0.0.0/16" "10.0.5.1"}}`,
+		res:  "true",
+	}, {
+		tmpl: `{{cidrContains "10.0.0.0/16" "10.1.0.1"}}`,
+		res:  "false",
+	}, {
+		tmpl: `{{cidrPrefixLen "not-a-cidr"}}`,
+		err:  true,
+	}}
+	for _, c := range cases {
+		t.Run(c.tmpl, func(t *testing.T) {
+			got, err := renderTemplate(RenderConfig{}, "dummy", []byte(c.tmpl))
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.res, string(got))
+		})
+	}
+}
+
+func TestSprigFuncs(t *testing.T) {
+	got, err := renderTemplate(RenderConfig{}, "dummy", []byte(`{{"hello" | b64enc}} {{"a: b" | indent 2}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "aGVsbG8=   a: b", string(got))
+
+	// sprig functions depending on the environment aren't available
+	_, err = renderTemplate(RenderConfig{}, "dummy", []byte(`{{env "HOME"}}`))
+	assert.Error(t, err)
+}
+
+func TestRenderTemplateMissingKey(t *testing.T) {
+	config := RenderConfig{
+		ControllerConfigSpec: &mcfgv1.ControllerConfigSpec{
+			Images: map[string]string{InfraImageKey: "image/infraImage:1"},
+		},
+	}
+	tmpl := []byte("image: {{.Images.infraImageKey}}\nother: {{.Images.doesNotExist}}\n")
+
+	_, err := renderTemplate(config, "master/00-master/_base/files/dummy.yaml", tmpl)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "master/00-master/_base/files/dummy.yaml:2")
+	assert.Contains(t, err.Error(), `map has no entry for key "doesNotExist"`)
+}
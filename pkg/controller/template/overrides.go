@@ -0,0 +1,55 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// templateOverridePath matches the paths of the templates which can be
+// overridden: the files and units templates of the roles and the common ones.
+var templateOverridePath = regexp.MustCompile(`^(common|master|worker)/([^/]+/)+(files|units)/[^/]+\.yaml$`)
+
+// ValidateTemplateOverridePath returns an error if the template at path, relative
+// to the templates directory, can't be overridden.
+func ValidateTemplateOverridePath(path string) error {
+	if filepath.Clean(path) != path || !templateOverridePath.MatchString(path) {
+		return fmt.Errorf("%q isn't the path of a files or units template, e.g. master/00-master/_base/units/kubelet.yaml", path)
+	}
+	return nil
+}
+
+// validateTemplateOverrides returns an error if an override of config doesn't
+// replace one of the templates of templateDir, so that a mistyped path isn't
+// silently ignored.
+func validateTemplateOverrides(config *RenderConfig, templateDir string) error {
+	paths := []string{}
+	for path := range config.TemplateOverrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := ValidateTemplateOverridePath(path); err != nil {
+			return fmt.Errorf("invalid template override: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(templateDir, path)); err != nil {
+			return fmt.Errorf("invalid template override: %v", err)
+		}
+	}
+	return nil
+}
+
+// templateOverride returns the override of the template at path, if any.
+func templateOverride(config *RenderConfig, templateDir, path string) (string, bool) {
+	if len(config.TemplateOverrides) == 0 {
+		return "", false
+	}
+	rel, err := filepath.Rel(templateDir, path)
+	if err != nil {
+		return "", false
+	}
+	data, ok := config.TemplateOverrides[filepath.ToSlash(rel)]
+	return data, ok
+}
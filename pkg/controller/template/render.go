@@ -12,7 +12,6 @@ import (
 	"strings"
 	"text/template"
 
-	"github.com/Masterminds/sprig"
 	ctconfig "github.com/coreos/container-linux-config-transpiler/config"
 	cttypes "github.com/coreos/container-linux-config-transpiler/config/types"
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
@@ -29,6 +28,9 @@ import (
 type RenderConfig struct {
 	*mcfgv1.ControllerConfigSpec
 	PullSecret string
+	// ContainerRuntime is the container runtime the templates are rendered for,
+	// the default one when empty.
+	ContainerRuntime mcfgv1.ContainerRuntimeName
 }
 
 const (
@@ -46,6 +48,9 @@ const (
 	platformVSphere   = "vsphere"
 	platformBase      = "_base"
 	platformOvirt     = "ovirt"
+
+	// nodeDNSDir holds the common templates of the clusters setting nodeDNS.
+	nodeDNSDir = "dns"
 )
 
 // generateTemplateMachineConfigs returns MachineConfig objects from the templateDir and a config object
@@ -108,6 +113,10 @@ func GenerateMachineConfigsForRole(config *RenderConfig, role, templateDir strin
 		rolePath = "worker"
 	}
 
+	if err := validateTemplateOverrides(config, templateDir); err != nil {
+		return nil, err
+	}
+
 	path := filepath.Join(templateDir, rolePath)
 	infos, err := ioutil.ReadDir(path)
 	if err != nil {
@@ -136,7 +145,11 @@ func GenerateMachineConfigsForRole(config *RenderConfig, role, templateDir strin
 	return cfgs, nil
 }
 
-func platformFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec) (string, error) {
+// platformFromControllerConfigSpec returns the platform whose templates are
+// rendered for ic. Platforms not known here are supported as soon as templates
+// are added for them in templateDir, so that enabling a platform only takes
+// adding its template fragments.
+func platformFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec, templateDir string) (string, error) {
 	switch ic.Platform {
 	case "":
 		// if Platform is nil, return nil platform and an error message
@@ -146,6 +159,13 @@ func platformFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec) (string,
 	case platformAWS, platformAzure, platformBaremetal, platformGCP, platformOpenStack, platformLibvirt, platformOvirt, platformVSphere, platformNone:
 		return ic.Platform, nil
 	default:
+		hasTemplates, err := hasPlatformTemplates(templateDir, ic.Platform)
+		if err != nil {
+			return "", err
+		}
+		if hasTemplates {
+			return ic.Platform, nil
+		}
 		// platformNone is used for a non-empty, but currently unsupported platform.
 		// This allows us to incrementally roll out new platforms across the project
 		// by provisioning platforms before all support is added.
@@ -154,7 +174,31 @@ func platformFromControllerConfigSpec(ic *mcfgv1.ControllerConfigSpec) (string,
 	}
 }
 
-func filterTemplates(toFilter map[string]string, path string, config *RenderConfig) error {
+// hasPlatformTemplates returns whether templateDir has templates for platform,
+// either common ones or ones of a role.
+func hasPlatformTemplates(templateDir, platform string) (bool, error) {
+	for _, pattern := range []string{
+		filepath.Join(templateDir, "common", platform),
+		filepath.Join(templateDir, "*", "*", platform),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return false, err
+		}
+		for _, m := range matches {
+			exists, err := existsDir(m)
+			if err != nil {
+				return false, err
+			}
+			if exists {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func filterTemplates(toFilter map[string]string, path, templateDir string, config *RenderConfig) error {
 	walkFn := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -163,19 +207,29 @@ func filterTemplates(toFilter map[string]string, path string, config *RenderConf
 			return nil
 		}
 
+		var filedata []byte
+		if override, ok := templateOverride(config, templateDir, path); ok {
+			glog.Warningf("Rendering template %s from its override", path)
+			filedata = []byte(override)
+		} else {
+			filedata, err = ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read file %q: %v", path, err)
+			}
+		}
+
 		// empty templates signify don't create
-		if info.Size() == 0 {
+		if len(filedata) == 0 {
 			delete(toFilter, info.Name())
 			return nil
 		}
 
-		filedata, err := ioutil.ReadFile(path)
+		// Render the template file, named after its path under templateDir in errors
+		name, err := filepath.Rel(templateDir, path)
 		if err != nil {
-			return fmt.Errorf("failed to read file %q: %v", path, err)
+			name = path
 		}
-
-		// Render the template file
-		renderedData, err := renderTemplate(*config, path, filedata)
+		renderedData, err := renderTemplate(*config, name, filedata)
 		if err != nil {
 			return err
 		}
@@ -187,15 +241,21 @@ func filterTemplates(toFilter map[string]string, path string, config *RenderConf
 }
 
 func generateMachineConfigForName(config *RenderConfig, role, name, templateDir, path string, commonAdded *bool) (*mcfgv1.MachineConfig, error) {
-	platform, err := platformFromControllerConfigSpec(config.ControllerConfigSpec)
+	platform, err := platformFromControllerConfigSpec(config.ControllerConfigSpec, templateDir)
 	if err != nil {
 		return nil, err
 	}
 
 	platformDirs := []string{}
-	if !*commonAdded {
+	withCommon := !*commonAdded
+	if withCommon {
+		commonDirs := []string{platformBase, platform}
+		if config.NodeDNS != nil {
+			// the NetworkManager global DNS configuration
+			commonDirs = append(commonDirs, nodeDNSDir)
+		}
 		// Loop over templates/common which applies everywhere
-		for _, dir := range []string{platformBase, platform} {
+		for _, dir := range commonDirs {
 			basePath := filepath.Join(templateDir, "common", dir)
 			exists, err := existsDir(basePath)
 			if err != nil {
@@ -208,9 +268,9 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 		}
 		*commonAdded = true
 	}
-	// And now over the target e.g. templates/master/00-master,01-master-container-runtime,01-master-kubelet
-	for _, dir := range []string{platformBase, platform} {
-		platformPath := filepath.Join(path, dir)
+	// And now over the target e.g. templates/master/00-master,01-master-container-runtime,01-master-kubelet,
+	// then over the templates of the container runtime
+	for _, platformPath := range []string{filepath.Join(path, platformBase), filepath.Join(path, platform), runtimeTemplatesDir(*config, path)} {
 		exists, err := existsDir(platformPath)
 		if err != nil {
 			return nil, err
@@ -231,7 +291,7 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 			return nil, err
 		}
 		if exists {
-			if err := filterTemplates(files, p, config); err != nil {
+			if err := filterTemplates(files, p, templateDir, config); err != nil {
 				return nil, err
 			}
 		}
@@ -242,7 +302,7 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 			return nil, err
 		}
 		if exists {
-			if err := filterTemplates(units, p, config); err != nil {
+			if err := filterTemplates(units, p, templateDir, config); err != nil {
 				return nil, err
 			}
 		}
@@ -275,6 +335,13 @@ func generateMachineConfigForName(config *RenderConfig, role, name, templateDir,
 	}
 	// And inject the osimageurl here
 	mcfg.Spec.OSImageURL = config.OSImageURL
+	// FIPS mode goes with the common templates, so that it's set once per role.
+	// The installer's 99-<role>-fips MachineConfigs set it too, and the OS
+	// enables it in the kernel and the crypto policies on its first boot, so
+	// the flag is all there is to render.
+	if withCommon && config.FIPS {
+		mcfg.Spec.FIPS = true
+	}
 
 	return mcfg, nil
 }
@@ -335,18 +402,11 @@ func transpileToIgn(files, units []string) (*igntypes.Config, error) {
 }
 
 // renderTemplate renders a template file with values from a RenderConfig
-// returns the rendered file data
+// returns the rendered file data. A key missing from a map fails the rendering,
+// instead of rendering an empty string, and the error has the path and line
+// of the template.
 func renderTemplate(config RenderConfig, path string, b []byte) ([]byte, error) {
-	funcs := sprig.TxtFuncMap()
-	funcs["skip"] = skipMissing
-	funcs["etcdServerCertDNSNames"] = etcdServerCertDNSNames
-	funcs["etcdPeerCertDNSNames"] = etcdPeerCertDNSNames
-	funcs["etcdServerCertCommand"] = etcdServerCertCommand
-	funcs["etcdPeerCertCommand"] = etcdPeerCertCommand
-	funcs["etcdMetricCertCommand"] = etcdMetricCertCommand
-	funcs["cloudProvider"] = cloudProvider
-	funcs["cloudConfigFlag"] = cloudConfigFlag
-	tmpl, err := template.New(path).Funcs(funcs).Parse(string(b))
+	tmpl, err := template.New(path).Option("missingkey=error").Funcs(templateFuncs()).Parse(string(b))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template %s: %v", path, err)
 	}
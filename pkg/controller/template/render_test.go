@@ -7,11 +7,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	ign "github.com/coreos/ignition/config/v2_2"
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/vincent-petithory/dataurl"
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
@@ -56,7 +58,7 @@ func TestCloudProvider(t *testing.T) {
 					Platform: c.platform,
 				},
 			}
-			got, err := renderTemplate(RenderConfig{&config.Spec, `{"dummy":"dummy"}`}, name, dummyTemplate)
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`}, name, dummyTemplate)
 			if err != nil {
 				t.Fatalf("expected nil error %v", err)
 			}
@@ -68,6 +70,43 @@ func TestCloudProvider(t *testing.T) {
 	}
 }
 
+func TestContainerRuntime(t *testing.T) {
+	dummyTemplate := []byte(`{{containerRuntimeUnit .}} {{containerRuntimeEndpoint .}}`)
+
+	cases := []struct {
+		runtime mcfgv1.ContainerRuntimeName
+		res     string
+	}{{
+		runtime: "",
+		res:     "crio.service /var/run/crio/crio.sock",
+	}, {
+		runtime: mcfgv1.ContainerRuntimeCRIO,
+		res:     "crio.service /var/run/crio/crio.sock",
+	}}
+	for idx, c := range cases {
+		name := fmt.Sprintf("case #%d", idx)
+		t.Run(name, func(t *testing.T) {
+			config := &mcfgv1.ControllerConfig{
+				Spec: mcfgv1.ControllerConfigSpec{
+					Platform: "aws",
+				},
+			}
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`, ContainerRuntime: c.runtime}, name, dummyTemplate)
+			if err != nil {
+				t.Fatalf("expected nil error %v", err)
+			}
+
+			if string(got) != c.res {
+				t.Fatalf("mismatch got: %s want: %s", got, c.res)
+			}
+		})
+	}
+
+	if err := ValidateContainerRuntime("docker"); err == nil {
+		t.Fatalf("expected error for an unsupported runtime")
+	}
+}
+
 func TestCloudConfigFlag(t *testing.T) {
 	dummyTemplate := []byte(`{{cloudConfigFlag .}}`)
 
@@ -118,7 +157,7 @@ func TestCloudConfigFlag(t *testing.T) {
 					CloudProviderConfig: c.content,
 				},
 			}
-			got, err := renderTemplate(RenderConfig{&config.Spec, `{"dummy":"dummy"}`}, name, dummyTemplate)
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`}, name, dummyTemplate)
 			if err != nil {
 				t.Fatalf("expected nil error %v", err)
 			}
@@ -155,7 +194,7 @@ func TestEtcdPeerCertDNSNames(t *testing.T) {
 					EtcdDiscoveryDomain: c.etcdDiscoveryDomain,
 				},
 			}
-			got, err := renderTemplate(RenderConfig{&config.Spec, `{"dummy":"dummy"}`}, name, dummyTemplate)
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`}, name, dummyTemplate)
 			if err != nil && !c.err {
 				t.Fatalf("expected nil error %v", err)
 			}
@@ -183,7 +222,7 @@ func TestEtcdServerCertDNSNames(t *testing.T) {
 			config := &mcfgv1.ControllerConfig{
 				Spec: mcfgv1.ControllerConfigSpec{},
 			}
-			got, err := renderTemplate(RenderConfig{&config.Spec, `{"dummy":"dummy"}`}, name, dummyTemplate)
+			got, err := renderTemplate(RenderConfig{ControllerConfigSpec: &config.Spec, PullSecret: `{"dummy":"dummy"}`}, name, dummyTemplate)
 			if err != nil && !c.err {
 				t.Fatalf("expected nil error %v", err)
 			}
@@ -275,17 +314,54 @@ func TestInvalidPlatform(t *testing.T) {
 
 	// we must treat unrecognized constants as "none"
 	controllerConfig.Spec.Platform = "_bad_"
-	_, err = generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`}, templateDir)
+	_, err = generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
 	if err != nil {
 		t.Errorf("expect nil error, got: %v", err)
 	}
 
 	// explicitly blocked
 	controllerConfig.Spec.Platform = "_base"
-	_, err = generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`}, templateDir)
+	_, err = generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
 	expectErr(err, "failed to create MachineConfig for role master: platform _base unsupported")
 }
 
+func TestPlatformTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, d := range []string{"common/_base/files", "worker/00-worker/_base/files", "worker/00-worker/newcloud/files"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		platform string
+		res      string
+	}{{
+		platform: "aws",
+		res:      "aws",
+	}, {
+		// not known, but has templates
+		platform: "newcloud",
+		res:      "newcloud",
+	}, {
+		platform: "othercloud",
+		res:      "none",
+	}}
+	for _, c := range cases {
+		got, err := platformFromControllerConfigSpec(&mcfgv1.ControllerConfigSpec{Platform: c.platform}, dir)
+		if err != nil {
+			t.Fatalf("expected nil error %v", err)
+		}
+		if got != c.res {
+			t.Errorf("mismatch got: %s want: %s", got, c.res)
+		}
+	}
+}
+
 func TestGenerateMachineConfigs(t *testing.T) {
 	for _, config := range configs {
 		controllerConfig, err := controllerConfigFromFile(config)
@@ -293,7 +369,7 @@ func TestGenerateMachineConfigs(t *testing.T) {
 			t.Fatalf("failed to get controllerconfig config: %v", err)
 		}
 
-		cfgs, err := generateTemplateMachineConfigs(&RenderConfig{&controllerConfig.Spec, `{"dummy":"dummy"}`}, templateDir)
+		cfgs, err := generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
 		if err != nil {
 			t.Fatalf("failed to generate machine configs: %v", err)
 		}
@@ -351,6 +427,139 @@ func TestGenerateMachineConfigs(t *testing.T) {
 	}
 }
 
+func TestGenerateMachineConfigsBaremetal(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["baremetal"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+
+	cfgs, err := generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+
+	// the VIPs are managed by static pods, and the node resolves the cluster names through its own coredns
+	common := []string{
+		"/etc/kubernetes/manifests/keepalived.yaml",
+		"/etc/kubernetes/manifests/coredns.yaml",
+		"/etc/kubernetes/manifests/mdns-publisher.yaml",
+		"/etc/kubernetes/static-pod-resources/keepalived/keepalived.conf.tmpl",
+		"/etc/NetworkManager/dispatcher.d/30-resolv-prepender",
+		"/etc/NetworkManager/dispatcher.d/40-mdns-hostname",
+	}
+	want := map[string][]string{
+		"master": append([]string{"/etc/kubernetes/manifests/haproxy.yaml"}, common...),
+		"worker": common,
+	}
+	found := map[string][]igntypes.File{}
+	for _, cfg := range cfgs {
+		ign, _, err := ign.Parse(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		role := cfg.Labels[mcfgv1.MachineConfigRoleLabelKey]
+		found[role] = append(found[role], ign.Storage.Files...)
+	}
+	for role, paths := range want {
+		for _, path := range paths {
+			if !findIgnFile(found[role], path, t) {
+				t.Errorf("failed to find %s for %s", path, role)
+			}
+		}
+		for _, f := range found[role] {
+			if f.Path != "/etc/kubernetes/manifests/keepalived.yaml" {
+				continue
+			}
+			contents, err := dataurl.DecodeString(f.Contents.Source)
+			if err != nil {
+				t.Fatalf("failed to decode %s: %v", f.Path, err)
+			}
+			for _, vip := range []string{"10.0.0.1", "10.0.0.2"} {
+				if !bytes.Contains(contents.Data, []byte(vip)) {
+					t.Errorf("keepalived static pod for %s doesn't manage VIP %s", role, vip)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateMachineConfigsFIPS(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	controllerConfig.Spec.FIPS = true
+
+	cfgs, err := generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+
+	// FIPS mode is set once per role, the OS enables it on its first boot
+	fips := map[string]int{}
+	for _, cfg := range cfgs {
+		if !cfg.Spec.FIPS {
+			continue
+		}
+		fips[cfg.Labels[mcfgv1.MachineConfigRoleLabelKey]]++
+		if len(cfg.Spec.KernelArguments) > 0 {
+			t.Errorf("%s has kernel arguments %v, want none", cfg.Name, cfg.Spec.KernelArguments)
+		}
+		ign, _, err := ign.Parse(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		if findIgnFile(ign.Storage.Files, "/etc/crypto-policies/config", t) {
+			t.Errorf("%s sets the crypto policy of the OS", cfg.Name)
+		}
+	}
+	if !reflect.DeepEqual(fips, map[string]int{"master": 1, "worker": 1}) {
+		t.Errorf("FIPS mode set by %v MachineConfigs per role, want one", fips)
+	}
+}
+
+func TestGenerateMachineConfigsNodeDNS(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	controllerConfig.Spec.NodeDNS = &mcfgv1.NodeDNS{
+		Nameservers: []string{"10.0.0.53", "10.0.1.53"},
+		Searches:    []string{"corp.example.com"},
+	}
+
+	cfgs, err := generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+
+	found := map[string]int{}
+	for _, cfg := range cfgs {
+		ign, _, err := ign.Parse(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		for _, f := range ign.Storage.Files {
+			if f.Path != "/etc/NetworkManager/conf.d/99-node-dns.conf" {
+				continue
+			}
+			found[cfg.Labels[mcfgv1.MachineConfigRoleLabelKey]]++
+			contents, err := dataurl.DecodeString(f.Contents.Source)
+			if err != nil {
+				t.Fatalf("failed to decode %s: %v", f.Path, err)
+			}
+			for _, line := range []string{"searches=corp.example.com\n", "servers=10.0.0.53,10.0.1.53\n"} {
+				if !bytes.Contains(contents.Data, []byte(line)) {
+					t.Errorf("%s of %s doesn't contain %q", f.Path, cfg.Name, line)
+				}
+			}
+		}
+	}
+	if !reflect.DeepEqual(found, map[string]int{"master": 1, "worker": 1}) {
+		t.Errorf("node DNS configuration rendered by %v MachineConfigs per role, want one", found)
+	}
+}
+
 func controllerConfigFromFile(path string) (*mcfgv1.ControllerConfig, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
@@ -427,3 +636,59 @@ func verifyIgn(actual [][]byte, dir string, t *testing.T) {
 		t.Errorf("can't find expected file:\n%v", key)
 	}
 }
+
+func TestGenerateMachineConfigsTemplateOverrides(t *testing.T) {
+	controllerConfig, err := controllerConfigFromFile(configs["aws"])
+	if err != nil {
+		t.Fatalf("failed to get controllerconfig config: %v", err)
+	}
+	controllerConfig.Spec.TemplateOverrides = map[string]string{
+		"common/_base/files/nm-ignore-sdn.yaml": `filesystem: "root"
+mode: 0644
+path: "/etc/NetworkManager/conf.d/sdn.conf"
+contents:
+  inline: |
+    # overridden
+`,
+	}
+
+	cfgs, err := generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir)
+	if err != nil {
+		t.Fatalf("failed to generate machine configs: %v", err)
+	}
+	overridden := 0
+	for _, cfg := range cfgs {
+		ign, _, err := ign.Parse(cfg.Spec.Config.Raw)
+		if err != nil {
+			t.Fatalf("failed to parse Ignition config: %v", err)
+		}
+		for _, f := range ign.Storage.Files {
+			if f.Path != "/etc/NetworkManager/conf.d/sdn.conf" {
+				continue
+			}
+			contents, err := dataurl.DecodeString(f.Contents.Source)
+			if err != nil {
+				t.Fatalf("failed to decode %s: %v", f.Path, err)
+			}
+			if string(contents.Data) != "# overridden\n" {
+				t.Errorf("%s of %s wasn't overridden: %q", f.Path, cfg.Name, contents.Data)
+			}
+			overridden++
+		}
+	}
+	if overridden != 2 {
+		t.Errorf("override rendered in %d MachineConfigs, want one per role", overridden)
+	}
+
+	for _, path := range []string{
+		"common/_base/files/missing.yaml",
+		"common/_base/files/../../../../etc/passwd",
+		"/common/_base/files/nm-ignore-sdn.yaml",
+		"master/00-master/_base/kubelet.yaml",
+	} {
+		controllerConfig.Spec.TemplateOverrides = map[string]string{path: ""}
+		if _, err := generateTemplateMachineConfigs(&RenderConfig{ControllerConfigSpec: &controllerConfig.Spec, PullSecret: `{"dummy":"dummy"}`}, templateDir); err == nil {
+			t.Errorf("override of %s didn't fail the render", path)
+		}
+	}
+}
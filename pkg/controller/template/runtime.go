@@ -0,0 +1,66 @@
+package template
+
+import (
+	"fmt"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+// containerRuntime is how the kubelet runs the containers with a runtime.
+type containerRuntime struct {
+	// unit is the systemd service of the runtime.
+	unit string
+	// endpoint is the CRI socket the kubelet connects to.
+	endpoint string
+}
+
+// containerRuntimes are the supported container runtimes. Adding one takes an
+// entry here, and its templates in the runtime-<name> directories of the
+// templates, e.g. templates/worker/01-worker-container-runtime/runtime-<name>,
+// which are rendered after the platform ones. Empty files there drop the
+// templates of the default runtime.
+var containerRuntimes = map[mcfgv1.ContainerRuntimeName]containerRuntime{
+	mcfgv1.ContainerRuntimeCRIO: {unit: "crio.service", endpoint: "/var/run/crio/crio.sock"},
+}
+
+// ValidateContainerRuntime returns an error if name isn't a supported runtime.
+// The empty name is the default runtime.
+func ValidateContainerRuntime(name mcfgv1.ContainerRuntimeName) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := containerRuntimes[name]; !ok {
+		return fmt.Errorf("unsupported container runtime %q, must be %s", name, mcfgv1.ContainerRuntimeCRIO)
+	}
+	return nil
+}
+
+// getContainerRuntime returns the name and the description of the container
+// runtime of cfg, the default one when it isn't set or supported.
+func getContainerRuntime(cfg RenderConfig) (mcfgv1.ContainerRuntimeName, containerRuntime) {
+	if runtime, ok := containerRuntimes[cfg.ContainerRuntime]; ok {
+		return cfg.ContainerRuntime, runtime
+	}
+	return mcfgv1.ContainerRuntimeCRIO, containerRuntimes[mcfgv1.ContainerRuntimeCRIO]
+}
+
+// runtimeTemplatesDir returns the directory of the templates of the container
+// runtime of cfg, in the directory path of a MachineConfig.
+func runtimeTemplatesDir(cfg RenderConfig, path string) string {
+	name, _ := getContainerRuntime(cfg)
+	return path + "/runtime-" + string(name)
+}
+
+// Process the {{containerRuntimeUnit .}}
+// returns the systemd service of the container runtime the kubelet depends on.
+func containerRuntimeUnit(cfg RenderConfig) interface{} {
+	_, runtime := getContainerRuntime(cfg)
+	return runtime.unit
+}
+
+// Process the {{containerRuntimeEndpoint .}}
+// returns the CRI socket of the container runtime the kubelet connects to.
+func containerRuntimeEndpoint(cfg RenderConfig) interface{} {
+	_, runtime := getContainerRuntime(cfg)
+	return runtime.endpoint
+}
@@ -20,7 +20,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	corev1clientset "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -70,6 +69,7 @@ func New(
 	secretsInformer coreinformersv1.SecretInformer,
 	kubeClient clientset.Interface,
 	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
 ) *Controller {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -80,7 +80,7 @@ func New(
 		client:        mcfgClient,
 		kubeClient:    kubeClient,
 		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-templatecontroller"}),
-		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "machineconfigcontroller-templatecontroller"),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-templatecontroller"),
 	}
 
 	ccInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -183,11 +183,9 @@ func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
 	glog.Info("Starting MachineConfigController-TemplateController")
 	defer glog.Info("Shutting down MachineConfigController-TemplateController")
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(ctrl.worker, time.Second, stopCh)
-	}
-
-	<-stopCh
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-TemplateController", ctrl.queue)
 }
 
 func (ctrl *Controller) addControllerConfig(obj interface{}) {
@@ -361,6 +359,7 @@ func (ctrl *Controller) handleErr(err error, key interface{}) {
 		ctrl.queue.Forget(key)
 		return
 	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.TemplateControllerName).Inc()
 
 	if ctrl.queue.NumRequeues(key) < maxRetries {
 		glog.V(2).Infof("Error syncing controllerconfig %v: %v", key, err)
@@ -420,6 +419,7 @@ func (ctrl *Controller) syncControllerConfig(key string) error {
 	}
 	mcs, err := getMachineConfigsForControllerConfig(ctrl.templatesDir, cfg, pullSecretRaw)
 	if err != nil {
+		ctrl.eventRecorder.Eventf(cfg, corev1.EventTypeWarning, "RenderFailed", "Failed to render the templates: %v", err)
 		return ctrl.syncFailingStatus(cfg, err)
 	}
 
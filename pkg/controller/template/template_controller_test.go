@@ -9,6 +9,7 @@ import (
 
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
@@ -66,6 +67,12 @@ func newControllerConfig(name string) *mcfgv1.ControllerConfig {
 				Namespace: "default",
 				Name:      "coreos-pull-secret",
 			},
+			Images: map[string]string{
+				EtcdImageKey:            "image/etcd:1",
+				SetupEtcdEnvKey:         "image/setupEtcdEnv:1",
+				InfraImageKey:           "image/infraImage:1",
+				KubeClientAgentImageKey: "image/kubeClientAgentImage:1",
+			},
 		},
 	}
 }
@@ -87,7 +94,7 @@ func (f *fixture) newController() *Controller {
 	i := informers.NewSharedInformerFactory(f.client, noResyncPeriodFunc())
 	c := New(templateDir,
 		i.Machineconfiguration().V1().ControllerConfigs(), i.Machineconfiguration().V1().MachineConfigs(), cinformer.Core().V1().Secrets(),
-		f.kubeclient, f.client)
+		f.kubeclient, f.client, workqueue.DefaultControllerRateLimiter())
 
 	c.ccListerSynced = alwaysReady
 	c.mcListerSynced = alwaysReady
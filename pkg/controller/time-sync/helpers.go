@@ -0,0 +1,119 @@
+package timesync
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/vincent-petithory/dataurl"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const chronyConfigPath = "/etc/chrony.conf"
+
+// hostnameRegex matches a DNS host name.
+var hostnameRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// sourceFlags are the options of the chrony server and pool directives
+// taking no argument, sourceIntOptions the ones taking an integer.
+var (
+	sourceFlags      = map[string]bool{"iburst": true, "burst": true, "prefer": true, "noselect": true, "trust": true, "require": true, "xleave": true, "nts": true}
+	sourceIntOptions = map[string]bool{"minpoll": true, "maxpoll": true, "polltarget": true, "maxsources": true}
+)
+
+// validateTimeSync returns an error if the TimeSync sets no source, or a
+// source with an invalid address or option.
+func validateTimeSync(cfg *mcfgv1.TimeSync) error {
+	if len(cfg.Spec.Servers) == 0 && len(cfg.Spec.Pools) == 0 {
+		return fmt.Errorf("TimeSync: at least one server or pool must be set")
+	}
+	seen := make(map[string]bool)
+	for _, src := range append(append([]mcfgv1.TimeSource{}, cfg.Spec.Servers...), cfg.Spec.Pools...) {
+		if net.ParseIP(src.Address) == nil && !hostnameRegex.MatchString(src.Address) {
+			return fmt.Errorf("TimeSync: invalid address %q", src.Address)
+		}
+		if seen[src.Address] {
+			return fmt.Errorf("TimeSync: address %q is set more than once", src.Address)
+		}
+		seen[src.Address] = true
+		if err := validateSourceOptions(src.Options); err != nil {
+			return fmt.Errorf("TimeSync: invalid options for %q: %v", src.Address, err)
+		}
+	}
+	return nil
+}
+
+func validateSourceOptions(options []string) error {
+	for _, opt := range options {
+		fields := strings.Fields(opt)
+		switch {
+		case len(fields) == 1 && sourceFlags[fields[0]]:
+		case len(fields) == 2 && sourceIntOptions[fields[0]]:
+			if _, err := strconv.Atoi(fields[1]); err != nil {
+				return fmt.Errorf("%s takes an integer, got %q", fields[0], fields[1])
+			}
+		default:
+			return fmt.Errorf("unsupported option %q", opt)
+		}
+	}
+	return nil
+}
+
+// renderChronyConf returns the chrony.conf synchronizing with the sources of
+// the TimeSync, with the defaults of the chrony.conf shipped by RHCOS.
+func renderChronyConf(spec mcfgv1.TimeSyncSpec) []byte {
+	var b strings.Builder
+	writeSource := func(directive string, src mcfgv1.TimeSource) {
+		fields := append([]string{directive, src.Address}, src.Options...)
+		b.WriteString(strings.Join(fields, " "))
+		b.WriteString("\n")
+	}
+	for _, src := range spec.Servers {
+		writeSource("server", src)
+	}
+	for _, src := range spec.Pools {
+		writeSource("pool", src)
+	}
+	b.WriteString("driftfile /var/lib/chrony/drift\n")
+	b.WriteString("makestep 1.0 3\n")
+	b.WriteString("rtcsync\n")
+	b.WriteString("logdir /var/log/chrony\n")
+	return []byte(b.String())
+}
+
+// createNewTimeSyncIgnition returns an Ignition config writing chrony.conf.
+func createNewTimeSyncIgnition(chronyConf []byte) igntypes.Config {
+	tempIgnConfig := ctrlcommon.NewIgnConfig()
+	mode := 0644
+	du := dataurl.New(chronyConf, "text/plain")
+	du.Encoding = dataurl.EncodingASCII
+	tempIgnConfig.Storage.Files = append(tempIgnConfig.Storage.Files, igntypes.File{
+		Node: igntypes.Node{
+			Filesystem: "root",
+			Path:       chronyConfigPath,
+		},
+		FileEmbedded1: igntypes.FileEmbedded1{
+			Mode: &mode,
+			Contents: igntypes.FileContents{
+				Source: du.String(),
+			},
+		},
+	})
+	return tempIgnConfig
+}
+
+// getManagedTimeSyncKey returns the name of the MachineConfig of pool. It sorts
+// after the MachineConfigs shipping the default chrony.conf.
+func getManagedTimeSyncKey(pool *mcfgv1.MachineConfigPool) string {
+	return fmt.Sprintf("99-%s-%s-chrony", pool.Name, pool.ObjectMeta.UID)
+}
+
+func wrapErrorWithCondition(err error, args ...interface{}) mcfgv1.TimeSyncCondition {
+	condition := ctrlcommon.NewSyncCondition(err, args...)
+	return *mcfgv1.NewTimeSyncCondition(mcfgv1.TimeSyncStatusConditionType(condition.Type), condition.Status, condition.Message)
+}
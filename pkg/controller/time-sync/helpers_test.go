@@ -0,0 +1,75 @@
+package timesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestValidateTimeSync(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []mcfgv1.TimeSource
+		pools   []mcfgv1.TimeSource
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:    "servers and pools",
+			servers: []mcfgv1.TimeSource{{Address: "ntp1.example.com", Options: []string{"iburst", "prefer"}}, {Address: "10.0.0.1"}, {Address: "fd00::1"}},
+			pools:   []mcfgv1.TimeSource{{Address: "2.rhel.pool.ntp.org", Options: []string{"maxsources 3"}}},
+		},
+		{
+			name:    "address with a scheme",
+			servers: []mcfgv1.TimeSource{{Address: "ntp://ntp1.example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate address",
+			servers: []mcfgv1.TimeSource{{Address: "ntp1.example.com"}},
+			pools:   []mcfgv1.TimeSource{{Address: "ntp1.example.com"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported option",
+			servers: []mcfgv1.TimeSource{{Address: "ntp1.example.com", Options: []string{"iburst\nmakestep 1000 -1"}}},
+			wantErr: true,
+		},
+		{
+			name:    "option without its integer",
+			servers: []mcfgv1.TimeSource{{Address: "ntp1.example.com", Options: []string{"minpoll fast"}}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &mcfgv1.TimeSync{Spec: mcfgv1.TimeSyncSpec{Servers: test.servers, Pools: test.pools}}
+			err := validateTimeSync(cfg)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRenderChronyConf(t *testing.T) {
+	spec := mcfgv1.TimeSyncSpec{
+		Servers: []mcfgv1.TimeSource{{Address: "ntp1.example.com", Options: []string{"iburst", "prefer"}}, {Address: "10.0.0.1"}},
+		Pools:   []mcfgv1.TimeSource{{Address: "pool.ntp.example.com", Options: []string{"maxsources 3"}}},
+	}
+	expected := "server ntp1.example.com iburst prefer\n" +
+		"server 10.0.0.1\n" +
+		"pool pool.ntp.example.com maxsources 3\n" +
+		"driftfile /var/lib/chrony/drift\n" +
+		"makestep 1.0 3\n" +
+		"rtcsync\n" +
+		"logdir /var/log/chrony\n"
+	assert.Equal(t, expected, string(renderChronyConf(spec)))
+}
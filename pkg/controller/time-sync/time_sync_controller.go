@@ -0,0 +1,384 @@
+package timesync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+
+	corev1 "k8s.io/api/core/v1"
+	macherrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	mtmpl "github.com/openshift/machine-config-operator/pkg/controller/template"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
+	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
+	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/version"
+)
+
+const (
+	// maxRetries is the number of times a TimeSync will be retried before it is dropped out of the queue.
+	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a TimeSync is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	maxRetries = 15
+)
+
+var (
+	// controllerKind contains the schema.GroupVersionKind for this controller type.
+	controllerKind = mcfgv1.SchemeGroupVersion.WithKind("TimeSync")
+)
+
+var updateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Jitter:   1.0,
+}
+
+// Controller defines the time sync controller. It renders the NTP sources of
+// TimeSyncs into the chrony.conf of the selected pools.
+type Controller struct {
+	client        mcfgclientset.Interface
+	eventRecorder record.EventRecorder
+
+	syncHandler     func(key string) error
+	enqueueTimeSync func(*mcfgv1.TimeSync)
+
+	tsLister       mcfglistersv1.TimeSyncLister
+	tsListerSynced cache.InformerSynced
+
+	mcpLister       mcfglistersv1.MachineConfigPoolLister
+	mcpListerSynced cache.InformerSynced
+
+	queue workqueue.RateLimitingInterface
+}
+
+// New returns a new time sync controller
+func New(
+	mcpInformer mcfginformersv1.MachineConfigPoolInformer,
+	tsInformer mcfginformersv1.TimeSyncInformer,
+	kubeClient clientset.Interface,
+	mcfgClient mcfgclientset.Interface,
+	rateLimiter workqueue.RateLimiter,
+) *Controller {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(&coreclientsetv1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	ctrl := &Controller{
+		client:        mcfgClient,
+		eventRecorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigcontroller-timesynccontroller"}),
+		queue:         workqueue.NewNamedRateLimitingQueue(rateLimiter, "machineconfigcontroller-timesynccontroller"),
+	}
+
+	tsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ctrl.addTimeSync,
+		UpdateFunc: ctrl.updateTimeSync,
+		DeleteFunc: ctrl.deleteTimeSync,
+	})
+
+	mcpInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctrl.addMachineConfigPool,
+	})
+
+	ctrl.syncHandler = ctrl.syncTimeSync
+	ctrl.enqueueTimeSync = ctrl.enqueue
+
+	ctrl.mcpLister = mcpInformer.Lister()
+	ctrl.mcpListerSynced = mcpInformer.Informer().HasSynced
+
+	ctrl.tsLister = tsInformer.Lister()
+	ctrl.tsListerSynced = tsInformer.Informer().HasSynced
+
+	return ctrl
+}
+
+// Run executes the time sync controller.
+func (ctrl *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer ctrl.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(stopCh, ctrl.mcpListerSynced, ctrl.tsListerSynced) {
+		return
+	}
+
+	glog.Info("Starting MachineConfigController-TimeSyncController")
+	defer glog.Info("Shutting down MachineConfigController-TimeSyncController")
+
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, ctrl.worker)
+	w.Wait("MachineConfigController-TimeSyncController", ctrl.queue)
+}
+
+func (ctrl *Controller) updateTimeSync(old, cur interface{}) {
+	oldConfig := old.(*mcfgv1.TimeSync)
+	newConfig := cur.(*mcfgv1.TimeSync)
+
+	if !reflect.DeepEqual(oldConfig.Spec, newConfig.Spec) {
+		glog.V(4).Infof("Update TimeSync %s", oldConfig.Name)
+		ctrl.enqueueTimeSync(newConfig)
+	}
+}
+
+func (ctrl *Controller) addTimeSync(obj interface{}) {
+	cfg := obj.(*mcfgv1.TimeSync)
+	glog.V(4).Infof("Adding TimeSync %s", cfg.Name)
+	ctrl.enqueueTimeSync(cfg)
+}
+
+func (ctrl *Controller) deleteTimeSync(obj interface{}) {
+	cfg, ok := obj.(*mcfgv1.TimeSync)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		cfg, ok = tombstone.Obj.(*mcfgv1.TimeSync)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("Tombstone contained object that is not a TimeSync %#v", obj))
+			return
+		}
+	}
+	if err := ctrl.cascadeDelete(cfg); err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't delete MachineConfigs for %#v: %v", cfg, err))
+	} else {
+		glog.V(4).Infof("Deleted TimeSync %s and its MachineConfigs", cfg.Name)
+	}
+}
+
+// addMachineConfigPool requeues all the TimeSyncs so that newly created pools
+// get the NTP sources they are selected for.
+func (ctrl *Controller) addMachineConfigPool(obj interface{}) {
+	cfgs, err := ctrl.tsLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't list TimeSyncs: %v", err))
+		return
+	}
+	for _, cfg := range cfgs {
+		ctrl.enqueueTimeSync(cfg)
+	}
+}
+
+// cascadeDelete removes the MachineConfigs rendered for the given TimeSync
+func (ctrl *Controller) cascadeDelete(cfg *mcfgv1.TimeSync) error {
+	mcs, err := ctrl.client.MachineconfigurationV1().MachineConfigs().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, mc := range mcs.Items {
+		ref := metav1.GetControllerOf(&mc)
+		if ref == nil || ref.Kind != controllerKind.Kind || ref.UID != cfg.UID {
+			continue
+		}
+		if err := ctrl.client.MachineconfigurationV1().MachineConfigs().Delete(context.TODO(), mc.Name, metav1.DeleteOptions{}); err != nil && !macherrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ctrl *Controller) enqueue(cfg *mcfgv1.TimeSync) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(cfg)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %#v: %v", cfg, err))
+		return
+	}
+	ctrl.queue.Add(key)
+}
+
+// worker runs a worker thread that just dequeues items, processes them, and marks them done.
+// It enforces that the syncHandler is never invoked concurrently with the same key.
+func (ctrl *Controller) worker() {
+	for ctrl.processNextWorkItem() {
+	}
+}
+
+func (ctrl *Controller) processNextWorkItem() bool {
+	key, quit := ctrl.queue.Get()
+	if quit {
+		return false
+	}
+	defer ctrl.queue.Done(key)
+
+	err := ctrl.syncHandler(key.(string))
+	ctrl.handleErr(err, key)
+
+	return true
+}
+
+func (ctrl *Controller) handleErr(err error, key interface{}) {
+	if err == nil {
+		ctrl.queue.Forget(key)
+		return
+	}
+	ctrlcommon.MCCSyncErrors.WithLabelValues(ctrlcommon.TimeSyncControllerName).Inc()
+
+	if _, ok := err.(*ctrlcommon.ForgetError); ok {
+		ctrl.queue.Forget(key)
+		return
+	}
+
+	if ctrl.queue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing timesync %v: %v", key, err)
+		ctrl.queue.AddRateLimited(key)
+		return
+	}
+
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping timesync %q out of the queue: %v", key, err)
+	ctrl.queue.Forget(key)
+	ctrl.queue.AddAfter(key, 1*time.Minute)
+}
+
+func (ctrl *Controller) syncStatusOnly(cfg *mcfgv1.TimeSync, err error, args ...interface{}) error {
+	statusUpdateError := retry.RetryOnConflict(updateBackoff, func() error {
+		newcfg, getErr := ctrl.tsLister.Get(cfg.Name)
+		if getErr != nil {
+			return getErr
+		}
+		newcfg = newcfg.DeepCopy()
+		newcfg.Status.ObservedGeneration = cfg.Generation
+		mcfgv1.SetSyncCondition(&newcfg.Status.Conditions, wrapErrorWithCondition(err, args...))
+		_, lerr := ctrl.client.MachineconfigurationV1().TimeSyncs().UpdateStatus(context.TODO(), newcfg, metav1.UpdateOptions{})
+		return lerr
+	})
+	if statusUpdateError != nil {
+		glog.Warningf("error updating timesync status: %v", statusUpdateError)
+	}
+	return err
+}
+
+// syncTimeSync will sync the TimeSync with the given key.
+// This function is not meant to be invoked concurrently with the same key.
+func (ctrl *Controller) syncTimeSync(key string) error {
+	startTime := time.Now()
+	glog.V(4).Infof("Started syncing timesync %q (%v)", key, startTime)
+	defer func() {
+		glog.V(4).Infof("Finished syncing timesync %q (%v)", key, time.Since(startTime))
+	}()
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ctrl.tsLister.Get(name)
+	if macherrors.IsNotFound(err) {
+		glog.V(2).Infof("TimeSync %v has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deep-copy otherwise we are mutating our cache.
+	cfg = cfg.DeepCopy()
+
+	if cfg.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if err := validateTimeSync(cfg); err != nil {
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	pools, err := ctrl.getPoolsForTimeSync(cfg)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, err)
+	}
+	if len(pools) == 0 {
+		err := fmt.Errorf("TimeSync %v does not match any MachineConfigPools", key)
+		glog.V(2).Infof("%v", err)
+		return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+	}
+
+	ignConfig := createNewTimeSyncIgnition(renderChronyConf(cfg.Spec))
+	rawIgn, err := json.Marshal(ignConfig)
+	if err != nil {
+		return ctrl.syncStatusOnly(cfg, err, "could not marshal chrony Ignition: %v", err)
+	}
+
+	for _, pool := range pools {
+		role := pool.Name
+		managedKey := getManagedTimeSyncKey(pool)
+		mc, err := ctrl.client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), managedKey, metav1.GetOptions{})
+		if err != nil && !macherrors.IsNotFound(err) {
+			return ctrl.syncStatusOnly(cfg, err, "could not find MachineConfig: %v", managedKey)
+		}
+		isNotFound := macherrors.IsNotFound(err)
+		if isNotFound {
+			mc, err = mtmpl.MachineConfigFromIgnConfig(role, managedKey, ignConfig)
+			if err != nil {
+				return ctrl.syncStatusOnly(cfg, err, "could not create MachineConfig from new Ignition config: %v", err)
+			}
+		} else {
+			if ref := metav1.GetControllerOf(mc); ref != nil && ref.UID != cfg.UID {
+				err := fmt.Errorf("MachineConfigPool %s already has the NTP sources of %s %s", pool.Name, ref.Kind, ref.Name)
+				return ctrl.syncStatusOnly(cfg, ctrlcommon.NewForgetError(err))
+			}
+			mc.Spec.Config.Raw = rawIgn
+		}
+
+		mc.SetAnnotations(map[string]string{
+			ctrlcommon.GeneratedByControllerVersionAnnotationKey: version.Hash,
+		})
+		oref := metav1.NewControllerRef(cfg, controllerKind)
+		mc.SetOwnerReferences([]metav1.OwnerReference{*oref})
+
+		// Create or Update, on conflict retry
+		if err := retry.RetryOnConflict(updateBackoff, func() error {
+			var err error
+			if isNotFound {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), mc, metav1.CreateOptions{})
+			} else {
+				_, err = ctrl.client.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), mc, metav1.UpdateOptions{})
+			}
+			return err
+		}); err != nil {
+			return ctrl.syncStatusOnly(cfg, err, "could not Create/Update MachineConfig: %v", err)
+		}
+		glog.Infof("Applied TimeSync %v on MachineConfigPool %v", key, pool.Name)
+	}
+
+	return ctrl.syncStatusOnly(cfg, nil)
+}
+
+func (ctrl *Controller) getPoolsForTimeSync(config *mcfgv1.TimeSync) ([]*mcfgv1.MachineConfigPool, error) {
+	pList, err := ctrl.mcpLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(config.Spec.MachineConfigPoolSelector)
+	if err != nil {
+		return nil, ctrlcommon.NewForgetError(fmt.Errorf("invalid label selector: %v", err))
+	}
+
+	var pools []*mcfgv1.MachineConfigPool
+	for _, p := range pList {
+		// If a pool with a nil or empty selector creeps in, it should match nothing, not everything.
+		if selector.Empty() || !selector.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		pools = append(pools, p)
+	}
+	return pools, nil
+}
@@ -0,0 +1,132 @@
+package timesync
+
+import (
+	"context"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	informers "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+var alwaysReady = func() bool { return true }
+
+func newTimeSync(name string, servers []mcfgv1.TimeSource, selector *metav1.LabelSelector) *mcfgv1.TimeSync {
+	return &mcfgv1.TimeSync{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcfgv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(utilrand.String(5)), Generation: 1},
+		Spec: mcfgv1.TimeSyncSpec{
+			MachineConfigPoolSelector: selector,
+			Servers:                   servers,
+		},
+	}
+}
+
+func newController(t *testing.T, pools []*mcfgv1.MachineConfigPool, cfgs []*mcfgv1.TimeSync, objects ...runtime.Object) (*Controller, *fake.Clientset) {
+	for _, cfg := range cfgs {
+		objects = append(objects, cfg)
+	}
+	client := fake.NewSimpleClientset(objects...)
+	i := informers.NewSharedInformerFactory(client, 0)
+
+	c := New(
+		i.Machineconfiguration().V1().MachineConfigPools(),
+		i.Machineconfiguration().V1().TimeSyncs(),
+		k8sfake.NewSimpleClientset(),
+		client,
+		workqueue.DefaultControllerRateLimiter(),
+	)
+	c.mcpListerSynced = alwaysReady
+	c.tsListerSynced = alwaysReady
+	c.eventRecorder = &record.FakeRecorder{}
+
+	for _, p := range pools {
+		require.Nil(t, i.Machineconfiguration().V1().MachineConfigPools().Informer().GetIndexer().Add(p))
+	}
+	for _, cfg := range cfgs {
+		require.Nil(t, i.Machineconfiguration().V1().TimeSyncs().Informer().GetIndexer().Add(cfg))
+	}
+	return c, client
+}
+
+func TestTimeSyncCreate(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["time-sync"] = "internal"
+	mcp2 := helpers.NewMachineConfigPool("master", helpers.MasterSelector, helpers.MasterSelector, "v0")
+	ts := newTimeSync("internal", []mcfgv1.TimeSource{{Address: "ntp1.example.com", Options: []string{"iburst"}}},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "time-sync", "internal"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp, mcp2}, []*mcfgv1.TimeSync{ts})
+	require.Nil(t, c.syncHandler(ts.Name))
+
+	rendered, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTimeSyncKey(mcp), metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "worker", rendered.Labels[mcfgv1.MachineConfigRoleLabelKey])
+	require.NotNil(t, metav1.GetControllerOf(rendered))
+	assert.Equal(t, ts.UID, metav1.GetControllerOf(rendered).UID)
+
+	ignCfg, _, err := ign.Parse(rendered.Spec.Config.Raw)
+	require.Nil(t, err)
+	require.Len(t, ignCfg.Storage.Files, 1)
+	assert.Equal(t, chronyConfigPath, ignCfg.Storage.Files[0].Path)
+	contents, err := dataurl.DecodeString(ignCfg.Storage.Files[0].Contents.Source)
+	require.Nil(t, err)
+	assert.Contains(t, string(contents.Data), "server ntp1.example.com iburst\n")
+
+	// The master pool isn't selected
+	_, err = client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTimeSyncKey(mcp2), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	ts, err = client.MachineconfigurationV1().TimeSyncs().Get(context.TODO(), ts.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, ts.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.TimeSyncSuccess, ts.Status.Conditions[0].Type)
+}
+
+func TestTimeSyncPoolAlreadyOwned(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["time-sync"] = "internal"
+	selector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, "time-sync", "internal")
+	first := newTimeSync("first", []mcfgv1.TimeSource{{Address: "ntp1.example.com"}}, selector)
+	second := newTimeSync("second", []mcfgv1.TimeSource{{Address: "ntp2.example.com"}}, selector)
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, []*mcfgv1.TimeSync{first, second})
+	require.Nil(t, c.syncHandler(first.Name))
+	err := c.syncHandler(second.Name)
+	require.NotNil(t, err)
+	_, ok := err.(*ctrlcommon.ForgetError)
+	assert.True(t, ok)
+
+	second, err = client.MachineconfigurationV1().TimeSyncs().Get(context.TODO(), second.Name, metav1.GetOptions{})
+	require.Nil(t, err)
+	require.Len(t, second.Status.Conditions, 1)
+	assert.Equal(t, mcfgv1.TimeSyncFailure, second.Status.Conditions[0].Type)
+}
+
+func TestTimeSyncCascadeDelete(t *testing.T) {
+	mcp := helpers.NewMachineConfigPool("worker", helpers.WorkerSelector, helpers.WorkerSelector, "v0")
+	mcp.ObjectMeta.Labels["time-sync"] = "internal"
+	ts := newTimeSync("internal", []mcfgv1.TimeSource{{Address: "ntp1.example.com"}},
+		metav1.AddLabelToSelector(&metav1.LabelSelector{}, "time-sync", "internal"))
+
+	c, client := newController(t, []*mcfgv1.MachineConfigPool{mcp}, []*mcfgv1.TimeSync{ts})
+	require.Nil(t, c.syncHandler(ts.Name))
+	require.Nil(t, c.cascadeDelete(ts))
+
+	_, err := client.MachineconfigurationV1().MachineConfigs().Get(context.TODO(), getManagedTimeSyncKey(mcp), metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// DefaultAPISocketPath is where the read-only daemon API is served by default.
+	DefaultAPISocketPath = "/run/machine-config-daemon/daemon.sock"
+
+	// apiJournalLines is the number of daemon journal entries returned by /v1/journal.
+	apiJournalLines = 200
+)
+
+// pendingConfigResponse is the body returned by /v1/config/pending.
+type pendingConfigResponse struct {
+	Name   string `json:"name"`
+	BootID string `json:"bootID,omitempty"`
+}
+
+// driftResponse is the body returned by /v1/drift.
+type driftResponse struct {
+	Config  string   `json:"config"`
+	Drifted bool     `json:"drifted"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// StartAPIListener serves the read-only daemon API on the unix socket at path
+// until stopCh is closed, so that support tooling, e.g. from an `oc debug`
+// session, can query the state of the daemon without scraping its logs.
+func (dn *Daemon) StartAPIListener(path string, stopCh <-chan struct{}) {
+	if path == "" {
+		path = DefaultAPISocketPath
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		glog.Errorf("unable to create API socket directory: %v", err)
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		glog.Errorf("unable to remove stale API socket: %v", err)
+		return
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		glog.Errorf("unable to listen on API socket: %v", err)
+		return
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		glog.Errorf("unable to restrict API socket permissions: %v", err)
+		l.Close()
+		return
+	}
+
+	glog.Infof("Starting API listener on %s", path)
+	s := http.Server{Handler: dn.apiHandler()}
+	go func() {
+		if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("API listener exited with error: %v", err)
+		}
+	}()
+	<-stopCh
+	if err := s.Shutdown(context.Background()); err != nil {
+		glog.Errorf("error stopping API listener: %v", err)
+	}
+}
+
+func (dn *Daemon) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/config/current", readOnly(dn.serveCurrentConfig))
+	mux.HandleFunc("/v1/config/pending", readOnly(dn.servePendingConfig))
+	mux.HandleFunc("/v1/journal", readOnly(dn.serveJournal))
+	mux.HandleFunc("/v1/drift", readOnly(dn.serveDrift))
+	return mux
+}
+
+// readOnly rejects anything but GET requests.
+func readOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		glog.Warningf("Failed to write API response: %v", err)
+	}
+}
+
+func (dn *Daemon) serveCurrentConfig(w http.ResponseWriter, r *http.Request) {
+	config, err := dn.getCurrentConfigOnDisk()
+	if os.IsNotExist(err) {
+		http.Error(w, "no current config on disk", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, config)
+}
+
+func (dn *Daemon) servePendingConfig(w http.ResponseWriter, r *http.Request) {
+	pending, err := dn.getPendingState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if pending == nil {
+		http.Error(w, "no pending config", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, pendingConfigResponse{Name: pending.Message, BootID: pending.BootID})
+}
+
+// serveJournal returns the last entries the daemon logged to the journal,
+// across reboots, which cover its last updates.
+func (dn *Daemon) serveJournal(w http.ResponseWriter, r *http.Request) {
+	out, err := exec.Command("journalctl", "-o", "short-iso", "--no-pager", "_UID=0").Output()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	for _, line := range filterJournal(string(out), apiJournalLines, daemonJournalMarker) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// daemonJournalMarker is found in the journal lines logged by the daemon.
+const daemonJournalMarker = "machine-config-daemon["
+
+// filterJournal returns the last n lines of journal containing one of markers.
+func filterJournal(journal string, n int, markers ...string) []string {
+	var lines []string
+	for _, line := range strings.Split(journal, "\n") {
+		for _, marker := range markers {
+			if strings.Contains(line, marker) {
+				lines = append(lines, line)
+				break
+			}
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+func (dn *Daemon) serveDrift(w http.ResponseWriter, r *http.Request) {
+	config, err := dn.getCurrentConfigOnDisk()
+	if os.IsNotExist(err) {
+		http.Error(w, "no current config on disk", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report := driftResponse{Config: config.GetName()}
+	if err := dn.validateOnDiskState(config); err != nil {
+		report.Drifted = true
+		if agg, ok := err.(utilerrors.Aggregate); ok {
+			for _, e := range utilerrors.Flatten(agg).Errors() {
+				report.Errors = append(report.Errors, e.Error())
+			}
+		} else {
+			report.Errors = []string{err.Error()}
+		}
+	}
+	writeJSON(w, report)
+}
@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPICurrentConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daemon-api")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	dn := &Daemon{currentConfigPath: filepath.Join(dir, "currentconfig")}
+	handler := dn.apiHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/config/current", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	config := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+	config.Name = "rendered-worker-1"
+	require.Nil(t, dn.storeCurrentConfigOnDisk(config))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/config/current", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	current := &mcfgv1.MachineConfig{}
+	require.Nil(t, json.Unmarshal(rec.Body.Bytes(), current))
+	assert.Equal(t, "rendered-worker-1", current.Name)
+
+	// the API is read-only
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/config/current", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestFilterJournal(t *testing.T) {
+	journal := `2020-06-01T10:00:00+0000 host root[1]: machine-config-daemon[42]: Starting update from a to b
+2020-06-01T10:00:01+0000 host kernel: something else
+2020-06-01T10:00:02+0000 host root[1]: machine-config-daemon[42]: Update prepared; beginning drain
+2020-06-01T10:00:03+0000 host root[1]: machine-config-daemon[42]: drain complete`
+	assert.Equal(t, []string{
+		"2020-06-01T10:00:02+0000 host root[1]: machine-config-daemon[42]: Update prepared; beginning drain",
+		"2020-06-01T10:00:03+0000 host root[1]: machine-config-daemon[42]: drain complete",
+	}, filterJournal(journal, 2, daemonJournalMarker))
+}
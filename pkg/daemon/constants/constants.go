@@ -25,6 +25,98 @@ const (
 	MachineConfigDaemonStateDegraded = "Degraded"
 	// MachineConfigDaemonStateUnreconcilable is set by the daemon when a MachineConfig cannot be applied.
 	MachineConfigDaemonStateUnreconcilable = "Unreconcilable"
+	// MachineConfigDaemonPhaseAnnotationKey is set by the daemon while it's Working to report which step of the update it is in.
+	MachineConfigDaemonPhaseAnnotationKey = "machineconfiguration.openshift.io/phase"
+	// MachineConfigDaemonPhasePullingImage is set by the daemon while it pulls the new OS image, before draining the node.
+	MachineConfigDaemonPhasePullingImage = "PullingImage"
+	// MachineConfigDaemonPhaseDraining is set by the daemon when it starts draining the node.
+	MachineConfigDaemonPhaseDraining = "Draining"
+	// MachineConfigDaemonPhaseRebooting is set by the daemon right before rebooting the node.
+	MachineConfigDaemonPhaseRebooting = "Rebooting"
+	// MachineConfigDaemonPhaseStaged is set by the daemon once a staged update is written to disk and waits to be applied.
+	MachineConfigDaemonPhaseStaged = "Staged"
+	// MachineConfigDaemonPhaseConfigDrifted is set by the daemon along with the Degraded state when the files or units
+	// on disk drifted from the current config.
+	MachineConfigDaemonPhaseConfigDrifted = "ConfigDrifted"
+	// MachineConfigDaemonPhaseHookFailed is set by the daemon along with the Degraded state when a pre or post update hook failed.
+	MachineConfigDaemonPhaseHookFailed = "HookFailed"
+	// MachineConfigDaemonPhaseDrainFailed is set by the daemon when the node couldn't be drained with its drain policy.
+	MachineConfigDaemonPhaseDrainFailed = "DrainFailed"
+	// MachineConfigDaemonPhaseTentative is set by the daemon after rebooting into a new config while it runs the
+	// post-update health checks of the node, the update is rolled back if they fail.
+	MachineConfigDaemonPhaseTentative = "Tentative"
+	// StagedUpdateAnnotationKey is set to "true" by the node controller along with the desiredConfig of a node whose pool
+	// stages updates: the daemon writes the new configuration and the OS update but doesn't drain nor reboot the node.
+	StagedUpdateAnnotationKey = "machineconfiguration.openshift.io/stagedUpdate"
+	// ApplyStagedUpdateAnnotationKey is set to "true" by administrators to have the daemon drain and reboot a node
+	// into its staged update. The daemon clears it once the update is done.
+	ApplyStagedUpdateAnnotationKey = "machineconfiguration.openshift.io/applyStagedUpdate"
+	// DrainPolicyAnnotationKey is set by the node controller along with the desiredConfig of a node to the JSON encoded
+	// drain policy of its pool. The daemon uses the default drain policy when it's not set.
+	DrainPolicyAnnotationKey = "machineconfiguration.openshift.io/drainPolicy"
+	// NodeDisruptionPolicyAnnotationKey is set by the node controller along with the desiredConfig of a node to the JSON
+	// encoded node disruption policy of the cluster. Without it, the daemon reboots for any change it can't apply live.
+	NodeDisruptionPolicyAnnotationKey = "machineconfiguration.openshift.io/nodeDisruptionPolicy"
+	// UpdateFailureAnnotationKey is set by the daemon when it fails to sync the node to the JSON encoded reason of the
+	// failure and the last relevant lines of the journal. It's cleared once the node is Done.
+	UpdateFailureAnnotationKey = "machineconfiguration.openshift.io/lastUpdateFailure"
+	// AutoRemediateDriftAnnotationKey is set to "true" by the node controller on the nodes of pools with autoRemediateDrift.
+	// The daemon then writes the current config back to disk when it finds it drifted instead of going Degraded.
+	AutoRemediateDriftAnnotationKey = "machineconfiguration.openshift.io/autoRemediateDrift"
+	// UnmanagedPathsAnnotationKey is set by the node controller to the JSON encoded unmanagedPaths of the pool of
+	// the node. The daemon doesn't write, delete nor check the files under these paths.
+	UnmanagedPathsAnnotationKey = "machineconfiguration.openshift.io/unmanagedPaths"
+	// UnmanagedNodeAnnotationKey is set to "true", as an annotation or a label, by the administrator to exclude a node
+	// from the management of the MCO, e.g. for debugging. The node controller doesn't update it and the daemon leaves
+	// it at its current config.
+	UnmanagedNodeAnnotationKey = "machineconfiguration.openshift.io/unmanaged"
+	// RebootStrategyAnnotationKey is set by the node controller to the JSON encoded rebootStrategy of the pool of the
+	// node. The daemon reboots with systemctl reboot when it's not set.
+	RebootStrategyAnnotationKey = "machineconfiguration.openshift.io/rebootStrategy"
+	// PostUpdateHealthCheckAnnotationKey is set by the node controller to the JSON encoded postUpdateHealthCheck of the
+	// pool of the node. The daemon completes updates without checking the health of the node when it's not set.
+	PostUpdateHealthCheckAnnotationKey = "machineconfiguration.openshift.io/postUpdateHealthCheck"
+	// HealthCheckRollbackAnnotationKey is set by the daemon to the JSON encoded details of the last update it rolled
+	// back because the node failed its post-update health checks. The daemon doesn't update to that config again.
+	HealthCheckRollbackAnnotationKey = "machineconfiguration.openshift.io/lastHealthCheckRollback"
+	// UpdatePriorityAnnotationKey can be set by administrators, as an annotation or a label, to an integer priority of
+	// the node. The node controller updates the nodes of a pool with higher priorities first, 0 being the default.
+	UpdatePriorityAnnotationKey = "machineconfiguration.openshift.io/updatePriority"
+	// ValidateOnDiskStateAnnotationKey can be set by administrators to have the daemon check the files and units on disk
+	// against the current config right away. The check runs every time its value changes.
+	ValidateOnDiskStateAnnotationKey = "machineconfiguration.openshift.io/validateOnDiskState"
+	// UpdateStrategyAnnotationKey is set by the daemon to how it applied the last update to the node.
+	UpdateStrategyAnnotationKey = "machineconfiguration.openshift.io/lastUpdateStrategy"
+	// UpdateStrategyReboot is set when the node was rebooted into the update.
+	UpdateStrategyReboot = "Reboot"
+	// UpdateStrategyServiceRestart is set when the update only needed some services to be reloaded or restarted.
+	UpdateStrategyServiceRestart = "ServiceRestart"
+	// UpdateStrategyNone is set when the update was applied by writing it to disk, e.g. ssh keys changes.
+	UpdateStrategyNone = "None"
+	// CordonedByAnnotationKey is set by the daemon, when it begins to drain the node for an update, to who the node
+	// is cordoned by: CordonedByDaemon, or CordonedByOther when the node was already unschedulable. It's removed
+	// once the update completes, and nodes cordoned by others are left unschedulable.
+	CordonedByAnnotationKey = "machineconfiguration.openshift.io/cordonedBy"
+	// CordonedByDaemon is set when the daemon cordoned the node to update it.
+	CordonedByDaemon = "machine-config-daemon"
+	// CordonedByOther is set when the node was already cordoned, e.g. by an administrator, before the update began.
+	CordonedByOther = "other"
+	// FirstbootPhaseAnnotationKey is set by the daemon to the step of the firstboot pivot into the initial
+	// MachineConfig the node is in, or Done once the node booted into it.
+	FirstbootPhaseAnnotationKey = "machineconfiguration.openshift.io/firstbootPhase"
+	// FirstbootProgressAnnotationKey is set by the daemon to the RFC 3339 time of the last firstboot phase change or
+	// retry. A firstboot pivot that doesn't report progress for long is hung rather than slow.
+	FirstbootProgressAnnotationKey = "machineconfiguration.openshift.io/firstbootProgress"
+	// FirstbootPhaseStaging is set while the OS image of the initial MachineConfig is pulled.
+	FirstbootPhaseStaging = "Staging"
+	// FirstbootPhasePivoting is set while the initial MachineConfig is written to disk and the OS is pivoted to its image.
+	FirstbootPhasePivoting = "Pivoting"
+	// FirstbootPhaseRebooting is set right before rebooting into the initial MachineConfig.
+	FirstbootPhaseRebooting = "Rebooting"
+	// FirstbootPhaseFailed is set when a firstboot step still failed after all its retries.
+	FirstbootPhaseFailed = "Failed"
+	// FirstbootPhaseDone is set once the node booted into its initial MachineConfig.
+	FirstbootPhaseDone = "Done"
 	// MachineConfigDaemonReasonAnnotationKey is set by the daemon when it needs to report a human readable reason for its state. E.g. when state flips to degraded/unreconcilable.
 	MachineConfigDaemonReasonAnnotationKey = "machineconfiguration.openshift.io/reason"
 	// InitialNodeAnnotationsFilePath defines the path at which it will find the node annotations it needs to set on the node once it comes up for the first time.
@@ -48,6 +140,15 @@ const (
 	// processing for debugging and auditing purposes.
 	MachineConfigEncapsulatedBakPath = "/etc/ignition-machine-config-encapsulated.json.bak"
 
+	// FirstbootProgressPath is where the machine-config-daemon-firstboot.service persists its progress, so that the
+	// daemon reports it on the node once the node joined the cluster.
+	FirstbootProgressPath = "/etc/machine-config-daemon/firstboot-progress.json"
+
+	// PackageUpdateHookPath is the executable the daemon runs on non-CoreOS hosts, e.g. RHEL workers, to update
+	// their packages with yum or dnf. It's called with the osImageURL of the new config and the package manager
+	// found on the host, and must be idempotent since it's called on every update requiring a reboot.
+	PackageUpdateHookPath = "/etc/machine-config-daemon/package-update-hook"
+
 	// MachineConfigDaemonForceFile if present causes the MCD to skip checking the validity of the
 	// "currentConfig" state.  Create this file (empty contents is fine) if you wish the MCD
 	// to proceed and attempt to "reconcile" to the new "desiredConfig" state regardless.
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/kubectl/pkg/drain"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// getCordonOwner returns who node is cordoned by when its drain begins. The
+// owner recorded earlier in the update is kept, e.g. when the drain is retried
+// after the daemon restarted, since the node is then cordoned by the daemon.
+func getCordonOwner(node *corev1.Node) string {
+	if owner, ok := node.Annotations[constants.CordonedByAnnotationKey]; ok && owner != "" {
+		return owner
+	}
+	if node.Spec.Unschedulable {
+		return constants.CordonedByOther
+	}
+	return constants.CordonedByDaemon
+}
+
+// setCordonOwner records who the node is cordoned by, or removes the record
+// when owner is empty.
+func setCordonOwner(client corev1client.NodeInterface, nodeName, owner string) (*corev1.Node, error) {
+	var value interface{}
+	if owner != "" {
+		value = owner
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{constants.CordonedByAnnotationKey: value},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.Patch(context.TODO(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+}
+
+// recordCordonOwner records who the node is cordoned by before the daemon
+// cordons it to drain it.
+func (dn *Daemon) recordCordonOwner() error {
+	owner := getCordonOwner(dn.node)
+	if dn.node.Annotations[constants.CordonedByAnnotationKey] == owner {
+		return nil
+	}
+	if owner == constants.CordonedByOther {
+		dn.logSystem("Node was already cordoned before the update, it will be left unschedulable once updated")
+	}
+	node, err := setCordonOwner(dn.kubeClient.CoreV1().Nodes(), dn.node.Name, owner)
+	if err != nil {
+		return errors.Wrap(err, "failed to record who cordoned the node")
+	}
+	dn.node = node
+	return nil
+}
+
+// uncordon makes node schedulable again once updated, unless it was cordoned
+// by someone else before the update began, and removes who cordoned it.
+func (dn *Daemon) uncordon(node *corev1.Node) error {
+	owner := node.Annotations[constants.CordonedByAnnotationKey]
+	if owner == constants.CordonedByOther {
+		glog.Infof("Node was cordoned before the update, leaving it unschedulable")
+	} else if err := drain.RunCordonOrUncordon(dn.drainer, node, false); err != nil {
+		return err
+	}
+	if owner == "" || dn.kubeClient == nil {
+		return nil
+	}
+	updated, err := setCordonOwner(dn.kubeClient.CoreV1().Nodes(), node.Name, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to remove who cordoned the node")
+	}
+	if node == dn.node {
+		dn.node = updated
+	}
+	return nil
+}
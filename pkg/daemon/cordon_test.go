@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+func TestGetCordonOwner(t *testing.T) {
+	tests := []struct {
+		name          string
+		unschedulable bool
+		annotations   map[string]string
+		owner         string
+	}{{
+		name:  "schedulable",
+		owner: constants.CordonedByDaemon,
+	}, {
+		name:          "cordoned by an administrator",
+		unschedulable: true,
+		owner:         constants.CordonedByOther,
+	}, {
+		name:          "drain retried after the daemon cordoned the node",
+		unschedulable: true,
+		annotations:   map[string]string{constants.CordonedByAnnotationKey: constants.CordonedByDaemon},
+		owner:         constants.CordonedByDaemon,
+	}, {
+		name:          "drain retried on a node cordoned by an administrator",
+		unschedulable: true,
+		annotations:   map[string]string{constants.CordonedByAnnotationKey: constants.CordonedByOther},
+		owner:         constants.CordonedByOther,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: test.annotations},
+				Spec:       corev1.NodeSpec{Unschedulable: test.unschedulable},
+			}
+			assert.Equal(t, test.owner, getCordonOwner(node))
+		})
+	}
+}
+
+func TestCordonOwnerAccounting(t *testing.T) {
+	for _, unschedulable := range []bool{false, true} {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		}
+		client := fake.NewSimpleClientset(node)
+		dn := &Daemon{
+			kubeClient: client,
+			drainer:    &drain.Helper{Client: client, Ctx: context.TODO()},
+			node:       node,
+		}
+
+		require.Nil(t, dn.recordCordonOwner())
+		require.Nil(t, drain.RunCordonOrUncordon(dn.drainer, dn.node, true))
+		// the drain is retried once the node is cordoned
+		require.Nil(t, dn.recordCordonOwner())
+		expected := constants.CordonedByDaemon
+		if unschedulable {
+			expected = constants.CordonedByOther
+		}
+		assert.Equal(t, expected, dn.node.Annotations[constants.CordonedByAnnotationKey])
+
+		require.Nil(t, dn.uncordon(dn.node))
+		updated, err := client.CoreV1().Nodes().Get(context.TODO(), "node-0", metav1.GetOptions{})
+		require.Nil(t, err)
+		// nodes cordoned by others are left unschedulable
+		assert.Equal(t, unschedulable, updated.Spec.Unschedulable)
+		assert.NotContains(t, updated.Annotations, constants.CordonedByAnnotationKey)
+	}
+}
@@ -22,9 +22,12 @@ import (
 	ign "github.com/coreos/ignition/config/v2_2"
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/openshift/machine-config-operator/lib/resourceread"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
 	mcfglistersv1 "github.com/openshift/machine-config-operator/pkg/generated/listers/machineconfiguration.openshift.io/v1"
 	"github.com/pkg/errors"
@@ -33,6 +36,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/diff"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformersv1 "k8s.io/client-go/informers/core/v1"
@@ -61,6 +65,9 @@ type Daemon struct {
 	// NodeUpdaterClient an instance of the client which interfaces with host content deployments
 	NodeUpdaterClient NodeUpdaterClient
 
+	// packageUpdater updates the packages of non-CoreOS hosts, where NodeUpdaterClient isn't used.
+	packageUpdater PackageUpdater
+
 	// bootID is a unique value per boot (generated by the kernel)
 	bootID string
 
@@ -91,6 +98,9 @@ type Daemon struct {
 
 	nodeWriter NodeWriter
 
+	// firstbootNodes reports the firstboot progress on the node, when set.
+	firstbootNodes corev1client.NodeInterface
+
 	// channel used by callbacks to signal Run() of an error
 	exitCh chan<- error
 
@@ -109,9 +119,22 @@ type Daemon struct {
 
 	currentConfigPath string
 
+	updateJournalPath string
+
+	postUpdateHealthPath string
+
+	osImageAliasPath string
+
 	loggerSupportsJournal bool
 
 	drainer *drain.Helper
+
+	// driftCheckRequested is set to 1 by the periodic drift check to have the
+	// worker validate the on-disk state on its next sync.
+	driftCheckRequested int32
+	// lastValidateRequest is the last value of the validateOnDiskState
+	// annotation the worker acted upon.
+	lastValidateRequest string
 }
 
 const (
@@ -124,6 +147,15 @@ const (
 	// currentConfigPath is where we store the current config on disk to validate
 	// against annotations changes
 	currentConfigPath = "/etc/machine-config-daemon/currentconfig"
+	// updateJournalPath is where we record the update in progress, to recover
+	// from it being interrupted
+	updateJournalPath = "/etc/machine-config-daemon/update-journal.json"
+	// postUpdateHealthPath is where we hand the post-update health check of
+	// an update over to machine-config-daemon-update-health.service
+	postUpdateHealthPath = "/etc/machine-config-daemon/post-update-health.json"
+	// osImageAliasPath is where we record the osImageURL the booted OS was
+	// last updated to without a pivot, as its image has the same ostree commit
+	osImageAliasPath = "/etc/machine-config-daemon/os-image-alias.json"
 	// pendingStateMessageID is the id we store the pending state in journal. We use it to
 	// also retrieve the pending config after a reboot
 	pendingStateMessageID = "machine-config-daemon-pending-state"
@@ -162,9 +194,24 @@ var (
 // However note we use `;` instead of `&&` so we keep rebooting even
 // if kubelet failed to shutdown - that way the machine will still eventually reboot
 // as systemd will time out the stop invocation.
-func rebootCommand(rationale string) *exec.Cmd {
+// The reboot strategy of the pool, which may be nil, adds a grace period after
+// stopping kubelet and replaces the reboot with a kexec or a custom unit, which
+// fall back to a reboot if they fail to start.
+func rebootCommand(rationale string, strategy *mcfgv1.RebootStrategy) *exec.Cmd {
+	script := "systemctl stop kubelet.service; "
+	if strategy != nil && strategy.GracePeriod != nil && strategy.GracePeriod.Duration > 0 {
+		script += fmt.Sprintf("sleep %d; ", int64(strategy.GracePeriod.Duration.Round(time.Second)/time.Second))
+	}
+	switch {
+	case strategy != nil && strategy.Type == mcfgv1.RebootStrategyKexec:
+		script += "systemctl kexec || systemctl reboot"
+	case strategy != nil && strategy.Type == mcfgv1.RebootStrategyUnit:
+		script += fmt.Sprintf("systemctl start %s || systemctl reboot", strategy.Unit)
+	default:
+		script += "systemctl reboot"
+	}
 	return exec.Command("systemd-run", "--unit", "machine-config-daemon-reboot",
-		"--description", fmt.Sprintf("machine-config-daemon: %s", rationale), "/bin/sh", "-c", "systemctl stop kubelet.service; systemctl reboot")
+		"--description", fmt.Sprintf("machine-config-daemon: %s", rationale), "/bin/sh", "-c", script)
 }
 
 // getBootID loads the unique "boot id" which is generated by the Linux kernel.
@@ -203,7 +250,7 @@ func New(
 	}
 
 	// Only pull the osImageURL from OSTree when we are on RHCOS or FCOS
-	if operatingSystem == machineConfigDaemonOSRHCOS || operatingSystem == machineConfigDaemonOSFCOS {
+	if isCoreOSVariant(operatingSystem) {
 		osImageURL, osVersion, err = nodeUpdaterClient.GetBootedOSImageURL()
 		if err != nil {
 			return nil, fmt.Errorf("error reading osImageURL from rpm-ostree: %v", err)
@@ -234,15 +281,24 @@ func New(
 	// report OS & version (if RHCOS or FCOS) to prometheus
 	HostOS.WithLabelValues(operatingSystem, osVersion).Set(1)
 
+	var packageUpdater PackageUpdater
+	if !mock && !isCoreOSVariant(operatingSystem) {
+		packageUpdater = newHookPackageUpdater()
+	}
+
 	return &Daemon{
 		mock:                  mock,
 		booting:               true,
 		OperatingSystem:       operatingSystem,
 		NodeUpdaterClient:     nodeUpdaterClient,
+		packageUpdater:        packageUpdater,
 		bootedOSImageURL:      osImageURL,
 		bootID:                bootID,
 		exitCh:                exitCh,
 		currentConfigPath:     currentConfigPath,
+		updateJournalPath:     updateJournalPath,
+		postUpdateHealthPath:  postUpdateHealthPath,
+		osImageAliasPath:      osImageAliasPath,
 		loggerSupportsJournal: loggerSupportsJournal,
 	}, nil
 }
@@ -252,15 +308,17 @@ func New(
 func (dn *Daemon) ClusterConnect(
 	name string,
 	kubeClient kubernetes.Interface,
+	mcfgClient mcfgclientset.Interface,
 	mcInformer mcfginformersv1.MachineConfigInformer,
 	nodeInformer coreinformersv1.NodeInformer,
 	kubeletHealthzEnabled bool,
 	kubeletHealthzEndpoint string,
 ) {
 	dn.name = name
+	logging.SetField("node", name)
 	dn.kubeClient = kubeClient
 
-	dn.nodeWriter = newNodeWriter()
+	dn.nodeWriter = newNodeWriter(mcfgClient.MachineconfigurationV1().NodeMachineConfigStates(ctrlcommon.MCONamespace))
 	go dn.nodeWriter.Run(dn.stopCh)
 
 	// Other controllers start out with the default controller limiter which retries
@@ -273,7 +331,7 @@ func (dn *Daemon) ClusterConnect(
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.V(2).Infof)
 	eventBroadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{Interface: dn.kubeClient.CoreV1().Events("")})
-	dn.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigdaemon", Host: dn.name})
+	dn.recorder = ctrlcommon.NewAggregatingRecorder(eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "machineconfigdaemon", Host: dn.name}), ctrlcommon.EventAggregationWindow, ctrlcommon.EventAggregationBurst)
 
 	go dn.runLoginMonitor(dn.stopCh, dn.exitCh)
 
@@ -361,6 +419,7 @@ func (dn *Daemon) updateErrorState(err error) {
 	default:
 		dn.nodeWriter.SetDegraded(err, dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name)
 	}
+	dn.reportUpdateFailure(err)
 }
 
 func (dn *Daemon) syncNode(key string) error {
@@ -399,6 +458,13 @@ func (dn *Daemon) syncNode(key string) error {
 	// Update our cached copy of the node
 	dn.node = node
 
+	// Leave the node as is while it's excluded from the management of the MCO,
+	// including the checks of the first sync which run once it's managed again.
+	if isNodeUnmanaged(node) {
+		glog.V(2).Infof("Node %s is unmanaged, not syncing it", node.Name)
+		return nil
+	}
+
 	// Take care of the very first sync of the MCD on a node.
 	// This loads the node annotation from the bootstrap (if we're really bootstrapping)
 	// and then proceeds to check the state of the node, which includes
@@ -414,6 +480,21 @@ func (dn *Daemon) syncNode(key string) error {
 		return nil
 	}
 
+	if handled, err := dn.syncConfigDrift(); handled || err != nil {
+		return err
+	}
+
+	if isStaged(node) {
+		pendingConfig, err := dn.getStagedConfig()
+		if err != nil {
+			return err
+		}
+		if pendingConfig != nil {
+			return dn.syncStagedUpdate(pendingConfig)
+		}
+		// the desiredConfig changed since the update was staged, stage the new one
+	}
+
 	// Pass to the shared update prep method
 	current, desired, err := dn.prepUpdateFromCluster()
 	if err != nil {
@@ -480,53 +561,6 @@ func (dn *Daemon) RunOnceFrom(onceFrom string, skipReboot bool) error {
 	return errors.New("unsupported onceFrom type provided")
 }
 
-// RunFirstbootCompleteMachineconfig is run via systemd on the first boot
-// to complete processing of the target MachineConfig.
-func (dn *Daemon) RunFirstbootCompleteMachineconfig() error {
-	data, err := ioutil.ReadFile(constants.MachineConfigEncapsulatedPath)
-	if err != nil {
-		return err
-	}
-	var mc mcfgv1.MachineConfig
-	err = json.Unmarshal(data, &mc)
-	if err != nil {
-		return errors.Wrapf(err, "failed to parse MachineConfig")
-	}
-
-	// Start with an empty config, then add our *booted* osImageURL to
-	// it, reflecting the current machine state.
-	oldConfig := canonicalizeEmptyMC(nil)
-	oldConfig.Spec.OSImageURL = dn.bootedOSImageURL
-	// Currently, we generally expect the bootimage to be older, but in the special
-	// case of having bootimage == machine-os-content, and no kernel arguments
-	// specified, then we don't need to do anything here.
-	mcDiffNotEmpty, err := dn.compareMachineConfig(oldConfig, &mc)
-	if err != nil {
-		return errors.Wrapf(err, "failed to compare MachineConfig")
-	}
-	if !mcDiffNotEmpty {
-		// Removing this file signals completion of the initial MC processing.
-		if err := os.Remove(constants.MachineConfigEncapsulatedPath); err != nil {
-			return errors.Wrapf(err, "failed to remove %s", constants.MachineConfigEncapsulatedPath)
-		}
-		return nil
-	}
-
-	dn.skipReboot = true
-	err = dn.update(nil, &mc)
-	if err != nil {
-		return err
-	}
-
-	// Removing this file signals completion of the initial MC processing.
-	if err := os.Rename(constants.MachineConfigEncapsulatedPath, constants.MachineConfigEncapsulatedBakPath); err != nil {
-		return errors.Wrap(err, "failed to rename encapsulated MachineConfig after processing on firstboot")
-	}
-
-	dn.skipReboot = false
-	return dn.reboot(fmt.Sprintf("Completing firstboot provisioning to %s", mc.GetName()))
-}
-
 // InstallSignalHandler installs the handler for the signals the daemon should act on
 func (dn *Daemon) InstallSignalHandler(signaled chan struct{}) {
 	termChan := make(chan os.Signal, 2048)
@@ -580,6 +614,7 @@ func (dn *Daemon) Run(stopCh <-chan struct{}, exitCh <-chan error) error {
 	}
 
 	go wait.Until(dn.worker, time.Second, stopCh)
+	go wait.Until(dn.requestDriftCheck, driftCheckInterval, stopCh)
 
 	for {
 		select {
@@ -806,10 +841,10 @@ func (dn *Daemon) getStateAndConfigs(pendingConfigName string) (*stateAndConfigs
 // dynamically after a reboot.
 func (dn *Daemon) LogSystemData() {
 	// Print status if available
-	if dn.OperatingSystem == machineConfigDaemonOSRHCOS || dn.OperatingSystem == machineConfigDaemonOSFCOS {
+	if isCoreOSVariant(dn.OperatingSystem) {
 		status, err := dn.NodeUpdaterClient.GetStatus()
 		if err != nil {
-			glog.Fatalf("unable to get rpm-ostree status: %s", err)
+			logging.Fatalf("unable to get rpm-ostree status: %s", err)
 		}
 		glog.Info(status)
 	}
@@ -897,6 +932,9 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	}
 	// Update our cached copy
 	dn.node = node
+	if err := dn.reportPostUpdateRollback(); err != nil {
+		return err
+	}
 	pendingState, err := dn.getPendingState()
 	if err != nil {
 		return err
@@ -924,6 +962,16 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		return err
 	}
 
+	// if we have a pendingConfig but we're into the same bootid and the update was staged,
+	// we're still waiting to be told to apply it. If the desiredConfig changed in the
+	// meantime, the next sync stages the new one.
+	if state.pendingConfig != nil && bootID == dn.bootID && isStaged(dn.node) {
+		if state.pendingConfig.GetName() != state.desiredConfig.GetName() {
+			return nil
+		}
+		return dn.syncStagedUpdate(state.pendingConfig)
+	}
+
 	// if we have a pendingConfig but we're into the same bootid, we failed to drain or reboot
 	// and if we still have a pendingConfig it means we've been killed by kube after 600s
 	// take a stab at that and re-run the drain+reboot routine
@@ -958,6 +1006,9 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		if err := os.Rename(constants.InitialNodeAnnotationsFilePath, constants.InitialNodeAnnotationsBakPath); err != nil {
 			return errors.Wrap(err, "renaming initial node annotation file")
 		}
+		if err := dn.reportFirstbootDone(); err != nil {
+			glog.Warningf("Failed to report the end of the firstboot pivot: %v", err)
+		}
 	}
 
 	var currentOnDisk *mcfgv1.MachineConfig
@@ -995,8 +1046,18 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 		expectedConfig = state.currentConfig
 	}
 	if _, err := os.Stat(constants.MachineConfigDaemonForceFile); err != nil {
-		if !dn.validateOnDiskState(expectedConfig) {
-			return fmt.Errorf("unexpected on-disk state validating against %s", expectedConfig.GetName())
+		if err := dn.validateOnDiskState(expectedConfig); err != nil {
+			err = errors.Wrapf(err, "unexpected on-disk state validating against %s", expectedConfig.GetName())
+			// An update interrupted halfway is expected not to validate, recover
+			// from it instead of going degraded.
+			journal, jerr := dn.getUpdateJournal()
+			if jerr != nil {
+				glog.Warningf("Failed to read the update journal: %v", jerr)
+			}
+			if journal == nil {
+				return err
+			}
+			return dn.recoverInterruptedUpdate(journal, state.desiredConfig.GetName(), err)
 		}
 	} else {
 		glog.Infof("Skipping on-disk validation; %s present", constants.MachineConfigDaemonForceFile)
@@ -1006,6 +1067,17 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	}
 	glog.Info("Validated on-disk state")
 
+	// A new config is tentative until it passes the post-update health checks
+	// of the pool, the host rolls it back otherwise.
+	if state.pendingConfig != nil {
+		if err := dn.waitForPostUpdateHealth(state.pendingConfig); err != nil {
+			return err
+		}
+	}
+	if err := dn.removeUpdateJournal(); err != nil {
+		return err
+	}
+
 	// We've validated our state.  In the case where we had a pendingConfig,
 	// make that now currentConfig.  We update the node annotation, delete the
 	// state file, etc.
@@ -1014,6 +1086,9 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 	// were coming up, so we next look at that before uncordoning the node (so
 	// we don't uncordon and then immediately re-cordon)
 	if state.pendingConfig != nil {
+		if err := dn.runHook(state.pendingConfig, postUpdateHookTarget); err != nil {
+			return err
+		}
 		if dn.recorder != nil {
 			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "NodeDone", fmt.Sprintf("Setting node %s, currentConfig %s to Done", dn.node.Name, state.pendingConfig.GetName()))
 		}
@@ -1056,6 +1131,7 @@ func (dn *Daemon) checkStateOnFirstRun() error {
 
 		glog.Infof("In desired config %s", state.currentConfig.GetName())
 		MCDUpdateState.WithLabelValues(state.currentConfig.GetName(), "").SetToCurrentTime()
+		setLastUpdateResult(state.currentConfig.GetName(), true)
 
 		// All good!
 		return nil
@@ -1165,11 +1241,33 @@ func (dn *Daemon) prepUpdateFromCluster() (*mcfgv1.MachineConfig, *mcfgv1.Machin
 	return currentConfig, desiredConfig, nil
 }
 
-// completeUpdate marks the node as schedulable again, then deletes the
+// isStaged returns whether the daemon staged an update on the node.
+func isStaged(node *corev1.Node) bool {
+	return node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] == constants.MachineConfigDaemonStateWorking &&
+		node.Annotations[constants.MachineConfigDaemonPhaseAnnotationKey] == constants.MachineConfigDaemonPhaseStaged
+}
+
+// getStagedConfig returns the config of the staged update if it's still the desiredConfig of the node.
+func (dn *Daemon) getStagedConfig() (*mcfgv1.MachineConfig, error) {
+	pendingState, err := dn.getPendingState()
+	if err != nil {
+		return nil, err
+	}
+	if pendingState == nil || pendingState.BootID != dn.bootID {
+		return nil, nil
+	}
+	if pendingState.Message != dn.node.Annotations[constants.DesiredMachineConfigAnnotationKey] {
+		return nil, nil
+	}
+	return dn.mcLister.Get(pendingState.Message)
+}
+
+// completeUpdate marks the node as schedulable again, unless it was cordoned
+// by someone else before the update, then deletes the
 // "transient state" file, which signifies that all of those prior steps have
 // been completed.
 func (dn *Daemon) completeUpdate(node *corev1.Node, desiredConfigName string) error {
-	if err := drain.RunCordonOrUncordon(dn.drainer, node, false); err != nil {
+	if err := dn.uncordon(node); err != nil {
 		return err
 	}
 
@@ -1203,6 +1301,10 @@ func (dn *Daemon) triggerUpdateWithMachineConfig(currentConfig, desiredConfig *m
 		}
 	}
 
+	if rollback := getHealthCheckRollback(dn.node); rollback != nil && rollback.From == desiredConfig.GetName() {
+		return fmt.Errorf("not updating to config %s again, it was rolled back at %s after failing its post-update health checks: %s", rollback.From, rollback.Time, rollback.Reason)
+	}
+
 	// run the update process. this function doesn't currently return.
 	return dn.update(currentConfig, desiredConfig)
 }
@@ -1211,30 +1313,24 @@ func (dn *Daemon) triggerUpdateWithMachineConfig(currentConfig, desiredConfig *m
 // specifies.  If for example an admin ssh'd into a node, or another operator
 // is stomping on our files, we want to highlight that and mark the system
 // degraded.
-func (dn *Daemon) validateOnDiskState(currentConfig *mcfgv1.MachineConfig) bool {
+func (dn *Daemon) validateOnDiskState(currentConfig *mcfgv1.MachineConfig) error {
 	// Be sure we're booted into the OS we expect
 	osMatch, err := dn.checkOS(currentConfig.Spec.OSImageURL)
 	if err != nil {
-		glog.Errorf("%s", err)
-		return false
+		return err
 	}
 	if !osMatch {
-		glog.Errorf("expected target osImageURL %s", currentConfig.Spec.OSImageURL)
-		return false
+		return fmt.Errorf("expected target osImageURL %q, have %q", currentConfig.Spec.OSImageURL, dn.bootedOSImageURL)
 	}
 	// And the rest of the disk state
-	currentIgnConfig, report, err := ign.Parse(currentConfig.Spec.Config.Raw)
+	currentIgnConfig, err := ctrlcommon.ParseAndConvertConfig(currentConfig.Spec.Config.Raw)
 	if err != nil {
-		glog.Errorf("Failed to parse Ignition for validation: %s\nReport: %v", err, report)
-		return false
-	}
-	if !checkFiles(currentIgnConfig.Storage.Files) {
-		return false
-	}
-	if !checkUnits(currentIgnConfig.Systemd.Units) {
-		return false
+		return errors.Wrapf(err, "parsing Ignition for validation")
 	}
-	return true
+	return utilerrors.NewAggregate([]error{
+		checkFiles(dn.managedFiles(currentIgnConfig.Storage.Files)),
+		checkUnits(currentIgnConfig.Systemd.Units),
+	})
 }
 
 // getRefDigest parses a Docker/OCI image reference and returns
@@ -1298,52 +1394,75 @@ func compareOSImageURL(current, desired string) (bool, error) {
 // Otherwise if `false` is returned, then we need to perform an update.
 func (dn *Daemon) checkOS(osImageURL string) (bool, error) {
 	// Nothing to do if we're not on RHCOS or FCOS
-	if dn.OperatingSystem != machineConfigDaemonOSRHCOS && dn.OperatingSystem != machineConfigDaemonOSFCOS {
+	if !isCoreOSVariant(dn.OperatingSystem) {
 		glog.Infof(`Not booted into a CoreOS variant, ignoring target OSImageURL %s`, osImageURL)
 		return true, nil
 	}
 
-	return compareOSImageURL(dn.bootedOSImageURL, osImageURL)
+	osMatch, err := compareOSImageURL(dn.bootedOSImageURL, osImageURL)
+	if err != nil || osMatch {
+		return osMatch, err
+	}
+	return dn.isOSImageAlias(osImageURL)
 }
 
 // checkUnits validates the contents of all the units in the
-// target config and returns true if they match.
-func checkUnits(units []igntypes.Unit) bool {
+// target config and returns an error listing the ones which don't match.
+func checkUnits(units []igntypes.Unit) error {
+	var errs []error
 	for _, u := range units {
-		for j := range u.Dropins {
-			path := filepath.Join(pathSystemd, u.Name+".d", u.Dropins[j].Name)
-			if status := checkFileContentsAndMode(path, []byte(u.Dropins[j].Contents), defaultFilePermissions); !status {
-				return false
-			}
-		}
+		errs = append(errs, checkUnit(u)...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
 
-		if u.Contents == "" {
-			continue
+// checkUnit validates the contents of the unit and of its dropins, and returns
+// the mismatches.
+func checkUnit(u igntypes.Unit) []error {
+	var errs []error
+	for j := range u.Dropins {
+		path := filepath.Join(pathSystemd, u.Name+".d", u.Dropins[j].Name)
+		if err := checkFileContentsAndMode(path, []byte(u.Dropins[j].Contents), defaultFilePermissions); err != nil {
+			errs = append(errs, err)
 		}
+	}
 
-		path := filepath.Join(pathSystemd, u.Name)
-		if u.Mask {
-			link, err := filepath.EvalSymlinks(path)
-			if err != nil {
-				glog.Errorf("state validation: error while evaluation symlink for path: %q, err: %v", path, err)
-				return false
-			}
-			if strings.Compare(pathDevNull, link) != 0 {
-				glog.Errorf("state validation: invalid unit masked setting. path: %q; expected: %v; received: %v", path, pathDevNull, link)
-				return false
-			}
+	if u.Contents == "" {
+		return errs
+	}
+
+	path := filepath.Join(pathSystemd, u.Name)
+	if u.Mask {
+		link, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return append(errs, fmt.Errorf("error while evaluation symlink for path %q: %v", path, err))
 		}
-		if status := checkFileContentsAndMode(path, []byte(u.Contents), defaultFilePermissions); !status {
-			return false
+		if strings.Compare(pathDevNull, link) != 0 {
+			return append(errs, fmt.Errorf("invalid unit masked setting. path: %q; expected: %v; received: %v", path, pathDevNull, link))
 		}
+	}
+	if err := checkFileContentsAndMode(path, []byte(u.Contents), defaultFilePermissions); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
 
+// checkFiles validates the contents of all the files in the
+// target config and returns an error listing the ones which don't match.
+func checkFiles(files []igntypes.File) error {
+	var errs []error
+	for _, f := range lastFilesByPath(files) {
+		if err := checkFile(f); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return true
+	return utilerrors.NewAggregate(errs)
 }
 
-// checkFiles validates the contents of  all the files in the
-// target config.
-func checkFiles(files []igntypes.File) bool {
+// lastFilesByPath returns the files in reverse order, skipping the ones
+// overridden by a later file with the same path.
+func lastFilesByPath(files []igntypes.File) []igntypes.File {
+	var out []igntypes.File
 	checkedFiles := make(map[string]bool)
 	for i := len(files) - 1; i >= 0; i-- {
 		f := files[i]
@@ -1351,47 +1470,44 @@ func checkFiles(files []igntypes.File) bool {
 		if _, ok := checkedFiles[f.Path]; ok {
 			continue
 		}
-		mode := defaultFilePermissions
-		if f.Mode != nil {
-			mode = os.FileMode(*f.Mode)
-		}
-		contents, err := dataurl.DecodeString(f.Contents.Source)
-		if err != nil {
-			glog.Errorf("couldn't parse file: %v", err)
-			return false
-		}
-		if status := checkFileContentsAndMode(f.Path, contents.Data, mode); !status {
-			return false
-		}
 		checkedFiles[f.Path] = true
+		out = append(out, f)
 	}
-	return true
+	return out
+}
+
+// checkFile validates the contents and mode of the file.
+func checkFile(f igntypes.File) error {
+	mode := defaultFilePermissions
+	if f.Mode != nil {
+		mode = os.FileMode(*f.Mode)
+	}
+	contents, err := dataurl.DecodeString(f.Contents.Source)
+	if err != nil {
+		return fmt.Errorf("couldn't parse file %q: %v", f.Path, err)
+	}
+	return checkFileContentsAndMode(f.Path, contents.Data, mode)
 }
 
 // checkFileContentsAndMode reads the file from the filepath and compares its
-// contents and mode with the expectedContent and mode parameters. It logs an
-// error in case of an error or mismatch and returns the status of the
-// evaluation.
-func checkFileContentsAndMode(filePath string, expectedContent []byte, mode os.FileMode) bool {
+// contents and mode with the expectedContent and mode parameters. It returns
+// an error describing the mismatch, if any.
+func checkFileContentsAndMode(filePath string, expectedContent []byte, mode os.FileMode) error {
 	fi, err := os.Lstat(filePath)
 	if err != nil {
-		glog.Errorf("could not stat file: %q, error: %v", filePath, err)
-		return false
+		return fmt.Errorf("could not stat file %q: %v", filePath, err)
 	}
 	if fi.Mode() != mode {
-		glog.Errorf("mode mismatch for file: %q; expected: %v; received: %v", filePath, mode, fi.Mode())
-		return false
+		return fmt.Errorf("mode mismatch for file %q; expected: %v; received: %v", filePath, mode, fi.Mode())
 	}
 	contents, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		glog.Errorf("could not read file: %q, error: %v", filePath, err)
-		return false
+		return fmt.Errorf("could not read file %q: %v", filePath, err)
 	}
 	if !bytes.Equal(contents, expectedContent) {
-		glog.Errorf("content mismatch for file %s: %s", filePath, diff.StringDiff(string(contents), string(expectedContent)))
-		return false
+		return fmt.Errorf("content mismatch for file %q: %s", filePath, diff.StringDiff(string(contents), string(expectedContent)))
 	}
-	return true
+	return nil
 }
 
 // Close closes all the connections the node agent has open for it's lifetime
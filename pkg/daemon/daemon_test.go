@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -74,8 +75,8 @@ func TestOverwrittenFile(t *testing.T) {
 		},
 	}
 
-	if status := checkFiles(files); !status {
-		t.Errorf("Invalid files")
+	if err := checkFiles(files); err != nil {
+		t.Errorf("Invalid files: %v", err)
 	}
 
 	// validate overwritten file
@@ -104,8 +105,52 @@ func TestOverwrittenFile(t *testing.T) {
 		},
 	}
 
-	if status := checkFiles(files); !status {
-		t.Errorf("Validating an overwritten file failed")
+	if err := checkFiles(files); err != nil {
+		t.Errorf("Validating an overwritten file failed: %v", err)
+	}
+}
+
+func TestCheckFilesReportsDrift(t *testing.T) {
+	fi, err := os.Lstat("fixtures/test1.txt")
+	if err != nil {
+		t.Fatalf("Could not Lstat file: %v", err)
+	}
+	fileMode := int(fi.Mode().Perm())
+
+	files := []igntypes.File{
+		{
+			Node: igntypes.Node{
+				Path: "fixtures/test1.txt",
+			},
+			FileEmbedded1: igntypes.FileEmbedded1{
+				Contents: igntypes.FileContents{
+					Source: dataurl.EncodeBytes([]byte("hello\n")),
+				},
+				Mode: &fileMode,
+			},
+		},
+		{
+			Node: igntypes.Node{
+				Path: "fixtures/missing.txt",
+			},
+			FileEmbedded1: igntypes.FileEmbedded1{
+				Contents: igntypes.FileContents{
+					Source: dataurl.EncodeBytes([]byte("hello\n")),
+				},
+				Mode: &fileMode,
+			},
+		},
+	}
+
+	err = checkFiles(files)
+	if err == nil {
+		t.Fatalf("Expected drifted files to be reported")
+	}
+	// every drifted file is reported
+	for _, path := range []string{"fixtures/test1.txt", "fixtures/missing.txt"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected %s in %q", path, err)
+		}
 	}
 }
 
@@ -176,6 +221,7 @@ func (f *fixture) newController() *Daemon {
 	}
 	d.ClusterConnect("node_name_test",
 		f.kubeclient,
+		f.client,
 		i.Machineconfiguration().V1().MachineConfigs(),
 		k8sI.Core().V1().Nodes(),
 		false,
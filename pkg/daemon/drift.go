@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	errors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// driftCheckInterval is how often the files and units on disk are checked
+// against the current config once the node is up to date.
+const driftCheckInterval = 30 * time.Minute
+
+// requestDriftCheck has the worker validate the on-disk state on its next sync.
+func (dn *Daemon) requestDriftCheck() {
+	atomic.StoreInt32(&dn.driftCheckRequested, 1)
+	dn.queue.Add(dn.name)
+}
+
+// isConfigDrifted returns whether the daemon reported the on-disk state of the node
+// drifted from its current config.
+func isConfigDrifted(node *corev1.Node) bool {
+	return node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] == constants.MachineConfigDaemonStateDegraded &&
+		node.Annotations[constants.MachineConfigDaemonPhaseAnnotationKey] == constants.MachineConfigDaemonPhaseConfigDrifted
+}
+
+// syncConfigDrift validates the on-disk state of an up to date node when it's
+// due, when an administrator asked for it, or while the node is reported drifted.
+// A drifted node is marked Degraded with the mismatches found, or gets its current
+// config written back to disk when its pool auto remediates drift. It returns
+// whether the sync of the node was handled.
+func (dn *Daemon) syncConfigDrift() (bool, error) {
+	node := dn.node
+	requested := atomic.SwapInt32(&dn.driftCheckRequested, 0) == 1
+	if value := node.Annotations[constants.ValidateOnDiskStateAnnotationKey]; value != "" && value != dn.lastValidateRequest {
+		dn.lastValidateRequest = value
+		requested = true
+	}
+	drifted := isConfigDrifted(node)
+	if !requested && !drifted {
+		return false, nil
+	}
+
+	// updates validate the on-disk state on their own
+	currentConfigName := node.Annotations[constants.CurrentMachineConfigAnnotationKey]
+	if currentConfigName == "" || currentConfigName != node.Annotations[constants.DesiredMachineConfigAnnotationKey] {
+		return false, nil
+	}
+	if !drifted && node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] != constants.MachineConfigDaemonStateDone {
+		return false, nil
+	}
+	currentConfig, err := dn.mcLister.Get(currentConfigName)
+	if err != nil {
+		return false, err
+	}
+
+	validationErr := dn.validateOnDiskState(currentConfig)
+	if validationErr == nil {
+		if !drifted {
+			glog.V(2).Infof("On-disk state matches config %s", currentConfigName)
+			return false, nil
+		}
+		dn.logSystem("On-disk state matches config %s again", currentConfigName)
+		if err := dn.nodeWriter.SetDone(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, currentConfigName); err != nil {
+			return true, errors.Wrap(err, "error setting node's state to Done")
+		}
+		return true, nil
+	}
+
+	if !drifted {
+		MCDDriftDetections.WithLabelValues(configMetricLabels(currentConfigName)).Inc()
+	}
+	if node.Annotations[constants.AutoRemediateDriftAnnotationKey] == "true" {
+		dn.logSystem("On-disk state drifted from config %s, writing it back: %v", currentConfigName, validationErr)
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(node), corev1.EventTypeWarning, "ConfigDriftRemediated", fmt.Sprintf("Writing config %s back to disk", currentConfigName))
+		}
+		return true, dn.triggerUpdateWithMachineConfig(currentConfig, currentConfig)
+	}
+
+	if !drifted && dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(node), corev1.EventTypeWarning, "ConfigDrift", fmt.Sprintf("On-disk state drifted from config %s", currentConfigName))
+	}
+	dn.setPhase(constants.MachineConfigDaemonPhaseConfigDrifted)
+	return true, errors.Wrapf(validationErr, "on-disk state drifted from config %s", currentConfigName)
+}
@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+// nodeStateFiles are the files of the host copied by DumpState, when present.
+var nodeStateFiles = []string{
+	currentConfigPath,
+	constants.FirstbootProgressPath,
+	constants.MachineConfigEncapsulatedPath,
+	constants.MachineConfigEncapsulatedBakPath,
+	constants.InitialNodeAnnotationsFilePath,
+	constants.InitialNodeAnnotationsBakPath,
+}
+
+// nodeStateDump is the summary of the node written by DumpState.
+type nodeStateDump struct {
+	OperatingSystem  string `json:"operatingSystem"`
+	BootedOSImageURL string `json:"bootedOSImageURL"`
+	BootID           string `json:"bootID"`
+	// RpmOstreeStatus is the output of rpm-ostree status on CoreOS hosts.
+	RpmOstreeStatus string `json:"rpmOstreeStatus,omitempty"`
+	// Validation is the check of the node against its current config.
+	Validation *ValidationReport `json:"validation,omitempty"`
+	// Errors are the parts of the state which couldn't be collected.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DumpState writes to dir a snapshot of the MCO state of the node: the booted
+// OS, the check of the node against its current config, and the state files of
+// the daemon, to go along with the cluster state written by
+// `machine-config-controller dump-state`.
+func (dn *Daemon) DumpState(dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0755); err != nil {
+		return err
+	}
+
+	state := nodeStateDump{
+		OperatingSystem:  dn.OperatingSystem,
+		BootedOSImageURL: dn.bootedOSImageURL,
+		BootID:           dn.bootID,
+	}
+	if isCoreOSVariant(dn.OperatingSystem) {
+		status, err := dn.NodeUpdaterClient.GetStatus()
+		if err != nil {
+			state.Errors = append(state.Errors, err.Error())
+		}
+		state.RpmOstreeStatus = status
+	}
+	report, err := dn.ValidateCurrentConfig()
+	if err != nil {
+		state.Errors = append(state.Errors, err.Error())
+	}
+	state.Validation = report
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "node.json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	for _, path := range nodeStateFiles {
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			glog.Warningf("Not copying %s: %v", path, err)
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "files", filepath.Base(path)), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// failureJournalLines is the number of journal lines recorded with an update failure.
+	failureJournalLines = 20
+	// failureJournalLineLength caps the recorded journal lines, to stay well
+	// under the annotation size limit.
+	failureJournalLineLength = 512
+)
+
+// failureJournalMarkers are found in the journal lines relevant to an update failure:
+// the ones logged by the daemon, rpm-ostree and pivot.
+var failureJournalMarkers = []string{daemonJournalMarker, "rpm-ostree[", "rpm-ostreed[", "pivot["}
+
+// updateFailure is the body of the lastUpdateFailure annotation.
+type updateFailure struct {
+	Time    metav1.Time `json:"time"`
+	Config  string      `json:"config,omitempty"`
+	Reason  string      `json:"reason"`
+	Journal []string    `json:"journal,omitempty"`
+}
+
+// newUpdateFailure returns the failure to sync to config with the journal the
+// relevant lines are taken from.
+func newUpdateFailure(err error, config, journal string) updateFailure {
+	failure := updateFailure{
+		Time:   metav1.Now(),
+		Config: config,
+		Reason: fmt.Sprintf("%.2000s", err.Error()),
+	}
+	for _, line := range filterJournal(journal, failureJournalLines, failureJournalMarkers...) {
+		failure.Journal = append(failure.Journal, fmt.Sprintf("%.*s", failureJournalLineLength, line))
+	}
+	return failure
+}
+
+// reportUpdateFailure makes a failure to sync the node visible in the API: an
+// event is emitted on the node and the last relevant journal lines are
+// recorded in the lastUpdateFailure annotation, so that it can be debugged
+// without accessing the node.
+func (dn *Daemon) reportUpdateFailure(err error) {
+	if dn.nodeWriter == nil || dn.kubeClient == nil || dn.node == nil {
+		return
+	}
+	config := dn.node.Annotations[constants.DesiredMachineConfigAnnotationKey]
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "UpdateFailed", "Failed to update to config %s: %.1000s", config, err.Error())
+	}
+
+	journal, jerr := exec.Command("journalctl", "-o", "short-iso", "--no-pager", "-n", "5000", "_UID=0").Output()
+	if jerr != nil {
+		glog.Warningf("Failed to read the journal for the update failure: %v", jerr)
+	}
+	data, jerr := json.Marshal(newUpdateFailure(err, config, string(journal)))
+	if jerr != nil {
+		glog.Warningf("Failed to encode the update failure: %v", jerr)
+		return
+	}
+	if jerr := dn.nodeWriter.SetUpdateFailure(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, string(data)); jerr != nil {
+		glog.Warningf("Failed to record the update failure: %v", jerr)
+	}
+}
@@ -0,0 +1,27 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUpdateFailure(t *testing.T) {
+	journal := `2020-06-01T10:00:00+0000 host root[1]: machine-config-daemon[42]: Starting update from a to b
+2020-06-01T10:00:01+0000 host kernel: something else
+2020-06-01T10:00:02+0000 host rpm-ostree[7]: Txn Rebase on /org/projectatomic/rpmostree1/rhcos failed: No space left on device
+2020-06-01T10:00:03+0000 host root[1]: machine-config-daemon[42]: ` + strings.Repeat("x", 1000)
+
+	failure := newUpdateFailure(fmt.Errorf("failed to run pivot"), "rendered-worker-2", journal)
+	assert.Equal(t, "rendered-worker-2", failure.Config)
+	assert.Equal(t, "failed to run pivot", failure.Reason)
+	assert.Len(t, failure.Journal, 3)
+	assert.Contains(t, failure.Journal[1], "No space left on device")
+	assert.Len(t, failure.Journal[2], failureJournalLineLength)
+
+	failure = newUpdateFailure(fmt.Errorf("%s", strings.Repeat("e", 3000)), "", "")
+	assert.Len(t, failure.Reason, 2000)
+	assert.Empty(t, failure.Journal)
+}
@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// firstbootAttempts is the number of times a firstboot step is run before
+	// the firstboot pivot fails.
+	firstbootAttempts = 3
+	// firstbootBackoff is the wait before retrying a failed firstboot step, doubled on
+	// every retry.
+	firstbootBackoff = 15 * time.Second
+)
+
+// firstbootProgress is the step of the firstboot pivot the host is in, persisted in
+// constants.FirstbootProgressPath.
+type firstbootProgress struct {
+	Phase   string    `json:"phase"`
+	Attempt int       `json:"attempt"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+}
+
+// firstbootStep is a step of the firstboot pivot, retried with a backoff.
+type firstbootStep struct {
+	phase string
+	run   func() error
+}
+
+// SetFirstbootNode has the firstboot pivot report its progress on the node name
+// as well, when the node is already registered.
+func (dn *Daemon) SetFirstbootNode(name string, nodes corev1client.NodeInterface) {
+	dn.name = name
+	logging.SetField("node", name)
+	dn.firstbootNodes = nodes
+}
+
+// runFirstbootSteps runs steps in order. A failing step is retried attempts times
+// in total, waiting backoff and then twice as long every time. report is called
+// on every phase change and retry, so that a slow pivot keeps reporting progress
+// while a hung one doesn't.
+func runFirstbootSteps(steps []firstbootStep, attempts int, backoff time.Duration, report func(firstbootProgress)) error {
+	for _, step := range steps {
+		wait := backoff
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			report(firstbootProgress{Phase: step.phase, Attempt: attempt, Time: time.Now()})
+			if err = step.run(); err == nil {
+				break
+			}
+			// an unreconcilable config fails the same way every time
+			if errors.Cause(err) == errUnreconcilable || attempt == attempts {
+				break
+			}
+			glog.Warningf("Firstboot %s failed (attempt %d/%d), retrying in %v: %v", step.phase, attempt, attempts, wait, err)
+			time.Sleep(wait)
+			wait *= 2
+		}
+		if err != nil {
+			report(firstbootProgress{Phase: constants.FirstbootPhaseFailed, Time: time.Now(), Message: fmt.Sprintf("%s: %v", step.phase, err)})
+			return err
+		}
+	}
+	return nil
+}
+
+// reportFirstbootProgress persists progress on the host and, when the node is
+// registered, reports it on the node. Failing to do so doesn't fail the pivot.
+func (dn *Daemon) reportFirstbootProgress(progress firstbootProgress) {
+	logging.SetField("phase", progress.Phase)
+	glog.Infof("Firstboot phase: %s (attempt %d)", progress.Phase, progress.Attempt)
+
+	data, err := json.Marshal(progress)
+	if err == nil {
+		err = writeFileAtomicallyWithDefaults(constants.FirstbootProgressPath, data)
+	}
+	if err != nil {
+		glog.Warningf("Failed to persist the firstboot progress: %v", err)
+	}
+
+	if dn.firstbootNodes == nil {
+		return
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				constants.FirstbootPhaseAnnotationKey:    progress.Phase,
+				constants.FirstbootProgressAnnotationKey: progress.Time.UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		glog.Warningf("Failed to report the firstboot progress on node %s: %v", dn.name, err)
+		return
+	}
+	if _, err := dn.firstbootNodes.Patch(context.TODO(), dn.name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		glog.V(2).Infof("Not reporting the firstboot progress on node %s: %v", dn.name, err)
+	}
+}
+
+// RunFirstbootCompleteMachineconfig is run via systemd on the first boot
+// to complete processing of the target MachineConfig. It pulls the OS image
+// (Staging), writes the config and pivots (Pivoting) and reboots (Rebooting),
+// reporting each phase and retry.
+func (dn *Daemon) RunFirstbootCompleteMachineconfig() error {
+	data, err := ioutil.ReadFile(constants.MachineConfigEncapsulatedPath)
+	if err != nil {
+		return err
+	}
+	var mc mcfgv1.MachineConfig
+	err = json.Unmarshal(data, &mc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse MachineConfig")
+	}
+
+	// Start with an empty config, then add our *booted* osImageURL to
+	// it, reflecting the current machine state.
+	oldConfig := canonicalizeEmptyMC(nil)
+	oldConfig.Spec.OSImageURL = dn.bootedOSImageURL
+	// Currently, we generally expect the bootimage to be older, but in the special
+	// case of having bootimage == machine-os-content, and no kernel arguments
+	// specified, then we don't need to do anything here.
+	mcDiffNotEmpty, err := dn.compareMachineConfig(oldConfig, &mc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compare MachineConfig")
+	}
+	if !mcDiffNotEmpty {
+		// Removing this file signals completion of the initial MC processing.
+		if err := os.Remove(constants.MachineConfigEncapsulatedPath); err != nil {
+			return errors.Wrapf(err, "failed to remove %s", constants.MachineConfigEncapsulatedPath)
+		}
+		dn.reportFirstbootProgress(firstbootProgress{Phase: constants.FirstbootPhaseDone, Attempt: 1, Time: time.Now()})
+		return nil
+	}
+
+	dn.skipReboot = true
+	steps := []firstbootStep{
+		{phase: constants.FirstbootPhaseStaging, run: func() error { return dn.prePullOSImage(&mc) }},
+		{phase: constants.FirstbootPhasePivoting, run: func() error { return dn.update(nil, &mc) }},
+	}
+	if err := runFirstbootSteps(steps, firstbootAttempts, firstbootBackoff, dn.reportFirstbootProgress); err != nil {
+		return err
+	}
+
+	// Removing this file signals completion of the initial MC processing.
+	if err := os.Rename(constants.MachineConfigEncapsulatedPath, constants.MachineConfigEncapsulatedBakPath); err != nil {
+		return errors.Wrap(err, "failed to rename encapsulated MachineConfig after processing on firstboot")
+	}
+
+	dn.skipReboot = false
+	dn.reportFirstbootProgress(firstbootProgress{Phase: constants.FirstbootPhaseRebooting, Attempt: 1, Time: time.Now()})
+	return dn.reboot(fmt.Sprintf("Completing firstboot provisioning to %s", mc.GetName()))
+}
+
+// reportFirstbootDone reports on the node that it booted into its initial
+// MachineConfig, once the firstboot pivot persisted its progress.
+func (dn *Daemon) reportFirstbootDone() error {
+	if _, err := os.Stat(constants.FirstbootProgressPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := dn.nodeWriter.SetFirstbootPhase(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, constants.FirstbootPhaseDone, time.Now()); err != nil {
+		return errors.Wrap(err, "reporting the firstboot progress")
+	}
+	return os.Remove(constants.FirstbootProgressPath)
+}
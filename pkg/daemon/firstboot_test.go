@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunFirstbootSteps(t *testing.T) {
+	var phases []string
+	report := func(p firstbootProgress) { phases = append(phases, p.Phase) }
+
+	pulls := 0
+	steps := []firstbootStep{
+		{phase: constants.FirstbootPhaseStaging, run: func() error {
+			pulls++
+			if pulls < 2 {
+				return errors.New("pull failed")
+			}
+			return nil
+		}},
+		{phase: constants.FirstbootPhasePivoting, run: func() error { return nil }},
+	}
+	assert.Nil(t, runFirstbootSteps(steps, 3, 0, report))
+	assert.Equal(t, []string{constants.FirstbootPhaseStaging, constants.FirstbootPhaseStaging, constants.FirstbootPhasePivoting}, phases)
+
+	// retries are bounded
+	phases = nil
+	pivots := 0
+	steps[1].run = func() error {
+		pivots++
+		return errors.New("pivot failed")
+	}
+	pulls = 0
+	assert.EqualError(t, runFirstbootSteps(steps, 3, 0, report), "pivot failed")
+	assert.Equal(t, 3, pivots)
+	assert.Equal(t, constants.FirstbootPhaseFailed, phases[len(phases)-1])
+
+	// unreconcilable configs aren't retried
+	pivots = 0
+	steps[1].run = func() error {
+		pivots++
+		return errUnreconcilable
+	}
+	assert.NotNil(t, runFirstbootSteps(steps, 3, 0, report))
+	assert.Equal(t, 1, pivots)
+}
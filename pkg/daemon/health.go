@@ -0,0 +1,375 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// The post-update health checks of a pool are run on the host, by
+// machine-config-daemon-update-health.service, rather than by the daemon: a
+// config breaking crio or the kubelet would keep the daemon pod from running,
+// and so from ever rolling it back. Before rebooting into a config, the daemon
+// hands the health check over to the host at postUpdateHealthPath. Once the
+// node booted into the config, the service checks its health and, if the
+// checks don't pass in time, rolls back the OS with rpm-ostree rollback and
+// reboots. Before crio and the kubelet start on the next boot, the service
+// restores the files of the previous config, and the daemon then records the
+// rollback on the node.
+
+const (
+	// defaultHealthCheckTimeout is how long the post-update health checks have
+	// to pass when the pool doesn't set a timeout.
+	defaultHealthCheckTimeout = 10 * time.Minute
+	// healthCheckInterval is how often the post-update health checks run until
+	// they all pass.
+	healthCheckInterval = 10 * time.Second
+	// healthCheckGracePeriod is how long the daemon waits for the host past the
+	// timeout of the checks.
+	healthCheckGracePeriod = 2 * time.Minute
+)
+
+// postUpdateHealthChecks run the post-update health checks, they return nil
+// once the node is healthy.
+var postUpdateHealthChecks = map[mcfgv1.PostUpdateHealthCheckType]func(dn *Daemon) error{
+	mcfgv1.PostUpdateHealthCheckKubelet: (*Daemon).checkKubeletHealth,
+	mcfgv1.PostUpdateHealthCheckCRIO:    (*Daemon).checkCRIOHealth,
+}
+
+// healthCheckRollback is the body of the lastHealthCheckRollback annotation.
+type healthCheckRollback struct {
+	Time metav1.Time `json:"time"`
+	// From is the config which failed its health checks.
+	From string `json:"from"`
+	// To is the config rolled back to.
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// postUpdateHealth is the post-update health check of an update the daemon
+// hands over to the host.
+type postUpdateHealth struct {
+	Check mcfgv1.PostUpdateHealthCheck `json:"check"`
+	// From is the config updated from, restored if To fails its checks.
+	From *mcfgv1.MachineConfig `json:"from"`
+	To   *mcfgv1.MachineConfig `json:"to"`
+	// BootID is the boot the update was applied in, the checks run once the
+	// node rebooted.
+	BootID string `json:"bootID"`
+	// Deployment is the rpm-ostree deployment booted before the update, rolled
+	// back to if the update deployed another one.
+	Deployment string `json:"deployment,omitempty"`
+	// Rollback is set once the checks failed.
+	Rollback *healthCheckRollback `json:"rollback,omitempty"`
+	// Restored is set once the files of From are restored.
+	Restored bool `json:"restored,omitempty"`
+}
+
+// getPostUpdateHealthCheck returns the post-update health check the node
+// controller set for the pool of the node, nil to complete updates without
+// checking the health of the node.
+func getPostUpdateHealthCheck(node *corev1.Node) *mcfgv1.PostUpdateHealthCheck {
+	if node == nil {
+		return nil
+	}
+	value, ok := node.Annotations[constants.PostUpdateHealthCheckAnnotationKey]
+	if !ok || value == "" {
+		return nil
+	}
+	check := &mcfgv1.PostUpdateHealthCheck{}
+	if err := json.Unmarshal([]byte(value), check); err != nil {
+		glog.Warningf("Ignoring invalid %s annotation: %v", constants.PostUpdateHealthCheckAnnotationKey, err)
+		return nil
+	}
+	return check
+}
+
+// getHealthCheckRollback returns the last update the daemon rolled back after
+// failing its health checks, nil if there's none.
+func getHealthCheckRollback(node *corev1.Node) *healthCheckRollback {
+	if node == nil {
+		return nil
+	}
+	value, ok := node.Annotations[constants.HealthCheckRollbackAnnotationKey]
+	if !ok || value == "" {
+		return nil
+	}
+	rollback := &healthCheckRollback{}
+	if err := json.Unmarshal([]byte(value), rollback); err != nil {
+		glog.Warningf("Ignoring invalid %s annotation: %v", constants.HealthCheckRollbackAnnotationKey, err)
+		return nil
+	}
+	return rollback
+}
+
+// getPostUpdateHealth returns the post-update health check handed over to the
+// host, nil if there's none.
+func (dn *Daemon) getPostUpdateHealth() (*postUpdateHealth, error) {
+	data, err := ioutil.ReadFile(dn.postUpdateHealthPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var health postUpdateHealth
+	if err := json.Unmarshal(data, &health); err != nil {
+		return nil, errors.Wrapf(err, "parsing post-update health check %s", dn.postUpdateHealthPath)
+	}
+	return &health, nil
+}
+
+// savePostUpdateHealth writes health on disk.
+func (dn *Daemon) savePostUpdateHealth(health *postUpdateHealth) error {
+	data, err := json.Marshal(health)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicallyWithDefaults(dn.postUpdateHealthPath, data)
+}
+
+// removePostUpdateHealth marks the post-update health check as done.
+func (dn *Daemon) removePostUpdateHealth() error {
+	if err := os.Remove(dn.postUpdateHealthPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing post-update health check")
+	}
+	return nil
+}
+
+// handOverPostUpdateHealth hands the post-update health check of the pool,
+// if any, of the update from oldConfig to newConfig over to the host before
+// rebooting. Only CoreOS hosts run it.
+func (dn *Daemon) handOverPostUpdateHealth(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+	check := getPostUpdateHealthCheck(dn.node)
+	if check == nil {
+		return dn.removePostUpdateHealth()
+	}
+	if !isCoreOSVariant(dn.OperatingSystem) {
+		glog.Warningf("Skipping the post-update health checks of config %s, only CoreOS hosts run them", newConfig.GetName())
+		return dn.removePostUpdateHealth()
+	}
+	deployment, err := dn.NodeUpdaterClient.GetBootedDeployment()
+	if err != nil {
+		return errors.Wrap(err, "getting the booted deployment")
+	}
+	return dn.savePostUpdateHealth(&postUpdateHealth{
+		Check:      *check,
+		From:       oldConfig,
+		To:         newConfig,
+		BootID:     dn.bootID,
+		Deployment: deployment.ID,
+	})
+}
+
+// checkKubeletHealth returns an error until the kubelet is healthy.
+func (dn *Daemon) checkKubeletHealth() error {
+	if err := dn.getHealth(); err != nil {
+		return errors.Wrap(err, "kubelet isn't healthy")
+	}
+	return nil
+}
+
+// checkCRIOHealth returns an error until crio is active.
+func (dn *Daemon) checkCRIOHealth() error {
+	if err := exec.Command("systemctl", "is-active", "--quiet", "crio.service").Run(); err != nil {
+		return fmt.Errorf("crio.service isn't active")
+	}
+	return nil
+}
+
+// waitForHealthChecks runs checks every interval until they all pass, it
+// returns the last failure if they still don't after timeout.
+func waitForHealthChecks(checks []func() error, interval, timeout time.Duration) error {
+	var lastErr error
+	err := wait.PollImmediate(interval, timeout, func() (bool, error) {
+		for _, check := range checks {
+			if lastErr = check(); lastErr != nil {
+				glog.Infof("Post-update health check failed: %v", lastErr)
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		return fmt.Errorf("health checks didn't pass within %v: %v", timeout, lastErr)
+	}
+	return err
+}
+
+// getHealthCheckTimeout returns how long the checks of check have to pass.
+func getHealthCheckTimeout(check mcfgv1.PostUpdateHealthCheck) time.Duration {
+	if check.Timeout != nil {
+		return check.Timeout.Duration
+	}
+	return defaultHealthCheckTimeout
+}
+
+// RunPostUpdateHealthCheck is run via systemd once the node booted into the
+// config of the post-update health check handed over to the host. It rolls
+// the update back and reboots if the checks don't pass in time.
+func (dn *Daemon) RunPostUpdateHealthCheck(kubeletHealthzEndpoint string) error {
+	health, err := dn.getPostUpdateHealth()
+	if err != nil || health == nil {
+		return err
+	}
+	if health.Rollback != nil {
+		glog.Infof("Config %s is being rolled back, not checking its health", health.To.GetName())
+		return nil
+	}
+	if health.BootID == dn.bootID {
+		glog.Infof("The node didn't reboot into config %s yet, not checking its health", health.To.GetName())
+		return nil
+	}
+
+	dn.kubeletHealthzEndpoint = kubeletHealthzEndpoint
+	types := health.Check.Checks
+	if len(types) == 0 {
+		types = []mcfgv1.PostUpdateHealthCheckType{mcfgv1.PostUpdateHealthCheckKubelet, mcfgv1.PostUpdateHealthCheckCRIO}
+	}
+	var checks []func() error
+	for _, t := range types {
+		run, ok := postUpdateHealthChecks[t]
+		if !ok {
+			glog.Warningf("Ignoring unknown post-update health check %q", t)
+			continue
+		}
+		checks = append(checks, func() error { return run(dn) })
+	}
+
+	dn.logSystem("Config %s is tentative, running post-update health checks %v", health.To.GetName(), types)
+	err = waitForHealthChecks(checks, healthCheckInterval, getHealthCheckTimeout(health.Check))
+	if err == nil {
+		dn.logSystem("Post-update health checks of config %s passed", health.To.GetName())
+		return dn.removePostUpdateHealth()
+	}
+	err = errors.Wrapf(err, "config %s failed its post-update health checks", health.To.GetName())
+	dn.logSystem("Rolling back from config %s to %s: %v", health.To.GetName(), health.From.GetName(), err)
+
+	health.Rollback = &healthCheckRollback{
+		Time:   metav1.Now(),
+		From:   health.To.GetName(),
+		To:     health.From.GetName(),
+		Reason: fmt.Sprintf("%.2000s", err.Error()),
+	}
+	if err := dn.savePostUpdateHealth(health); err != nil {
+		return errors.Wrap(err, "recording the rollback")
+	}
+	deployment, err := dn.NodeUpdaterClient.GetBootedDeployment()
+	if err != nil {
+		return errors.Wrap(err, "getting the booted deployment")
+	}
+	if deployment.ID != health.Deployment {
+		if out, err := runGetOut("rpm-ostree", "rollback"); err != nil {
+			return errors.Wrapf(err, "rolling back to deployment %s: %s", health.Deployment, string(out))
+		}
+	}
+	return dn.reboot(fmt.Sprintf("Rolling back from config %s to %s", health.To.GetName(), health.From.GetName()))
+}
+
+// RestorePostUpdateRollback is run via systemd before crio and the kubelet
+// start. Once the node rebooted after failing the post-update health checks
+// of a config, it restores the files of the previous one.
+func (dn *Daemon) RestorePostUpdateRollback() error {
+	health, err := dn.getPostUpdateHealth()
+	if err != nil || health == nil || health.Rollback == nil || health.Restored {
+		return err
+	}
+	if health.BootID == dn.bootID {
+		return nil
+	}
+	deployment, err := dn.NodeUpdaterClient.GetBootedDeployment()
+	if err != nil {
+		return errors.Wrap(err, "getting the booted deployment")
+	}
+	if deployment.ID != health.Deployment {
+		return fmt.Errorf("booted deployment %s, rolling back to %s failed", deployment.ID, health.Deployment)
+	}
+
+	dn.logSystem("Restoring config %s rolled back to from %s", health.From.GetName(), health.To.GetName())
+	if err := dn.updateFiles(health.To, health.From); err != nil {
+		return err
+	}
+	toIgn, err := ctrlcommon.ParseAndConvertConfig(health.To.Spec.Config.Raw)
+	if err != nil {
+		return err
+	}
+	fromIgn, err := ctrlcommon.ParseAndConvertConfig(health.From.Spec.Config.Raw)
+	if err != nil {
+		return err
+	}
+	if err := dn.updateSSHKeys(toIgn.Passwd.Users, fromIgn.Passwd.Users); err != nil {
+		return err
+	}
+	if err := dn.storeCurrentConfigOnDisk(health.From); err != nil {
+		return err
+	}
+	if out, err := dn.storePendingState(health.To, 0); err != nil {
+		return errors.Wrapf(err, "failed to reset pending config: %s", string(out))
+	}
+	if err := dn.removeUpdateJournal(); err != nil {
+		return err
+	}
+	health.Restored = true
+	return dn.savePostUpdateHealth(health)
+}
+
+// waitForPostUpdateHealth waits for the host to check the health of the node
+// which rebooted into config, the config is tentative until it does. It
+// returns an error if the host rolls config back, or doesn't check it in time.
+func (dn *Daemon) waitForPostUpdateHealth(config *mcfgv1.MachineConfig) error {
+	health, err := dn.getPostUpdateHealth()
+	if err != nil || health == nil || health.To.GetName() != config.GetName() {
+		return err
+	}
+
+	dn.setPhase(constants.MachineConfigDaemonPhaseTentative)
+	glog.Infof("Config %s is tentative, waiting for its post-update health checks", config.GetName())
+	timeout := getHealthCheckTimeout(health.Check) + healthCheckGracePeriod
+	err = wait.PollImmediate(healthCheckInterval, timeout, func() (bool, error) {
+		health, err = dn.getPostUpdateHealth()
+		return err != nil || health == nil || health.Rollback != nil, err
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("the post-update health checks of config %s didn't complete within %v", config.GetName(), timeout)
+	}
+	if err != nil {
+		return err
+	}
+	if health != nil {
+		return fmt.Errorf("config %s failed its post-update health checks, the node is rolling back to %s: %s", config.GetName(), health.Rollback.To, health.Rollback.Reason)
+	}
+	return nil
+}
+
+// reportPostUpdateRollback records on the node the rollback the host restored,
+// so that the daemon doesn't update to the config rolled back again.
+func (dn *Daemon) reportPostUpdateRollback() error {
+	health, err := dn.getPostUpdateHealth()
+	if err != nil || health == nil || !health.Restored {
+		return err
+	}
+	rollback := health.Rollback
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "UpdateRolledBack", "Rolled back from config %s to %s: %.1000s", rollback.From, rollback.To, rollback.Reason)
+	}
+	data, err := json.Marshal(rollback)
+	if err != nil {
+		return err
+	}
+	if err := dn.nodeWriter.SetHealthCheckRollback(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, string(data)); err != nil {
+		return errors.Wrap(err, "recording the rollback")
+	}
+	return dn.removePostUpdateHealth()
+}
@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPostUpdateHealthCheck(t *testing.T) {
+	node := &corev1.Node{}
+	assert.Nil(t, getPostUpdateHealthCheck(node))
+
+	node.Annotations = map[string]string{constants.PostUpdateHealthCheckAnnotationKey: `{"checks":["CRIO"],"timeout":"5m0s"}`}
+	assert.Equal(t, &mcfgv1.PostUpdateHealthCheck{
+		Checks:  []mcfgv1.PostUpdateHealthCheckType{mcfgv1.PostUpdateHealthCheckCRIO},
+		Timeout: &metav1.Duration{Duration: 5 * time.Minute},
+	}, getPostUpdateHealthCheck(node))
+
+	node.Annotations[constants.PostUpdateHealthCheckAnnotationKey] = "{"
+	assert.Nil(t, getPostUpdateHealthCheck(node))
+}
+
+func TestGetHealthCheckRollback(t *testing.T) {
+	node := &corev1.Node{}
+	assert.Nil(t, getHealthCheckRollback(node))
+
+	node.Annotations = map[string]string{constants.HealthCheckRollbackAnnotationKey: `{"time":"2020-06-01T10:00:00Z","from":"rendered-worker-2","to":"rendered-worker-1","reason":"crio.service isn't active"}`}
+	rollback := getHealthCheckRollback(node)
+	if assert.NotNil(t, rollback) {
+		assert.Equal(t, "rendered-worker-2", rollback.From)
+		assert.Equal(t, "rendered-worker-1", rollback.To)
+		assert.Equal(t, "crio.service isn't active", rollback.Reason)
+	}
+}
+
+func TestWaitForHealthChecks(t *testing.T) {
+	calls := 0
+	healthy := func() error { return nil }
+	eventually := func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("node isn't Ready")
+		}
+		return nil
+	}
+	assert.Nil(t, waitForHealthChecks([]func() error{healthy, eventually}, time.Millisecond, time.Second))
+	assert.Equal(t, 3, calls)
+
+	never := func() error { return fmt.Errorf("crio.service isn't active") }
+	err := waitForHealthChecks([]func() error{healthy, never}, time.Millisecond, 10*time.Millisecond)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "crio.service isn't active")
+	}
+}
+
+func TestHandOverPostUpdateHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "health")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	dn := &Daemon{
+		postUpdateHealthPath: filepath.Join(dir, "post-update-health.json"),
+		bootID:               "boot-1",
+		OperatingSystem:      machineConfigDaemonOSRHCOS,
+		NodeUpdaterClient:    RpmOstreeClientMock{BootedDeployment: &RpmOstreeDeployment{ID: "rhcos-1"}},
+		node:                 &corev1.Node{},
+	}
+	oldConfig := helpers.NewMachineConfig("rendered-worker-1", nil, "", nil)
+	newConfig := helpers.NewMachineConfig("rendered-worker-2", nil, "", nil)
+
+	// nothing is handed over without health checks
+	require.Nil(t, dn.handOverPostUpdateHealth(oldConfig, newConfig))
+	health, err := dn.getPostUpdateHealth()
+	require.Nil(t, err)
+	assert.Nil(t, health)
+
+	dn.node.Annotations = map[string]string{constants.PostUpdateHealthCheckAnnotationKey: `{"checks":["CRIO"]}`}
+	require.Nil(t, dn.handOverPostUpdateHealth(oldConfig, newConfig))
+	health, err = dn.getPostUpdateHealth()
+	require.Nil(t, err)
+	require.NotNil(t, health)
+	assert.Equal(t, "rendered-worker-1", health.From.GetName())
+	assert.Equal(t, "rendered-worker-2", health.To.GetName())
+	assert.Equal(t, "boot-1", health.BootID)
+	assert.Equal(t, "rhcos-1", health.Deployment)
+
+	// the checks only run once the node rebooted
+	require.Nil(t, dn.RunPostUpdateHealthCheck(""))
+	health, err = dn.getPostUpdateHealth()
+	require.Nil(t, err)
+	assert.NotNil(t, health)
+	assert.Nil(t, health.Rollback)
+}
+
+func TestWaitForPostUpdateHealth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "health")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	dn := &Daemon{postUpdateHealthPath: filepath.Join(dir, "post-update-health.json")}
+	oldConfig := helpers.NewMachineConfig("rendered-worker-1", nil, "", nil)
+	newConfig := helpers.NewMachineConfig("rendered-worker-2", nil, "", nil)
+
+	// passed, or not handed over
+	assert.Nil(t, dn.waitForPostUpdateHealth(newConfig))
+
+	// rolled back by the host
+	require.Nil(t, dn.savePostUpdateHealth(&postUpdateHealth{
+		From: oldConfig,
+		To:   newConfig,
+		Rollback: &healthCheckRollback{
+			From:   "rendered-worker-2",
+			To:     "rendered-worker-1",
+			Reason: "crio.service isn't active",
+		},
+	}))
+	err = dn.waitForPostUpdateHealth(newConfig)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "crio.service isn't active")
+	}
+
+	// the health check of another update
+	assert.Nil(t, dn.waitForPostUpdateHealth(oldConfig))
+}
@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	errors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// preUpdateHookTarget is started by the daemon before draining the node for an update.
+	preUpdateHookTarget = "machine-config-daemon-pre-update.target"
+	// postUpdateHookTarget is started by the daemon once an update is applied, after the reboot.
+	postUpdateHookTarget = "machine-config-daemon-post-update.target"
+	// hookTimeout bounds how long the daemon waits on a hook target. Shorter
+	// timeouts can be set with JobTimeoutSec= on the target.
+	hookTimeout = 10 * time.Minute
+)
+
+// hasHookTarget returns whether config declares the hook target unit.
+func hasHookTarget(config *mcfgv1.MachineConfig, target string) (bool, error) {
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(config.Spec.Config.Raw)
+	if err != nil {
+		return false, fmt.Errorf("parsing Ignition config failed with error: %v", err)
+	}
+	for _, u := range ignConfig.Systemd.Units {
+		if u.Name == target {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getFailedHookUnits returns the units pulled in by target which failed.
+func getFailedHookUnits(target string) ([]string, error) {
+	out, err := exec.Command("systemctl", "show", "--property=Wants,Requires", "--value", target).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the units of %s", target)
+	}
+	var failed []string
+	for _, unit := range strings.Fields(string(out)) {
+		// is-failed exits with 0 for failed units only
+		if err := exec.Command("systemctl", "is-failed", "--quiet", unit).Run(); err == nil {
+			failed = append(failed, unit)
+		}
+	}
+	return failed, nil
+}
+
+// runHook starts the hook target if config declares it and waits for it.
+// Failures, including the hook units pulled in by the target which failed,
+// are returned so that the node is reported Degraded.
+func (dn *Daemon) runHook(config *mcfgv1.MachineConfig, target string) error {
+	declared, err := hasHookTarget(config, target)
+	if err != nil || !declared {
+		return err
+	}
+
+	dn.logSystem("Running %s hook of config %s", target, config.GetName())
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "systemctl", "start", target).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %v", hookTimeout)
+	}
+	if err == nil {
+		var failed []string
+		failed, err = getFailedHookUnits(target)
+		if err == nil && len(failed) > 0 {
+			err = fmt.Errorf("units failed: %s", strings.Join(failed, ", "))
+		}
+	}
+	// stop the target so that it runs again on the next update
+	if stopErr := exec.Command("systemctl", "stop", target).Run(); stopErr != nil {
+		glog.Warningf("Failed to stop %s: %v", target, stopErr)
+	}
+	if err != nil {
+		dn.setPhase(constants.MachineConfigDaemonPhaseHookFailed)
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "HookFailed", fmt.Sprintf("%s hook failed: %v", target, err))
+		}
+		return errors.Wrapf(err, "%s hook failed: %s", target, strings.TrimSpace(string(out)))
+	}
+	dn.logSystem("%s hook completed", target)
+	return nil
+}
@@ -0,0 +1,31 @@
+package daemon
+
+import (
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasHookTarget(t *testing.T) {
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Systemd.Units = []igntypes.Unit{
+		{Name: preUpdateHookTarget, Contents: "[Unit]\nJobTimeoutSec=60\n"},
+		{Name: "flush-storage.service", Enabled: &[]bool{true}[0]},
+	}
+	config := helpers.CreateMachineConfigFromIgnition(ignCfg)
+
+	declared, err := hasHookTarget(config, preUpdateHookTarget)
+	assert.Nil(t, err)
+	assert.True(t, declared)
+
+	declared, err = hasHookTarget(config, postUpdateHookTarget)
+	assert.Nil(t, err)
+	assert.False(t, declared)
+
+	// configs without hooks don't run anything
+	dn := &Daemon{}
+	assert.Nil(t, dn.runHook(newMachineConfigFromFiles(nil), preUpdateHookTarget))
+}
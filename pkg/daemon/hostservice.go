@@ -0,0 +1,181 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+const (
+	// HostServiceName is the systemd service running the daemon on the host,
+	// when the operator runs it in host mode.
+	HostServiceName = "machine-config-daemon-engine.service"
+	// HostServiceBinaryPath is where the pod copies the daemon on the host.
+	HostServiceBinaryPath = "/usr/local/bin/machine-config-daemon"
+	// HostServiceKubeconfigPath is the kubeconfig of the host service. It holds
+	// the token of the service account of the pod.
+	HostServiceKubeconfigPath = "/etc/kubernetes/machine-config-daemon/kubeconfig"
+
+	hostServiceUnitPath = "/etc/systemd/system/" + HostServiceName
+	// hostServiceStopTimeout matches the terminationGracePeriodSeconds of the
+	// pod, the daemon ignores SIGTERM while it updates the node.
+	hostServiceStopTimeout = 600 * time.Second
+)
+
+// hostServiceUnit returns the unit of the host service of the daemon of node.
+func hostServiceUnit(node string) string {
+	return fmt.Sprintf(`[Unit]
+Description=Machine Config Daemon
+# Installed and supervised by the machine-config-daemon pod, the daemon runs on
+# the host so that updates proceed while the container runtime is down.
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart=%s start --host-service --kubeconfig=%s --node-name=%s
+Restart=always
+RestartSec=10
+TimeoutStopSec=%d
+
+[Install]
+WantedBy=multi-user.target
+`, HostServiceBinaryPath, HostServiceKubeconfigPath, node, int(hostServiceStopTimeout.Seconds()))
+}
+
+// HostServiceKubeconfig returns a kubeconfig authenticating to server with
+// token, and trusting the certificates of caData.
+func HostServiceKubeconfig(server string, caData, token []byte) ([]byte, error) {
+	cfg := clientcmdv1.Config{
+		Kind:       "Config",
+		APIVersion: "v1",
+		Clusters: []clientcmdv1.NamedCluster{{
+			Name: "cluster",
+			Cluster: clientcmdv1.Cluster{
+				Server:                   server,
+				CertificateAuthorityData: caData,
+			},
+		}},
+		AuthInfos: []clientcmdv1.NamedAuthInfo{{
+			Name:     "machine-config-daemon",
+			AuthInfo: clientcmdv1.AuthInfo{Token: string(bytes.TrimSpace(token))},
+		}},
+		Contexts: []clientcmdv1.NamedContext{{
+			Name: "machine-config-daemon",
+			Context: clientcmdv1.Context{
+				Cluster:  "cluster",
+				AuthInfo: "machine-config-daemon",
+			},
+		}},
+		CurrentContext: "machine-config-daemon",
+	}
+	return yaml.Marshal(cfg)
+}
+
+// writeFileIfChanged writes data to path through a temporary file, unless path
+// already holds data. It returns whether path was written.
+func writeFileIfChanged(path string, data []byte, mode os.FileMode) (bool, error) {
+	current, err := ioutil.ReadFile(path)
+	if err == nil && bytes.Equal(current, data) {
+		return false, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, mode); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func systemctl(args ...string) error {
+	if out, err := exec.Command("systemctl", args...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to run systemctl %s: %s", strings.Join(args, " "), string(out))
+	}
+	return nil
+}
+
+// InstallHostService installs the daemon binary, its kubeconfig and the unit of
+// the host service of node, and makes sure the service is enabled and running.
+// The service is restarted when its binary or unit changed.
+func InstallHostService(node string, binary, kubeconfig []byte) error {
+	binaryChanged, err := writeFileIfChanged(HostServiceBinaryPath, binary, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "installing %s", HostServiceBinaryPath)
+	}
+	if _, err := writeFileIfChanged(HostServiceKubeconfigPath, kubeconfig, 0600); err != nil {
+		return errors.Wrapf(err, "installing %s", HostServiceKubeconfigPath)
+	}
+	unitChanged, err := writeFileIfChanged(hostServiceUnitPath, []byte(hostServiceUnit(node)), 0644)
+	if err != nil {
+		return errors.Wrapf(err, "installing %s", hostServiceUnitPath)
+	}
+
+	if unitChanged {
+		if err := systemctl("daemon-reload"); err != nil {
+			return err
+		}
+	}
+	if err := systemctl("enable", HostServiceName); err != nil {
+		return err
+	}
+	if binaryChanged || unitChanged {
+		glog.Infof("Restarting %s", HostServiceName)
+		return systemctl("restart", HostServiceName)
+	}
+	return systemctl("start", HostServiceName)
+}
+
+// RemoveHostService stops and removes the host service of the daemon, if it was
+// installed, so that it doesn't compete with the daemon of the pod.
+func RemoveHostService() error {
+	if _, err := os.Stat(hostServiceUnitPath); os.IsNotExist(err) {
+		return nil
+	}
+	glog.Infof("Removing %s, the daemon runs in its pod", HostServiceName)
+	if err := systemctl("disable", "--now", HostServiceName); err != nil {
+		return err
+	}
+	for _, path := range []string{hostServiceUnitPath, HostServiceKubeconfigPath, HostServiceBinaryPath} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "removing %s", path)
+		}
+	}
+	return systemctl("daemon-reload")
+}
+
+// HostServiceActive returns whether the host service of the daemon is running.
+func HostServiceActive() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", HostServiceName).Run() == nil
+}
+
+// FollowHostServiceLogs proxies the journal of the host service until stopCh is
+// closed, so that the logs of the pod are the ones of the daemon.
+func FollowHostServiceLogs(stopCh <-chan struct{}) {
+	cmd := exec.Command("journalctl", "-f", "-o", "cat", "-u", HostServiceName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		glog.Warningf("Failed to follow the logs of %s: %v", HostServiceName, err)
+		return
+	}
+	<-stopCh
+	cmd.Process.Kill()
+	cmd.Wait()
+}
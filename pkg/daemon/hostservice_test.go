@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestHostServiceUnit(t *testing.T) {
+	unit := hostServiceUnit("worker-0")
+	assert.Contains(t, unit, "ExecStart=/usr/local/bin/machine-config-daemon start --host-service --kubeconfig=/etc/kubernetes/machine-config-daemon/kubeconfig --node-name=worker-0\n")
+	assert.Contains(t, unit, "Restart=always\n")
+	assert.Contains(t, unit, "TimeoutStopSec=600\n")
+	assert.True(t, strings.HasSuffix(unit, "WantedBy=multi-user.target\n"))
+}
+
+func TestHostServiceKubeconfig(t *testing.T) {
+	data, err := HostServiceKubeconfig("https://api-int.example.com:6443", []byte("ca"), []byte("token\n"))
+	require.Nil(t, err)
+
+	cfg, err := clientcmd.Load(data)
+	require.Nil(t, err)
+	restCfg, err := clientcmd.NewDefaultClientConfig(*cfg, &clientcmd.ConfigOverrides{}).ClientConfig()
+	require.Nil(t, err)
+	assert.Equal(t, "https://api-int.example.com:6443", restCfg.Host)
+	assert.Equal(t, []byte("ca"), restCfg.CAData)
+	assert.Equal(t, "token", restCfg.BearerToken)
+}
+
+func TestWriteFileIfChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hostservice")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "file")
+
+	changed, err := writeFileIfChanged(path, []byte("a"), 0600)
+	require.Nil(t, err)
+	assert.True(t, changed)
+	changed, err = writeFileIfChanged(path, []byte("a"), 0600)
+	require.Nil(t, err)
+	assert.False(t, changed)
+	changed, err = writeFileIfChanged(path, []byte("b"), 0600)
+	require.Nil(t, err)
+	assert.True(t, changed)
+
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "b", string(data))
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
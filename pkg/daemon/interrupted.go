@@ -0,0 +1,145 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// maxInterruptedUpdateAttempts is how many times an interrupted update is
+// recovered from before the node is left degraded, e.g. when applying it
+// makes the node reboot halfway every time.
+const maxInterruptedUpdateAttempts = 3
+
+// updateJournal records an update in progress on disk. It's written before
+// the first change is made to the node and removed once the update either
+// completed or was rolled back, so finding it when the daemon starts means
+// the update was interrupted, e.g. by an unexpected reboot.
+type updateJournal struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	BootID  string    `json:"bootID"`
+	Started time.Time `json:"started"`
+	// Attempts is the number of times the update between From and To, in
+	// either direction, was recovered from an interruption.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// writeUpdateJournal records the update from oldConfig to newConfig as in
+// progress. The attempts of a recovered update between the same configs are
+// kept.
+func (dn *Daemon) writeUpdateJournal(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+	journal := &updateJournal{
+		From:    oldConfig.GetName(),
+		To:      newConfig.GetName(),
+		BootID:  dn.bootID,
+		Started: time.Now().UTC(),
+	}
+	previous, err := dn.getUpdateJournal()
+	if err != nil {
+		glog.Warningf("Failed to read the update journal: %v", err)
+	}
+	if previous != nil && sameUpdate(previous, journal) {
+		journal.Attempts = previous.Attempts
+	}
+	return dn.saveUpdateJournal(journal)
+}
+
+// saveUpdateJournal writes journal on disk.
+func (dn *Daemon) saveUpdateJournal(journal *updateJournal) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicallyWithDefaults(dn.updateJournalPath, data)
+}
+
+// sameUpdate tells whether a and b are updates between the same configs, a
+// rollback being the same update as the one it rolls back.
+func sameUpdate(a, b *updateJournal) bool {
+	return (a.From == b.From && a.To == b.To) || (a.From == b.To && a.To == b.From)
+}
+
+// getUpdateJournal returns the update in progress, nil if there's none.
+func (dn *Daemon) getUpdateJournal() (*updateJournal, error) {
+	data, err := ioutil.ReadFile(dn.updateJournalPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var journal updateJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, errors.Wrapf(err, "parsing update journal %s", dn.updateJournalPath)
+	}
+	return &journal, nil
+}
+
+// removeUpdateJournal marks the update in progress as finished.
+func (dn *Daemon) removeUpdateJournal() error {
+	if err := os.Remove(dn.updateJournalPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing update journal")
+	}
+	return nil
+}
+
+// resumeInterruptedUpdate tells whether an interrupted update should be
+// resumed rather than rolled back: that's the case while the config it
+// was updating to is still the one the node should end up with.
+func resumeInterruptedUpdate(journal *updateJournal, target string) bool {
+	return journal.To == target
+}
+
+// recoverInterruptedUpdate brings a node whose on-disk state failed validation
+// with validationErr out of the partial state left by the update recorded in
+// journal, when a reboot interrupted it: the update is applied again if target
+// is still the config it was updating to, or rolled back otherwise. Both
+// configs must still exist. validationErr is returned when the update wasn't
+// interrupted by a reboot or can't be recovered, and after
+// maxInterruptedUpdateAttempts recoveries, so that the node goes degraded.
+func (dn *Daemon) recoverInterruptedUpdate(journal *updateJournal, target string, validationErr error) error {
+	if journal.BootID == dn.bootID {
+		glog.Warningf("The update from %s to %s started in this boot, not recovering it", journal.From, journal.To)
+		return validationErr
+	}
+	if journal.Attempts >= maxInterruptedUpdateAttempts {
+		return errors.Wrapf(validationErr, "the update from %s to %s was interrupted %d times, giving up", journal.From, journal.To, journal.Attempts+1)
+	}
+	from, err := dn.mcLister.Get(journal.From)
+	if err != nil {
+		glog.Warningf("Can't recover the update from %s to %s: %v", journal.From, journal.To, err)
+		return validationErr
+	}
+	to, err := dn.mcLister.Get(journal.To)
+	if err != nil {
+		glog.Warningf("Can't recover the update from %s to %s: %v", journal.From, journal.To, err)
+		return validationErr
+	}
+	// count the attempt before making any change, the recovery itself may
+	// be interrupted
+	journal.Attempts++
+	journal.BootID = dn.bootID
+	if err := dn.saveUpdateJournal(journal); err != nil {
+		return errors.Wrapf(validationErr, "recording the recovery of the update from %s to %s: %v", journal.From, journal.To, err)
+	}
+
+	if resumeInterruptedUpdate(journal, target) {
+		dn.logSystem("Resuming the update from %s to %s started %s, interrupted with: %v", journal.From, journal.To, journal.Started, validationErr)
+		if dn.recorder != nil {
+			dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "ResumingUpdate", "Resuming the interrupted update from %s to %s", journal.From, journal.To)
+		}
+		return dn.update(from, to)
+	}
+	dn.logSystem("Rolling back the update from %s to %s started %s, %s is now expected, interrupted with: %v", journal.From, journal.To, journal.Started, target, validationErr)
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "RollingBackUpdate", "Rolling back the interrupted update from %s to %s", journal.From, journal.To)
+	}
+	return dn.update(to, from)
+}
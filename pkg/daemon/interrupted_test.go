@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "update-journal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dn := &Daemon{updateJournalPath: filepath.Join(dir, "update-journal.json"), bootID: "boot-1"}
+
+	journal, err := dn.getUpdateJournal()
+	require.Nil(t, err)
+	assert.Nil(t, journal)
+
+	oldConfig := helpers.NewMachineConfig("rendered-worker-1", nil, "", nil)
+	newConfig := helpers.NewMachineConfig("rendered-worker-2", nil, "", nil)
+	require.Nil(t, dn.writeUpdateJournal(oldConfig, newConfig))
+	journal, err = dn.getUpdateJournal()
+	require.Nil(t, err)
+	require.NotNil(t, journal)
+	assert.Equal(t, "rendered-worker-1", journal.From)
+	assert.Equal(t, "rendered-worker-2", journal.To)
+	assert.Equal(t, "boot-1", journal.BootID)
+	assert.False(t, journal.Started.IsZero())
+
+	// the update is resumed while its target is still desired, rolled back otherwise
+	assert.True(t, resumeInterruptedUpdate(journal, "rendered-worker-2"))
+	assert.False(t, resumeInterruptedUpdate(journal, "rendered-worker-3"))
+
+	require.Nil(t, dn.removeUpdateJournal())
+	journal, err = dn.getUpdateJournal()
+	require.Nil(t, err)
+	assert.Nil(t, journal)
+	// removing it again is a no-op
+	assert.Nil(t, dn.removeUpdateJournal())
+
+	require.Nil(t, ioutil.WriteFile(dn.updateJournalPath, []byte("{"), 0644))
+	_, err = dn.getUpdateJournal()
+	assert.NotNil(t, err)
+}
+
+func TestRecoverInterruptedUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "update-journal")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dn := &Daemon{updateJournalPath: filepath.Join(dir, "update-journal.json"), bootID: "boot-1"}
+	validationErr := errors.New("unexpected on-disk state")
+
+	oldConfig := helpers.NewMachineConfig("rendered-worker-1", nil, "", nil)
+	newConfig := helpers.NewMachineConfig("rendered-worker-2", nil, "", nil)
+	require.Nil(t, dn.writeUpdateJournal(oldConfig, newConfig))
+	journal, err := dn.getUpdateJournal()
+	require.Nil(t, err)
+
+	// an update started in this boot wasn't interrupted by a reboot
+	assert.Equal(t, validationErr, dn.recoverInterruptedUpdate(journal, newConfig.GetName(), validationErr))
+
+	// the node gives up after maxInterruptedUpdateAttempts recoveries
+	dn.bootID = "boot-2"
+	journal.Attempts = maxInterruptedUpdateAttempts
+	err = dn.recoverInterruptedUpdate(journal, newConfig.GetName(), validationErr)
+	require.NotNil(t, err)
+	assert.NotEqual(t, validationErr, err)
+	assert.Contains(t, err.Error(), validationErr.Error())
+
+	// the attempts are kept by the update, and its rollback, being recovered
+	journal.Attempts = 2
+	require.Nil(t, dn.saveUpdateJournal(journal))
+	require.Nil(t, dn.writeUpdateJournal(newConfig, oldConfig))
+	journal, err = dn.getUpdateJournal()
+	require.Nil(t, err)
+	assert.Equal(t, 2, journal.Attempts)
+	assert.Equal(t, "boot-2", journal.BootID)
+	// but not by another update
+	require.Nil(t, dn.writeUpdateJournal(newConfig, helpers.NewMachineConfig("rendered-worker-3", nil, "", nil)))
+	journal, err = dn.getUpdateJournal()
+	require.Nil(t, err)
+	assert.Equal(t, 0, journal.Attempts)
+}
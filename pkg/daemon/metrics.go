@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
@@ -68,6 +69,64 @@ var (
 			Help: "completed update config or error",
 		}, []string{"config", "err"})
 
+	// MCDDrainDuration is how long draining the node for an update takes
+	MCDDrainDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcd_drain_duration_seconds",
+			Help:    "time taken to drain the node for an update",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}, []string{"pool", "config"})
+
+	// MCDPivotDuration is how long updating the OS of the node takes
+	MCDPivotDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcd_pivot_duration_seconds",
+			Help:    "time taken to update the OS of the node",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}, []string{"pool", "config"})
+
+	// MCDReboots counts the reboots initiated to apply a config
+	MCDReboots = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcd_reboots_total",
+			Help: "reboots initiated to apply a config",
+		}, []string{"pool", "config"})
+
+	// MCDFilesWritten counts the files written to apply a config
+	MCDFilesWritten = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcd_files_written_total",
+			Help: "files written to apply a config",
+		}, []string{"pool", "config"})
+
+	// MCDLastUpdateResult is 1 when the last update succeeded, 0 when it failed
+	MCDLastUpdateResult = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcd_last_update_result",
+			Help: "1 when the last update to config succeeded, 0 when it failed",
+		}, []string{"pool", "config"})
+
+	// MCDDriftDetections counts the times the on-disk state drifted from the current config
+	MCDDriftDetections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcd_drift_detections_total",
+			Help: "times the on-disk state was found drifted from the current config",
+		}, []string{"pool", "config"})
+
+	// MCDNodeWrites counts the patches of the annotations of the node
+	MCDNodeWrites = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mcd_node_writes_total",
+			Help: "patches of the annotations of the node",
+		})
+
+	// MCDNodeWriteConflicts counts the patches of the annotations of the node which conflicted
+	MCDNodeWriteConflicts = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mcd_node_write_conflicts_total",
+			Help: "patches of the annotations of the node which conflicted and were retried",
+		})
+
 	metricsList = []prometheus.Collector{
 		HostOS,
 		MCDSSHAccessed,
@@ -77,6 +136,14 @@ var (
 		KubeletHealthState,
 		MCDRebootErr,
 		MCDUpdateState,
+		MCDDrainDuration,
+		MCDPivotDuration,
+		MCDReboots,
+		MCDFilesWritten,
+		MCDLastUpdateResult,
+		MCDDriftDetections,
+		MCDNodeWrites,
+		MCDNodeWriteConflicts,
 	}
 )
 
@@ -97,6 +164,30 @@ func registerMCDMetrics() error {
 	return nil
 }
 
+// configMetricLabels returns the pool and config labels of the update metrics
+// for the rendered config named config.
+func configMetricLabels(config string) (string, string) {
+	// rendered configs are named rendered-<pool>-<hash>
+	pool := strings.TrimPrefix(config, "rendered-")
+	if i := strings.LastIndex(pool, "-"); i > 0 && pool != config {
+		pool = pool[:i]
+	} else {
+		pool = ""
+	}
+	return pool, config
+}
+
+// setLastUpdateResult reports whether the last update, to config, succeeded.
+func setLastUpdateResult(config string, succeeded bool) {
+	result := 0.0
+	if succeeded {
+		result = 1
+	}
+	// only the last update is reported
+	MCDLastUpdateResult.Reset()
+	MCDLastUpdateResult.WithLabelValues(configMetricLabels(config)).Set(result)
+}
+
 // StartMetricsListener is metrics listener via http on localhost
 func StartMetricsListener(addr string, stopCh chan struct{}) {
 	if addr == "" {
@@ -0,0 +1,25 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigMetricLabels(t *testing.T) {
+	tests := []struct {
+		config string
+		pool   string
+	}{
+		{"rendered-worker-0a1b2c3d4e5f", "worker"},
+		{"rendered-infra-east-0a1b2c3d4e5f", "infra-east"},
+		{"rendered-worker", ""},
+		{"99-worker-ssh", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		pool, config := configMetricLabels(test.config)
+		assert.Equal(t, test.pool, pool, test.config)
+		assert.Equal(t, test.config, config)
+	}
+}
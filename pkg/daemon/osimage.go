@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/pkg/errors"
+)
+
+// osImageAlias records that OSImageURL was applied without a pivot, as its
+// image has the ostree commit Checksum the node was already booted into. The
+// booted deployment keeps the origin of the image it was deployed from, so
+// this is how the daemon knows the node is running OSImageURL.
+type osImageAlias struct {
+	OSImageURL string `json:"osImageURL"`
+	Checksum   string `json:"checksum"`
+}
+
+// getBootedOSCommit returns the ostree commit of the booted OS, ignoring the
+// packages layered on top of it.
+func (dn *Daemon) getBootedOSCommit() (string, error) {
+	booted, err := dn.NodeUpdaterClient.GetBootedDeployment()
+	if err != nil {
+		return "", err
+	}
+	if booted.BaseChecksum != "" {
+		return booted.BaseChecksum, nil
+	}
+	return booted.Checksum, nil
+}
+
+// getOSImageRebuildCommit returns the booted ostree commit if newConfig only
+// changes the osImageURL of oldConfig to an image of that same commit, e.g. a
+// payload rebuilt without changing the OS, and "" otherwise.
+func (dn *Daemon) getOSImageRebuildCommit(oldConfig, newConfig *mcfgv1.MachineConfig) (string, error) {
+	if !isCoreOSVariant(dn.OperatingSystem) || oldConfig.Spec.OSImageURL == newConfig.Spec.OSImageURL {
+		return "", nil
+	}
+	osMatch, err := dn.checkOS(newConfig.Spec.OSImageURL)
+	if err != nil || osMatch {
+		return "", err
+	}
+	booted, err := dn.getBootedOSCommit()
+	if err != nil {
+		return "", errors.Wrap(err, "getting the booted ostree commit")
+	}
+	target, err := dn.NodeUpdaterClient.GetImageOSTreeCommit(newConfig.Spec.OSImageURL)
+	if err != nil {
+		return "", errors.Wrap(err, "getting the ostree commit of the OS image")
+	}
+	if target == "" || target != booted {
+		return "", nil
+	}
+	return booted, nil
+}
+
+// getOSUpdateConfig returns the config to pull and pivot the OS to. That's
+// newConfig, or a copy of it with the osImageURL of oldConfig when its image
+// has the booted ostree commit, which is then returned as well.
+func (dn *Daemon) getOSUpdateConfig(oldConfig, newConfig *mcfgv1.MachineConfig) (*mcfgv1.MachineConfig, string) {
+	osCommit, err := dn.getOSImageRebuildCommit(oldConfig, newConfig)
+	if err != nil {
+		glog.Warningf("Can't tell whether %s changes the OS, assuming it does: %v", newConfig.Spec.OSImageURL, err)
+		return newConfig, ""
+	}
+	if osCommit == "" {
+		return newConfig, ""
+	}
+	dn.logSystem("OS image %s has the booted ostree commit %s, not updating the OS", newConfig.Spec.OSImageURL, osCommit)
+	osConfig := newConfig.DeepCopy()
+	osConfig.Spec.OSImageURL = oldConfig.Spec.OSImageURL
+	return osConfig, osCommit
+}
+
+// writeOSImageAlias records osImageURL as an alias of the booted commit.
+func (dn *Daemon) writeOSImageAlias(osImageURL, checksum string) error {
+	alias, err := json.Marshal(osImageAlias{OSImageURL: osImageURL, Checksum: checksum})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicallyWithDefaults(dn.osImageAliasPath, alias)
+}
+
+// isOSImageAlias tells whether osImageURL was applied without a pivot to the
+// OS that's still booted.
+func (dn *Daemon) isOSImageAlias(osImageURL string) (bool, error) {
+	data, err := ioutil.ReadFile(dn.osImageAliasPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var alias osImageAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return false, errors.Wrapf(err, "parsing OS image alias %s", dn.osImageAliasPath)
+	}
+	if alias.OSImageURL != osImageURL {
+		return false, nil
+	}
+	// the alias is stale once the node pivoted to another commit
+	booted, err := dn.getBootedOSCommit()
+	if err != nil {
+		return false, err
+	}
+	return alias.Checksum == booted, nil
+}
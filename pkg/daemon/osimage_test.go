@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bootedOSImage  = "registry.example.com/os@sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	rebuiltOSImage = "registry.example.com/os@sha256:0000000000000000000000000000000000000000000000000000000000000002"
+)
+
+func TestOSImageRebuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "os-image-alias")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	oldConfig := helpers.NewMachineConfig("rendered-worker-1", nil, bootedOSImage, nil)
+	newConfig := helpers.NewMachineConfig("rendered-worker-2", nil, rebuiltOSImage, nil)
+	newDaemon := func(booted, image string) *Daemon {
+		return &Daemon{
+			OperatingSystem:  machineConfigDaemonOSRHCOS,
+			bootedOSImageURL: bootedOSImage,
+			osImageAliasPath: filepath.Join(dir, "os-image-alias.json"),
+			NodeUpdaterClient: RpmOstreeClientMock{
+				BootedDeployment:  &RpmOstreeDeployment{Checksum: "layered", BaseChecksum: booted},
+				ImageOSTreeCommit: image,
+			},
+		}
+	}
+
+	// the image has another commit, or none we could find
+	commit, err := newDaemon("abc", "def").getOSImageRebuildCommit(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.Equal(t, "", commit)
+	commit, err = newDaemon("abc", "").getOSImageRebuildCommit(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.Equal(t, "", commit)
+
+	// the image has the booted base commit
+	dn := newDaemon("abc", "abc")
+	commit, err = dn.getOSImageRebuildCommit(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.Equal(t, "abc", commit)
+
+	// once recorded the new image is the booted one
+	osMatch, err := dn.checkOS(rebuiltOSImage)
+	require.Nil(t, err)
+	assert.False(t, osMatch)
+	require.Nil(t, dn.writeOSImageAlias(rebuiltOSImage, commit))
+	osMatch, err = dn.checkOS(rebuiltOSImage)
+	require.Nil(t, err)
+	assert.True(t, osMatch)
+	commit, err = dn.getOSImageRebuildCommit(oldConfig, newConfig)
+	require.Nil(t, err)
+	assert.Equal(t, "", commit)
+
+	// until the node boots another commit
+	osMatch, err = newDaemon("ghi", "abc").checkOS(rebuiltOSImage)
+	require.Nil(t, err)
+	assert.False(t, osMatch)
+}
+
+func TestOSImageRebuildWithReboot(t *testing.T) {
+	oldConfig := newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0)})
+	oldConfig.Spec.OSImageURL = bootedOSImage
+	// the rebuilt image comes with a file change that needs a reboot
+	newConfig := newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(1)})
+	newConfig.Spec.OSImageURL = rebuiltOSImage
+	dn := &Daemon{
+		OperatingSystem:  machineConfigDaemonOSRHCOS,
+		bootedOSImageURL: bootedOSImage,
+		NodeUpdaterClient: RpmOstreeClientMock{
+			BootedDeployment:  &RpmOstreeDeployment{Checksum: "abc"},
+			ImageOSTreeCommit: "abc",
+			PullImageError:    fmt.Errorf("unexpected pull"),
+			RunPivotReturns:   []error{fmt.Errorf("unexpected pivot")},
+		},
+	}
+
+	osConfig, commit := dn.getOSUpdateConfig(oldConfig, newConfig)
+	assert.Equal(t, "abc", commit)
+	assert.Equal(t, bootedOSImage, osConfig.Spec.OSImageURL)
+	assert.Equal(t, newConfig.Spec.Config, osConfig.Spec.Config)
+	_, _, rebootless, err := getRebootlessActions(oldConfig, osConfig, nil)
+	require.Nil(t, err)
+	assert.False(t, rebootless)
+
+	// neither the pull before the drain nor the pivot before the reboot update the OS
+	assert.Nil(t, dn.prePullOSImage(osConfig))
+	assert.Nil(t, dn.updateOS(osConfig))
+	assert.Error(t, dn.prePullOSImage(newConfig))
+}
@@ -17,6 +17,12 @@ const (
 	machineConfigDaemonOSFCOS = "FCOS"
 )
 
+// isCoreOSVariant returns whether the OS is managed through rpm-ostree, as
+// opposed to e.g. RHEL workers whose packages are managed with yum or dnf.
+func isCoreOSVariant(operatingSystem string) bool {
+	return operatingSystem == machineConfigDaemonOSRHCOS || operatingSystem == machineConfigDaemonOSFCOS
+}
+
 // getHostRunningOS reads os-release from the rootFs prefix to return what
 // OS variant the daemon is running on. If we are unable to read the
 // os-release file OR the information doesn't match MCD supported OS's
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	errors "github.com/pkg/errors"
+)
+
+// PackageUpdater updates the OS of hosts whose packages aren't managed by
+// rpm-ostree, e.g. RHEL 7/8 workers.
+type PackageUpdater interface {
+	// Update brings the host packages in line with osImageURL.
+	Update(osImageURL string) error
+}
+
+// hookPackageUpdater runs an administrator provided executable with the
+// package manager of the host, yum or dnf.
+type hookPackageUpdater struct {
+	hookPath string
+}
+
+// newHookPackageUpdater returns a PackageUpdater running the hook at
+// constants.PackageUpdateHookPath, if any.
+func newHookPackageUpdater() PackageUpdater {
+	return &hookPackageUpdater{hookPath: constants.PackageUpdateHookPath}
+}
+
+// getPackageManager returns the package manager available on the host, dnf
+// being preferred on RHEL 8.
+func getPackageManager() string {
+	for _, pm := range []string{"dnf", "yum"} {
+		if _, err := exec.LookPath(pm); err == nil {
+			return pm
+		}
+	}
+	return ""
+}
+
+func (u *hookPackageUpdater) Update(osImageURL string) error {
+	if _, err := os.Stat(u.hookPath); err != nil {
+		if os.IsNotExist(err) {
+			glog.V(2).Infof("No package update hook at %s, not updating packages", u.hookPath)
+			return nil
+		}
+		return errors.Wrapf(err, "checking package update hook")
+	}
+	pm := getPackageManager()
+	glog.Infof("Running package update hook %s %s %s", u.hookPath, osImageURL, pm)
+	if out, err := exec.Command(u.hookPath, osImageURL, pm).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "running package update hook: %s", string(out))
+	}
+	return nil
+}
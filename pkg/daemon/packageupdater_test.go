@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePackageUpdater struct {
+	osImageURLs []string
+}
+
+func (f *fakePackageUpdater) Update(osImageURL string) error {
+	f.osImageURLs = append(f.osImageURLs, osImageURL)
+	return nil
+}
+
+func TestHookPackageUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "package-update-hook")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// a missing hook isn't an error
+	updater := &hookPackageUpdater{hookPath: filepath.Join(dir, "hook")}
+	assert.Nil(t, updater.Update("quay.io/openshift/os@sha256:new"))
+
+	out := filepath.Join(dir, "out")
+	require.Nil(t, ioutil.WriteFile(updater.hookPath, []byte("#!/bin/sh\necho \"$1\" > "+out+"\n"), 0755))
+	assert.Nil(t, updater.Update("quay.io/openshift/os@sha256:new"))
+	data, err := ioutil.ReadFile(out)
+	require.Nil(t, err)
+	assert.Equal(t, "quay.io/openshift/os@sha256:new\n", string(data))
+
+	require.Nil(t, ioutil.WriteFile(updater.hookPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	assert.NotNil(t, updater.Update("quay.io/openshift/os@sha256:new"))
+}
+
+func TestUpdateOSWithPackageUpdater(t *testing.T) {
+	updater := &fakePackageUpdater{}
+	dn := &Daemon{OperatingSystem: machineConfigDaemonOSRHEL, packageUpdater: updater}
+	config := &mcfgv1.MachineConfig{Spec: mcfgv1.MachineConfigSpec{OSImageURL: "quay.io/openshift/os@sha256:new"}}
+	assert.Nil(t, dn.updateOS(config))
+	assert.Equal(t, []string{"quay.io/openshift/os@sha256:new"}, updater.osImageURLs)
+
+	// without an updater, the OS of non-CoreOS hosts is left alone
+	dn.packageUpdater = nil
+	assert.Nil(t, dn.updateOS(config))
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -45,7 +46,7 @@ func runExtBackoff(capture bool, backoff wait.Backoff, command string, args ...s
 		return true, nil
 	})
 	if err != nil {
-		glog.Fatalf("%s: %s", command, err)
+		logging.Fatalf("%s: %s", command, err)
 	}
 	return output
 }
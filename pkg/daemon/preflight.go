@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	errors "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// bootRequiredSpace is the free space a new deployment needs in /boot,
+	// for its kernel and initramfs.
+	bootRequiredSpace = 100 * 1024 * 1024
+	// sysrootRequiredSpace is the free space an OS update needs in /sysroot.
+	sysrootRequiredSpace = 1024 * 1024 * 1024
+)
+
+// checkFreeSpace returns an error if the filesystem of path has less than required bytes available.
+func checkFreeSpace(path string, required uint64) error {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return errors.Wrapf(err, "checking free space in %s", path)
+	}
+	available := stat.Bavail * uint64(stat.Bsize)
+	if available < required {
+		return fmt.Errorf("not enough space in %s: %d MiB available, %d MiB required", path, available/1024/1024, required/1024/1024)
+	}
+	return nil
+}
+
+// checkImagePullable makes sure image can be pulled with the pull secret of the node.
+func checkImagePullable(image string) error {
+	args := []string{"inspect", "--no-tags"}
+	if _, err := os.Stat(kubeletAuthFile); err == nil {
+		args = append(args, "--authfile", kubeletAuthFile)
+	}
+	args = append(args, "docker://"+image)
+	var lastErr error
+	if err := wait.ExponentialBackoff(wait.Backoff{Steps: 3, Duration: 5 * time.Second, Factor: 2}, func() (bool, error) {
+		_, lastErr = runGetOut("skopeo", args...)
+		return lastErr == nil, nil
+	}); err != nil {
+		return errors.Wrapf(lastErr, "image %s isn't pullable", image)
+	}
+	return nil
+}
+
+// runPreflightChecks makes sure the update from oldConfig to newConfig can be
+// applied before the node is drained: rpm-ostree must not be busy, and updates
+// creating a new deployment need enough disk space and a pullable OS image.
+func (dn *Daemon) runPreflightChecks(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+	if !isCoreOSVariant(dn.OperatingSystem) {
+		return nil
+	}
+	diff, err := NewMachineConfigDiff(oldConfig, newConfig)
+	if err != nil {
+		return err
+	}
+	if !diff.osUpdate && !diff.kargs && !diff.kernelType && !diff.extensions {
+		return nil
+	}
+
+	transaction, err := dn.NodeUpdaterClient.GetTransaction()
+	if err != nil {
+		return errors.Wrap(err, "checking rpm-ostree status")
+	}
+	if transaction != "" {
+		return fmt.Errorf("rpm-ostree is busy with transaction %q", transaction)
+	}
+	if err := checkFreeSpace("/boot", bootRequiredSpace); err != nil {
+		return err
+	}
+	if diff.osUpdate {
+		if err := checkFreeSpace("/sysroot", sysrootRequiredSpace); err != nil {
+			return err
+		}
+	}
+	// the OS image is also pulled to switch kernels and install extensions
+	osImageURL := newConfig.Spec.OSImageURL
+	if (diff.osUpdate || diff.kernelType || diff.extensions) && osImageURL != "" && osImageURL != "://dummy" {
+		return checkImagePullable(osImageURL)
+	}
+	return nil
+}
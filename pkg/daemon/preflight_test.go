@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"math"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFreeSpace(t *testing.T) {
+	assert.Nil(t, checkFreeSpace("/", 0))
+	assert.NotNil(t, checkFreeSpace("/", math.MaxUint64))
+	assert.NotNil(t, checkFreeSpace("/does/not/exist", 0))
+}
+
+func TestRunPreflightChecks(t *testing.T) {
+	oldConfig := newMachineConfigFromFiles(nil)
+	newConfig := newMachineConfigFromFiles(nil)
+	newConfig.Spec.KernelArguments = []string{"nosmt"}
+
+	// rpm-ostree isn't used on non-CoreOS nodes
+	dn := &Daemon{OperatingSystem: machineConfigDaemonOSRHEL, NodeUpdaterClient: RpmOstreeClientMock{Transaction: "upgrade"}}
+	assert.Nil(t, dn.runPreflightChecks(oldConfig, newConfig))
+
+	// updates which don't create a new deployment don't need rpm-ostree
+	dn.OperatingSystem = machineConfigDaemonOSRHCOS
+	assert.Nil(t, dn.runPreflightChecks(oldConfig, newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0)})))
+
+	err := dn.runPreflightChecks(oldConfig, newConfig)
+	assert.EqualError(t, err, `rpm-ostree is busy with transaction "upgrade"`)
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// rebootUnitRegex matches the name of a systemd service or target, the unit is
+// passed to a shell.
+var rebootUnitRegex = regexp.MustCompile(`^[a-zA-Z0-9:_.\\@-]+\.(service|target)$`)
+
+// getRebootStrategy returns the reboot strategy the node controller set for the
+// pool of the node, nil to reboot the default way.
+func getRebootStrategy(node *corev1.Node) *mcfgv1.RebootStrategy {
+	if node == nil {
+		return nil
+	}
+	value, ok := node.Annotations[constants.RebootStrategyAnnotationKey]
+	if !ok || value == "" {
+		return nil
+	}
+	strategy := &mcfgv1.RebootStrategy{}
+	if err := json.Unmarshal([]byte(value), strategy); err != nil {
+		glog.Warningf("Ignoring invalid %s annotation: %v", constants.RebootStrategyAnnotationKey, err)
+		return nil
+	}
+	if strategy.Type == mcfgv1.RebootStrategyUnit && !rebootUnitRegex.MatchString(strategy.Unit) {
+		glog.Warningf("Ignoring invalid %s annotation: unit %q is not the name of a systemd service or target", constants.RebootStrategyAnnotationKey, strategy.Unit)
+		return nil
+	}
+	return strategy
+}
@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestRebootCommand(t *testing.T) {
+	script := func(strategy *mcfgv1.RebootStrategy) string {
+		args := rebootCommand("test", strategy).Args
+		return args[len(args)-1]
+	}
+	assert.Equal(t, "systemctl stop kubelet.service; systemctl reboot", script(nil))
+	assert.Equal(t, "systemctl stop kubelet.service; systemctl reboot", script(&mcfgv1.RebootStrategy{Type: mcfgv1.RebootStrategyReboot}))
+	assert.Equal(t, "systemctl stop kubelet.service; sleep 90; systemctl kexec || systemctl reboot",
+		script(&mcfgv1.RebootStrategy{Type: mcfgv1.RebootStrategyKexec, GracePeriod: &metav1.Duration{Duration: 90 * time.Second}}))
+	assert.Equal(t, "systemctl stop kubelet.service; systemctl start bmc-power-cycle.service || systemctl reboot",
+		script(&mcfgv1.RebootStrategy{Type: mcfgv1.RebootStrategyUnit, Unit: "bmc-power-cycle.service"}))
+}
+
+func TestGetRebootStrategy(t *testing.T) {
+	assert.Nil(t, getRebootStrategy(nil))
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+	assert.Nil(t, getRebootStrategy(node))
+
+	node.Annotations[constants.RebootStrategyAnnotationKey] = `{"type":"Unit","unit":"bmc-power-cycle.service","gracePeriod":"30s"}`
+	assert.Equal(t, &mcfgv1.RebootStrategy{Type: mcfgv1.RebootStrategyUnit, Unit: "bmc-power-cycle.service", GracePeriod: &metav1.Duration{Duration: 30 * time.Second}},
+		getRebootStrategy(node))
+
+	// invalid annotations reboot the default way
+	for _, value := range []string{"Kexec", `{"type":"Unit","unit":"x.service; rm -rf /"}`} {
+		node.Annotations[constants.RebootStrategyAnnotationKey] = value
+		assert.Nil(t, getRebootStrategy(node), value)
+	}
+}
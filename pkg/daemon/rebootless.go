@@ -0,0 +1,279 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/golang/glog"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	errors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// serviceAction is the systemctl verb run on a unit to pick up changes to its configuration,
+// or the command run instead when set.
+type serviceAction struct {
+	verb    string
+	unit    string
+	command string
+}
+
+// rebootlessFiles are the files whose changes are applied by acting on the services
+// reading them instead of rebooting the node.
+var rebootlessFiles = map[string][]serviceAction{
+	"/etc/containers/registries.conf": {{verb: "reload", unit: "crio.service"}},
+	"/etc/kubernetes/kubelet.conf":    {{verb: "restart", unit: "kubelet.service"}},
+	"/etc/chrony.conf":                {{verb: "restart", unit: "chronyd.service"}},
+	// the kubelet picks up changes to static pod manifests by itself
+	"/etc/kubernetes/manifests/keepalived.yaml": nil,
+	"/etc/kubernetes/manifests/coredns.yaml":    nil,
+	// the cloud provider config and its CAs, e.g. rotated vSphere credentials, are
+	// only read by the kubelet
+	"/etc/kubernetes/cloud.conf": {{verb: "restart", unit: "kubelet.service"}},
+	"/etc/kubernetes/static-pod-resources/configmaps/cloud-config/ca-bundle.pem": {{verb: "restart", unit: "kubelet.service"}},
+	// the cluster-wide additional trusted CAs: regenerate the system trust store,
+	// and have crio pull images with it
+	"/etc/pki/ca-trust/source/anchors/openshift-config-user-ca-bundle.crt": {
+		{command: "update-ca-trust"},
+		{verb: "reload", unit: "crio.service"},
+	},
+}
+
+// getNodeDisruptionPolicy reads the node disruption policy the node controller
+// set on the node. An invalid policy is logged and ignored, which reboots the
+// node as when none is set.
+func getNodeDisruptionPolicy(node *corev1.Node) *mcfgv1.NodeDisruptionPolicy {
+	if node == nil {
+		return nil
+	}
+	value, ok := node.Annotations[constants.NodeDisruptionPolicyAnnotationKey]
+	if !ok {
+		return nil
+	}
+	policy := &mcfgv1.NodeDisruptionPolicy{}
+	if err := json.Unmarshal([]byte(value), policy); err != nil {
+		glog.Warningf("Ignoring invalid node disruption policy %q: %v", value, err)
+		return nil
+	}
+	return policy
+}
+
+// rebootlessPlan accumulates the actions applying an update without rebooting.
+type rebootlessPlan struct {
+	actions []serviceAction
+	seen    map[serviceAction]bool
+	drain   bool
+}
+
+func (p *rebootlessPlan) add(action serviceAction) {
+	if p.seen == nil {
+		p.seen = map[serviceAction]bool{}
+	}
+	if !p.seen[action] {
+		p.seen[action] = true
+		p.actions = append(p.actions, action)
+	}
+}
+
+// addPolicyActions adds the actions of a node disruption policy entry, with
+// defaultUnit the unit reloaded or restarted when the action doesn't name one.
+// It returns false if the actions need a reboot.
+func (p *rebootlessPlan) addPolicyActions(actions []mcfgv1.NodeDisruptionAction, defaultUnit string) bool {
+	for _, a := range actions {
+		unit := a.Unit
+		if unit == "" {
+			unit = defaultUnit
+		}
+		switch a.Type {
+		case mcfgv1.NodeDisruptionActionNone:
+		case mcfgv1.NodeDisruptionActionDrain:
+			p.drain = true
+		case mcfgv1.NodeDisruptionActionReload, mcfgv1.NodeDisruptionActionRestart:
+			if unit == "" {
+				return false
+			}
+			p.add(serviceAction{verb: strings.ToLower(string(a.Type)), unit: unit})
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// getRebootlessActions returns whether the update from oldConfig to newConfig
+// can be applied without rebooting the node, whether the node must be drained
+// first, and the services to act on to do so. Changed files and units are
+// looked up in the node disruption policy, then files in rebootlessFiles.
+// SSH keys changes, the only passwd changes Reconcilable lets through, don't need any.
+func getRebootlessActions(oldConfig, newConfig *mcfgv1.MachineConfig, policy *mcfgv1.NodeDisruptionPolicy) (actions []serviceAction, drain, rebootless bool, err error) {
+	diff, err := NewMachineConfigDiff(oldConfig, newConfig)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if diff.osUpdate || diff.kargs || diff.fips || diff.kernelType || diff.extensions {
+		return nil, false, false, nil
+	}
+	if !diff.files && !diff.units {
+		return nil, false, true, nil
+	}
+	if diff.units && policy == nil {
+		return nil, false, false, nil
+	}
+
+	oldIgn, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("parsing old Ignition config failed with error: %v", err)
+	}
+	newIgn, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("parsing new Ignition config failed with error: %v", err)
+	}
+
+	filePolicies := map[string][]mcfgv1.NodeDisruptionAction{}
+	unitPolicies := map[string][]mcfgv1.NodeDisruptionAction{}
+	if policy != nil {
+		for _, f := range policy.Files {
+			filePolicies[f.Path] = f.Actions
+		}
+		for _, u := range policy.Units {
+			unitPolicies[u.Name] = u.Actions
+		}
+	}
+
+	plan := &rebootlessPlan{}
+	for _, path := range getChangedFiles(oldIgn.Storage.Files, newIgn.Storage.Files) {
+		if actions, ok := filePolicies[path]; ok {
+			if !plan.addPolicyActions(actions, "") {
+				return nil, false, false, nil
+			}
+			continue
+		}
+		actions, ok := rebootlessFiles[path]
+		if !ok {
+			return nil, false, false, nil
+		}
+		for _, action := range actions {
+			plan.add(action)
+		}
+	}
+
+	units := getChangedUnits(oldIgn.Systemd.Units, newIgn.Systemd.Units)
+	if len(units) > 0 {
+		// pick up the new unit files before acting on them
+		unitActions := &rebootlessPlan{}
+		unitActions.add(serviceAction{verb: "daemon-reload"})
+		for _, name := range units {
+			actions, ok := unitPolicies[name]
+			if !ok || !unitActions.addPolicyActions(actions, name) {
+				return nil, false, false, nil
+			}
+		}
+		for _, action := range unitActions.actions {
+			plan.add(action)
+		}
+		plan.drain = plan.drain || unitActions.drain
+	}
+	return plan.actions, plan.drain, true, nil
+}
+
+// getChangedFiles returns the sorted paths of the files which differ between oldFiles and newFiles.
+func getChangedFiles(oldFiles, newFiles []igntypes.File) []string {
+	files := map[string][2]*igntypes.File{}
+	for i, f := range oldFiles {
+		entry := files[f.Path]
+		entry[0] = &oldFiles[i]
+		files[f.Path] = entry
+	}
+	for i, f := range newFiles {
+		entry := files[f.Path]
+		entry[1] = &newFiles[i]
+		files[f.Path] = entry
+	}
+
+	var paths []string
+	for path, entry := range files {
+		if !reflect.DeepEqual(entry[0], entry[1]) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// getChangedUnits returns the sorted names of the units which differ between oldUnits and newUnits.
+func getChangedUnits(oldUnits, newUnits []igntypes.Unit) []string {
+	units := map[string][2]*igntypes.Unit{}
+	for i, u := range oldUnits {
+		entry := units[u.Name]
+		entry[0] = &oldUnits[i]
+		units[u.Name] = entry
+	}
+	for i, u := range newUnits {
+		entry := units[u.Name]
+		entry[1] = &newUnits[i]
+		units[u.Name] = entry
+	}
+
+	var names []string
+	for name, entry := range units {
+		if !reflect.DeepEqual(entry[0], entry[1]) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyRebootless completes an update which doesn't need a reboot: the services
+// reading the changed files are reloaded or restarted, the node is uncordoned if
+// it was drained and marked Done.
+func (dn *Daemon) applyRebootless(newConfig *mcfgv1.MachineConfig, actions []serviceAction, drained bool) error {
+	strategy := constants.UpdateStrategyNone
+	for _, action := range actions {
+		if action.command != "" {
+			dn.logSystem("Running %s to apply config %s", action.command, newConfig.GetName())
+			if out, err := exec.Command(action.command).CombinedOutput(); err != nil {
+				return errors.Wrapf(err, "failed to run %s: %s", action.command, string(out))
+			}
+			continue
+		}
+		args := []string{action.verb}
+		if action.unit != "" {
+			args = append(args, action.unit)
+		}
+		dn.logSystem("Running systemctl %s to apply config %s", strings.Join(args, " "), newConfig.GetName())
+		if out, err := exec.Command("systemctl", args...).CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "failed to run systemctl %s: %s", strings.Join(args, " "), string(out))
+		}
+		strategy = constants.UpdateStrategyServiceRestart
+	}
+	if err := dn.runHook(newConfig, postUpdateHookTarget); err != nil {
+		return err
+	}
+	dn.cancelSIGTERM()
+
+	dn.logSystem("Update to %s applied without rebooting the node", newConfig.GetName())
+	if dn.nodeWriter == nil {
+		return nil
+	}
+	if drained {
+		if err := dn.uncordon(dn.node); err != nil {
+			return errors.Wrap(err, "failed to uncordon node")
+		}
+	}
+	dn.setUpdateStrategy(strategy)
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "NodeDone", fmt.Sprintf("Setting node %s, currentConfig %s to Done", dn.node.Name, newConfig.GetName()))
+	}
+	if err := dn.nodeWriter.SetDone(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, newConfig.GetName()); err != nil {
+		return errors.Wrap(err, "error setting node's state to Done")
+	}
+	return nil
+}
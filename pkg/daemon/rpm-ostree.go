@@ -12,11 +12,13 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/opencontainers/go-digest"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	pivottypes "github.com/openshift/machine-config-operator/pkg/daemon/pivot/types"
 	pivotutils "github.com/openshift/machine-config-operator/pkg/daemon/pivot/utils"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const (
@@ -24,6 +26,9 @@ const (
 	numRetriesNetCommands = 5
 	// Pull secret.  Written by the machine-config-operator
 	kubeletAuthFile = "/var/lib/kubelet/config.json"
+	// ostreeCommitKey is the label, or manifest annotation, of an OS image
+	// holding its ostree commit
+	ostreeCommitKey = "com.coreos.ostree-commit"
 )
 
 // rpmOstreeState houses zero or more RpmOstreeDeployments
@@ -31,6 +36,7 @@ const (
 // https://github.com/projectatomic/rpm-ostree/blob/bce966a9812df141d38e3290f845171ec745aa4e/src/daemon/rpmostreed-deployment-utils.c#L227
 type rpmOstreeState struct {
 	Deployments []RpmOstreeDeployment
+	Transaction []string
 }
 
 // RpmOstreeDeployment represents a single deployment on a node
@@ -39,6 +45,7 @@ type RpmOstreeDeployment struct {
 	OSName             string   `json:"osname"`
 	Serial             int32    `json:"serial"`
 	Checksum           string   `json:"checksum"`
+	BaseChecksum       string   `json:"base-checksum"`
 	Version            string   `json:"version"`
 	Timestamp          uint64   `json:"timestamp"`
 	Booted             bool     `json:"booted"`
@@ -70,6 +77,9 @@ type NodeUpdaterClient interface {
 	PullAndRebase(string, bool) (string, bool, error)
 	RunPivot(string) error
 	GetBootedDeployment() (*RpmOstreeDeployment, error)
+	GetTransaction() (string, error)
+	PullImage(string) error
+	GetImageOSTreeCommit(string) (string, error)
 }
 
 // RpmOstreeClient provides all RpmOstree related methods in one structure.
@@ -105,6 +115,73 @@ func (r *RpmOstreeClient) GetBootedDeployment() (*RpmOstreeDeployment, error) {
 	return nil, fmt.Errorf("not currently booted in a deployment")
 }
 
+// GetTransaction returns the rpm-ostree transaction in progress, if any
+func (r *RpmOstreeClient) GetTransaction() (string, error) {
+	var rosState rpmOstreeState
+	output, err := runGetOut("rpm-ostree", "status", "--json")
+	if err != nil {
+		return "", err
+	}
+
+	if err := json.Unmarshal(output, &rosState); err != nil {
+		return "", fmt.Errorf("failed to parse `rpm-ostree status --json` output: %v", err)
+	}
+	return strings.Join(rosState.Transaction, " "), nil
+}
+
+// PullImage pulls the OS image into the local container storage, from which
+// pivot then rebases without downloading it again.
+func (r *RpmOstreeClient) PullImage(osImageURL string) error {
+	args := []string{"pull", "-q"}
+	if _, err := os.Stat(kubeletAuthFile); err == nil {
+		args = append(args, "--authfile", kubeletAuthFile)
+	}
+	args = append(args, osImageURL)
+	var lastErr error
+	if err := wait.ExponentialBackoff(wait.Backoff{Steps: numRetriesNetCommands, Duration: 5 * time.Second, Factor: 2}, func() (bool, error) {
+		_, lastErr = runGetOut("podman", args...)
+		return lastErr == nil, nil
+	}); err != nil {
+		return errors.Wrapf(lastErr, "pulling %s", osImageURL)
+	}
+	return nil
+}
+
+// GetImageOSTreeCommit returns the ostree commit of the OS image, from its
+// label or, failing that, its manifest annotation, without pulling it. It
+// returns "" if the image has neither.
+func (r *RpmOstreeClient) GetImageOSTreeCommit(osImageURL string) (string, error) {
+	var args []string
+	if _, err := os.Stat(kubeletAuthFile); err == nil {
+		args = append(args, "--authfile", kubeletAuthFile)
+	}
+	args = append(args, "docker://"+osImageURL)
+
+	output, err := runGetOut("skopeo", append([]string{"inspect", "--no-tags"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	var inspection imageInspection
+	if err := json.Unmarshal(output, &inspection); err != nil {
+		return "", errors.Wrap(err, "parsing skopeo inspect")
+	}
+	if commit := inspection.Labels[ostreeCommitKey]; commit != "" {
+		return commit, nil
+	}
+
+	output, err = runGetOut("skopeo", append([]string{"inspect", "--raw"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(output, &manifest); err != nil {
+		return "", errors.Wrap(err, "parsing image manifest")
+	}
+	return manifest.Annotations[ostreeCommitKey], nil
+}
+
 // GetStatus returns multi-line human-readable text describing system status
 func (r *RpmOstreeClient) GetStatus() (string, error) {
 	output, err := runGetOut("rpm-ostree", "status")
@@ -242,7 +319,7 @@ func (r *RpmOstreeClient) PullAndRebase(container string, keep bool) (imgid stri
 	// Now we need to figure out the commit to rebase to
 
 	// Commit label takes priority
-	ostreeCsum, ok := imagedata.Labels["com.coreos.ostree-commit"]
+	ostreeCsum, ok := imagedata.Labels[ostreeCommitKey]
 	if ok {
 		if ostreeVersion, ok := imagedata.Labels["version"]; ok {
 			glog.Infof("Pivoting to: %s (%s)", ostreeVersion, ostreeCsum)
@@ -353,7 +430,7 @@ func followPivotJournalLogs(stopCh <-chan time.Time) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Start(); err != nil {
-		glog.Fatal(err)
+		logging.Fatal(err)
 		MCDPivotErr.WithLabelValues("", err.Error()).SetToCurrentTime()
 	}
 
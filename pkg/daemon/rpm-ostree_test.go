@@ -19,6 +19,10 @@ type GetBootedOSImageURLReturn struct {
 type RpmOstreeClientMock struct {
 	GetBootedOSImageURLReturns []GetBootedOSImageURLReturn
 	RunPivotReturns            []error
+	Transaction                string
+	PullImageError             error
+	BootedDeployment           *RpmOstreeDeployment
+	ImageOSTreeCommit          string
 }
 
 // GetBootedOSImageURL implements a test version of RpmOStreeClients GetBootedOSImageURL.
@@ -51,5 +55,23 @@ func (r RpmOstreeClientMock) GetStatus() (string, error) {
 }
 
 func (r RpmOstreeClientMock) GetBootedDeployment() (*RpmOstreeDeployment, error) {
+	if r.BootedDeployment != nil {
+		return r.BootedDeployment, nil
+	}
 	return &RpmOstreeDeployment{}, nil
 }
+
+// GetTransaction is a mock
+func (r RpmOstreeClientMock) GetTransaction() (string, error) {
+	return r.Transaction, nil
+}
+
+// PullImage is a mock
+func (r RpmOstreeClientMock) PullImage(string) error {
+	return r.PullImageError
+}
+
+// GetImageOSTreeCommit is a mock
+func (r RpmOstreeClientMock) GetImageOSTreeCommit(string) (string, error) {
+	return r.ImageOSTreeCommit, nil
+}
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/golang/glog"
+	errors "github.com/pkg/errors"
+)
+
+const (
+	// sshDirectoryPermissions is the mode of the .ssh directory of the users
+	// other than core the daemon writes keys for
+	sshDirectoryPermissions = 0700
+	// sshKeysPermissions is the mode of their authorized_keys file
+	sshKeysPermissions = 0600
+)
+
+// verifyUserFields returns nil if the user has a name and if all the fields
+// of the user other than SSHAuthorizedKeys are empty.
+// Otherwise, an error will be returned and the proposed config will not be reconcilable.
+// At this time we do not support creating users or any changes to them
+// outside of SSHAuthorizedKeys.
+func verifyUserFields(pwdUser igntypes.PasswdUser) error {
+	if pwdUser.Name == "" {
+		return errors.New("ignition passwd user section contains unsupported changes: user without a name")
+	}
+	emptyUser := igntypes.PasswdUser{}
+	tempUser := pwdUser
+	tempUser.Name = ""
+	tempUser.SSHAuthorizedKeys = nil
+	if !reflect.DeepEqual(emptyUser, tempUser) {
+		return fmt.Errorf("ignition passwd user section contains unsupported changes: non-sshKey changes for user %s", pwdUser.Name)
+	}
+	return nil
+}
+
+// verifyPasswdUsers returns nil if going from the users of oldUsers to the
+// ones of newUsers only changes SSH keys, and doesn't remove the last SSH key of
+// the core user, which would lock administrators out of the node.
+func verifyPasswdUsers(oldUsers, newUsers []igntypes.PasswdUser) error {
+	seen := make(map[string]bool, len(newUsers))
+	for _, u := range newUsers {
+		if err := verifyUserFields(u); err != nil {
+			return err
+		}
+		if seen[u.Name] {
+			return fmt.Errorf("ignition passwd user section contains unsupported changes: user %s is listed twice", u.Name)
+		}
+		seen[u.Name] = true
+	}
+	oldKeys := sshKeysByUser(oldUsers)
+	newKeys := sshKeysByUser(newUsers)
+	if len(oldKeys[coreUserName]) > 0 && len(newKeys[coreUserName]) == 0 {
+		return fmt.Errorf("ignition passwd user section contains unsupported changes: the last SSH key of the %s user can't be removed", coreUserName)
+	}
+	glog.Info("SSH Keys reconcilable")
+	return nil
+}
+
+// sshKeysByUser returns the non-empty SSH keys of users by user name.
+func sshKeysByUser(users []igntypes.PasswdUser) map[string][]string {
+	keys := make(map[string][]string, len(users))
+	for _, u := range users {
+		if _, ok := keys[u.Name]; !ok {
+			keys[u.Name] = nil
+		}
+		for _, k := range u.SSHAuthorizedKeys {
+			if k := strings.TrimSpace(string(k)); k != "" {
+				keys[u.Name] = append(keys[u.Name], k)
+			}
+		}
+	}
+	return keys
+}
+
+// updateSSHKeys writes the authorized_keys of the users of newUsers, and empties
+// the one of the users of oldUsers which were removed.
+func (dn *Daemon) updateSSHKeys(oldUsers, newUsers []igntypes.PasswdUser) error {
+	keys := sshKeysByUser(newUsers)
+	for name := range sshKeysByUser(oldUsers) {
+		if _, ok := keys[name]; !ok {
+			keys[name] = nil
+		}
+	}
+	if len(keys) == 0 || dn.mock {
+		return nil
+	}
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := dn.atomicallyWriteSSHKey(name, keys[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atomicallyWriteSSHKey writes keys to the authorized_keys of userName. Users
+// other than core must already exist on the host.
+func (dn *Daemon) atomicallyWriteSSHKey(userName string, keys []string) error {
+	var data string
+	for _, k := range keys {
+		data += k + "\n"
+	}
+
+	if userName == coreUserName {
+		authKeyPath := filepath.Join(coreUserSSHPath, "authorized_keys")
+		glog.Infof("Writing SSHKeys at %q", authKeyPath)
+		if err := writeFileAtomicallyWithDefaults(authKeyPath, []byte(data)); err != nil {
+			return err
+		}
+		glog.V(2).Infof("Wrote SSHKeys at %s", authKeyPath)
+		return nil
+	}
+
+	osUser, err := user.Lookup(userName)
+	if err != nil && len(keys) == 0 {
+		// a removed user may not exist anymore, there's nothing to clear then
+		glog.V(2).Infof("Not clearing the SSH keys of %s: %v", userName, err)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to look up user %s, SSH keys can only be set for existing users", userName)
+	}
+	uid, err := strconv.Atoi(osUser.Uid)
+	if err != nil {
+		return errors.Wrapf(err, "invalid uid of user %s", userName)
+	}
+	gid, err := strconv.Atoi(osUser.Gid)
+	if err != nil {
+		return errors.Wrapf(err, "invalid gid of user %s", userName)
+	}
+	authKeyPath := filepath.Join(osUser.HomeDir, ".ssh", "authorized_keys")
+	glog.Infof("Writing SSHKeys of %s at %q", userName, authKeyPath)
+	if err := writeFileAtomically(authKeyPath, []byte(data), sshDirectoryPermissions, sshKeysPermissions, uid, gid); err != nil {
+		return err
+	}
+	glog.V(2).Infof("Wrote SSHKeys at %s", authKeyPath)
+	return nil
+}
@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"encoding/json"
+	"strings"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isNodeUnmanaged returns whether the administrator excluded the node from the
+// management of the MCO, the daemon then leaves it at its current config.
+func isNodeUnmanaged(node *corev1.Node) bool {
+	if node == nil {
+		return false
+	}
+	return node.Annotations[constants.UnmanagedNodeAnnotationKey] == "true" ||
+		node.Labels[constants.UnmanagedNodeAnnotationKey] == "true"
+}
+
+// getUnmanagedPaths returns the paths the node controller asked the daemon not
+// to manage, validated against the paths the MCO relies on already.
+func getUnmanagedPaths(node *corev1.Node) []string {
+	if node == nil {
+		return nil
+	}
+	value, ok := node.Annotations[constants.UnmanagedPathsAnnotationKey]
+	if !ok || value == "" {
+		return nil
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(value), &paths); err != nil {
+		glog.Warningf("Ignoring invalid %s annotation: %v", constants.UnmanagedPathsAnnotationKey, err)
+		return nil
+	}
+	return paths
+}
+
+// isUnmanagedPath returns whether path is one of paths, or in the directory
+// tree of one of them ending with /.
+func isUnmanagedPath(path string, paths []string) bool {
+	for _, p := range paths {
+		if p == path || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// managedFiles returns the files of files which aren't under the unmanaged
+// paths of the node.
+func (dn *Daemon) managedFiles(files []igntypes.File) []igntypes.File {
+	paths := getUnmanagedPaths(dn.node)
+	if len(paths) == 0 {
+		return files
+	}
+	var out []igntypes.File
+	for _, f := range files {
+		if isUnmanagedPath(f.Path, paths) {
+			glog.V(2).Infof("Skipping unmanaged file %s", f.Path)
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
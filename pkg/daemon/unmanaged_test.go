@@ -0,0 +1,40 @@
+package daemon
+
+import (
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+)
+
+func TestManagedFiles(t *testing.T) {
+	files := []igntypes.File{
+		{Node: igntypes.Node{Path: "/etc/resolv.conf"}},
+		{Node: igntypes.Node{Path: "/etc/agent/config"}},
+		{Node: igntypes.Node{Path: "/etc/agent.conf"}},
+		{Node: igntypes.Node{Path: "/etc/hosts"}},
+	}
+	dn := &Daemon{}
+	assert.Equal(t, files, dn.managedFiles(files))
+
+	dn.node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		constants.UnmanagedPathsAnnotationKey: `["/etc/resolv.conf","/etc/agent/"]`,
+	}}}
+	assert.Equal(t, []igntypes.File{files[2], files[3]}, dn.managedFiles(files))
+
+	// an invalid annotation doesn't unmanage anything
+	dn.node.Annotations[constants.UnmanagedPathsAnnotationKey] = "/etc/resolv.conf"
+	assert.Equal(t, files, dn.managedFiles(files))
+}
+
+func TestIsNodeUnmanaged(t *testing.T) {
+	assert.False(t, isNodeUnmanaged(nil))
+	assert.False(t, isNodeUnmanaged(&corev1.Node{}))
+	assert.False(t, isNodeUnmanaged(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constants.UnmanagedNodeAnnotationKey: "false"}}}))
+	assert.True(t, isNodeUnmanaged(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constants.UnmanagedNodeAnnotationKey: "true"}}}))
+	assert.True(t, isNodeUnmanaged(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{constants.UnmanagedNodeAnnotationKey: "true"}}}))
+}
@@ -17,10 +17,10 @@ import (
 	"syscall"
 	"time"
 
-	ign "github.com/coreos/ignition/config/v2_2"
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	"github.com/golang/glog"
 	"github.com/google/renameio"
+	"github.com/openshift/machine-config-operator/internal/logging"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
@@ -40,12 +40,14 @@ const (
 	defaultDirectoryPermissions os.FileMode = 0755
 	// defaultFilePermissions houses the default mode to use when no file permissions are provided
 	defaultFilePermissions os.FileMode = 0644
-	// coreUser is "core" and currently the only permissible user name
+	// coreUserName is the user whose last SSH key can't be removed
 	coreUserName = "core"
-	// SSH Keys for user "core" will only be written at /home/core/.ssh
+	// SSH Keys for user "core" are written at /home/core/.ssh
 	coreUserSSHPath = "/home/core/.ssh/"
 	// fipsFile is the file to check if FIPS is enabled
 	fipsFile = "/proc/sys/crypto/fips_enabled"
+	// extensionsRepo is the temporary yum repository pointing at the extensions shipped in the OS container
+	extensionsRepo = "/etc/yum.repos.d/coreos-extensions.repo"
 )
 
 func installedRTKernelRpmsOnHost() ([]string, error) {
@@ -136,10 +138,82 @@ func (dn *Daemon) finalizeAndReboot(newConfig *mcfgv1.MachineConfig) (retErr err
 		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "PendingConfig", fmt.Sprintf("Written pending config %s", newConfig.GetName()))
 	}
 
+	dn.setUpdateStrategy(constants.UpdateStrategyReboot)
+	dn.setPhase(constants.MachineConfigDaemonPhaseRebooting)
+	MCDReboots.WithLabelValues(configMetricLabels(newConfig.GetName())).Inc()
+
 	// reboot. this function shouldn't actually return.
 	return dn.reboot(fmt.Sprintf("Node will reboot into config %v", newConfig.GetName()))
 }
 
+// setPhase reports the step of the update on the node. Failing to do so
+// only affects the pool status, so it doesn't fail the update.
+func (dn *Daemon) setPhase(phase string) {
+	logging.SetField("phase", phase)
+	if dn.nodeWriter == nil || dn.kubeClient == nil {
+		return
+	}
+	if err := dn.nodeWriter.SetPhase(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, phase); err != nil {
+		glog.Warningf("Failed to set the node's phase to %s: %v", phase, err)
+	}
+}
+
+// setUpdateStrategy records how the update is applied to the node. Failing to do
+// so doesn't fail the update.
+func (dn *Daemon) setUpdateStrategy(strategy string) {
+	if dn.nodeWriter == nil || dn.kubeClient == nil {
+		return
+	}
+	if err := dn.nodeWriter.SetUpdateStrategy(dn.kubeClient.CoreV1().Nodes(), dn.nodeLister, dn.name, strategy); err != nil {
+		glog.Warningf("Failed to record the %s update strategy: %v", strategy, err)
+	}
+}
+
+// drainPolicy is the drain policy of the node with the defaults applied.
+type drainPolicy struct {
+	timeout                  time.Duration
+	attempts                 int
+	backoff                  time.Duration
+	skipWaitForDeleteTimeout time.Duration
+	forceAfterAttempts       int
+}
+
+// getDrainPolicy reads the drain policy the node controller set on the node.
+// An invalid policy is logged and the defaults are used instead, a drain
+// shouldn't be blocked by it.
+func getDrainPolicy(node *corev1.Node) drainPolicy {
+	policy := drainPolicy{
+		timeout:  20 * time.Second,
+		attempts: 5,
+		backoff:  10 * time.Second,
+	}
+	value, ok := node.Annotations[constants.DrainPolicyAnnotationKey]
+	if !ok {
+		return policy
+	}
+	var spec mcfgv1.DrainPolicy
+	if err := json.Unmarshal([]byte(value), &spec); err != nil {
+		glog.Warningf("Ignoring invalid drain policy %q: %v", value, err)
+		return policy
+	}
+	if spec.Timeout != nil && spec.Timeout.Duration > 0 {
+		policy.timeout = spec.Timeout.Duration
+	}
+	if spec.Attempts > 0 {
+		policy.attempts = int(spec.Attempts)
+	}
+	if spec.Backoff != nil && spec.Backoff.Duration > 0 {
+		policy.backoff = spec.Backoff.Duration
+	}
+	if spec.SkipWaitForDeleteTimeout != nil && spec.SkipWaitForDeleteTimeout.Duration > 0 {
+		policy.skipWaitForDeleteTimeout = spec.SkipWaitForDeleteTimeout.Duration
+	}
+	if spec.ForceAfterAttempts > 0 {
+		policy.forceAfterAttempts = int(spec.ForceAfterAttempts)
+	}
+	return policy
+}
+
 func (dn *Daemon) drain() error {
 	// Skip draining of the node when we're not cluster driven
 	if dn.kubeClient == nil {
@@ -147,24 +221,40 @@ func (dn *Daemon) drain() error {
 	}
 
 	dn.logSystem("Update prepared; beginning drain")
+	dn.setPhase(constants.MachineConfigDaemonPhaseDraining)
 	startTime := time.Now()
 
 	dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "Drain", "Draining node to update config.")
 
+	if err := dn.recordCordonOwner(); err != nil {
+		return err
+	}
+
+	policy := getDrainPolicy(dn.node)
+	drainer := *dn.drainer
+	drainer.Timeout = policy.timeout
+	drainer.SkipWaitForDeleteTimeoutSeconds = int(policy.skipWaitForDeleteTimeout.Seconds())
+
 	backoff := wait.Backoff{
-		Steps:    5,
-		Duration: 10 * time.Second,
+		Steps:    policy.attempts,
+		Duration: policy.backoff,
 		Factor:   2,
 	}
+	attempt := 0
 	var lastErr error
 	if err := wait.ExponentialBackoff(backoff, func() (bool, error) {
-		err := drain.RunCordonOrUncordon(dn.drainer, dn.node, true)
+		attempt++
+		if policy.forceAfterAttempts > 0 && attempt > policy.forceAfterAttempts && !drainer.DisableEviction {
+			glog.Infof("Drain failed %d times, deleting pods instead of evicting them", policy.forceAfterAttempts)
+			drainer.DisableEviction = true
+		}
+		err := drain.RunCordonOrUncordon(&drainer, dn.node, true)
 		if err != nil {
 			lastErr = err
 			glog.Infof("Cordon failed with: %v, retrying", err)
 			return false, nil
 		}
-		err = drain.RunNodeDrain(dn.drainer, dn.node.Name)
+		err = drain.RunNodeDrain(&drainer, dn.node.Name)
 		if err == nil {
 			return true, nil
 		}
@@ -172,6 +262,7 @@ func (dn *Daemon) drain() error {
 		glog.Infof("Draining failed with: %v, retrying", err)
 		return false, nil
 	}); err != nil {
+		dn.setPhase(constants.MachineConfigDaemonPhaseDrainFailed)
 		failTime := fmt.Sprintf("%v sec", time.Since(startTime).Seconds())
 		if err == wait.ErrWaitTimeout {
 			failMsg := fmt.Sprintf("%d tries: %v", backoff.Steps, lastErr)
@@ -189,6 +280,7 @@ func (dn *Daemon) drain() error {
 	glog.Infof("Successful drain took %v seconds", t)
 	successTime := fmt.Sprintf("%v sec", t)
 	MCDDrainErr.WithLabelValues(successTime, "").Set(0)
+	MCDDrainDuration.WithLabelValues(configMetricLabels(dn.node.Annotations[constants.DesiredMachineConfigAnnotationKey])).Observe(t)
 
 	return nil
 }
@@ -246,6 +338,9 @@ func (dn *Daemon) compareMachineConfig(oldConfig, newConfig *mcfgv1.MachineConfi
 	oldConfig = canonicalizeEmptyMC(oldConfig)
 	oldConfigName := oldConfig.GetName()
 	newConfigName := newConfig.GetName()
+	pool, _ := configMetricLabels(newConfigName)
+	logging.SetField("pool", pool)
+	logging.SetField("config", newConfigName)
 	mcDiff, err := NewMachineConfigDiff(oldConfig, newConfig)
 	if err != nil {
 		return true, errors.Wrapf(err, "error creating MachineConfigDiff for comparison")
@@ -277,6 +372,7 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 	defer func() {
 		if retErr != nil {
 			dn.cancelSIGTERM()
+			setLastUpdateResult(newConfig.GetName(), false)
 		}
 	}()
 
@@ -303,9 +399,50 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 
 	dn.logSystem("Starting update from %s to %s: %+v", oldConfigName, newConfigName, diff)
 
-	if err := dn.drain(); err != nil {
+	// an OS image rebuilt without changing the OS is neither pulled nor pivoted to
+	osConfig, osCommit := dn.getOSUpdateConfig(oldConfig, newConfig)
+
+	actions, drainNeeded, rebootless, err := getRebootlessActions(oldConfig, osConfig, getNodeDisruptionPolicy(dn.node))
+	if err != nil {
 		return err
 	}
+	if !rebootless {
+		if err := dn.runPreflightChecks(oldConfig, osConfig); err != nil {
+			if dn.recorder != nil {
+				dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeWarning, "PreflightFailed", err.Error())
+			}
+			return errors.Wrap(err, "pre-flight checks failed")
+		}
+		if err := dn.prePullOSImage(osConfig); err != nil {
+			return err
+		}
+	}
+	staged := !rebootless && dn.isStagedUpdate()
+	if rebootless && !drainNeeded {
+		dn.logSystem("Update can be applied without rebooting, the node will not be drained")
+	} else if staged {
+		dn.logSystem("Staging update, the node will not be drained nor rebooted")
+	} else {
+		if err := dn.runHook(oldConfig, preUpdateHookTarget); err != nil {
+			return err
+		}
+		if err := dn.drain(); err != nil {
+			return err
+		}
+	}
+
+	// record the update as in progress until it either completes or it's
+	// rolled back, to recover from it if it's interrupted
+	if err := dn.writeUpdateJournal(oldConfig, newConfig); err != nil {
+		return errors.Wrap(err, "writing update journal")
+	}
+	defer func() {
+		if retErr != nil {
+			if err := dn.removeUpdateJournal(); err != nil {
+				retErr = errors.Wrapf(retErr, "error removing update journal %v", err)
+			}
+		}
+	}()
 
 	// update files on disk that need updating
 	if err := dn.updateFiles(oldConfig, newConfig); err != nil {
@@ -321,22 +458,22 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		}
 	}()
 
-	oldIgnConfig, report, err := ign.Parse(oldConfig.Spec.Config.Raw)
+	oldIgnConfig, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
 	if err != nil {
-		return fmt.Errorf("parsing old Ignition config failed with error: %v\nReport: %v", err, report)
+		return fmt.Errorf("parsing old Ignition config failed with error: %v", err)
 	}
-	newIgnConfig, report, err := ign.Parse(newConfig.Spec.Config.Raw)
+	newIgnConfig, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
 	if err != nil {
-		return fmt.Errorf("parsing new Ignition config failed with error: %v\nReport: %v", err, report)
+		return fmt.Errorf("parsing new Ignition config failed with error: %v", err)
 	}
 
-	if err := dn.updateSSHKeys(newIgnConfig.Passwd.Users); err != nil {
+	if err := dn.updateSSHKeys(oldIgnConfig.Passwd.Users, newIgnConfig.Passwd.Users); err != nil {
 		return err
 	}
 
 	defer func() {
 		if retErr != nil {
-			if err := dn.updateSSHKeys(oldIgnConfig.Passwd.Users); err != nil {
+			if err := dn.updateSSHKeys(newIgnConfig.Passwd.Users, oldIgnConfig.Passwd.Users); err != nil {
 				retErr = errors.Wrapf(retErr, "error rolling back SSH keys updates %v", err)
 				return
 			}
@@ -382,7 +519,87 @@ func (dn *Daemon) update(oldConfig, newConfig *mcfgv1.MachineConfig) (retErr err
 		}
 	}()
 
-	return dn.updateOSAndReboot(newConfig)
+	// Apply extensions
+	if err := dn.applyExtensions(oldConfig, newConfig); err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			if err := dn.applyExtensions(newConfig, oldConfig); err != nil {
+				retErr = errors.Wrapf(retErr, "error rolling back extensions %v", err)
+				return
+			}
+		}
+	}()
+
+	// the booted deployment keeps the origin of the previous image, record
+	// the new one before rebooting for the node to come back on newConfig
+	if osCommit != "" {
+		if err := dn.writeOSImageAlias(newConfig.Spec.OSImageURL, osCommit); err != nil {
+			return errors.Wrap(err, "recording OS image alias")
+		}
+	}
+
+	if rebootless {
+		if err := dn.applyRebootless(newConfig, actions, drainNeeded && dn.kubeClient != nil); err != nil {
+			return err
+		}
+		return dn.removeUpdateJournal()
+	}
+	if err := dn.handOverPostUpdateHealth(oldConfig, newConfig); err != nil {
+		return errors.Wrap(err, "handing the post-update health checks over to the host")
+	}
+	if staged {
+		return dn.stageUpdate(osConfig)
+	}
+	return dn.updateOSAndReboot(osConfig)
+}
+
+// isStagedUpdate returns whether the node controller asked to only stage the
+// update and nobody asked to apply it yet.
+func (dn *Daemon) isStagedUpdate() bool {
+	if dn.node == nil {
+		return false
+	}
+	return dn.node.Annotations[constants.StagedUpdateAnnotationKey] == "true" &&
+		dn.node.Annotations[constants.ApplyStagedUpdateAnnotationKey] != "true"
+}
+
+// stageUpdate is the last step of a staged update(): the new OS is deployed
+// and newConfig recorded as pending, so that the next reboot, whatever
+// triggers it, completes the update.
+func (dn *Daemon) stageUpdate(newConfig *mcfgv1.MachineConfig) error {
+	if err := dn.updateOS(newConfig); err != nil {
+		return err
+	}
+	if out, err := dn.storePendingState(newConfig, 1); err != nil {
+		return errors.Wrapf(err, "failed to log pending config: %s", string(out))
+	}
+	dn.setPhase(constants.MachineConfigDaemonPhaseStaged)
+	dn.logSystem("Update to %s staged, waiting for a reboot or the %s annotation", newConfig.GetName(), constants.ApplyStagedUpdateAnnotationKey)
+	if dn.recorder != nil {
+		dn.recorder.Eventf(getNodeRef(dn.node), corev1.EventTypeNormal, "StagedConfig", fmt.Sprintf("Staged config %s", newConfig.GetName()))
+	}
+	return nil
+}
+
+// syncStagedUpdate handles a node whose update to pendingConfig is staged:
+// it waits until the update is applied, and drains and reboots the node
+// once it is annotated to do so.
+func (dn *Daemon) syncStagedUpdate(pendingConfig *mcfgv1.MachineConfig) error {
+	if dn.isStagedUpdate() {
+		glog.V(2).Infof("Update to %s is staged, waiting for a reboot or the %s annotation", pendingConfig.GetName(), constants.ApplyStagedUpdateAnnotationKey)
+		return nil
+	}
+	dn.logSystem("Applying staged update to %s", pendingConfig.GetName())
+	// the files of the pending config are already on disk
+	if err := dn.runHook(pendingConfig, preUpdateHookTarget); err != nil {
+		return err
+	}
+	if err := dn.drain(); err != nil {
+		return err
+	}
+	return dn.finalizeAndReboot(pendingConfig)
 }
 
 // MachineConfigDiff represents an ad-hoc difference between two MachineConfig objects.
@@ -397,6 +614,7 @@ type MachineConfigDiff struct {
 	files      bool
 	units      bool
 	kernelType bool
+	extensions bool
 }
 
 // canonicalizeKernelType returns a valid kernelType. We consider empty("") and default kernelType as same
@@ -409,13 +627,13 @@ func canonicalizeKernelType(kernelType string) string {
 
 // NewMachineConfigDiff compares two MachineConfig objects.
 func NewMachineConfigDiff(oldConfig, newConfig *mcfgv1.MachineConfig) (*MachineConfigDiff, error) {
-	oldIgn, report, err := ign.Parse(oldConfig.Spec.Config.Raw)
+	oldIgn, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
 	if err != nil {
-		return nil, fmt.Errorf("parsing old Ignition config failed with error: %v\nReport: %v", err, report)
+		return nil, fmt.Errorf("parsing old Ignition config failed with error: %v", err)
 	}
-	newIgn, report, err := ign.Parse(newConfig.Spec.Config.Raw)
+	newIgn, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
 	if err != nil {
-		return nil, fmt.Errorf("parsing new Ignition config failed with error: %v\nReport: %v", err, report)
+		return nil, fmt.Errorf("parsing new Ignition config failed with error: %v", err)
 	}
 
 	// Both nil and empty slices are of zero length,
@@ -430,6 +648,7 @@ func NewMachineConfigDiff(oldConfig, newConfig *mcfgv1.MachineConfig) (*MachineC
 		files:      !reflect.DeepEqual(oldIgn.Storage.Files, newIgn.Storage.Files),
 		units:      !reflect.DeepEqual(oldIgn.Systemd.Units, newIgn.Systemd.Units),
 		kernelType: canonicalizeKernelType(oldConfig.Spec.KernelType) != canonicalizeKernelType(newConfig.Spec.KernelType),
+		extensions: len(generateExtensionsArgs(oldConfig, newConfig)) > 0,
 	}, nil
 }
 
@@ -453,14 +672,14 @@ func (d *MachineConfigDiff) IsEmpty() bool {
 // config currently.
 func Reconcilable(oldConfig, newConfig *mcfgv1.MachineConfig) (*MachineConfigDiff, error) {
 	// The parser will try to translate versions less than maxVersion to maxVersion, or output an err.
-	// The ignition output in case of success will always have maxVersion
-	oldIgn, report, err := ign.Parse(oldConfig.Spec.Config.Raw)
+	// Spec 3 configs are translated to spec 2.2 so the rest of the diff doesn't care about the version.
+	oldIgn, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
 	if err != nil {
-		return nil, fmt.Errorf("parsing old Ignition config failed with error: %v\nReport: %v", err, report)
+		return nil, fmt.Errorf("parsing old Ignition config failed with error: %v", err)
 	}
-	newIgn, report, err := ign.Parse(newConfig.Spec.Config.Raw)
+	newIgn, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
 	if err != nil {
-		return nil, fmt.Errorf("parsing new Ignition config failed with error: %v\nReport: %v", err, report)
+		return nil, fmt.Errorf("parsing new Ignition config failed with error: %v", err)
 	}
 
 	// Check if this is a generally valid Ignition Config
@@ -479,28 +698,18 @@ func Reconcilable(oldConfig, newConfig *mcfgv1.MachineConfig) (*MachineConfigDif
 	// Passwd section
 
 	// we don't currently configure Groups in place. we don't configure Users except
-	// for setting/updating SSHAuthorizedKeys for the only allowed user "core".
+	// for setting/updating their SSHAuthorizedKeys, see verifyPasswdUsers.
 	// otherwise we can't fix it if something changed here.
 	passwdChanged := !reflect.DeepEqual(oldIgn.Passwd, newIgn.Passwd)
 	if passwdChanged {
 		if !reflect.DeepEqual(oldIgn.Passwd.Groups, newIgn.Passwd.Groups) {
 			return nil, errors.New("ignition Passwd Groups section contains changes")
 		}
-		if !reflect.DeepEqual(oldIgn.Passwd.Users, newIgn.Passwd.Users) {
-			// check if the prior config is empty and that this is the first time running.
-			// if so, the SSHKey from the cluster config and user "core" must be added to machine config.
-			if len(oldIgn.Passwd.Users) > 0 && len(newIgn.Passwd.Users) >= 1 {
-				// there is an update to Users, we must verify that it is ONLY making an acceptable
-				// change to the SSHAuthorizedKeys for the user "core"
-				for _, user := range newIgn.Passwd.Users {
-					if user.Name != coreUserName {
-						return nil, errors.New("ignition passwd user section contains unsupported changes: non-core user")
-					}
-				}
-				glog.Infof("user data to be verified before ssh update: %v", newIgn.Passwd.Users[len(newIgn.Passwd.Users)-1])
-				if err := verifyUserFields(newIgn.Passwd.Users[len(newIgn.Passwd.Users)-1]); err != nil {
-					return nil, err
-				}
+		// check if the prior config is empty and that this is the first time running.
+		// if so, the SSHKey from the cluster config and user "core" must be added to machine config.
+		if len(oldIgn.Passwd.Users) > 0 && !reflect.DeepEqual(oldIgn.Passwd.Users, newIgn.Passwd.Users) {
+			if err := verifyPasswdUsers(oldIgn.Passwd.Users, newIgn.Passwd.Users); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -557,27 +766,6 @@ func Reconcilable(oldConfig, newConfig *mcfgv1.MachineConfig) (*MachineConfigDif
 	return mcDiff, nil
 }
 
-// verifyUserFields returns nil if the user Name = "core", if 1 or more SSHKeys exist for
-// this user and if all other fields in User are empty.
-// Otherwise, an error will be returned and the proposed config will not be reconcilable.
-// At this time we do not support non-"core" users or any changes to the "core" user
-// outside of SSHAuthorizedKeys.
-func verifyUserFields(pwdUser igntypes.PasswdUser) error {
-	emptyUser := igntypes.PasswdUser{}
-	tempUser := pwdUser
-	if tempUser.Name == coreUserName && len(tempUser.SSHAuthorizedKeys) >= 1 {
-		tempUser.Name = ""
-		tempUser.SSHAuthorizedKeys = nil
-		if !reflect.DeepEqual(emptyUser, tempUser) {
-			return errors.New("ignition passwd user section contains unsupported changes: non-sshKey changes")
-		}
-		glog.Info("SSH Keys reconcilable")
-	} else {
-		return errors.New("ignition passwd user section contains unsupported changes: user must be core and have 1 or more sshKeys")
-	}
-	return nil
-}
-
 // checkFIPS verifies the state of FIPS on the system before an update.
 // Our new thought around this is that really FIPS should be a "day 1"
 // operation, and we don't want to make it editable after the fact.
@@ -589,6 +777,10 @@ func checkFIPS(current, desired *mcfgv1.MachineConfig) error {
 	content, err := ioutil.ReadFile(fipsFile)
 	if err != nil {
 		if os.IsNotExist(err) {
+			// a kernel without FIPS support can't run a config requiring it
+			if desired.Spec.FIPS {
+				return fmt.Errorf("%s requires FIPS mode, but the kernel doesn't support it: no %s", desired.GetName(), fipsFile)
+			}
 			// we just exit cleanly if we're not even on linux
 			glog.Infof("no %s on this system, skipping FIPS check", fipsFile)
 			return nil
@@ -604,32 +796,37 @@ func checkFIPS(current, desired *mcfgv1.MachineConfig) error {
 		current.Spec.FIPS = nodeFIPS
 		return nil
 	}
-	return errors.New("detected change to FIPS flag. Refusing to modify FIPS on a running cluster")
+	return fmt.Errorf("detected change to FIPS flag: %s has fips %t but the node runs with fips %t. Refusing to modify FIPS on a running cluster", desired.GetName(), desired.Spec.FIPS, nodeFIPS)
 }
 
 // generateKargsCommand performs a diff between the old/new MC kernelArguments,
 // and generates the command line arguments suitable for `rpm-ostree kargs`.
+// Arguments are compared by occurrence count rather than presence, so that an
+// argument listed several times (e.g. by different MachineConfigs) is added or
+// deleted as many times as needed to match the desired config.
 // Note what we really should be doing though is also looking at the *current*
 // kernel arguments in case there was drift.  But doing that requires us knowing
 // what the "base" arguments are.  See https://github.com/ostreedev/ostree/issues/479
 func generateKargsCommand(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
-	oldKargs := make(map[string]bool)
+	oldKargs := make(map[string]int)
 	for _, arg := range oldConfig.Spec.KernelArguments {
-		oldKargs[arg] = true
+		oldKargs[arg]++
 	}
-	newKargs := make(map[string]bool)
+	newKargs := make(map[string]int)
 	for _, arg := range newConfig.Spec.KernelArguments {
-		newKargs[arg] = true
+		newKargs[arg]++
 	}
 	cmdArgs := []string{}
 	for _, arg := range oldConfig.Spec.KernelArguments {
-		if !newKargs[arg] {
+		if oldKargs[arg] > newKargs[arg] {
 			cmdArgs = append(cmdArgs, "--delete="+arg)
+			oldKargs[arg]--
 		}
 	}
 	for _, arg := range newConfig.Spec.KernelArguments {
-		if !oldKargs[arg] {
+		if newKargs[arg] > oldKargs[arg] {
 			cmdArgs = append(cmdArgs, "--append="+arg)
+			newKargs[arg]--
 		}
 	}
 	return cmdArgs
@@ -641,7 +838,7 @@ func (dn *Daemon) updateKernelArguments(oldConfig, newConfig *mcfgv1.MachineConf
 	if len(diff) == 0 {
 		return nil
 	}
-	if dn.OperatingSystem != machineConfigDaemonOSRHCOS && dn.OperatingSystem != machineConfigDaemonOSFCOS {
+	if !isCoreOSVariant(dn.OperatingSystem) {
 		return fmt.Errorf("Updating kargs on non-CoreOS nodes is not supported: %v", diff)
 	}
 
@@ -798,6 +995,71 @@ func (dn *Daemon) switchKernel(oldConfig, newConfig *mcfgv1.MachineConfig) error
 	return nil
 }
 
+// generateExtensionsArgs performs a diff between the old/new MC extensions and
+// generates the `rpm-ostree update` arguments installing the packages of the
+// added extensions and uninstalling the ones of the removed extensions.
+func generateExtensionsArgs(oldConfig, newConfig *mcfgv1.MachineConfig) []string {
+	oldExt := make(map[string]bool)
+	for _, ext := range oldConfig.Spec.Extensions {
+		oldExt[ext] = true
+	}
+	newExt := make(map[string]bool)
+	for _, ext := range newConfig.Spec.Extensions {
+		newExt[ext] = true
+	}
+	args := []string{}
+	for _, ext := range oldConfig.Spec.Extensions {
+		if !newExt[ext] {
+			for _, pkg := range ctrlcommon.SupportedExtensions[ext] {
+				args = append(args, "--uninstall", pkg)
+			}
+		}
+	}
+	for _, ext := range newConfig.Spec.Extensions {
+		if !oldExt[ext] {
+			for _, pkg := range ctrlcommon.SupportedExtensions[ext] {
+				args = append(args, "--install", pkg)
+			}
+		}
+	}
+	return args
+}
+
+// applyExtensions layers the packages of the requested extensions with rpm-ostree,
+// using the extensions repository shipped in the OS container of newConfig.
+func (dn *Daemon) applyExtensions(oldConfig, newConfig *mcfgv1.MachineConfig) error {
+	extArgs := generateExtensionsArgs(oldConfig, newConfig)
+	if len(extArgs) == 0 {
+		return nil
+	}
+	// We support extensions only on RHCOS nodes
+	if dn.OperatingSystem != machineConfigDaemonOSRHCOS {
+		return fmt.Errorf("Installing extensions on non-RHCOS nodes is not supported")
+	}
+
+	mnt, containerName, err := dn.mountOSContainer(newConfig.Spec.OSImageURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		podmanRemove(containerName)
+		exec.Command("podman", "rmi", newConfig.Spec.OSImageURL).Run()
+		os.Remove(extensionsRepo)
+	}()
+
+	repo := fmt.Sprintf("[coreos-extensions]\nenabled=1\nmetadata_expire=1m\nbaseurl=%s/extensions/\ngpgcheck=0\nskip_if_unavailable=False\n", mnt)
+	if err := writeFileAtomicallyWithDefaults(extensionsRepo, []byte(repo)); err != nil {
+		return err
+	}
+
+	args := append([]string{"update"}, extArgs...)
+	dn.logSystem("Applying extensions, invoking rpm-ostree %+q", args)
+	if out, err := exec.Command("rpm-ostree", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Failed to execute rpm-ostree %+q: %v: %s", args, err, string(out))
+	}
+	return nil
+}
+
 // updateFiles writes files specified by the nodeconfig to disk. it also writes
 // systemd units. there is no support for multiple filesystems at this point.
 //
@@ -817,17 +1079,18 @@ func (dn *Daemon) switchKernel(oldConfig, newConfig *mcfgv1.MachineConfig) error
 // touched.
 func (dn *Daemon) updateFiles(oldConfig, newConfig *mcfgv1.MachineConfig) error {
 	glog.Info("Updating files")
-	oldIgnConfig, report, err := ign.Parse(oldConfig.Spec.Config.Raw)
+	oldIgnConfig, err := ctrlcommon.ParseAndConvertConfig(oldConfig.Spec.Config.Raw)
 	if err != nil {
-		return fmt.Errorf("failed to update files. Parsing old Ignition config failed with error: %v\nReport: %v", err, report)
+		return fmt.Errorf("failed to update files. Parsing old Ignition config failed with error: %v", err)
 	}
-	newIgnConfig, report, err := ign.Parse(newConfig.Spec.Config.Raw)
+	newIgnConfig, err := ctrlcommon.ParseAndConvertConfig(newConfig.Spec.Config.Raw)
 	if err != nil {
-		return fmt.Errorf("failed to update files. Parsing new Ignition config failed with error: %v\nReport: %v", err, report)
+		return fmt.Errorf("failed to update files. Parsing new Ignition config failed with error: %v", err)
 	}
 	if err := dn.writeFiles(newIgnConfig.Storage.Files); err != nil {
 		return err
 	}
+	MCDFilesWritten.WithLabelValues(configMetricLabels(newConfig.GetName())).Add(float64(len(newIgnConfig.Storage.Files)))
 	if err := dn.writeUnits(newIgnConfig.Systemd.Units); err != nil {
 		return err
 	}
@@ -853,7 +1116,7 @@ func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig *igntypes.Config) e
 		return errors.Wrapf(err, "checking operating system")
 	}
 
-	for _, f := range oldIgnConfig.Storage.Files {
+	for _, f := range dn.managedFiles(oldIgnConfig.Storage.Files) {
 		if _, ok := newFileSet[f.Path]; !ok {
 			if _, err := os.Stat(noOrigFileStampName(f.Path)); err == nil {
 				if err := os.Remove(noOrigFileStampName(f.Path)); err != nil {
@@ -869,7 +1132,7 @@ func (dn *Daemon) deleteStaleData(oldIgnConfig, newIgnConfig *igntypes.Config) e
 						return errors.Wrapf(err, "deleting orig file %q: %v", origFileName(f.Path), err)
 					}
 				} else if _, err := os.Stat("/usr" + f.Path); strings.HasPrefix(f.Path, "/etc") && os.IsNotExist(err) &&
-					(operatingSystem == machineConfigDaemonOSRHCOS || operatingSystem == machineConfigDaemonOSFCOS) {
+					isCoreOSVariant(operatingSystem) {
 					if err := os.Remove(origFileName(f.Path)); err != nil {
 						return errors.Wrapf(err, "deleting orig file %q: %v", origFileName(f.Path), err)
 					}
@@ -1057,7 +1320,7 @@ func (dn *Daemon) writeUnits(units []igntypes.Unit) error {
 // writeFiles writes the given files to disk.
 // it doesn't fetch remote files and expects a flattened config file.
 func (dn *Daemon) writeFiles(files []igntypes.File) error {
-	for _, file := range files {
+	for _, file := range dn.managedFiles(files) {
 		glog.Infof("Writing file %q", file.Path)
 
 		contents, err := dataurl.DecodeString(file.Contents.Source)
@@ -1168,55 +1431,18 @@ func getFileOwnership(file igntypes.File) (int, int, error) {
 	return uid, gid, nil
 }
 
-func (dn *Daemon) atomicallyWriteSSHKey(keys string) error {
-	authKeyPath := filepath.Join(coreUserSSHPath, "authorized_keys")
-
-	// Keys should only be written to "/home/core/.ssh"
-	// Once Users are supported fully this should be writing to PasswdUser.HomeDir
-	glog.Infof("Writing SSHKeys at %q", authKeyPath)
-
-	if err := writeFileAtomicallyWithDefaults(authKeyPath, []byte(keys)); err != nil {
-		return err
-	}
-
-	glog.V(2).Infof("Wrote SSHKeys at %s", authKeyPath)
-
-	return nil
-}
-
-// Update a given PasswdUser's SSHKey
-func (dn *Daemon) updateSSHKeys(newUsers []igntypes.PasswdUser) error {
-	if len(newUsers) == 0 {
-		return nil
-	}
-
-	// we're also appending all keys for any user to core, so for now
-	// we pass this to atomicallyWriteSSHKeys to write.
-	// we know these users are "core" ones also cause this slice went through Reconcilable
-	var concatSSHKeys string
-	for _, u := range newUsers {
-		for _, k := range u.SSHAuthorizedKeys {
-			concatSSHKeys = concatSSHKeys + string(k) + "\n"
-		}
-	}
-	if !dn.mock {
-		// Note we write keys only for the core user and so this ignores the user list
-		if err := dn.atomicallyWriteSSHKey(concatSSHKeys); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 // updateOS updates the system OS to the one specified in newConfig
 func (dn *Daemon) updateOS(config *mcfgv1.MachineConfig) error {
-	if dn.OperatingSystem != machineConfigDaemonOSRHCOS && dn.OperatingSystem != machineConfigDaemonOSFCOS {
-		glog.V(2).Info("Updating of non-CoreOS nodes are not supported")
-		return nil
+	if !isCoreOSVariant(dn.OperatingSystem) {
+		if dn.packageUpdater == nil {
+			glog.V(2).Info("Updating of non-CoreOS nodes are not supported")
+			return nil
+		}
+		return dn.packageUpdater.Update(config.Spec.OSImageURL)
 	}
 
 	newURL := config.Spec.OSImageURL
-	osMatch, err := compareOSImageURL(dn.bootedOSImageURL, newURL)
+	osMatch, err := dn.checkOS(newURL)
 	if err != nil {
 		return err
 	}
@@ -1228,14 +1454,43 @@ func (dn *Daemon) updateOS(config *mcfgv1.MachineConfig) error {
 	}
 
 	glog.Infof("Updating OS to %s", newURL)
+	startTime := time.Now()
 	if err := dn.NodeUpdaterClient.RunPivot(newURL); err != nil {
 		MCDPivotErr.WithLabelValues(newURL, err.Error()).SetToCurrentTime()
 		return fmt.Errorf("failed to run pivot: %v", err)
 	}
+	MCDPivotDuration.WithLabelValues(configMetricLabels(config.GetName())).Observe(time.Since(startTime).Seconds())
 
 	return nil
 }
 
+// prePullOSImage pulls the new OS image while the node is still schedulable,
+// so that the node is only drained once the image is available locally and
+// pivot doesn't need to download it.
+func (dn *Daemon) prePullOSImage(config *mcfgv1.MachineConfig) error {
+	newURL := config.Spec.OSImageURL
+	if !isCoreOSVariant(dn.OperatingSystem) {
+		return nil
+	}
+	osMatch, err := dn.checkOS(newURL)
+	if err != nil {
+		return err
+	}
+	if osMatch {
+		return nil
+	}
+
+	dn.logSystem("Pulling OS image %s before draining the node", newURL)
+	dn.setPhase(constants.MachineConfigDaemonPhasePullingImage)
+	startTime := time.Now()
+	if err := dn.NodeUpdaterClient.PullImage(newURL); err != nil {
+		MCDPivotErr.WithLabelValues(newURL, err.Error()).SetToCurrentTime()
+		return errors.Wrap(err, "failed to pull the OS image")
+	}
+	dn.logSystem("Pulled OS image in %v", time.Since(startTime).Round(time.Second))
+	return nil
+}
+
 func (dn *Daemon) getPendingStateLegacyLogger() (*journalMsg, error) {
 	glog.Info("logger doesn't support --jounald, grepping the journal")
 
@@ -1400,7 +1655,7 @@ func (dn *Daemon) reboot(rationale string) error {
 	}
 	dn.logSystem("initiating reboot: %s", rationale)
 
-	rebootCmd := rebootCommand(rationale)
+	rebootCmd := rebootCommand(rationale, getRebootStrategy(dn.node))
 
 	// reboot, executed async via systemd-run so that the reboot command is executed
 	// in the context of the host asynchronously from us
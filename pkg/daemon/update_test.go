@@ -10,8 +10,11 @@ import (
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
@@ -70,8 +73,8 @@ func TestReconcilable(t *testing.T) {
 	oldConfig := helpers.CreateMachineConfigFromIgnition(oldIgnCfg)
 	newIgnCfg := ctrlcommon.NewIgnConfig()
 
-	// Set improper version
-	newIgnCfg.Ignition.Version = "3.0.0"
+	// Set unsupported version
+	newIgnCfg.Ignition.Version = "4.0.0"
 
 	// newConfig is the config that is being requested to apply to the system
 	newConfig := helpers.CreateMachineConfigFromIgnition(newIgnCfg)
@@ -325,6 +328,214 @@ func TestKernelAguments(t *testing.T) {
 	}
 }
 
+func TestKernelArgumentsDuplicates(t *testing.T) {
+	tests := []struct {
+		oldArgs  []string
+		newArgs  []string
+		expected []string
+	}{
+		{
+			oldArgs:  nil,
+			newArgs:  []string{"console=ttyS0", "console=ttyS0"},
+			expected: []string{"--append=console=ttyS0", "--append=console=ttyS0"},
+		},
+		{
+			oldArgs:  []string{"console=ttyS0", "console=ttyS0", "nosmt"},
+			newArgs:  []string{"console=ttyS0", "nosmt"},
+			expected: []string{"--delete=console=ttyS0"},
+		},
+		{
+			oldArgs:  []string{"console=ttyS0", "nosmt"},
+			newArgs:  []string{"nosmt", "console=ttyS0", "console=ttyS0"},
+			expected: []string{"--append=console=ttyS0"},
+		},
+		{
+			oldArgs:  []string{"foo", "foo"},
+			newArgs:  nil,
+			expected: []string{"--delete=foo", "--delete=foo"},
+		},
+	}
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("case#%d", idx), func(t *testing.T) {
+			oldMcfg := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+			oldMcfg.Spec.KernelArguments = test.oldArgs
+			newMcfg := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+			newMcfg.Spec.KernelArguments = test.newArgs
+			assert.Equal(t, test.expected, generateKargsCommand(oldMcfg, newMcfg))
+		})
+	}
+}
+
+func TestExtensionsArgs(t *testing.T) {
+	oldMcfg := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+	newMcfg := helpers.CreateMachineConfigFromIgnition(ctrlcommon.NewIgnConfig())
+	assert.Empty(t, generateExtensionsArgs(oldMcfg, newMcfg))
+
+	oldMcfg.Spec.Extensions = []string{"usbguard"}
+	newMcfg.Spec.Extensions = []string{"usbguard", "kernel-devel"}
+	assert.Equal(t, []string{"--install", "kernel-devel", "--install", "kernel-headers"}, generateExtensionsArgs(oldMcfg, newMcfg))
+	assert.Equal(t, []string{"--uninstall", "kernel-devel", "--uninstall", "kernel-headers"}, generateExtensionsArgs(newMcfg, oldMcfg))
+
+	diff, err := NewMachineConfigDiff(oldMcfg, newMcfg)
+	require.Nil(t, err)
+	assert.True(t, diff.extensions)
+}
+
+func TestIsStagedUpdate(t *testing.T) {
+	dn := &Daemon{node: &corev1.Node{}}
+	assert.False(t, dn.isStagedUpdate())
+
+	dn.node.Annotations = map[string]string{constants.StagedUpdateAnnotationKey: "true"}
+	assert.True(t, dn.isStagedUpdate())
+
+	// an administrator asked to apply it
+	dn.node.Annotations[constants.ApplyStagedUpdateAnnotationKey] = "true"
+	assert.False(t, dn.isStagedUpdate())
+
+	dn.node.Annotations = map[string]string{
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+		constants.MachineConfigDaemonPhaseAnnotationKey: constants.MachineConfigDaemonPhaseStaged,
+	}
+	assert.True(t, isStaged(dn.node))
+	dn.node.Annotations[constants.MachineConfigDaemonStateAnnotationKey] = constants.MachineConfigDaemonStateDone
+	assert.False(t, isStaged(dn.node))
+}
+
+func TestGetRebootlessActions(t *testing.T) {
+	mode := 0644
+	registries := igntypes.File{Node: igntypes.Node{Path: "/etc/containers/registries.conf", Filesystem: "root"},
+		FileEmbedded1: igntypes.FileEmbedded1{Contents: igntypes.FileContents{Source: "data:,registries"}, Mode: &mode}}
+	oldConfig := newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0)})
+
+	// no changes
+	actions, drain, rebootless, err := getRebootlessActions(oldConfig, newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0)}), nil)
+	assert.Nil(t, err)
+	assert.True(t, rebootless)
+	assert.False(t, drain)
+	assert.Empty(t, actions)
+
+	// registries.conf only needs crio to be reloaded
+	actions, _, rebootless, err = getRebootlessActions(oldConfig, newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0), registries}), nil)
+	assert.Nil(t, err)
+	assert.True(t, rebootless)
+	assert.Equal(t, []serviceAction{{verb: "reload", unit: "crio.service"}}, actions)
+
+	// so does the additional trust bundle, once the system trust store is regenerated
+	trustBundle := igntypes.File{Node: igntypes.Node{Path: "/etc/pki/ca-trust/source/anchors/openshift-config-user-ca-bundle.crt", Filesystem: "root"},
+		FileEmbedded1: igntypes.FileEmbedded1{Contents: igntypes.FileContents{Source: "data:,bundle"}, Mode: &mode}}
+	actions, _, rebootless, err = getRebootlessActions(oldConfig, newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0), trustBundle}), nil)
+	assert.Nil(t, err)
+	assert.True(t, rebootless)
+	assert.Equal(t, []serviceAction{{command: "update-ca-trust"}, {verb: "reload", unit: "crio.service"}}, actions)
+
+	// the cloud provider config only needs the kubelet to be restarted
+	cloudConf := igntypes.File{Node: igntypes.Node{Path: "/etc/kubernetes/cloud.conf", Filesystem: "root"},
+		FileEmbedded1: igntypes.FileEmbedded1{Contents: igntypes.FileContents{Source: "data:,cloud"}, Mode: &mode}}
+	actions, drain, rebootless, err = getRebootlessActions(oldConfig, newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0), cloudConf}), nil)
+	assert.Nil(t, err)
+	assert.True(t, rebootless)
+	assert.False(t, drain)
+	assert.Equal(t, []serviceAction{{verb: "restart", unit: "kubelet.service"}}, actions)
+
+	// any other file needs a reboot
+	_, _, rebootless, err = getRebootlessActions(oldConfig, newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(1), registries}), nil)
+	assert.Nil(t, err)
+	assert.False(t, rebootless)
+
+	// so does an OS update
+	newConfig := newMachineConfigFromFiles([]igntypes.File{newTestIgnitionFile(0)})
+	newConfig.Spec.OSImageURL = "quay.io/openshift/os@sha256:new"
+	_, _, rebootless, err = getRebootlessActions(oldConfig, newConfig, nil)
+	assert.Nil(t, err)
+	assert.False(t, rebootless)
+
+	// ssh keys are picked up without any action
+	newIgnCfg := ctrlcommon.NewIgnConfig()
+	newIgnCfg.Storage.Files = []igntypes.File{newTestIgnitionFile(0)}
+	newIgnCfg.Passwd.Users = []igntypes.PasswdUser{{Name: "core", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"1234"}}}
+	actions, _, rebootless, err = getRebootlessActions(oldConfig, helpers.CreateMachineConfigFromIgnition(newIgnCfg), nil)
+	assert.Nil(t, err)
+	assert.True(t, rebootless)
+	assert.Empty(t, actions)
+}
+
+func TestGetRebootlessActionsWithPolicy(t *testing.T) {
+	oldIgnCfg := ctrlcommon.NewIgnConfig()
+	oldIgnCfg.Storage.Files = []igntypes.File{newTestIgnitionFile(0)}
+	oldConfig := helpers.CreateMachineConfigFromIgnition(oldIgnCfg)
+
+	changed := newTestIgnitionFile(0)
+	changed.Contents.Source = "data:,changed"
+	newIgnCfg := ctrlcommon.NewIgnConfig()
+	newIgnCfg.Storage.Files = []igntypes.File{changed}
+	newIgnCfg.Systemd.Units = []igntypes.Unit{{Name: "chronyd.service", Contents: "[Service]\n"}}
+	newConfig := helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	path := changed.Path
+
+	// without a policy, files and units changes need a reboot
+	_, _, rebootless, err := getRebootlessActions(oldConfig, newConfig, nil)
+	assert.Nil(t, err)
+	assert.False(t, rebootless)
+
+	policy := &mcfgv1.NodeDisruptionPolicy{
+		Files: []mcfgv1.NodeDisruptionPolicyFile{{Path: path, Actions: []mcfgv1.NodeDisruptionAction{
+			{Type: mcfgv1.NodeDisruptionActionDrain},
+			{Type: mcfgv1.NodeDisruptionActionRestart, Unit: "crio.service"},
+		}}},
+		Units: []mcfgv1.NodeDisruptionPolicyUnit{{Name: "chronyd.service", Actions: []mcfgv1.NodeDisruptionAction{
+			{Type: mcfgv1.NodeDisruptionActionRestart},
+		}}},
+	}
+	actions, drain, rebootless, err := getRebootlessActions(oldConfig, newConfig, policy)
+	assert.Nil(t, err)
+	assert.True(t, rebootless)
+	assert.True(t, drain)
+	assert.Equal(t, []serviceAction{{verb: "restart", unit: "crio.service"}, {verb: "daemon-reload"}, {verb: "restart", unit: "chronyd.service"}}, actions)
+
+	// units missing from the policy still need a reboot
+	policy.Units = nil
+	_, _, rebootless, err = getRebootlessActions(oldConfig, newConfig, policy)
+	assert.Nil(t, err)
+	assert.False(t, rebootless)
+
+	// and so do entries asking for one
+	policy.Units = []mcfgv1.NodeDisruptionPolicyUnit{{Name: "chronyd.service", Actions: []mcfgv1.NodeDisruptionAction{
+		{Type: mcfgv1.NodeDisruptionActionReboot},
+	}}}
+	_, _, rebootless, err = getRebootlessActions(oldConfig, newConfig, policy)
+	assert.Nil(t, err)
+	assert.False(t, rebootless)
+}
+
+func TestGetNodeDisruptionPolicy(t *testing.T) {
+	node := &corev1.Node{}
+	assert.Nil(t, getNodeDisruptionPolicy(node))
+
+	node.Annotations = map[string]string{constants.NodeDisruptionPolicyAnnotationKey: "not json"}
+	assert.Nil(t, getNodeDisruptionPolicy(node))
+
+	node.Annotations[constants.NodeDisruptionPolicyAnnotationKey] = `{"units":[{"name":"chronyd.service","actions":[{"type":"Restart"}]}]}`
+	assert.Equal(t, &mcfgv1.NodeDisruptionPolicy{Units: []mcfgv1.NodeDisruptionPolicyUnit{{Name: "chronyd.service", Actions: []mcfgv1.NodeDisruptionAction{{Type: mcfgv1.NodeDisruptionActionRestart}}}}}, getNodeDisruptionPolicy(node))
+}
+
+func TestGetDrainPolicy(t *testing.T) {
+	node := &corev1.Node{}
+	assert.Equal(t, drainPolicy{timeout: 20 * time.Second, attempts: 5, backoff: 10 * time.Second}, getDrainPolicy(node))
+
+	node.Annotations = map[string]string{constants.DrainPolicyAnnotationKey: `{"timeout":"1m","skipWaitForDeleteTimeout":"5m","forceAfterAttempts":3}`}
+	assert.Equal(t, drainPolicy{
+		timeout:                  time.Minute,
+		attempts:                 5,
+		backoff:                  10 * time.Second,
+		skipWaitForDeleteTimeout: 5 * time.Minute,
+		forceAfterAttempts:       3,
+	}, getDrainPolicy(node))
+
+	// an invalid policy doesn't block the drain
+	node.Annotations[constants.DrainPolicyAnnotationKey] = "{"
+	assert.Equal(t, drainPolicy{timeout: 20 * time.Second, attempts: 5, backoff: 10 * time.Second}, getDrainPolicy(node))
+}
+
 func TestReconcilableSSH(t *testing.T) {
 	// Check that updating SSH Key of user core supported
 	oldIgnCfg := ctrlcommon.NewIgnConfig()
@@ -353,13 +564,19 @@ func TestReconcilableSSH(t *testing.T) {
 	_, errMsg = Reconcilable(oldMcfg, newMcfg)
 	checkIrreconcilableResults(t, "SSH", errMsg)
 
-	// check that we cannot add a user or have len(Passwd.Users)> 1
+	// check that we cannot change the other fields of an added user
 	tempUser5 := igntypes.PasswdUser{Name: "some user", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"5678"}}
 	newIgnCfg.Passwd.Users = append(newIgnCfg.Passwd.Users, tempUser5)
 	newMcfg = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
 	_, errMsg = Reconcilable(oldMcfg, newMcfg)
 	checkIrreconcilableResults(t, "SSH", errMsg)
 
+	// check that the keys of several users can be set
+	newIgnCfg.Passwd.Users[0] = igntypes.PasswdUser{Name: "core", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"5678"}}
+	newMcfg = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	_, errMsg = Reconcilable(oldMcfg, newMcfg)
+	checkReconcilableResults(t, "SSH", errMsg)
+
 	// check that user is not attempting to remove the only sshkey from core user
 	tempUser6 := igntypes.PasswdUser{Name: "core", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{}}
 	newIgnCfg.Passwd.Users[0] = tempUser6
@@ -368,13 +585,34 @@ func TestReconcilableSSH(t *testing.T) {
 	_, errMsg = Reconcilable(oldMcfg, newMcfg)
 	checkIrreconcilableResults(t, "SSH", errMsg)
 
-	//check that empty Users does not generate error/degrade node
+	// check that the last key of core can't be removed by dropping the users either
 	newIgnCfg.Passwd.Users = nil
 	newMcfg = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
 	_, errMsg = Reconcilable(oldMcfg, newMcfg)
+	checkIrreconcilableResults(t, "SSH", errMsg)
+
+	// check that other users can be removed
+	oldIgnCfg.Passwd.Users = append(oldIgnCfg.Passwd.Users, tempUser5)
+	oldMcfg = helpers.CreateMachineConfigFromIgnition(oldIgnCfg)
+	newIgnCfg.Passwd.Users = []igntypes.PasswdUser{tempUser2}
+	newMcfg = helpers.CreateMachineConfigFromIgnition(newIgnCfg)
+	_, errMsg = Reconcilable(oldMcfg, newMcfg)
 	checkReconcilableResults(t, "SSH", errMsg)
 }
 
+func TestSSHKeysByUser(t *testing.T) {
+	users := []igntypes.PasswdUser{
+		{Name: "core", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"1234", " "}},
+		{Name: "admin", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"5678\n"}},
+		{Name: "empty"},
+	}
+	assert.Equal(t, map[string][]string{
+		"core":  {"1234"},
+		"admin": {"5678"},
+		"empty": nil,
+	}, sshKeysByUser(users))
+}
+
 func TestUpdateSSHKeys(t *testing.T) {
 	// expectedError is the error we will use when expecting an error to return
 	expectedError := fmt.Errorf("broken")
@@ -401,7 +639,7 @@ func TestUpdateSSHKeys(t *testing.T) {
 	tempUser := igntypes.PasswdUser{Name: "core", SSHAuthorizedKeys: []igntypes.SSHAuthorizedKey{"1234", "4567"}}
 	newIgnCfg := ctrlcommon.NewIgnConfig()
 	newIgnCfg.Passwd.Users = []igntypes.PasswdUser{tempUser}
-	err := d.updateSSHKeys(newIgnCfg.Passwd.Users)
+	err := d.updateSSHKeys(nil, newIgnCfg.Passwd.Users)
 	if err != nil {
 		t.Errorf("Expected no error. Got %s.", err)
 
@@ -410,7 +648,7 @@ func TestUpdateSSHKeys(t *testing.T) {
 	// if Users is empty, nothing should happen and no error should ever be generated
 	newIgnCfg2 := ctrlcommon.NewIgnConfig()
 	newIgnCfg2.Passwd.Users = []igntypes.PasswdUser{}
-	err = d.updateSSHKeys(newIgnCfg2.Passwd.Users)
+	err = d.updateSSHKeys(nil, newIgnCfg2.Passwd.Users)
 	if err != nil {
 		t.Errorf("Expected no error. Got: %s", err)
 	}
@@ -455,3 +693,28 @@ func checkIrreconcilableResults(t *testing.T, key string, reconcilableError erro
 		t.Errorf("Different %s values should not be reconcilable.", key)
 	}
 }
+
+func TestPrePullOSImage(t *testing.T) {
+	booted := "quay.io/openshift/os@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	config := newMachineConfigFromFiles(nil)
+	config.Spec.OSImageURL = booted
+	dn := &Daemon{
+		OperatingSystem:   machineConfigDaemonOSRHCOS,
+		bootedOSImageURL:  booted,
+		NodeUpdaterClient: RpmOstreeClientMock{PullImageError: fmt.Errorf("manifest unknown")},
+	}
+
+	// nothing to pull when the OS doesn't change
+	assert.Nil(t, dn.prePullOSImage(config))
+
+	config.Spec.OSImageURL = "quay.io/openshift/os@sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	assert.EqualError(t, dn.prePullOSImage(config), "failed to pull the OS image: manifest unknown")
+
+	dn.NodeUpdaterClient = RpmOstreeClientMock{}
+	assert.Nil(t, dn.prePullOSImage(config))
+
+	// non-CoreOS hosts are updated by their package updater
+	dn.OperatingSystem = machineConfigDaemonOSRHEL
+	dn.NodeUpdaterClient = RpmOstreeClientMock{PullImageError: fmt.Errorf("manifest unknown")}
+	assert.Nil(t, dn.prePullOSImage(config))
+}
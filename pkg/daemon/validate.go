@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"sort"
+	"strings"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/pkg/errors"
+)
+
+// ValidationReport is the machine readable result of checking the node
+// against a rendered MachineConfig, printed by `machine-config-daemon validate`.
+type ValidationReport struct {
+	// Config is the name of the MachineConfig the node was checked against.
+	Config string `json:"config"`
+	// Converged is true when all the checks matched.
+	Converged bool `json:"converged"`
+	// OS is the check of the booted OS image against the osImageURL.
+	OS OSValidationResult `json:"os"`
+	// Files are the checks of the files, sorted by path.
+	Files []ValidationResult `json:"files"`
+	// Units are the checks of the systemd units and their dropins, sorted by name.
+	Units []ValidationResult `json:"units"`
+}
+
+// ValidationResult is the check of a file or unit.
+type ValidationResult struct {
+	Name  string `json:"name"`
+	Match bool   `json:"match"`
+	// Reasons describe the mismatches.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// OSValidationResult is the check of the booted OS image.
+type OSValidationResult struct {
+	Expected string   `json:"expected"`
+	Booted   string   `json:"booted"`
+	Match    bool     `json:"match"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// ValidateConfig checks the booted OS, and the files and units on disk, against
+// config and reports the matches and mismatches. Unlike the drift detection, it
+// doesn't stop at the OS mismatch.
+func (dn *Daemon) ValidateConfig(config *mcfgv1.MachineConfig) (*ValidationReport, error) {
+	ignConfig, err := ctrlcommon.ParseAndConvertConfig(config.Spec.Config.Raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing Ignition for validation")
+	}
+
+	report := &ValidationReport{
+		Config: config.GetName(),
+		OS: OSValidationResult{
+			Expected: config.Spec.OSImageURL,
+			Booted:   dn.bootedOSImageURL,
+		},
+	}
+	report.OS.Match, err = dn.checkOS(config.Spec.OSImageURL)
+	if err != nil {
+		report.OS.Reasons = []string{err.Error()}
+	}
+
+	for _, f := range lastFilesByPath(dn.managedFiles(ignConfig.Storage.Files)) {
+		report.Files = append(report.Files, newValidationResult(f.Path, []error{checkFile(f)}))
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Name < report.Files[j].Name })
+	for _, u := range ignConfig.Systemd.Units {
+		report.Units = append(report.Units, newValidationResult(u.Name, checkUnit(u)))
+	}
+	sort.Slice(report.Units, func(i, j int) bool { return report.Units[i].Name < report.Units[j].Name })
+
+	report.Converged = report.OS.Match
+	for _, results := range [][]ValidationResult{report.Files, report.Units} {
+		for _, r := range results {
+			report.Converged = report.Converged && r.Match
+		}
+	}
+	return report, nil
+}
+
+func newValidationResult(name string, errs []error) ValidationResult {
+	result := ValidationResult{Name: name, Match: true}
+	for _, err := range errs {
+		if err != nil {
+			result.Match = false
+			result.Reasons = append(result.Reasons, strings.TrimSpace(err.Error()))
+		}
+	}
+	return result
+}
+
+// ValidateCurrentConfig checks the node against the current config the daemon
+// stored on disk.
+func (dn *Daemon) ValidateCurrentConfig() (*ValidationReport, error) {
+	config, err := dn.getCurrentConfigOnDisk()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the current config on disk")
+	}
+	return dn.ValidateConfig(config)
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func TestValidateConfig(t *testing.T) {
+	// Ignition only takes absolute paths
+	present, err := filepath.Abs("fixtures/test1.txt")
+	require.Nil(t, err)
+	missing, err := filepath.Abs("fixtures/missing.txt")
+	require.Nil(t, err)
+	fi, err := os.Lstat(present)
+	require.Nil(t, err)
+	fileMode := int(fi.Mode().Perm())
+	newFile := func(path, contents string) igntypes.File {
+		return igntypes.File{
+			Node: igntypes.Node{Path: path, Filesystem: "root"},
+			FileEmbedded1: igntypes.FileEmbedded1{
+				Contents: igntypes.FileContents{Source: dataurl.EncodeBytes([]byte(contents))},
+				Mode:     &fileMode,
+			},
+		}
+	}
+
+	ignCfg := ctrlcommon.NewIgnConfig()
+	ignCfg.Storage.Files = []igntypes.File{
+		newFile(present, "hello\n"),
+		newFile(present, "hello world\n"),
+		newFile(missing, "hello\n"),
+	}
+	mc := helpers.CreateMachineConfigFromIgnition(ignCfg)
+	mc.Name = "rendered-worker-1"
+
+	dn := &Daemon{OperatingSystem: "mock"}
+	report, err := dn.ValidateConfig(mc)
+	require.Nil(t, err)
+	assert.Equal(t, "rendered-worker-1", report.Config)
+	assert.False(t, report.Converged)
+	assert.True(t, report.OS.Match)
+	require.Len(t, report.Files, 2)
+	assert.Equal(t, missing, report.Files[0].Name)
+	assert.False(t, report.Files[0].Match)
+	assert.Len(t, report.Files[0].Reasons, 1)
+	// the last file with a path is the one checked
+	assert.Equal(t, ValidationResult{Name: present, Match: true}, report.Files[1])
+
+	ignCfg.Storage.Files = ignCfg.Storage.Files[:2]
+	report, err = dn.ValidateConfig(helpers.CreateMachineConfigFromIgnition(ignCfg))
+	require.Nil(t, err)
+	assert.True(t, report.Converged)
+}
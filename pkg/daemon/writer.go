@@ -1,14 +1,25 @@
 package daemon
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/golang/glog"
-	"github.com/openshift/machine-config-operator/internal"
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfgclientv1 "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/typed/machineconfiguration.openshift.io/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
 )
 
 const (
@@ -21,7 +32,18 @@ const (
 	machineConfigDaemonSSHAccessValue = "accessed"
 )
 
-// message wraps a client and responseChannel
+// nodeWriteBackoff is how the annotation patches of the node are retried. The
+// jitter spreads the retries of the daemons updating at the same time.
+var nodeWriteBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 50 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   1.0,
+}
+
+// message wraps a client and responseChannel. Messages without a responseChannel
+// are deferred: their annotations are written along with the next message for
+// the node.
 type message struct {
 	client          corev1client.NodeInterface
 	lister          corev1lister.NodeLister
@@ -33,6 +55,9 @@ type message struct {
 // clusterNodeWriter is a single writer to Kubernetes to prevent race conditions
 type clusterNodeWriter struct {
 	writer chan message
+	// states reports the state of the node in its NodeMachineConfigState, nil
+	// when it isn't reported there.
+	states mcfgclientv1.NodeMachineConfigStateInterface
 }
 
 // NodeWriter is the interface to implement a single writer to Kubernetes to prevent race conditions
@@ -40,27 +65,88 @@ type NodeWriter interface {
 	Run(stop <-chan struct{})
 	SetDone(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, dcAnnotation string) error
 	SetWorking(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
+	SetPhase(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, phase string) error
+	SetFirstbootPhase(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, phase string, at time.Time) error
+	SetUpdateStrategy(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, strategy string) error
+	SetUpdateFailure(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, failure string) error
+	SetHealthCheckRollback(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, rollback string) error
 	SetUnreconcilable(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetDegraded(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 	SetSSHAccessed(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error
 }
 
 // newNodeWriter Create a new NodeWriter
-func newNodeWriter() NodeWriter {
+func newNodeWriter(states mcfgclientv1.NodeMachineConfigStateInterface) NodeWriter {
 	return &clusterNodeWriter{
 		writer: make(chan message, defaultWriterQueue),
+		states: states,
 	}
 }
 
 // Run reads from the writer channel and sets the node annotation. It will
 // return if the stop channel is closed. Intended to be run via a goroutine.
+// The messages queued while a patch is sent are written together by the next
+// one, and deferred messages wait for the next message of their node.
 func (nw *clusterNodeWriter) Run(stop <-chan struct{}) {
+	pending := map[string]map[string]string{}
 	for {
 		select {
 		case <-stop:
 			return
 		case msg := <-nw.writer:
-			_, err := setNodeAnnotations(msg.client, msg.lister, msg.node, msg.annos)
+			batch := []message{msg}
+		drain:
+			for {
+				select {
+				case next := <-nw.writer:
+					batch = append(batch, next)
+				default:
+					break drain
+				}
+			}
+			writeBatch(nw.states, batch, pending)
+		}
+	}
+}
+
+// writeBatch sends one patch per node of batch, with the annotations of its
+// messages in order, and answers each message with the result of its patch.
+// The NodeMachineConfigState of the node is updated first, the annotations are
+// kept for the components still reading them.
+func writeBatch(states mcfgclientv1.NodeMachineConfigStateInterface, batch []message, pending map[string]map[string]string) {
+	var nodes []string
+	byNode := map[string][]message{}
+	for _, msg := range batch {
+		if _, ok := byNode[msg.node]; !ok {
+			nodes = append(nodes, msg.node)
+		}
+		byNode[msg.node] = append(byNode[msg.node], msg)
+	}
+	for _, node := range nodes {
+		msgs := byNode[node]
+		annos := pending[node]
+		if annos == nil {
+			annos = map[string]string{}
+		}
+		var waiting []message
+		for _, msg := range msgs {
+			for k, v := range msg.annos {
+				annos[k] = v
+			}
+			if msg.responseChannel != nil {
+				waiting = append(waiting, msg)
+			}
+		}
+		if len(waiting) == 0 {
+			pending[node] = annos
+			continue
+		}
+		delete(pending, node)
+		err := setNodeState(states, waiting[0].lister, node, annos)
+		if err == nil {
+			_, err = setNodeAnnotations(waiting[0].client, waiting[0].lister, node, annos)
+		}
+		for _, msg := range waiting {
 			msg.responseChannel <- err
 		}
 	}
@@ -73,6 +159,9 @@ func (nw *clusterNodeWriter) SetDone(client corev1client.NodeInterface, lister c
 		constants.CurrentMachineConfigAnnotationKey:     dcAnnotation,
 		// clear out any Degraded/Unreconcilable reason
 		constants.MachineConfigDaemonReasonAnnotationKey: "",
+		constants.MachineConfigDaemonPhaseAnnotationKey:  "",
+		constants.ApplyStagedUpdateAnnotationKey:         "",
+		constants.UpdateFailureAnnotationKey:             "",
 	}
 	MCDState.WithLabelValues(constants.MachineConfigDaemonStateDone, "").SetToCurrentTime()
 	respChan := make(chan error, 1)
@@ -90,6 +179,7 @@ func (nw *clusterNodeWriter) SetDone(client corev1client.NodeInterface, lister c
 func (nw *clusterNodeWriter) SetWorking(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
 	annos := map[string]string{
 		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+		constants.MachineConfigDaemonPhaseAnnotationKey: "",
 	}
 	MCDState.WithLabelValues(constants.MachineConfigDaemonStateWorking, "").SetToCurrentTime()
 	respChan := make(chan error, 1)
@@ -103,6 +193,88 @@ func (nw *clusterNodeWriter) SetWorking(client corev1client.NodeInterface, liste
 	return <-respChan
 }
 
+// SetPhase reports the step of the update the daemon is in while Working.
+func (nw *clusterNodeWriter) SetPhase(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, phase string) error {
+	annos := map[string]string{
+		constants.MachineConfigDaemonPhaseAnnotationKey: phase,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetFirstbootPhase reports the step of the firstboot pivot the node is in.
+func (nw *clusterNodeWriter) SetFirstbootPhase(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, phase string, at time.Time) error {
+	annos := map[string]string{
+		constants.FirstbootPhaseAnnotationKey:    phase,
+		constants.FirstbootProgressAnnotationKey: at.UTC().Format(time.RFC3339),
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetUpdateStrategy records how the daemon applied the last update. As the
+// daemon always reports the next phase or state right after, it is deferred and
+// written with that change rather than on its own.
+func (nw *clusterNodeWriter) SetUpdateStrategy(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, strategy string) error {
+	annos := map[string]string{
+		constants.UpdateStrategyAnnotationKey: strategy,
+	}
+	nw.writer <- message{
+		client: client,
+		lister: lister,
+		node:   node,
+		annos:  annos,
+	}
+	return nil
+}
+
+// SetUpdateFailure records the JSON encoded details of the last update failure.
+func (nw *clusterNodeWriter) SetUpdateFailure(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, failure string) error {
+	annos := map[string]string{
+		constants.UpdateFailureAnnotationKey: failure,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
+// SetHealthCheckRollback records the JSON encoded details of the last update
+// rolled back after failing its post-update health checks.
+func (nw *clusterNodeWriter) SetHealthCheckRollback(client corev1client.NodeInterface, lister corev1lister.NodeLister, node string, rollback string) error {
+	annos := map[string]string{
+		constants.HealthCheckRollbackAnnotationKey: rollback,
+	}
+	respChan := make(chan error, 1)
+	nw.writer <- message{
+		client:          client,
+		lister:          lister,
+		node:            node,
+		annos:           annos,
+		responseChannel: respChan,
+	}
+	return <-respChan
+}
+
 // SetUnreconcilable sets the state to Unreconcilable.
 func (nw *clusterNodeWriter) SetUnreconcilable(err error, client corev1client.NodeInterface, lister corev1lister.NodeLister, node string) error {
 	glog.Errorf("Marking Unreconcilable due to: %v", err)
@@ -173,11 +345,112 @@ func (nw *clusterNodeWriter) SetSSHAccessed(client corev1client.NodeInterface, l
 	return <-respChan
 }
 
+// setNodeAnnotations sets the annotations m on the node with a merge patch of
+// only these annotations, so that the other changes to the node can't make it
+// conflict. It's skipped when the cached node already has them. Conflicts and
+// throttling are retried with a jittered backoff.
 func setNodeAnnotations(client corev1client.NodeInterface, lister corev1lister.NodeLister, nodeName string, m map[string]string) (*corev1.Node, error) {
-	node, err := internal.UpdateNodeRetry(client, lister, nodeName, func(node *corev1.Node) {
-		for k, v := range m {
-			node.Annotations[k] = v
+	if cached, err := lister.Get(nodeName); err == nil && hasAnnotations(cached, m) {
+		return cached, nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": m},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var node *corev1.Node
+	err = retry.OnError(nodeWriteBackoff, func(err error) bool {
+		if apierrors.IsConflict(err) {
+			MCDNodeWriteConflicts.Inc()
 		}
+		return apierrors.IsConflict(err) || apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err)
+	}, func() error {
+		MCDNodeWrites.Inc()
+		var err error
+		node, err = client.Patch(context.TODO(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
 	})
-	return node, err
+	if err != nil {
+		return nil, fmt.Errorf("unable to update node %q: %v", nodeName, err)
+	}
+	return node, nil
+}
+
+// nodeStateStatus returns status updated with the annotations annos of the node,
+// merged with its current ones. The transition times are set to now when the
+// state changes.
+func nodeStateStatus(status mcfgv1.NodeMachineConfigStateStatus, current, annos map[string]string, now metav1.Time) mcfgv1.NodeMachineConfigStateStatus {
+	merged := map[string]string{}
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range annos {
+		merged[k] = v
+	}
+	oldState := status.State
+	status.CurrentConfig = merged[constants.CurrentMachineConfigAnnotationKey]
+	status.DesiredConfig = merged[constants.DesiredMachineConfigAnnotationKey]
+	status.State = merged[constants.MachineConfigDaemonStateAnnotationKey]
+	status.Phase = merged[constants.MachineConfigDaemonPhaseAnnotationKey]
+	status.LastError = merged[constants.MachineConfigDaemonReasonAnnotationKey]
+	if status.State == oldState {
+		return status
+	}
+	status.LastTransitionTime = now
+	switch status.State {
+	case constants.MachineConfigDaemonStateWorking:
+		status.UpdateStartTime = &now
+		status.UpdateCompletionTime = nil
+	case constants.MachineConfigDaemonStateDone:
+		if status.UpdateStartTime != nil {
+			status.UpdateCompletionTime = &now
+		}
+	}
+	return status
+}
+
+// setNodeState reports the annotations annos of the node in the status of its
+// NodeMachineConfigState. It's skipped when the node controller didn't create
+// it yet, the annotations are then the only report.
+func setNodeState(states mcfgclientv1.NodeMachineConfigStateInterface, lister corev1lister.NodeLister, nodeName string, annos map[string]string) error {
+	if states == nil {
+		return nil
+	}
+	var current map[string]string
+	if cached, err := lister.Get(nodeName); err == nil {
+		current = cached.Annotations
+	}
+	err := retry.OnError(nodeWriteBackoff, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err)
+	}, func() error {
+		state, err := states.Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		status := nodeStateStatus(state.Status, current, annos, metav1.Now())
+		if equality.Semantic.DeepEqual(status, state.Status) {
+			return nil
+		}
+		state.Status = status
+		_, err = states.UpdateStatus(context.TODO(), state, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		glog.V(4).Infof("NodeMachineConfigState %s/%s not found, only annotating the node", ctrlcommon.MCONamespace, nodeName)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update the NodeMachineConfigState of node %q: %v", nodeName, err)
+	}
+	return nil
+}
+
+func hasAnnotations(node *corev1.Node, m map[string]string) bool {
+	for k, v := range m {
+		if cur, ok := node.Annotations[k]; !ok || cur != v {
+			return false
+		}
+	}
+	return true
 }
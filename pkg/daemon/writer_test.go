@@ -0,0 +1,168 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/pkg/daemon/constants"
+	mcfgfake "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newWriterFixture(t *testing.T, annotations map[string]string) (*fake.Clientset, corev1lister.NodeLister) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0", Annotations: annotations}}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.Nil(t, indexer.Add(node))
+	return fake.NewSimpleClientset(node), corev1lister.NewNodeLister(indexer)
+}
+
+func counterValue(t *testing.T, c interface{ Write(*dto.Metric) error }) float64 {
+	m := &dto.Metric{}
+	require.Nil(t, c.Write(m))
+	return m.GetCounter().GetValue()
+}
+
+func patchActions(client *fake.Clientset) []core.Action {
+	var patches []core.Action
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" {
+			patches = append(patches, action)
+		}
+	}
+	return patches
+}
+
+func TestWriteBatch(t *testing.T) {
+	client, lister := newWriterFixture(t, map[string]string{})
+	nodes := client.CoreV1().Nodes()
+	pending := map[string]map[string]string{}
+
+	// the update strategy waits for the next state change
+	writeBatch(nil, []message{{client: nodes, lister: lister, node: "node-0", annos: map[string]string{constants.UpdateStrategyAnnotationKey: "Rebootless"}}}, pending)
+	assert.Empty(t, patchActions(client))
+
+	done := make(chan error, 1)
+	ssh := make(chan error, 1)
+	writeBatch(nil, []message{
+		{client: nodes, lister: lister, node: "node-0", annos: map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking}},
+		{client: nodes, lister: lister, node: "node-0", annos: map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone}, responseChannel: done},
+		{client: nodes, lister: lister, node: "node-0", annos: map[string]string{machineConfigDaemonSSHAccessAnnotationKey: machineConfigDaemonSSHAccessValue}, responseChannel: ssh},
+	}, pending)
+	assert.Nil(t, <-done)
+	assert.Nil(t, <-ssh)
+	assert.Empty(t, pending)
+
+	require.Len(t, patchActions(client), 1)
+	node, err := nodes.Get(context.TODO(), "node-0", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		constants.UpdateStrategyAnnotationKey:           "Rebootless",
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		machineConfigDaemonSSHAccessAnnotationKey:       machineConfigDaemonSSHAccessValue,
+	}, node.Annotations)
+}
+
+func TestSetNodeAnnotations(t *testing.T) {
+	client, lister := newWriterFixture(t, map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone})
+
+	// already set in the cache
+	_, err := setNodeAnnotations(client.CoreV1().Nodes(), lister, "node-0", map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone})
+	require.Nil(t, err)
+	assert.Empty(t, patchActions(client))
+
+	// conflicts are retried
+	conflicts := 0
+	client.PrependReactor("patch", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		if conflicts < 2 {
+			conflicts++
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-0", nil)
+		}
+		return false, nil, nil
+	})
+	before := counterValue(t, MCDNodeWriteConflicts)
+	node, err := setNodeAnnotations(client.CoreV1().Nodes(), lister, "node-0", map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking})
+	require.Nil(t, err)
+	assert.Equal(t, constants.MachineConfigDaemonStateWorking, node.Annotations[constants.MachineConfigDaemonStateAnnotationKey])
+	assert.Len(t, patchActions(client), 3)
+	assert.Equal(t, 2.0, counterValue(t, MCDNodeWriteConflicts)-before)
+}
+
+func TestNodeStateStatus(t *testing.T) {
+	start := metav1.NewTime(time.Unix(100, 0))
+	end := metav1.NewTime(time.Unix(200, 0))
+	current := map[string]string{
+		constants.CurrentMachineConfigAnnotationKey:     "v0",
+		constants.DesiredMachineConfigAnnotationKey:     "v1",
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+	}
+
+	status := nodeStateStatus(mcfgv1.NodeMachineConfigStateStatus{State: constants.MachineConfigDaemonStateDone}, current, map[string]string{
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+		constants.MachineConfigDaemonPhaseAnnotationKey: "Draining",
+	}, start)
+	assert.Equal(t, mcfgv1.NodeMachineConfigStateStatus{
+		CurrentConfig:      "v0",
+		DesiredConfig:      "v1",
+		State:              constants.MachineConfigDaemonStateWorking,
+		Phase:              "Draining",
+		LastTransitionTime: start,
+		UpdateStartTime:    &start,
+	}, status)
+
+	// the phase alone isn't a transition
+	status = nodeStateStatus(status, current, map[string]string{
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking,
+		constants.MachineConfigDaemonPhaseAnnotationKey: "Rebooting",
+	}, end)
+	assert.Equal(t, "Rebooting", status.Phase)
+	assert.Equal(t, start, status.LastTransitionTime)
+
+	status = nodeStateStatus(status, current, map[string]string{
+		constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateDone,
+		constants.CurrentMachineConfigAnnotationKey:     "v1",
+		constants.MachineConfigDaemonPhaseAnnotationKey: "",
+	}, end)
+	assert.Equal(t, mcfgv1.NodeMachineConfigStateStatus{
+		CurrentConfig:        "v1",
+		DesiredConfig:        "v1",
+		State:                constants.MachineConfigDaemonStateDone,
+		LastTransitionTime:   end,
+		UpdateStartTime:      &start,
+		UpdateCompletionTime: &end,
+	}, status)
+}
+
+func TestSetNodeState(t *testing.T) {
+	_, lister := newWriterFixture(t, map[string]string{constants.CurrentMachineConfigAnnotationKey: "v0"})
+	annos := map[string]string{constants.MachineConfigDaemonStateAnnotationKey: constants.MachineConfigDaemonStateWorking}
+
+	// not created by the node controller yet
+	client := mcfgfake.NewSimpleClientset()
+	states := client.MachineconfigurationV1().NodeMachineConfigStates(ctrlcommon.MCONamespace)
+	require.Nil(t, setNodeState(states, lister, "node-0", annos))
+
+	client = mcfgfake.NewSimpleClientset(&mcfgv1.NodeMachineConfigState{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0", Namespace: ctrlcommon.MCONamespace},
+	})
+	states = client.MachineconfigurationV1().NodeMachineConfigStates(ctrlcommon.MCONamespace)
+	require.Nil(t, setNodeState(states, lister, "node-0", annos))
+	state, err := states.Get(context.TODO(), "node-0", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, "v0", state.Status.CurrentConfig)
+	assert.Equal(t, constants.MachineConfigDaemonStateWorking, state.Status.State)
+	assert.NotNil(t, state.Status.UpdateStartTime)
+}
@@ -12,6 +12,10 @@ type FakeMachineconfigurationV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeMachineconfigurationV1) AcceleratorConfigs() v1.AcceleratorConfigInterface {
+	return &FakeAcceleratorConfigs{c}
+}
+
 func (c *FakeMachineconfigurationV1) ContainerRuntimeConfigs() v1.ContainerRuntimeConfigInterface {
 	return &FakeContainerRuntimeConfigs{c}
 }
@@ -20,6 +24,10 @@ func (c *FakeMachineconfigurationV1) ControllerConfigs() v1.ControllerConfigInte
 	return &FakeControllerConfigs{c}
 }
 
+func (c *FakeMachineconfigurationV1) ImagePolicies() v1.ImagePolicyInterface {
+	return &FakeImagePolicies{c}
+}
+
 func (c *FakeMachineconfigurationV1) KubeletConfigs() v1.KubeletConfigInterface {
 	return &FakeKubeletConfigs{c}
 }
@@ -32,6 +40,26 @@ func (c *FakeMachineconfigurationV1) MachineConfigPools() v1.MachineConfigPoolIn
 	return &FakeMachineConfigPools{c}
 }
 
+func (c *FakeMachineconfigurationV1) NodeMachineConfigStates(namespace string) v1.NodeMachineConfigStateInterface {
+	return &FakeNodeMachineConfigStates{c, namespace}
+}
+
+func (c *FakeMachineconfigurationV1) NodeSSHKeySets() v1.NodeSSHKeySetInterface {
+	return &FakeNodeSSHKeySets{c}
+}
+
+func (c *FakeMachineconfigurationV1) NodeTuningConfigs() v1.NodeTuningConfigInterface {
+	return &FakeNodeTuningConfigs{c}
+}
+
+func (c *FakeMachineconfigurationV1) OnPremNetworkings() v1.OnPremNetworkingInterface {
+	return &FakeOnPremNetworkings{c}
+}
+
+func (c *FakeMachineconfigurationV1) TimeSyncs() v1.TimeSyncInterface {
+	return &FakeTimeSyncs{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeMachineconfigurationV1) RESTClient() rest.Interface {
@@ -2,12 +2,26 @@
 
 package v1
 
+type AcceleratorConfigExpansion interface{}
+
 type ContainerRuntimeConfigExpansion interface{}
 
 type ControllerConfigExpansion interface{}
 
+type ImagePolicyExpansion interface{}
+
 type KubeletConfigExpansion interface{}
 
 type MachineConfigExpansion interface{}
 
 type MachineConfigPoolExpansion interface{}
+
+type NodeMachineConfigStateExpansion interface{}
+
+type NodeSSHKeySetExpansion interface{}
+
+type NodeTuningConfigExpansion interface{}
+
+type OnPremNetworkingExpansion interface{}
+
+type TimeSyncExpansion interface{}
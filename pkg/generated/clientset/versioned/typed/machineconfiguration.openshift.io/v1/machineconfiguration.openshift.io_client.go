@@ -10,11 +10,18 @@ import (
 
 type MachineconfigurationV1Interface interface {
 	RESTClient() rest.Interface
+	AcceleratorConfigsGetter
 	ContainerRuntimeConfigsGetter
 	ControllerConfigsGetter
+	ImagePoliciesGetter
 	KubeletConfigsGetter
 	MachineConfigsGetter
 	MachineConfigPoolsGetter
+	NodeMachineConfigStatesGetter
+	NodeSSHKeySetsGetter
+	NodeTuningConfigsGetter
+	OnPremNetworkingsGetter
+	TimeSyncsGetter
 }
 
 // MachineconfigurationV1Client is used to interact with features provided by the machineconfiguration.openshift.io group.
@@ -22,6 +29,10 @@ type MachineconfigurationV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *MachineconfigurationV1Client) AcceleratorConfigs() AcceleratorConfigInterface {
+	return newAcceleratorConfigs(c)
+}
+
 func (c *MachineconfigurationV1Client) ContainerRuntimeConfigs() ContainerRuntimeConfigInterface {
 	return newContainerRuntimeConfigs(c)
 }
@@ -30,6 +41,10 @@ func (c *MachineconfigurationV1Client) ControllerConfigs() ControllerConfigInter
 	return newControllerConfigs(c)
 }
 
+func (c *MachineconfigurationV1Client) ImagePolicies() ImagePolicyInterface {
+	return newImagePolicies(c)
+}
+
 func (c *MachineconfigurationV1Client) KubeletConfigs() KubeletConfigInterface {
 	return newKubeletConfigs(c)
 }
@@ -42,6 +57,26 @@ func (c *MachineconfigurationV1Client) MachineConfigPools() MachineConfigPoolInt
 	return newMachineConfigPools(c)
 }
 
+func (c *MachineconfigurationV1Client) NodeMachineConfigStates(namespace string) NodeMachineConfigStateInterface {
+	return newNodeMachineConfigStates(c, namespace)
+}
+
+func (c *MachineconfigurationV1Client) NodeSSHKeySets() NodeSSHKeySetInterface {
+	return newNodeSSHKeySets(c)
+}
+
+func (c *MachineconfigurationV1Client) NodeTuningConfigs() NodeTuningConfigInterface {
+	return newNodeTuningConfigs(c)
+}
+
+func (c *MachineconfigurationV1Client) OnPremNetworkings() OnPremNetworkingInterface {
+	return newOnPremNetworkings(c)
+}
+
+func (c *MachineconfigurationV1Client) TimeSyncs() TimeSyncInterface {
+	return newTimeSyncs(c)
+}
+
 // NewForConfig creates a new MachineconfigurationV1Client for the given config.
 func NewForConfig(c *rest.Config) (*MachineconfigurationV1Client, error) {
 	config := *c
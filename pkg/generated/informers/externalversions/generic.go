@@ -37,16 +37,30 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=machineconfiguration.openshift.io, Version=v1
+	case v1.SchemeGroupVersion.WithResource("acceleratorconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().AcceleratorConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("containerruntimeconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().ContainerRuntimeConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("controllerconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().ControllerConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("imagepolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().ImagePolicies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("kubeletconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().KubeletConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("machineconfigpools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().MachineConfigPools().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("nodemachineconfigstates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().NodeMachineConfigStates().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("nodesshkeysets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().NodeSSHKeySets().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("nodetuningconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().NodeTuningConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("onpremnetworkings"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().OnPremNetworkings().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("timesyncs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Machineconfiguration().V1().TimeSyncs().Informer()}, nil
 
 	}
 
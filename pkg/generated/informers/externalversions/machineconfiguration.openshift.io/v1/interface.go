@@ -8,16 +8,30 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AcceleratorConfigs returns a AcceleratorConfigInformer.
+	AcceleratorConfigs() AcceleratorConfigInformer
 	// ContainerRuntimeConfigs returns a ContainerRuntimeConfigInformer.
 	ContainerRuntimeConfigs() ContainerRuntimeConfigInformer
 	// ControllerConfigs returns a ControllerConfigInformer.
 	ControllerConfigs() ControllerConfigInformer
+	// ImagePolicies returns a ImagePolicyInformer.
+	ImagePolicies() ImagePolicyInformer
 	// KubeletConfigs returns a KubeletConfigInformer.
 	KubeletConfigs() KubeletConfigInformer
 	// MachineConfigs returns a MachineConfigInformer.
 	MachineConfigs() MachineConfigInformer
 	// MachineConfigPools returns a MachineConfigPoolInformer.
 	MachineConfigPools() MachineConfigPoolInformer
+	// NodeMachineConfigStates returns a NodeMachineConfigStateInformer.
+	NodeMachineConfigStates() NodeMachineConfigStateInformer
+	// NodeSSHKeySets returns a NodeSSHKeySetInformer.
+	NodeSSHKeySets() NodeSSHKeySetInformer
+	// NodeTuningConfigs returns a NodeTuningConfigInformer.
+	NodeTuningConfigs() NodeTuningConfigInformer
+	// OnPremNetworkings returns a OnPremNetworkingInformer.
+	OnPremNetworkings() OnPremNetworkingInformer
+	// TimeSyncs returns a TimeSyncInformer.
+	TimeSyncs() TimeSyncInformer
 }
 
 type version struct {
@@ -31,6 +45,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AcceleratorConfigs returns a AcceleratorConfigInformer.
+func (v *version) AcceleratorConfigs() AcceleratorConfigInformer {
+	return &acceleratorConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ContainerRuntimeConfigs returns a ContainerRuntimeConfigInformer.
 func (v *version) ContainerRuntimeConfigs() ContainerRuntimeConfigInformer {
 	return &containerRuntimeConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -41,6 +60,11 @@ func (v *version) ControllerConfigs() ControllerConfigInformer {
 	return &controllerConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// ImagePolicies returns a ImagePolicyInformer.
+func (v *version) ImagePolicies() ImagePolicyInformer {
+	return &imagePolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // KubeletConfigs returns a KubeletConfigInformer.
 func (v *version) KubeletConfigs() KubeletConfigInformer {
 	return &kubeletConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -55,3 +79,28 @@ func (v *version) MachineConfigs() MachineConfigInformer {
 func (v *version) MachineConfigPools() MachineConfigPoolInformer {
 	return &machineConfigPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// NodeMachineConfigStates returns a NodeMachineConfigStateInformer.
+func (v *version) NodeMachineConfigStates() NodeMachineConfigStateInformer {
+	return &nodeMachineConfigStateInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// NodeSSHKeySets returns a NodeSSHKeySetInformer.
+func (v *version) NodeSSHKeySets() NodeSSHKeySetInformer {
+	return &nodeSSHKeySetInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// NodeTuningConfigs returns a NodeTuningConfigInformer.
+func (v *version) NodeTuningConfigs() NodeTuningConfigInformer {
+	return &nodeTuningConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// OnPremNetworkings returns a OnPremNetworkingInformer.
+func (v *version) OnPremNetworkings() OnPremNetworkingInformer {
+	return &onPremNetworkingInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// TimeSyncs returns a TimeSyncInformer.
+func (v *version) TimeSyncs() TimeSyncInformer {
+	return &timeSyncInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -2,6 +2,10 @@
 
 package v1
 
+// AcceleratorConfigListerExpansion allows custom methods to be added to
+// AcceleratorConfigLister.
+type AcceleratorConfigListerExpansion interface{}
+
 // ContainerRuntimeConfigListerExpansion allows custom methods to be added to
 // ContainerRuntimeConfigLister.
 type ContainerRuntimeConfigListerExpansion interface{}
@@ -10,6 +14,10 @@ type ContainerRuntimeConfigListerExpansion interface{}
 // ControllerConfigLister.
 type ControllerConfigListerExpansion interface{}
 
+// ImagePolicyListerExpansion allows custom methods to be added to
+// ImagePolicyLister.
+type ImagePolicyListerExpansion interface{}
+
 // KubeletConfigListerExpansion allows custom methods to be added to
 // KubeletConfigLister.
 type KubeletConfigListerExpansion interface{}
@@ -21,3 +29,27 @@ type MachineConfigListerExpansion interface{}
 // MachineConfigPoolListerExpansion allows custom methods to be added to
 // MachineConfigPoolLister.
 type MachineConfigPoolListerExpansion interface{}
+
+// NodeMachineConfigStateListerExpansion allows custom methods to be added to
+// NodeMachineConfigStateLister.
+type NodeMachineConfigStateListerExpansion interface{}
+
+// NodeMachineConfigStateNamespaceListerExpansion allows custom methods to be added to
+// NodeMachineConfigStateNamespaceLister.
+type NodeMachineConfigStateNamespaceListerExpansion interface{}
+
+// NodeSSHKeySetListerExpansion allows custom methods to be added to
+// NodeSSHKeySetLister.
+type NodeSSHKeySetListerExpansion interface{}
+
+// NodeTuningConfigListerExpansion allows custom methods to be added to
+// NodeTuningConfigLister.
+type NodeTuningConfigListerExpansion interface{}
+
+// OnPremNetworkingListerExpansion allows custom methods to be added to
+// OnPremNetworkingLister.
+type OnPremNetworkingListerExpansion interface{}
+
+// TimeSyncListerExpansion allows custom methods to be added to
+// TimeSyncLister.
+type TimeSyncListerExpansion interface{}
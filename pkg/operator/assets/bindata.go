@@ -1,5 +1,6 @@
 // Package assets Code generated by go-bindata. (@generated) DO NOT EDIT.
 // sources:
+// manifests/acceleratorconfig.crd.yaml
 // manifests/baremetal/coredns-corefile.tmpl
 // manifests/baremetal/coredns.yaml
 // manifests/baremetal/keepalived.conf.tmpl
@@ -7,6 +8,7 @@
 // manifests/bootstrap-pod-v2.yaml
 // manifests/containerruntimeconfig.crd.yaml
 // manifests/controllerconfig.crd.yaml
+// manifests/imagepolicy.crd.yaml
 // manifests/kubeletconfig.crd.yaml
 // manifests/machineconfig.crd.yaml
 // manifests/machineconfigcontroller/clusterrole.yaml
@@ -14,6 +16,8 @@
 // manifests/machineconfigcontroller/controllerconfig.yaml
 // manifests/machineconfigcontroller/deployment.yaml
 // manifests/machineconfigcontroller/sa.yaml
+// manifests/machineconfigcontroller/service.yaml
+// manifests/machineconfigcontroller/servicemonitor.yaml
 // manifests/machineconfigdaemon/clusterrole.yaml
 // manifests/machineconfigdaemon/clusterrolebinding.yaml
 // manifests/machineconfigdaemon/cookie-secret.yaml
@@ -33,6 +37,10 @@
 // manifests/machineconfigserver/node-bootstrapper-token.yaml
 // manifests/machineconfigserver/sa.yaml
 // manifests/master.machineconfigpool.yaml
+// manifests/nodemachineconfigstate.crd.yaml
+// manifests/nodesshkeyset.crd.yaml
+// manifests/nodetuningconfig.crd.yaml
+// manifests/onpremnetworking.crd.yaml
 // manifests/openstack/coredns-corefile.tmpl
 // manifests/openstack/coredns.yaml
 // manifests/openstack/keepalived.conf.tmpl
@@ -41,6 +49,7 @@
 // manifests/ovirt/coredns.yaml
 // manifests/ovirt/keepalived.conf.tmpl
 // manifests/ovirt/keepalived.yaml
+// manifests/timesync.crd.yaml
 // manifests/vsphere/coredns-corefile.tmpl
 // manifests/vsphere/coredns.yaml
 // manifests/vsphere/keepalived.conf.tmpl
@@ -98,6 +107,176 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
+var _manifestsAcceleratorconfigCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: acceleratorconfigs.machineconfiguration.openshift.io
+  labels:
+    "openshift.io/operator-managed": ""
+spec:
+  group: machineconfiguration.openshift.io
+  names:
+    kind: AcceleratorConfig
+    listKind: AcceleratorConfigList
+    plural: acceleratorconfigs
+    singular: acceleratorconfig
+  scope: Cluster
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: 'AcceleratorConfig describes what the machines of the selected
+        pools need for a device, e.g. a GPU, to work: kernel arguments, extensions
+        and kernel modules not to load. It lets device operators prepare the machines
+        without writing MachineConfigs which collide with each other''s.'
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: AcceleratorConfigSpec defines the desired state of AcceleratorConfig
+          type: object
+          properties:
+            blacklistedModules:
+              description: blacklistedModules are the kernel modules not to load, e.g.
+                nouveau, neither from the initramfs nor from the root filesystem.
+              type: array
+              items:
+                type: string
+            extensions:
+              description: extensions are the RHCOS extensions to install, e.g. kernel-devel.
+              type: array
+              items:
+                type: string
+            kernelArguments:
+              description: kernelArguments are the kernel arguments to add, e.g. intel_iommu=on.
+              type: array
+              items:
+                type: string
+            machineConfigPoolSelector:
+              description: A label selector is a label query over a set of resources.
+                The result of matchLabels and matchExpressions are ANDed. An empty
+                label selector matches all objects. A null label selector matches
+                no objects.
+              type: object
+              properties:
+                matchExpressions:
+                  description: matchExpressions is a list of label selector requirements.
+                    The requirements are ANDed.
+                  type: array
+                  items:
+                    description: A label selector requirement is a selector that contains
+                      values, a key, and an operator that relates the key and values.
+                    type: object
+                    required:
+                    - key
+                    - operator
+                    properties:
+                      key:
+                        description: key is the label key that the selector applies
+                          to.
+                        type: string
+                      operator:
+                        description: operator represents a key's relationship to a
+                          set of values. Valid operators are In, NotIn, Exists and
+                          DoesNotExist.
+                        type: string
+                      values:
+                        description: values is an array of string values. If the operator
+                          is In or NotIn, the values array must be non-empty. If the
+                          operator is Exists or DoesNotExist, the values array must
+                          be empty. This array is replaced during a strategic merge
+                          patch.
+                        type: array
+                        items:
+                          type: string
+                matchLabels:
+                  description: matchLabels is a map of {key,value} pairs. A single
+                    {key,value} in the matchLabels map is equivalent to an element
+                    of matchExpressions, whose key field is "key", the operator is
+                    "In", and the values array contains only "value". The requirements
+                    are ANDed.
+                  type: object
+                  additionalProperties:
+                    type: string
+        status:
+          description: AcceleratorConfigStatus defines the observed state of a
+            AcceleratorConfig
+          type: object
+          properties:
+            conditions:
+              description: conditions represents the latest available observations
+                of current state.
+              type: array
+              items:
+                description: AcceleratorConfigCondition defines the state of the
+                  AcceleratorConfig
+                type: object
+                properties:
+                  lastTransitionTime:
+                    description: lastTransitionTime is the time of the last update
+                      to the current status object.
+                    type: string
+                    format: date-time
+                    nullable: true
+                  message:
+                    description: message provides additional information about the
+                      current condition. This is only to be consumed by humans.
+                    type: string
+                  reason:
+                    description: reason is the reason for the condition's last transition.  Reasons
+                      are PascalCase
+                    type: string
+                  status:
+                    description: status of the condition, one of True, False, Unknown.
+                    type: string
+                  type:
+                    description: type specifies the state of the operator's reconciliation
+                      functionality.
+                    type: string
+            observedGeneration:
+              description: observedGeneration represents the generation observed by
+                the controller.
+              type: integer
+              format: int64
+            pools:
+              description: pools are the names of the pools whose MachineConfig
+                holds the AcceleratorConfig.
+              type: array
+              items:
+                type: string
+`)
+
+func manifestsAcceleratorconfigCrdYamlBytes() ([]byte, error) {
+	return _manifestsAcceleratorconfigCrdYaml, nil
+}
+
+func manifestsAcceleratorconfigCrdYaml() (*asset, error) {
+	bytes, err := manifestsAcceleratorconfigCrdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/acceleratorconfig.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _manifestsBaremetalCorednsCorefileTmpl = []byte(`. {
     errors
     health :18080
@@ -506,6 +685,55 @@ spec:
                 the container runtime
               type: object
               properties:
+                additionalSearchRegistries:
+                  description: 'additionalSearchRegistries are registries searched,
+                    after the ones of the cluster Image config, when pulling images
+                    with unqualified names. Deprecated: use registrySources.containerRuntimeSearchRegistries
+                    of the cluster Image config.'
+                  type: array
+                  items:
+                    type: string
+                cniConfigDir:
+                  description: cniConfigDir specifies the directory CRI-O loads the
+                    CNI network configurations from. It must be /etc/kubernetes/cni/net.d,
+                    where the cluster network operator writes the pod network configuration.
+                  type: string
+                cniPluginDirs:
+                  description: cniPluginDirs specifies the directories CRI-O looks
+                    the CNI plugins up in. They must be absolute paths and include
+                    /var/lib/cni/bin, where the cluster network operator installs
+                    its plugins.
+                  type: array
+                  items:
+                    type: string
+                journaldRateLimit:
+                  description: journaldRateLimit limits the rate of the messages journald
+                    accepts from each service, including CRI-O and the containers logging
+                    to journald.
+                  type: object
+                  required:
+                  - burst
+                  - interval
+                  properties:
+                    burst:
+                      description: burst is the number of messages accepted from a
+                        service within interval.
+                      type: integer
+                      format: int32
+                    interval:
+                      description: interval is the time interval the messages are
+                        counted over.
+                      type: string
+                logDir:
+                  description: logDir specifies the default directory of the container
+                    logs, used when the kubelet doesn't pass one. It must be an absolute
+                    path under /var/log.
+                  type: string
+                logFilter:
+                  description: logFilter is a regular expression the messages of CRI-O
+                    must match to be logged, e.g. to only keep the ones relevant to
+                    an audit.
+                  type: string
                 logLevel:
                   description: logLevel specifies the verbosity of the logs based
                     on the level it is set to. Options are fatal, panic, error, warn,
@@ -517,6 +745,10 @@ spec:
                     is imposed. If it is positive, it must be >= 8192 to match/exceed
                     conmon's read buffer.
                   type: string
+                logToJournald:
+                  description: logToJournald specifies whether the container logs are
+                    also sent to journald, besides the container log files.
+                  type: boolean
                 overlaySize:
                   description: 'overlaySize specifies the maximum size of a container
                     image. This flag can be used to set quota on the size of container
@@ -527,6 +759,24 @@ spec:
                     allowed in a container
                   type: integer
                   format: int64
+                runtime:
+                  description: runtime is the container runtime of the pool, whose
+                    unit and templates are rendered and whose endpoint the kubelet
+                    uses. (default: crio)
+                  type: string
+                  enum:
+                  - crio
+                separateLogStreams:
+                  description: separateLogStreams specifies whether the stdout and
+                    stderr of the containers are logged as separate streams, so that
+                    they can be audited apart.
+                  type: boolean
+                shortNameAliases:
+                  description: shortNameAliases maps image short names, e.g. busybox,
+                    to the fully qualified repositories they resolve to, e.g. mirror.example.com/library/busybox.
+                  type: object
+                  additionalProperties:
+                    type: string
             machineConfigPoolSelector:
               description: A label selector is a label query over a set of resources.
                 The result of matchLabels and matchExpressions are ANDed. An empty
@@ -700,6 +950,39 @@ spec:
             clusterDNSIP:
               description: clusterDNSIP is the cluster DNS IP address
               type: string
+            drainPolicy:
+              description: drainPolicy configures how the machine-config-daemon drains
+                the nodes before rebooting them. Its value is taken from the data.drainPolicy
+                field on the machine-config-operator-config ConfigMap and can be overridden
+                per pool.
+              type: object
+              properties:
+                attempts:
+                  description: attempts is the number of drain attempts before the update
+                    is failed. default is 5.
+                  type: integer
+                  format: int32
+                  minimum: 0
+                backoff:
+                  description: backoff is the wait before the second drain attempt, doubled
+                    after every failed attempt. default is 10s.
+                  type: string
+                forceAfterAttempts:
+                  description: forceAfterAttempts, when set, deletes the pods instead of
+                    evicting them once that many drain attempts failed, bypassing their
+                    PodDisruptionBudgets.
+                  type: integer
+                  format: int32
+                  minimum: 0
+                skipWaitForDeleteTimeout:
+                  description: skipWaitForDeleteTimeout, when set, skips waiting for the
+                    pods whose deletion was requested longer than this ago, e.g. pods stuck
+                    on an unreachable volume.
+                  type: string
+                timeout:
+                  description: timeout is how long a single drain attempt waits for the
+                    pods to be evicted. default is 20s.
+                  type: string
             etcdCAData:
               description: etcdCAData specifies the etcd CA data
               type: string
@@ -711,6 +994,12 @@ spec:
               description: etcdMetricData specifies the etcd metric CA data
               type: string
               format: byte
+            fips:
+              description: fips enables FIPS mode on the machines of the cluster.
+                Its value is taken from the fips field of the install-config in the
+                kube-system/cluster-config-v1 ConfigMap, as FIPS mode can only be chosen
+                at install time.
+              type: boolean
             images:
               description: images is map of images that are used by the controller
                 to render templates under ./templates/
@@ -978,6 +1267,128 @@ spec:
               description: kubeletIPv6 is true to force a single-stack IPv6 kubelet
                 config
               type: boolean
+            nodeDNS:
+              description: nodeDNS sets the DNS resolvers of the machines of the
+                cluster, overriding the ones from DHCP. Its value is taken from the
+                data.nodeDNS field on the machine-config-operator-config ConfigMap.
+              type: object
+              required:
+              - nameservers
+              properties:
+                nameservers:
+                  description: nameservers are the IP addresses of the DNS servers
+                    the machines send their queries to, at most 3.
+                  type: array
+                  maxItems: 3
+                  items:
+                    type: string
+                searches:
+                  description: searches are the search domains of the machines,
+                    at most 6.
+                  type: array
+                  maxItems: 6
+                  items:
+                    type: string
+            nodeDisruptionPolicy:
+              description: nodeDisruptionPolicy maps files and units to the actions the
+                machine-config-daemon takes to apply changes to them instead of rebooting
+                the nodes. Its value is taken from the data.nodeDisruptionPolicy field
+                on the machine-config-operator-config ConfigMap.
+              type: object
+              properties:
+                files:
+                  description: files are the actions applying changes to files, by path.
+                  type: array
+                  items:
+                    type: object
+                    required:
+                    - path
+                    - actions
+                    properties:
+                      actions:
+                        description: actions are run in order once the file is written.
+                        type: array
+                        items:
+                          type: object
+                          required:
+                          - type
+                          properties:
+                            type:
+                              description: type is one of None, Reload, Restart, Drain or
+                                Reboot.
+                              type: string
+                              enum:
+                              - None
+                              - Reload
+                              - Restart
+                              - Drain
+                              - Reboot
+                            unit:
+                              description: unit is the systemd unit to reload or restart.
+                                It's required for the Reload and Restart actions of files,
+                                and defaults to the unit itself for the actions of units.
+                              type: string
+                      path:
+                        description: path is the absolute path of the file.
+                        type: string
+                units:
+                  description: units are the actions applying changes to systemd units,
+                    by name.
+                  type: array
+                  items:
+                    type: object
+                    required:
+                    - name
+                    - actions
+                    properties:
+                      actions:
+                        description: actions are run in order once the unit is written.
+                        type: array
+                        items:
+                          type: object
+                          required:
+                          - type
+                          properties:
+                            type:
+                              description: type is one of None, Reload, Restart, Drain or
+                                Reboot.
+                              type: string
+                              enum:
+                              - None
+                              - Reload
+                              - Restart
+                              - Drain
+                              - Reboot
+                            unit:
+                              description: unit is the systemd unit to reload or restart.
+                                It's required for the Reload and Restart actions of files,
+                                and defaults to the unit itself for the actions of units.
+                              type: string
+                      name:
+                        description: name is the name of the unit, e.g. chronyd.service.
+                        type: string
+            osImageAllowList:
+              description: osImageAllowList is the signed list of the OS images MachineConfigPools
+                may run instead of osImageURL. Its value is taken from the os-image-allow-list
+                ConfigMap in openshift-config, and its keyring from the os-image-allow-list-keyring
+                ConfigMap in openshift-config-managed.
+              type: object
+              required:
+              - images
+              - keyring
+              - signature
+              properties:
+                images:
+                  description: images are the allowed OS image pullspecs, one per line.
+                  type: string
+                keyring:
+                  description: keyring is the ASCII-armored OpenPGP public keyring signature
+                    is verified with.
+                  type: string
+                signature:
+                  description: signature is the ASCII-armored detached OpenPGP signature
+                    of images.
+                  type: string
             osImageURL:
               description: osImageURL is the location of the container image that
                 contains the OS update payload. Its value is taken from the data.osImageURL
@@ -1039,10 +1450,65 @@ spec:
                 uid:
                   description: 'UID of the referent. More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#uids'
                   type: string
+            renderedConfigHistoryLimit:
+              description: renderedConfigHistoryLimit is the number of rendered MachineConfigs
+                kept per pool to roll back to. Its value is taken from the data.renderedConfigHistoryLimit
+                field on the machine-config-operator-config ConfigMap, defaults to 5.
+              type: integer
+              format: int32
             rootCAData:
               description: rootCAData specifies the root CA data
               type: string
               format: byte
+            templateOverrides:
+              description: templateOverrides replace the contents of templates rendered
+                by the template controller, keyed by their path under templates/, e.g.
+                master/00-master/_base/units/kubelet.yaml, to trial fixes without rebuilding
+                the operator image. Only the files and units templates can be overridden.
+                Its value is taken from the data.templateOverrides field on the machine-config-operator-config
+                ConfigMap.
+              type: object
+              additionalProperties:
+                type: string
+            tuning:
+              description: tuning tunes the informers and sub-controllers of the machine-config-controller
+                for large clusters. Its value is taken from the data.controllerTuning
+                field on the machine-config-operator-config ConfigMap.
+              type: object
+              properties:
+                controllers:
+                  description: controllers tunes the sub-controllers, by name.
+                  type: array
+                  items:
+                    type: object
+                    required:
+                    - name
+                    properties:
+                      burst:
+                        description: burst is the number of syncs allowed above qps.
+                          default is 100.
+                        type: integer
+                        format: int32
+                      name:
+                        description: name is one of template, kubelet-config, container-runtime-config,
+                          node-tuning-config, image-policy, time-sync, on-prem-networking,
+                          accelerator-config, node-ssh-keys, config-snippet, render
+                          or node.
+                        type: string
+                      qps:
+                        description: qps is the overall rate of syncs per second of
+                          the work queue. default is 10.
+                        type: integer
+                        format: int32
+                      workers:
+                        description: workers is the number of objects synced concurrently.
+                          default is 2.
+                        type: integer
+                        format: int32
+                resyncPeriod:
+                  description: resyncPeriod is the minimum period the informers resync
+                    after, jittered up to twice its value. default is 20m.
+                  type: string
         status:
           description: ControllerConfigStatus is the status for ControllerConfig
           type: object
@@ -1099,19 +1565,19 @@ func manifestsControllerconfigCrdYaml() (*asset, error) {
 	return a, nil
 }
 
-var _manifestsKubeletconfigCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+var _manifestsImagepolicyCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
 metadata:
-  name: kubeletconfigs.machineconfiguration.openshift.io
+  name: imagepolicies.machineconfiguration.openshift.io
   labels:
     "openshift.io/operator-managed": ""
 spec:
   group: machineconfiguration.openshift.io
   names:
-    kind: KubeletConfig
-    listKind: KubeletConfigList
-    plural: kubeletconfigs
-    singular: kubeletconfig
+    kind: ImagePolicy
+    listKind: ImagePolicyList
+    plural: imagepolicies
+    singular: imagepolicy
   scope: Cluster
   preserveUnknownFields: false
   subresources:
@@ -1122,7 +1588,8 @@ spec:
     storage: true
   "validation":
     "openAPIV3Schema":
-      description: KubeletConfig describes a customized Kubelet configuration.
+      description: ImagePolicy describes the signatures the images pulled from
+        registries must carry on the nodes of the selected pools.
       type: object
       properties:
         apiVersion:
@@ -1138,12 +1605,11 @@ spec:
         metadata:
           type: object
         spec:
-          description: KubeletConfigSpec defines the desired state of KubeletConfig
+          description: ImagePolicySpec defines the desired state of ImagePolicy
           type: object
+          required:
+          - registries
           properties:
-            kubeletConfig:
-              type: object
-              x-kubernetes-preserve-unknown-fields: true
             machineConfigPoolSelector:
               description: A label selector is a label query over a set of resources.
                 The result of matchLabels and matchExpressions are ANDed. An empty
@@ -1190,8 +1656,37 @@ spec:
                   type: object
                   additionalProperties:
                     type: string
+            registries:
+              description: registries lists the registries whose images must be
+                signed.
+              type: array
+              items:
+                description: RegistrySignaturePolicy defines the signatures required
+                  for the images of a registry.
+                type: object
+                required:
+                - gpgKeys
+                - scope
+                properties:
+                  gpgKeys:
+                    description: gpgKeys are the ASCII armored GPG public keys trusted
+                      to sign the images of the scope. An image is accepted when
+                      one of them signed it.
+                    type: array
+                    items:
+                      type: string
+                  scope:
+                    description: scope is the registry, optionally followed by a
+                      repository path, the policy applies to, e.g. quay.io or registry.example.com:5000/team.
+                    type: string
+                  sigstore:
+                    description: sigstore is the URL of the lookaside storage the
+                      signatures of the images are read from, for registries which
+                      don't serve them.
+                    type: string
         status:
-          description: KubeletConfigStatus defines the observed state of a KubeletConfig
+          description: ImagePolicyStatus defines the observed state of an
+            ImagePolicy
           type: object
           properties:
             conditions:
@@ -1199,7 +1694,8 @@ spec:
                 of current state.
               type: array
               items:
-                description: KubeletConfigCondition defines the state of the KubeletConfig
+                description: ImagePolicyCondition defines the state of the
+                  ImagePolicy
                 type: object
                 properties:
                   lastTransitionTime:
@@ -1230,32 +1726,263 @@ spec:
               format: int64
 `)
 
-func manifestsKubeletconfigCrdYamlBytes() ([]byte, error) {
-	return _manifestsKubeletconfigCrdYaml, nil
+func manifestsImagepolicyCrdYamlBytes() ([]byte, error) {
+	return _manifestsImagepolicyCrdYaml, nil
 }
 
-func manifestsKubeletconfigCrdYaml() (*asset, error) {
-	bytes, err := manifestsKubeletconfigCrdYamlBytes()
+func manifestsImagepolicyCrdYaml() (*asset, error) {
+	bytes, err := manifestsImagepolicyCrdYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "manifests/kubeletconfig.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "manifests/imagepolicy.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _manifestsMachineconfigCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+var _manifestsKubeletconfigCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
 metadata:
-  # name must match the spec fields below, and be in the form: <plural>.<group>
-  name: machineconfigs.machineconfiguration.openshift.io
+  name: kubeletconfigs.machineconfiguration.openshift.io
   labels:
     "openshift.io/operator-managed": ""
 spec:
-  additionalPrinterColumns:
-  - JSONPath: .metadata.annotations.machineconfiguration\.openshift\.io/generated-by-controller-version
-    description: Version of the controller that generated the machineconfig. This
+  group: machineconfiguration.openshift.io
+  names:
+    kind: KubeletConfig
+    listKind: KubeletConfigList
+    plural: kubeletconfigs
+    singular: kubeletconfig
+  scope: Cluster
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: KubeletConfig describes a customized Kubelet configuration.
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: KubeletConfigSpec defines the desired state of KubeletConfig
+          type: object
+          properties:
+            cpuManagerPolicy:
+              description: cpuManagerPolicy sets the kubelet cpuManagerPolicy.
+                The static policy needs CPUs reserved for the system.
+              type: string
+              enum:
+              - none
+              - static
+            failSwapOn:
+              description: failSwapOn sets the kubelet failSwapOn. When true swap
+                is also disabled with the systemd.swap=0 kernel argument.
+              type: boolean
+            hugepages:
+              description: hugepages lists the huge pages allocated at boot on
+                the nodes of the selected pools, through kernel arguments.
+                The size of the first one is the default huge page size, and
+                each size is listed once.
+              type: array
+              maxItems: 2
+              items:
+                description: HugepagesAllocation defines the huge pages of a size
+                  allocated at boot
+                type: object
+                required:
+                - count
+                - size
+                properties:
+                  count:
+                    description: count is the number of huge pages to allocate.
+                    type: integer
+                    format: int32
+                    minimum: 1
+                  size:
+                    description: size of the huge pages, 2M or 1G.
+                    type: string
+                    enum:
+                    - 2M
+                    - 1G
+            kubeletConfig:
+              type: object
+              x-kubernetes-preserve-unknown-fields: true
+            logLevel:
+              description: logLevel sets the kubelet verbosity (KUBELET_LOG_LEVEL)
+                for the nodes in the selected pools.
+              type: integer
+              format: int32
+              minimum: 0
+              maximum: 10
+            machineConfigPoolSelector:
+              description: A label selector is a label query over a set of resources.
+                The result of matchLabels and matchExpressions are ANDed. An empty
+                label selector matches all objects. A null label selector matches
+                no objects.
+              type: object
+              properties:
+                matchExpressions:
+                  description: matchExpressions is a list of label selector requirements.
+                    The requirements are ANDed.
+                  type: array
+                  items:
+                    description: A label selector requirement is a selector that contains
+                      values, a key, and an operator that relates the key and values.
+                    type: object
+                    required:
+                    - key
+                    - operator
+                    properties:
+                      key:
+                        description: key is the label key that the selector applies
+                          to.
+                        type: string
+                      operator:
+                        description: operator represents a key's relationship to a
+                          set of values. Valid operators are In, NotIn, Exists and
+                          DoesNotExist.
+                        type: string
+                      values:
+                        description: values is an array of string values. If the operator
+                          is In or NotIn, the values array must be non-empty. If the
+                          operator is Exists or DoesNotExist, the values array must
+                          be empty. This array is replaced during a strategic merge
+                          patch.
+                        type: array
+                        items:
+                          type: string
+                matchLabels:
+                  description: matchLabels is a map of {key,value} pairs. A single
+                    {key,value} in the matchLabels map is equivalent to an element
+                    of matchExpressions, whose key field is "key", the operator is
+                    "In", and the values array contains only "value". The requirements
+                    are ANDed.
+                  type: object
+                  additionalProperties:
+                    type: string
+            memoryManagerPolicy:
+              description: memoryManagerPolicy sets the kubelet memoryManagerPolicy.
+              type: string
+              enum:
+              - None
+              - Static
+            reservedMemory:
+              description: reservedMemory sets the kubelet reservedMemory, the memory
+                reserved for the system on each NUMA node. It needs the Static memoryManagerPolicy.
+              type: array
+              items:
+                description: MemoryReservation defines the memory reserved for the
+                  system on a NUMA node
+                type: object
+                required:
+                - limits
+                - numaNode
+                properties:
+                  limits:
+                    description: limits are the quantities reserved by type of memory,
+                      memory or hugepages-<size>.
+                    type: object
+                    additionalProperties:
+                      type: string
+                  numaNode:
+                    description: numaNode is the ID of the NUMA node.
+                    type: integer
+                    format: int32
+                    minimum: 0
+            reservedSystemCPUs:
+              description: reservedSystemCPUs sets the kubelet reservedSystemCPUs,
+                the CPUs reserved for the system and kubernetes daemons, e.g. 0-1,4.
+              type: string
+            topologyManagerPolicy:
+              description: topologyManagerPolicy sets the kubelet topologyManagerPolicy.
+              type: string
+              enum:
+              - none
+              - best-effort
+              - restricted
+              - single-numa-node
+        status:
+          description: KubeletConfigStatus defines the observed state of a KubeletConfig
+          type: object
+          properties:
+            conditions:
+              description: conditions represents the latest available observations
+                of current state.
+              type: array
+              items:
+                description: KubeletConfigCondition defines the state of the KubeletConfig
+                type: object
+                properties:
+                  lastTransitionTime:
+                    description: lastTransitionTime is the time of the last update
+                      to the current status object.
+                    type: string
+                    format: date-time
+                    nullable: true
+                  message:
+                    description: message provides additional information about the
+                      current condition. This is only to be consumed by humans.
+                    type: string
+                  reason:
+                    description: reason is the reason for the condition's last transition.  Reasons
+                      are PascalCase
+                    type: string
+                  status:
+                    description: status of the condition, one of True, False, Unknown.
+                    type: string
+                  type:
+                    description: type specifies the state of the operator's reconciliation
+                      functionality.
+                    type: string
+            observedGeneration:
+              description: observedGeneration represents the generation observed by
+                the controller.
+              type: integer
+              format: int64
+`)
+
+func manifestsKubeletconfigCrdYamlBytes() ([]byte, error) {
+	return _manifestsKubeletconfigCrdYaml, nil
+}
+
+func manifestsKubeletconfigCrdYaml() (*asset, error) {
+	bytes, err := manifestsKubeletconfigCrdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/kubeletconfig.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsMachineconfigCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  # name must match the spec fields below, and be in the form: <plural>.<group>
+  name: machineconfigs.machineconfiguration.openshift.io
+  labels:
+    "openshift.io/operator-managed": ""
+spec:
+  additionalPrinterColumns:
+  - JSONPath: .metadata.annotations.machineconfiguration\.openshift\.io/generated-by-controller-version
+    description: Version of the controller that generated the machineconfig. This
       will be empty if the machineconfig is not managed by a controller.
     name: GeneratedByController
     type: string
@@ -1310,6 +2037,10 @@ spec:
           description: MachineConfigSpec is the spec for MachineConfig
           type: object
           properties:
+            butane:
+              description: butane is a Butane (Fedora CoreOS Config) YAML document,
+                translated to Ignition by the render controller and merged after config.
+              type: string
             config:
               description: Config is a Ignition Config object.
               type: object
@@ -1552,6 +2283,13 @@ spec:
                             description: Name is the name of the unit. This must be suffixed with a
                               valid unit type (e.g. 'thing.service')
                             type: string
+            extensions:
+              description: extensions lists the supported package sets (e.g. usbguard)
+                to be layered on top of the OS with rpm-ostree.
+              type: array
+              items:
+                type: string
+              nullable: true
             fips:
               description: FIPS controls FIPS mode
               type: boolean
@@ -1564,6 +2302,10 @@ spec:
             kernelType:
               description: Contains which kernel we want to be running like default (traditional), realtime
               type: string
+              enum:
+              - ""
+              - default
+              - realtime
             osImageURL:
               description: OSImageURL specifies the remote location that will be used to fetch the OS
                 to fetch the OS.
@@ -1594,6 +2336,12 @@ rules:
 - apiGroups: [""]
   resources: ["nodes"]
   verbs: ["get", "list", "watch", "patch"]
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["list", "watch"]
+- apiGroups: ["policy"]
+  resources: ["poddisruptionbudgets"]
+  verbs: ["list", "watch"]
 - apiGroups: ["machineconfiguration.openshift.io"]
   resources: ["*"]
   verbs: ["*"]
@@ -1612,6 +2360,19 @@ rules:
 - apiGroups: ["operator.openshift.io"]
   resources: ["etcds"]
   verbs: ["get", "list", "watch"]
+- apiGroups:
+  - authentication.k8s.io
+  resources:
+  - tokenreviews
+  - subjectaccessreviews
+  verbs:
+  - create
+- apiGroups:
+  - authorization.k8s.io
+  resources:
+  - subjectaccessreviews
+  verbs:
+  - create
 `)
 
 func manifestsMachineconfigcontrollerClusterroleYamlBytes() ([]byte, error) {
@@ -1708,11 +2469,47 @@ spec:
             cpu: 20m
             memory: 50Mi
         terminationMessagePolicy: FallbackToLogsOnError
+      - name: oauth-proxy
+        image: {{.Images.OauthProxy}}
+        ports:
+        - containerPort: 9001
+          name: metrics
+          protocol: TCP
+        args:
+        - --https-address=:9001
+        - --provider=openshift
+        - --openshift-service-account=machine-config-controller
+        - --upstream=http://127.0.0.1:8796
+        - --tls-cert=/etc/tls/private/tls.crt
+        - --tls-key=/etc/tls/private/tls.key
+        - --cookie-secret-file=/etc/tls/cookie-secret/cookie-secret
+        - '--openshift-sar={"resource": "namespaces", "verb": "get"}'
+        - '--openshift-delegate-urls={"/": {"resource": "namespaces", "verb": "get"}}'
+        resources:
+          requests:
+            cpu: 20m
+            memory: 50Mi
+        volumeMounts:
+        - mountPath: /etc/tls/private
+          name: mcc-proxy-tls
+        - mountPath: /etc/tls/cookie-secret
+          name: cookie-secret
       serviceAccountName: machine-config-controller
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+              labelSelector:
+                matchLabels:
+                  k8s-app: machine-config-controller
       nodeSelector:
         node-role.kubernetes.io/master: ""
       priorityClassName: "system-cluster-critical"
       restartPolicy: Always
+      # leaves the controllers time to drain their queues, see --shutdown-deadline
+      terminationGracePeriodSeconds: 60
       tolerations:
       - key: node-role.kubernetes.io/master
         operator: Exists
@@ -1725,6 +2522,13 @@ spec:
         operator: "Exists"
         effect: "NoExecute"
         tolerationSeconds: 120
+      volumes:
+      - name: mcc-proxy-tls
+        secret:
+          secretName: mcc-proxy-tls
+      - name: cookie-secret
+        secret:
+          secretName: cookie-secret
 `)
 
 func manifestsMachineconfigcontrollerDeploymentYamlBytes() ([]byte, error) {
@@ -1764,6 +2568,80 @@ func manifestsMachineconfigcontrollerSaYaml() (*asset, error) {
 	return a, nil
 }
 
+var _manifestsMachineconfigcontrollerServiceYaml = []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: machine-config-controller
+  namespace: {{.TargetNamespace}}
+  labels:
+    k8s-app: machine-config-controller
+  annotations:
+    service.beta.openshift.io/serving-cert-secret-name: mcc-proxy-tls
+spec:
+  type: ClusterIP
+  selector:
+    k8s-app: machine-config-controller
+  ports:
+  - name: metrics
+    port: 9001
+    protocol: TCP
+`)
+
+func manifestsMachineconfigcontrollerServiceYamlBytes() ([]byte, error) {
+	return _manifestsMachineconfigcontrollerServiceYaml, nil
+}
+
+func manifestsMachineconfigcontrollerServiceYaml() (*asset, error) {
+	bytes, err := manifestsMachineconfigcontrollerServiceYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/machineconfigcontroller/service.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsMachineconfigcontrollerServicemonitorYaml = []byte(`apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: machine-config-controller
+  namespace: {{.TargetNamespace}}
+  labels:
+    k8s-app: machine-config-controller
+spec:
+  endpoints:
+  - interval: 30s
+    bearerTokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+    port: metrics
+    scheme: https
+    path: /metrics
+    tlsConfig:
+      caFile: /etc/prometheus/configmaps/serving-certs-ca-bundle/service-ca.crt
+      serverName: machine-config-controller.{{.TargetNamespace}}.svc
+  namespaceSelector:
+    matchNames:
+    - {{.TargetNamespace}}
+  selector:
+    matchLabels:
+      k8s-app: machine-config-controller
+`)
+
+func manifestsMachineconfigcontrollerServicemonitorYamlBytes() ([]byte, error) {
+	return _manifestsMachineconfigcontrollerServicemonitorYaml, nil
+}
+
+func manifestsMachineconfigcontrollerServicemonitorYaml() (*asset, error) {
+	bytes, err := manifestsMachineconfigcontrollerServicemonitorYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/machineconfigcontroller/servicemonitor.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _manifestsMachineconfigdaemonClusterroleYaml = []byte(`apiVersion: rbac.authorization.k8s.io/v1
 kind: ClusterRole
 metadata:
@@ -1788,6 +2666,9 @@ rules:
 - apiGroups: ["machineconfiguration.openshift.io"]
   resources: ["machineconfigs"]
   verbs: ["*"]
+- apiGroups: ["machineconfiguration.openshift.io"]
+  resources: ["nodemachineconfigstates", "nodemachineconfigstates/status"]
+  verbs: ["get", "update"]
 - apiGroups:
   - authentication.k8s.io
   resources:
@@ -1907,7 +2788,12 @@ spec:
         image: {{.Images.MachineConfigOperator}}
         command: ["/usr/bin/machine-config-daemon"]
         args:
+{{- if eq .DaemonMode "host" }}
+          - "supervise"
+          - "--apiserver-url={{.APIServerURL}}"
+{{- else }}
           - "start"
+{{- end }}
         resources:
           requests:
             cpu: 20m
@@ -2139,6 +3025,11 @@ spec:
     description: Total number of machines marked degraded (or unreconcilable)
     name: DegradedMachineCount
     type: number
+  - JSONPath: .status.unmanagedMachineCount
+    description: Total number of machines excluded from the management of the MCO
+    name: UnmanagedMachineCount
+    type: number
+    priority: 1
   - JSONPath: .metadata.creationTimestamp
     name: Age
     type: date
@@ -2187,6 +3078,11 @@ spec:
           description: MachineConfigPoolSpec is the spec for MachineConfigPool resource.
           type: object
           properties:
+            autoRemediateDrift:
+              description: autoRemediateDrift, when true, has the machines of the
+                pool write the files and units of their current MachineConfig back
+                to disk when they drifted from it, instead of reporting Degraded.
+              type: boolean
             configuration:
               description: The targeted MachineConfig object for the machine config
                 pool.
@@ -2311,9 +3207,38 @@ spec:
                   type: object
                   additionalProperties:
                     type: string
+            maintenanceWindows:
+              description: maintenanceWindows, when set, restricts when the machines
+                of the pool start updating to a new rendered MachineConfig. Updates
+                in progress when a window closes are completed. Rollbacks are not
+                restricted.
+              type: array
+              items:
+                description: MaintenanceWindow is a recurring time range during which
+                  machines can start updating.
+                type: object
+                required:
+                - schedule
+                - duration
+                properties:
+                  duration:
+                    description: duration is how long the window stays open, e.g.
+                      4h.
+                    type: string
+                  schedule:
+                    description: 'schedule is when the window opens, as a cron expression
+                      of 5 fields: minute, hour, day of month, month and day of week,
+                      e.g. "0 22 * * 1-5".'
+                    type: string
+                  timeZone:
+                    description: timeZone is the IANA time zone of the schedule, e.g.
+                      Europe/Paris. default is UTC.
+                    type: string
             maxUnavailable:
               description: maxUnavailable specifies the percentage or constant number
-                of machines that can be updating at any given time. default is 1.
+                of machines that can be updating at any given time. Percentages are
+                relative to the number of ready machines and rounded down. default
+                is 1.
               anyOf:
               - type: integer
               - type: string
@@ -2361,16 +3286,192 @@ spec:
                   type: object
                   additionalProperties:
                     type: string
+            osImageURL:
+              description: osImageURL, when set, overrides the OS image of the release
+                payload for the machines of the pool, e.g. to run a hotfix OS image.
+                It must be pinned by digest and listed in the osImageAllowList of the
+                ControllerConfig.
+              type: string
             paused:
               description: paused specifies whether or not changes to this machine
                 config pool should be stopped. This includes generating new desiredMachineConfig
                 and update of machines.
               type: boolean
+            pausedUntil:
+              description: pausedUntil, when set along with paused, is when the pool
+                resumes automatically. The controller clears both fields once it is
+                reached.
+              type: string
+              format: date-time
+              nullable: true
+            postUpdateHealthCheck:
+              description: postUpdateHealthCheck, when set, has the machines of
+                the pool check their health after rebooting into a new MachineConfig,
+                and roll back to their previous one when the checks don't pass in
+                time.
+              type: object
+              properties:
+                checks:
+                  description: checks are the health checks which must pass, Kubelet
+                    and CRIO. default is all of them.
+                  type: array
+                  items:
+                    type: string
+                    enum:
+                    - Kubelet
+                    - CRIO
+                timeout:
+                  description: timeout is how long the checks have to pass after
+                    the reboot before the machine rolls back, between 1m and 1h. default
+                    is 10m.
+                  type: string
+            progressDeadlineMinutes:
+              description: progressDeadlineMinutes is how long a rollout can go
+                without a machine of the pool finishing its update before the pool
+                reports RolloutStalled. When unset, the rollout is never reported
+                as stalled.
+              type: integer
+              format: int32
+              minimum: 1
+            rebootStrategy:
+              description: rebootStrategy sets how the machines of the pool reboot
+                into a new MachineConfig, e.g. for hardware which needs a custom power
+                cycle. default is a systemd reboot.
+              type: object
+              properties:
+                gracePeriod:
+                  description: gracePeriod is how long the machines wait after stopping
+                    the kubelet before rebooting, at most 1h. default is 0.
+                  type: string
+                type:
+                  description: type is Reboot, Kexec or Unit. Kexec and Unit fall
+                    back to a systemd reboot when they fail to start. default is Reboot.
+                  type: string
+                  enum:
+                  - ""
+                  - Reboot
+                  - Kexec
+                  - Unit
+                unit:
+                  description: unit is the systemd unit started to reboot with the
+                    Unit type, e.g. one power cycling the machine through its BMC.
+                    It must be shipped by a MachineConfig of the pool.
+                  type: string
+            rollbackTo:
+              description: rollbackTo is the name of a rendered MachineConfig from
+                status.history the nodes of the pool should be rolled back to. While
+                it is set, newly rendered configurations are not rolled out.
+              type: string
+            unmanagedPaths:
+              description: unmanagedPaths are the absolute paths of the files the
+                machines of the pool stop writing, deleting and checking for drift,
+                e.g. files owned by another node agent. Paths ending with / cover
+                the files of that directory tree. Paths the MCO relies on, e.g. under
+                /etc/kubernetes, are ignored.
+              type: array
+              items:
+                type: string
+            updateStrategy:
+              description: updateStrategy controls how new rendered MachineConfigs
+                are rolled out to the machines of the pool.
+              type: object
+              properties:
+                canary:
+                  description: canary, when set, updates a subset of the machines
+                    first and holds the rest of the pool until the canary is promoted.
+                  type: object
+                  properties:
+                    healthyDuration:
+                      description: healthyDuration, when set, promotes the canary
+                        automatically once all the canary machines have been updated
+                        and ready for that long. Otherwise the pool is paused when
+                        the canary machines are updated and the rollout continues
+                        once it is unpaused.
+                      type: string
+                    nodes:
+                      description: nodes is the number or percentage of machines
+                        updated during the canary phase. default is 1.
+                      anyOf:
+                      - type: integer
+                      - type: string
+                      x-kubernetes-int-or-string: true
+                drainPolicy:
+                  description: drainPolicy overrides, field by field, the drainPolicy
+                    of the ControllerConfig for the machines of this pool.
+                  type: object
+                  properties:
+                    attempts:
+                      description: attempts is the number of drain attempts before the update
+                        is failed. default is 5.
+                      type: integer
+                      format: int32
+                      minimum: 0
+                    backoff:
+                      description: backoff is the wait before the second drain attempt, doubled
+                        after every failed attempt. default is 10s.
+                      type: string
+                    forceAfterAttempts:
+                      description: forceAfterAttempts, when set, deletes the pods instead of
+                        evicting them once that many drain attempts failed, bypassing their
+                        PodDisruptionBudgets.
+                      type: integer
+                      format: int32
+                      minimum: 0
+                    skipWaitForDeleteTimeout:
+                      description: skipWaitForDeleteTimeout, when set, skips waiting for the
+                        pods whose deletion was requested longer than this ago, e.g. pods stuck
+                        on an unreachable volume.
+                      type: string
+                    timeout:
+                      description: timeout is how long a single drain attempt waits for the
+                        pods to be evicted. default is 20s.
+                      type: string
+                maxUnavailablePerZone:
+                  description: maxUnavailablePerZone is the number of machines sharing
+                    the same topology.kubernetes.io/zone label that can be updating
+                    at any given time. Machines without a zone label are only bound
+                    by maxUnavailable. default is 1, 0 disables the limit.
+                  type: integer
+                  format: int32
+                  minimum: 0
+                staged:
+                  description: staged, when true, has the machines write new rendered
+                    MachineConfigs to disk and stage the OS update without draining
+                    nor rebooting. The update is applied on the next reboot of the
+                    machine, or once its node is annotated with machineconfiguration.openshift.io/applyStagedUpdate=true.
+                  type: boolean
         status:
           description: MachineConfigPoolStatus is the status for MachineConfigPool
             resource.
           type: object
           properties:
+            canary:
+              description: canary reports the canary phase of the rendered MachineConfig
+                being rolled out.
+              type: object
+              required:
+              - configuration
+              - machineCount
+              - promoted
+              properties:
+                completedTime:
+                  description: completedTime is when all the canary machines were
+                    updated and ready.
+                  type: string
+                  format: date-time
+                  nullable: true
+                configuration:
+                  description: configuration is the rendered MachineConfig being rolled
+                    out.
+                  type: string
+                machineCount:
+                  description: machineCount is the number of machines updated during
+                    the canary phase.
+                  type: integer
+                  format: int32
+                promoted:
+                  description: promoted is true once the rest of the pool can be updated.
+                  type: boolean
             conditions:
               description: conditions represents the latest available observations
                 of current state.
@@ -2487,11 +3588,68 @@ spec:
                 applying a configuration failed..
               type: integer
               format: int32
+            history:
+              description: history lists the rendered MachineConfigs all the machines
+                of the pool were updated to, most recent first.
+              type: array
+              items:
+                description: MachineConfigPoolHistoryEntry records a rendered MachineConfig
+                  a pool was updated to.
+                type: object
+                required:
+                - name
+                properties:
+                  name:
+                    description: name of the rendered MachineConfig.
+                    type: string
+                  timestamp:
+                    description: timestamp is when all the machines of the pool were
+                      updated to the rendered MachineConfig.
+                    type: string
+                    format: date-time
+                    nullable: true
+            lastProgressTime:
+              description: lastProgressTime is when the rollout in progress started
+                or a machine last finished updating. It's only tracked for pools with
+                a progress deadline, and unset while the pool isn't updating.
+              type: string
+              format: date-time
+              nullable: true
             machineCount:
               description: machineCount represents the total number of machines in
                 the machine config pool.
               type: integer
               format: int32
+            nodes:
+              description: nodes reports where each machine of the pool is in the
+                rollout, sorted by name.
+              type: array
+              items:
+                description: MachineConfigPoolNodeStatus reports the rollout progress
+                  of a machine.
+                type: object
+                required:
+                - name
+                - phase
+                properties:
+                  currentConfig:
+                    description: currentConfig is the rendered MachineConfig the machine
+                      runs.
+                    type: string
+                  desiredConfig:
+                    description: desiredConfig is the rendered MachineConfig the machine
+                      was told to update to.
+                    type: string
+                  name:
+                    description: name of the node.
+                    type: string
+                  phase:
+                    description: phase is the step of the rollout the machine is in.
+                    type: string
+                  reason:
+                    description: reason is reported by the daemon when the machine
+                      is degraded.
+                    type: string
             observedGeneration:
               description: observedGeneration represents the generation observed by
                 the controller.
@@ -2508,6 +3666,13 @@ spec:
                 unavailable if it is in updating state or NodeReady condition is false.
               type: integer
               format: int32
+            unmanagedMachineCount:
+              description: unmanagedMachineCount represents the total number of machines
+                excluded from the management of the MCO with the machineconfiguration.openshift.io/unmanaged
+                annotation or label. They are frozen at their current config, and don't
+                count against the update of the pool.
+              type: integer
+              format: int32
             updatedMachineCount:
               description: updatedMachineCount represents the total number of machines
                 targeted by the pool that have the CurrentMachineConfig as their config.
@@ -2539,6 +3704,14 @@ rules:
 - apiGroups: ["machineconfiguration.openshift.io"]
   resources: ["machineconfigs", "machineconfigpools"]
   verbs: ["*"]
+- apiGroups: [""]
+  resources: ["configmaps"]
+  resourceNames: ["machine-config-server-node-config"]
+  verbs: ["get"]
+- apiGroups: [""]
+  resources: ["configmaps"]
+  resourceNames: ["machine-config-server-audit"]
+  verbs: ["get", "update"]
 `)
 
 func manifestsMachineconfigserverClusterroleYamlBytes() ([]byte, error) {
@@ -2671,6 +3844,12 @@ spec:
         args:
           - "start"
           - "--apiserver-url={{.APIServerURL}}"
+        readinessProbe:
+          httpGet:
+            path: /readyz
+            port: 22623
+            scheme: HTTPS
+          periodSeconds: 10
         resources:
           requests:
             cpu: 20m
@@ -2841,152 +4020,816 @@ func manifestsMasterMachineconfigpoolYaml() (*asset, error) {
 	return a, nil
 }
 
-var _manifestsOpenstackCorednsCorefileTmpl = []byte(`. {
-    errors
-    health :18080
-    mdns {{ .ControllerConfig.EtcdDiscoveryDomain }} {{`+"`"+`{{.Cluster.MasterAmount}}`+"`"+`}} {{`+"`"+`{{.Cluster.Name}}`+"`"+`}}
-    forward . {{`+"`"+`{{- range $upstream := .DNSUpstreams}} {{$upstream}}{{- end}}`+"`"+`}} {
-        policy sequential
-    }
-    cache 30
-    reload
-    hosts /etc/coredns/api-int.hosts {{ .ControllerConfig.EtcdDiscoveryDomain }} {
-        {{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.APIServerInternalIP }} api-int.{{ .ControllerConfig.EtcdDiscoveryDomain }} api.{{ .ControllerConfig.EtcdDiscoveryDomain }}
-        fallthrough
-    }
-}
-`)
-
-func manifestsOpenstackCorednsCorefileTmplBytes() ([]byte, error) {
-	return _manifestsOpenstackCorednsCorefileTmpl, nil
-}
-
-func manifestsOpenstackCorednsCorefileTmpl() (*asset, error) {
-	bytes, err := manifestsOpenstackCorednsCorefileTmplBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "manifests/openstack/coredns-corefile.tmpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _manifestsOpenstackCorednsYaml = []byte(`---
-kind: Pod
-apiVersion: v1
+var _manifestsNodemachineconfigstateCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
 metadata:
-  name: coredns
-  namespace: openshift-openstack-infra
-  creationTimestamp:
-  deletionGracePeriodSeconds: 65
+  name: nodemachineconfigstates.machineconfiguration.openshift.io
   labels:
-    app: openstack-infra-mdns
+    "openshift.io/operator-managed": ""
 spec:
-  volumes:
-  - name: resource-dir
-    hostPath:
-      path: "/etc/kubernetes/static-pod-resources/coredns"
-  - name: kubeconfig
-    hostPath:
-      path: "/etc/kubernetes/kubeconfig"
-  - name: conf-dir
-    empty-dir: {}
-  - name: manifests
-    hostPath:
-      path: "/opt/openshift/manifests"
-  initContainers:
-  - name: render-config
-    image: {{ .Images.BaremetalRuntimeCfgBootstrap }}
-    command:
-    - runtimecfg
-    - render
-    - "/etc/kubernetes/kubeconfig"
-    - "--api-vip"
-    - "{{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.APIServerInternalIP }}"
-    - "--dns-vip"
-    - "{{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.NodeDNSIP }}"
-    - "--ingress-vip"
-    - "{{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.IngressIP }}"
-    - "/config"
-    - "--out-dir"
-    - "/etc/coredns"
-    - "--cluster-config"
-    - "/opt/openshift/manifests/cluster-config.yaml"
-    resources: {}
-    volumeMounts:
-    - name: kubeconfig
-      mountPath: "/etc/kubernetes/kubeconfig"
-    - name: resource-dir
-      mountPath: "/config"
-    - name: conf-dir
-      mountPath: "/etc/coredns"
-    - name: manifests
-      mountPath: "/opt/openshift/manifests"
-    imagePullPolicy: IfNotPresent
-  containers:
-  - name: coredns
-    securityContext:
-      privileged: true
-    image: {{ .Images.CorednsBootstrap }}
-    args:
-    - "--conf"
-    - "/etc/coredns/Corefile"
-    resources:
-      requests:
-        cpu: 100m
-        memory: 200Mi
-    volumeMounts:
-    - name: conf-dir
-      mountPath: "/etc/coredns"
-    readinessProbe:
-      httpGet:
-        path: /health
-        port: 18080
-        scheme: HTTP
-      initialDelaySeconds: 10
-      periodSeconds: 10
-      successThreshold: 1
-      failureThreshold: 3
-      timeoutSeconds: 10
-    livenessProbe:
-      httpGet:
-        path: /health
-        port: 18080
-        scheme: HTTP
-      initialDelaySeconds: 60
-      timeoutSeconds: 5
-      successThreshold: 1
-      failureThreshold: 5
-    terminationMessagePolicy: FallbackToLogsOnError
-  hostNetwork: true
-  tolerations:
-  - operator: Exists
-  priorityClassName: system-node-critical
-status: {}
+  group: machineconfiguration.openshift.io
+  names:
+    kind: NodeMachineConfigState
+    listKind: NodeMachineConfigStateList
+    plural: nodemachineconfigstates
+    singular: nodemachineconfigstate
+  scope: Namespaced
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+  - JSONPath: .status.currentConfig
+    name: Current
+    type: string
+  - JSONPath: .spec.desiredConfig
+    name: Desired
+    type: string
+  - JSONPath: .status.state
+    name: State
+    type: string
+  - JSONPath: .status.phase
+    name: Phase
+    type: string
+  - JSONPath: .metadata.creationTimestamp
+    name: Age
+    type: date
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: NodeMachineConfigState describes the config of a node and the
+        progress of its daemon.
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: NodeMachineConfigStateSpec defines the desired state of NodeMachineConfigState
+          type: object
+          required:
+          - node
+          properties:
+            desiredConfig:
+              description: desiredConfig is the rendered MachineConfig the node
+                controller wants the node to run.
+              type: string
+            node:
+              description: node is the name of the node.
+              type: string
+        status:
+          description: NodeMachineConfigStateStatus defines the observed state of
+            a NodeMachineConfigState
+          type: object
+          properties:
+            currentConfig:
+              description: currentConfig is the rendered MachineConfig the node runs.
+              type: string
+            desiredConfig:
+              description: desiredConfig is the rendered MachineConfig the daemon
+                last saw desired.
+              type: string
+            lastError:
+              description: lastError is why the daemon is Degraded or Unreconcilable.
+              type: string
+            lastTransitionTime:
+              description: lastTransitionTime is when the state last changed.
+              type: string
+              format: date-time
+              nullable: true
+            phase:
+              description: phase is the step of the update the daemon is in while
+                Working, e.g. Draining or Rebooting.
+              type: string
+            state:
+              description: 'state of the daemon: Done, Working, Degraded or Unreconcilable.'
+              type: string
+            updateCompletionTime:
+              description: updateCompletionTime is when the daemon completed the
+                last update.
+              type: string
+              format: date-time
+              nullable: true
+            updateStartTime:
+              description: updateStartTime is when the daemon started the last
+                update.
+              type: string
+              format: date-time
+              nullable: true
 `)
 
-func manifestsOpenstackCorednsYamlBytes() ([]byte, error) {
-	return _manifestsOpenstackCorednsYaml, nil
+func manifestsNodemachineconfigstateCrdYamlBytes() ([]byte, error) {
+	return _manifestsNodemachineconfigstateCrdYaml, nil
 }
 
-func manifestsOpenstackCorednsYaml() (*asset, error) {
-	bytes, err := manifestsOpenstackCorednsYamlBytes()
+func manifestsNodemachineconfigstateCrdYaml() (*asset, error) {
+	bytes, err := manifestsNodemachineconfigstateCrdYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "manifests/openstack/coredns.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "manifests/nodemachineconfigstate.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _manifestsOpenstackKeepalivedConfTmpl = []byte(`# Configuration template for Keepalived, which is used to manage the DNS and
-# API VIPs.
-#
-# For more information, see installer/data/data/bootstrap/baremetal/README.md
-# in the installer repo.
-
+var _manifestsNodesshkeysetCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: nodesshkeysets.machineconfiguration.openshift.io
+  labels:
+    "openshift.io/operator-managed": ""
+spec:
+  group: machineconfiguration.openshift.io
+  names:
+    kind: NodeSSHKeySet
+    listKind: NodeSSHKeySetList
+    plural: nodesshkeysets
+    singular: nodesshkeyset
+  scope: Cluster
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: NodeSSHKeySet lists SSH public keys allowed to log in as a user
+        on the machines of the selected pools. Rotating keys is a change of the
+        list, which the nodes pick up without rebooting.
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: NodeSSHKeySetSpec defines the desired state of NodeSSHKeySet
+          type: object
+          required:
+          - authorizedKeys
+          properties:
+            authorizedKeys:
+              description: authorizedKeys are the SSH public keys, one per entry,
+                in the authorized_keys format without options, e.g. ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI...
+                admin@example.com.
+              type: array
+              items:
+                type: string
+            machineConfigPoolSelector:
+              description: A label selector is a label query over a set of resources.
+                The result of matchLabels and matchExpressions are ANDed. An empty
+                label selector matches all objects. A null label selector matches
+                no objects.
+              type: object
+              properties:
+                matchExpressions:
+                  description: matchExpressions is a list of label selector requirements.
+                    The requirements are ANDed.
+                  type: array
+                  items:
+                    description: A label selector requirement is a selector that contains
+                      values, a key, and an operator that relates the key and values.
+                    type: object
+                    required:
+                    - key
+                    - operator
+                    properties:
+                      key:
+                        description: key is the label key that the selector applies
+                          to.
+                        type: string
+                      operator:
+                        description: operator represents a key's relationship to a
+                          set of values. Valid operators are In, NotIn, Exists and
+                          DoesNotExist.
+                        type: string
+                      values:
+                        description: values is an array of string values. If the operator
+                          is In or NotIn, the values array must be non-empty. If the
+                          operator is Exists or DoesNotExist, the values array must
+                          be empty. This array is replaced during a strategic merge
+                          patch.
+                        type: array
+                        items:
+                          type: string
+                matchLabels:
+                  description: matchLabels is a map of {key,value} pairs. A single
+                    {key,value} in the matchLabels map is equivalent to an element
+                    of matchExpressions, whose key field is "key", the operator is
+                    "In", and the values array contains only "value". The requirements
+                    are ANDed.
+                  type: object
+                  additionalProperties:
+                    type: string
+            user:
+              description: user is the user the keys log in as, core when empty.
+                Users other than core must already exist on the machines.
+              type: string
+        status:
+          description: NodeSSHKeySetStatus defines the observed state of a
+            NodeSSHKeySet
+          type: object
+          properties:
+            conditions:
+              description: conditions represents the latest available observations
+                of current state.
+              type: array
+              items:
+                description: NodeSSHKeySetCondition defines the state of the
+                  NodeSSHKeySet
+                type: object
+                properties:
+                  lastTransitionTime:
+                    description: lastTransitionTime is the time of the last update
+                      to the current status object.
+                    type: string
+                    format: date-time
+                    nullable: true
+                  message:
+                    description: message provides additional information about the
+                      current condition. This is only to be consumed by humans.
+                    type: string
+                  reason:
+                    description: reason is the reason for the condition's last transition.  Reasons
+                      are PascalCase
+                    type: string
+                  status:
+                    description: status of the condition, one of True, False, Unknown.
+                    type: string
+                  type:
+                    description: type specifies the state of the operator's reconciliation
+                      functionality.
+                    type: string
+            fingerprints:
+              description: fingerprints are the SHA256 fingerprints of the authorizedKeys,
+                in the same order, as printed by ssh-keygen -l.
+              type: array
+              items:
+                type: string
+            observedGeneration:
+              description: observedGeneration represents the generation observed by
+                the controller.
+              type: integer
+              format: int64
+            pools:
+              description: pools are the names of the pools whose MachineConfig
+                holds the keys.
+              type: array
+              items:
+                type: string
+`)
+
+func manifestsNodesshkeysetCrdYamlBytes() ([]byte, error) {
+	return _manifestsNodesshkeysetCrdYaml, nil
+}
+
+func manifestsNodesshkeysetCrdYaml() (*asset, error) {
+	bytes, err := manifestsNodesshkeysetCrdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/nodesshkeyset.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsNodetuningconfigCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: nodetuningconfigs.machineconfiguration.openshift.io
+  labels:
+    "openshift.io/operator-managed": ""
+spec:
+  group: machineconfiguration.openshift.io
+  names:
+    kind: NodeTuningConfig
+    listKind: NodeTuningConfigList
+    plural: nodetuningconfigs
+    singular: nodetuningconfig
+  scope: Cluster
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: NodeTuningConfig describes sysctls and kernel arguments to
+        apply to the nodes of the selected pools.
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: NodeTuningConfigSpec defines the desired state of NodeTuningConfig
+          type: object
+          properties:
+            kernelArguments:
+              description: kernelArguments contains a list of kernel arguments to
+                be added. Only a whitelisted set of arguments is accepted.
+              type: array
+              items:
+                type: string
+              nullable: true
+            machineConfigPoolSelector:
+              description: A label selector is a label query over a set of resources.
+                The result of matchLabels and matchExpressions are ANDed. An empty
+                label selector matches all objects. A null label selector matches
+                no objects.
+              type: object
+              properties:
+                matchExpressions:
+                  description: matchExpressions is a list of label selector requirements.
+                    The requirements are ANDed.
+                  type: array
+                  items:
+                    description: A label selector requirement is a selector that contains
+                      values, a key, and an operator that relates the key and values.
+                    type: object
+                    required:
+                    - key
+                    - operator
+                    properties:
+                      key:
+                        description: key is the label key that the selector applies
+                          to.
+                        type: string
+                      operator:
+                        description: operator represents a key's relationship to a
+                          set of values. Valid operators are In, NotIn, Exists and
+                          DoesNotExist.
+                        type: string
+                      values:
+                        description: values is an array of string values. If the operator
+                          is In or NotIn, the values array must be non-empty. If the
+                          operator is Exists or DoesNotExist, the values array must
+                          be empty. This array is replaced during a strategic merge
+                          patch.
+                        type: array
+                        items:
+                          type: string
+                matchLabels:
+                  description: matchLabels is a map of {key,value} pairs. A single
+                    {key,value} in the matchLabels map is equivalent to an element
+                    of matchExpressions, whose key field is "key", the operator is
+                    "In", and the values array contains only "value". The requirements
+                    are ANDed.
+                  type: object
+                  additionalProperties:
+                    type: string
+            sysctls:
+              description: sysctls is the list of kernel parameters to set on the
+                nodes. Only a whitelisted set of parameters is accepted.
+              type: array
+              items:
+                description: Sysctl defines a kernel parameter to be set
+                type: object
+                required:
+                - name
+                - value
+                properties:
+                  name:
+                    description: name of the kernel parameter, e.g. vm.max_map_count.
+                    type: string
+                  value:
+                    description: value of the kernel parameter.
+                    type: string
+        status:
+          description: NodeTuningConfigStatus defines the observed state of a
+            NodeTuningConfig
+          type: object
+          properties:
+            conditions:
+              description: conditions represents the latest available observations
+                of current state.
+              type: array
+              items:
+                description: NodeTuningConfigCondition defines the state of the
+                  NodeTuningConfig
+                type: object
+                properties:
+                  lastTransitionTime:
+                    description: lastTransitionTime is the time of the last update
+                      to the current status object.
+                    type: string
+                    format: date-time
+                    nullable: true
+                  message:
+                    description: message provides additional information about the
+                      current condition. This is only to be consumed by humans.
+                    type: string
+                  reason:
+                    description: reason is the reason for the condition's last transition.  Reasons
+                      are PascalCase
+                    type: string
+                  status:
+                    description: status of the condition, one of True, False, Unknown.
+                    type: string
+                  type:
+                    description: type specifies the state of the operator's reconciliation
+                      functionality.
+                    type: string
+            observedGeneration:
+              description: observedGeneration represents the generation observed by
+                the controller.
+              type: integer
+              format: int64
+`)
+
+func manifestsNodetuningconfigCrdYamlBytes() ([]byte, error) {
+	return _manifestsNodetuningconfigCrdYaml, nil
+}
+
+func manifestsNodetuningconfigCrdYaml() (*asset, error) {
+	bytes, err := manifestsNodetuningconfigCrdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/nodetuningconfig.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsOnpremnetworkingCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: onpremnetworkings.machineconfiguration.openshift.io
+  labels:
+    "openshift.io/operator-managed": ""
+spec:
+  group: machineconfiguration.openshift.io
+  names:
+    kind: OnPremNetworking
+    listKind: OnPremNetworkingList
+    plural: onpremnetworkings
+    singular: onpremnetworking
+  scope: Cluster
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: OnPremNetworking describes the keepalived and coredns static
+        pods serving the API and ingress virtual IPs and the internal DNS of the
+        machines of the selected pools, on the baremetal, oVirt and vSphere IPI
+        platforms.
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: OnPremNetworkingSpec defines the desired state of OnPremNetworking
+          type: object
+          properties:
+            coreDNS:
+              description: coreDNS configures the coredns static pod, which resolves the
+                names of the cluster for the machines.
+              type: object
+              properties:
+                resources:
+                  description: resources are the cpu and memory requests of each
+                    container of the pod. They default to 100m of cpu and 200Mi of
+                    memory.
+                  type: object
+                  additionalProperties:
+                    anyOf:
+                    - type: integer
+                    - type: string
+                    pattern: ^(\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))))?$
+                    x-kubernetes-int-or-string: true
+            keepalived:
+              description: keepalived configures the keepalived static pod, which holds the
+                virtual IPs.
+              type: object
+              properties:
+                resources:
+                  description: resources are the cpu and memory requests of each
+                    container of the pod. They default to 100m of cpu and 200Mi of
+                    memory.
+                  type: object
+                  additionalProperties:
+                    anyOf:
+                    - type: integer
+                    - type: string
+                    pattern: ^(\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))))?$
+                    x-kubernetes-int-or-string: true
+            machineConfigPoolSelector:
+              description: A label selector is a label query over a set of resources.
+                The result of matchLabels and matchExpressions are ANDed. An empty
+                label selector matches all objects. A null label selector matches
+                no objects.
+              type: object
+              properties:
+                matchExpressions:
+                  description: matchExpressions is a list of label selector requirements.
+                    The requirements are ANDed.
+                  type: array
+                  items:
+                    description: A label selector requirement is a selector that contains
+                      values, a key, and an operator that relates the key and values.
+                    type: object
+                    required:
+                    - key
+                    - operator
+                    properties:
+                      key:
+                        description: key is the label key that the selector applies
+                          to.
+                        type: string
+                      operator:
+                        description: operator represents a key's relationship to a
+                          set of values. Valid operators are In, NotIn, Exists and
+                          DoesNotExist.
+                        type: string
+                      values:
+                        description: values is an array of string values. If the operator
+                          is In or NotIn, the values array must be non-empty. If the
+                          operator is Exists or DoesNotExist, the values array must
+                          be empty. This array is replaced during a strategic merge
+                          patch.
+                        type: array
+                        items:
+                          type: string
+                matchLabels:
+                  description: matchLabels is a map of {key,value} pairs. A single
+                    {key,value} in the matchLabels map is equivalent to an element
+                    of matchExpressions, whose key field is "key", the operator is
+                    "In", and the values array contains only "value". The requirements
+                    are ANDed.
+                  type: object
+                  additionalProperties:
+                    type: string
+        status:
+          description: OnPremNetworkingStatus defines the observed state of a
+            OnPremNetworking
+          type: object
+          properties:
+            apiVIP:
+              description: apiVIP is the virtual IP of the API server the static
+                pods serve.
+              type: string
+            conditions:
+              description: conditions represents the latest available observations
+                of current state.
+              type: array
+              items:
+                description: OnPremNetworkingCondition defines the state of the
+                  OnPremNetworking
+                type: object
+                properties:
+                  lastTransitionTime:
+                    description: lastTransitionTime is the time of the last update
+                      to the current status object.
+                    type: string
+                    format: date-time
+                    nullable: true
+                  message:
+                    description: message provides additional information about the
+                      current condition. This is only to be consumed by humans.
+                    type: string
+                  reason:
+                    description: reason is the reason for the condition's last transition.  Reasons
+                      are PascalCase
+                    type: string
+                  status:
+                    description: status of the condition, one of True, False, Unknown.
+                    type: string
+                  type:
+                    description: type specifies the state of the operator's reconciliation
+                      functionality.
+                    type: string
+            ingressVIP:
+              description: ingressVIP is the virtual IP of the ingress the static
+                pods serve.
+              type: string
+            observedGeneration:
+              description: observedGeneration represents the generation observed by
+                the controller.
+              type: integer
+              format: int64
+            platform:
+              description: platform is the platform the static pods were last rendered
+                for.
+              type: string
+            staticPods:
+              description: staticPods are the paths of the static pod manifests
+                rendered.
+              type: array
+              items:
+                type: string
+`)
+
+func manifestsOnpremnetworkingCrdYamlBytes() ([]byte, error) {
+	return _manifestsOnpremnetworkingCrdYaml, nil
+}
+
+func manifestsOnpremnetworkingCrdYaml() (*asset, error) {
+	bytes, err := manifestsOnpremnetworkingCrdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/onpremnetworking.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsOpenstackCorednsCorefileTmpl = []byte(`. {
+    errors
+    health :18080
+    mdns {{ .ControllerConfig.EtcdDiscoveryDomain }} {{`+"`"+`{{.Cluster.MasterAmount}}`+"`"+`}} {{`+"`"+`{{.Cluster.Name}}`+"`"+`}}
+    forward . {{`+"`"+`{{- range $upstream := .DNSUpstreams}} {{$upstream}}{{- end}}`+"`"+`}} {
+        policy sequential
+    }
+    cache 30
+    reload
+    hosts /etc/coredns/api-int.hosts {{ .ControllerConfig.EtcdDiscoveryDomain }} {
+        {{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.APIServerInternalIP }} api-int.{{ .ControllerConfig.EtcdDiscoveryDomain }} api.{{ .ControllerConfig.EtcdDiscoveryDomain }}
+        fallthrough
+    }
+}
+`)
+
+func manifestsOpenstackCorednsCorefileTmplBytes() ([]byte, error) {
+	return _manifestsOpenstackCorednsCorefileTmpl, nil
+}
+
+func manifestsOpenstackCorednsCorefileTmpl() (*asset, error) {
+	bytes, err := manifestsOpenstackCorednsCorefileTmplBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/openstack/coredns-corefile.tmpl", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsOpenstackCorednsYaml = []byte(`---
+kind: Pod
+apiVersion: v1
+metadata:
+  name: coredns
+  namespace: openshift-openstack-infra
+  creationTimestamp:
+  deletionGracePeriodSeconds: 65
+  labels:
+    app: openstack-infra-mdns
+spec:
+  volumes:
+  - name: resource-dir
+    hostPath:
+      path: "/etc/kubernetes/static-pod-resources/coredns"
+  - name: kubeconfig
+    hostPath:
+      path: "/etc/kubernetes/kubeconfig"
+  - name: conf-dir
+    empty-dir: {}
+  - name: manifests
+    hostPath:
+      path: "/opt/openshift/manifests"
+  initContainers:
+  - name: render-config
+    image: {{ .Images.BaremetalRuntimeCfgBootstrap }}
+    command:
+    - runtimecfg
+    - render
+    - "/etc/kubernetes/kubeconfig"
+    - "--api-vip"
+    - "{{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.APIServerInternalIP }}"
+    - "--dns-vip"
+    - "{{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.NodeDNSIP }}"
+    - "--ingress-vip"
+    - "{{ .ControllerConfig.Infra.Status.PlatformStatus.OpenStack.IngressIP }}"
+    - "/config"
+    - "--out-dir"
+    - "/etc/coredns"
+    - "--cluster-config"
+    - "/opt/openshift/manifests/cluster-config.yaml"
+    resources: {}
+    volumeMounts:
+    - name: kubeconfig
+      mountPath: "/etc/kubernetes/kubeconfig"
+    - name: resource-dir
+      mountPath: "/config"
+    - name: conf-dir
+      mountPath: "/etc/coredns"
+    - name: manifests
+      mountPath: "/opt/openshift/manifests"
+    imagePullPolicy: IfNotPresent
+  containers:
+  - name: coredns
+    securityContext:
+      privileged: true
+    image: {{ .Images.CorednsBootstrap }}
+    args:
+    - "--conf"
+    - "/etc/coredns/Corefile"
+    resources:
+      requests:
+        cpu: 100m
+        memory: 200Mi
+    volumeMounts:
+    - name: conf-dir
+      mountPath: "/etc/coredns"
+    readinessProbe:
+      httpGet:
+        path: /health
+        port: 18080
+        scheme: HTTP
+      initialDelaySeconds: 10
+      periodSeconds: 10
+      successThreshold: 1
+      failureThreshold: 3
+      timeoutSeconds: 10
+    livenessProbe:
+      httpGet:
+        path: /health
+        port: 18080
+        scheme: HTTP
+      initialDelaySeconds: 60
+      timeoutSeconds: 5
+      successThreshold: 1
+      failureThreshold: 5
+    terminationMessagePolicy: FallbackToLogsOnError
+  hostNetwork: true
+  tolerations:
+  - operator: Exists
+  priorityClassName: system-node-critical
+status: {}
+`)
+
+func manifestsOpenstackCorednsYamlBytes() ([]byte, error) {
+	return _manifestsOpenstackCorednsYaml, nil
+}
+
+func manifestsOpenstackCorednsYaml() (*asset, error) {
+	bytes, err := manifestsOpenstackCorednsYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/openstack/coredns.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _manifestsOpenstackKeepalivedConfTmpl = []byte(`# Configuration template for Keepalived, which is used to manage the DNS and
+# API VIPs.
+#
+# For more information, see installer/data/data/bootstrap/baremetal/README.md
+# in the installer repo.
+
 vrrp_instance {{`+"`"+`{{.Cluster.Name}}`+"`"+`}}_API {
     state BACKUP
     interface {{`+"`"+`{{.VRRPInterface}}`+"`"+`}}
@@ -3423,6 +5266,191 @@ func manifestsOvirtKeepalivedYaml() (*asset, error) {
 	return a, nil
 }
 
+var _manifestsTimesyncCrdYaml = []byte(`apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: timesyncs.machineconfiguration.openshift.io
+  labels:
+    "openshift.io/operator-managed": ""
+spec:
+  group: machineconfiguration.openshift.io
+  names:
+    kind: TimeSync
+    listKind: TimeSyncList
+    plural: timesyncs
+    singular: timesync
+  scope: Cluster
+  preserveUnknownFields: false
+  subresources:
+    status: {}
+  versions:
+  - name: v1
+    served: true
+    storage: true
+  "validation":
+    "openAPIV3Schema":
+      description: TimeSync describes the NTP sources chrony synchronizes the
+        clock of the machines of the selected pools with.
+      type: object
+      properties:
+        apiVersion:
+          description: 'APIVersion defines the versioned schema of this representation
+            of an object. Servers should convert recognized schemas to the latest
+            internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+          type: string
+        kind:
+          description: 'Kind is a string value representing the REST resource this
+            object represents. Servers may infer this from the endpoint the client
+            submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+          type: string
+        metadata:
+          type: object
+        spec:
+          description: TimeSyncSpec defines the desired state of TimeSync
+          type: object
+          properties:
+            machineConfigPoolSelector:
+              description: A label selector is a label query over a set of resources.
+                The result of matchLabels and matchExpressions are ANDed. An empty
+                label selector matches all objects. A null label selector matches
+                no objects.
+              type: object
+              properties:
+                matchExpressions:
+                  description: matchExpressions is a list of label selector requirements.
+                    The requirements are ANDed.
+                  type: array
+                  items:
+                    description: A label selector requirement is a selector that contains
+                      values, a key, and an operator that relates the key and values.
+                    type: object
+                    required:
+                    - key
+                    - operator
+                    properties:
+                      key:
+                        description: key is the label key that the selector applies
+                          to.
+                        type: string
+                      operator:
+                        description: operator represents a key's relationship to a
+                          set of values. Valid operators are In, NotIn, Exists and
+                          DoesNotExist.
+                        type: string
+                      values:
+                        description: values is an array of string values. If the operator
+                          is In or NotIn, the values array must be non-empty. If the
+                          operator is Exists or DoesNotExist, the values array must
+                          be empty. This array is replaced during a strategic merge
+                          patch.
+                        type: array
+                        items:
+                          type: string
+                matchLabels:
+                  description: matchLabels is a map of {key,value} pairs. A single
+                    {key,value} in the matchLabels map is equivalent to an element
+                    of matchExpressions, whose key field is "key", the operator is
+                    "In", and the values array contains only "value". The requirements
+                    are ANDed.
+                  type: object
+                  additionalProperties:
+                    type: string
+            pools:
+              description: pools are the NTP pools to synchronize with, whose names
+                resolve to several servers, e.g. 2.rhel.pool.ntp.org.
+              type: array
+              items:
+                description: TimeSource is an NTP server or pool.
+                type: object
+                required:
+                - address
+                properties:
+                  address:
+                    description: address is the host name or IP address of the server
+                      or pool.
+                    type: string
+                  options:
+                    description: options are chrony options of the source, e.g.
+                      iburst, prefer or maxpoll 6. Only the options which don't refer
+                      to files on the machines are allowed.
+                    type: array
+                    items:
+                      type: string
+            servers:
+              description: servers are the NTP servers to synchronize with.
+              type: array
+              items:
+                description: TimeSource is an NTP server or pool.
+                type: object
+                required:
+                - address
+                properties:
+                  address:
+                    description: address is the host name or IP address of the server
+                      or pool.
+                    type: string
+                  options:
+                    description: options are chrony options of the source, e.g.
+                      iburst, prefer or maxpoll 6. Only the options which don't refer
+                      to files on the machines are allowed.
+                    type: array
+                    items:
+                      type: string
+        status:
+          description: TimeSyncStatus defines the observed state of a TimeSync
+          type: object
+          properties:
+            conditions:
+              description: conditions represents the latest available observations
+                of current state.
+              type: array
+              items:
+                description: TimeSyncCondition defines the state of the TimeSync
+                type: object
+                properties:
+                  lastTransitionTime:
+                    description: lastTransitionTime is the time of the last update
+                      to the current status object.
+                    type: string
+                    format: date-time
+                    nullable: true
+                  message:
+                    description: message provides additional information about the
+                      current condition. This is only to be consumed by humans.
+                    type: string
+                  reason:
+                    description: reason is the reason for the condition's last transition.  Reasons
+                      are PascalCase
+                    type: string
+                  status:
+                    description: status of the condition, one of True, False, Unknown.
+                    type: string
+                  type:
+                    description: type specifies the state of the operator's reconciliation
+                      functionality.
+                    type: string
+            observedGeneration:
+              description: observedGeneration represents the generation observed by
+                the controller.
+              type: integer
+              format: int64
+`)
+
+func manifestsTimesyncCrdYamlBytes() ([]byte, error) {
+	return _manifestsTimesyncCrdYaml, nil
+}
+
+func manifestsTimesyncCrdYaml() (*asset, error) {
+	bytes, err := manifestsTimesyncCrdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "manifests/timesync.crd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _manifestsVsphereCorednsCorefileTmpl = []byte(`. {
     errors
     health :18080
@@ -3782,6 +5810,7 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"manifests/acceleratorconfig.crd.yaml":                                   manifestsAcceleratorconfigCrdYaml,
 	"manifests/baremetal/coredns-corefile.tmpl":                              manifestsBaremetalCorednsCorefileTmpl,
 	"manifests/baremetal/coredns.yaml":                                       manifestsBaremetalCorednsYaml,
 	"manifests/baremetal/keepalived.conf.tmpl":                               manifestsBaremetalKeepalivedConfTmpl,
@@ -3789,6 +5818,7 @@ var _bindata = map[string]func() (*asset, error){
 	"manifests/bootstrap-pod-v2.yaml":                                        manifestsBootstrapPodV2Yaml,
 	"manifests/containerruntimeconfig.crd.yaml":                              manifestsContainerruntimeconfigCrdYaml,
 	"manifests/controllerconfig.crd.yaml":                                    manifestsControllerconfigCrdYaml,
+	"manifests/imagepolicy.crd.yaml":                                         manifestsImagepolicyCrdYaml,
 	"manifests/kubeletconfig.crd.yaml":                                       manifestsKubeletconfigCrdYaml,
 	"manifests/machineconfig.crd.yaml":                                       manifestsMachineconfigCrdYaml,
 	"manifests/machineconfigcontroller/clusterrole.yaml":                     manifestsMachineconfigcontrollerClusterroleYaml,
@@ -3796,6 +5826,8 @@ var _bindata = map[string]func() (*asset, error){
 	"manifests/machineconfigcontroller/controllerconfig.yaml":                manifestsMachineconfigcontrollerControllerconfigYaml,
 	"manifests/machineconfigcontroller/deployment.yaml":                      manifestsMachineconfigcontrollerDeploymentYaml,
 	"manifests/machineconfigcontroller/sa.yaml":                              manifestsMachineconfigcontrollerSaYaml,
+	"manifests/machineconfigcontroller/service.yaml":                         manifestsMachineconfigcontrollerServiceYaml,
+	"manifests/machineconfigcontroller/servicemonitor.yaml":                  manifestsMachineconfigcontrollerServicemonitorYaml,
 	"manifests/machineconfigdaemon/clusterrole.yaml":                         manifestsMachineconfigdaemonClusterroleYaml,
 	"manifests/machineconfigdaemon/clusterrolebinding.yaml":                  manifestsMachineconfigdaemonClusterrolebindingYaml,
 	"manifests/machineconfigdaemon/cookie-secret.yaml":                       manifestsMachineconfigdaemonCookieSecretYaml,
@@ -3815,6 +5847,10 @@ var _bindata = map[string]func() (*asset, error){
 	"manifests/machineconfigserver/node-bootstrapper-token.yaml":             manifestsMachineconfigserverNodeBootstrapperTokenYaml,
 	"manifests/machineconfigserver/sa.yaml":                                  manifestsMachineconfigserverSaYaml,
 	"manifests/master.machineconfigpool.yaml":                                manifestsMasterMachineconfigpoolYaml,
+	"manifests/nodemachineconfigstate.crd.yaml":                              manifestsNodemachineconfigstateCrdYaml,
+	"manifests/nodesshkeyset.crd.yaml":                                       manifestsNodesshkeysetCrdYaml,
+	"manifests/nodetuningconfig.crd.yaml":                                    manifestsNodetuningconfigCrdYaml,
+	"manifests/onpremnetworking.crd.yaml":                                    manifestsOnpremnetworkingCrdYaml,
 	"manifests/openstack/coredns-corefile.tmpl":                              manifestsOpenstackCorednsCorefileTmpl,
 	"manifests/openstack/coredns.yaml":                                       manifestsOpenstackCorednsYaml,
 	"manifests/openstack/keepalived.conf.tmpl":                               manifestsOpenstackKeepalivedConfTmpl,
@@ -3823,6 +5859,7 @@ var _bindata = map[string]func() (*asset, error){
 	"manifests/ovirt/coredns.yaml":                                           manifestsOvirtCorednsYaml,
 	"manifests/ovirt/keepalived.conf.tmpl":                                   manifestsOvirtKeepalivedConfTmpl,
 	"manifests/ovirt/keepalived.yaml":                                        manifestsOvirtKeepalivedYaml,
+	"manifests/timesync.crd.yaml":                                            manifestsTimesyncCrdYaml,
 	"manifests/vsphere/coredns-corefile.tmpl":                                manifestsVsphereCorednsCorefileTmpl,
 	"manifests/vsphere/coredns.yaml":                                         manifestsVsphereCorednsYaml,
 	"manifests/vsphere/keepalived.conf.tmpl":                                 manifestsVsphereKeepalivedConfTmpl,
@@ -3872,6 +5909,7 @@ type bintree struct {
 
 var _bintree = &bintree{nil, map[string]*bintree{
 	"manifests": &bintree{nil, map[string]*bintree{
+		"acceleratorconfig.crd.yaml": &bintree{manifestsAcceleratorconfigCrdYaml, map[string]*bintree{}},
 		"baremetal": &bintree{nil, map[string]*bintree{
 			"coredns-corefile.tmpl": &bintree{manifestsBaremetalCorednsCorefileTmpl, map[string]*bintree{}},
 			"coredns.yaml":          &bintree{manifestsBaremetalCorednsYaml, map[string]*bintree{}},
@@ -3881,6 +5919,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"bootstrap-pod-v2.yaml":           &bintree{manifestsBootstrapPodV2Yaml, map[string]*bintree{}},
 		"containerruntimeconfig.crd.yaml": &bintree{manifestsContainerruntimeconfigCrdYaml, map[string]*bintree{}},
 		"controllerconfig.crd.yaml":       &bintree{manifestsControllerconfigCrdYaml, map[string]*bintree{}},
+		"imagepolicy.crd.yaml":            &bintree{manifestsImagepolicyCrdYaml, map[string]*bintree{}},
 		"kubeletconfig.crd.yaml":          &bintree{manifestsKubeletconfigCrdYaml, map[string]*bintree{}},
 		"machineconfig.crd.yaml":          &bintree{manifestsMachineconfigCrdYaml, map[string]*bintree{}},
 		"machineconfigcontroller": &bintree{nil, map[string]*bintree{
@@ -3889,6 +5928,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"controllerconfig.yaml":   &bintree{manifestsMachineconfigcontrollerControllerconfigYaml, map[string]*bintree{}},
 			"deployment.yaml":         &bintree{manifestsMachineconfigcontrollerDeploymentYaml, map[string]*bintree{}},
 			"sa.yaml":                 &bintree{manifestsMachineconfigcontrollerSaYaml, map[string]*bintree{}},
+			"service.yaml":            &bintree{manifestsMachineconfigcontrollerServiceYaml, map[string]*bintree{}},
+			"servicemonitor.yaml":     &bintree{manifestsMachineconfigcontrollerServicemonitorYaml, map[string]*bintree{}},
 		}},
 		"machineconfigdaemon": &bintree{nil, map[string]*bintree{
 			"clusterrole.yaml":                &bintree{manifestsMachineconfigdaemonClusterroleYaml, map[string]*bintree{}},
@@ -3912,7 +5953,11 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"node-bootstrapper-token.yaml":             &bintree{manifestsMachineconfigserverNodeBootstrapperTokenYaml, map[string]*bintree{}},
 			"sa.yaml":                                  &bintree{manifestsMachineconfigserverSaYaml, map[string]*bintree{}},
 		}},
-		"master.machineconfigpool.yaml": &bintree{manifestsMasterMachineconfigpoolYaml, map[string]*bintree{}},
+		"master.machineconfigpool.yaml":   &bintree{manifestsMasterMachineconfigpoolYaml, map[string]*bintree{}},
+		"nodemachineconfigstate.crd.yaml": &bintree{manifestsNodemachineconfigstateCrdYaml, map[string]*bintree{}},
+		"nodesshkeyset.crd.yaml":          &bintree{manifestsNodesshkeysetCrdYaml, map[string]*bintree{}},
+		"nodetuningconfig.crd.yaml":       &bintree{manifestsNodetuningconfigCrdYaml, map[string]*bintree{}},
+		"onpremnetworking.crd.yaml":       &bintree{manifestsOnpremnetworkingCrdYaml, map[string]*bintree{}},
 		"openstack": &bintree{nil, map[string]*bintree{
 			"coredns-corefile.tmpl": &bintree{manifestsOpenstackCorednsCorefileTmpl, map[string]*bintree{}},
 			"coredns.yaml":          &bintree{manifestsOpenstackCorednsYaml, map[string]*bintree{}},
@@ -3925,6 +5970,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 			"keepalived.conf.tmpl":  &bintree{manifestsOvirtKeepalivedConfTmpl, map[string]*bintree{}},
 			"keepalived.yaml":       &bintree{manifestsOvirtKeepalivedYaml, map[string]*bintree{}},
 		}},
+		"timesync.crd.yaml": &bintree{manifestsTimesyncCrdYaml, map[string]*bintree{}},
 		"vsphere": &bintree{nil, map[string]*bintree{
 			"coredns-corefile.tmpl": &bintree{manifestsVsphereCorednsCorefileTmpl, map[string]*bintree{}},
 			"coredns.yaml":          &bintree{manifestsVsphereCorednsYaml, map[string]*bintree{}},
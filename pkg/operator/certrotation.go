@@ -0,0 +1,276 @@
+package operator
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// mcsCASecretName is the secret holding the CA signing the machine-config-server
+	// serving certificate, and the bundle of the CAs still trusted.
+	mcsCASecretName = "machine-config-server-ca"
+	// mcsTLSSecretName is the secret holding the machine-config-server serving certificate.
+	mcsTLSSecretName = "machine-config-server-tls"
+	// caBundleKey is the key of the bundle of trusted CAs in the mcsCASecretName secret.
+	caBundleKey = "ca-bundle.crt"
+
+	mcsCAValidity          = 10 * 365 * 24 * time.Hour
+	mcsServingCertValidity = 365 * 24 * time.Hour
+	// certificates are renewed once this fraction of their validity elapsed.
+	certRenewalFraction = 0.8
+	// mcsCAPropagationGrace is how long the pointer Ignition configs have to trust a
+	// new CA before the serving certificate is signed by it, so that machines being
+	// provisioned with the previous pointer Ignition can still fetch their config.
+	mcsCAPropagationGrace = time.Hour
+
+	// servingCertHashAnnotationKey is set on the machine-config-server pods to the hash
+	// of the serving certificate, so that they're restarted when it's rotated.
+	servingCertHashAnnotationKey = "machineconfiguration.openshift.io/serving-cert-hash"
+)
+
+// syncMachineConfigServerCerts rotates the CA and the serving certificate of the
+// machine-config-server, making sure the pointer Ignition configs trust the CA first.
+// It also keeps the MachineConfigServer endpoint of the pointer Ignition configs
+// up to date.
+func (optr *Operator) syncMachineConfigServerCerts(config *renderConfig) error {
+	now := time.Now()
+	ca, key, bundle, err := optr.ensureMCSCA(now)
+	if err != nil {
+		return err
+	}
+	rootCA, err := optr.getCAsFromConfigMap("kube-system", "root-ca", "ca.crt")
+	if err != nil {
+		return err
+	}
+	// keep trusting the root CA, which signed the serving certificate created by the installer
+	trustBundle := append(append([]byte{}, bundle...), rootCA...)
+	propagated, err := optr.syncUserDataSecrets(config, trustBundle, ca)
+	if err != nil {
+		return err
+	}
+	return optr.ensureMCSServingCert(ca, key, now, propagated)
+}
+
+// ensureMCSCA returns the current CA of the machine-config-server and the bundle of
+// trusted CAs, generating a new CA when there's none or it's due for renewal.
+func (optr *Operator) ensureMCSCA(now time.Time) (*x509.Certificate, *rsa.PrivateKey, []byte, error) {
+	secret, err := optr.kubeClient.CoreV1().Secrets(optr.namespace).Get(context.TODO(), mcsCASecretName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, nil, nil, err
+	}
+	exists := err == nil
+	var bundle []byte
+	if exists {
+		ca, key, err := parseCertAndKey(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err == nil && !needsRenewal(ca, now) {
+			return ca, key, secret.Data[caBundleKey], nil
+		}
+		if err != nil {
+			glog.Warningf("Regenerating invalid machine-config-server CA: %v", err)
+		}
+		bundle = secret.Data[caBundleKey]
+	}
+
+	ca, key, err := newMCSCA(now)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caPEM := encodeCertPEM(ca)
+	// the new CA comes first, followed by the previous ones until they expire
+	newBundle := append([]byte{}, caPEM...)
+	for _, c := range parseCertsPEM(bundle) {
+		if now.Before(c.NotAfter) {
+			newBundle = append(newBundle, encodeCertPEM(c)...)
+		}
+	}
+	required := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mcsCASecretName, Namespace: optr.namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       caPEM,
+			corev1.TLSPrivateKeyKey: encodeKeyPEM(key),
+			caBundleKey:             newBundle,
+		},
+	}
+	if !exists {
+		_, err = optr.kubeClient.CoreV1().Secrets(optr.namespace).Create(context.TODO(), required, metav1.CreateOptions{})
+	} else {
+		secret.Type = required.Type
+		secret.Data = required.Data
+		_, err = optr.kubeClient.CoreV1().Secrets(optr.namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	glog.Infof("Generated machine-config-server CA %s valid until %v", ca.Subject.CommonName, ca.NotAfter)
+	return ca, key, newBundle, nil
+}
+
+// ensureMCSServingCert renews the serving certificate of the machine-config-server
+// when it's due, or signs it with the current CA once the pointer Ignition configs
+// all trust the CA, as reported by propagated, and machines being provisioned had
+// time to pick it up.
+func (optr *Operator) ensureMCSServingCert(ca *x509.Certificate, key *rsa.PrivateKey, now time.Time, propagated bool) error {
+	secret, err := optr.kubeClient.CoreV1().Secrets(optr.namespace).Get(context.TODO(), mcsTLSSecretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	certs := parseCertsPEM(secret.Data[corev1.TLSCertKey])
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate in %s/%s", optr.namespace, mcsTLSSecretName)
+	}
+	cert := certs[0]
+	if cert.CheckSignatureFrom(ca) == nil {
+		if !needsRenewal(cert, now) {
+			return nil
+		}
+	} else if !propagated || now.Before(ca.NotBefore.Add(mcsCAPropagationGrace)) {
+		// the previous CA is renewed well before it expires, so the serving
+		// certificate it signed stays valid until the new CA is trusted
+		glog.V(4).Infof("Waiting for the machine-config-server CA %s to propagate before signing the serving certificate with it", ca.Subject.CommonName)
+		return nil
+	}
+
+	newCert, newKey, err := newMCSServingCert(cert, ca, key, now)
+	if err != nil {
+		return err
+	}
+	secret.Data[corev1.TLSCertKey] = encodeCertPEM(newCert)
+	secret.Data[corev1.TLSPrivateKeyKey] = encodeKeyPEM(newKey)
+	if _, err := optr.kubeClient.CoreV1().Secrets(optr.namespace).Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	glog.Infof("Rotated the machine-config-server serving certificate, valid until %v", newCert.NotAfter)
+	return nil
+}
+
+// servingCertHash returns the hash of the machine-config-server serving certificate.
+func (optr *Operator) servingCertHash() (string, error) {
+	secret, err := optr.kubeClient.CoreV1().Secrets(optr.namespace).Get(context.TODO(), mcsTLSSecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(secret.Data[corev1.TLSCertKey])), nil
+}
+
+// needsRenewal returns whether the certificate is past certRenewalFraction of its validity.
+func needsRenewal(cert *x509.Certificate, now time.Time) bool {
+	validity := cert.NotAfter.Sub(cert.NotBefore)
+	return now.After(cert.NotBefore.Add(time.Duration(float64(validity) * certRenewalFraction)))
+}
+
+func newMCSCA(now time.Time) (*x509.Certificate, *rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("machine-config-server-ca@%d", now.Unix())},
+		NotBefore:             now,
+		NotAfter:              now.Add(mcsCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// newMCSServingCert returns a certificate for the same names as previous signed by ca.
+func newMCSServingCert(previous, ca *x509.Certificate, caKey *rsa.PrivateKey, now time.Time) (*x509.Certificate, *rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	notAfter := now.Add(mcsServingCertValidity)
+	if notAfter.After(ca.NotAfter) {
+		notAfter = ca.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      previous.Subject,
+		DNSNames:     previous.DNSNames,
+		IPAddresses:  previous.IPAddresses,
+		NotBefore:    now,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func parseCertAndKey(certPEM, keyPEM []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	certs := parseCertsPEM(certPEM)
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("no certificate found")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no private key found")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certs[0], key, nil
+}
+
+// parseCertsPEM returns the certificates of data, skipping invalid ones.
+func parseCertsPEM(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			glog.Warningf("Skipping invalid certificate: %v", err)
+			continue
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func encodeCertPEM(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func encodeKeyPEM(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
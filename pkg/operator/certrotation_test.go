@@ -0,0 +1,99 @@
+package operator
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNeedsRenewal(t *testing.T) {
+	now := time.Now()
+	ca, _, err := newMCSCA(now)
+	require.Nil(t, err)
+
+	assert.False(t, needsRenewal(ca, now))
+	assert.False(t, needsRenewal(ca, now.Add(mcsCAValidity/2)))
+	assert.True(t, needsRenewal(ca, now.Add(mcsCAValidity*9/10)))
+}
+
+func TestEnsureMCSServingCert(t *testing.T) {
+	now := time.Now()
+	oldCA, oldKey, err := newMCSCA(now.Add(-2 * mcsCAPropagationGrace))
+	require.Nil(t, err)
+	previous := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "system:machine-config-server"},
+		DNSNames: []string{"api-int.example.com"},
+	}
+	cert, key, err := newMCSServingCert(previous, oldCA, oldKey, now.Add(-time.Hour))
+	require.Nil(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		caAge      time.Duration
+		certAge    time.Duration
+		propagated bool
+		reissued   bool
+		signedNew  bool
+	}{{
+		name:       "new CA within the grace period",
+		propagated: true,
+	}, {
+		name:  "new CA not propagated to every pool",
+		caAge: 2 * mcsCAPropagationGrace,
+	}, {
+		name:       "new CA propagated",
+		caAge:      2 * mcsCAPropagationGrace,
+		propagated: true,
+		reissued:   true,
+		signedNew:  true,
+	}, {
+		name:       "certificate due for renewal within the grace period",
+		certAge:    mcsServingCertValidity,
+		propagated: true,
+	}, {
+		name:    "certificate due for renewal before new CA propagated",
+		caAge:   2 * mcsCAPropagationGrace,
+		certAge: mcsServingCertValidity,
+	}, {
+		name:       "certificate due for renewal",
+		caAge:      2 * mcsCAPropagationGrace,
+		certAge:    mcsServingCertValidity,
+		propagated: true,
+		reissued:   true,
+		signedNew:  true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ca, caKey, err := newMCSCA(now.Add(tc.certAge - tc.caAge))
+			require.Nil(t, err)
+			existing := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: mcsTLSSecretName, Namespace: "test"},
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       encodeCertPEM(cert),
+					corev1.TLSPrivateKeyKey: encodeKeyPEM(key),
+				},
+			}
+			optr := &Operator{namespace: "test", kubeClient: fake.NewSimpleClientset(existing)}
+
+			require.Nil(t, optr.ensureMCSServingCert(ca, caKey, now.Add(tc.certAge), tc.propagated))
+
+			secret, err := optr.kubeClient.CoreV1().Secrets("test").Get(context.TODO(), mcsTLSSecretName, metav1.GetOptions{})
+			require.Nil(t, err)
+			got := parseCertsPEM(secret.Data[corev1.TLSCertKey])[0]
+			if !tc.reissued {
+				assert.Equal(t, cert.Raw, got.Raw)
+				return
+			}
+			assert.NotEqual(t, cert.Raw, got.Raw)
+			assert.Equal(t, []string{"api-int.example.com"}, got.DNSNames)
+			assert.Equal(t, tc.signedNew, got.CheckSignatureFrom(ca) == nil)
+		})
+	}
+}
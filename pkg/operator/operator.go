@@ -18,7 +18,6 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	appsinformersv1 "k8s.io/client-go/informers/apps/v1"
 	coreinformersv1 "k8s.io/client-go/informers/core/v1"
 	rbacinformersv1 "k8s.io/client-go/informers/rbac/v1"
@@ -35,6 +34,7 @@ import (
 	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
 	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
 
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	mcfgclientset "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/scheme"
 	mcfginformersv1 "github.com/openshift/machine-config-operator/pkg/generated/informers/externalversions/machineconfiguration.openshift.io/v1"
@@ -51,6 +51,14 @@ const (
 
 	// osImageConfigMapName is the name of our configmap for the osImageURL
 	osImageConfigMapName = "machine-config-osimageurl"
+
+	// operatorConfigConfigMapName is the name of the optional configmap used to tune the operator
+	operatorConfigConfigMapName = "machine-config-operator-config"
+
+	// daemonModePod runs the machine-config-daemon in its pod, the default
+	daemonModePod = "pod"
+	// daemonModeHost runs the machine-config-daemon as a host service the pod supervises
+	daemonModeHost = "host"
 )
 
 // Operator defines machince config operator.
@@ -71,19 +79,21 @@ type Operator struct {
 
 	syncHandler func(ic string) error
 
-	crdLister        apiextlistersv1beta1.CustomResourceDefinitionLister
-	mcpLister        mcfglistersv1.MachineConfigPoolLister
-	ccLister         mcfglistersv1.ControllerConfigLister
-	mcLister         mcfglistersv1.MachineConfigLister
-	deployLister     appslisterv1.DeploymentLister
-	daemonsetLister  appslisterv1.DaemonSetLister
-	infraLister      configlistersv1.InfrastructureLister
-	networkLister    configlistersv1.NetworkLister
-	mcoCmLister      corelisterv1.ConfigMapLister
-	clusterCmLister  corelisterv1.ConfigMapLister
-	proxyLister      configlistersv1.ProxyLister
-	oseKubeAPILister corelisterv1.ConfigMapLister
-	etcdLister       operatorlisterv1.EtcdLister
+	crdLister            apiextlistersv1beta1.CustomResourceDefinitionLister
+	mcpLister            mcfglistersv1.MachineConfigPoolLister
+	ccLister             mcfglistersv1.ControllerConfigLister
+	mcLister             mcfglistersv1.MachineConfigLister
+	deployLister         appslisterv1.DeploymentLister
+	daemonsetLister      appslisterv1.DaemonSetLister
+	infraLister          configlistersv1.InfrastructureLister
+	networkLister        configlistersv1.NetworkLister
+	mcoCmLister          corelisterv1.ConfigMapLister
+	clusterCmLister      corelisterv1.ConfigMapLister
+	proxyLister          configlistersv1.ProxyLister
+	nodeLister           corelisterv1.NodeLister
+	userDataSecretLister corelisterv1.SecretLister
+	oseKubeAPILister     corelisterv1.ConfigMapLister
+	etcdLister           operatorlisterv1.EtcdLister
 
 	crdListerSynced                  cache.InformerSynced
 	deployListerSynced               cache.InformerSynced
@@ -99,6 +109,8 @@ type Operator struct {
 	clusterRoleInformerSynced        cache.InformerSynced
 	clusterRoleBindingInformerSynced cache.InformerSynced
 	proxyListerSynced                cache.InformerSynced
+	nodeListerSynced                 cache.InformerSynced
+	userDataSecretListerSynced       cache.InformerSynced
 	oseKubeAPIListerSynced           cache.InformerSynced
 	etcdSynced                       cache.InformerSynced
 
@@ -127,6 +139,8 @@ func New(
 	infraInformer configinformersv1.InfrastructureInformer,
 	networkInformer configinformersv1.NetworkInformer,
 	proxyInformer configinformersv1.ProxyInformer,
+	nodeInformer coreinformersv1.NodeInformer,
+	userDataSecretInformer coreinformersv1.SecretInformer,
 	client mcfgclientset.Interface,
 	kubeClient kubernetes.Interface,
 	apiExtClient apiextclientset.Interface,
@@ -171,6 +185,12 @@ func New(
 
 	optr.syncHandler = optr.sync
 
+	// the cloud provider config is rendered into the ControllerConfig, which
+	// the nodes pick up with a kubelet restart
+	clusterCmInfomer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isKubeCloudConfig,
+		Handler:    optr.eventHandler(),
+	})
 	optr.clusterCmLister = clusterCmInfomer.Lister()
 	optr.clusterCmListerSynced = clusterCmInfomer.Informer().HasSynced
 	optr.mcpLister = mcpInformer.Lister()
@@ -181,6 +201,16 @@ func New(
 	optr.mcListerSynced = mcInformer.Informer().HasSynced
 	optr.proxyLister = proxyInformer.Lister()
 	optr.proxyListerSynced = proxyInformer.Informer().HasSynced
+	// nodes are only used to report the ones blocking the pools, don't sync on their changes
+	optr.nodeLister = nodeInformer.Lister()
+	optr.nodeListerSynced = nodeInformer.Informer().HasSynced
+	// only the user-data secrets are of interest in their namespace
+	userDataSecretInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isUserDataSecret,
+		Handler:    optr.eventHandler(),
+	})
+	optr.userDataSecretLister = userDataSecretInformer.Lister()
+	optr.userDataSecretListerSynced = userDataSecretInformer.Informer().HasSynced
 	optr.oseKubeAPILister = oseKubeAPIInformer.Lister()
 	optr.oseKubeAPIListerSynced = oseKubeAPIInformer.Informer().HasSynced
 
@@ -244,6 +274,8 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 		optr.clusterRoleBindingInformerSynced,
 		optr.networkListerSynced,
 		optr.proxyListerSynced,
+		optr.nodeListerSynced,
+		optr.userDataSecretListerSynced,
 		optr.oseKubeAPIListerSynced,
 		optr.etcdSynced) {
 		glog.Error("failed to sync caches")
@@ -267,11 +299,9 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 
 	optr.stopCh = stopCh
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(optr.worker, time.Second, stopCh)
-	}
-
-	<-stopCh
+	w := ctrlcommon.NewWorkers(stopCh)
+	w.Run(workers, optr.worker)
+	w.Wait("MachineConfigOperator", optr.queue)
 }
 
 func (optr *Operator) enqueue(obj interface{}) {
@@ -350,6 +380,7 @@ func (optr *Operator) sync(key string) error {
 		{"MachineConfigPools", optr.syncMachineConfigPools},
 		{"MachineConfigDaemon", optr.syncMachineConfigDaemon},
 		{"MachineConfigController", optr.syncMachineConfigController},
+		{"MachineConfigServerCerts", optr.syncMachineConfigServerCerts},
 		{"MachineConfigServer", optr.syncMachineConfigServer},
 		// this check must always run last since it makes sure the pools are in sync/upgrading correctly
 		{"RequiredPools", optr.syncRequiredMachineConfigPools},
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net"
+	"net/url"
 	"strings"
 	"text/template"
 
@@ -28,6 +29,8 @@ type renderConfig struct {
 	Images                 *RenderConfigImages
 	KubeAPIServerServingCA string
 	Infra                  configv1.Infrastructure
+	// DaemonMode is daemonModeHost when the machine-config-daemon runs as a host service
+	DaemonMode string
 }
 
 func renderAsset(config *renderConfig, path string) ([]byte, error) {
@@ -104,13 +107,50 @@ func createDiscoveredControllerConfigSpec(infra *configv1.Infrastructure, networ
 		if proxy.Status == (configv1.ProxyStatus{}) {
 			glog.V(2).Info("Not setting proxy config because Proxy status is empty")
 		} else {
-			ccSpec.Proxy = &proxy.Status
+			proxyStatus := proxy.Status
+			if proxyStatus.HTTPProxy != "" || proxyStatus.HTTPSProxy != "" {
+				proxyStatus.NoProxy = computeNoProxy(proxyStatus.NoProxy, infra, network)
+			}
+			ccSpec.Proxy = &proxyStatus
 		}
 	}
 
 	return ccSpec, nil
 }
 
+// computeNoProxy returns noProxy with the destinations which must never go
+// through the proxy added: the local and cluster-internal names, the internal
+// API server and the cluster and service networks.
+func computeNoProxy(noProxy string, infra *configv1.Infrastructure, network *configv1.Network) string {
+	required := []string{"localhost", "127.0.0.1", ".svc", ".cluster.local"}
+	if u, err := url.Parse(infra.Status.APIServerInternalURL); err == nil && u.Hostname() != "" {
+		required = append(required, u.Hostname())
+	}
+	// the status holds the networks actually in use, the spec only the requested ones
+	clusterNetworks, serviceNetworks := network.Status.ClusterNetwork, network.Status.ServiceNetwork
+	if len(clusterNetworks) == 0 {
+		clusterNetworks = network.Spec.ClusterNetwork
+	}
+	if len(serviceNetworks) == 0 {
+		serviceNetworks = network.Spec.ServiceNetwork
+	}
+	for _, n := range clusterNetworks {
+		required = append(required, n.CIDR)
+	}
+	required = append(required, serviceNetworks...)
+
+	var entries []string
+	seen := map[string]bool{}
+	for _, e := range append(strings.Split(noProxy, ","), required...) {
+		e = strings.TrimSpace(e)
+		if e != "" && !seen[e] {
+			seen[e] = true
+			entries = append(entries, e)
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
 func clusterDNSIP(iprange string) (string, error) {
 	_, network, err := net.ParseCIDR(iprange)
 	if err != nil {
@@ -106,6 +106,25 @@ func TestRenderAsset(t *testing.T) {
 			TargetNamespace: "testing-namespace",
 		},
 		Error: true,
+	}, {
+		// The daemon runs in its pod by default
+		Path: "manifests/machineconfigdaemon/daemonset.yaml",
+		RenderConfig: &renderConfig{
+			TargetNamespace: "testing-namespace",
+			Images:          &RenderConfigImages{},
+		},
+		FindExpected: `- "start"`,
+	}, {
+		// The pod supervises the host service of the daemon in host mode
+		Path: "manifests/machineconfigdaemon/daemonset.yaml",
+		RenderConfig: &renderConfig{
+			TargetNamespace: "testing-namespace",
+			Images:          &RenderConfigImages{},
+			APIServerURL:    "https://api-int.example.com:6443",
+			DaemonMode:      daemonModeHost,
+		},
+		FindExpected: `- "supervise"
+          - "--apiserver-url=https://api-int.example.com:6443"`,
 	}, {
 		// Bad path, will cause asset error
 		Path:  "BAD PATH",
@@ -236,3 +255,25 @@ func TestCreateDiscoveredControllerConfigSpec(t *testing.T) {
 	}
 
 }
+
+func TestComputeNoProxy(t *testing.T) {
+	infra := &configv1.Infrastructure{
+		Status: configv1.InfrastructureStatus{APIServerInternalURL: "https://api-int.tt.testing:6443"}}
+	network := &configv1.Network{
+		Spec: configv1.NetworkSpec{
+			ClusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.0.0.0/16"}},
+			ServiceNetwork: []string{"172.30.0.0/16"},
+		},
+		Status: configv1.NetworkStatus{
+			ClusterNetwork: []configv1.ClusterNetworkEntry{{CIDR: "10.128.0.0/14"}},
+		},
+	}
+
+	want := "example.com,localhost,.svc,127.0.0.1,.cluster.local,api-int.tt.testing,10.128.0.0/14,172.30.0.0/16"
+	if got := computeNoProxy("example.com, localhost,,.svc", infra, network); got != want {
+		t.Fatalf("got = %s want = %s", got, want)
+	}
+	if got := computeNoProxy(want, infra, network); got != want {
+		t.Fatalf("recomputing: got = %s want = %s", got, want)
+	}
+}
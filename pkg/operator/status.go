@@ -3,7 +3,10 @@ package operator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/golang/glog"
 	configv1 "github.com/openshift/api/config/v1"
@@ -17,6 +20,7 @@ import (
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	"github.com/openshift/machine-config-operator/pkg/version"
 )
 
@@ -179,7 +183,8 @@ func (optr *Operator) clearDegradedStatus(task string) error {
 	if degradedStatusCondition == nil {
 		return nil
 	}
-	if degradedStatusCondition.Reason != task+"Failed" {
+	// the reason is the task followed by Failed, or by the reason of a componentFailure
+	if !strings.HasPrefix(degradedStatusCondition.Reason, task) {
 		return nil
 	}
 	return optr.syncDegradedStatus(syncError{})
@@ -208,6 +213,10 @@ func (optr *Operator) syncDegradedStatus(ierr syncError) (err error) {
 			message = fmt.Sprintf("Unable to apply %s: %v", optrVersion, ierr.err.Error())
 		}
 		reason = ierr.task + "Failed"
+		var cf *componentFailure
+		if errors.As(ierr.err, &cf) {
+			reason = ierr.task + cf.reason
+		}
 
 		// set progressing
 		if cov1helpers.IsStatusConditionTrue(co.Status.Conditions, configv1.OperatorProgressing) {
@@ -314,6 +323,12 @@ func (optr *Operator) setOperatorStatusExtension(status *configv1.ClusterOperato
 	}
 	if statusErr != nil {
 		statuses["lastSyncError"] = statusErr.Error()
+		var cf *componentFailure
+		if errors.As(statusErr, &cf) {
+			statuses["failureReason"] = cf.reason
+			statuses["failingPools"] = strings.Join(cf.pools, ",")
+			statuses["blockingNodes"] = strings.Join(cf.nodes, ",")
+		}
 	}
 	raw, err := json.Marshal(statuses)
 	if err != nil {
@@ -392,6 +407,9 @@ func machineConfigPoolStatus(pool *mcfgv1.MachineConfigPool) string {
 	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolNodeDegraded):
 		cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolNodeDegraded)
 		return fmt.Sprintf("pool is degraded because nodes fail with %q: %q", cond.Reason, cond.Message)
+	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled):
+		cond := mcfgv1.GetMachineConfigPoolCondition(pool.Status, mcfgv1.MachineConfigPoolRolloutStalled)
+		return fmt.Sprintf("pool rollout is stalled with %q: %q", cond.Reason, cond.Message)
 	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdated):
 		return fmt.Sprintf("all %d nodes are at latest configuration %s", pool.Status.MachineCount, pool.Status.Configuration.Name)
 	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolUpdating):
@@ -400,3 +418,98 @@ func machineConfigPoolStatus(pool *mcfgv1.MachineConfigPool) string {
 		return "<unknown>"
 	}
 }
+
+// Reasons of the component failures blocking the required pools. They're
+// reported in the Degraded condition after the name of the failed sync task,
+// e.g. RequiredPoolsNodesDegraded.
+const (
+	// renderDegradedReason is reported when the render controller fails to render a pool.
+	renderDegradedReason = "RenderDegraded"
+	// nodesDegradedReason is reported when machine-config-daemons fail to update nodes.
+	nodesDegradedReason = "NodesDegraded"
+	// nodesUpdatingReason is reported when nodes haven't finished updating yet.
+	nodesUpdatingReason = "NodesUpdating"
+	// rolloutStalledReason is reported when no node finished updating within
+	// the progress deadline of the pool.
+	rolloutStalledReason = "RolloutStalled"
+)
+
+// componentFailure is a sync failure attributed to the failing component of the
+// MCO, along with the pools and nodes it blocks.
+type componentFailure struct {
+	reason string
+	pools  []string
+	nodes  []string
+	err    error
+}
+
+func (f *componentFailure) Error() string {
+	if len(f.nodes) == 0 {
+		return f.err.Error()
+	}
+	return fmt.Sprintf("%v (blocking nodes: %s)", f.err, strings.Join(f.nodes, ", "))
+}
+
+// poolFailure attributes the failure of pool to progress to the component
+// blocking it, listing the nodes of the pool which are blocking it.
+func (optr *Operator) poolFailure(pool *mcfgv1.MachineConfigPool, err error) *componentFailure {
+	f := &componentFailure{pools: []string{pool.Name}, err: err}
+	switch {
+	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRenderDegraded):
+		f.reason = renderDegradedReason
+		return f
+	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolNodeDegraded):
+		f.reason = nodesDegradedReason
+	case mcfgv1.IsMachineConfigPoolConditionTrue(pool.Status.Conditions, mcfgv1.MachineConfigPoolRolloutStalled):
+		f.reason = rolloutStalledReason
+	default:
+		f.reason = nodesUpdatingReason
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.NodeSelector)
+	if err != nil {
+		glog.Warningf("Invalid node selector of pool %s: %v", pool.Name, err)
+		return f
+	}
+	nodes, err := optr.nodeLister.List(selector)
+	if err != nil {
+		glog.Warningf("Failed to list the nodes of pool %s: %v", pool.Name, err)
+		return f
+	}
+	for _, node := range nodes {
+		if isNodeBlockingPool(node, pool, f.reason) {
+			f.nodes = append(f.nodes, node.Name)
+		}
+	}
+	sort.Strings(f.nodes)
+	return f
+}
+
+// isNodeBlockingPool returns whether node is blocking pool for reason: it's
+// degraded, or it's not done updating to the pool's configuration.
+func isNodeBlockingPool(node *corev1.Node, pool *mcfgv1.MachineConfigPool, reason string) bool {
+	state := node.Annotations[daemonconsts.MachineConfigDaemonStateAnnotationKey]
+	if reason == nodesDegradedReason {
+		return state == daemonconsts.MachineConfigDaemonStateDegraded || state == daemonconsts.MachineConfigDaemonStateUnreconcilable
+	}
+	return state != daemonconsts.MachineConfigDaemonStateDone ||
+		node.Annotations[daemonconsts.CurrentMachineConfigAnnotationKey] != pool.Spec.Configuration.Name
+}
+
+// mergeComponentFailures merges the failures of several pools, attributing
+// them to the most severe reason among them.
+func mergeComponentFailures(failures []*componentFailure) *componentFailure {
+	severity := map[string]int{nodesUpdatingReason: 0, rolloutStalledReason: 1, nodesDegradedReason: 2, renderDegradedReason: 3}
+	merged := &componentFailure{reason: failures[0].reason}
+	var msgs []string
+	for _, f := range failures {
+		if severity[f.reason] > severity[merged.reason] {
+			merged.reason = f.reason
+		}
+		merged.pools = append(merged.pools, f.pools...)
+		merged.nodes = append(merged.nodes, f.nodes...)
+		msgs = append(msgs, f.err.Error())
+	}
+	merged.err = errors.New(strings.Join(msgs, "; "))
+	return merged
+}
@@ -10,6 +10,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/stretchr/testify/assert"
@@ -20,6 +22,7 @@ import (
 	cov1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
 	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 )
 
 func TestIsMachineConfigPoolConfigurationValid(t *testing.T) {
@@ -600,3 +603,92 @@ func TestInClusterBringUpStayOnErr(t *testing.T) {
 
 	assert.False(t, optr.inClusterBringup)
 }
+
+func TestPoolFailure(t *testing.T) {
+	newNode := func(name, role, state, current string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"node-role/" + role: ""},
+				Annotations: map[string]string{
+					daemonconsts.MachineConfigDaemonStateAnnotationKey: state,
+					daemonconsts.CurrentMachineConfigAnnotationKey:     current,
+				},
+			},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range []*corev1.Node{
+		newNode("node-a", "worker", daemonconsts.MachineConfigDaemonStateDone, "rendered-worker-2"),
+		newNode("node-b", "worker", daemonconsts.MachineConfigDaemonStateWorking, "rendered-worker-1"),
+		newNode("node-c", "worker", daemonconsts.MachineConfigDaemonStateDegraded, "rendered-worker-1"),
+		newNode("node-d", "master", daemonconsts.MachineConfigDaemonStateDegraded, "rendered-master-1"),
+	} {
+		indexer.Add(node)
+	}
+	optr := &Operator{nodeLister: corelisterv1.NewNodeLister(indexer)}
+
+	newPool := func(condition mcfgv1.MachineConfigPoolConditionType) *mcfgv1.MachineConfigPool {
+		pool := &mcfgv1.MachineConfigPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+			Spec: mcfgv1.MachineConfigPoolSpec{
+				NodeSelector:  metav1.AddLabelToSelector(&metav1.LabelSelector{}, "node-role/worker", ""),
+				Configuration: mcfgv1.MachineConfigPoolStatusConfiguration{ObjectReference: corev1.ObjectReference{Name: "rendered-worker-2"}},
+			},
+		}
+		mcfgv1.SetMachineConfigPoolCondition(&pool.Status, *mcfgv1.NewMachineConfigPoolCondition(condition, corev1.ConditionTrue, "", ""))
+		return pool
+	}
+
+	tests := []struct {
+		condition mcfgv1.MachineConfigPoolConditionType
+		reason    string
+		nodes     []string
+	}{
+		{mcfgv1.MachineConfigPoolUpdating, nodesUpdatingReason, []string{"node-b", "node-c"}},
+		{mcfgv1.MachineConfigPoolRolloutStalled, rolloutStalledReason, []string{"node-b", "node-c"}},
+		{mcfgv1.MachineConfigPoolNodeDegraded, nodesDegradedReason, []string{"node-c"}},
+		{mcfgv1.MachineConfigPoolRenderDegraded, renderDegradedReason, nil},
+	}
+	for _, test := range tests {
+		f := optr.poolFailure(newPool(test.condition), errors.New("pool worker is not ready"))
+		assert.Equal(t, test.reason, f.reason)
+		assert.Equal(t, []string{"worker"}, f.pools)
+		assert.Equal(t, test.nodes, f.nodes)
+	}
+
+	merged := mergeComponentFailures([]*componentFailure{
+		{reason: nodesUpdatingReason, pools: []string{"infra"}, err: errors.New("pool infra is not ready")},
+		{reason: nodesDegradedReason, pools: []string{"worker"}, nodes: []string{"node-c"}, err: errors.New("pool worker is not ready")},
+	})
+	assert.Equal(t, nodesDegradedReason, merged.reason)
+	assert.Equal(t, []string{"infra", "worker"}, merged.pools)
+	assert.Equal(t, "pool infra is not ready; pool worker is not ready (blocking nodes: node-c)", merged.Error())
+}
+
+func TestSyncDegradedStatusComponentFailure(t *testing.T) {
+	optr := &Operator{
+		eventRecorder: &record.FakeRecorder{},
+		name:          "test",
+		mcpLister:     &mockMCPLister{},
+	}
+	optr.vStore = newVersionStore()
+	optr.vStore.Set("operator", "test-version")
+	optr.configClient = fakeconfigclientset.NewSimpleClientset(&configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: "test"}})
+
+	cf := &componentFailure{reason: nodesDegradedReason, pools: []string{"worker"}, nodes: []string{"node-c"}, err: errors.New("pool worker is not ready")}
+	err := optr.syncDegradedStatus(syncError{task: "RequiredPools", err: fmt.Errorf("timed out during syncRequiredMachineConfigPools: %w", cf)})
+	assert.Nil(t, err)
+	co, err := optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), "test", metav1.GetOptions{})
+	assert.Nil(t, err)
+	cond := cov1helpers.FindStatusCondition(co.Status.Conditions, configv1.OperatorDegraded)
+	assert.Equal(t, configv1.ConditionTrue, cond.Status)
+	assert.Equal(t, "RequiredPoolsNodesDegraded", cond.Reason)
+	assert.Contains(t, cond.Message, "blocking nodes: node-c")
+
+	// clearing the RequiredPools task clears its component failure
+	assert.Nil(t, optr.clearDegradedStatus("RequiredPools"))
+	co, err = optr.configClient.ConfigV1().ClusterOperators().Get(context.TODO(), "test", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, cov1helpers.IsStatusConditionFalse(co.Status.Conditions, configv1.OperatorDegraded))
+}
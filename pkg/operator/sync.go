@@ -8,17 +8,24 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 
@@ -27,6 +34,7 @@ import (
 	"github.com/openshift/machine-config-operator/lib/resourceapply"
 	"github.com/openshift/machine-config-operator/lib/resourceread"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	templatectrl "github.com/openshift/machine-config-operator/pkg/controller/template"
 	"github.com/openshift/machine-config-operator/pkg/operator/assets"
 	"github.com/openshift/machine-config-operator/pkg/version"
@@ -34,6 +42,12 @@ import (
 
 const (
 	requiredForUpgradeMachineConfigPoolLabelKey = "operator.machineconfiguration.openshift.io/required-for-upgrade"
+
+	// clusterConfigNamespace and clusterConfigName are the ConfigMap holding the
+	// install-config of the cluster, in its installConfigKey.
+	clusterConfigNamespace = "kube-system"
+	clusterConfigName      = "cluster-config-v1"
+	installConfigKey       = "install-config"
 )
 
 type syncFunc struct {
@@ -111,6 +125,16 @@ func isCloudConfigRequired(infra *configv1.Infrastructure) bool {
 	return false
 }
 
+// isKubeCloudConfig returns whether obj is the openshift-config-managed/kube-cloud-config
+// ConfigMap, whose changes are rendered into the ControllerConfig right away.
+func isKubeCloudConfig(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cm, ok := obj.(*corev1.ConfigMap)
+	return ok && cm.Namespace == "openshift-config-managed" && cm.Name == "kube-cloud-config"
+}
+
 // Sync cloud config on supported platform from cloud.conf available in openshift-config-managed/kube-cloud-config ConfigMap.
 func (optr *Operator) syncCloudConfig(spec *mcfgv1.ControllerConfigSpec, infra *configv1.Infrastructure) error {
 	if _, err := optr.clusterCmLister.ConfigMaps("openshift-config-managed").Get("kube-cloud-config"); err != nil {
@@ -265,6 +289,38 @@ func (optr *Operator) syncRenderConfig(_ *renderConfig) error {
 	spec.RootCAData = bundle
 	spec.PullSecret = &corev1.ObjectReference{Namespace: "openshift-config", Name: "pull-secret"}
 	spec.OSImageURL = imgs.MachineOSContent
+	spec.RenderedConfigHistoryLimit, err = optr.getRenderedConfigHistoryLimit(optr.namespace)
+	if err != nil {
+		return err
+	}
+	spec.DrainPolicy, err = optr.getDrainPolicy(optr.namespace)
+	if err != nil {
+		return err
+	}
+	spec.NodeDisruptionPolicy, err = optr.getNodeDisruptionPolicy(optr.namespace)
+	if err != nil {
+		return err
+	}
+	spec.Tuning, err = optr.getControllerTuning(optr.namespace)
+	if err != nil {
+		return err
+	}
+	spec.OSImageAllowList, err = optr.getOSImageAllowList()
+	if err != nil {
+		return err
+	}
+	spec.FIPS, err = optr.getFIPS()
+	if err != nil {
+		return err
+	}
+	spec.NodeDNS, err = optr.getNodeDNS(optr.namespace)
+	if err != nil {
+		return err
+	}
+	spec.TemplateOverrides, err = optr.getTemplateOverrides(optr.namespace)
+	if err != nil {
+		return err
+	}
 	spec.Images = map[string]string{
 		templatectrl.EtcdImageKey:                imgs.Etcd,
 		templatectrl.SetupEtcdEnvKey:             imgs.MachineConfigOperator,
@@ -279,8 +335,14 @@ func (optr *Operator) syncRenderConfig(_ *renderConfig) error {
 		templatectrl.BaremetalRuntimeCfgKey:      imgs.BaremetalRuntimeCfg,
 	}
 
+	daemonMode, err := optr.getDaemonMode(optr.namespace)
+	if err != nil {
+		return err
+	}
+
 	// create renderConfig
 	optr.renderConfig = getRenderConfig(optr.namespace, string(kubeAPIServerServingCABytes), spec, &imgs.RenderConfigImages, infra.Status.APIServerInternalURL)
+	optr.renderConfig.DaemonMode = daemonMode
 	return nil
 }
 
@@ -291,6 +353,13 @@ func (optr *Operator) syncCustomResourceDefinitions() error {
 		"manifests/machineconfigpool.crd.yaml",
 		"manifests/kubeletconfig.crd.yaml",
 		"manifests/containerruntimeconfig.crd.yaml",
+		"manifests/nodetuningconfig.crd.yaml",
+		"manifests/imagepolicy.crd.yaml",
+		"manifests/timesync.crd.yaml",
+		"manifests/onpremnetworking.crd.yaml",
+		"manifests/acceleratorconfig.crd.yaml",
+		"manifests/nodesshkeyset.crd.yaml",
+		"manifests/nodemachineconfigstate.crd.yaml",
 	}
 
 	for _, crd := range crds {
@@ -365,11 +434,36 @@ func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
 		return err
 	}
 
+	if err := optr.ensureProxyCookieSecret(config); err != nil {
+		return err
+	}
+
+	svcBytes, err := renderAsset(config, "manifests/machineconfigcontroller/service.yaml")
+	if err != nil {
+		return err
+	}
+	svc := resourceread.ReadServiceV1OrDie(svcBytes)
+	_, _, err = resourceapply.ApplyService(optr.kubeClient.CoreV1(), svc)
+	if err != nil {
+		return err
+	}
+
+	smBytes, err := renderAsset(config, "manifests/machineconfigcontroller/servicemonitor.yaml")
+	if err != nil {
+		return err
+	}
+	if err := optr.applyServiceMonitor(smBytes); err != nil {
+		return err
+	}
+
 	mccBytes, err := renderAsset(config, "manifests/machineconfigcontroller/deployment.yaml")
 	if err != nil {
 		return err
 	}
 	mcc := resourceread.ReadDeploymentV1OrDie(mccBytes)
+	if err := optr.applyComponentResources(&mcc.Spec.Template.Spec, "machine-config-controller"); err != nil {
+		return err
+	}
 
 	_, updated, err := resourceapply.ApplyDeployment(optr.kubeClient.AppsV1(), mcc)
 	if err != nil {
@@ -392,6 +486,65 @@ func (optr *Operator) syncMachineConfigController(config *renderConfig) error {
 	return optr.waitForControllerConfigToBeCompleted(cc)
 }
 
+// ensureProxyCookieSecret creates the cookie secret of the oauth-proxy sidecars
+// in front of the metrics of the MCC and the MCD.
+func (optr *Operator) ensureProxyCookieSecret(config *renderConfig) error {
+	// Only generate a new proxy cookie secret if the secret does not exist or if it has been deleted.
+	_, err := optr.kubeClient.CoreV1().Secrets(config.TargetNamespace).Get(context.TODO(), "cookie-secret", metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cookieSecretBytes, err := renderAsset(config, "manifests/machineconfigdaemon/cookie-secret.yaml")
+		if err != nil {
+			return err
+		}
+		cookieSecret := resourceread.ReadSecretV1OrDie(cookieSecretBytes)
+		_, _, err = resourceapply.ApplySecret(optr.kubeClient.CoreV1(), cookieSecret)
+		return err
+	}
+	return err
+}
+
+// applyServiceMonitor creates or replaces the ServiceMonitor in smBytes. There's
+// no typed client for the monitoring API, and the cluster may run without it:
+// the ServiceMonitor is skipped then.
+func (optr *Operator) applyServiceMonitor(smBytes []byte) error {
+	sm := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(smBytes, &sm.Object); err != nil {
+		return fmt.Errorf("parsing ServiceMonitor: %v", err)
+	}
+	collection := fmt.Sprintf("/apis/monitoring.coreos.com/v1/namespaces/%s/servicemonitors", sm.GetNamespace())
+	client := optr.kubeClient.Discovery().RESTClient()
+
+	body, err := json.Marshal(sm.Object)
+	if err != nil {
+		return err
+	}
+	existingBytes, err := client.Get().AbsPath(collection, sm.GetName()).DoRaw(context.TODO())
+	if apierrors.IsNotFound(err) {
+		_, err = client.Post().AbsPath(collection).Body(body).DoRaw(context.TODO())
+		if apierrors.IsNotFound(err) {
+			glog.V(4).Infof("Monitoring API isn't available, skipping ServiceMonitor %s/%s", sm.GetNamespace(), sm.GetName())
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	existing := &unstructured.Unstructured{}
+	if err := json.Unmarshal(existingBytes, &existing.Object); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(existing.Object["spec"], sm.Object["spec"]) {
+		return nil
+	}
+	sm.SetResourceVersion(existing.GetResourceVersion())
+	if body, err = json.Marshal(sm.Object); err != nil {
+		return err
+	}
+	_, err = client.Put().AbsPath(collection, sm.GetName()).Body(body).DoRaw(context.TODO())
+	return err
+}
+
 func (optr *Operator) syncMachineConfigDaemon(config *renderConfig) error {
 	for _, path := range []string{
 		"manifests/machineconfigdaemon/clusterrole.yaml",
@@ -443,19 +596,7 @@ func (optr *Operator) syncMachineConfigDaemon(config *renderConfig) error {
 		return err
 	}
 
-	// Only generate a new proxy cookie secret if the secret does not exist or if it has been deleted.
-	_, err = optr.kubeClient.CoreV1().Secrets(config.TargetNamespace).Get(context.TODO(), "cookie-secret", metav1.GetOptions{})
-	if apierrors.IsNotFound(err) {
-		cookieSecretBytes, err := renderAsset(config, "manifests/machineconfigdaemon/cookie-secret.yaml")
-		if err != nil {
-			return err
-		}
-		cookieSecret := resourceread.ReadSecretV1OrDie(cookieSecretBytes)
-		_, _, err = resourceapply.ApplySecret(optr.kubeClient.CoreV1(), cookieSecret)
-		if err != nil {
-			return err
-		}
-	} else if err != nil {
+	if err := optr.ensureProxyCookieSecret(config); err != nil {
 		return err
 	}
 
@@ -464,6 +605,9 @@ func (optr *Operator) syncMachineConfigDaemon(config *renderConfig) error {
 		return err
 	}
 	mcd := resourceread.ReadDaemonSetV1OrDie(mcdBytes)
+	if err := optr.applyComponentResources(&mcd.Spec.Template.Spec, "machine-config-daemon"); err != nil {
+		return err
+	}
 
 	_, updated, err := resourceapply.ApplyDaemonSet(optr.kubeClient.AppsV1(), mcd)
 	if err != nil {
@@ -535,6 +679,17 @@ func (optr *Operator) syncMachineConfigServer(config *renderConfig) error {
 	}
 
 	mcs := resourceread.ReadDaemonSetV1OrDie(mcsBytes)
+	if err := optr.applyComponentResources(&mcs.Spec.Template.Spec, "machine-config-server"); err != nil {
+		return err
+	}
+	certHash, err := optr.servingCertHash()
+	if err != nil {
+		return err
+	}
+	if mcs.Spec.Template.Annotations == nil {
+		mcs.Spec.Template.Annotations = map[string]string{}
+	}
+	mcs.Spec.Template.Annotations[servingCertHashAnnotationKey] = certHash
 
 	_, updated, err := resourceapply.ApplyDaemonSet(optr.kubeClient.AppsV1(), mcs)
 	if err != nil {
@@ -586,10 +741,11 @@ func (optr *Operator) syncRequiredMachineConfigPools(_ *renderConfig) error {
 			lastErr = err
 			return false, nil
 		}
+		var failures []*componentFailure
 		for _, pool := range pools {
 			if err := isMachineConfigPoolConfigurationValid(pool, version.Hash, optr.mcLister.Get); err != nil {
-				lastErr = fmt.Errorf("pool %s has not progressed to latest configuration: %v, retrying", pool.Name, err)
-				return false, nil
+				failures = append(failures, optr.poolFailure(pool, fmt.Errorf("pool %s has not progressed to latest configuration: %v, retrying", pool.Name, err)))
+				continue
 			}
 			degraded := isPoolStatusConditionTrue(pool, mcfgv1.MachineConfigPoolDegraded)
 			if pool.Generation <= pool.Status.ObservedGeneration &&
@@ -597,13 +753,16 @@ func (optr *Operator) syncRequiredMachineConfigPools(_ *renderConfig) error {
 				!degraded {
 				continue
 			}
-			lastErr = fmt.Errorf("error pool %s is not ready, retrying. Status: (pool degraded: %v total: %d, ready %d, updated: %d, unavailable: %d)", pool.Name, degraded, pool.Status.MachineCount, pool.Status.ReadyMachineCount, pool.Status.UpdatedMachineCount, pool.Status.UnavailableMachineCount)
+			failures = append(failures, optr.poolFailure(pool, fmt.Errorf("error pool %s is not ready, retrying. Status: (pool degraded: %v total: %d, ready %d, updated: %d, unavailable: %d)", pool.Name, degraded, pool.Status.MachineCount, pool.Status.ReadyMachineCount, pool.Status.UpdatedMachineCount, pool.Status.UnavailableMachineCount)))
+		}
+		if len(failures) > 0 {
+			lastErr = mergeComponentFailures(failures)
 			return false, nil
 		}
 		return true, nil
 	}); err != nil {
 		if err == wait.ErrWaitTimeout {
-			return fmt.Errorf("%v during syncRequiredMachineConfigPools: %v", err, lastErr)
+			return fmt.Errorf("%v during syncRequiredMachineConfigPools: %w", err, lastErr)
 		}
 		return err
 	}
@@ -747,6 +906,374 @@ func (optr *Operator) getOsImageURL(namespace string) (string, error) {
 	return cm.Data["osImageURL"], nil
 }
 
+// getFIPS returns whether the install-config of the cluster enables FIPS mode,
+// false when there's no install-config.
+func (optr *Operator) getFIPS() (bool, error) {
+	cm, err := optr.clusterCmLister.ConfigMaps(clusterConfigNamespace).Get(clusterConfigName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return installConfigFIPS(cm)
+}
+
+// installConfigFIPS returns whether the install-config of the cluster-config-v1
+// ConfigMap cm enables FIPS mode.
+func installConfigFIPS(cm *corev1.ConfigMap) (bool, error) {
+	var installConfig struct {
+		FIPS bool `json:"fips"`
+	}
+	if err := yaml.Unmarshal([]byte(cm.Data[installConfigKey]), &installConfig); err != nil {
+		return false, fmt.Errorf("parsing the install-config of %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	return installConfig.FIPS, nil
+}
+
+// getRenderedConfigHistoryLimit returns the renderedConfigHistoryLimit set in the operator
+// configmap, or 0 to let the controller use its default.
+func (optr *Operator) getRenderedConfigHistoryLimit(namespace string) (int32, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	value, ok := cm.Data["renderedConfigHistoryLimit"]
+	if !ok {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("configmap %s/%s: renderedConfigHistoryLimit must be a positive integer, got %q", namespace, operatorConfigConfigMapName, value)
+	}
+	return int32(limit), nil
+}
+
+// getDaemonMode returns the daemonMode set in the operator configmap, daemonModePod
+// when unset.
+func (optr *Operator) getDaemonMode(namespace string) (string, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return daemonModePod, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	value, ok := cm.Data["daemonMode"]
+	if !ok {
+		return daemonModePod, nil
+	}
+	if value != daemonModePod && value != daemonModeHost {
+		return "", fmt.Errorf("configmap %s/%s: daemonMode must be %s or %s, got %q", namespace, operatorConfigConfigMapName, daemonModePod, daemonModeHost, value)
+	}
+	return value, nil
+}
+
+// getDrainPolicy returns the drainPolicy set in the operator configmap, if any.
+func (optr *Operator) getDrainPolicy(namespace string) (*mcfgv1.DrainPolicy, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, ok := cm.Data["drainPolicy"]
+	if !ok {
+		return nil, nil
+	}
+	policy := &mcfgv1.DrainPolicy{}
+	if err := yaml.Unmarshal([]byte(value), policy); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: invalid drainPolicy: %v", namespace, operatorConfigConfigMapName, err)
+	}
+	if policy.Attempts < 0 || policy.ForceAfterAttempts < 0 {
+		return nil, fmt.Errorf("configmap %s/%s: drainPolicy attempts and forceAfterAttempts can't be negative", namespace, operatorConfigConfigMapName)
+	}
+	return policy, nil
+}
+
+// getNodeDisruptionPolicy returns the nodeDisruptionPolicy set in the operator configmap, if any.
+func (optr *Operator) getNodeDisruptionPolicy(namespace string) (*mcfgv1.NodeDisruptionPolicy, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, ok := cm.Data["nodeDisruptionPolicy"]
+	if !ok {
+		return nil, nil
+	}
+	policy := &mcfgv1.NodeDisruptionPolicy{}
+	if err := yaml.Unmarshal([]byte(value), policy); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: invalid nodeDisruptionPolicy: %v", namespace, operatorConfigConfigMapName, err)
+	}
+	for _, f := range policy.Files {
+		if !filepath.IsAbs(f.Path) {
+			return nil, fmt.Errorf("configmap %s/%s: nodeDisruptionPolicy file path %q isn't absolute", namespace, operatorConfigConfigMapName, f.Path)
+		}
+		if err := validateNodeDisruptionActions(f.Actions, true); err != nil {
+			return nil, fmt.Errorf("configmap %s/%s: nodeDisruptionPolicy file %s: %v", namespace, operatorConfigConfigMapName, f.Path, err)
+		}
+	}
+	for _, u := range policy.Units {
+		if u.Name == "" {
+			return nil, fmt.Errorf("configmap %s/%s: nodeDisruptionPolicy unit name can't be empty", namespace, operatorConfigConfigMapName)
+		}
+		if err := validateNodeDisruptionActions(u.Actions, false); err != nil {
+			return nil, fmt.Errorf("configmap %s/%s: nodeDisruptionPolicy unit %s: %v", namespace, operatorConfigConfigMapName, u.Name, err)
+		}
+	}
+	return policy, nil
+}
+
+// getControllerTuning returns the controllerTuning set in the operator configmap, if any.
+func (optr *Operator) getControllerTuning(namespace string) (*mcfgv1.ControllerTuning, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, ok := cm.Data["controllerTuning"]
+	if !ok {
+		return nil, nil
+	}
+	tuning := &mcfgv1.ControllerTuning{}
+	if err := yaml.Unmarshal([]byte(value), tuning); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: invalid controllerTuning: %v", namespace, operatorConfigConfigMapName, err)
+	}
+	if tuning.ResyncPeriod != nil && tuning.ResyncPeriod.Duration < time.Minute {
+		return nil, fmt.Errorf("configmap %s/%s: controllerTuning resyncPeriod can't be less than 1m", namespace, operatorConfigConfigMapName)
+	}
+	for _, c := range tuning.Controllers {
+		known := false
+		for _, name := range ctrlcommon.SubControllerNames {
+			if c.Name == name {
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("configmap %s/%s: controllerTuning controller %q isn't one of %s", namespace, operatorConfigConfigMapName, c.Name, strings.Join(ctrlcommon.SubControllerNames, ", "))
+		}
+		if c.Workers < 0 || c.QPS < 0 || c.Burst < 0 {
+			return nil, fmt.Errorf("configmap %s/%s: controllerTuning controller %s: workers, qps and burst can't be negative", namespace, operatorConfigConfigMapName, c.Name)
+		}
+	}
+	return tuning, nil
+}
+
+// getTemplateOverrides returns the templateOverrides set in the operator configmap, if any.
+func (optr *Operator) getTemplateOverrides(namespace string) (map[string]string, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, ok := cm.Data["templateOverrides"]
+	if !ok {
+		return nil, nil
+	}
+	overrides := map[string]string{}
+	if err := yaml.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: invalid templateOverrides: %v", namespace, operatorConfigConfigMapName, err)
+	}
+	for path := range overrides {
+		if err := templatectrl.ValidateTemplateOverridePath(path); err != nil {
+			return nil, fmt.Errorf("configmap %s/%s: templateOverrides: %v", namespace, operatorConfigConfigMapName, err)
+		}
+	}
+	return overrides, nil
+}
+
+const (
+	// maxNodeDNSNameservers and maxNodeDNSSearches are the limits of resolv.conf.
+	maxNodeDNSNameservers = 3
+	maxNodeDNSSearches    = 6
+)
+
+// getNodeDNS returns the nodeDNS set in the operator configmap, if any.
+func (optr *Operator) getNodeDNS(namespace string) (*mcfgv1.NodeDNS, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, ok := cm.Data["nodeDNS"]
+	if !ok {
+		return nil, nil
+	}
+	dns := &mcfgv1.NodeDNS{}
+	if err := yaml.Unmarshal([]byte(value), dns); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: invalid nodeDNS: %v", namespace, operatorConfigConfigMapName, err)
+	}
+	if err := validateNodeDNS(dns); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: nodeDNS %v", namespace, operatorConfigConfigMapName, err)
+	}
+	return dns, nil
+}
+
+// validateNodeDNS returns an error if dns can't be rendered into a valid
+// NetworkManager configuration.
+func validateNodeDNS(dns *mcfgv1.NodeDNS) error {
+	if len(dns.Nameservers) == 0 || len(dns.Nameservers) > maxNodeDNSNameservers {
+		return fmt.Errorf("must have between 1 and %d nameservers", maxNodeDNSNameservers)
+	}
+	for _, ns := range dns.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return fmt.Errorf("nameserver %q isn't an IP address", ns)
+		}
+	}
+	if len(dns.Searches) > maxNodeDNSSearches {
+		return fmt.Errorf("can't have more than %d searches", maxNodeDNSSearches)
+	}
+	for _, search := range dns.Searches {
+		if errs := validation.IsDNS1123Subdomain(search); len(errs) > 0 {
+			return fmt.Errorf("search %q isn't a domain name: %s", search, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+const (
+	osImageAllowListNamespace        = "openshift-config"
+	osImageAllowListConfigMapName    = "os-image-allow-list"
+	osImageAllowListKeyringNamespace = "openshift-config-managed"
+	osImageAllowListKeyringName      = "os-image-allow-list-keyring"
+)
+
+// getOSImageAllowList returns the signed OS image allow list and the keyring to
+// verify it with. The render controller verifies the signature, so that pools
+// can't be pointed at OS images which weren't signed off.
+func (optr *Operator) getOSImageAllowList() (*mcfgv1.OSImageAllowList, error) {
+	cm, err := optr.clusterCmLister.ConfigMaps(osImageAllowListNamespace).Get(osImageAllowListConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyring, err := optr.clusterCmLister.ConfigMaps(osImageAllowListKeyringNamespace).Get(osImageAllowListKeyringName)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("configmap %s/%s is set but configmap %s/%s holding its keyring doesn't exist", osImageAllowListNamespace, osImageAllowListConfigMapName, osImageAllowListKeyringNamespace, osImageAllowListKeyringName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	allowList := &mcfgv1.OSImageAllowList{
+		Images:    cm.Data["images"],
+		Signature: cm.Data["images.asc"],
+		Keyring:   keyring.Data["keyring"],
+	}
+	if allowList.Images == "" || allowList.Signature == "" {
+		return nil, fmt.Errorf("configmap %s/%s: images and images.asc are required", osImageAllowListNamespace, osImageAllowListConfigMapName)
+	}
+	if allowList.Keyring == "" {
+		return nil, fmt.Errorf("configmap %s/%s: keyring is required", osImageAllowListKeyringNamespace, osImageAllowListKeyringName)
+	}
+	return allowList, nil
+}
+
+// componentResources overrides the scheduling and resources of the pods of a
+// component deployed by the operator.
+type componentResources struct {
+	// PriorityClassName is the priority class of the pods, when set.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Containers are the resources of the containers, by name.
+	Containers map[string]corev1.ResourceRequirements `json:"containers,omitempty"`
+}
+
+// getComponentResources returns the componentResources set in the operator
+// configmap, by component name.
+func (optr *Operator) getComponentResources(namespace string) (map[string]componentResources, error) {
+	cm, err := optr.mcoCmLister.ConfigMaps(namespace).Get(operatorConfigConfigMapName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, ok := cm.Data["componentResources"]
+	if !ok {
+		return nil, nil
+	}
+	resources := map[string]componentResources{}
+	if err := yaml.Unmarshal([]byte(value), &resources); err != nil {
+		return nil, fmt.Errorf("configmap %s/%s: invalid componentResources: %v", namespace, operatorConfigConfigMapName, err)
+	}
+	for name := range resources {
+		switch name {
+		case "machine-config-controller", "machine-config-daemon", "machine-config-server":
+		default:
+			return nil, fmt.Errorf("configmap %s/%s: componentResources component %q isn't one of machine-config-controller, machine-config-daemon or machine-config-server", namespace, operatorConfigConfigMapName, name)
+		}
+	}
+	return resources, nil
+}
+
+// applyComponentResources applies the componentResources set for component in
+// the operator configmap to the pod spec rendered for it.
+func (optr *Operator) applyComponentResources(spec *corev1.PodSpec, component string) error {
+	resources, err := optr.getComponentResources(optr.namespace)
+	if err != nil {
+		return err
+	}
+	r, ok := resources[component]
+	if !ok {
+		return nil
+	}
+	if err := setComponentResources(spec, component, r); err != nil {
+		return fmt.Errorf("configmap %s/%s: invalid componentResources: %v", optr.namespace, operatorConfigConfigMapName, err)
+	}
+	return nil
+}
+
+// setComponentResources overrides the priority class and the container resources of spec.
+func setComponentResources(spec *corev1.PodSpec, component string, r componentResources) error {
+	if r.PriorityClassName != "" {
+		spec.PriorityClassName = r.PriorityClassName
+	}
+	for name, requirements := range r.Containers {
+		found := false
+		for i := range spec.Containers {
+			if spec.Containers[i].Name == name {
+				spec.Containers[i].Resources = requirements
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s has no container %s", component, name)
+		}
+	}
+	return nil
+}
+
+// validateNodeDisruptionActions makes sure the actions are known, and that the
+// reload and restart actions of files name the unit to act on.
+func validateNodeDisruptionActions(actions []mcfgv1.NodeDisruptionAction, file bool) error {
+	for _, a := range actions {
+		switch a.Type {
+		case mcfgv1.NodeDisruptionActionNone, mcfgv1.NodeDisruptionActionDrain, mcfgv1.NodeDisruptionActionReboot:
+		case mcfgv1.NodeDisruptionActionReload, mcfgv1.NodeDisruptionActionRestart:
+			if file && a.Unit == "" {
+				return fmt.Errorf("%s action needs a unit", a.Type)
+			}
+		default:
+			return fmt.Errorf("unknown action %q", a.Type)
+		}
+	}
+	return nil
+}
+
 func (optr *Operator) getCAsFromConfigMap(namespace, name, key string) ([]byte, error) {
 	cm, err := optr.clusterCmLister.ConfigMaps(namespace).Get(name)
 	if err != nil {
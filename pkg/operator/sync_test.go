@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+func TestSetComponentResources(t *testing.T) {
+	spec := &corev1.PodSpec{
+		PriorityClassName: "system-node-critical",
+		Containers: []corev1.Container{
+			{Name: "machine-config-daemon"},
+			{Name: "oauth-proxy"},
+		},
+	}
+	requirements := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+
+	err := setComponentResources(spec, "machine-config-daemon", componentResources{
+		Containers: map[string]corev1.ResourceRequirements{"machine-config-daemon": requirements},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "system-node-critical", spec.PriorityClassName)
+	assert.Equal(t, requirements, spec.Containers[0].Resources)
+	assert.Equal(t, corev1.ResourceRequirements{}, spec.Containers[1].Resources)
+
+	err = setComponentResources(spec, "machine-config-daemon", componentResources{PriorityClassName: "mco-critical"})
+	assert.Nil(t, err)
+	assert.Equal(t, "mco-critical", spec.PriorityClassName)
+
+	err = setComponentResources(spec, "machine-config-daemon", componentResources{
+		Containers: map[string]corev1.ResourceRequirements{"machine-config-server": requirements},
+	})
+	assert.EqualError(t, err, "machine-config-daemon has no container machine-config-server")
+}
+
+func TestIsKubeCloudConfig(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config-managed", Name: "kube-cloud-config"}}
+	assert.True(t, isKubeCloudConfig(cm))
+	assert.True(t, isKubeCloudConfig(cache.DeletedFinalStateUnknown{Obj: cm}))
+	assert.False(t, isKubeCloudConfig(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "kube-cloud-config"}}))
+	assert.False(t, isKubeCloudConfig(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config-managed", Name: "kube-apiserver-client-ca"}}))
+}
+
+func TestInstallConfigFIPS(t *testing.T) {
+	newClusterConfig := func(installConfig string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: clusterConfigNamespace, Name: clusterConfigName},
+			Data:       map[string]string{installConfigKey: installConfig},
+		}
+	}
+
+	fips, err := installConfigFIPS(newClusterConfig("apiVersion: v1\nbaseDomain: example.com\nfips: true\n"))
+	assert.Nil(t, err)
+	assert.True(t, fips)
+
+	fips, err = installConfigFIPS(newClusterConfig("apiVersion: v1\nbaseDomain: example.com\n"))
+	assert.Nil(t, err)
+	assert.False(t, fips)
+
+	_, err = installConfigFIPS(newClusterConfig("fips: [true"))
+	assert.NotNil(t, err)
+}
+
+func TestValidateNodeDNS(t *testing.T) {
+	for _, dns := range []mcfgv1.NodeDNS{
+		{Nameservers: []string{"10.0.0.53"}},
+		{Nameservers: []string{"10.0.0.53", "fd00::53", "10.0.1.53"}, Searches: []string{"corp.example.com", "example.com"}},
+	} {
+		assert.Nil(t, validateNodeDNS(&dns), "%+v", dns)
+	}
+	for _, dns := range []mcfgv1.NodeDNS{
+		{},
+		{Searches: []string{"corp.example.com"}},
+		{Nameservers: []string{"10.0.0.53", "10.0.1.53", "10.0.2.53", "10.0.3.53"}},
+		{Nameservers: []string{"dns.corp.example.com"}},
+		{Nameservers: []string{"10.0.0.53"}, Searches: []string{"corp example"}},
+		{Nameservers: []string{"10.0.0.53"}, Searches: []string{"a.com", "b.com", "c.com", "d.com", "e.com", "f.com", "g.com"}},
+	} {
+		assert.NotNil(t, validateNodeDNS(&dns), "%+v", dns)
+	}
+}
@@ -0,0 +1,252 @@
+package operator
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+)
+
+const (
+	// userDataNamespace is the namespace of the <pool>-user-data secrets holding the
+	// pointer Ignition configs used by the MachineSets to provision the machines of the pools.
+	userDataNamespace = "openshift-machine-api"
+	// userDataSecretSuffix is the suffix of the names of the user-data secrets.
+	userDataSecretSuffix = "-user-data"
+	// mcsPort is the port the machine-config-server listens on for Ignition requests.
+	mcsPort = "22623"
+	// registriesConfigPath is the containers registries config, embedded in the pointer
+	// Ignition configs so that the first boot pulls images through the mirrors.
+	registriesConfigPath = "/etc/containers/registries.conf"
+)
+
+// syncUserDataSecrets keeps the pointer Ignition configs of the pools trusting
+// bundle, pointing at the current MachineConfigServer endpoint and carrying the
+// registries config of the pools, and reports whether they trust the current CA
+// on the pools. It returns whether they all do.
+func (optr *Operator) syncUserDataSecrets(config *renderConfig, bundle []byte, ca *x509.Certificate) (bool, error) {
+	var mcsHost string
+	if infra := config.ControllerConfig.Infra; infra != nil && infra.Status.APIServerInternalURL != "" {
+		u, err := url.Parse(infra.Status.APIServerInternalURL)
+		if err != nil {
+			return false, fmt.Errorf("parsing the internal API server URL: %v", err)
+		}
+		mcsHost = net.JoinHostPort(u.Hostname(), mcsPort)
+	}
+
+	pools, err := optr.mcpLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	propagated := true
+	for _, pool := range pools {
+		name := pool.Name + userDataSecretSuffix
+		secret, err := optr.userDataSecretLister.Secrets(userDataNamespace).Get(name)
+		if apierrors.IsNotFound(err) {
+			// e.g. custom pools, whose machines are provisioned with the worker pointer Ignition
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		registries, err := optr.getRegistriesConfigSource(pool)
+		if err != nil {
+			return false, err
+		}
+		userData, changed, err := updatePointerIgnition(secret.Data["userData"], bundle, mcsHost, registries)
+		condition := mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolServingCAPropagated, corev1.ConditionTrue, "",
+			fmt.Sprintf("%s/%s trusts the machine-config-server CA %s", userDataNamespace, name, ca.Subject.CommonName))
+		if err != nil {
+			condition = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolServingCAPropagated, corev1.ConditionFalse, "InvalidUserData",
+				fmt.Sprintf("Failed to update %s/%s: %v", userDataNamespace, name, err))
+		} else if changed {
+			newSecret := secret.DeepCopy()
+			newSecret.Data["userData"] = userData
+			if _, err = optr.kubeClient.CoreV1().Secrets(userDataNamespace).Update(context.TODO(), newSecret, metav1.UpdateOptions{}); err != nil {
+				condition = mcfgv1.NewMachineConfigPoolCondition(mcfgv1.MachineConfigPoolServingCAPropagated, corev1.ConditionFalse, "UpdateFailed",
+					fmt.Sprintf("Failed to update %s/%s: %v", userDataNamespace, name, err))
+			} else {
+				glog.Infof("Updated the pointer Ignition config in %s/%s", userDataNamespace, name)
+			}
+		}
+		if condition.Status != corev1.ConditionTrue {
+			propagated = false
+		}
+		if err := optr.setPoolCondition(pool, condition); err != nil {
+			return false, err
+		}
+	}
+	return propagated, nil
+}
+
+// setPoolCondition sets condition on pool, if it changed.
+func (optr *Operator) setPoolCondition(pool *mcfgv1.MachineConfigPool, condition *mcfgv1.MachineConfigPoolCondition) error {
+	current := mcfgv1.GetMachineConfigPoolCondition(pool.Status, condition.Type)
+	if current != nil && current.Status == condition.Status && current.Reason == condition.Reason && current.Message == condition.Message {
+		return nil
+	}
+	newPool := pool.DeepCopy()
+	mcfgv1.SetMachineConfigPoolCondition(&newPool.Status, *condition)
+	_, err := optr.client.MachineconfigurationV1().MachineConfigPools().UpdateStatus(context.TODO(), newPool, metav1.UpdateOptions{})
+	return err
+}
+
+// getRegistriesConfigSource returns the source of the registries config of the
+// rendered config of pool, empty when the pool has none yet.
+func (optr *Operator) getRegistriesConfigSource(pool *mcfgv1.MachineConfigPool) (string, error) {
+	if pool.Spec.Configuration.Name == "" {
+		return "", nil
+	}
+	mc, err := optr.mcLister.Get(pool.Spec.Configuration.Name)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	ignCfg, err := ctrlcommon.ParseAndConvertConfig(mc.Spec.Config.Raw)
+	if err != nil {
+		return "", fmt.Errorf("parsing rendered config %s: %v", mc.Name, err)
+	}
+	for _, f := range ignCfg.Storage.Files {
+		if f.Path == registriesConfigPath {
+			return f.Contents.Source, nil
+		}
+	}
+	return "", nil
+}
+
+// updatePointerIgnition sets the CAs trusted by the pointer Ignition config to
+// bundle, the host of its MachineConfigServer sources to mcsHost and the source of
+// its registries config to registries, unless they're empty, returning whether it
+// changed. The registries config lets disconnected machines pull the images of
+// their first boot, e.g. the machine-config-daemon, from the mirrors before the
+// rendered config is applied. Both Ignition spec 2 and 3 configs are handled, and
+// everything else in the config is kept.
+func updatePointerIgnition(userData, bundle []byte, mcsHost, registries string) ([]byte, bool, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(userData, &config); err != nil {
+		return nil, false, fmt.Errorf("parsing pointer Ignition config: %v", err)
+	}
+	ignition, ok := config["ignition"].(map[string]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("pointer Ignition config has no ignition section")
+	}
+	changed := false
+
+	if mcsHost != "" {
+		ignConfig, _ := ignition["config"].(map[string]interface{})
+		// spec 3 merges the sources, spec 2 appends them
+		for _, key := range []string{"merge", "append"} {
+			sources, _ := ignConfig[key].([]interface{})
+			for _, s := range sources {
+				source, ok := s.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				src, _ := source["source"].(string)
+				u, err := url.Parse(src)
+				if err != nil || !strings.HasPrefix(u.Path, "/config/") || u.Host == mcsHost {
+					continue
+				}
+				u.Host = mcsHost
+				source["source"] = u.String()
+				changed = true
+			}
+		}
+	}
+
+	security, _ := ignition["security"].(map[string]interface{})
+	if security == nil {
+		security = map[string]interface{}{}
+		ignition["security"] = security
+	}
+	tls, _ := security["tls"].(map[string]interface{})
+	if tls == nil {
+		tls = map[string]interface{}{}
+		security["tls"] = tls
+	}
+	caSource := "data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(bundle)
+	cas, _ := tls["certificateAuthorities"].([]interface{})
+	var current map[string]interface{}
+	if len(cas) == 1 {
+		current, _ = cas[0].(map[string]interface{})
+	}
+	if current == nil || current["source"] != caSource {
+		tls["certificateAuthorities"] = []interface{}{map[string]interface{}{"source": caSource}}
+		changed = true
+	}
+
+	if registries != "" && setPointerFile(config, registriesConfigPath, registries) {
+		changed = true
+	}
+
+	if !changed {
+		return userData, false, nil
+	}
+	out, err := json.Marshal(config)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+// setPointerFile sets the source of the file at path of the pointer Ignition
+// config, adding it when missing, and returns whether it changed.
+func setPointerFile(config map[string]interface{}, path, source string) bool {
+	storage, _ := config["storage"].(map[string]interface{})
+	if storage == nil {
+		storage = map[string]interface{}{}
+		config["storage"] = storage
+	}
+	files, _ := storage["files"].([]interface{})
+	for _, f := range files {
+		file, ok := f.(map[string]interface{})
+		if !ok || file["path"] != path {
+			continue
+		}
+		if contents, _ := file["contents"].(map[string]interface{}); contents != nil && contents["source"] == source {
+			return false
+		}
+		file["contents"] = map[string]interface{}{"source": source}
+		return true
+	}
+
+	file := map[string]interface{}{
+		"path":     path,
+		"mode":     0644,
+		"contents": map[string]interface{}{"source": source},
+	}
+	ignition, _ := config["ignition"].(map[string]interface{})
+	if version, _ := ignition["version"].(string); strings.HasPrefix(version, "2.") {
+		file["filesystem"] = "root"
+	} else {
+		// the file is shipped in the OS image, which spec 3 doesn't overwrite by default
+		file["overwrite"] = true
+	}
+	storage["files"] = append(files, file)
+	return true
+}
+
+// isUserDataSecret returns whether obj is one of the user-data secrets.
+func isUserDataSecret(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	return ok && strings.HasSuffix(secret.Name, userDataSecretSuffix)
+}
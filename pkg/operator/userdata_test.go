@@ -0,0 +1,141 @@
+package operator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePointerIgnition(t *testing.T) {
+	bundle := []byte("bundle")
+	caSource := "data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(bundle)
+
+	type source struct {
+		Source string `json:"source"`
+	}
+	type pointer struct {
+		Ignition struct {
+			Config struct {
+				Append []source `json:"append"`
+				Merge  []source `json:"merge"`
+			} `json:"config"`
+			Security struct {
+				TLS struct {
+					CertificateAuthorities []source `json:"certificateAuthorities"`
+				} `json:"tls"`
+			} `json:"security"`
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}
+
+	tests := []struct {
+		name       string
+		userData   string
+		mcsHost    string
+		wantSource string
+	}{{
+		name:       "spec 3",
+		userData:   `{"ignition":{"config":{"merge":[{"source":"https://api-int.old.example.com:22623/config/worker"}]},"security":{"tls":{"certificateAuthorities":[{"source":"data:text/plain;charset=utf-8;base64,b2xk"}]}},"version":"3.1.0"}}`,
+		mcsHost:    "api-int.example.com:22623",
+		wantSource: "https://api-int.example.com:22623/config/worker",
+	}, {
+		name:       "spec 2",
+		userData:   `{"ignition":{"config":{"append":[{"source":"https://api-int.old.example.com:22623/config/master"}]},"version":"2.2.0"}}`,
+		mcsHost:    "api-int.example.com:22623",
+		wantSource: "https://api-int.example.com:22623/config/master",
+	}, {
+		name:       "unknown endpoint",
+		userData:   `{"ignition":{"config":{"merge":[{"source":"https://api-int.old.example.com:22623/config/worker"}]},"version":"3.1.0"}}`,
+		wantSource: "https://api-int.old.example.com:22623/config/worker",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, changed, err := updatePointerIgnition([]byte(tc.userData), bundle, tc.mcsHost, "")
+			require.Nil(t, err)
+			assert.True(t, changed)
+
+			var in, got pointer
+			require.Nil(t, json.Unmarshal([]byte(tc.userData), &in))
+			require.Nil(t, json.Unmarshal(out, &got))
+			assert.Equal(t, in.Ignition.Version, got.Ignition.Version)
+			sources := append(got.Ignition.Config.Merge, got.Ignition.Config.Append...)
+			require.Len(t, sources, 1)
+			assert.Equal(t, tc.wantSource, sources[0].Source)
+			assert.Equal(t, []source{{Source: caSource}}, got.Ignition.Security.TLS.CertificateAuthorities)
+
+			_, changed, err = updatePointerIgnition(out, bundle, tc.mcsHost, "")
+			require.Nil(t, err)
+			assert.False(t, changed)
+		})
+	}
+
+	_, _, err := updatePointerIgnition([]byte(`{}`), bundle, "", "")
+	assert.NotNil(t, err)
+}
+
+func TestUpdatePointerIgnitionRegistries(t *testing.T) {
+	bundle := []byte("bundle")
+	registries := "data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte("[[registry]]\nlocation = \"quay.io\"\n"))
+
+	type file struct {
+		Filesystem string `json:"filesystem"`
+		Path       string `json:"path"`
+		Mode       int    `json:"mode"`
+		Overwrite  *bool  `json:"overwrite"`
+		Contents   struct {
+			Source string `json:"source"`
+		} `json:"contents"`
+	}
+	type pointer struct {
+		Storage struct {
+			Files []file `json:"files"`
+		} `json:"storage"`
+	}
+
+	tests := []struct {
+		name           string
+		userData       string
+		wantFilesystem string
+		wantOverwrite  bool
+		wantFiles      int
+	}{{
+		name:          "spec 3",
+		userData:      `{"ignition":{"config":{"merge":[{"source":"https://api-int.example.com:22623/config/worker"}]},"version":"3.1.0"}}`,
+		wantOverwrite: true,
+		wantFiles:     1,
+	}, {
+		name:           "spec 2",
+		userData:       `{"ignition":{"config":{"append":[{"source":"https://api-int.example.com:22623/config/worker"}]},"version":"2.2.0"}}`,
+		wantFilesystem: "root",
+		wantFiles:      1,
+	}, {
+		name:          "outdated registries config",
+		userData:      `{"ignition":{"version":"3.1.0"},"storage":{"files":[{"path":"/etc/hosts","contents":{"source":"data:,"}},{"path":"/etc/containers/registries.conf","mode":420,"overwrite":true,"contents":{"source":"data:,"}}]}}`,
+		wantOverwrite: true,
+		wantFiles:     2,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, changed, err := updatePointerIgnition([]byte(tc.userData), bundle, "", registries)
+			require.Nil(t, err)
+			assert.True(t, changed)
+
+			var got pointer
+			require.Nil(t, json.Unmarshal(out, &got))
+			require.Len(t, got.Storage.Files, tc.wantFiles)
+			f := got.Storage.Files[tc.wantFiles-1]
+			assert.Equal(t, registriesConfigPath, f.Path)
+			assert.Equal(t, registries, f.Contents.Source)
+			assert.Equal(t, 0644, f.Mode)
+			assert.Equal(t, tc.wantFilesystem, f.Filesystem)
+			assert.Equal(t, tc.wantOverwrite, f.Overwrite != nil && *f.Overwrite)
+
+			_, changed, err = updatePointerIgnition(out, bundle, "", registries)
+			require.Nil(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
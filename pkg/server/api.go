@@ -1,17 +1,122 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"net/http"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
 )
 
 type poolRequest struct {
 	machineConfigPool string
+	// nodeName, when set, asks for the values of the node to be injected in the config.
+	nodeName string
+}
+
+// ignitionMediaType is the media type Ignition advertises the spec versions it accepts with.
+const ignitionMediaType = "application/vnd.coreos.ignition+json"
+
+const (
+	// ignitionV2 is the spec version served to clients which don't advertise spec 3.
+	ignitionV2 = "2.2.0"
+	// ignitionV3 is the spec version served to clients accepting spec 3.
+	ignitionV3 = "3.0.0"
+)
+
+// majorVersion returns the major version of a "X.Y.Z" version, or -1.
+func majorVersion(version string) int {
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return -1
+	}
+	return major
+}
+
+// getIgnitionVersion returns the Ignition spec version to serve to the client
+// of r. Ignition advertises the spec it accepts in the Accept header, e.g.
+// "application/vnd.coreos.ignition+json;version=3.0.0, */*;q=0.1", and Ignition
+// 2.x, the first release supporting spec 3, identifies itself in User-Agent.
+// Spec 2.2 is served otherwise, which every installer we support understands.
+func getIgnitionVersion(r *http.Request) string {
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		params := strings.Split(mediaRange, ";")
+		if strings.TrimSpace(params[0]) != ignitionMediaType {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			// spec 3 clients accept older 3.x minors
+			if len(kv) == 2 && kv[0] == "version" && majorVersion(kv[1]) == 3 {
+				return ignitionV3
+			}
+		}
+	}
+	if ua := strings.Fields(r.Header.Get("User-Agent")); len(ua) > 0 && strings.HasPrefix(ua[0], "Ignition/") {
+		if majorVersion(strings.TrimPrefix(ua[0], "Ignition/")) >= 2 {
+			return ignitionV3
+		}
+	}
+	return ignitionV2
+}
+
+// acceptsGzip returns whether the client of r accepts gzip encoded responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(coding, ";")
+		if strings.TrimSpace(params[0]) != "gzip" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && kv[0] == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipData compresses data. The gzip header doesn't carry a modification time,
+// so that the same config is always compressed to the same bytes and keeps its
+// ETag across requests and servers.
+func gzipData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// entityTag returns the strong ETag of data, served with the given content
+// encoding. The encodings of a config get different tags, as their bytes differ.
+func entityTag(data []byte, encoding string) string {
+	sum := sha256.Sum256(data)
+	tag := hex.EncodeToString(sum[:])
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+	return `"` + tag + `"`
 }
 
 // APIServer provides the HTTP(s) endpoint
@@ -22,15 +127,24 @@ type APIServer struct {
 	insecure bool
 	cert     string
 	key      string
+	clientCA string
 }
 
 // NewAPIServer initializes a new API server
 // that runs the Machine Config Server as a
-// handler.
-func NewAPIServer(a *APIHandler, p int, is bool, c, k string) *APIServer {
+// handler. When ca, the path of a CA bundle, is set,
+// configs are only served to clients presenting a
+// certificate signed by it, which insecure servers
+// can't verify.
+func NewAPIServer(a *APIHandler, p int, is bool, c, k, ca string) *APIServer {
+	var config http.Handler = a
+	if ca != "" {
+		config = &clientCertHandler{handler: a}
+	}
 	mux := http.NewServeMux()
-	mux.Handle("/config/", a)
+	mux.Handle("/config/", &auditHandler{handler: &metricsHandler{handler: config}, log: a.audit})
 	mux.Handle("/healthz", &healthHandler{})
+	mux.Handle("/readyz", &readyHandler{server: a.server})
 	mux.Handle("/", &defaultHandler{})
 
 	return &APIServer{
@@ -39,6 +153,7 @@ func NewAPIServer(a *APIHandler, p int, is bool, c, k string) *APIServer {
 		insecure: is,
 		cert:     c,
 		key:      k,
+		clientCA: ca,
 	}
 }
 
@@ -53,15 +168,29 @@ func (a *APIServer) Serve() {
 		},
 	}
 
+	if a.clientCA != "" && !a.insecure {
+		pem, err := ioutil.ReadFile(a.clientCA)
+		if err != nil {
+			logging.Exitf("Machine Config Server failed to read client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			logging.Exitf("Machine Config Server found no certificates in client CA %s", a.clientCA)
+		}
+		mcs.TLSConfig.ClientCAs = pool
+		// /healthz is still served to clients without certificates, e.g. load balancers
+		mcs.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
 	glog.Infof("Launching server on %s", mcs.Addr)
 	if a.insecure {
 		// Serve a non TLS server.
 		if err := mcs.ListenAndServe(); err != http.ErrServerClosed {
-			glog.Exitf("Machine Config Server exited with error: %v", err)
+			logging.Exitf("Machine Config Server exited with error: %v", err)
 		}
 	} else {
 		if err := mcs.ListenAndServeTLS(a.cert, a.key); err != http.ErrServerClosed {
-			glog.Exitf("Machine Config Server exited with error: %v", err)
+			logging.Exitf("Machine Config Server exited with error: %v", err)
 		}
 	}
 }
@@ -70,16 +199,29 @@ func (a *APIServer) Serve() {
 // Machine Config Server.
 type APIHandler struct {
 	server Server
+	audit  *auditLog
 }
 
 // NewServerAPIHandler initializes a new API handler
-// for the Machine Config Server.
+// for the Machine Config Server. The configs served
+// from the cluster are audited to the audit ConfigMap
+// too, when it exists.
 func NewServerAPIHandler(s Server) *APIHandler {
+	audit := &auditLog{}
+	if cs, ok := s.(*clusterServer); ok {
+		audit.client = cs.configMapClient
+	}
 	return &APIHandler{
 		server: s,
+		audit:  audit,
 	}
 }
 
+// RunAudit persists the audit events until stopCh is closed.
+func (sh *APIHandler) RunAudit(stopCh <-chan struct{}) {
+	sh.audit.run(stopCh)
+}
+
 // ServeHTTP handles the requests for the machine config server
 // API handler.
 func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -97,11 +239,30 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	cr := poolRequest{
 		machineConfigPool: path.Base(r.URL.Path),
+		nodeName:          r.URL.Query().Get("node"),
+	}
+	if cr.nodeName != "" {
+		if err := validateNodeName(cr.nodeName); err != nil {
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusBadRequest)
+			glog.Errorf("rejecting req: %v, error: %v", cr, err)
+			return
+		}
+		glog.Infof("Pool %s requested for node %s by %s", cr.machineConfigPool, cr.nodeName, r.RemoteAddr)
+	} else {
+		glog.Infof("Pool %s requested by %s", cr.machineConfigPool, r.RemoteAddr)
 	}
-
-	glog.Infof("Pool %s requested by %s", cr.machineConfigPool, r.RemoteAddr)
 
 	conf, err := sh.server.GetConfig(cr)
+	if pending, ok := err.(*renderPendingError); ok {
+		// Ignition retries failed fetches, the new machine gets the config
+		// once it's rendered instead of rebooting into it right after joining
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(pending.retryAfter.Seconds()))))
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		glog.Infof("Deferring req: %v: %v", cr, err)
+		return
+	}
 	if err != nil {
 		w.Header().Set("Content-Length", "0")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -114,6 +275,19 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	info := getAuditInfo(r)
+	info.config = getServedConfigName(conf)
+	info.ignitionVersion = getIgnitionVersion(r)
+	if version := info.ignitionVersion; version == ignitionV3 {
+		glog.Infof("Serving Ignition spec %s for pool %s", version, cr.machineConfigPool)
+		if err := convertToIgnitionV3(conf); err != nil {
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusInternalServerError)
+			glog.Errorf("couldn't convert config for req: %v, error: %v", cr, err)
+			return
+		}
+	}
+
 	data, err := json.Marshal(conf)
 	if err != nil {
 		w.Header().Set("Content-Length", "0")
@@ -122,17 +296,43 @@ func (sh *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	// the served config depends on the spec version and encoding the client accepts
+	w.Header().Set("Vary", "Accept, Accept-Encoding, User-Agent")
 	w.Header().Set("Content-Type", "application/json")
-	if r.Method == http.MethodHead {
-		w.WriteHeader(http.StatusOK)
-		return
+	encoding := ""
+	if acceptsGzip(r) {
+		gzipped, err := gzipData(data)
+		if err != nil {
+			w.Header().Set("Content-Length", "0")
+			w.WriteHeader(http.StatusInternalServerError)
+			glog.Errorf("failed to compress %v config: %v", cr, err)
+			return
+		}
+		data, encoding = gzipped, "gzip"
+		w.Header().Set("Content-Encoding", encoding)
 	}
+	w.Header().Set("ETag", entityTag(data, encoding))
 
-	_, err = w.Write(data)
-	if err != nil {
-		glog.Errorf("failed to write %v response: %v", cr, err)
+	// ServeContent answers If-None-Match with 304 Not Modified given the ETag,
+	// serves Range requests, so that clients can resume, and HEAD requests.
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// clientCertHandler only lets through requests authenticated with a
+// client certificate the TLS server verified.
+type clientCertHandler struct {
+	handler http.Handler
+}
+
+// ServeHTTP rejects requests without a verified client certificate.
+func (h *clientCertHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		glog.Infof("Rejecting config request without client certificate from %s", r.RemoteAddr)
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusForbidden)
+		return
 	}
+	h.handler.ServeHTTP(w, r)
 }
 
 type healthHandler struct{}
@@ -149,6 +349,27 @@ func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// readyHandler reports whether the rendered configs of the pools can be served,
+// so that load balancers stop sending requests which would fail.
+type readyHandler struct {
+	server Server
+}
+
+// ServeHTTP handles /readyz requests.
+func (h *readyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Length", "0")
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.server.Ready(); err != nil {
+		glog.Errorf("not ready: %v", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // defaultHandler is the HTTP Handler for backstopping invalid requests.
 type defaultHandler struct{}
 
@@ -1,25 +1,36 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type mockServer struct {
 	GetConfigFn func(poolRequest) (*runtime.RawExtension, error)
+	ReadyErr    error
 }
 
 func (ms *mockServer) GetConfig(pr poolRequest) (*runtime.RawExtension, error) {
 	return ms.GetConfigFn(pr)
 }
 
+func (ms *mockServer) Ready() error {
+	return ms.ReadyErr
+}
+
 type checkResponse func(t *testing.T, response *http.Response)
 
 type scenario struct {
@@ -60,6 +71,21 @@ func TestAPIHandler(t *testing.T) {
 				checkBodyLength(t, response, 114)
 			},
 		},
+		{
+			name:    "get config path with changes not rendered yet",
+			request: httptest.NewRequest(http.MethodGet, "http://testrequest/config/master", nil),
+			serverFunc: func(poolRequest) (*runtime.RawExtension, error) {
+				return nil, &renderPendingError{pool: "master", reason: "test", retryAfter: 1500 * time.Millisecond}
+			},
+			checkResponse: func(t *testing.T, response *http.Response) {
+				checkStatus(t, response, http.StatusServiceUnavailable)
+				if retryAfter := response.Header.Get("Retry-After"); retryAfter != "2" {
+					t.Errorf("expected response Retry-After %q, received %q", "2", retryAfter)
+				}
+				checkContentLength(t, response, 0)
+				checkBodyLength(t, response, 0)
+			},
+		},
 		{
 			name:    "head config path that exists",
 			request: httptest.NewRequest(http.MethodHead, "http://testrequest/config/master", nil),
@@ -107,6 +133,140 @@ func TestAPIHandler(t *testing.T) {
 	}
 }
 
+func TestGetIgnitionVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		accept    string
+		userAgent string
+		version   string
+	}{
+		{"no headers", "", "", ignitionV2},
+		{"curl", "*/*", "curl/7.66.0", ignitionV2},
+		{"spec 2 Ignition", "application/vnd.coreos.ignition+json; version=2.2.0, application/vnd.coreos.ignition+json; version=1; q=0.5, */*; q=0.1", "Ignition/0.35.0", ignitionV2},
+		{"spec 3.0 Ignition", "application/vnd.coreos.ignition+json;version=3.0.0, */*;q=0.1", "Ignition/2.2.1", ignitionV3},
+		{"spec 3.1 Ignition", "application/vnd.coreos.ignition+json;version=3.1.0, */*;q=0.1", "Ignition/2.3.0", ignitionV3},
+		{"spec 3 Accept only", "application/vnd.coreos.ignition+json;version=3.0.0", "", ignitionV3},
+		{"spec 3 Ignition without Accept", "", "Ignition/2.2.1", ignitionV3},
+		{"other media type with version 3", "application/json;version=3.0.0", "", ignitionV2},
+		{"invalid version", "application/vnd.coreos.ignition+json;version=x", "Ignition/y", ignitionV2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "http://testrequest/config/master", nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			if test.userAgent != "" {
+				r.Header.Set("User-Agent", test.userAgent)
+			}
+			if version := getIgnitionVersion(r); version != test.version {
+				t.Errorf("expected Ignition spec %s, got %s", test.version, version)
+			}
+		})
+	}
+}
+
+func TestAPIHandlerIgnitionV3(t *testing.T) {
+	ms := &mockServer{
+		GetConfigFn: func(poolRequest) (*runtime.RawExtension, error) {
+			return &runtime.RawExtension{
+				Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig()),
+			}, nil
+		},
+	}
+	handler := NewServerAPIHandler(ms)
+
+	for accept, version := range map[string]string{
+		"": ignitionV2,
+		"application/vnd.coreos.ignition+json;version=3.0.0": ignitionV3,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "http://testrequest/config/master", nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		resp := w.Result()
+		checkStatus(t, resp, http.StatusOK)
+		var served struct {
+			Ignition struct {
+				Version string `json:"version"`
+			} `json:"ignition"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&served); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if served.Ignition.Version != version {
+			t.Errorf("Accept %q: expected Ignition spec %s, got %s", accept, version, served.Ignition.Version)
+		}
+	}
+}
+
+func TestAPIHandlerCaching(t *testing.T) {
+	ms := &mockServer{
+		GetConfigFn: func(poolRequest) (*runtime.RawExtension, error) {
+			return &runtime.RawExtension{
+				Raw: helpers.MarshalOrDie(new(igntypes.Config)),
+			}, nil
+		},
+	}
+	handler := NewServerAPIHandler(ms)
+	serve := func(headers map[string]string) *http.Response {
+		r := httptest.NewRequest(http.MethodGet, "http://testrequest/config/master", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	resp := serve(nil)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || resp.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("expected an ETag and no encoding, got %v", resp.Header)
+	}
+
+	// the same config gets the same ETag
+	resp = serve(map[string]string{"If-None-Match": etag})
+	checkStatus(t, resp, http.StatusNotModified)
+	checkBodyLength(t, resp, 0)
+
+	resp = serve(map[string]string{"Range": "bytes=10-"})
+	checkStatus(t, resp, http.StatusPartialContent)
+	checkContentLength(t, resp, len(body)-10)
+
+	resp = serve(map[string]string{"Accept-Encoding": "gzip, deflate"})
+	checkStatus(t, resp, http.StatusOK)
+	checkContentType(t, resp, "application/json")
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzip encoded response, got %v", resp.Header)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Errorf("expected the gzip encoded config to get another ETag than %s", etag)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unzipped, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, unzipped) {
+		t.Errorf("expected the gzip encoded config to be %s, got %s", body, unzipped)
+	}
+
+	resp = serve(map[string]string{"Accept-Encoding": "gzip;q=0"})
+	if resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("expected no encoding when gzip is refused, got %v", resp.Header)
+	}
+}
+
 func TestHealthzHandler(t *testing.T) {
 	scenarios := []scenario{
 		{
@@ -165,6 +325,29 @@ func TestHealthzHandler(t *testing.T) {
 	}
 }
 
+func TestReadyHandler(t *testing.T) {
+	tests := []struct {
+		method   string
+		readyErr error
+		status   int
+	}{
+		{http.MethodGet, nil, http.StatusOK},
+		{http.MethodHead, nil, http.StatusOK},
+		{http.MethodGet, fmt.Errorf("could not fetch config rendered-master-1"), http.StatusServiceUnavailable},
+		{http.MethodPost, nil, http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		server := NewAPIServer(NewServerAPIHandler(&mockServer{ReadyErr: test.readyErr}), 0, false, "", "", "")
+		w := httptest.NewRecorder()
+		server.handler.ServeHTTP(w, httptest.NewRequest(test.method, "http://testrequest/readyz", nil))
+
+		resp := w.Result()
+		checkStatus(t, resp, test.status)
+		checkContentLength(t, resp, 0)
+		resp.Body.Close()
+	}
+}
+
 func TestDefaultHandler(t *testing.T) {
 	scenarios := []scenario{
 		{
@@ -374,7 +557,7 @@ func TestAPIServer(t *testing.T) {
 			ms := &mockServer{
 				GetConfigFn: scenario.serverFunc,
 			}
-			server := NewAPIServer(NewServerAPIHandler(ms), 0, false, "", "")
+			server := NewAPIServer(NewServerAPIHandler(ms), 0, false, "", "", "")
 			server.handler.ServeHTTP(w, scenario.request)
 
 			resp := w.Result()
@@ -384,6 +567,40 @@ func TestAPIServer(t *testing.T) {
 	}
 }
 
+func TestAPIServerClientCA(t *testing.T) {
+	ms := &mockServer{
+		GetConfigFn: func(poolRequest) (*runtime.RawExtension, error) {
+			return &runtime.RawExtension{
+				Raw: helpers.MarshalOrDie(new(igntypes.Config)),
+			}, nil
+		},
+	}
+	server := NewAPIServer(NewServerAPIHandler(ms), 0, false, "", "", "/etc/ssl/mcs/client-ca.crt")
+
+	verified := httptest.NewRequest(http.MethodGet, "https://testrequest/config/master", nil)
+	verified.TLS.VerifiedChains = [][]*x509.Certificate{{&x509.Certificate{}}}
+	scenarios := []struct {
+		name    string
+		request *http.Request
+		status  int
+	}{
+		{"config over http", httptest.NewRequest(http.MethodGet, "http://testrequest/config/master", nil), http.StatusForbidden},
+		{"config without client certificate", httptest.NewRequest(http.MethodGet, "https://testrequest/config/master", nil), http.StatusForbidden},
+		{"config with client certificate", verified, http.StatusOK},
+		{"healthz without client certificate", httptest.NewRequest(http.MethodGet, "https://testrequest/healthz", nil), http.StatusOK},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.handler.ServeHTTP(w, scenario.request)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			checkStatus(t, resp, scenario.status)
+		})
+	}
+}
+
 func checkStatus(t *testing.T, response *http.Response, expected int) {
 	if response.StatusCode != expected {
 		t.Errorf("expected response status %d, received %d", expected, response.StatusCode)
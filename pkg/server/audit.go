@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// auditConfigMapName is the ConfigMap the audit events are persisted to,
+	// in the same namespace as the node configs. Persistence is enabled by
+	// creating it.
+	auditConfigMapName = "machine-config-server-audit"
+	// auditConfigMapKey holds the persisted events, one JSON object per line.
+	auditConfigMapKey = "events"
+	// auditConfigMapEvents is how many of the most recent events are persisted.
+	auditConfigMapEvents = 200
+	// auditFlushInterval is how often the pending events are persisted.
+	auditFlushInterval = 10 * time.Second
+)
+
+// Results of the config requests recorded in the audit log.
+const (
+	auditResultServed       = "Served"
+	auditResultNotModified  = "NotModified"
+	auditResultNotFound     = "NotFound"
+	auditResultRejected     = "Rejected"
+	auditResultUnauthorized = "Unauthorized"
+	auditResultError        = "Error"
+)
+
+// auditEvent records a config request handled by the server.
+type auditEvent struct {
+	Time     time.Time `json:"time"`
+	RemoteIP string    `json:"remoteIP"`
+	Pool     string    `json:"pool"`
+	Node     string    `json:"node,omitempty"`
+	// Config is the rendered MachineConfig the served config was generated from.
+	Config          string `json:"config,omitempty"`
+	IgnitionVersion string `json:"ignitionVersion,omitempty"`
+	Code            int    `json:"code"`
+	Result          string `json:"result"`
+}
+
+// auditInfoKey is the context key of the auditInfo of a config request.
+type auditInfoKey struct{}
+
+// auditInfo is what the API handler reports about the config it served.
+type auditInfo struct {
+	config          string
+	ignitionVersion string
+}
+
+// getAuditInfo returns the auditInfo of r to fill in.
+func getAuditInfo(r *http.Request) *auditInfo {
+	if info, ok := r.Context().Value(auditInfoKey{}).(*auditInfo); ok {
+		return info
+	}
+	return &auditInfo{}
+}
+
+// getAuditResult returns the result of a request answered with code.
+func getAuditResult(code int) string {
+	switch {
+	case code == http.StatusNotModified:
+		return auditResultNotModified
+	case code < 300:
+		return auditResultServed
+	case code == http.StatusNotFound:
+		return auditResultNotFound
+	case code == http.StatusForbidden:
+		return auditResultUnauthorized
+	case code < 500:
+		return auditResultRejected
+	default:
+		return auditResultError
+	}
+}
+
+// auditLog logs the audit events and persists the most recent ones to the
+// audit ConfigMap, when the server has a client and the ConfigMap exists.
+type auditLog struct {
+	client corev1client.ConfigMapsGetter
+
+	mu      sync.Mutex
+	pending []auditEvent
+}
+
+// record logs event and queues it to be persisted.
+func (a *auditLog) record(event auditEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		glog.Errorf("failed to marshal audit event: %v", err)
+		return
+	}
+	glog.Infof("audit: %s", data)
+	if a.client == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = append(a.pending, event)
+	if len(a.pending) > auditConfigMapEvents {
+		a.pending = a.pending[len(a.pending)-auditConfigMapEvents:]
+	}
+}
+
+// run persists the pending events every auditFlushInterval until stopCh is closed.
+func (a *auditLog) run(stopCh <-chan struct{}) {
+	if a.client == nil {
+		return
+	}
+	ticker := time.NewTicker(auditFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := a.flush(); err != nil {
+				glog.Warningf("failed to persist the audit events, retrying: %v", err)
+			}
+		}
+	}
+}
+
+// flush appends the pending events to the audit ConfigMap, keeping the most
+// recent ones. They're dropped when the ConfigMap doesn't exist.
+func (a *auditLog) flush() error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	cm, err := a.client.ConfigMaps(nodeConfigNamespace).Get(context.TODO(), auditConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[auditConfigMapKey], err = appendAuditEvents(cm.Data[auditConfigMapKey], pending)
+	}
+	if err == nil {
+		_, err = a.client.ConfigMaps(nodeConfigNamespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		// requeue the events, e.g. after a conflict with another server
+		a.mu.Lock()
+		a.pending = append(pending, a.pending...)
+		a.mu.Unlock()
+		return fmt.Errorf("could not update configmap %s/%s: %v", nodeConfigNamespace, auditConfigMapName, err)
+	}
+	return nil
+}
+
+// appendAuditEvents appends events to the persisted ones, one JSON object per
+// line, keeping the auditConfigMapEvents most recent.
+func appendAuditEvents(persisted string, events []auditEvent) (string, error) {
+	var lines []string
+	if persisted != "" {
+		lines = strings.Split(strings.TrimSuffix(persisted, "\n"), "\n")
+	}
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, string(data))
+	}
+	if len(lines) > auditConfigMapEvents {
+		lines = lines[len(lines)-auditConfigMapEvents:]
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// auditHandler records the config requests handled by handler in the audit log.
+type auditHandler struct {
+	handler http.Handler
+	log     *auditLog
+}
+
+// ServeHTTP serves the request and records who got which config.
+func (h *auditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	info := &auditInfo{}
+	h.handler.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), auditInfoKey{}, info)))
+
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	h.log.record(auditEvent{
+		Time:            time.Now().UTC(),
+		RemoteIP:        remoteIP,
+		Pool:            path.Base(r.URL.Path),
+		Node:            r.URL.Query().Get("node"),
+		Config:          info.config,
+		IgnitionVersion: info.ignitionVersion,
+		Code:            rec.status,
+		Result:          getAuditResult(rec.status),
+	})
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetAuditResult(t *testing.T) {
+	for code, result := range map[int]string{
+		http.StatusOK:                  auditResultServed,
+		http.StatusPartialContent:      auditResultServed,
+		http.StatusNotModified:         auditResultNotModified,
+		http.StatusBadRequest:          auditResultRejected,
+		http.StatusMethodNotAllowed:    auditResultRejected,
+		http.StatusForbidden:           auditResultUnauthorized,
+		http.StatusNotFound:            auditResultNotFound,
+		http.StatusInternalServerError: auditResultError,
+	} {
+		assert.Equal(t, result, getAuditResult(code), "code %d", code)
+	}
+}
+
+func TestGetServedConfigName(t *testing.T) {
+	rawExt := &runtime.RawExtension{Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())}
+	assert.Equal(t, "", getServedConfigName(rawExt))
+
+	require.Nil(t, appendNodeAnnotations(rawExt, "rendered-worker-1"))
+	assert.Equal(t, "rendered-worker-1", getServedConfigName(rawExt))
+}
+
+func TestAuditHandler(t *testing.T) {
+	ms := &mockServer{
+		GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+			if pr.machineConfigPool != "worker" {
+				return nil, nil
+			}
+			rawExt := &runtime.RawExtension{Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())}
+			return rawExt, appendNodeAnnotations(rawExt, "rendered-worker-1")
+		},
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: nodeConfigNamespace, Name: auditConfigMapName}}
+	client := kubefake.NewSimpleClientset(cm).CoreV1()
+	handler := NewServerAPIHandler(ms)
+	handler.audit.client = client
+	server := NewAPIServer(handler, 0, false, "", "", "")
+
+	req := httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker?node=worker-3", nil)
+	req.RemoteAddr = "10.0.0.3:4321"
+	req.Header.Set("Accept", "application/vnd.coreos.ignition+json;version=3.0.0")
+	server.handler.ServeHTTP(httptest.NewRecorder(), req)
+	server.handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://testrequest/config/does-not-exist", nil))
+
+	require.Len(t, handler.audit.pending, 2)
+	served := handler.audit.pending[0]
+	assert.Equal(t, "10.0.0.3", served.RemoteIP)
+	assert.Equal(t, "worker", served.Pool)
+	assert.Equal(t, "worker-3", served.Node)
+	assert.Equal(t, "rendered-worker-1", served.Config)
+	assert.Equal(t, ignitionV3, served.IgnitionVersion)
+	assert.Equal(t, http.StatusOK, served.Code)
+	assert.Equal(t, auditResultServed, served.Result)
+	assert.Equal(t, "does-not-exist", handler.audit.pending[1].Pool)
+	assert.Equal(t, auditResultNotFound, handler.audit.pending[1].Result)
+
+	require.Nil(t, handler.audit.flush())
+	assert.Empty(t, handler.audit.pending)
+	cm, err := client.ConfigMaps(nodeConfigNamespace).Get(context.TODO(), auditConfigMapName, metav1.GetOptions{})
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSuffix(cm.Data[auditConfigMapKey], "\n"), "\n")
+	require.Len(t, lines, 2)
+	var event auditEvent
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "rendered-worker-1", event.Config)
+}
+
+func TestAuditLogFlush(t *testing.T) {
+	// without the configmap the events are dropped
+	a := &auditLog{client: kubefake.NewSimpleClientset().CoreV1()}
+	a.record(auditEvent{Pool: "worker", Code: http.StatusOK, Result: auditResultServed})
+	assert.Nil(t, a.flush())
+	assert.Empty(t, a.pending)
+
+	// only the most recent events are kept
+	var persisted string
+	var err error
+	for i := 0; i < auditConfigMapEvents+10; i++ {
+		persisted, err = appendAuditEvents(persisted, []auditEvent{{Pool: "worker", Code: i}})
+		require.Nil(t, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(persisted, "\n"), "\n")
+	require.Len(t, lines, auditConfigMapEvents)
+	var event auditEvent
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, 10, event.Code)
+}
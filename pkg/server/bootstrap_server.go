@@ -5,7 +5,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sync"
 
+	"github.com/fsnotify/fsnotify"
 	yaml "github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,6 +27,11 @@ type bootstrapServer struct {
 	serverBaseDir string
 
 	kubeconfigFunc kubeconfigFunc
+
+	// rendered caches the configs rendered from serverBaseDir by pool name.
+	// It is only used while watching serverBaseDir, see NewWatchingBootstrapServer.
+	renderedMu sync.Mutex
+	rendered   map[string]*runtime.RawExtension
 }
 
 // NewBootstrapServer initializes a new Bootstrap server that implements
@@ -39,6 +46,73 @@ func NewBootstrapServer(dir, kubeconfig string) (Server, error) {
 	}, nil
 }
 
+// NewWatchingBootstrapServer initializes a Bootstrap server like NewBootstrapServer
+// which also watches the pools and configs under dir, re-rendering the served
+// configs as the files change until stopCh is closed. A file which fails to
+// render stops its pool from being served until it is fixed.
+func NewWatchingBootstrapServer(dir, kubeconfig string, stopCh <-chan struct{}) (Server, error) {
+	s, err := NewBootstrapServer(dir, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	bsc := s.(*bootstrapServer)
+	if err := bsc.startWatching(stopCh); err != nil {
+		return nil, err
+	}
+	return bsc, nil
+}
+
+// startWatching renders the served configs and keeps them up to date with
+// serverBaseDir until stopCh is closed.
+func (bsc *bootstrapServer) startWatching(stopCh <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("could not create watcher: %v", err)
+	}
+	for _, d := range []string{"machine-pools", "machine-configs"} {
+		if err := watcher.Add(path.Join(bsc.serverBaseDir, d)); err != nil {
+			watcher.Close()
+			return fmt.Errorf("could not watch %s: %v", path.Join(bsc.serverBaseDir, d), err)
+		}
+	}
+	bsc.rendered = make(map[string]*runtime.RawExtension)
+	bsc.rerender()
+	go bsc.watch(watcher, stopCh)
+	return nil
+}
+
+// watch re-renders the served configs on every change under serverBaseDir.
+func (bsc *bootstrapServer) watch(watcher *fsnotify.Watcher, stopCh <-chan struct{}) {
+	defer watcher.Close()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case event := <-watcher.Events:
+			glog.Infof("%s changed (%s), re-rendering", event.Name, event.Op)
+			bsc.rerender()
+		case err := <-watcher.Errors:
+			glog.Errorf("error watching %s: %v", bsc.serverBaseDir, err)
+		}
+	}
+}
+
+// rerender renders the config of every pool served during bootstrap and
+// updates the cache.
+func (bsc *bootstrapServer) rerender() {
+	pool := "master"
+	conf, err := bsc.renderConfig(pool)
+	bsc.renderedMu.Lock()
+	defer bsc.renderedMu.Unlock()
+	if err != nil || conf == nil {
+		glog.Errorf("could not render config of pool %s, not serving it: %v", pool, err)
+		delete(bsc.rendered, pool)
+		return
+	}
+	bsc.rendered[pool] = conf
+	glog.Infof("rendered config of pool %s", pool)
+}
+
 // GetConfig fetches the machine config(type - Ignition) from the bootstrap server,
 // based on the pool request.
 // If a config cannot be found or parsed, it returns a nil conf, along with an error.
@@ -59,8 +133,27 @@ func (bsc *bootstrapServer) GetConfig(cr poolRequest) (*runtime.RawExtension, er
 	if cr.machineConfigPool != "master" {
 		return nil, fmt.Errorf("refusing to serve bootstrap configuration to pool %q", cr.machineConfigPool)
 	}
+	if cr.nodeName != "" {
+		glog.Errorf("node configs aren't served during bootstrap, refusing config for node %s", cr.nodeName)
+		return nil, nil
+	}
+	if bsc.rendered != nil {
+		bsc.renderedMu.Lock()
+		defer bsc.renderedMu.Unlock()
+		conf, ok := bsc.rendered[cr.machineConfigPool]
+		if !ok {
+			return nil, fmt.Errorf("config of pool %s failed to render, see the server logs", cr.machineConfigPool)
+		}
+		// the API handler converts the served config in place
+		return conf.DeepCopy(), nil
+	}
+	return bsc.renderConfig(cr.machineConfigPool)
+}
+
+// renderConfig reads the config of pool from serverBaseDir and renders it.
+func (bsc *bootstrapServer) renderConfig(pool string) (*runtime.RawExtension, error) {
 	// 1. Read the Machine Config Pool object.
-	fileName := path.Join(bsc.serverBaseDir, "machine-pools", cr.machineConfigPool+".yaml")
+	fileName := path.Join(bsc.serverBaseDir, "machine-pools", pool+".yaml")
 	glog.Infof("reading file %q", fileName)
 	data, err := ioutil.ReadFile(fileName)
 	if os.IsNotExist(err) {
@@ -112,6 +205,25 @@ func (bsc *bootstrapServer) GetConfig(cr poolRequest) (*runtime.RawExtension, er
 	return rawIgn, nil
 }
 
+// Ready makes sure the rendered config of the master pool, the only one
+// served during bootstrap, is on disk.
+func (bsc *bootstrapServer) Ready() error {
+	fileName := path.Join(bsc.serverBaseDir, "machine-pools", "master.yaml")
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("server: could not read file %s, err: %v", fileName, err)
+	}
+	mp := new(mcfgv1.MachineConfigPool)
+	if err := yaml.Unmarshal(data, mp); err != nil {
+		return fmt.Errorf("server: could not unmarshal file %s, err: %v", fileName, err)
+	}
+	fileName = path.Join(bsc.serverBaseDir, "machine-configs", mp.Status.Configuration.Name+".yaml")
+	if _, err := os.Stat(fileName); err != nil {
+		return fmt.Errorf("server: rendered config of pool master unavailable: %v", err)
+	}
+	return nil
+}
+
 func kubeconfigFromFile(path string) ([]byte, []byte, error) {
 	kcData, err := ioutil.ReadFile(path)
 	if err != nil {
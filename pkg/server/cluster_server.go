@@ -2,18 +2,26 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
+	"time"
 
 	yaml "github.com/ghodss/yaml"
+	"github.com/golang/glog"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	rest "k8s.io/client-go/rest"
 	clientcmd "k8s.io/client-go/tools/clientcmd"
 	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	v1 "github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/typed/machineconfiguration.openshift.io/v1"
 )
 
@@ -36,6 +44,30 @@ type clusterServer struct {
 	machineClient v1.MachineconfigurationV1Interface
 
 	kubeconfigFunc kubeconfigFunc
+
+	// nodeConfigFunc returns the values injected in the configs requested for a node.
+	nodeConfigFunc nodeConfigFunc
+
+	// configMapClient persists the audit events of the served configs.
+	configMapClient corev1client.ConfigMapsGetter
+
+	// renderPendingRetryAfter, when set, defers the requests for pools whose
+	// latest rendered config doesn't hold the changes of their MachineConfigs
+	// yet, telling clients to retry after it.
+	renderPendingRetryAfter time.Duration
+}
+
+// renderPendingError is returned by GetConfig when the latest rendered config
+// of the pool doesn't hold all the changes of its MachineConfigs yet: a new
+// machine served the current config would reboot into the next one right away.
+type renderPendingError struct {
+	pool       string
+	reason     string
+	retryAfter time.Duration
+}
+
+func (e *renderPendingError) Error() string {
+	return fmt.Sprintf("pool %s has changes not rendered yet: %s", e.pool, e.reason)
 }
 
 // NewClusterServer is used to initialize the machine config
@@ -44,16 +76,23 @@ type clusterServer struct {
 // It accepts a kubeConfig, which is not required when it's
 // run from within a cluster(useful in testing).
 // It accepts the apiserverURL which is the location of the KubeAPIServer.
-func NewClusterServer(kubeConfig, apiserverURL string) (Server, error) {
+// When renderPendingRetryAfter is set, the requests for pools with changes not
+// rendered yet are deferred, see renderPendingError.
+func NewClusterServer(kubeConfig, apiserverURL string, renderPendingRetryAfter time.Duration) (Server, error) {
 	restConfig, err := getClientConfig(kubeConfig)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create Kubernetes rest client: %v", err)
 	}
 
 	mc := v1.NewForConfigOrDie(restConfig)
+	kc := kubernetes.NewForConfigOrDie(restConfig)
 	return &clusterServer{
-		machineClient:  mc,
-		kubeconfigFunc: func() ([]byte, []byte, error) { return kubeconfigFromSecret(bootstrapTokenDir, apiserverURL) },
+		machineClient:   mc,
+		kubeconfigFunc:  func() ([]byte, []byte, error) { return kubeconfigFromSecret(bootstrapTokenDir, apiserverURL) },
+		nodeConfigFunc:  nodeConfigFromConfigMap(kc.CoreV1()),
+		configMapClient: kc.CoreV1(),
+
+		renderPendingRetryAfter: renderPendingRetryAfter,
 	}, nil
 }
 
@@ -65,6 +104,16 @@ func (cs *clusterServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error
 		return nil, fmt.Errorf("could not fetch pool. err: %v", err)
 	}
 
+	if cs.renderPendingRetryAfter > 0 {
+		reason, err := cs.getPendingRender(mp)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			return nil, &renderPendingError{pool: mp.Name, reason: reason, retryAfter: cs.renderPendingRetryAfter}
+		}
+	}
+
 	currConf := mp.Status.Configuration.Name
 
 	mc, err := cs.machineClient.MachineConfigs().Get(context.TODO(), currConf, metav1.GetOptions{})
@@ -73,6 +122,17 @@ func (cs *clusterServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error
 	}
 
 	appenders := getAppenders(currConf, cs.kubeconfigFunc, mc.Spec.OSImageURL)
+	if cr.nodeName != "" {
+		nc, err := cs.nodeConfigFunc(cr.nodeName)
+		if err != nil {
+			return nil, err
+		}
+		if nc == nil {
+			glog.Errorf("no config found for node %s", cr.nodeName)
+			return nil, nil
+		}
+		appenders = append(appenders, func(mc *mcfgv1.MachineConfig) error { return appendNodeConfig(&mc.Spec.Config, nc) })
+	}
 	for _, a := range appenders {
 		if err := a(mc); err != nil {
 			return nil, err
@@ -87,6 +147,90 @@ func (cs *clusterServer) GetConfig(cr poolRequest) (*runtime.RawExtension, error
 	return rawIgn, nil
 }
 
+// getPendingRender returns which change of the MachineConfigs of pool its
+// latest rendered config doesn't hold yet, or "" when it holds them all. The
+// rendered configs list the name and generation of their sources; older ones
+// without the list can't be checked and are considered up to date.
+func (cs *clusterServer) getPendingRender(pool *mcfgv1.MachineConfigPool) (string, error) {
+	latest := pool.Spec.Configuration.Name
+	if latest == "" {
+		return "no config rendered yet", nil
+	}
+	rendered, err := cs.machineClient.MachineConfigs().Get(context.TODO(), latest, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("could not fetch config %s, err: %v", latest, err)
+	}
+	value, ok := rendered.Annotations[ctrlcommon.SourceMachineConfigsAnnotationKey]
+	if !ok {
+		return "", nil
+	}
+	var sources []struct {
+		Name       string `json:"name"`
+		Generation int64  `json:"generation"`
+	}
+	if err := json.Unmarshal([]byte(value), &sources); err != nil {
+		return "", fmt.Errorf("could not parse the sources of config %s, err: %v", latest, err)
+	}
+	renderedGenerations := make(map[string]int64, len(sources))
+	for _, source := range sources {
+		renderedGenerations[source.Name] = source.Generation
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pool.Spec.MachineConfigSelector)
+	if err != nil {
+		return "", fmt.Errorf("invalid label selector of pool %s: %v", pool.Name, err)
+	}
+	// the render controller doesn't render pools with an empty selector either
+	if selector.Empty() {
+		return "", nil
+	}
+	configs, err := cs.machineClient.MachineConfigs().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return "", fmt.Errorf("could not list the configs of pool %s, err: %v", pool.Name, err)
+	}
+	sort.Slice(configs.Items, func(i, j int) bool { return configs.Items[i].Name < configs.Items[j].Name })
+	selected := make(map[string]bool, len(configs.Items))
+	for _, mc := range configs.Items {
+		selected[mc.Name] = true
+		renderedGeneration, ok := renderedGenerations[mc.Name]
+		if !ok {
+			return fmt.Sprintf("MachineConfig %s isn't in %s", mc.Name, latest), nil
+		}
+		// the render controller records configs without a generation as generation 1
+		generation := mc.Generation
+		if generation == 0 {
+			generation = 1
+		}
+		if generation > renderedGeneration {
+			return fmt.Sprintf("generation %d of MachineConfig %s is newer than generation %d in %s", generation, mc.Name, renderedGeneration, latest), nil
+		}
+	}
+	for _, source := range sources {
+		if !selected[source.Name] {
+			return fmt.Sprintf("MachineConfig %s was removed but is still in %s", source.Name, latest), nil
+		}
+	}
+	return "", nil
+}
+
+// Ready makes sure the rendered config of every pool can be fetched.
+func (cs *clusterServer) Ready() error {
+	pools, err := cs.machineClient.MachineConfigPools().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("could not list pools. err: %v", err)
+	}
+	for _, pool := range pools.Items {
+		name := pool.Status.Configuration.Name
+		if name == "" {
+			return fmt.Errorf("pool %s has no rendered config yet", pool.Name)
+		}
+		if _, err := cs.machineClient.MachineConfigs().Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("could not fetch config %s of pool %s, err: %v", name, pool.Name, err)
+		}
+	}
+	return nil
+}
+
 // getClientConfig returns a Kubernetes client Config.
 func getClientConfig(path string) (*rest.Config, error) {
 	if path != inClusterConfig {
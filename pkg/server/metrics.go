@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// DefaultMetricsBindAddress is the address of the metrics listener
+	DefaultMetricsBindAddress = "127.0.0.1:8798"
+
+	// MCSRequests counts the config requests per pool and response code
+	MCSRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcs_requests_total",
+			Help: "config requests served by the MCS per pool and response code",
+		}, []string{"pool", "code"})
+
+	// MCSLastSuccessfulServe is when a config was last served to a pool
+	MCSLastSuccessfulServe = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcs_last_successful_serve_timestamp_seconds",
+			Help: "unix time a config was last served successfully per pool",
+		}, []string{"pool"})
+
+	metricsList = []prometheus.Collector{
+		MCSRequests,
+		MCSLastSuccessfulServe,
+	}
+)
+
+func registerMCSMetrics() error {
+	for _, metric := range metricsList {
+		if err := prometheus.Register(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartMetricsListener is metrics listener via http on localhost
+func StartMetricsListener(addr string, stopCh chan struct{}) {
+	if addr == "" {
+		addr = DefaultMetricsBindAddress
+	}
+
+	glog.Info("Registering Prometheus metrics")
+	if err := registerMCSMetrics(); err != nil {
+		glog.Errorf("unable to register metrics: %v", err)
+	}
+
+	glog.Infof("Starting metrics listener on %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	s := http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("metrics listener exited with error: %v", err)
+		}
+	}()
+	<-stopCh
+	if err := s.Shutdown(context.Background()); err != nil {
+		glog.Errorf("error stopping metrics listener: %v", err)
+	}
+}
+
+// statusRecorder records the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// metricsHandler records the config requests handled by handler.
+type metricsHandler struct {
+	handler http.Handler
+}
+
+// ServeHTTP serves the request and records it per pool and response code.
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.handler.ServeHTTP(rec, r)
+
+	// the pool comes from the client, only record the ones which exist to
+	// keep the cardinality of the metrics bounded
+	pool := path.Base(r.URL.Path)
+	if rec.status == http.StatusNotFound || rec.status == http.StatusBadRequest || rec.status == http.StatusMethodNotAllowed {
+		pool = ""
+	}
+	MCSRequests.WithLabelValues(pool, strconv.Itoa(rec.status)).Inc()
+	if rec.status == http.StatusOK {
+		MCSLastSuccessfulServe.WithLabelValues(pool).Set(float64(time.Now().Unix()))
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	MCSRequests.Reset()
+	MCSLastSuccessfulServe.Reset()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(metricsList...)
+
+	ms := &mockServer{
+		GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+			if pr.machineConfigPool != "master" {
+				return nil, nil
+			}
+			return &runtime.RawExtension{Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())}, nil
+		},
+	}
+	server := NewAPIServer(NewServerAPIHandler(ms), 0, false, "", "", "")
+	for _, url := range []string{"/config/master", "/config/master", "/config/does-not-exist"} {
+		server.handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://testrequest"+url, nil))
+	}
+
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := ioutil.ReadAll(w.Result().Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `mcs_requests_total{code="200",pool="master"} 2`)
+	// unknown pools aren't recorded by name
+	assert.Contains(t, string(body), `mcs_requests_total{code="404",pool=""} 1`)
+	assert.NotContains(t, string(body), "does-not-exist")
+	assert.Contains(t, string(body), `mcs_last_successful_serve_timestamp_seconds{pool="master"}`)
+}
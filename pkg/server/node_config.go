@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	yaml "github.com/ghodss/yaml"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// nodeConfigNamespace and nodeConfigName locate the ConfigMap holding the
+	// per-node values, keyed by node name.
+	nodeConfigNamespace = "openshift-machine-config-operator"
+	nodeConfigName      = "machine-config-server-node-config"
+
+	hostnamePath            = "/etc/hostname"
+	networkManagerConnsPath = "/etc/NetworkManager/system-connections"
+)
+
+// nodeConfig are the node-specific values injected in the config served to a node.
+type nodeConfig struct {
+	// Hostname is written to /etc/hostname.
+	Hostname string `json:"hostname,omitempty"`
+	// NetworkManagerConnections are NetworkManager keyfiles, e.g. static IP
+	// configurations, by connection name.
+	NetworkManagerConnections map[string]string `json:"networkManagerConnections,omitempty"`
+}
+
+// nodeConfigFunc returns the values of the node, or nil if it has none.
+type nodeConfigFunc func(node string) (*nodeConfig, error)
+
+// validateNodeName makes sure node can be used as a ConfigMap key.
+func validateNodeName(node string) error {
+	if errs := validation.IsDNS1123Subdomain(node); len(errs) > 0 {
+		return fmt.Errorf("invalid node name %q: %v", node, errs)
+	}
+	return nil
+}
+
+// nodeConfigFromConfigMap returns a nodeConfigFunc reading the values of the
+// nodes from the machine-config-server-node-config ConfigMap.
+func nodeConfigFromConfigMap(client corev1client.ConfigMapsGetter) nodeConfigFunc {
+	return func(node string) (*nodeConfig, error) {
+		cm, err := client.ConfigMaps(nodeConfigNamespace).Get(context.TODO(), nodeConfigName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch configmap %s/%s: %v", nodeConfigNamespace, nodeConfigName, err)
+		}
+		data, ok := cm.Data[node]
+		if !ok {
+			return nil, nil
+		}
+		nc := new(nodeConfig)
+		if err := yaml.Unmarshal([]byte(data), nc); err != nil {
+			return nil, fmt.Errorf("configmap %s/%s: invalid config for node %s: %v", nodeConfigNamespace, nodeConfigName, node, err)
+		}
+		return nc, nil
+	}
+}
+
+// appendNodeConfig appends the files carrying the values of the node.
+func appendNodeConfig(rawExt *runtime.RawExtension, nc *nodeConfig) error {
+	if nc.Hostname != "" {
+		if errs := validation.IsDNS1123Subdomain(nc.Hostname); len(errs) > 0 {
+			return fmt.Errorf("invalid hostname %q: %v", nc.Hostname, errs)
+		}
+		if err := appendFileToRawIgnition(rawExt, hostnamePath, nc.Hostname+"\n"); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(nc.NetworkManagerConnections))
+	for name := range nc.NetworkManagerConnections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || filepath.Base(name) != name {
+			return fmt.Errorf("invalid NetworkManager connection name %q", name)
+		}
+		// NetworkManager ignores keyfiles readable by other users
+		path := filepath.Join(networkManagerConnsPath, name+".nmconnection")
+		if err := appendFileWithModeToRawIgnition(rawExt, path, nc.NetworkManagerConnections[name], 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
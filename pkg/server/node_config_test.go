@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ign "github.com/coreos/ignition/config/v2_2"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	"github.com/openshift/machine-config-operator/test/helpers"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeConfigFromConfigMap(t *testing.T) {
+	// no configmap
+	f := nodeConfigFromConfigMap(kubefake.NewSimpleClientset().CoreV1())
+	nc, err := f("worker-3")
+	assert.Nil(t, err)
+	assert.Nil(t, nc)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: nodeConfigNamespace, Name: nodeConfigName},
+		Data: map[string]string{
+			"worker-3": "hostname: worker-3.example.com\nnetworkManagerConnections:\n  ens3: |\n    [ipv4]\n    method=manual\n",
+			"worker-4": "hostname: [",
+		},
+	}
+	f = nodeConfigFromConfigMap(kubefake.NewSimpleClientset(cm).CoreV1())
+	nc, err = f("worker-3")
+	assert.Nil(t, err)
+	assert.Equal(t, &nodeConfig{
+		Hostname:                  "worker-3.example.com",
+		NetworkManagerConnections: map[string]string{"ens3": "[ipv4]\nmethod=manual\n"},
+	}, nc)
+
+	nc, err = f("worker-5")
+	assert.Nil(t, err)
+	assert.Nil(t, nc)
+
+	_, err = f("worker-4")
+	assert.NotNil(t, err)
+}
+
+func TestAppendNodeConfig(t *testing.T) {
+	rawExt := &runtime.RawExtension{Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())}
+	err := appendNodeConfig(rawExt, &nodeConfig{
+		Hostname:                  "worker-3.example.com",
+		NetworkManagerConnections: map[string]string{"ens4": "b", "ens3": "a"},
+	})
+	assert.Nil(t, err)
+
+	conf, _, err := ign.Parse(rawExt.Raw)
+	assert.Nil(t, err)
+	assert.Len(t, conf.Storage.Files, 3)
+	assert.Equal(t, hostnamePath, conf.Storage.Files[0].Path)
+	contents, err := getDecodedContent(conf.Storage.Files[0].Contents.Source)
+	assert.Nil(t, err)
+	assert.Equal(t, "worker-3.example.com\n", contents)
+	assert.Equal(t, "/etc/NetworkManager/system-connections/ens3.nmconnection", conf.Storage.Files[1].Path)
+	assert.Equal(t, 0600, *conf.Storage.Files[1].Mode)
+	assert.Equal(t, "/etc/NetworkManager/system-connections/ens4.nmconnection", conf.Storage.Files[2].Path)
+
+	assert.NotNil(t, appendNodeConfig(rawExt, &nodeConfig{Hostname: "not a hostname"}))
+	assert.NotNil(t, appendNodeConfig(rawExt, &nodeConfig{NetworkManagerConnections: map[string]string{"../../shadow": ""}}))
+}
+
+func TestAPIHandlerNodeConfig(t *testing.T) {
+	var requested poolRequest
+	ms := &mockServer{
+		GetConfigFn: func(pr poolRequest) (*runtime.RawExtension, error) {
+			requested = pr
+			return &runtime.RawExtension{Raw: helpers.MarshalOrDie(ctrlcommon.NewIgnConfig())}, nil
+		},
+	}
+	handler := NewServerAPIHandler(ms)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker?node=worker-3", nil))
+	checkStatus(t, w.Result(), http.StatusOK)
+	assert.Equal(t, poolRequest{machineConfigPool: "worker", nodeName: "worker-3"}, requested)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://testrequest/config/worker?node=Worker_3", nil))
+	checkStatus(t, w.Result(), http.StatusBadRequest)
+}
@@ -44,10 +44,14 @@ type appenderFunc func(*mcfgv1.MachineConfig) error
 // machine config server implementations.
 type Server interface {
 	GetConfig(poolRequest) (*runtime.RawExtension, error)
+	// Ready returns an error if the rendered configs of the pools can't be served.
+	Ready() error
 }
 
 func getAppenders(currMachineConfig string, f kubeconfigFunc, osimageurl string) []appenderFunc {
 	appenders := []appenderFunc{
+		// the appenders work on spec 2.2, the API handler translates to spec 3 for clients accepting it
+		func(mc *mcfgv1.MachineConfig) error { return convertToIgnitionV2(&mc.Spec.Config) },
 		// append machine annotations file.
 		func(mc *mcfgv1.MachineConfig) error { return appendNodeAnnotations(&mc.Spec.Config, currMachineConfig) },
 		// append pivot
@@ -61,6 +65,38 @@ func getAppenders(currMachineConfig string, f kubeconfigFunc, osimageurl string)
 	return appenders
 }
 
+// convertToIgnitionV2 translates a rendered spec 3 config to spec 2.2 in place.
+func convertToIgnitionV2(rawExt *runtime.RawExtension) error {
+	conf, err := ctrlcommon.ParseAndConvertConfig(rawExt.Raw)
+	if err != nil {
+		return fmt.Errorf("failed to convert config to Ignition spec 2.2: %v", err)
+	}
+	raw, err := json.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	rawExt.Raw = raw
+	return nil
+}
+
+// convertToIgnitionV3 translates a served spec 2.2 config to spec 3.0 in place.
+func convertToIgnitionV3(rawExt *runtime.RawExtension) error {
+	conf, err := ctrlcommon.ParseAndConvertConfig(rawExt.Raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse served config: %v", err)
+	}
+	conf3, err := ctrlcommon.ConvertIgnition2to3(conf)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(conf3)
+	if err != nil {
+		return err
+	}
+	rawExt.Raw = raw
+	return nil
+}
+
 // machineConfigToRawIgnition converts a MachineConfig object into raw Ignition.
 func machineConfigToRawIgnition(mccfg *mcfgv1.MachineConfig) (*runtime.RawExtension, error) {
 	tmpcfg := mccfg.DeepCopy()
@@ -177,11 +213,14 @@ func getNodeAnnotation(conf string) (string, error) {
 }
 
 func appendFileToRawIgnition(rawExt *runtime.RawExtension, outPath, contents string) error {
+	return appendFileWithModeToRawIgnition(rawExt, outPath, contents, 420)
+}
+
+func appendFileWithModeToRawIgnition(rawExt *runtime.RawExtension, outPath, contents string, fileMode int) error {
 	conf, report, err := ign.Parse(rawExt.Raw)
 	if err != nil {
 		return fmt.Errorf("failed to append file. Parsing Ignition config failed with error: %v\nReport: %v", err, report)
 	}
-	fileMode := int(420)
 	file := igntypes.File{
 		Node: igntypes.Node{
 			Filesystem: defaultFileSystem,
@@ -220,3 +259,27 @@ func getEncodedContent(inp string) string {
 		Opaque: "," + dataurl.Escape([]byte(inp)),
 	}).String()
 }
+
+// getServedConfigName returns the name of the rendered MachineConfig a spec 2.2
+// config was generated from, as recorded in its node annotations file, or "".
+func getServedConfigName(rawExt *runtime.RawExtension) string {
+	conf, _, err := ign.Parse(rawExt.Raw)
+	if err != nil {
+		return ""
+	}
+	for _, f := range conf.Storage.Files {
+		if f.Path != daemonconsts.InitialNodeAnnotationsFilePath {
+			continue
+		}
+		contents, err := getDecodedContent(f.Contents.Source)
+		if err != nil {
+			return ""
+		}
+		var annotations map[string]string
+		if err := json.Unmarshal([]byte(contents), &annotations); err != nil {
+			return ""
+		}
+		return annotations[daemonconsts.CurrentMachineConfigAnnotationKey]
+	}
+	return ""
+}
@@ -3,22 +3,30 @@ package server
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	ign "github.com/coreos/ignition/config/v2_2"
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	ign3types "github.com/coreos/ignition/v2/config/v3_0/types"
 	yaml "github.com/ghodss/yaml"
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	daemonconsts "github.com/openshift/machine-config-operator/pkg/daemon/constants"
 	"github.com/openshift/machine-config-operator/pkg/generated/clientset/versioned/fake"
+	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const (
@@ -90,6 +98,26 @@ func TestMachineConfigToRawIgnition(t *testing.T) {
 	assert.Equal(t, ignCfg.Storage.Files[1].Path, daemonconsts.MachineConfigEncapsulatedPath)
 }
 
+func TestConvertToIgnitionV2(t *testing.T) {
+	source := "data:,foo"
+	ign3Config := ign3types.Config{Ignition: ign3types.Ignition{Version: "3.0.0"}}
+	ign3Config.Storage.Files = []ign3types.File{{
+		Node:          ign3types.Node{Path: "/etc/foo"},
+		FileEmbedded1: ign3types.FileEmbedded1{Contents: ign3types.FileContents{Source: &source}},
+	}}
+	raw, err := json.Marshal(ign3Config)
+	assert.Nil(t, err)
+
+	rawExt := &runtime.RawExtension{Raw: raw}
+	assert.Nil(t, convertToIgnitionV2(rawExt))
+	ignCfg, _, err := ign.Parse(rawExt.Raw)
+	assert.Nil(t, err)
+	assert.Equal(t, igntypes.MaxVersion.String(), ignCfg.Ignition.Version)
+	assert.Equal(t, 1, len(ignCfg.Storage.Files))
+	assert.Equal(t, "root", ignCfg.Storage.Files[0].Filesystem)
+	assert.Equal(t, "/etc/foo", ignCfg.Storage.Files[0].Path)
+}
+
 // TestBootstrapServer tests the behavior of the machine config server
 // when it's running in bootstrap mode.
 // The test does the following:
@@ -166,6 +194,15 @@ func TestBootstrapServer(t *testing.T) {
 	validateIgnitionFiles(t, ignCfg.Storage.Files, resCfg.Storage.Files)
 	validateIgnitionSystemd(t, ignCfg.Systemd.Units, resCfg.Systemd.Units)
 
+	if err := bs.Ready(); err != nil {
+		t.Fatalf("expected bootstrap server to be ready, received: %v", err)
+	}
+	bs.serverBaseDir = filepath.Join(testDir, "does-not-exist")
+	if err := bs.Ready(); err == nil {
+		t.Fatalf("expected bootstrap server without rendered configs to not be ready")
+	}
+	bs.serverBaseDir = testDir
+
 	// verify bootstrap cannot serve ignition to other pool than master
 	res, err = bs.GetConfig(poolRequest{
 		machineConfigPool: testPool,
@@ -175,6 +212,69 @@ func TestBootstrapServer(t *testing.T) {
 	}
 }
 
+// TestWatchingBootstrapServer tests that the watching bootstrap server
+// stops serving a pool once its file breaks and serves it again once fixed.
+func TestWatchingBootstrapServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mcs-bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, f := range []string{"machine-pools/master.yaml", "machine-configs/test-config.yaml"} {
+		data, err := ioutil.ReadFile(filepath.Join(testDir, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, f), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	bs := &bootstrapServer{
+		serverBaseDir:  dir,
+		kubeconfigFunc: func() ([]byte, []byte, error) { return getKubeConfigContent(t) },
+	}
+	if err := bs.startWatching(stopCh); err != nil {
+		t.Fatal(err)
+	}
+	pr := poolRequest{machineConfigPool: "master"}
+	if _, err := bs.GetConfig(pr); err != nil {
+		t.Fatalf("expected err to be nil, received: %v", err)
+	}
+
+	poolFile := filepath.Join(dir, "machine-pools", "master.yaml")
+	pool, err := ioutil.ReadFile(poolFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(poolFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := bs.GetConfig(pr)
+		return err != nil, nil
+	})
+	if err != nil {
+		t.Fatalf("expected the broken pool to not be served")
+	}
+
+	if err := ioutil.WriteFile(poolFile, pool, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := bs.GetConfig(pr)
+		return err == nil, nil
+	})
+	if err != nil {
+		t.Fatalf("expected the fixed pool to be served again")
+	}
+}
+
 // TestClusterServer tests the behavior of the machine config server
 // when it's running within the cluster.
 // The test does the following:
@@ -211,15 +311,22 @@ func TestClusterServer(t *testing.T) {
 	if err != nil {
 		t.Logf("err: %v", err)
 	}
-	_, err = cs.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), origMC, metav1.CreateOptions{})
-	if err != nil {
-		t.Logf("err: %v", err)
-	}
 
 	csc := &clusterServer{
 		machineClient:  cs.MachineconfigurationV1(),
 		kubeconfigFunc: func() ([]byte, []byte, error) { return getKubeConfigContent(t) },
 	}
+	if err := csc.Ready(); err == nil {
+		t.Fatalf("expected cluster server to not be ready before the rendered config exists")
+	}
+
+	_, err = cs.MachineconfigurationV1().MachineConfigs().Create(context.TODO(), origMC, metav1.CreateOptions{})
+	if err != nil {
+		t.Logf("err: %v", err)
+	}
+	if err := csc.Ready(); err != nil {
+		t.Fatalf("expected cluster server to be ready, received: %v", err)
+	}
 
 	mc := new(mcfgv1.MachineConfig)
 	err = yaml.Unmarshal([]byte(mcData), mc)
@@ -336,6 +443,44 @@ func createFileMap(files []igntypes.File) map[string]igntypes.File {
 	return m
 }
 
+func TestClusterServerRenderPending(t *testing.T) {
+	mcSelector := metav1.AddLabelToSelector(&metav1.LabelSelector{}, mcfgv1.MachineConfigRoleLabelKey, "worker")
+	mp := helpers.NewMachineConfigPool("worker", mcSelector, helpers.WorkerSelector, "rendered-worker-1")
+	rendered := helpers.NewMachineConfig("rendered-worker-1", nil, "", nil)
+	rendered.Annotations = map[string]string{ctrlcommon.SourceMachineConfigsAnnotationKey: `[{"name":"00-worker","generation":1}]`}
+	source := helpers.NewMachineConfig("00-worker", map[string]string{mcfgv1.MachineConfigRoleLabelKey: "worker"}, "", nil)
+	source.Generation = 2
+
+	cs := fake.NewSimpleClientset(mp, rendered, source)
+	csc := &clusterServer{
+		machineClient:           cs.MachineconfigurationV1(),
+		kubeconfigFunc:          func() ([]byte, []byte, error) { return getKubeConfigContent(t) },
+		renderPendingRetryAfter: time.Minute,
+	}
+
+	// 00-worker changed since rendered-worker-1
+	_, err := csc.GetConfig(poolRequest{machineConfigPool: "worker"})
+	pending, ok := err.(*renderPendingError)
+	if assert.True(t, ok, "expected a renderPendingError, received: %v", err) {
+		assert.Equal(t, time.Minute, pending.retryAfter)
+	}
+
+	rendered.Annotations[ctrlcommon.SourceMachineConfigsAnnotationKey] = `[{"name":"00-worker","generation":2}]`
+	_, err = cs.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), rendered, metav1.UpdateOptions{})
+	assert.Nil(t, err)
+	reason, err := csc.getPendingRender(mp)
+	assert.Nil(t, err)
+	assert.Empty(t, reason)
+
+	// 01-worker was removed from the pool but is still rendered
+	rendered.Annotations[ctrlcommon.SourceMachineConfigsAnnotationKey] = `[{"name":"00-worker","generation":2},{"name":"01-worker","generation":1}]`
+	_, err = cs.MachineconfigurationV1().MachineConfigs().Update(context.TODO(), rendered, metav1.UpdateOptions{})
+	assert.Nil(t, err)
+	reason, err = csc.getPendingRender(mp)
+	assert.Nil(t, err)
+	assert.Contains(t, reason, "01-worker")
+}
+
 func getTestMachineConfigPool() (*mcfgv1.MachineConfigPool, error) {
 	mpPath := path.Join(testDir, "machine-pools", testPool+".yaml")
 	mpData, err := ioutil.ReadFile(mpPath)
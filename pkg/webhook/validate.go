@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"fmt"
+
+	ign2types "github.com/coreos/ignition/config/v2_2/types"
+	ign3types "github.com/coreos/ignition/v2/config/v3_0/types"
+	"github.com/pkg/errors"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
+	containerruntimeconfig "github.com/openshift/machine-config-operator/pkg/controller/container-runtime-config"
+	kubeletconfig "github.com/openshift/machine-config-operator/pkg/controller/kubelet-config"
+)
+
+// validateMachineConfig runs the same checks the render controller does and
+// additionally rejects configs that Ignition would accept but that can't be
+// applied sanely on a node, e.g. the same file written twice.
+func validateMachineConfig(mc *mcfgv1.MachineConfig) error {
+	if err := ctrlcommon.ValidateMachineConfig(mc.Spec); err != nil {
+		return err
+	}
+	if mc.Spec.Butane != "" {
+		butaneCfg, err := ctrlcommon.TranslateButane([]byte(mc.Spec.Butane))
+		if err != nil {
+			return err
+		}
+		if err := validateIgnitionV2Contents(butaneCfg); err != nil {
+			return errors.Wrapf(err, "invalid butane config")
+		}
+	}
+	if mc.Spec.Config.Raw == nil {
+		return nil
+	}
+	ignCfg, err := ctrlcommon.IgnParseWrapper(mc.Spec.Config.Raw)
+	if err != nil {
+		return err
+	}
+	switch cfg := ignCfg.(type) {
+	case ign2types.Config:
+		return validateIgnitionV2Contents(cfg)
+	case ign3types.Config:
+		return validateIgnitionV3Contents(cfg)
+	default:
+		return errors.Errorf("unrecognized ignition type")
+	}
+}
+
+func validateMode(path string, mode *int) error {
+	if mode != nil && (*mode < 0 || *mode > 07777) {
+		return errors.Errorf("file %q has invalid mode %#o", path, *mode)
+	}
+	return nil
+}
+
+func validateIgnitionV2Contents(cfg ign2types.Config) error {
+	files := make(map[string]bool)
+	for _, f := range cfg.Storage.Files {
+		if files[f.Path] {
+			return errors.Errorf("file %q is defined more than once", f.Path)
+		}
+		files[f.Path] = true
+		if err := validateMode(f.Path, f.Mode); err != nil {
+			return err
+		}
+	}
+	units := make(map[string]bool)
+	for _, u := range cfg.Systemd.Units {
+		if units[u.Name] {
+			return errors.Errorf("unit %q is defined more than once", u.Name)
+		}
+		units[u.Name] = true
+		dropins := make(map[string]bool)
+		for _, d := range u.Dropins {
+			if dropins[d.Name] {
+				return errors.Errorf("dropin %q of unit %q is defined more than once", d.Name, u.Name)
+			}
+			dropins[d.Name] = true
+		}
+		if u.Mask && u.Enabled != nil && *u.Enabled {
+			return errors.Errorf("unit %q cannot be both masked and enabled", u.Name)
+		}
+	}
+	return nil
+}
+
+func validateIgnitionV3Contents(cfg ign3types.Config) error {
+	files := make(map[string]bool)
+	for _, f := range cfg.Storage.Files {
+		if files[f.Path] {
+			return errors.Errorf("file %q is defined more than once", f.Path)
+		}
+		files[f.Path] = true
+		if err := validateMode(f.Path, f.Mode); err != nil {
+			return err
+		}
+	}
+	units := make(map[string]bool)
+	for _, u := range cfg.Systemd.Units {
+		if units[u.Name] {
+			return errors.Errorf("unit %q is defined more than once", u.Name)
+		}
+		units[u.Name] = true
+		dropins := make(map[string]bool)
+		for _, d := range u.Dropins {
+			if dropins[d.Name] {
+				return errors.Errorf("dropin %q of unit %q is defined more than once", d.Name, u.Name)
+			}
+			dropins[d.Name] = true
+		}
+		if u.Mask != nil && *u.Mask && u.Enabled != nil && *u.Enabled {
+			return errors.Errorf("unit %q cannot be both masked and enabled", u.Name)
+		}
+	}
+	return nil
+}
+
+func validateKubeletConfig(kc *mcfgv1.KubeletConfig) error {
+	if err := kubeletconfig.ValidateUserKubeletConfig(kc); err != nil {
+		return fmt.Errorf("KubeletConfig %s is invalid: %v", kc.Name, err)
+	}
+	return nil
+}
+
+func validateContainerRuntimeConfig(ctrcfg *mcfgv1.ContainerRuntimeConfig) error {
+	if err := containerruntimeconfig.ValidateUserContainerRuntimeConfig(ctrcfg); err != nil {
+		return fmt.Errorf("ContainerRuntimeConfig %s is invalid: %v", ctrcfg.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/openshift/machine-config-operator/internal/logging"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+)
+
+const (
+	// ValidatePath is the path the ValidatingWebhookConfiguration points to.
+	ValidatePath = "/validate"
+
+	// maxRequestSize bounds the body we're willing to read, the apiserver
+	// already limits objects to a few MB.
+	maxRequestSize = 8 * 1024 * 1024
+)
+
+// Server serves the MachineConfig validating admission webhook.
+type Server struct {
+	handler http.Handler
+	port    int
+	cert    string
+	key     string
+}
+
+// NewServer initializes a new webhook server listening on port p
+// with the given TLS cert and key.
+func NewServer(p int, c, k string) *Server {
+	mux := http.NewServeMux()
+	mux.Handle(ValidatePath, &validatingHandler{})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return &Server{
+		handler: mux,
+		port:    p,
+		cert:    c,
+		key:     k,
+	}
+}
+
+// Serve launches the webhook server.
+func (s *Server) Serve() {
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%v", s.port),
+		Handler: s.handler,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+
+	glog.Infof("Launching webhook server on %s", srv.Addr)
+	if err := srv.ListenAndServeTLS(s.cert, s.key); err != http.ErrServerClosed {
+		logging.Exitf("Machine Config Webhook exited with error: %v", err)
+	}
+}
+
+type validatingHandler struct{}
+
+// ServeHTTP decodes an AdmissionReview, validates the object it carries
+// and writes back an AdmissionReview with the verdict.
+func (h *validatingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "failed to decode AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = admit(review.Request)
+	review.Response.UID = review.Request.UID
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		glog.Errorf("failed to marshal AdmissionReview response: %v", err)
+		http.Error(w, "failed to encode AdmissionReview", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		glog.Errorf("failed to write AdmissionReview response: %v", err)
+	}
+}
+
+// admit validates the object in req. Unknown kinds and deletes are allowed
+// since there is nothing to check.
+func admit(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	var err error
+	switch req.Kind.Kind {
+	case "MachineConfig":
+		mc := &mcfgv1.MachineConfig{}
+		if err = json.Unmarshal(req.Object.Raw, mc); err == nil {
+			err = validateMachineConfig(mc)
+		}
+	case "KubeletConfig":
+		kc := &mcfgv1.KubeletConfig{}
+		if err = json.Unmarshal(req.Object.Raw, kc); err == nil {
+			err = validateKubeletConfig(kc)
+		}
+	case "ContainerRuntimeConfig":
+		ctrcfg := &mcfgv1.ContainerRuntimeConfig{}
+		if err = json.Unmarshal(req.Object.Raw, ctrcfg); err == nil {
+			err = validateContainerRuntimeConfig(ctrcfg)
+		}
+	default:
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	if err != nil {
+		glog.Infof("Rejecting %s %s: %v", req.Kind.Kind, req.Name, err)
+		return &admissionv1beta1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInvalid,
+				Message: err.Error(),
+				Code:    http.StatusUnprocessableEntity,
+			},
+		}
+	}
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
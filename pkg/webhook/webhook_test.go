@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	igntypes "github.com/coreos/ignition/config/v2_2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	"github.com/openshift/machine-config-operator/test/helpers"
+)
+
+func newReview(t *testing.T, kind string, obj interface{}) *admissionv1beta1.AdmissionReview {
+	raw, err := json.Marshal(obj)
+	require.Nil(t, err)
+	return &admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "test-uid",
+			Kind:      metav1.GroupVersionKind{Group: mcfgv1.GroupName, Version: "v1", Kind: kind},
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func newMachineConfigWithIgn(ign *igntypes.Config) *mcfgv1.MachineConfig {
+	mc := helpers.NewMachineConfig("99-test", nil, "", nil)
+	mc.Spec.Config.Raw = helpers.MarshalOrDie(ign)
+	return mc
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestValidatingHandler(t *testing.T) {
+	validFile := igntypes.File{Node: igntypes.Node{Filesystem: "root", Path: "/etc/foo"}, FileEmbedded1: igntypes.FileEmbedded1{Mode: intPtr(0644)}}
+	logLevel := int32(20)
+
+	tests := []struct {
+		name    string
+		review  *admissionv1beta1.AdmissionReview
+		allowed bool
+	}{
+		{
+			name:    "valid MachineConfig",
+			review:  newReview(t, "MachineConfig", helpers.NewMachineConfig("99-test", nil, "", []igntypes.File{validFile})),
+			allowed: true,
+		},
+		{
+			name: "unparseable Ignition",
+			review: newReview(t, "MachineConfig", &mcfgv1.MachineConfig{
+				Spec: mcfgv1.MachineConfigSpec{Config: runtime.RawExtension{Raw: []byte(`{"ignition": {"version": "9.9.9"}}`)}},
+			}),
+		},
+		{
+			name: "invalid file mode",
+			review: newReview(t, "MachineConfig", newMachineConfigWithIgn(&igntypes.Config{
+				Ignition: igntypes.Ignition{Version: igntypes.MaxVersion.String()},
+				Storage: igntypes.Storage{Files: []igntypes.File{
+					{Node: igntypes.Node{Filesystem: "root", Path: "/etc/foo"}, FileEmbedded1: igntypes.FileEmbedded1{Mode: intPtr(010000)}},
+				}},
+			})),
+		},
+		{
+			name: "duplicate file",
+			review: newReview(t, "MachineConfig", newMachineConfigWithIgn(&igntypes.Config{
+				Ignition: igntypes.Ignition{Version: igntypes.MaxVersion.String()},
+				Storage:  igntypes.Storage{Files: []igntypes.File{validFile, validFile}},
+			})),
+		},
+		{
+			name: "duplicate unit",
+			review: newReview(t, "MachineConfig", newMachineConfigWithIgn(&igntypes.Config{
+				Ignition: igntypes.Ignition{Version: igntypes.MaxVersion.String()},
+				Systemd:  igntypes.Systemd{Units: []igntypes.Unit{{Name: "foo.service"}, {Name: "foo.service"}}},
+			})),
+		},
+		{
+			name: "masked and enabled unit",
+			review: newReview(t, "MachineConfig", newMachineConfigWithIgn(&igntypes.Config{
+				Ignition: igntypes.Ignition{Version: igntypes.MaxVersion.String()},
+				Systemd:  igntypes.Systemd{Units: []igntypes.Unit{{Name: "foo.service", Mask: true, Enabled: boolPtr(true)}}},
+			})),
+		},
+		{
+			name: "valid Butane",
+			review: newReview(t, "MachineConfig", &mcfgv1.MachineConfig{
+				Spec: mcfgv1.MachineConfigSpec{Butane: "storage:\n  files:\n  - path: /etc/foo\n    mode: 0644\n"},
+			}),
+			allowed: true,
+		},
+		{
+			name: "unparseable Butane",
+			review: newReview(t, "MachineConfig", &mcfgv1.MachineConfig{
+				Spec: mcfgv1.MachineConfigSpec{Butane: "storage: ["},
+			}),
+		},
+		{
+			name: "duplicate file in Butane",
+			review: newReview(t, "MachineConfig", &mcfgv1.MachineConfig{
+				Spec: mcfgv1.MachineConfigSpec{Butane: "storage:\n  files:\n  - path: /etc/foo\n  - path: /etc/foo\n"},
+			}),
+		},
+		{
+			name: "unsupported extension",
+			review: newReview(t, "MachineConfig", &mcfgv1.MachineConfig{
+				Spec: mcfgv1.MachineConfigSpec{Extensions: []string{"bogus"}},
+			}),
+		},
+		{
+			name:    "valid KubeletConfig",
+			review:  newReview(t, "KubeletConfig", &mcfgv1.KubeletConfig{}),
+			allowed: true,
+		},
+		{
+			name: "invalid KubeletConfig",
+			review: newReview(t, "KubeletConfig", &mcfgv1.KubeletConfig{
+				Spec: mcfgv1.KubeletConfigSpec{LogLevel: &logLevel},
+			}),
+		},
+		{
+			name: "invalid ContainerRuntimeConfig",
+			review: newReview(t, "ContainerRuntimeConfig", &mcfgv1.ContainerRuntimeConfig{
+				Spec: mcfgv1.ContainerRuntimeConfigSpec{ContainerRuntimeConfig: &mcfgv1.ContainerRuntimeConfiguration{LogLevel: "loud"}},
+			}),
+		},
+		{
+			name:    "unknown kind",
+			review:  newReview(t, "MachineConfigPool", &mcfgv1.MachineConfigPool{}),
+			allowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := json.Marshal(test.review)
+			require.Nil(t, err)
+
+			w := httptest.NewRecorder()
+			h := &validatingHandler{}
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://testrequest"+ValidatePath, bytes.NewReader(body)))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			got := admissionv1beta1.AdmissionReview{}
+			require.Nil(t, json.NewDecoder(resp.Body).Decode(&got))
+			require.NotNil(t, got.Response)
+			assert.Equal(t, test.review.Request.UID, got.Response.UID)
+			assert.Equal(t, test.allowed, got.Response.Allowed)
+			if !test.allowed {
+				require.NotNil(t, got.Response.Result)
+				assert.NotEmpty(t, got.Response.Result.Message)
+			}
+		})
+	}
+}
+
+func TestValidatingHandlerBadRequests(t *testing.T) {
+	w := httptest.NewRecorder()
+	h := &validatingHandler{}
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://testrequest"+ValidatePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://testrequest"+ValidatePath, bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	mcfgv1 "github.com/openshift/machine-config-operator/pkg/apis/machineconfiguration.openshift.io/v1"
+	ctrlcommon "github.com/openshift/machine-config-operator/pkg/controller/common"
 	"github.com/openshift/machine-config-operator/test/e2e/framework"
 	"github.com/openshift/machine-config-operator/test/helpers"
 	"github.com/pkg/errors"
@@ -123,23 +124,7 @@ func getNodesByRole(cs *framework.ClientSet, role string) ([]corev1.Node, error)
 // it will also use mcpName as the label selector, so any node you want to be included
 // in the pool should have a label node-role.kubernetes.io/mcpName = ""
 func createMCP(t *testing.T, cs *framework.ClientSet, mcpName string) func() {
-	infraMCP := &mcfgv1.MachineConfigPool{}
-	infraMCP.Name = mcpName
-	nodeSelector := metav1.LabelSelector{}
-	infraMCP.Spec.NodeSelector = &nodeSelector
-	infraMCP.Spec.NodeSelector.MatchLabels = make(map[string]string)
-	infraMCP.Spec.NodeSelector.MatchLabels[mcpNameToRole(mcpName)] = ""
-	mcSelector := metav1.LabelSelector{}
-	infraMCP.Spec.MachineConfigSelector = &mcSelector
-	infraMCP.Spec.MachineConfigSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
-		{
-			Key:      mcfgv1.MachineConfigRoleLabelKey,
-			Operator: metav1.LabelSelectorOpIn,
-			Values:   []string{"worker", mcpName},
-		},
-	}
-	infraMCP.ObjectMeta.Labels = make(map[string]string)
-	infraMCP.ObjectMeta.Labels[mcpName] = ""
+	infraMCP := ctrlcommon.NewCustomMachineConfigPool(mcpName)
 	_, err := cs.MachineConfigPools().Create(context.TODO(), infraMCP, metav1.CreateOptions{})
 	require.Nil(t, err)
 	return func() {
@@ -2,6 +2,8 @@ package helpers
 
 import (
 	"encoding/json"
+	"reflect"
+	"testing"
 
 	igntypes "github.com/coreos/ignition/config/v2_2/types"
 	corev1 "k8s.io/api/core/v1"
@@ -116,3 +118,23 @@ func MarshalOrDie(input interface{}) []byte {
 	}
 	return bytes
 }
+
+// InformerFactory is a shared informer factory, e.g. of a fake clientset.
+type InformerFactory interface {
+	Start(stopCh <-chan struct{})
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+}
+
+// StartInformers starts the informers requested from factory, e.g. by the New
+// of a controller, and waits for their listers to hold the objects of the
+// clientset. They're stopped at the end of the test.
+func StartInformers(t *testing.T, factory InformerFactory) {
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	factory.Start(stopCh)
+	for informer, synced := range factory.WaitForCacheSync(stopCh) {
+		if !synced {
+			t.Fatalf("informer %v didn't sync", informer)
+		}
+	}
+}